@@ -0,0 +1,79 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/logger"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Write a consistent snapshot of the database",
+	Long: `Write a consistent snapshot of the configured database to a file.
+
+SQLite databases are copied with VACUUM INTO after checkpointing the WAL, so the
+backup is a plain SQLite file. PostgreSQL is dumped with pg_dump in custom format
+(restore with pg_restore) and MySQL with mysqldump; both tools must be installed.
+
+The backup is written to <dbname>-<timestamp>.bak unless --output is given.`,
+	SilenceUsage: true,
+	RunE:         runBackup,
+}
+
+func init() {
+	backupCmd.Flags().StringP("output", "o", "", "backup file path (default <dbname>-<timestamp>.bak)")
+}
+
+func runBackup(cmd *cobra.Command, args []string) error {
+	logger.Init(config.LoggingConfig{Level: "info"}, config.ServerConfig{}, false)
+
+	configPath, err := config.EnsureConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to ensure config exists: %w", err)
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		output = defaultBackupPath(cfg.Database, time.Now())
+	}
+
+	db := database.New(cfg.Database)
+	defer db.Close()
+
+	if err := db.Backup(cmd.Context(), output); err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+
+	log.Info().Str("type", string(cfg.Database.Type)).Str("file", output).Msg("Database backup written")
+	return nil
+}
+
+// defaultBackupPath names a backup after the database, e.g. netronome-20260102-150405.bak.
+// SQLite backups are placed next to the database file.
+func defaultBackupPath(cfg config.DatabaseConfig, now time.Time) string {
+	timestamp := now.Format("20060102-150405")
+
+	if cfg.Type == config.SQLite {
+		base := filepath.Base(cfg.Path)
+		name := strings.TrimSuffix(base, filepath.Ext(base))
+		return filepath.Join(filepath.Dir(cfg.Path), fmt.Sprintf("%s-%s.bak", name, timestamp))
+	}
+
+	return fmt.Sprintf("%s-%s.bak", cfg.DBName, timestamp)
+}
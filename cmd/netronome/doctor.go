@@ -0,0 +1,237 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"time"
+
+	probing "github.com/prometheus-community/pro-bing"
+	"github.com/spf13/cobra"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/logger"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the environment for the tools and permissions Netronome needs",
+	Long: `Check that the configuration loads, the database is reachable, ICMP pings are
+permitted and the external tools used for diagnostics (mtr, traceroute, iperf3) are
+installed. Each check is reported as ok, warn or fail with a hint on how to fix it.
+
+Exits non-zero when a required check fails; missing optional tools only warn.`,
+	SilenceUsage: true,
+	RunE:         runDoctor,
+}
+
+type doctorStatus string
+
+const (
+	doctorPass doctorStatus = "ok"
+	doctorWarn doctorStatus = "warn"
+	doctorFail doctorStatus = "fail"
+)
+
+// doctorICMPTimeout bounds each loopback ping
+const doctorICMPTimeout = 2 * time.Second
+
+// doctorCheck is one line of the doctor checklist
+type doctorCheck struct {
+	name   string
+	status doctorStatus
+	detail string
+	hint   string
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	logger.Init(config.LoggingConfig{Level: "error"}, config.ServerConfig{}, false)
+
+	out := cmd.OutOrStdout()
+	failed := 0
+	report := func(check doctorCheck) {
+		printDoctorCheck(out, check)
+		if check.status == doctorFail {
+			failed++
+		}
+	}
+
+	cfg, configCheck := checkDoctorConfig()
+	report(configCheck)
+
+	report(checkDoctorTool("mtr", "packet loss monitors in MTR mode",
+		installHint("mtr", "install WinMTRCmd from https://github.com/dqos/WinMTRCmd/releases and add it to PATH")))
+	tracerouteTool := "traceroute"
+	if runtime.GOOS == "windows" {
+		tracerouteTool = "tracert"
+	}
+	report(checkDoctorTool(tracerouteTool, "traceroutes",
+		installHint("traceroute", "tracert ships with Windows, check that System32 is in PATH")))
+	report(checkDoctorTool("iperf3", "iperf3 speed tests",
+		installHint("iperf3", "install iperf3 from https://iperf.fr/iperf-download.php and add it to PATH")))
+	report(checkDoctorICMP())
+
+	if cfg != nil {
+		report(checkDoctorDatabase(cfg.Database))
+	} else {
+		report(doctorCheck{name: "database", status: doctorFail, detail: "skipped, the configuration didn't load"})
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d required check(s) failed", failed)
+	}
+	return nil
+}
+
+func printDoctorCheck(w io.Writer, check doctorCheck) {
+	fmt.Fprintf(w, "[%-4s] %-10s %s\n", check.status, check.name, check.detail)
+	if check.hint != "" && check.status != doctorPass {
+		fmt.Fprintf(w, "       %-10s -> %s\n", "", check.hint)
+	}
+}
+
+// checkDoctorConfig loads the configuration strictly, like validate-config
+func checkDoctorConfig() (*config.Config, doctorCheck) {
+	check := doctorCheck{name: "config"}
+
+	cfg, err := config.LoadStrict(configPath)
+	if err != nil {
+		check.status = doctorFail
+		check.detail = err.Error()
+		check.hint = "fix the file or run 'netronome generate-config' to create a fresh one"
+		return nil, check
+	}
+
+	if err := cfg.Validate(); err != nil {
+		var problems config.ValidationErrors
+		if errors.As(err, &problems) {
+			check.detail = fmt.Sprintf("%d invalid value(s), first: %s", len(problems), problems[0])
+		} else {
+			check.detail = err.Error()
+		}
+		check.status = doctorFail
+		check.hint = "run 'netronome validate-config' to list every problem"
+		return nil, check
+	}
+
+	check.status = doctorPass
+	check.detail = "loaded and valid"
+	return cfg, check
+}
+
+// checkDoctorTool warns when an optional external tool is not on the PATH
+func checkDoctorTool(name, usedFor, hint string) doctorCheck {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return doctorCheck{
+			name:   name,
+			status: doctorWarn,
+			detail: fmt.Sprintf("not found in PATH, needed for %s", usedFor),
+			hint:   hint,
+		}
+	}
+	return doctorCheck{name: name, status: doctorPass, detail: path}
+}
+
+// installHint suggests how to install a tool on the current platform
+func installHint(pkg, windowsHint string) string {
+	switch runtime.GOOS {
+	case "windows":
+		return windowsHint
+	case "darwin":
+		return "brew install " + pkg
+	default:
+		return fmt.Sprintf("install the %s package, e.g. apt install %s", pkg, pkg)
+	}
+}
+
+// checkDoctorICMP pings the loopback address unprivileged (UDP ICMP sockets) and
+// privileged (raw sockets). Packet loss monitors use whichever mode works.
+func checkDoctorICMP() doctorCheck {
+	check := doctorCheck{name: "icmp"}
+
+	// Windows only supports privileged pings, which don't need elevation there
+	if runtime.GOOS == "windows" {
+		if err := doctorPing(true); err != nil {
+			check.status = doctorWarn
+			check.detail = fmt.Sprintf("ping failed: %v", err)
+			check.hint = "allow ICMP echo in Windows Firewall"
+			return check
+		}
+		check.status = doctorPass
+		check.detail = "ping works"
+		return check
+	}
+
+	unprivilegedErr := doctorPing(false)
+	if unprivilegedErr == nil {
+		check.status = doctorPass
+		check.detail = "unprivileged ping works"
+		return check
+	}
+
+	if err := doctorPing(true); err == nil {
+		check.status = doctorPass
+		check.detail = fmt.Sprintf("privileged ping works, unprivileged ping failed: %v", unprivilegedErr)
+		return check
+	}
+
+	check.status = doctorWarn
+	check.detail = fmt.Sprintf("ICMP pings aren't permitted (%v), packet loss monitors won't work", unprivilegedErr)
+	if runtime.GOOS == "linux" {
+		check.hint = `allow unprivileged pings with 'sysctl -w net.ipv4.ping_group_range="0 2147483647"' or grant 'setcap cap_net_raw+ep' to the binary`
+	} else {
+		check.hint = "run Netronome with permission to open raw sockets"
+	}
+	return check
+}
+
+// doctorPing sends a single ping to the loopback address
+func doctorPing(privileged bool) error {
+	pinger, err := probing.NewPinger("127.0.0.1")
+	if err != nil {
+		return err
+	}
+	pinger.Count = 1
+	pinger.Timeout = doctorICMPTimeout
+	pinger.SetPrivileged(privileged)
+
+	if err := pinger.Run(); err != nil {
+		return err
+	}
+	if pinger.Statistics().PacketsRecv == 0 {
+		return errors.New("no reply")
+	}
+	return nil
+}
+
+// checkDoctorDatabase opens the configured database read-only and queries it, without
+// creating or migrating it
+func checkDoctorDatabase(cfg config.DatabaseConfig) doctorCheck {
+	check := doctorCheck{name: "database"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := database.Check(ctx, cfg); err != nil {
+		check.status = doctorFail
+		check.detail = err.Error()
+		if cfg.Type == config.SQLite {
+			check.hint = "check that the database path is correct and readable; the server creates it on first start"
+		} else {
+			check.hint = "check that the database server is running and the host, port and credentials are correct"
+		}
+		return check
+	}
+
+	check.status = doctorPass
+	check.detail = fmt.Sprintf("%s reachable", cfg.Type)
+	return check
+}
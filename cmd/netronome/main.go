@@ -6,12 +6,15 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
@@ -29,6 +32,7 @@ import (
 	"github.com/autobrr/netronome/internal/scheduler"
 	"github.com/autobrr/netronome/internal/server"
 	"github.com/autobrr/netronome/internal/speedtest"
+	"github.com/autobrr/netronome/internal/telemetry"
 	appversion "github.com/autobrr/netronome/internal/version"
 )
 
@@ -38,8 +42,9 @@ var (
 	buildTime = "unknown"
 	commit    = "unknown"
 
-	configPath string
-	rootCmd    = &cobra.Command{
+	configPath   string
+	strictConfig bool
+	rootCmd      = &cobra.Command{
 		Use:   "netronome",
 		Short: "Netronome is a network performance testing and monitoring tool",
 		Long: `Netronome is a network performance testing and monitoring tool that helps you
@@ -58,9 +63,17 @@ track and analyze your network performance over time.`,
 	generateConfigCmd = &cobra.Command{
 		Use:   "generate-config",
 		Short: "Generate a default configuration file",
+		Long:  "Generate a default configuration file. Use --stdout to print it instead, e.g. from a container entrypoint, and --format yaml for YAML output.",
 		RunE:  generateConfig,
 	}
 
+	validateConfigCmd = &cobra.Command{
+		Use:          "validate-config",
+		Short:        "Check the configuration file and environment without starting the server",
+		SilenceUsage: true,
+		RunE:         validateConfig,
+	}
+
 	changePasswordCmd = &cobra.Command{
 		Use:   "change-password [username]",
 		Short: "Change password for a user",
@@ -112,15 +125,23 @@ func init() {
 	}
 
 	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "path to config file")
+	rootCmd.PersistentFlags().BoolVar(&strictConfig, "strict-config", false, "fail on any configuration error instead of falling back to defaults (env: NETRONOME__STRICT_CONFIG)")
+
+	serveCmd.Flags().Bool("dry-run", false, "log the scheduled tests and monitors that would run and keep scheduling disabled")
+
+	generateConfigCmd.Flags().Bool("stdout", false, "write the config to stdout instead of a file")
+	generateConfigCmd.Flags().String("format", "toml", "config format: toml or yaml")
 
 	agentCmd.Flags().StringP("host", "H", "0.0.0.0", "IP address to bind to")
 	agentCmd.Flags().IntP("port", "p", 8200, "port to listen on")
-	agentCmd.Flags().StringP("interface", "i", "", "network interface to monitor (empty for all)")
+	agentCmd.Flags().String("socket", "", "Unix socket to listen on instead of host and port (e.g., /run/netronome-agent.sock)")
+	agentCmd.Flags().StringSliceP("interface", "i", []string{}, "network interfaces to monitor, repeat or comma-separate for several (empty for all)")
 	agentCmd.Flags().StringP("api-key", "k", "", "API key for authentication")
 	agentCmd.Flags().StringP("log-level", "l", "", "log level (trace, debug, info, warn, error)")
 	agentCmd.Flags().StringSlice("disk-include", []string{}, "disk mount points to force into monitoring, even if small or normally filtered (e.g., /mnt/storage)")
 	agentCmd.Flags().StringSlice("disk-exclude", []string{}, "disk mount points to exclude from monitoring (e.g., /boot)")
 	agentCmd.Flags().Bool("disable-system-metrics", false, "disable system metrics collection (CPU, memory, disk, temperature)")
+	agentCmd.Flags().Bool("maintenance", false, "start in maintenance mode, the server holds back notifications for this agent")
 	agentCmd.Flags().Bool("tailscale", false, "enable Tailscale for secure connectivity")
 	agentCmd.Flags().String("tailscale-hostname", "", "custom Tailscale hostname (default: netronome-agent-<hostname>)")
 	agentCmd.Flags().String("tailscale-auth-key", "", "Tailscale auth key for automatic registration")
@@ -129,8 +150,11 @@ func init() {
 
 	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(generateConfigCmd)
+	rootCmd.AddCommand(validateConfigCmd)
+	rootCmd.AddCommand(doctorCmd)
 	rootCmd.AddCommand(changePasswordCmd)
 	rootCmd.AddCommand(createUserCmd)
+	rootCmd.AddCommand(backupCmd)
 	rootCmd.AddCommand(agentCmd)
 	rootCmd.AddCommand(updateCmd)
 	rootCmd.AddCommand(versionCmd)
@@ -148,34 +172,56 @@ func main() {
 }
 
 func generateConfig(cmd *cobra.Command, args []string) error {
-	logger.Init(config.LoggingConfig{Level: "info"}, config.ServerConfig{}, false)
+	toStdout, _ := cmd.Flags().GetBool("stdout")
+	format, _ := cmd.Flags().GetString("format")
+
+	// The logger writes to stdout, so keep zerolog's stderr default when the config goes there
+	if !toStdout {
+		logger.Init(config.LoggingConfig{Level: "info"}, config.ServerConfig{}, false)
+	}
 
 	cfg := config.New()
 
+	var write func(io.Writer) error
+	fileName := "config.toml"
+	switch strings.ToLower(format) {
+	case "toml":
+		write = cfg.WriteToml
+	case "yaml", "yml":
+		write = cfg.WriteYAML
+		fileName = "config.yaml"
+	default:
+		return fmt.Errorf("unsupported config format %q, expected toml or yaml", format)
+	}
+
+	if toStdout {
+		return write(cmd.OutOrStdout())
+	}
+
 	if configPath == "" {
 		homeDir, err := os.UserHomeDir()
 		if err == nil {
 			configDir := filepath.Join(homeDir, ".config")
 			netronomeDir := filepath.Join(configDir, config.AppName)
 			if err := os.MkdirAll(netronomeDir, 0755); err == nil {
-				configPath = filepath.Join(netronomeDir, "config.toml")
+				configPath = filepath.Join(netronomeDir, fileName)
 			} else {
 				// Fall back to platform-specific user config dir
 				if configDir, err := os.UserConfigDir(); err == nil {
 					netronomeDir := filepath.Join(configDir, config.AppName)
 					if err := os.MkdirAll(netronomeDir, 0755); err == nil {
-						configPath = filepath.Join(netronomeDir, "config.toml")
+						configPath = filepath.Join(netronomeDir, fileName)
 					} else {
 						log.Warn().
 							Err(err).
 							Msg("could not create config directory, falling back to working directory")
-						configPath = "config.toml"
+						configPath = fileName
 					}
 				} else {
 					log.Warn().
 						Err(err).
 						Msg("could not determine user config directory, falling back to working directory")
-					configPath = "config.toml"
+					configPath = fileName
 				}
 			}
 		}
@@ -191,7 +237,7 @@ func generateConfig(cmd *cobra.Command, args []string) error {
 	}
 	defer f.Close()
 
-	if err := cfg.WriteToml(f); err != nil {
+	if err := write(f); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
@@ -199,14 +245,85 @@ func generateConfig(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// validateConfig loads the config strictly and reports every invalid value.
+// It exits non-zero when any problem is found, so it can gate deployments.
+func validateConfig(cmd *cobra.Command, args []string) error {
+	logger.Init(config.LoggingConfig{Level: "warn"}, config.ServerConfig{}, false)
+
+	cfg, err := config.LoadStrict(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		var problems config.ValidationErrors
+		if !errors.As(err, &problems) {
+			return err
+		}
+		for _, problem := range problems {
+			fmt.Fprintf(cmd.ErrOrStderr(), "invalid %s\n", problem)
+		}
+		return fmt.Errorf("configuration has %d problem(s)", len(problems))
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "configuration OK")
+	return nil
+}
+
+// isStrictConfig reports whether config errors should abort startup
+func isStrictConfig() bool {
+	return strictConfig || config.StrictFromEnv()
+}
+
+// loadConfig loads configuration, failing on any config error in strict mode
+func loadConfig(path string) (*config.Config, error) {
+	if isStrictConfig() {
+		return config.LoadStrict(path)
+	}
+	return config.Load(path)
+}
+
+// reloadConfig re-reads the configuration and pushes the hot-reloadable parts into
+// the running services. Changes to database.type or server.port need a restart, so a
+// reload that changes them is rejected as a whole.
+func reloadConfig(current *config.Config, speedtestSvc speedtest.Service, monitorService *monitor.Service, notifier *notifications.Notifier) {
+	log.Info().Msg("Received SIGHUP, reloading configuration")
+
+	next, err := loadConfig(configPath)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to reload configuration, keeping current settings")
+		return
+	}
+	if err := next.Validate(); err != nil {
+		log.Warn().Err(err).Msg("Reloaded configuration is invalid, keeping current settings")
+		return
+	}
+	if next.Database.Type != current.Database.Type || next.Server.Port != current.Server.Port {
+		log.Warn().
+			Str("database_type", string(next.Database.Type)).
+			Int("server_port", next.Server.Port).
+			Msg("database.type and server.port cannot change without a restart, reload rejected")
+		return
+	}
+
+	logger.Reload(next.Logging, next.Server)
+	speedtestSvc.Reload(next)
+	if monitorService != nil {
+		monitorService.Reload(next)
+	}
+	notifier.Reload(next)
+
+	log.Info().Msg("Configuration reloaded")
+}
+
 func runServer(cmd *cobra.Command, args []string) error {
 	// initialize logger with default settings first (silent)
 	logger.Init(config.LoggingConfig{Level: "info"}, config.ServerConfig{}, true)
 
 	// Load config from file and environment variables
-	cfg, err := config.Load(configPath)
+	cfg, err := loadConfig(configPath)
 	if err != nil {
-		if configPath != "" {
+		if configPath != "" || isStrictConfig() || errors.Is(err, config.ErrSecretFile) {
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
 		cfg = config.New()
@@ -218,6 +335,12 @@ func runServer(cmd *cobra.Command, args []string) error {
 	// reinitialize logger with loaded config (not silent)
 	logger.Init(cfg.Logging, cfg.Server, false)
 
+	// start exporting traces before anything worth tracing runs
+	shutdownTelemetry, err := telemetry.Init(context.Background(), cfg.Telemetry)
+	if err != nil {
+		return fmt.Errorf("failed to initialize telemetry: %w", err)
+	}
+
 	// initialize database
 	db := database.New(cfg.Database)
 	if err := db.InitializeTables(context.Background()); err != nil {
@@ -225,11 +348,17 @@ func runServer(cmd *cobra.Command, args []string) error {
 	}
 
 	// create notifier
-	notifier, err := notifications.NewNotifier(db)
+	notifier, err := notifications.NewNotifier(db, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create notifier: %w", err)
 	}
 
+	// fail early when the configured source address isn't on this host
+	sourceAddress := speedtest.NewSourceAddress(cfg.SpeedTest)
+	if _, err := sourceAddress.Resolve(); err != nil {
+		return fmt.Errorf("invalid speedtest source address: %w", err)
+	}
+
 	// create server handler with all services
 	speedtestSvc := speedtest.New(db, cfg.SpeedTest, notifier, cfg)
 
@@ -239,17 +368,26 @@ func runServer(cmd *cobra.Command, args []string) error {
 	// create packet loss service if enabled
 	if cfg.PacketLoss.Enabled {
 		// We'll set the actual broadcaster after creating the server
-		packetLossService = speedtest.NewPacketLossService(db, notifier, nil, cfg.PacketLoss.MaxConcurrentMonitors, cfg.PacketLoss.PrivilegedMode, cfg.PacketLoss.MTREnableDNS)
+		packetLossService = speedtest.NewPacketLossService(db, notifier, nil, cfg.PacketLoss.MaxConcurrentMonitors, cfg.PacketLoss.PrivilegedMode, cfg.PacketLoss.MTREnableDNS, time.Duration(cfg.PacketLoss.TimeoutMargin)*time.Second)
+		packetLossService.SetCompletionWindows(
+			time.Duration(cfg.PacketLoss.CompletedStatusWindow)*time.Second,
+			time.Duration(cfg.PacketLoss.CompletedRetention)*time.Second,
+		)
+		packetLossService.SetSourceAddress(sourceAddress)
 	}
 
 	// Create monitor service variable
 	var monitorService *monitor.Service
 
-	// Now create scheduler with packet loss service
-	schedulerSvc := scheduler.New(db, speedtestSvc, packetLossService, notifier)
+	// Scheduled traceroutes reuse the speedtest service's traceroute runner
+	tracerouteService := speedtest.NewTracerouteService(db, speedtestSvc)
+
+	// Now create scheduler with packet loss and traceroute services
+	schedulerSvc := scheduler.New(db, speedtestSvc, packetLossService, tracerouteService, notifier, cfg.PacketLoss.StaggerMonitors)
 
 	// create server handler with packet loss service and monitor service
 	serverHandler := server.NewServer(speedtestSvc, db, schedulerSvc, cfg, packetLossService, monitorService, notifier)
+	serverHandler.RestoreMonitoringPause(context.Background())
 
 	speedtestSvc.SetBroadcastUpdate(serverHandler.BroadcastUpdate)
 	speedtestSvc.SetBroadcastTracerouteUpdate(serverHandler.BroadcastTracerouteUpdate)
@@ -264,6 +402,8 @@ func runServer(cmd *cobra.Command, args []string) error {
 
 	// Create and set monitor service if enabled
 	if cfg.Monitor.Enabled {
+		monitor.SetTransportOptions(monitor.TransportOptionsFromConfig(&cfg.Monitor))
+
 		// Use Tailscale-enabled service if auto-discovery is enabled
 		// This works with both host and tsnet modes
 		if cfg.Tailscale.IsServerDiscoveryMode() {
@@ -274,6 +414,8 @@ func runServer(cmd *cobra.Command, args []string) error {
 			monitorService = monitor.NewService(db, &cfg.Monitor, serverHandler.BroadcastMonitorUpdate, notifier)
 		}
 		serverHandler.SetMonitorService(monitorService)
+		speedtestSvc.SetConditionsProvider(monitorService)
+		speedtestSvc.SetSamplingBooster(monitorService)
 
 		// Start monitor service
 		if err := monitorService.Start(); err != nil {
@@ -284,27 +426,45 @@ func runServer(cmd *cobra.Command, args []string) error {
 	// Initialize server (register routes and static files)
 	serverHandler.Initialize()
 
-	serverHandler.StartScheduler(context.Background())
-
+	// Listen before the scheduler starts, so /readyz reports the rest of startup
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
-	srv := &http.Server{
-		Addr:    addr,
-		Handler: serverHandler.Router,
+	srv := serverHandler.NewHTTPServer(addr)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
 	}
 
 	go func() {
 		log.Info().Str("addr", addr).Msg("Starting server")
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
 			log.Fatal().Err(err).Msg("Failed to start server")
 		}
 	}()
 
-	// wait for interrupt signal to gracefully shutdown the server
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		logSchedulePlan(schedulerSvc, cfg)
+	} else {
+		serverHandler.StartScheduler(context.Background())
+	}
+	serverHandler.MarkReady()
+
+	// wait for interrupt signal to gracefully shutdown the server, reloading config on SIGHUP
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	for running := true; running; {
+		select {
+		case <-hup:
+			reloadConfig(cfg, speedtestSvc, monitorService, notifier)
+		case <-quit:
+			running = false
+		}
+	}
 
 	log.Info().Msg("Shutting down server...")
+	serverHandler.MarkShuttingDown()
 
 	// the context is used to inform the server it has 5 seconds to finish
 	// the request it is currently handling
@@ -325,10 +485,41 @@ func runServer(cmd *cobra.Command, args []string) error {
 		log.Error().Err(err).Msg("Failed to close database")
 	}
 
+	// Flush the spans of the last runs
+	if err := shutdownTelemetry(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to flush telemetry")
+	}
+
 	log.Info().Msg("Server exiting")
 	return nil
 }
 
+// logSchedulePlan logs what the scheduler would run, for --dry-run. The scheduler is not
+// started, so nothing runs on a schedule until the server is restarted without the flag.
+func logSchedulePlan(schedulerSvc scheduler.Service, cfg *config.Config) {
+	plan, err := schedulerSvc.Plan(context.Background(), time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Dry run: failed to plan scheduled runs")
+		return
+	}
+
+	for _, run := range plan {
+		log.Info().
+			Str("kind", run.Kind).
+			Int64("id", run.ID).
+			Str("name", run.Name).
+			Str("host", run.Host).
+			Str("interval", run.Interval).
+			Time("next_run", run.NextRun).
+			Msg("Dry run: would schedule")
+	}
+
+	log.Warn().
+		Int("planned", len(plan)).
+		Bool("restore_monitors_on_startup", cfg.PacketLoss.RestoreMonitorsOnStartup).
+		Msg("Dry run: scheduler disabled, no scheduled tests or monitors will run")
+}
+
 func changePassword(cmd *cobra.Command, args []string) error {
 	logger.Init(config.LoggingConfig{Level: "info"}, config.ServerConfig{}, false)
 
@@ -337,7 +528,7 @@ func changePassword(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to ensure config exists: %w", err)
 	}
 
-	cfg, err := config.Load(configPath)
+	cfg, err := loadConfig(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
@@ -393,7 +584,7 @@ func createUser(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to ensure config exists: %w", err)
 	}
 
-	cfg, err := config.Load(configPath)
+	cfg, err := loadConfig(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
@@ -445,15 +636,19 @@ func createUser(cmd *cobra.Command, args []string) error {
 func runAgent(cmd *cobra.Command, args []string) error {
 	host, _ := cmd.Flags().GetString("host")
 	port, _ := cmd.Flags().GetInt("port")
-	iface, _ := cmd.Flags().GetString("interface")
+	socket, _ := cmd.Flags().GetString("socket")
+	ifaces, _ := cmd.Flags().GetStringSlice("interface")
 	apiKey, _ := cmd.Flags().GetString("api-key")
 	logLevel, _ := cmd.Flags().GetString("log-level")
 	diskIncludes, _ := cmd.Flags().GetStringSlice("disk-include")
 	diskExcludes, _ := cmd.Flags().GetStringSlice("disk-exclude")
 
 	// Load config from file and environment variables
-	cfg, err := config.Load(configPath)
+	cfg, err := loadConfig(configPath)
 	if err != nil {
+		if isStrictConfig() || errors.Is(err, config.ErrSecretFile) {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
 		// Initialize logger with default settings if config load fails
 		logger.Init(config.LoggingConfig{Level: "info"}, config.ServerConfig{}, false)
 		log.Warn().Err(err).Msg("Failed to load config, using defaults")
@@ -475,8 +670,11 @@ func runAgent(cmd *cobra.Command, args []string) error {
 	if cmd.Flags().Changed("port") {
 		cfg.Agent.Port = port
 	}
+	if cmd.Flags().Changed("socket") {
+		cfg.Agent.Socket = socket
+	}
 	if cmd.Flags().Changed("interface") {
-		cfg.Agent.Interface = iface
+		cfg.Agent.Interfaces = ifaces
 	}
 	if cmd.Flags().Changed("api-key") {
 		cfg.Agent.APIKey = apiKey
@@ -490,6 +688,9 @@ func runAgent(cmd *cobra.Command, args []string) error {
 	if cmd.Flags().Changed("disable-system-metrics") {
 		cfg.Agent.DisableSystemMetrics, _ = cmd.Flags().GetBool("disable-system-metrics")
 	}
+	if cmd.Flags().Changed("maintenance") {
+		cfg.Agent.Maintenance, _ = cmd.Flags().GetBool("maintenance")
+	}
 
 	// Handle Tailscale flags
 	useTailscale, _ := cmd.Flags().GetBool("tailscale")
@@ -5,8 +5,12 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -16,22 +20,30 @@ import (
 
 // New creates a new Agent instance
 func New(cfg *config.AgentConfig) *Agent {
-	return &Agent{
-		config:      cfg,
-		clients:     make(map[chan string]bool),
-		monitorData: make(chan string, 100),
+	a := &Agent{
+		config:        cfg,
+		clients:       make(map[chan string]bool),
+		monitorData:   make(chan string, 100),
+		probeResults:  make(map[string][]ProbeResult),
+		probesChanged: make(chan struct{}, 1),
 	}
+	a.maintenance.Store(cfg.Maintenance)
+	return a
 }
 
 // NewWithTailscale creates a new Agent instance with Tailscale support
 func NewWithTailscale(cfg *config.AgentConfig, tsCfg *config.TailscaleConfig) *Agent {
-	return &Agent{
+	a := &Agent{
 		config:          cfg,
 		tailscaleConfig: tsCfg,
 		clients:         make(map[chan string]bool),
 		monitorData:     make(chan string, 100),
+		probeResults:    make(map[string][]ProbeResult),
+		probesChanged:   make(chan struct{}, 1),
 		useTailscale:    tsCfg != nil && tsCfg.IsAgentMode(),
 	}
+	a.maintenance.Store(cfg.Maintenance)
+	return a
 }
 
 // Start starts the agent server
@@ -61,6 +73,9 @@ func (a *Agent) Start(ctx context.Context) error {
 	// Start broadcaster
 	go a.broadcaster(ctx)
 
+	// Start agent-side probes
+	go a.runProbes(ctx)
+
 	// Set up routes
 	router := a.setupRoutes()
 
@@ -86,15 +101,46 @@ func (a *Agent) Start(ctx context.Context) error {
 		log.Info().Msg("System metrics collection disabled, running in bandwidth-only mode")
 	}
 
+	ln, err := a.listen(addr)
+	if err != nil {
+		return err
+	}
+
 	if a.config.APIKey != "" {
-		log.Info().Str("addr", addr).Msg("Starting monitor SSE agent with API key authentication")
+		log.Info().Str("addr", ln.Addr().String()).Msg("Starting monitor SSE agent with API key authentication")
 	} else {
-		log.Info().Str("addr", addr).Msg("Starting monitor SSE agent without authentication")
+		log.Info().Str("addr", ln.Addr().String()).Msg("Starting monitor SSE agent without authentication")
 	}
 
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("failed to start server: %w", err)
 	}
 
 	return nil
+}
+
+// listen opens the agent's listener: the Unix socket when one is configured, otherwise
+// addr. A socket left behind by an agent that didn't shut down cleanly is replaced.
+func (a *Agent) listen(addr string) (net.Listener, error) {
+	if a.config.Socket == "" {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		return ln, nil
+	}
+
+	if err := os.Remove(a.config.Socket); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale socket %s: %w", a.config.Socket, err)
+	}
+	ln, err := net.Listen("unix", a.config.Socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on socket %s: %w", a.config.Socket, err)
+	}
+	// Owner and group only, so access is granted by adding the server's user to the group
+	if err := os.Chmod(a.config.Socket, 0o660); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return ln, nil
 }
\ No newline at end of file
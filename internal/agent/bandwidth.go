@@ -20,8 +20,8 @@ import (
 func (a *Agent) handleHistoricalExport(c *gin.Context) {
 	// Get optional interface parameter
 	iface := c.Query("interface")
-	if iface == "" {
-		iface = a.config.Interface
+	if ifaces := a.config.MonitoredInterfaces(); iface == "" && len(ifaces) == 1 {
+		iface = ifaces[0]
 	}
 
 	// Build vnstat command for all historical data
@@ -84,12 +84,45 @@ func (a *Agent) handleHistoricalExport(c *gin.Context) {
 	c.Data(http.StatusOK, "application/json", enrichedOutput)
 }
 
-// runBandwidthMonitor runs vnstat command and sends data to the broadcast channel
+// runBandwidthMonitor runs vnstat for the monitored interfaces and sends data to the
+// broadcast channel. Several interfaces are sampled separately and combined per tick.
 func (a *Agent) runBandwidthMonitor(ctx context.Context) {
+	ifaces := a.config.MonitoredInterfaces()
+	if len(ifaces) <= 1 {
+		iface := ""
+		if len(ifaces) == 1 {
+			iface = ifaces[0]
+		}
+		a.runVnstatLive(ctx, iface, a.publishLiveData)
+		return
+	}
+
+	samples := newInterfaceSamples()
+	for _, iface := range ifaces {
+		go a.runVnstatLive(ctx, iface, samples.update)
+	}
+
+	ticker := time.NewTicker(liveCombineInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if data, ok := samples.combine(time.Now()); ok {
+				a.publishLiveData(data)
+			}
+		}
+	}
+}
+
+// runVnstatLive runs vnstat --live for one interface, or all interfaces when iface is
+// empty, and hands every parsed sample to handle
+func (a *Agent) runVnstatLive(ctx context.Context, iface string, handle func(MonitorLiveData)) {
 	// Build vnstat command
 	args := []string{"--live", "--json"}
-	if a.config.Interface != "" {
-		args = append(args, "--iface", a.config.Interface)
+	if iface != "" {
+		args = append(args, "--iface", iface)
 	}
 
 	cmd := exec.CommandContext(ctx, "vnstat", args...)
@@ -100,7 +133,7 @@ func (a *Agent) runBandwidthMonitor(ctx context.Context) {
 	}
 
 	if err := cmd.Start(); err != nil {
-		log.Error().Err(err).Msg("Failed to start vnstat")
+		log.Error().Err(err).Str("interface", iface).Msg("Failed to start vnstat")
 		return
 	}
 
@@ -115,30 +148,9 @@ func (a *Agent) runBandwidthMonitor(ctx context.Context) {
 			continue
 		}
 
-		// Track peak speeds
-		a.peakMu.Lock()
-		now := time.Now()
-		if data.Rx.Bytespersecond > a.peakRx {
-			a.peakRx = data.Rx.Bytespersecond
-			a.peakRxTimestamp = now
-		}
-		if data.Tx.Bytespersecond > a.peakTx {
-			a.peakTx = data.Tx.Bytespersecond
-			a.peakTxTimestamp = now
-		}
-		a.peakMu.Unlock()
-
-		// Send to broadcaster
-		select {
-		case a.monitorData <- line:
-		default:
-			// Channel full, skip
-		}
-
-		log.Trace().
-			Str("rx", data.Rx.Ratestring).
-			Str("tx", data.Tx.Ratestring).
-			Msg("Broadcasting bandwidth monitor data")
+		// Tag the sample with its interface so the server can apply per-interface thresholds
+		data.Interface = iface
+		handle(data)
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -146,7 +158,51 @@ func (a *Agent) runBandwidthMonitor(ctx context.Context) {
 	}
 
 	if err := cmd.Wait(); err != nil {
-		log.Error().Err(err).Msg("vnstat command failed")
+		log.Error().Err(err).Str("interface", iface).Msg("vnstat command failed")
+	}
+}
+
+// publishLiveData sends a vnstat sample to the broadcaster
+func (a *Agent) publishLiveData(data MonitorLiveData) {
+	// The boosted sampler reports in place of vnstat until the boost ends
+	if a.boosted.Load() {
+		return
+	}
+
+	line, err := json.Marshal(data)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode bandwidth data")
+		return
+	}
+
+	a.trackPeaks(&data)
+
+	// Send to broadcaster
+	select {
+	case a.monitorData <- string(line):
+	default:
+		// Channel full, skip
+	}
+
+	log.Trace().
+		Str("rx", data.Rx.Ratestring).
+		Str("tx", data.Tx.Ratestring).
+		Msg("Broadcasting bandwidth monitor data")
+}
+
+// trackPeaks records new peak speeds from a live sample
+func (a *Agent) trackPeaks(data *MonitorLiveData) {
+	a.peakMu.Lock()
+	defer a.peakMu.Unlock()
+
+	now := time.Now()
+	if data.Rx.Bytespersecond > a.peakRx {
+		a.peakRx = data.Rx.Bytespersecond
+		a.peakRxTimestamp = now
+	}
+	if data.Tx.Bytespersecond > a.peakTx {
+		a.peakTx = data.Tx.Bytespersecond
+		a.peakTxTimestamp = now
 	}
 }
 
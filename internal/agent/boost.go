@@ -0,0 +1,216 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	psnet "github.com/shirou/gopsutil/v4/net"
+)
+
+const (
+	// minBoostInterval bounds how fast boosted samples are taken
+	minBoostInterval = 100 * time.Millisecond
+	// maxBoostDuration ends a boost the server forgot to stop
+	maxBoostDuration     = 10 * time.Minute
+	defaultBoostDuration = 2 * time.Minute
+)
+
+// interfaceCounters are cumulative byte and packet counters of the monitored interface
+type interfaceCounters struct {
+	rxBytes, txBytes     uint64
+	rxPackets, txPackets uint64
+}
+
+type counterFunc func(ctx context.Context) (interfaceCounters, error)
+
+// handleStartBoost samples live bandwidth faster than vnstat until the duration ends or the boost is stopped
+func (a *Agent) handleStartBoost(c *gin.Context) {
+	var req struct {
+		Interval string `json:"interval"`
+		Duration string `json:"duration"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	interval, err := time.ParseDuration(req.Interval)
+	if err != nil || interval < minBoostInterval || interval >= time.Second {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("interval must be between %s and 1s", minBoostInterval)})
+		return
+	}
+
+	duration := defaultBoostDuration
+	if req.Duration != "" {
+		if duration, err = time.ParseDuration(req.Duration); err != nil || duration <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid duration"})
+			return
+		}
+	}
+	duration = min(duration, maxBoostDuration)
+
+	a.startBoost(interval, duration, a.interfaceCounters)
+	c.JSON(http.StatusOK, gin.H{"interval": interval.String(), "duration": duration.String()})
+}
+
+// handleStopBoost restores normal vnstat sampling
+func (a *Agent) handleStopBoost(c *gin.Context) {
+	a.stopBoost()
+	c.JSON(http.StatusOK, gin.H{"boosted": false})
+}
+
+func (a *Agent) startBoost(interval, duration time.Duration, counters counterFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+
+	a.boostMu.Lock()
+	if a.boostCancel != nil {
+		a.boostCancel()
+	}
+	a.boostCancel = cancel
+	a.boostGen++
+	gen := a.boostGen
+	a.boosted.Store(true)
+	a.boostMu.Unlock()
+
+	log.Info().Dur("interval", interval).Dur("duration", duration).Msg("Boosting live bandwidth sampling")
+
+	go func() {
+		a.runBoostedSampler(ctx, interval, counters)
+
+		a.boostMu.Lock()
+		if a.boostGen == gen {
+			a.boostCancel = nil
+			a.boosted.Store(false)
+			log.Info().Msg("Restored normal bandwidth sampling")
+		}
+		a.boostMu.Unlock()
+		cancel()
+	}()
+}
+
+func (a *Agent) stopBoost() {
+	a.boostMu.Lock()
+	defer a.boostMu.Unlock()
+	if a.boostCancel != nil {
+		a.boostCancel()
+	}
+}
+
+// runBoostedSampler emits a live sample every interval from the interface counters
+func (a *Agent) runBoostedSampler(ctx context.Context, interval time.Duration, counters counterFunc) {
+	prev, err := counters(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read interface counters for boosted sampling")
+		return
+	}
+	prevAt := time.Now()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for index := 1; ; index++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		cur, err := counters(ctx)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to read interface counters for boosted sampling")
+			continue
+		}
+		now := time.Now()
+
+		data := liveSample(prev, cur, now.Sub(prevAt))
+		data.Index = index
+		if ifaces := a.config.MonitoredInterfaces(); len(ifaces) == 1 {
+			data.Interface = ifaces[0]
+		}
+		prev, prevAt = cur, now
+
+		line, err := json.Marshal(data)
+		if err != nil {
+			continue
+		}
+		a.trackPeaks(&data)
+
+		select {
+		case a.monitorData <- string(line):
+		default:
+			// Channel full, skip
+		}
+	}
+}
+
+// liveSample converts two counter readings into the vnstat live format
+func liveSample(prev, cur interfaceCounters, elapsed time.Duration) MonitorLiveData {
+	var data MonitorLiveData
+	if elapsed <= 0 {
+		return data
+	}
+	perSecond := func(from, to uint64) int {
+		if to < from {
+			// Counter reset or wrap
+			return 0
+		}
+		return int(float64(to-from) / elapsed.Seconds())
+	}
+
+	data.Seconds = int(elapsed.Seconds())
+	data.Rx.Bytes = int(cur.rxBytes - min(prev.rxBytes, cur.rxBytes))
+	data.Rx.Bytespersecond = perSecond(prev.rxBytes, cur.rxBytes)
+	data.Rx.Packetspersecond = perSecond(prev.rxPackets, cur.rxPackets)
+	data.Rx.Ratestring = formatBytesPerSecond(data.Rx.Bytespersecond)
+	data.Rx.Totalbytes = int(cur.rxBytes)
+	data.Rx.Totalpackets = int(cur.rxPackets)
+	data.Tx.Bytes = int(cur.txBytes - min(prev.txBytes, cur.txBytes))
+	data.Tx.Bytespersecond = perSecond(prev.txBytes, cur.txBytes)
+	data.Tx.Packetspersecond = perSecond(prev.txPackets, cur.txPackets)
+	data.Tx.Ratestring = formatBytesPerSecond(data.Tx.Bytespersecond)
+	data.Tx.Totalbytes = int(cur.txBytes)
+	data.Tx.Totalpackets = int(cur.txPackets)
+	return data
+}
+
+// interfaceCounters sums the configured interfaces, or every non-loopback interface when none is set
+func (a *Agent) interfaceCounters(ctx context.Context) (interfaceCounters, error) {
+	stats, err := psnet.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return interfaceCounters{}, err
+	}
+
+	ifaces := a.config.MonitoredInterfaces()
+	var total interfaceCounters
+	found := false
+	for _, s := range stats {
+		if len(ifaces) > 0 && !slices.Contains(ifaces, s.Name) {
+			continue
+		}
+		if len(ifaces) == 0 && isLoopbackName(s.Name) {
+			continue
+		}
+		found = true
+		total.rxBytes += s.BytesRecv
+		total.txBytes += s.BytesSent
+		total.rxPackets += s.PacketsRecv
+		total.txPackets += s.PacketsSent
+	}
+	if !found {
+		return interfaceCounters{}, fmt.Errorf("interfaces %q not found", ifaces)
+	}
+	return total, nil
+}
+
+func isLoopbackName(name string) bool {
+	return name == "lo" || name == "lo0" || name == "Loopback Pseudo-Interface 1"
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+func TestLiveSample(t *testing.T) {
+	prev := interfaceCounters{rxBytes: 1000, txBytes: 500, rxPackets: 10, txPackets: 5}
+	cur := interfaceCounters{rxBytes: 3500, txBytes: 1000, rxPackets: 30, txPackets: 10}
+
+	data := liveSample(prev, cur, 250*time.Millisecond)
+	assert.Equal(t, 10000, data.Rx.Bytespersecond)
+	assert.Equal(t, 2000, data.Tx.Bytespersecond)
+	assert.Equal(t, 80, data.Rx.Packetspersecond)
+	assert.Equal(t, 2500, data.Rx.Bytes)
+	assert.Equal(t, 3500, data.Rx.Totalbytes)
+	assert.NotEmpty(t, data.Rx.Ratestring)
+
+	reset := liveSample(cur, prev, time.Second)
+	assert.Zero(t, reset.Rx.Bytespersecond, "counter reset should not report a negative rate")
+	assert.Zero(t, reset.Rx.Bytes)
+}
+
+func TestBoostedSampling(t *testing.T) {
+	a := New(&config.AgentConfig{Interface: "eth0"})
+
+	var mu sync.Mutex
+	var rx uint64
+	counters := func(ctx context.Context) (interfaceCounters, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		rx += 1000
+		return interfaceCounters{rxBytes: rx}, nil
+	}
+
+	a.startBoost(20*time.Millisecond, time.Minute, counters)
+	assert.True(t, a.boosted.Load())
+
+	select {
+	case line := <-a.monitorData:
+		var data MonitorLiveData
+		require.NoError(t, json.Unmarshal([]byte(line), &data))
+		assert.Equal(t, "eth0", data.Interface)
+		assert.Positive(t, data.Rx.Bytespersecond)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no boosted sample emitted")
+	}
+
+	a.stopBoost()
+	assert.Eventually(t, func() bool { return !a.boosted.Load() }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestHandleStartBoostValidation(t *testing.T) {
+	a := New(&config.AgentConfig{})
+	router := a.setupRoutes()
+
+	for _, body := range []string{`{"interval":"10ms"}`, `{"interval":"2s"}`, `{"interval":"250ms","duration":"soon"}`} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/sampling/boost", strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+	assert.False(t, a.boosted.Load())
+}
@@ -19,21 +19,8 @@ func (a *Agent) handleSSE(c *gin.Context) {
 		return
 	}
 
-	// Create a client channel
-	clientChan := make(chan string, 100)
-
-	// Register client
-	a.clientsMu.Lock()
-	a.clients[clientChan] = true
-	a.clientsMu.Unlock()
-
-	// Clean up on disconnect
-	defer func() {
-		a.clientsMu.Lock()
-		delete(a.clients, clientChan)
-		a.clientsMu.Unlock()
-		close(clientChan)
-	}()
+	clientChan := a.subscribe()
+	defer a.unsubscribe(clientChan)
 
 	c.Stream(func(w io.Writer) bool {
 		select {
@@ -46,7 +33,26 @@ func (a *Agent) handleSSE(c *gin.Context) {
 	})
 }
 
-// broadcaster distributes monitoring data to all connected SSE clients
+// subscribe registers a live data client and returns the channel its data arrives on
+func (a *Agent) subscribe() chan string {
+	clientChan := make(chan string, 100)
+
+	a.clientsMu.Lock()
+	a.clients[clientChan] = true
+	a.clientsMu.Unlock()
+
+	return clientChan
+}
+
+// unsubscribe removes a live data client on disconnect
+func (a *Agent) unsubscribe(clientChan chan string) {
+	a.clientsMu.Lock()
+	delete(a.clients, clientChan)
+	a.clientsMu.Unlock()
+	close(clientChan)
+}
+
+// broadcaster distributes monitoring data to all connected SSE and WebSocket clients
 func (a *Agent) broadcaster(ctx context.Context) {
 	for {
 		select {
@@ -46,6 +46,7 @@ func (a *Agent) getHardwareStats() (*HardwareStats, error) {
 		Msg("Starting getHardwareStats with disk filters")
 
 	stats := &HardwareStats{
+		Uptime:    getUptime(),
 		UpdatedAt: time.Now(),
 	}
 
@@ -97,11 +98,16 @@ func (a *Agent) getHardwareStats() (*HardwareStats, error) {
 		stats.CPU.Cores = stats.CPU.Threads
 	}
 
-	// Get CPU usage percentage
-	cpuPercent, err := cpu.Percent(time.Second, false)
-	if err == nil && len(cpuPercent) > 0 {
-		stats.CPU.UsagePercent = cpuPercent[0]
-		log.Debug().Float64("usage", cpuPercent[0]).Msg("Got CPU usage")
+	// Get per-core CPU usage in one sample and derive the aggregate from it,
+	// so both figures cover the same interval without sampling twice
+	perCore, err := cpu.Percent(time.Second, true)
+	if err == nil && len(perCore) > 0 {
+		stats.CPU.PerCore = perCore
+		stats.CPU.UsagePercent = averageUsage(perCore)
+		log.Debug().
+			Float64("usage", stats.CPU.UsagePercent).
+			Int("per_core_count", len(perCore)).
+			Msg("Got CPU usage")
 	} else {
 		log.Error().Err(err).Msg("Failed to get CPU usage")
 	}
@@ -354,6 +360,18 @@ func getZFSARCSize() uint64 {
 	return 0
 }
 
+// averageUsage returns the mean of per-core usage percentages
+func averageUsage(perCore []float64) float64 {
+	if len(perCore) == 0 {
+		return 0
+	}
+	var total float64
+	for _, usage := range perCore {
+		total += usage
+	}
+	return total / float64(len(perCore))
+}
+
 // getLoadAverage gets system load averages (Unix-like systems)
 func getLoadAverage() ([]float64, error) {
 	if runtime.GOOS == "windows" {
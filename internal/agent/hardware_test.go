@@ -0,0 +1,28 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAverageUsage(t *testing.T) {
+	tests := []struct {
+		name     string
+		perCore  []float64
+		expected float64
+	}{
+		{name: "no cores", perCore: nil, expected: 0},
+		{name: "single core", perCore: []float64{42.5}, expected: 42.5},
+		{name: "mixed load", perCore: []float64{100, 0, 50, 50}, expected: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, averageUsage(tt.perCore), 0.0001)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package agent
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// liveCombineInterval is how often samples of several interfaces are combined and sent
+	liveCombineInterval = time.Second
+	// liveSampleTTL drops interfaces whose vnstat process stopped reporting
+	liveSampleTTL = 5 * time.Second
+)
+
+// interfaceSamples holds the latest vnstat sample of each monitored interface
+type interfaceSamples struct {
+	mu      sync.Mutex
+	samples map[string]MonitorLiveData
+	seenAt  map[string]time.Time
+	fresh   bool // a sample arrived since the last combine
+}
+
+func newInterfaceSamples() *interfaceSamples {
+	return &interfaceSamples{
+		samples: make(map[string]MonitorLiveData),
+		seenAt:  make(map[string]time.Time),
+	}
+}
+
+func (s *interfaceSamples) update(data MonitorLiveData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples[data.Interface] = data
+	s.seenAt[data.Interface] = time.Now()
+	s.fresh = true
+}
+
+// combine sums the current samples into one live sample carrying each interface
+// under Interfaces. It reports false when nothing new arrived.
+func (s *interfaceSamples) combine(now time.Time) (MonitorLiveData, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var total MonitorLiveData
+	if !s.fresh {
+		return total, false
+	}
+	s.fresh = false
+
+	total.Interfaces = make(map[string]MonitorLiveData, len(s.samples))
+	for iface, data := range s.samples {
+		if now.Sub(s.seenAt[iface]) > liveSampleTTL {
+			continue
+		}
+		total.Interfaces[iface] = data
+		total.Index = max(total.Index, data.Index)
+		total.Seconds = max(total.Seconds, data.Seconds)
+		total.Rx.Bytespersecond += data.Rx.Bytespersecond
+		total.Rx.Packetspersecond += data.Rx.Packetspersecond
+		total.Rx.Bytes += data.Rx.Bytes
+		total.Rx.Packets += data.Rx.Packets
+		total.Rx.Totalbytes += data.Rx.Totalbytes
+		total.Rx.Totalpackets += data.Rx.Totalpackets
+		total.Tx.Bytespersecond += data.Tx.Bytespersecond
+		total.Tx.Packetspersecond += data.Tx.Packetspersecond
+		total.Tx.Bytes += data.Tx.Bytes
+		total.Tx.Packets += data.Tx.Packets
+		total.Tx.Totalbytes += data.Tx.Totalbytes
+		total.Tx.Totalpackets += data.Tx.Totalpackets
+	}
+	if len(total.Interfaces) == 0 {
+		return total, false
+	}
+	total.Rx.Ratestring = formatBytesPerSecond(total.Rx.Bytespersecond)
+	total.Tx.Ratestring = formatBytesPerSecond(total.Tx.Bytespersecond)
+	return total, true
+}
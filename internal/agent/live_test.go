@@ -0,0 +1,49 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterfaceSamplesCombine(t *testing.T) {
+	samples := newInterfaceSamples()
+
+	_, ok := samples.combine(time.Now())
+	assert.False(t, ok, "nothing to combine before the first sample")
+
+	var bond, vlan MonitorLiveData
+	bond.Interface, bond.Index = "bond0", 3
+	bond.Rx.Bytespersecond, bond.Tx.Bytespersecond = 1000, 200
+	vlan.Interface, vlan.Index = "vlan10", 5
+	vlan.Rx.Bytespersecond, vlan.Tx.Bytespersecond = 500, 100
+	samples.update(bond)
+	samples.update(vlan)
+
+	data, ok := samples.combine(time.Now())
+	require.True(t, ok)
+	assert.Empty(t, data.Interface)
+	assert.Equal(t, 5, data.Index)
+	assert.Equal(t, 1500, data.Rx.Bytespersecond)
+	assert.Equal(t, 300, data.Tx.Bytespersecond)
+	assert.NotEmpty(t, data.Rx.Ratestring)
+	require.Len(t, data.Interfaces, 2)
+	assert.Equal(t, 500, data.Interfaces["vlan10"].Rx.Bytespersecond)
+
+	_, ok = samples.combine(time.Now())
+	assert.False(t, ok, "no new samples since the last combine")
+
+	// A stale interface drops out of the combined sample
+	samples.seenAt["bond0"] = time.Now().Add(-2 * liveSampleTTL)
+	samples.update(vlan)
+	data, ok = samples.combine(time.Now())
+	require.True(t, ok)
+	assert.Len(t, data.Interfaces, 1)
+	assert.Contains(t, data.Interfaces, "vlan10")
+	assert.Equal(t, 500, data.Rx.Bytespersecond)
+}
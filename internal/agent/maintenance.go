@@ -0,0 +1,42 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package agent
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// InMaintenance reports whether the agent has declared maintenance
+func (a *Agent) InMaintenance() bool {
+	return a.maintenance.Load()
+}
+
+// SetMaintenance toggles maintenance; the server picks it up from /netronome/info
+func (a *Agent) SetMaintenance(enabled bool) {
+	if a.maintenance.Swap(enabled) != enabled {
+		log.Info().Bool("maintenance", enabled).Msg("Agent maintenance mode changed")
+	}
+}
+
+// handleGetMaintenance returns the maintenance state
+func (a *Agent) handleGetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"enabled": a.InMaintenance()})
+}
+
+// handleSetMaintenance turns maintenance on or off at runtime
+func (a *Agent) handleSetMaintenance(c *gin.Context) {
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Enabled == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "enabled is required"})
+		return
+	}
+
+	a.SetMaintenance(*req.Enabled)
+	c.JSON(http.StatusOK, gin.H{"enabled": a.InMaintenance()})
+}
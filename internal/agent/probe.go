@@ -0,0 +1,290 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	probing "github.com/prometheus-community/pro-bing"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxProbeResults is how many results are kept per host between server polls
+	maxProbeResults     = 60
+	minProbeInterval    = 10 * time.Second
+	maxProbePacketCount = 100
+	defaultProbeCount   = 10
+	probeReplyMargin    = 5 * time.Second
+)
+
+// Probe target sources
+const (
+	ProbeSourceLocal  = "local"  // from the agent's own config
+	ProbeSourceServer = "server" // pushed by the netronome server
+)
+
+// runProbes runs the configured probes until ctx is cancelled, restarting them whenever the targets change
+func (a *Agent) runProbes(ctx context.Context) {
+	for {
+		targets := a.probeTargets()
+		a.pruneProbeResults(targets)
+
+		runCtx, cancel := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		for _, target := range targets {
+			wg.Add(1)
+			go func(target ProbeTarget) {
+				defer wg.Done()
+				a.runProbe(runCtx, target)
+			}(target)
+		}
+
+		if len(targets) > 0 {
+			log.Info().Int("targets", len(targets)).Msg("Started agent probes")
+		}
+
+		select {
+		case <-ctx.Done():
+			cancel()
+			wg.Wait()
+			return
+		case <-a.probesChanged:
+			cancel()
+			wg.Wait()
+		}
+	}
+}
+
+// runProbe pings a single target on its interval
+func (a *Agent) runProbe(ctx context.Context, target ProbeTarget) {
+	interval, err := time.ParseDuration(target.Interval)
+	if err != nil || interval < minProbeInterval {
+		interval = minProbeInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result := a.ping(ctx, target)
+		if ctx.Err() != nil {
+			return
+		}
+		a.recordProbeResult(result)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ping runs one probe and summarizes it
+func (a *Agent) ping(ctx context.Context, target ProbeTarget) ProbeResult {
+	result := ProbeResult{Host: target.Host, MeasuredAt: time.Now()}
+
+	pinger, err := probing.NewPinger(target.Host)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to create pinger: %v", err)
+		return result
+	}
+
+	pinger.Interval = time.Second
+	pinger.Count = target.PacketCount
+	// Allow for sending every packet, then a few seconds for the final reply
+	pinger.Timeout = time.Duration(target.PacketCount)*pinger.Interval + probeReplyMargin
+	pinger.SetPrivileged(a.config.ProbePrivileged)
+
+	if err := pinger.RunWithContext(ctx); err != nil {
+		result.Error = fmt.Sprintf("ping failed: %v", err)
+		return result
+	}
+
+	stats := pinger.Statistics()
+	result.PacketLoss = stats.PacketLoss
+	result.MinRTT = float64(stats.MinRtt) / float64(time.Millisecond)
+	result.AvgRTT = float64(stats.AvgRtt) / float64(time.Millisecond)
+	result.MaxRTT = float64(stats.MaxRtt) / float64(time.Millisecond)
+	result.StdDevRTT = float64(stats.StdDevRtt) / float64(time.Millisecond)
+	result.PacketsSent = stats.PacketsSent
+	result.PacketsRecv = stats.PacketsRecv
+	result.MeasuredAt = time.Now()
+
+	log.Debug().
+		Str("host", target.Host).
+		Float64("packet_loss", result.PacketLoss).
+		Float64("avg_rtt_ms", result.AvgRTT).
+		Msg("Agent probe completed")
+
+	return result
+}
+
+// probeTargets merges the locally configured targets with those pushed by the server.
+// Local targets win when both name the same host.
+func (a *Agent) probeTargets() []ProbeTarget {
+	defaultCount := a.config.ProbePacketCount
+	if defaultCount <= 0 {
+		defaultCount = defaultProbeCount
+	}
+
+	seen := make(map[string]bool)
+	var targets []ProbeTarget
+	for _, host := range a.config.ProbeTargets {
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		targets = append(targets, ProbeTarget{
+			Host:        host,
+			Interval:    a.config.ProbeInterval,
+			PacketCount: defaultCount,
+			Source:      ProbeSourceLocal,
+		})
+	}
+
+	a.probesMu.RLock()
+	defer a.probesMu.RUnlock()
+	for _, target := range a.remoteProbes {
+		if seen[target.Host] {
+			continue
+		}
+		seen[target.Host] = true
+		if target.PacketCount <= 0 {
+			target.PacketCount = defaultCount
+		}
+		if target.Interval == "" {
+			target.Interval = a.config.ProbeInterval
+		}
+		target.Source = ProbeSourceServer
+		targets = append(targets, target)
+	}
+
+	return targets
+}
+
+// setRemoteProbes replaces the server-managed targets and restarts the probes
+func (a *Agent) setRemoteProbes(targets []ProbeTarget) {
+	a.probesMu.Lock()
+	a.remoteProbes = targets
+	a.probesMu.Unlock()
+
+	select {
+	case a.probesChanged <- struct{}{}:
+	default:
+		// A restart is already pending
+	}
+}
+
+func (a *Agent) recordProbeResult(result ProbeResult) {
+	a.probesMu.Lock()
+	defer a.probesMu.Unlock()
+
+	results := append(a.probeResults[result.Host], result)
+	if len(results) > maxProbeResults {
+		results = results[len(results)-maxProbeResults:]
+	}
+	a.probeResults[result.Host] = results
+}
+
+// pruneProbeResults drops buffered results for hosts that are no longer probed
+func (a *Agent) pruneProbeResults(targets []ProbeTarget) {
+	active := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		active[target.Host] = true
+	}
+
+	a.probesMu.Lock()
+	defer a.probesMu.Unlock()
+	for host := range a.probeResults {
+		if !active[host] {
+			delete(a.probeResults, host)
+		}
+	}
+}
+
+// probeResultsSince returns buffered results measured after since, oldest first
+func (a *Agent) probeResultsSince(since time.Time) []ProbeResult {
+	a.probesMu.RLock()
+	defer a.probesMu.RUnlock()
+
+	results := []ProbeResult{}
+	for _, hostResults := range a.probeResults {
+		for _, result := range hostResults {
+			if result.MeasuredAt.After(since) {
+				results = append(results, result)
+			}
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].MeasuredAt.Before(results[j].MeasuredAt)
+	})
+	return results
+}
+
+// handleGetProbes returns the probes the agent is running
+func (a *Agent) handleGetProbes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"targets":               a.probeTargets(),
+		"remote_probes_enabled": !a.config.DisableRemoteProbes,
+	})
+}
+
+// handleSetProbes replaces the server-managed probes
+func (a *Agent) handleSetProbes(c *gin.Context) {
+	if a.config.DisableRemoteProbes {
+		c.JSON(http.StatusForbidden, gin.H{"error": "remote probes are disabled on this agent"})
+		return
+	}
+
+	var req struct {
+		Targets []ProbeTarget `json:"targets"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	for _, target := range req.Targets {
+		if target.Host == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "probe host is required"})
+			return
+		}
+		if target.Interval != "" {
+			if _, err := time.ParseDuration(target.Interval); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid interval for %s", target.Host)})
+				return
+			}
+		}
+		if target.PacketCount < 0 || target.PacketCount > maxProbePacketCount {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("packet count for %s must be between 1 and %d", target.Host, maxProbePacketCount)})
+			return
+		}
+	}
+
+	a.setRemoteProbes(req.Targets)
+	c.JSON(http.StatusOK, gin.H{"targets": a.probeTargets()})
+}
+
+// handleProbeResults returns buffered probe results, optionally only those after ?since=
+func (a *Agent) handleProbeResults(c *gin.Context) {
+	var since time.Time
+	if v := c.Query("since"); v != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since parameter, expected RFC3339"})
+			return
+		}
+		since = parsed
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": a.probeResultsSince(since)})
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+func TestProbeTargets(t *testing.T) {
+	a := New(&config.AgentConfig{
+		ProbeTargets:     []string{"1.1.1.1", "", "1.1.1.1", "8.8.8.8"},
+		ProbeInterval:    "1m",
+		ProbePacketCount: 5,
+	})
+	a.setRemoteProbes([]ProbeTarget{
+		{Host: "8.8.8.8", Interval: "30s", PacketCount: 20},
+		{Host: "9.9.9.9"},
+	})
+
+	targets := a.probeTargets()
+	require.Len(t, targets, 3)
+
+	assert.Equal(t, ProbeTarget{Host: "1.1.1.1", Interval: "1m", PacketCount: 5, Source: ProbeSourceLocal}, targets[0])
+	assert.Equal(t, ProbeTarget{Host: "8.8.8.8", Interval: "1m", PacketCount: 5, Source: ProbeSourceLocal}, targets[1], "local targets win over server targets")
+	assert.Equal(t, ProbeTarget{Host: "9.9.9.9", Interval: "1m", PacketCount: 5, Source: ProbeSourceServer}, targets[2], "server targets fall back to agent defaults")
+
+	select {
+	case <-a.probesChanged:
+	default:
+		t.Fatal("setRemoteProbes should signal a restart")
+	}
+}
+
+func TestProbeResultsBuffer(t *testing.T) {
+	a := New(&config.AgentConfig{})
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < maxProbeResults+5; i++ {
+		a.recordProbeResult(ProbeResult{Host: "1.1.1.1", MeasuredAt: base.Add(time.Duration(i) * time.Minute)})
+	}
+	a.recordProbeResult(ProbeResult{Host: "8.8.8.8", MeasuredAt: base.Add(30 * time.Second)})
+
+	all := a.probeResultsSince(time.Time{})
+	assert.Len(t, all, maxProbeResults+1, "per-host buffer should be capped")
+	for i := 1; i < len(all); i++ {
+		assert.False(t, all[i].MeasuredAt.Before(all[i-1].MeasuredAt), "results should be sorted oldest first")
+	}
+
+	recent := a.probeResultsSince(base.Add(time.Duration(maxProbeResults+2) * time.Minute))
+	assert.Len(t, recent, 2)
+
+	a.pruneProbeResults([]ProbeTarget{{Host: "8.8.8.8"}})
+	assert.Len(t, a.probeResultsSince(time.Time{}), 1)
+}
+
+func TestHandleSetProbes(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        config.AgentConfig
+		body       string
+		wantStatus int
+	}{
+		{name: "valid", body: `{"targets":[{"host":"1.1.1.1","interval":"30s","packet_count":5}]}`, wantStatus: http.StatusOK},
+		{name: "missing host", body: `{"targets":[{"interval":"30s"}]}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid interval", body: `{"targets":[{"host":"1.1.1.1","interval":"soon"}]}`, wantStatus: http.StatusBadRequest},
+		{name: "too many packets", body: `{"targets":[{"host":"1.1.1.1","packet_count":1000}]}`, wantStatus: http.StatusBadRequest},
+		{name: "remote probes disabled", cfg: config.AgentConfig{DisableRemoteProbes: true}, body: `{"targets":[]}`, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(&tt.cfg)
+			router := a.setupRoutes()
+
+			req := httptest.NewRequest(http.MethodPut, "/probes", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantStatus == http.StatusOK {
+				var resp struct {
+					Targets []ProbeTarget `json:"targets"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				require.Len(t, resp.Targets, 1)
+				assert.Equal(t, ProbeSourceServer, resp.Targets[0].Source)
+			}
+		})
+	}
+}
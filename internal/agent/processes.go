@@ -0,0 +1,182 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/shirou/gopsutil/v4/mem"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+const (
+	defaultProcessLimit = 10
+	maxProcessLimit     = 50
+	// processSampleInterval is how long CPU time is measured to get per-process usage
+	processSampleInterval = time.Second
+	// maxCommandLength truncates long command lines
+	maxCommandLength = 256
+)
+
+// handleProcesses returns the processes using the most CPU and memory
+func (a *Agent) handleProcesses(c *gin.Context) {
+	limit := defaultProcessLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		limit = min(n, maxProcessLimit)
+	}
+
+	stats, err := getProcessStats(c.Request.Context(), limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get process stats")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed to get process stats",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// getProcessStats samples every process's CPU time twice, processSampleInterval apart,
+// and returns the top limit processes by CPU and by memory
+func getProcessStats(ctx context.Context, limit int) (*ProcessStats, error) {
+	before, err := sampleProcessCPU(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	select {
+	case <-time.After(processSampleInterval):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	procs, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	elapsed := time.Since(start)
+
+	var totalMemory uint64
+	if vm, err := mem.VirtualMemoryWithContext(ctx); err == nil {
+		totalMemory = vm.Total
+	}
+
+	byPID := make(map[int32]*process.Process, len(procs))
+	infos := make([]ProcessInfo, 0, len(procs))
+	for _, p := range procs {
+		times, err := p.TimesWithContext(ctx)
+		if err != nil {
+			// Exited or not accessible
+			continue
+		}
+
+		info := ProcessInfo{PID: p.Pid}
+		if prev, ok := before[p.Pid]; ok {
+			info.CPUPercent = processCPUPercent(prev, times.User+times.System, elapsed)
+		}
+		if memInfo, err := p.MemoryInfoWithContext(ctx); err == nil {
+			info.MemoryRSS = memInfo.RSS
+			if totalMemory > 0 {
+				info.MemoryPercent = float64(memInfo.RSS) / float64(totalMemory) * 100
+			}
+		}
+
+		byPID[p.Pid] = p
+		infos = append(infos, info)
+	}
+
+	stats := &ProcessStats{
+		TopCPU:    topProcesses(infos, limit, func(p ProcessInfo) float64 { return p.CPUPercent }),
+		TopMemory: topProcesses(infos, limit, func(p ProcessInfo) float64 { return float64(p.MemoryRSS) }),
+		Total:     len(infos),
+		UpdatedAt: time.Now(),
+	}
+
+	// Names and command lines are only looked up for the processes being returned
+	for _, list := range [][]ProcessInfo{stats.TopCPU, stats.TopMemory} {
+		for i := range list {
+			describeProcess(ctx, byPID[list[i].PID], &list[i])
+		}
+	}
+
+	return stats, nil
+}
+
+// sampleProcessCPU returns the total CPU seconds used so far by every process
+func sampleProcessCPU(ctx context.Context) (map[int32]float64, error) {
+	procs, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	samples := make(map[int32]float64, len(procs))
+	for _, p := range procs {
+		if times, err := p.TimesWithContext(ctx); err == nil {
+			samples[p.Pid] = times.User + times.System
+		}
+	}
+	return samples, nil
+}
+
+// processCPUPercent converts CPU seconds used over elapsed into a percentage of one core,
+// like top, so a busy multi-threaded process can exceed 100
+func processCPUPercent(before, after float64, elapsed time.Duration) float64 {
+	if elapsed <= 0 || after <= before {
+		return 0
+	}
+	return (after - before) / elapsed.Seconds() * 100
+}
+
+// topProcesses returns up to limit processes with the highest key, highest first
+func topProcesses(infos []ProcessInfo, limit int, key func(ProcessInfo) float64) []ProcessInfo {
+	sorted := slices.Clone(infos)
+	slices.SortStableFunc(sorted, func(a, b ProcessInfo) int {
+		switch ka, kb := key(a), key(b); {
+		case ka > kb:
+			return -1
+		case ka < kb:
+			return 1
+		default:
+			return int(a.PID - b.PID)
+		}
+	})
+	if len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
+// describeProcess fills in the name, owner and command line of a process
+func describeProcess(ctx context.Context, p *process.Process, info *ProcessInfo) {
+	if p == nil {
+		return
+	}
+	if name, err := p.NameWithContext(ctx); err == nil {
+		info.Name = name
+	}
+	if username, err := p.UsernameWithContext(ctx); err == nil {
+		info.Username = username
+	}
+	if cmdline, err := p.CmdlineWithContext(ctx); err == nil {
+		if len(cmdline) > maxCommandLength {
+			cmdline = cmdline[:maxCommandLength]
+		}
+		info.Command = cmdline
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package agent
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessCPUPercent(t *testing.T) {
+	assert.InDelta(t, 50.0, processCPUPercent(10, 10.5, time.Second), 0.001)
+	assert.InDelta(t, 200.0, processCPUPercent(10, 12, time.Second), 0.001, "multi-threaded processes can exceed one core")
+	assert.Zero(t, processCPUPercent(10, 9, time.Second), "a reused PID doesn't go negative")
+	assert.Zero(t, processCPUPercent(10, 11, 0))
+}
+
+func TestTopProcesses(t *testing.T) {
+	infos := []ProcessInfo{
+		{PID: 1, CPUPercent: 0.1, MemoryRSS: 900},
+		{PID: 2, CPUPercent: 75, MemoryRSS: 100},
+		{PID: 3, CPUPercent: 12, MemoryRSS: 5000},
+		{PID: 4, CPUPercent: 12, MemoryRSS: 10},
+	}
+
+	byCPU := topProcesses(infos, 3, func(p ProcessInfo) float64 { return p.CPUPercent })
+	require.Len(t, byCPU, 3)
+	assert.Equal(t, []int32{2, 3, 4}, []int32{byCPU[0].PID, byCPU[1].PID, byCPU[2].PID}, "ties are ordered by PID")
+
+	byMemory := topProcesses(infos, 10, func(p ProcessInfo) float64 { return float64(p.MemoryRSS) })
+	require.Len(t, byMemory, 4)
+	assert.Equal(t, int32(3), byMemory[0].PID)
+	assert.Equal(t, int32(1), infos[0].PID, "input order is left alone")
+}
+
+func TestGetProcessStats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("samples CPU usage for a second")
+	}
+
+	stats, err := getProcessStats(context.Background(), 500)
+	require.NoError(t, err)
+	assert.NotZero(t, stats.Total)
+	assert.LessOrEqual(t, len(stats.TopCPU), stats.Total)
+
+	var found bool
+	for _, p := range stats.TopMemory {
+		if p.PID == int32(os.Getpid()) {
+			found = true
+			assert.NotEmpty(t, p.Name)
+			assert.NotZero(t, p.MemoryRSS)
+		}
+	}
+	assert.True(t, found, "the test process is listed")
+}
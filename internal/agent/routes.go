@@ -34,16 +34,19 @@ func (a *Agent) setupRoutes() *gin.Engine {
 		protected.Use(a.authMiddleware())
 	}
 
-	// SSE endpoint (protected)
+	// SSE and WebSocket live data endpoints (protected)
 	protected.GET("/events", a.handleSSE)
+	protected.GET("/events/ws", a.handleWebSocket)
 
 	// Historical data export endpoint (protected)
 	protected.GET("/export/historical", a.handleHistoricalExport)
 
-	// System info and hardware endpoints (protected, unless disabled)
+	// System info, hardware, process and SMART endpoints (protected, unless disabled)
 	if !a.config.DisableSystemMetrics {
 		protected.GET("/system/info", a.handleSystemInfo)
 		protected.GET("/system/hardware", a.handleHardwareStats)
+		protected.GET("/system/processes", a.handleProcesses)
+		protected.GET("/system/smart", a.handleSmart)
 	}
 
 	// Peak stats endpoint (protected)
@@ -52,20 +55,38 @@ func (a *Agent) setupRoutes() *gin.Engine {
 	// Tailscale status endpoint (protected)
 	protected.GET("/tailscale/status", a.handleTailscaleStatus)
 
+	// Agent-side probe endpoints (protected)
+	protected.GET("/probes", a.handleGetProbes)
+	protected.PUT("/probes", a.handleSetProbes)
+	protected.GET("/probes/results", a.handleProbeResults)
+
+	// Maintenance toggle (protected)
+	protected.GET("/maintenance", a.handleGetMaintenance)
+	protected.PUT("/maintenance", a.handleSetMaintenance)
+
+	// High-resolution sampling control (protected)
+	protected.POST("/sampling/boost", a.handleStartBoost)
+	protected.DELETE("/sampling/boost", a.handleStopBoost)
+
 	return router
 }
 
 // handleRoot handles the root endpoint
 func (a *Agent) handleRoot(c *gin.Context) {
 	endpoints := gin.H{
-		"live":       "/events?stream=live-data",
-		"historical": "/export/historical",
-		"peaks":      "/stats/peaks",
-		"tailscale":  "/tailscale/status",
+		"live":        "/events?stream=live-data",
+		"live_ws":     "/events/ws",
+		"historical":  "/export/historical",
+		"peaks":       "/stats/peaks",
+		"tailscale":   "/tailscale/status",
+		"probes":      "/probes",
+		"maintenance": "/maintenance",
 	}
 	if !a.config.DisableSystemMetrics {
 		endpoints["system"] = "/system/info"
 		endpoints["hardware"] = "/system/hardware"
+		endpoints["processes"] = "/system/processes"
+		endpoints["smart"] = "/system/smart"
 	}
 
 	response := gin.H{
@@ -99,6 +120,7 @@ func (a *Agent) handleInfo(c *gin.Context) {
 		"listening_host":  a.config.Host,
 		"listening_port":  a.config.Port,
 		"using_tailscale": usingTailscale,
+		"maintenance":     a.maintenance.Load(),
 	})
 }
 
@@ -0,0 +1,202 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// SMART health states of a disk
+const (
+	smartHealthPassed  = "passed"
+	smartHealthPrefail = "prefail"
+	smartHealthFailing = "failing"
+	smartHealthUnknown = "unknown"
+)
+
+const (
+	// smartctlTimeout bounds a single smartctl run
+	smartctlTimeout = 15 * time.Second
+
+	// ATA attributes counting remapped and unstable sectors
+	smartAttrReallocatedSectors = 5
+	smartAttrPendingSectors     = 197
+)
+
+// smartctlScan is the output of smartctl --scan -j
+type smartctlScan struct {
+	Devices []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"devices"`
+}
+
+// smartctlOutput is the part of smartctl -j -i -H -A output used for a disk's health
+type smartctlOutput struct {
+	Smartctl struct {
+		Messages []struct {
+			String string `json:"string"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+	ModelName    string `json:"model_name"`
+	SerialNumber string `json:"serial_number"`
+	SmartStatus  *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature struct {
+		Current float64 `json:"current"`
+	} `json:"temperature"`
+	ATASmartAttributes struct {
+		Table []struct {
+			ID         int    `json:"id"`
+			Name       string `json:"name"`
+			WhenFailed string `json:"when_failed"` // "now" while the value is at or below the threshold
+			Flags      struct {
+				Prefailure bool `json:"prefailure"`
+			} `json:"flags"`
+			Raw struct {
+				Value int64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NVMeHealth *struct {
+		CriticalWarning int `json:"critical_warning"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// handleSmart returns the SMART health of every disk smartctl finds
+func (a *Agent) handleSmart(c *gin.Context) {
+	stats, err := getSmartStats(c.Request.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get SMART stats")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed to get SMART stats",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// getSmartStats reads the SMART health of each disk smartctl finds. Without smartctl
+// installed the stats are returned with Available unset.
+func getSmartStats(ctx context.Context) (*SmartStats, error) {
+	stats := &SmartStats{Devices: []SmartDevice{}, UpdatedAt: time.Now()}
+	if _, err := exec.LookPath("smartctl"); err != nil {
+		return stats, nil
+	}
+	stats.Available = true
+
+	output, err := runSmartctl(ctx, "--scan", "-j")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for disks: %w", err)
+	}
+	var scan smartctlScan
+	if err := json.Unmarshal(output, &scan); err != nil {
+		return nil, fmt.Errorf("failed to parse smartctl scan: %w", err)
+	}
+
+	for _, dev := range scan.Devices {
+		// Sleeping disks are skipped rather than spun up
+		args := []string{"-j", "-i", "-H", "-A", "-n", "standby"}
+		if dev.Type != "" {
+			args = append(args, "-d", dev.Type)
+		}
+		args = append(args, dev.Name)
+
+		device := SmartDevice{Device: dev.Name, Type: dev.Type, Health: smartHealthUnknown}
+		output, err := runSmartctl(ctx, args...)
+		if err != nil {
+			device.Error = err.Error()
+			stats.Devices = append(stats.Devices, device)
+			continue
+		}
+
+		var out smartctlOutput
+		if err := json.Unmarshal(output, &out); err != nil {
+			device.Error = fmt.Sprintf("failed to parse smartctl output: %v", err)
+			stats.Devices = append(stats.Devices, device)
+			continue
+		}
+		stats.Devices = append(stats.Devices, smartDeviceFromOutput(dev.Name, dev.Type, &out))
+	}
+
+	return stats, nil
+}
+
+// runSmartctl runs smartctl and returns its JSON output. smartctl reports problems with
+// the disk in its exit status bits while still printing the data, so a non-zero exit
+// only fails when nothing was printed.
+func runSmartctl(ctx context.Context, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, smartctlTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "smartctl", args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || len(output) == 0 {
+			return nil, fmt.Errorf("smartctl failed: %w", err)
+		}
+	}
+	return output, nil
+}
+
+// smartDeviceFromOutput picks the key attributes out of smartctl's output for a disk.
+// A failed overall self-assessment or an NVMe critical warning is failing; a pre-failure
+// attribute at or below its threshold is prefail.
+func smartDeviceFromOutput(name, deviceType string, out *smartctlOutput) SmartDevice {
+	device := SmartDevice{
+		Device:      name,
+		Type:        deviceType,
+		Model:       out.ModelName,
+		Serial:      out.SerialNumber,
+		Health:      smartHealthUnknown,
+		Temperature: out.Temperature.Current,
+	}
+
+	for _, attr := range out.ATASmartAttributes.Table {
+		switch attr.ID {
+		case smartAttrReallocatedSectors:
+			device.ReallocatedSectors = attr.Raw.Value
+		case smartAttrPendingSectors:
+			device.PendingSectors = attr.Raw.Value
+		}
+		if attr.Flags.Prefailure && attr.WhenFailed == "now" {
+			device.FailingAttributes = append(device.FailingAttributes, attr.Name)
+		}
+	}
+
+	switch {
+	case out.SmartStatus != nil && !out.SmartStatus.Passed,
+		out.NVMeHealth != nil && out.NVMeHealth.CriticalWarning != 0:
+		device.Health = smartHealthFailing
+	case len(device.FailingAttributes) > 0:
+		device.Health = smartHealthPrefail
+	case out.SmartStatus != nil:
+		device.Health = smartHealthPassed
+	}
+
+	if device.Health == smartHealthUnknown {
+		device.Error = "no SMART data"
+		for _, msg := range out.Smartctl.Messages {
+			if msg.String != "" {
+				// Such as "Device is in STANDBY mode" or a permission error
+				device.Error = msg.String
+				break
+			}
+		}
+	}
+
+	return device
+}
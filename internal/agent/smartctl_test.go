@@ -0,0 +1,60 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmartDeviceFromOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   SmartDevice
+	}{
+		{
+			name: "healthy ATA disk",
+			output: `{"model_name":"WDC WD40EFRX","serial_number":"WD-123","smart_status":{"passed":true},"temperature":{"current":34},
+				"ata_smart_attributes":{"table":[
+					{"id":5,"name":"Reallocated_Sector_Ct","when_failed":"","flags":{"prefailure":true},"raw":{"value":0}},
+					{"id":197,"name":"Current_Pending_Sector","when_failed":"","flags":{"prefailure":false},"raw":{"value":2}}]}}`,
+			want: SmartDevice{Device: "/dev/sda", Type: "sat", Model: "WDC WD40EFRX", Serial: "WD-123", Health: smartHealthPassed, PendingSectors: 2, Temperature: 34},
+		},
+		{
+			name: "pre-failure attribute at threshold",
+			output: `{"smart_status":{"passed":true},
+				"ata_smart_attributes":{"table":[
+					{"id":5,"name":"Reallocated_Sector_Ct","when_failed":"now","flags":{"prefailure":true},"raw":{"value":2048}},
+					{"id":194,"name":"Temperature_Celsius","when_failed":"past","flags":{"prefailure":false},"raw":{"value":60}}]}}`,
+			want: SmartDevice{Device: "/dev/sda", Type: "sat", Health: smartHealthPrefail, ReallocatedSectors: 2048, FailingAttributes: []string{"Reallocated_Sector_Ct"}},
+		},
+		{
+			name:   "failed self-assessment",
+			output: `{"smart_status":{"passed":false},"ata_smart_attributes":{"table":[{"id":5,"name":"Reallocated_Sector_Ct","when_failed":"now","flags":{"prefailure":true},"raw":{"value":4000}}]}}`,
+			want:   SmartDevice{Device: "/dev/sda", Type: "sat", Health: smartHealthFailing, ReallocatedSectors: 4000, FailingAttributes: []string{"Reallocated_Sector_Ct"}},
+		},
+		{
+			name:   "NVMe critical warning",
+			output: `{"smart_status":{"passed":true},"temperature":{"current":51},"nvme_smart_health_information_log":{"critical_warning":4}}`,
+			want:   SmartDevice{Device: "/dev/sda", Type: "sat", Health: smartHealthFailing, Temperature: 51},
+		},
+		{
+			name:   "sleeping disk",
+			output: `{"smartctl":{"messages":[{"string":"Device is in STANDBY mode, exit(2)","severity":"information"}]}}`,
+			want:   SmartDevice{Device: "/dev/sda", Type: "sat", Health: smartHealthUnknown, Error: "Device is in STANDBY mode, exit(2)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out smartctlOutput
+			require.NoError(t, json.Unmarshal([]byte(tt.output), &out))
+			assert.Equal(t, tt.want, smartDeviceFromOutput("/dev/sda", "sat", &out))
+		})
+	}
+}
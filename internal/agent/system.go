@@ -75,45 +75,7 @@ func (a *Agent) getSystemInfo() (*SystemInfo, error) {
 	}
 
 	// Get uptime
-	switch runtime.GOOS {
-	case "linux":
-		log.Debug().Msg("Getting Linux uptime from /proc/uptime")
-		data, err := os.ReadFile("/proc/uptime")
-		if err == nil {
-			content := string(data)
-			log.Debug().Str("proc_uptime_content", content).Msg("Read /proc/uptime")
-			fields := strings.Fields(content)
-			if len(fields) > 0 {
-				uptime, parseErr := strconv.ParseFloat(fields[0], 64)
-				if parseErr == nil {
-					info.Uptime = int64(uptime)
-					log.Debug().Int64("uptime_seconds", info.Uptime).Msg("Parsed uptime")
-				} else {
-					log.Error().Err(parseErr).Str("field", fields[0]).Msg("Failed to parse uptime")
-				}
-			} else {
-				log.Error().Str("content", content).Msg("No fields in /proc/uptime")
-			}
-		} else {
-			log.Error().Err(err).Msg("Failed to read /proc/uptime")
-		}
-	case "darwin":
-		// macOS: use sysctl
-		if output, err := exec.Command("sysctl", "-n", "kern.boottime").Output(); err == nil {
-			// Parse: { sec = 1234567890, usec = 123456 }
-			str := strings.TrimSpace(string(output))
-			log.Debug().Str("sysctl_output", str).Msg("Got macOS boottime")
-			if idx := strings.Index(str, "sec = "); idx != -1 {
-				str = str[idx+6:]
-				if idx := strings.Index(str, ","); idx != -1 {
-					if sec, err := strconv.ParseInt(str[:idx], 10, 64); err == nil {
-						info.Uptime = time.Now().Unix() - sec
-						log.Debug().Int64("uptime_seconds", info.Uptime).Msg("Calculated macOS uptime")
-					}
-				}
-			}
-		}
-	}
+	info.Uptime = getUptime()
 
 	// Get vnstat version
 	cmd := exec.Command("vnstat", "--version")
@@ -247,3 +209,48 @@ func (a *Agent) getSystemInfo() (*SystemInfo, error) {
 
 	return info, nil
 }
+
+// getUptime returns the seconds since the host booted, or 0 when it can't be read
+func getUptime() int64 {
+	switch runtime.GOOS {
+	case "linux":
+		log.Debug().Msg("Getting Linux uptime from /proc/uptime")
+		data, err := os.ReadFile("/proc/uptime")
+		if err == nil {
+			content := string(data)
+			log.Debug().Str("proc_uptime_content", content).Msg("Read /proc/uptime")
+			fields := strings.Fields(content)
+			if len(fields) > 0 {
+				uptime, parseErr := strconv.ParseFloat(fields[0], 64)
+				if parseErr == nil {
+					log.Debug().Int64("uptime_seconds", int64(uptime)).Msg("Parsed uptime")
+					return int64(uptime)
+				} else {
+					log.Error().Err(parseErr).Str("field", fields[0]).Msg("Failed to parse uptime")
+				}
+			} else {
+				log.Error().Str("content", content).Msg("No fields in /proc/uptime")
+			}
+		} else {
+			log.Error().Err(err).Msg("Failed to read /proc/uptime")
+		}
+	case "darwin":
+		// macOS: use sysctl
+		if output, err := exec.Command("sysctl", "-n", "kern.boottime").Output(); err == nil {
+			// Parse: { sec = 1234567890, usec = 123456 }
+			str := strings.TrimSpace(string(output))
+			log.Debug().Str("sysctl_output", str).Msg("Got macOS boottime")
+			if idx := strings.Index(str, "sec = "); idx != -1 {
+				str = str[idx+6:]
+				if idx := strings.Index(str, ","); idx != -1 {
+					if sec, err := strconv.ParseInt(str[:idx], 10, 64); err == nil {
+						uptime := time.Now().Unix() - sec
+						log.Debug().Int64("uptime_seconds", uptime).Msg("Calculated macOS uptime")
+						return uptime
+					}
+				}
+			}
+		}
+	}
+	return 0
+}
@@ -27,6 +27,9 @@ func (a *Agent) startWithTailscale(ctx context.Context) error {
 	// Start broadcaster
 	go a.broadcaster(ctx)
 
+	// Start agent-side probes
+	go a.runProbes(ctx)
+
 	// Configure tsnet
 	hostname := a.tailscaleConfig.Hostname
 	if hostname == "" {
@@ -136,6 +139,8 @@ func (a *Agent) startWithHostTailscale(ctx context.Context) error {
 	go a.runBandwidthMonitor(ctx)
 	// Start broadcaster
 	go a.broadcaster(ctx)
+	// Start agent-side probes
+	go a.runProbes(ctx)
 
 	// Get host tailscale client
 	hostClient, err := ts.GetHostClient()
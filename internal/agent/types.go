@@ -4,7 +4,9 @@
 package agent
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"tailscale.com/tsnet"
@@ -26,13 +28,28 @@ type Agent struct {
 	peakMu          sync.RWMutex
 	tsnetServer     *tsnet.Server
 	useTailscale    bool
+	remoteProbes    []ProbeTarget            // Probes pushed by the server
+	probeResults    map[string][]ProbeResult // Recent results per host
+	probesChanged   chan struct{}
+	probesMu        sync.RWMutex
+	maintenance     atomic.Bool // Reported on /netronome/info, seeded from config
+
+	// High-resolution sampling requested by the server around speedtests
+	boosted     atomic.Bool // vnstat samples are dropped while set
+	boostCancel context.CancelFunc
+	boostGen    int
+	boostMu     sync.Mutex
 }
 
 // MonitorLiveData represents the JSON structure from vnstat --live --json
 type MonitorLiveData struct {
-	Index   int `json:"index"`
-	Seconds int `json:"seconds"`
-	Rx      struct {
+	Index     int    `json:"index"`
+	Seconds   int    `json:"seconds"`
+	Interface string `json:"interface,omitempty"` // set by the agent when it monitors a specific interface
+	// Interfaces holds the sample of each interface when the agent monitors several, the
+	// top-level rates are their sum
+	Interfaces map[string]MonitorLiveData `json:"interfaces,omitempty"`
+	Rx         struct {
 		Ratestring       string `json:"ratestring"`
 		Bytespersecond   int    `json:"bytespersecond"`
 		Packetspersecond int    `json:"packetspersecond"`
@@ -89,6 +106,7 @@ type HardwareStats struct {
 	Memory      MemoryStats        `json:"memory"`
 	Disks       []DiskStats        `json:"disks"`
 	Temperature []TemperatureStats `json:"temperature,omitempty"`
+	Uptime      int64              `json:"uptime,omitempty"` // seconds, so every collection can detect reboots
 	UpdatedAt   time.Time          `json:"updated_at"`
 }
 
@@ -100,6 +118,7 @@ type CPUStats struct {
 	Model        string    `json:"model"`
 	Frequency    float64   `json:"frequency"`          // MHz
 	LoadAvg      []float64 `json:"load_avg,omitempty"` // 1, 5, 15 min
+	PerCore      []float64 `json:"per_core,omitempty"` // usage percent per logical CPU
 }
 
 // MemoryStats represents memory usage statistics
@@ -137,3 +156,65 @@ type TemperatureStats struct {
 	Label       string  `json:"label,omitempty"`
 	Critical    float64 `json:"critical,omitempty"`
 }
+
+// SmartStats is the SMART health of the host's disks, read with smartctl
+type SmartStats struct {
+	Available bool          `json:"available"` // false when smartctl isn't installed
+	Devices   []SmartDevice `json:"devices"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// SmartDevice holds the key SMART attributes of one disk
+type SmartDevice struct {
+	Device             string   `json:"device"`         // such as /dev/sda
+	Type               string   `json:"type,omitempty"` // smartctl device type, such as sat or nvme
+	Model              string   `json:"model,omitempty"`
+	Serial             string   `json:"serial,omitempty"`
+	Health             string   `json:"health"` // passed, prefail, failing or unknown
+	ReallocatedSectors int64    `json:"reallocated_sectors"`
+	PendingSectors     int64    `json:"pending_sectors"`
+	Temperature        float64  `json:"temperature,omitempty"`        // Celsius
+	FailingAttributes  []string `json:"failing_attributes,omitempty"` // pre-failure attributes at or below their threshold
+	Error              string   `json:"error,omitempty"`              // why SMART data couldn't be read
+}
+
+// ProcessStats lists the processes using the most CPU and memory
+type ProcessStats struct {
+	TopCPU    []ProcessInfo `json:"top_cpu"`
+	TopMemory []ProcessInfo `json:"top_memory"`
+	Total     int           `json:"total"` // processes running
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// ProcessInfo represents the resource usage of a single process
+type ProcessInfo struct {
+	PID           int32   `json:"pid"`
+	Name          string  `json:"name"`
+	Username      string  `json:"username,omitempty"`
+	Command       string  `json:"command,omitempty"`
+	CPUPercent    float64 `json:"cpu_percent"` // of one core, like top
+	MemoryPercent float64 `json:"memory_percent"`
+	MemoryRSS     uint64  `json:"memory_rss"` // bytes
+}
+
+// ProbeTarget is a host the agent pings from its own vantage point
+type ProbeTarget struct {
+	Host        string `json:"host"`
+	Interval    string `json:"interval,omitempty"`
+	PacketCount int    `json:"packet_count,omitempty"`
+	Source      string `json:"source,omitempty"` // "local" or "server"
+}
+
+// ProbeResult summarizes a single probe run
+type ProbeResult struct {
+	Host        string    `json:"host"`
+	PacketLoss  float64   `json:"packet_loss"` // percent
+	MinRTT      float64   `json:"min_rtt"`     // ms
+	AvgRTT      float64   `json:"avg_rtt"`     // ms
+	MaxRTT      float64   `json:"max_rtt"`     // ms
+	StdDevRTT   float64   `json:"std_dev_rtt"` // ms
+	PacketsSent int       `json:"packets_sent"`
+	PacketsRecv int       `json:"packets_recv"`
+	Error       string    `json:"error,omitempty"`
+	MeasuredAt  time.Time `json:"measured_at"`
+}
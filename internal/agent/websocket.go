@@ -0,0 +1,70 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// wsPingInterval is how often WebSocket clients are pinged; one that doesn't answer
+	// within wsPongTimeout is disconnected
+	wsPingInterval = 15 * time.Second
+	wsPongTimeout  = 10 * time.Second
+	// wsWriteTimeout bounds sending one message to a slow client
+	wsWriteTimeout = 10 * time.Second
+)
+
+// handleWebSocket streams live data over a WebSocket, for servers behind proxies that
+// buffer SSE. Each message is the same JSON as an SSE event.
+func (a *Agent) handleWebSocket(c *gin.Context) {
+	conn, err := websocket.Accept(c.Writer, c.Request, nil)
+	if err != nil {
+		// Accept has already written the error response
+		log.Debug().Err(err).Msg("WebSocket handshake failed")
+		return
+	}
+	defer conn.CloseNow()
+
+	// The server only reads control frames; CloseRead handles them and ends ctx on close
+	ctx := conn.CloseRead(c.Request.Context())
+
+	clientChan := a.subscribe()
+	defer a.unsubscribe(clientChan)
+
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case data := <-clientChan:
+			if err := writeWebSocket(ctx, conn, data); err != nil {
+				log.Debug().Err(err).Msg("WebSocket client write failed")
+				return
+			}
+		case <-ticker.C:
+			pingCtx, cancel := context.WithTimeout(ctx, wsPongTimeout)
+			err := conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				log.Debug().Err(err).Msg("WebSocket client missed ping")
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// writeWebSocket sends one live data message as text
+func writeWebSocket(ctx context.Context, conn *websocket.Conn, data string) error {
+	ctx, cancel := context.WithTimeout(ctx, wsWriteTimeout)
+	defer cancel()
+	return conn.Write(ctx, websocket.MessageText, []byte(data))
+}
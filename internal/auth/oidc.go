@@ -42,6 +42,8 @@ type IDTokenClaims struct {
 	Name     string `json:"name"`
 	Username string `json:"preferred_username"`
 	Expiry   int64  `json:"exp"`
+	// Raw holds every claim in the token, used for role mapping
+	Raw map[string]any `json:"-"`
 }
 
 // PKCEParams holds PKCE parameters for OAuth2 flow
@@ -323,6 +325,9 @@ func (c *OIDCConfig) VerifyTokenWithClaims(ctx context.Context, token string) (*
 	if err := idToken.Claims(&claims); err != nil {
 		return nil, fmt.Errorf("failed to parse claims: %w", err)
 	}
+	if err := idToken.Claims(&claims.Raw); err != nil {
+		return nil, fmt.Errorf("failed to parse claims: %w", err)
+	}
 
 	if claims.Subject == "" {
 		return nil, errors.New("token missing subject claim")
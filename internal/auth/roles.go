@@ -0,0 +1,67 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package auth
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+// ErrRoleDenied is returned when an OIDC user has no mapped role and no default applies
+var ErrRoleDenied = errors.New("no role mapped for user")
+
+// ResolveRole maps the OIDC role claim in raw to a Netronome role. Without a
+// configured claim every user is an admin. Admin wins over viewer when several
+// groups match, unmatched users get the default role or are denied.
+func ResolveRole(raw map[string]any, cfg config.OIDCConfig) (string, error) {
+	if cfg.RoleClaim == "" {
+		return config.RoleAdmin, nil
+	}
+
+	role := ""
+	for _, group := range claimValues(raw, cfg.RoleClaim) {
+		switch cfg.RoleMapping[group] {
+		case config.RoleAdmin:
+			return config.RoleAdmin, nil
+		case config.RoleViewer:
+			role = config.RoleViewer
+		}
+	}
+	if role == "" {
+		role = cfg.DefaultRole
+	}
+	if role == "" {
+		return "", ErrRoleDenied
+	}
+	return role, nil
+}
+
+// claimValues looks up a dotted claim path such as "realm_access.roles" and
+// returns its values. Both a single string and a list of strings are accepted.
+func claimValues(raw map[string]any, claim string) []string {
+	var value any = raw
+	for _, key := range strings.Split(claim, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = obj[key]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+func TestResolveRole(t *testing.T) {
+	cfg := config.OIDCConfig{
+		RoleClaim: "groups",
+		RoleMapping: map[string]string{
+			"netronome-admins": config.RoleAdmin,
+			"staff":            config.RoleViewer,
+		},
+	}
+
+	tests := []struct {
+		name    string
+		raw     map[string]any
+		cfg     func(config.OIDCConfig) config.OIDCConfig
+		want    string
+		wantErr error
+	}{
+		{
+			name: "mapping disabled",
+			raw:  map[string]any{},
+			cfg:  func(c config.OIDCConfig) config.OIDCConfig { c.RoleClaim = ""; return c },
+			want: config.RoleAdmin,
+		},
+		{
+			name: "admin wins over viewer",
+			raw:  map[string]any{"groups": []any{"staff", "netronome-admins"}},
+			want: config.RoleAdmin,
+		},
+		{
+			name: "single string claim",
+			raw:  map[string]any{"groups": "staff"},
+			want: config.RoleViewer,
+		},
+		{
+			name: "nested claim",
+			raw:  map[string]any{"realm_access": map[string]any{"roles": []any{"netronome-admins"}}},
+			cfg:  func(c config.OIDCConfig) config.OIDCConfig { c.RoleClaim = "realm_access.roles"; return c },
+			want: config.RoleAdmin,
+		},
+		{
+			name:    "unmapped user denied",
+			raw:     map[string]any{"groups": []any{"other"}},
+			wantErr: ErrRoleDenied,
+		},
+		{
+			name: "unmapped user gets default",
+			raw:  map[string]any{},
+			cfg:  func(c config.OIDCConfig) config.OIDCConfig { c.DefaultRole = config.RoleViewer; return c },
+			want: config.RoleViewer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cfg
+			if tt.cfg != nil {
+				c = tt.cfg(c)
+			}
+			role, err := ResolveRole(tt.raw, c)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, role)
+		})
+	}
+}
@@ -4,10 +4,16 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -32,23 +38,31 @@ type DatabaseType string
 const (
 	SQLite   DatabaseType = "sqlite"
 	Postgres DatabaseType = "postgres"
+	MySQL    DatabaseType = "mysql"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Database   DatabaseConfig   `toml:"database"`
-	Server     ServerConfig     `toml:"server"`
-	Logging    LoggingConfig    `toml:"logging"`
-	Auth       AuthConfig       `toml:"auth"`
-	OIDC       OIDCConfig       `toml:"oidc"`
-	SpeedTest  SpeedTestConfig  `toml:"speedtest"`
-	GeoIP      GeoIPConfig      `toml:"geoip"`
-	Pagination PaginationConfig `toml:"pagination"`
-	Session    SessionConfig    `toml:"session"`
-	PacketLoss PacketLossConfig `toml:"packetloss"`
-	Agent      AgentConfig      `toml:"agent"`
-	Monitor    MonitorConfig    `toml:"monitor"`
-	Tailscale  TailscaleConfig  `toml:"tailscale"`
+	// DataDir is the base directory for the SQLite database, tsnet state, downloaded
+	// GeoIP databases and librespeed-servers.json, unless they are set explicitly
+	DataDir string `toml:"data_dir" env:"DATA_DIR"`
+
+	Database      DatabaseConfig      `toml:"database"`
+	Server        ServerConfig        `toml:"server"`
+	Logging       LoggingConfig       `toml:"logging"`
+	Auth          AuthConfig          `toml:"auth"`
+	OIDC          OIDCConfig          `toml:"oidc"`
+	SMTP          SMTPConfig          `toml:"smtp"`
+	Notifications NotificationsConfig `toml:"notifications"`
+	SpeedTest     SpeedTestConfig     `toml:"speedtest"`
+	GeoIP         GeoIPConfig         `toml:"geoip"`
+	Pagination    PaginationConfig    `toml:"pagination"`
+	Session       SessionConfig       `toml:"session"`
+	PacketLoss    PacketLossConfig    `toml:"packetloss"`
+	Agent         AgentConfig         `toml:"agent"`
+	Monitor       MonitorConfig       `toml:"monitor"`
+	Tailscale     TailscaleConfig     `toml:"tailscale"`
+	Telemetry     TelemetryConfig     `toml:"telemetry"`
 }
 
 type DatabaseConfig struct {
@@ -60,6 +74,18 @@ type DatabaseConfig struct {
 	DBName   string       `toml:"dbname" env:"DB_NAME"`
 	SSLMode  string       `toml:"sslmode" env:"DB_SSLMODE"`
 	Path     string       `toml:"path" env:"DB_PATH"`
+
+	SQLite SQLiteConfig `toml:"sqlite"`
+}
+
+// SQLiteConfig tunes the SQLite connections and is ignored by the other backends. Each
+// setting is applied as a PRAGMA on every connection; an empty synchronous or a zero
+// cache size keeps SQLite's own default.
+type SQLiteConfig struct {
+	BusyTimeout string `toml:"busy_timeout" env:"DB_SQLITE_BUSY_TIMEOUT"` // How long a write waits for a lock before failing with "database is locked"
+	JournalMode string `toml:"journal_mode" env:"DB_SQLITE_JOURNAL_MODE"` // delete, truncate, persist, memory, wal or off
+	Synchronous string `toml:"synchronous" env:"DB_SQLITE_SYNCHRONOUS"`   // off, normal, full or extra
+	CacheSize   int    `toml:"cache_size" env:"DB_SQLITE_CACHE_SIZE"`     // Pages when positive, KiB when negative
 }
 
 type ServerConfig struct {
@@ -67,10 +93,63 @@ type ServerConfig struct {
 	Port    int    `toml:"port" env:"PORT"`
 	BaseURL string `toml:"base_url" env:"BASE_URL"`
 	GinMode string `toml:"gin_mode" env:"GIN_MODE"`
+	// MetricsEnabled serves Prometheus metrics on <base_url>/metrics without authentication
+	MetricsEnabled bool `toml:"metrics_enabled" env:"METRICS_ENABLED"`
+
+	// HTTP server timeouts as durations, "0" disables one. The write timeout has to
+	// cover a speed test run from the API, which answers when the test ends.
+	ReadTimeout       string `toml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	ReadHeaderTimeout string `toml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	WriteTimeout      string `toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout       string `toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	// MaxBodyBytes caps the size of API request bodies, 0 disables the limit
+	MaxBodyBytes int64 `toml:"max_body_bytes" env:"SERVER_MAX_BODY_BYTES"`
+
+	RateLimit RateLimitConfig `toml:"ratelimit"`
+	CORS      CORSConfig      `toml:"cors"`
+}
+
+// DefaultMaxBodyBytes is the default cap on API request bodies, far above any JSON the API takes
+const DefaultMaxBodyBytes = 1 << 20
+
+// RateLimitConfig limits requests per client IP with a token bucket that refills at
+// RequestsPerSecond and holds up to Burst requests. Networks in auth.whitelist are exempt.
+type RateLimitConfig struct {
+	Enabled           bool    `toml:"enabled" env:"SERVER_RATELIMIT_ENABLED"`
+	RequestsPerSecond float64 `toml:"requests_per_second" env:"SERVER_RATELIMIT_REQUESTS_PER_SECOND"`
+	Burst             int     `toml:"burst" env:"SERVER_RATELIMIT_BURST"`
+}
+
+// CORSConfig lets browsers on other origins call the API. Without allowed origins only
+// same-origin requests work. The wildcard origin "*" can't be combined with credentials.
+type CORSConfig struct {
+	AllowedOrigins   []string `toml:"allowed_origins" env:"SERVER_CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string `toml:"allowed_methods" env:"SERVER_CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string `toml:"allowed_headers" env:"SERVER_CORS_ALLOWED_HEADERS"`
+	AllowCredentials bool     `toml:"allow_credentials" env:"SERVER_CORS_ALLOW_CREDENTIALS"`
+}
+
+// Enabled reports whether any cross-origin requests are allowed
+func (c CORSConfig) Enabled() bool {
+	return len(c.AllowedOrigins) > 0
+}
+
+// corsMethods are the methods server.cors.allowed_methods may list
+var corsMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// validateCORSOrigin checks that origin is written the way browsers send it in the
+// Origin header: an http(s) scheme and host, with no path or trailing slash
+func validateCORSOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil || !isHTTPURL(origin) || u.User != nil || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid origin %q, expected scheme://host[:port] such as https://app.example.com", origin)
+	}
+	return nil
 }
 
 type LoggingConfig struct {
-	Level string `toml:"level" env:"LOG_LEVEL"`
+	Level      string `toml:"level" env:"LOG_LEVEL"`
+	SampleRate int    `toml:"sample_rate" env:"LOG_SAMPLE_RATE"` // log 1 in N per-packet/per-hop debug events
 }
 
 type AuthConfig struct {
@@ -83,24 +162,229 @@ type OIDCConfig struct {
 	ClientSecret string   `toml:"client_secret" env:"OIDC_CLIENT_SECRET"`
 	RedirectURL  string   `toml:"redirect_url" env:"OIDC_REDIRECT_URL"`
 	Scopes       []string `toml:"scopes" env:"OIDC_SCOPES"`
+	// RoleClaim names the ID token claim holding the user's groups, such as "groups" or
+	// "realm_access.roles". Empty disables role mapping and every OIDC user is an admin.
+	RoleClaim string `toml:"role_claim" env:"OIDC_ROLE_CLAIM"`
+	// RoleMapping maps claim values to Netronome roles (admin or viewer), the highest matching role wins
+	RoleMapping map[string]string `toml:"role_mapping" env:"OIDC_ROLE_MAPPING"`
+	// DefaultRole is given to users without a mapped group, empty denies them access
+	DefaultRole string `toml:"default_role" env:"OIDC_DEFAULT_ROLE"`
+}
+
+// Netronome roles assignable through OIDC role mapping
+const (
+	RoleAdmin  = "admin"
+	RoleViewer = "viewer"
+)
+
+// SMTPConfig configures email notifications, sent in addition to the notification
+// channels whenever a notification rule fires. Email is disabled while Host is empty.
+type SMTPConfig struct {
+	Host     string   `toml:"host" env:"SMTP_HOST"`
+	Port     int      `toml:"port" env:"SMTP_PORT"`
+	Username string   `toml:"username" env:"SMTP_USERNAME"`
+	Password string   `toml:"password" env:"SMTP_PASSWORD"`
+	From     string   `toml:"from" env:"SMTP_FROM"`
+	To       []string `toml:"to" env:"SMTP_TO" envSeparator:","`
+	TLS      string   `toml:"tls" env:"SMTP_TLS"` // starttls, tls (implicit) or none
+}
+
+// SMTP TLS modes
+const (
+	SMTPTLSStartTLS = "starttls"
+	SMTPTLSImplicit = "tls"
+	SMTPTLSNone     = "none"
+)
+
+// TelemetryConfig exports OpenTelemetry traces of speed tests, traceroutes, MTR runs and
+// database queries to an OTLP collector. Tracing is off unless Enabled is set.
+type TelemetryConfig struct {
+	Enabled bool `toml:"enabled" env:"TELEMETRY_ENABLED"`
+	// Endpoint is the collector URL, such as http://localhost:4317. Empty falls back to
+	// OTEL_EXPORTER_OTLP_ENDPOINT, then to the exporter's localhost default.
+	Endpoint    string            `toml:"endpoint" env:"TELEMETRY_ENDPOINT"`
+	Protocol    string            `toml:"protocol" env:"TELEMETRY_PROTOCOL"` // grpc or http
+	Headers     map[string]string `toml:"headers"`                           // sent with every export, e.g. for collector auth
+	ServiceName string            `toml:"service_name" env:"TELEMETRY_SERVICE_NAME"`
+	SampleRatio float64           `toml:"sample_ratio" env:"TELEMETRY_SAMPLE_RATIO"` // share of traces kept, 0 to 1
+}
+
+// OTLP export protocols
+const (
+	TelemetryProtocolGRPC = "grpc"
+	TelemetryProtocolHTTP = "http"
+)
+
+// NotificationsConfig configures how notification rules are delivered
+type NotificationsConfig struct {
+	QuietHours QuietHoursConfig `toml:"quiet_hours"`
+}
+
+// QuietHoursConfig is a daily window, e.g. a backup window, during which agent and
+// packet loss notifications are logged as suppressed instead of sent. Recovery events
+// are always sent. Quiet hours are disabled while Start and End are empty.
+type QuietHoursConfig struct {
+	Start    string `toml:"start" env:"NOTIFICATIONS_QUIET_HOURS_START"` // HH:MM
+	End      string `toml:"end" env:"NOTIFICATIONS_QUIET_HOURS_END"`     // HH:MM, before Start for overnight windows
+	Timezone string `toml:"timezone" env:"NOTIFICATIONS_QUIET_HOURS_TIMEZONE"`
+	// Weekdays limits the window to the days it starts on, e.g. ["sat", "sun"]; empty means every day
+	Weekdays []string `toml:"weekdays" env:"NOTIFICATIONS_QUIET_HOURS_WEEKDAYS" envSeparator:","`
+}
+
+// Enabled reports whether a quiet hours window is configured
+func (q QuietHoursConfig) Enabled() bool {
+	return q.Start != "" || q.End != ""
+}
+
+// QuietHoursTimeFormat is the layout of quiet hours start and end times
+const QuietHoursTimeFormat = "15:04"
+
+// ParseWeekday parses a weekday name such as "mon" or "Monday"
+func ParseWeekday(name string) (time.Weekday, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) >= 3 {
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if full := strings.ToLower(d.String()); name == full || name == full[:3] {
+				return d, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday %q", name)
 }
 
 type SpeedTestConfig struct {
 	IPerf      IperfConfig      `toml:"iperf"`
 	Librespeed LibrespeedConfig `toml:"librespeed"`
+	Ookla      OoklaConfig      `toml:"ookla"`
+	Traceroute TracerouteConfig `toml:"traceroute"`
 	Timeout    int              `toml:"timeout" env:"SPEEDTEST_TIMEOUT"`
+	// TracerouteMaxIPs is how many resolved IPs of a destination are traced (1 traces only the first)
+	TracerouteMaxIPs int `toml:"traceroute_max_ips" env:"SPEEDTEST_TRACEROUTE_MAX_IPS"`
+	// MTUProbeHost is probed for the path MTU when the test has no server host (speedtest.net)
+	MTUProbeHost string `toml:"mtu_probe_host" env:"SPEEDTEST_MTU_PROBE_HOST"`
+	// MaxConcurrent is how many speedtests may run at once; further tests wait in a queue
+	MaxConcurrent int `toml:"max_concurrent" env:"SPEEDTEST_MAX_CONCURRENT"`
+	// Retries is how many times a failed scheduled test is run again before it counts as
+	// failed. The wait before each retry starts at RetryBackoff and doubles every time.
+	Retries      int    `toml:"retries" env:"SPEEDTEST_RETRIES"`
+	RetryBackoff string `toml:"retry_backoff" env:"SPEEDTEST_RETRY_BACKOFF"`
+	// SourceInterface and SourceIP make speed tests and packet loss probes leave through
+	// one interface or local address on multi-homed hosts. An interface binds to its
+	// address; with both set, SourceIP must belong to SourceInterface.
+	SourceInterface string `toml:"source_interface" env:"SPEEDTEST_SOURCE_INTERFACE"`
+	SourceIP        string `toml:"source_ip" env:"SPEEDTEST_SOURCE_IP"`
+	// UserAgent is sent with librespeed and speedtest.net HTTP requests, empty keeps each client's default
+	UserAgent string `toml:"user_agent" env:"SPEEDTEST_USER_AGENT"`
 }
 
+// MaxSpeedTestRetries caps speedtest.retries, the last wait is RetryBackoff * 2^(retries-1)
+const MaxSpeedTestRetries = 5
+
 type IperfConfig struct {
-	TestDuration  int        `toml:"test_duration" env:"IPERF_TEST_DURATION"`
-	ParallelConns int        `toml:"parallel_conns" env:"IPERF_PARALLEL_CONNS"`
-	Timeout       int        `toml:"timeout" env:"IPERF_TIMEOUT"`
+	TestDuration  int    `toml:"test_duration" env:"IPERF_TEST_DURATION"`
+	ParallelConns int    `toml:"parallel_conns" env:"IPERF_PARALLEL_CONNS"`
+	Timeout       int    `toml:"timeout" env:"IPERF_TIMEOUT"`
+	Protocol      string `toml:"protocol" env:"IPERF_PROTOCOL"`
+	// Bitrate is the iperf3 target bitrate such as "100M", empty uses the iperf3 default
+	Bitrate string `toml:"bitrate" env:"IPERF_BITRATE"`
+	// Reverse only runs reverse mode (-R) tests, measuring the server to client download
+	Reverse bool `toml:"reverse" env:"IPERF_REVERSE"`
+	// Bidirectional measures download and upload at the same time with iperf3 --bidir
+	Bidirectional bool       `toml:"bidirectional" env:"IPERF_BIDIRECTIONAL"`
 	Ping          PingConfig `toml:"ping"`
 }
 
+// iperf3 test protocols
+const (
+	IperfProtocolTCP = "tcp"
+	IperfProtocolUDP = "udp"
+)
+
+// iperfBitratePattern matches the iperf3 -b syntax, e.g. 100M, 1.5G or 10M/100
+var iperfBitratePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?[KMGkmg]?(/[0-9]+)?$`)
+
+// DefaultLibrespeedTimeout replaces a missing, zero or negative librespeed timeout (seconds)
+const DefaultLibrespeedTimeout = 60
+
+// LibrespeedConfig selects the self-hosted librespeed servers. Inline servers are used
+// when set, then the list at ServersURL, then librespeed-servers.json next to the
+// config file.
 type LibrespeedConfig struct {
-	ServersPath string `toml:"-"`
-	Timeout     int    `toml:"timeout" env:"LIBRESPEED_TIMEOUT"`
+	ServersPath string             `toml:"-"`
+	ServersURL  string             `toml:"servers_url" env:"LIBRESPEED_SERVERS_URL"`
+	Servers     []LibrespeedServer `toml:"servers"`
+	Timeout     int                `toml:"timeout" env:"LIBRESPEED_TIMEOUT"`
+}
+
+// LibrespeedServer is a self-hosted librespeed backend in the librespeed-cli server
+// list format
+type LibrespeedServer struct {
+	ID       int    `toml:"id" json:"id"`
+	Name     string `toml:"name" json:"name"`
+	Server   string `toml:"server" json:"server"`
+	DlURL    string `toml:"dl_url" json:"dlURL"`
+	UlURL    string `toml:"ul_url" json:"ulURL"`
+	PingURL  string `toml:"ping_url" json:"pingURL"`
+	GetIpURL string `toml:"get_ip_url" json:"getIpURL"`
+}
+
+// ValidateLibrespeedServers checks that every server has a unique positive ID, a name
+// and an http(s) server URL, which librespeed-cli needs to run a test
+func ValidateLibrespeedServers(servers []LibrespeedServer) error {
+	seen := make(map[int]bool, len(servers))
+	for i, server := range servers {
+		if server.ID <= 0 {
+			return fmt.Errorf("server %d: id must be positive, got %d", i+1, server.ID)
+		}
+		if seen[server.ID] {
+			return fmt.Errorf("server %d: duplicate id %d", i+1, server.ID)
+		}
+		seen[server.ID] = true
+		if strings.TrimSpace(server.Name) == "" {
+			return fmt.Errorf("server %d: name is required", i+1)
+		}
+		if !isHTTPURL(server.Server) {
+			return fmt.Errorf("server %d: server must be an http(s) URL, got %q", i+1, server.Server)
+		}
+	}
+	return nil
+}
+
+// isHTTPURL reports whether value is an absolute http or https URL
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// DefaultOoklaTimeout replaces a missing, zero or negative Ookla timeout (seconds)
+const DefaultOoklaTimeout = 90
+
+// OoklaConfig configures tests run through the official Ookla speedtest CLI
+type OoklaConfig struct {
+	// ServerID is the speedtest.net server used when a test doesn't pick one, empty lets the CLI choose
+	ServerID string `toml:"server_id" env:"OOKLA_SERVER_ID"`
+	// AcceptLicense accepts the Ookla license and GDPR terms on the CLI's behalf, tests fail without it
+	AcceptLicense bool `toml:"accept_license" env:"OOKLA_ACCEPT_LICENSE"`
+	Timeout       int  `toml:"timeout" env:"OOKLA_TIMEOUT"`
+}
+
+// Traceroute limits, also used as fallbacks for missing values
+const (
+	DefaultTracerouteMaxHops = 30
+	DefaultTracerouteQueries = 3
+	MaxTracerouteHops        = 64
+	MaxTracerouteQueries     = 5
+)
+
+// TracerouteConfig configures the traceroute command run for speedtest servers and monitors
+type TracerouteConfig struct {
+	// MaxHops is the maximum TTL probed, raise it for long international paths
+	MaxHops int `toml:"max_hops" env:"TRACEROUTE_MAX_HOPS"`
+	// Queries is the number of probes per hop; Windows tracert always sends 3
+	Queries int `toml:"queries" env:"TRACEROUTE_QUERIES"`
+	// Native traces with ICMP from netronome itself instead of the system traceroute binary.
+	// It needs raw sockets and falls back to the binary when they aren't permitted.
+	Native bool `toml:"native" env:"TRACEROUTE_NATIVE"`
 }
 
 type PingConfig struct {
@@ -124,6 +408,38 @@ type SessionConfig struct {
 type GeoIPConfig struct {
 	CountryDatabasePath string `toml:"country_database_path" env:"GEOIP_COUNTRY_DATABASE_PATH"`
 	ASNDatabasePath     string `toml:"asn_database_path" env:"GEOIP_ASN_DATABASE_PATH"`
+	SkipPrivateHops     bool   `toml:"skip_private_hops" env:"GEOIP_SKIP_PRIVATE_HOPS"`
+	// PrivateHopRanges lists the CIDRs skipped when SkipPrivateHops is set
+	PrivateHopRanges []string `toml:"private_hop_ranges" env:"GEOIP_PRIVATE_HOP_RANGES"`
+	// LicenseKey enables downloading EditionIDs from MaxMind into DatabaseDir instead
+	// of loading the database paths above
+	LicenseKey     string   `toml:"license_key" env:"GEOIP_LICENSE_KEY"`
+	EditionIDs     []string `toml:"edition_ids" env:"GEOIP_EDITION_IDS"`
+	DatabaseDir    string   `toml:"database_dir" env:"GEOIP_DATABASE_DIR"`
+	UpdateInterval string   `toml:"update_interval" env:"GEOIP_UPDATE_INTERVAL"`
+	// CacheSize caps the in-memory lookup cache; 0 disables it
+	CacheSize int    `toml:"cache_size" env:"GEOIP_CACHE_SIZE"`
+	CacheTTL  string `toml:"cache_ttl" env:"GEOIP_CACHE_TTL"`
+}
+
+// DefaultPrivateHopRanges returns the private, loopback, link-local, CGNAT, multicast
+// and unspecified ranges that can never match a public GeoIP record
+func DefaultPrivateHopRanges() []string {
+	return []string{
+		"10.0.0.0/8",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"224.0.0.0/4",
+		"0.0.0.0/32",
+		"fc00::/7",
+		"fe80::/10",
+		"ff00::/8",
+		"::1/128",
+		"::/128",
+	}
 }
 
 type PacketLossConfig struct {
@@ -134,23 +450,90 @@ type PacketLossConfig struct {
 	PrivilegedMode           bool `toml:"privileged_mode" env:"PACKETLOSS_PRIVILEGED_MODE"`
 	MTREnableDNS             bool `toml:"mtr_enable_dns" env:"PACKETLOSS_MTR_ENABLE_DNS"`
 	RestoreMonitorsOnStartup bool `toml:"restore_monitors_on_startup" env:"PACKETLOSS_RESTORE_MONITORS_ON_STARTUP"`
+	StaggerMonitors          bool `toml:"stagger_monitors" env:"PACKETLOSS_STAGGER_MONITORS"`
+	TimeoutMargin            int  `toml:"timeout_margin" env:"PACKETLOSS_TIMEOUT_MARGIN"`                   // seconds added to the computed ping timeout
+	CompletedStatusWindow    int  `toml:"completed_status_window" env:"PACKETLOSS_COMPLETED_STATUS_WINDOW"` // seconds a finished test is reported as completed
+	CompletedRetention       int  `toml:"completed_retention" env:"PACKETLOSS_COMPLETED_RETENTION"`         // seconds completion times are kept in memory
 }
 
 type AgentConfig struct {
 	Host                 string   `toml:"host" env:"AGENT_HOST"`
 	Port                 int      `toml:"port" env:"AGENT_PORT"`
-	Interface            string   `toml:"interface" env:"AGENT_INTERFACE"`
+	Socket               string   `toml:"socket" env:"AGENT_SOCKET"`                          // Unix socket to listen on instead of host:port
+	Interfaces           []string `toml:"interfaces" env:"AGENT_INTERFACES" envSeparator:","` // empty monitors all interfaces combined
+	Interface            string   `toml:"interface" env:"AGENT_INTERFACE"`                    // Deprecated: use Interfaces
 	APIKey               string   `toml:"api_key" env:"AGENT_API_KEY"`
 	DiskIncludes         []string `toml:"disk_includes" env:"AGENT_DISK_INCLUDES" envSeparator:","`
 	DiskExcludes         []string `toml:"disk_excludes" env:"AGENT_DISK_EXCLUDES" envSeparator:","`
 	DisableSystemMetrics bool     `toml:"disable_system_metrics" env:"AGENT_DISABLE_SYSTEM_METRICS"`
+	// Agent-side ping probes, reported to the server
+	ProbeTargets        []string `toml:"probe_targets" env:"AGENT_PROBE_TARGETS" envSeparator:","`
+	ProbeInterval       string   `toml:"probe_interval" env:"AGENT_PROBE_INTERVAL"`
+	ProbePacketCount    int      `toml:"probe_packet_count" env:"AGENT_PROBE_PACKET_COUNT"`
+	ProbePrivileged     bool     `toml:"probe_privileged" env:"AGENT_PROBE_PRIVILEGED"`
+	DisableRemoteProbes bool     `toml:"disable_remote_probes" env:"AGENT_DISABLE_REMOTE_PROBES"` // Ignore probes configured from the server
+	// Maintenance is reported on /netronome/info so the server holds back alerts while the host is being worked on
+	Maintenance bool `toml:"maintenance" env:"AGENT_MAINTENANCE"`
 }
 
 type MonitorConfig struct {
 	Enabled           bool   `toml:"enabled" env:"MONITOR_ENABLED"`
 	ReconnectInterval string `toml:"reconnect_interval" env:"MONITOR_RECONNECT_INTERVAL"`
+	MaxAgents         int    `toml:"max_agents" env:"MONITOR_MAX_AGENTS"` // 0 = unlimited
+	// ReconnectInitial, ReconnectMax and ReconnectJitter shape the backoff between attempts
+	// to reach a dropped agent. The delay starts at ReconnectInitial, or ReconnectInterval
+	// when unset, and doubles up to ReconnectMax. ReconnectJitter (0-1) spreads each wait
+	// by up to that fraction so agents that dropped together don't reconnect together.
+	ReconnectInitial string  `toml:"reconnect_initial" env:"MONITOR_RECONNECT_INITIAL"`
+	ReconnectMax     string  `toml:"reconnect_max" env:"MONITOR_RECONNECT_MAX"`
+	ReconnectJitter  float64 `toml:"reconnect_jitter" env:"MONITOR_RECONNECT_JITTER"`
+	// PerInterfaceThresholds maps an agent interface name to a bandwidth limit in Mbps (rx+tx).
+	// Interfaces without an entry use the high bandwidth notification rule's threshold.
+	PerInterfaceThresholds map[string]float64 `toml:"per_interface_thresholds" env:"MONITOR_PER_INTERFACE_THRESHOLDS"`
+	// MaintenancePauseCollection skips system info and hardware polling for agents in maintenance
+	MaintenancePauseCollection bool `toml:"maintenance_pause_collection" env:"MONITOR_MAINTENANCE_PAUSE_COLLECTION"`
+	// SpeedtestSampleInterval asks agents on the server host to sample live bandwidth this often
+	// while a speedtest runs, empty keeps their normal one second sampling
+	SpeedtestSampleInterval string `toml:"speedtest_sample_interval" env:"MONITOR_SPEEDTEST_SAMPLE_INTERVAL"`
+	// ResourceInterval, SnapshotInterval and CleanupInterval set how often hardware stats are
+	// polled, historical snapshots are taken and old monitor data is pruned. Invalid values
+	// fall back to the defaults with a warning.
+	ResourceInterval string `toml:"resource_interval" env:"MONITOR_RESOURCE_INTERVAL"`
+	SnapshotInterval string `toml:"snapshot_interval" env:"MONITOR_SNAPSHOT_INTERVAL"`
+	CleanupInterval  string `toml:"cleanup_interval" env:"MONITOR_CLEANUP_INTERVAL"`
+	// MaxIdleConnsPerHost, IdleConnTimeout and KeepAlive tune the pooled connections reused
+	// for requests to each agent. A negative keep-alive disables TCP keep-alive probes.
+	MaxIdleConnsPerHost int    `toml:"max_idle_conns_per_host" env:"MONITOR_MAX_IDLE_CONNS_PER_HOST"`
+	IdleConnTimeout     string `toml:"idle_conn_timeout" env:"MONITOR_IDLE_CONN_TIMEOUT"`
+	KeepAlive           string `toml:"keep_alive" env:"MONITOR_KEEP_ALIVE"`
+	// NotificationCooldown is the minimum time between CPU, memory, swap, disk, temperature
+	// and bandwidth alerts for the same agent. A cooldown set on a notification rule wins.
+	NotificationCooldown string `toml:"notification_cooldown" env:"MONITOR_NOTIFICATION_COOLDOWN"`
+	// Transport streams live data from agents over "sse" or "ws" (WebSocket). Agents with a
+	// ws:// or wss:// URL always use WebSocket, and agents without it fall back to SSE.
+	Transport string `toml:"transport" env:"MONITOR_TRANSPORT"`
+
+	Retention MonitorRetentionConfig `toml:"retention"`
+}
+
+// Live data transports for monitor.transport
+const (
+	MonitorTransportSSE       = "sse"
+	MonitorTransportWebSocket = "ws"
+)
+
+// MonitorRetentionConfig sets how long each class of monitor data is kept. "0" disables
+// cleanup for that class; the latest snapshot of each type per agent is always kept.
+type MonitorRetentionConfig struct {
+	ResourceStats string `toml:"resource_stats" env:"MONITOR_RETENTION_RESOURCE_STATS"`
+	Snapshots     string `toml:"snapshots" env:"MONITOR_RETENTION_SNAPSHOTS"`
+	ProbeResults  string `toml:"probe_results" env:"MONITOR_RETENTION_PROBE_RESULTS"`
 }
 
+// DefaultTailscaleStateDir is where tsnet keeps its state when neither state_dir nor
+// data_dir is set
+const DefaultTailscaleStateDir = "~/.config/netronome/tsnet"
+
 type TailscaleConfig struct {
 	// Core settings
 	Enabled bool   `toml:"enabled" env:"TAILSCALE_ENABLED"`
@@ -233,20 +616,39 @@ func New() *Config {
 			DBName:  "netronome",
 			SSLMode: "disable",
 			Path:    "netronome.db",
+			SQLite: SQLiteConfig{
+				BusyTimeout: "5s",
+				JournalMode: "wal",
+			},
 		},
 		Server: ServerConfig{
-			Host:    "127.0.0.1",
-			Port:    7575,
-			BaseURL: "/",
+			Host:              "127.0.0.1",
+			Port:              7575,
+			BaseURL:           "/",
+			ReadTimeout:       "30s",
+			ReadHeaderTimeout: "10s",
+			WriteTimeout:      "5m",
+			IdleTimeout:       "2m",
+			MaxBodyBytes:      DefaultMaxBodyBytes,
+			RateLimit: RateLimitConfig{
+				RequestsPerSecond: 10,
+				Burst:             50,
+			},
+			CORS: CORSConfig{
+				AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+				AllowedHeaders: []string{"Content-Type", "Authorization"},
+			},
 		},
 		Logging: LoggingConfig{
-			Level: "info",
+			Level:      "info",
+			SampleRate: 1,
 		},
 		SpeedTest: SpeedTestConfig{
 			IPerf: IperfConfig{
 				TestDuration:  10,
 				ParallelConns: 4,
 				Timeout:       60,
+				Protocol:      IperfProtocolTCP,
 				Ping: PingConfig{
 					Count:    5,
 					Interval: 1000,
@@ -255,9 +657,20 @@ func New() *Config {
 			},
 			Librespeed: LibrespeedConfig{
 				ServersPath: "librespeed-servers.json",
-				Timeout:     60,
+				Timeout:     DefaultLibrespeedTimeout,
+			},
+			Ookla: OoklaConfig{
+				Timeout: DefaultOoklaTimeout,
+			},
+			Traceroute: TracerouteConfig{
+				MaxHops: DefaultTracerouteMaxHops,
+				Queries: DefaultTracerouteQueries,
 			},
-			Timeout: 30,
+			Timeout:          30,
+			TracerouteMaxIPs: 1,
+			MTUProbeHost:     "1.1.1.1",
+			MaxConcurrent:    1,
+			RetryBackoff:     "30s",
 		},
 		Pagination: PaginationConfig{
 			DefaultPage:      1,
@@ -272,6 +685,13 @@ func New() *Config {
 		GeoIP: GeoIPConfig{
 			CountryDatabasePath: "",
 			ASNDatabasePath:     "",
+			SkipPrivateHops:     true,
+			PrivateHopRanges:    DefaultPrivateHopRanges(),
+			EditionIDs:          []string{"GeoLite2-Country", "GeoLite2-ASN"},
+			DatabaseDir:         "geoip",
+			UpdateInterval:      "168h",
+			CacheSize:           4096,
+			CacheTTL:            "24h",
 		},
 		PacketLoss: PacketLossConfig{
 			Enabled:                  true,
@@ -281,17 +701,46 @@ func New() *Config {
 			PrivilegedMode:           true,
 			MTREnableDNS:             false,
 			RestoreMonitorsOnStartup: false,
+			StaggerMonitors:          false,
+			TimeoutMargin:            5,
+			CompletedStatusWindow:    5,
+			CompletedRetention:       60,
+		},
+		SMTP: SMTPConfig{
+			Port: 587,
+			To:   []string{},
+			TLS:  SMTPTLSStartTLS,
 		},
 		Agent: AgentConfig{
-			Host:         "0.0.0.0",
-			Port:         8200,
-			Interface:    "",
-			DiskIncludes: []string{},
-			DiskExcludes: []string{},
+			Host:             "0.0.0.0",
+			Port:             8200,
+			Interfaces:       []string{},
+			DiskIncludes:     []string{},
+			DiskExcludes:     []string{},
+			ProbeTargets:     []string{},
+			ProbeInterval:    "1m",
+			ProbePacketCount: 10,
 		},
 		Monitor: MonitorConfig{
 			Enabled:           true,
-			ReconnectInterval: "30s",
+			ReconnectInterval: "1s",
+			ReconnectMax:      "1m",
+			ResourceInterval:  "30s",
+			SnapshotInterval:  "1h",
+			CleanupInterval:   "1h",
+
+			MaxIdleConnsPerHost: 4,
+			IdleConnTimeout:     "90s",
+			KeepAlive:           "30s",
+
+			NotificationCooldown: "1h",
+			Transport:            MonitorTransportSSE,
+
+			Retention: MonitorRetentionConfig{
+				ResourceStats: "2h",
+				Snapshots:     "1h",
+				ProbeResults:  "168h",
+			},
 		},
 		Tailscale: TailscaleConfig{
 			Enabled:           false,
@@ -299,7 +748,7 @@ func New() *Config {
 			AuthKey:           "",
 			Hostname:          "",
 			Ephemeral:         false,
-			StateDir:          "~/.config/netronome/tsnet",
+			StateDir:          DefaultTailscaleStateDir,
 			ControlURL:        "",
 			AgentPort:         8200,
 			AutoDiscover:      true,
@@ -319,30 +768,46 @@ func New() *Config {
 				DiscoveryPort:     8200,
 			},
 		},
+		Telemetry: TelemetryConfig{
+			Protocol:    TelemetryProtocolGRPC,
+			ServiceName: "netronome",
+			SampleRatio: 1,
+		},
 	}
 }
 
-// Load loads the configuration from a TOML file and environment variables
+// Load loads the configuration from a TOML file and environment variables.
+// Unknown keys, unreadable default config files and invalid environment values
+// are logged and skipped; use LoadStrict to treat them as errors.
 func Load(configPath string) (*Config, error) {
+	return load(configPath, false)
+}
+
+// LoadStrict is like Load but fails on any configuration problem instead of
+// falling back to defaults, so a misparsed setting can't go unnoticed.
+func LoadStrict(configPath string) (*Config, error) {
+	return load(configPath, true)
+}
+
+// StrictFromEnv reports whether strict config loading is requested via NETRONOME__STRICT_CONFIG
+func StrictFromEnv() bool {
+	strict, _ := strconv.ParseBool(getEnv("STRICT_CONFIG"))
+	return strict
+}
+
+func load(configPath string, strict bool) (*Config, error) {
 	cfg := New()
+	var loadedPath string
 
 	// If specific config path provided, only try that one
 	if configPath != "" {
-		if _, err := toml.DecodeFile(configPath, cfg); err != nil {
-			return nil, fmt.Errorf("failed to decode config file %s: %w", configPath, err)
-		}
-		if cfg.SpeedTest.Librespeed.Timeout == 0 {
-			cfg.SpeedTest.Librespeed.Timeout = 60
+		if err := cfg.decodeFile(configPath, strict); err != nil {
+			return nil, err
 		}
+		loadedPath = configPath
 		log.Info().
 			Str("path", configPath).
 			Msg("Loaded configuration file")
-
-		// If db path is relative, make it relative to config file
-		if !filepath.IsAbs(cfg.Database.Path) {
-			cfg.Database.Path = filepath.Join(filepath.Dir(configPath), cfg.Database.Path)
-		}
-		cfg.SpeedTest.Librespeed.ServersPath = filepath.Join(filepath.Dir(configPath), "librespeed-servers.json")
 	} else {
 		// Try each default path in order
 		found := false
@@ -357,22 +822,23 @@ func Load(configPath string) (*Config, error) {
 					Str("found_at", path).
 					Msg("Found config file")
 
-				if _, err := toml.DecodeFile(path, cfg); err == nil {
-					if cfg.SpeedTest.Librespeed.Timeout == 0 {
-						cfg.SpeedTest.Librespeed.Timeout = 60
+				if err := cfg.decodeFile(path, strict); err != nil {
+					if strict {
+						return nil, err
 					}
-					log.Info().
+					log.Warn().
+						Err(err).
 						Str("path", path).
-						Msg("Loaded configuration file")
-					found = true
-
-					// If db path is relative, make it relative to config file
-					if !filepath.IsAbs(cfg.Database.Path) {
-						cfg.Database.Path = filepath.Join(filepath.Dir(path), cfg.Database.Path)
-					}
-					cfg.SpeedTest.Librespeed.ServersPath = filepath.Join(filepath.Dir(path), "librespeed-servers.json")
-					break
+						Msg("Skipping unreadable config file")
+					cfg = New()
+					continue
 				}
+				log.Info().
+					Str("path", path).
+					Msg("Loaded configuration file")
+				loadedPath = path
+				found = true
+				break
 			}
 		}
 		if !found {
@@ -383,257 +849,1105 @@ func Load(configPath string) (*Config, error) {
 
 	// Override with environment variables
 	if err := cfg.loadFromEnv(); err != nil {
-		return nil, fmt.Errorf("failed to load from environment: %w", err)
+		if strict || errors.Is(err, ErrSecretFile) {
+			return nil, fmt.Errorf("failed to load from environment: %w", err)
+		}
+		log.Warn().Err(err).Msg("Ignoring invalid environment variables")
+	}
+
+	cfg.applyTimeoutDefaults()
+	cfg.resolvePaths(loadedPath)
+
+	if cfg.Agent.Interface != "" {
+		log.Warn().
+			Str("interface", cfg.Agent.Interface).
+			Msg("agent.interface is deprecated, use agent.interfaces instead")
+		cfg.Agent.Interfaces = cfg.Agent.MonitoredInterfaces()
+		cfg.Agent.Interface = ""
 	}
 
 	return cfg, nil
 }
 
-// ApplyEnv loads configuration from environment variables.
-// This is useful when no config file is available and you want to apply
-// environment variable overrides to a default config.
-func (c *Config) ApplyEnv() {
-	c.loadFromEnv()
+// MonitoredInterfaces returns the interfaces the agent reports separately, with the
+// deprecated single Interface folded in. Empty means all interfaces combined.
+func (a *AgentConfig) MonitoredInterfaces() []string {
+	var ifaces []string
+	for _, iface := range append([]string{a.Interface}, a.Interfaces...) {
+		if iface != "" && !slices.Contains(ifaces, iface) {
+			ifaces = append(ifaces, iface)
+		}
+	}
+	return ifaces
 }
 
-// loadFromEnv loads configuration from environment variables
-func (c *Config) loadFromEnv() error {
-	c.loadDatabaseFromEnv()
-	c.loadServerFromEnv()
-	c.loadLoggingFromEnv()
-	c.loadAuthFromEnv()
-	c.loadOIDCFromEnv()
-	c.loadSpeedTestFromEnv()
-	c.loadPaginationFromEnv()
-	c.loadSessionFromEnv()
-	c.loadGeoIPFromEnv()
-	c.loadPacketLossFromEnv()
-	c.loadAgentFromEnv()
-	c.loadMonitorFromEnv()
-	c.loadTailscaleFromEnv()
-	return nil
+// applyTimeoutDefaults coerces unusable timeouts to safe defaults once every source
+// (defaults, file and environment) has been applied
+func (c *Config) applyTimeoutDefaults() {
+	if c.SpeedTest.Librespeed.Timeout <= 0 {
+		log.Warn().
+			Int("timeout", c.SpeedTest.Librespeed.Timeout).
+			Int("default", DefaultLibrespeedTimeout).
+			Msg("Librespeed timeout must be positive, using the default")
+		c.SpeedTest.Librespeed.Timeout = DefaultLibrespeedTimeout
+	}
+	if c.SpeedTest.Ookla.Timeout <= 0 {
+		log.Warn().
+			Int("timeout", c.SpeedTest.Ookla.Timeout).
+			Int("default", DefaultOoklaTimeout).
+			Msg("Ookla timeout must be positive, using the default")
+		c.SpeedTest.Ookla.Timeout = DefaultOoklaTimeout
+	}
 }
 
-func (c *Config) loadDatabaseFromEnv() {
-	if v := getEnv("DB_TYPE"); v != "" {
-		c.Database.Type = DatabaseType(v)
+// decodeFile decodes a TOML or YAML config file into c.
+// Keys that don't map to any setting are an error in strict mode and a warning otherwise.
+func (c *Config) decodeFile(path string, strict bool) error {
+	var md toml.MetaData
+	var err error
+	if isYAMLPath(path) {
+		md, err = decodeYAMLFile(path, c)
+	} else {
+		md, err = toml.DecodeFile(path, c)
 	}
-	if v := getEnv("DB_HOST"); v != "" {
-		c.Database.Host = v
+	if err != nil {
+		return fmt.Errorf("failed to decode config file %s: %w", path, err)
 	}
-	if v := getEnv("DB_PORT"); v != "" {
-		if port, err := strconv.Atoi(v); err == nil {
-			c.Database.Port = port
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
 		}
+		if strict {
+			return fmt.Errorf("config file %s contains unknown keys: %s", path, strings.Join(keys, ", "))
+		}
+		log.Warn().
+			Str("path", path).
+			Strs("keys", keys).
+			Msg("Ignoring unknown keys in config file")
 	}
-	if v := getEnv("DB_USER"); v != "" {
-		c.Database.User = v
-	}
-	if v := getEnv("DB_PASSWORD"); v != "" {
-		c.Database.Password = v
-	}
-	if v := getEnv("DB_NAME"); v != "" {
-		c.Database.DBName = v
-	}
-	if v := getEnv("DB_SSLMODE"); v != "" {
-		c.Database.SSLMode = v
-	}
-	if v := getEnv("DB_PATH"); v != "" {
-		c.Database.Path = v
-	}
+
+	return nil
 }
 
-func (c *Config) loadServerFromEnv() {
-	if v := getEnv("HOST"); v != "" {
-		c.Server.Host = v
+// resolvePaths makes relative file paths relative to DataDir, or to the directory of
+// the loaded config file when DataDir is unset. A relative DataDir is itself relative
+// to the config file. With DataDir set, the tsnet state also moves into it unless
+// state_dir was configured.
+func (c *Config) resolvePaths(configPath string) {
+	base := ""
+	if configPath != "" {
+		base = filepath.Dir(configPath)
 	}
-	if v := getEnv("PORT"); v != "" {
-		if port, err := strconv.Atoi(v); err == nil {
-			c.Server.Port = port
+	if c.DataDir != "" {
+		if !filepath.IsAbs(c.DataDir) && base != "" {
+			c.DataDir = filepath.Join(base, c.DataDir)
+		}
+		base = c.DataDir
+
+		if c.Tailscale.StateDir == DefaultTailscaleStateDir {
+			c.Tailscale.StateDir = filepath.Join(c.DataDir, "tsnet")
 		}
 	}
-	if v := getEnv("BASE_URL"); v != "" {
-		c.Server.BaseURL = strings.Trim(v, `"'`)
+	if base == "" {
+		return
 	}
-	if v := getEnv("GIN_MODE"); v != "" {
-		c.Server.GinMode = v
+
+	if !filepath.IsAbs(c.Database.Path) {
+		c.Database.Path = filepath.Join(base, c.Database.Path)
 	}
+	if c.GeoIP.DatabaseDir != "" && !filepath.IsAbs(c.GeoIP.DatabaseDir) {
+		c.GeoIP.DatabaseDir = filepath.Join(base, c.GeoIP.DatabaseDir)
+	}
+	c.SpeedTest.Librespeed.ServersPath = filepath.Join(base, "librespeed-servers.json")
 }
 
-func (c *Config) loadLoggingFromEnv() {
-	if v := getEnv("LOG_LEVEL"); v != "" {
-		c.Logging.Level = strings.ToLower(v)
-	}
+// ValidationError is a single invalid configuration value
+type ValidationError struct {
+	Key string
+	Err error
 }
 
-func (c *Config) loadAuthFromEnv() {
-	if v := getEnv("AUTH_WHITELIST"); v != "" {
-		c.Auth.Whitelist = strings.Split(v, ",")
-	}
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Key, e.Err)
 }
 
-func (c *Config) loadOIDCFromEnv() {
-	if v := getEnv("OIDC_ISSUER"); v != "" {
-		c.OIDC.Issuer = v
-	}
-	if v := getEnv("OIDC_CLIENT_ID"); v != "" {
-		c.OIDC.ClientID = v
-	}
-	if v := getEnv("OIDC_CLIENT_SECRET"); v != "" {
-		c.OIDC.ClientSecret = v
+// ValidationErrors collects every problem found by Validate
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
 	}
-	if v := getEnv("OIDC_REDIRECT_URL"); v != "" {
-		c.OIDC.RedirectURL = v
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks values that loading alone doesn't catch. It returns
+// ValidationErrors listing every invalid key, or nil when the config is usable.
+func (c *Config) Validate() error {
+	var errs ValidationErrors
+	add := func(key string, err error) {
+		errs = append(errs, ValidationError{Key: key, Err: err})
 	}
-	if v := getEnv("OIDC_SCOPES"); v != "" {
-		scopes := strings.FieldsFunc(v, func(r rune) bool {
-			return r == ',' || r == ' '
-		})
-		c.OIDC.Scopes = nil
-		for _, scope := range scopes {
-			scope = strings.TrimSpace(scope)
-			if scope != "" {
-				c.OIDC.Scopes = append(c.OIDC.Scopes, scope)
-			}
+	checkDuration := func(key, value string) bool {
+		if value == "" {
+			return true
 		}
+		if _, err := time.ParseDuration(value); err != nil {
+			add(key, fmt.Errorf("invalid duration %q", value))
+			return false
+		}
+		return true
 	}
-}
 
-func (c *Config) loadSpeedTestFromEnv() {
-	if v := getEnv("SPEEDTEST_TIMEOUT"); v != "" {
-		if val, err := strconv.Atoi(v); err == nil {
-			c.SpeedTest.Timeout = val
+	switch c.Database.Type {
+	case SQLite:
+	case Postgres, MySQL:
+		if c.Database.Port < 1 || c.Database.Port > 65535 {
+			add("database.port", fmt.Errorf("port %d out of range 1-65535", c.Database.Port))
 		}
+	default:
+		add("database.type", fmt.Errorf("unsupported database type %q, expected %q, %q or %q", c.Database.Type, SQLite, Postgres, MySQL))
 	}
-	if v := getEnv("IPERF_TEST_DURATION"); v != "" {
-		if val, err := strconv.Atoi(v); err == nil {
-			c.SpeedTest.IPerf.TestDuration = val
+	if sqlite := c.Database.SQLite; c.Database.Type == SQLite {
+		if checkDuration("database.sqlite.busy_timeout", sqlite.BusyTimeout) && strings.HasPrefix(sqlite.BusyTimeout, "-") {
+			add("database.sqlite.busy_timeout", fmt.Errorf("must not be negative, got %q", sqlite.BusyTimeout))
 		}
-	}
-	if v := getEnv("IPERF_PARALLEL_CONNS"); v != "" {
-		if val, err := strconv.Atoi(v); err == nil {
-			c.SpeedTest.IPerf.ParallelConns = val
+		switch strings.ToLower(sqlite.JournalMode) {
+		case "", "delete", "truncate", "persist", "memory", "wal", "off":
+		default:
+			add("database.sqlite.journal_mode", fmt.Errorf("invalid journal mode %q, expected delete, truncate, persist, memory, wal or off", sqlite.JournalMode))
 		}
-	}
-	if v := getEnv("IPERF_TIMEOUT"); v != "" {
-		if val, err := strconv.Atoi(v); err == nil {
-			c.SpeedTest.IPerf.Timeout = val
+		switch strings.ToLower(sqlite.Synchronous) {
+		case "", "off", "normal", "full", "extra":
+		default:
+			add("database.sqlite.synchronous", fmt.Errorf("invalid synchronous mode %q, expected off, normal, full or extra", sqlite.Synchronous))
 		}
 	}
-	if v := getEnv("IPERF_PING_COUNT"); v != "" {
-		if val, err := strconv.Atoi(v); err == nil {
-			c.SpeedTest.IPerf.Ping.Count = val
-		}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		add("server.port", fmt.Errorf("port %d out of range 1-65535", c.Server.Port))
 	}
-	if v := getEnv("IPERF_PING_INTERVAL"); v != "" {
-		if val, err := strconv.Atoi(v); err == nil {
-			c.SpeedTest.IPerf.Ping.Interval = val
+	for _, timeout := range []struct{ key, value string }{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.read_header_timeout", c.Server.ReadHeaderTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+	} {
+		if checkDuration(timeout.key, timeout.value) && strings.HasPrefix(timeout.value, "-") {
+			add(timeout.key, fmt.Errorf("must not be negative, got %s", timeout.value))
 		}
 	}
-	if v := getEnv("IPERF_PING_TIMEOUT"); v != "" {
-		if val, err := strconv.Atoi(v); err == nil {
-			c.SpeedTest.IPerf.Ping.Timeout = val
+	if c.Server.MaxBodyBytes < 0 {
+		add("server.max_body_bytes", fmt.Errorf("must not be negative, got %d", c.Server.MaxBodyBytes))
+	}
+	if limit := c.Server.RateLimit; limit.Enabled {
+		if limit.RequestsPerSecond <= 0 {
+			add("server.ratelimit.requests_per_second", fmt.Errorf("must be positive, got %g", limit.RequestsPerSecond))
+		}
+		if limit.Burst < 1 {
+			add("server.ratelimit.burst", fmt.Errorf("must be at least 1, got %d", limit.Burst))
 		}
 	}
-	if v := getEnv("LIBRESPEED_TIMEOUT"); v != "" {
-		if val, err := strconv.Atoi(v); err == nil {
-			c.SpeedTest.Librespeed.Timeout = val
+	if cors := c.Server.CORS; cors.Enabled() {
+		for _, origin := range cors.AllowedOrigins {
+			if origin == "*" {
+				if cors.AllowCredentials {
+					add("server.cors.allowed_origins", errors.New(`wildcard origin "*" can't be used with allow_credentials, list the origins instead`))
+				}
+				continue
+			}
+			if err := validateCORSOrigin(origin); err != nil {
+				add("server.cors.allowed_origins", err)
+			}
+		}
+		if len(cors.AllowedMethods) == 0 {
+			add("server.cors.allowed_methods", errors.New("must list at least one method"))
+		}
+		for _, method := range cors.AllowedMethods {
+			if !slices.Contains(corsMethods, method) {
+				add("server.cors.allowed_methods", fmt.Errorf("invalid method %q, expected one of %s", method, strings.Join(corsMethods, ", ")))
+			}
 		}
 	}
-}
 
-func (c *Config) loadPaginationFromEnv() {
-	if v := getEnv("DEFAULT_PAGE"); v != "" {
-		if page, err := strconv.Atoi(v); err == nil {
-			c.Pagination.DefaultPage = page
+	validRole := func(role string) bool { return role == RoleAdmin || role == RoleViewer }
+	for _, group := range slices.Sorted(maps.Keys(c.OIDC.RoleMapping)) {
+		if role := c.OIDC.RoleMapping[group]; !validRole(role) {
+			add("oidc.role_mapping."+group, fmt.Errorf("invalid role %q, expected %q or %q", role, RoleAdmin, RoleViewer))
 		}
 	}
-	if v := getEnv("DEFAULT_PAGE_SIZE"); v != "" {
-		if size, err := strconv.Atoi(v); err == nil {
-			c.Pagination.DefaultPageSize = size
-		}
+	if c.OIDC.DefaultRole != "" && !validRole(c.OIDC.DefaultRole) {
+		add("oidc.default_role", fmt.Errorf("invalid role %q, expected %q, %q or empty to deny", c.OIDC.DefaultRole, RoleAdmin, RoleViewer))
 	}
-	if v := getEnv("MAX_PAGE_SIZE"); v != "" {
-		if size, err := strconv.Atoi(v); err == nil {
-			c.Pagination.MaxPageSize = size
+
+	if c.SMTP.Host != "" {
+		if c.SMTP.Port < 1 || c.SMTP.Port > 65535 {
+			add("smtp.port", fmt.Errorf("port %d out of range 1-65535", c.SMTP.Port))
+		}
+		if c.SMTP.From == "" {
+			add("smtp.from", errors.New("sender address is required"))
+		}
+		if len(c.SMTP.To) == 0 {
+			add("smtp.to", errors.New("at least one recipient is required"))
+		}
+		switch c.SMTP.TLS {
+		case SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+		default:
+			add("smtp.tls", fmt.Errorf("invalid TLS mode %q, expected %q, %q or %q", c.SMTP.TLS, SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone))
 		}
 	}
-	if v := getEnv("DEFAULT_TIME_RANGE"); v != "" {
-		c.Pagination.DefaultTimeRange = v
+
+	if quiet := c.Notifications.QuietHours; quiet.Enabled() {
+		if _, err := time.Parse(QuietHoursTimeFormat, quiet.Start); err != nil {
+			add("notifications.quiet_hours.start", fmt.Errorf("invalid time %q, expected HH:MM", quiet.Start))
+		}
+		if _, err := time.Parse(QuietHoursTimeFormat, quiet.End); err != nil {
+			add("notifications.quiet_hours.end", fmt.Errorf("invalid time %q, expected HH:MM", quiet.End))
+		} else if quiet.End == quiet.Start {
+			add("notifications.quiet_hours.end", errors.New("must differ from start"))
+		}
+		if _, err := time.LoadLocation(quiet.Timezone); err != nil {
+			add("notifications.quiet_hours.timezone", err)
+		}
+		for _, day := range quiet.Weekdays {
+			if _, err := ParseWeekday(day); err != nil {
+				add("notifications.quiet_hours.weekdays", err)
+			}
+		}
 	}
-	if v := getEnv("DEFAULT_LIMIT"); v != "" {
-		if limit, err := strconv.Atoi(v); err == nil {
-			c.Pagination.DefaultLimit = limit
+
+	for _, name := range slices.Sorted(maps.Keys(c.Monitor.PerInterfaceThresholds)) {
+		if mbps := c.Monitor.PerInterfaceThresholds[name]; mbps <= 0 {
+			add("monitor.per_interface_thresholds."+name, fmt.Errorf("threshold must be positive, got %g", mbps))
 		}
 	}
-}
 
-func (c *Config) loadSessionFromEnv() {
-	if v := getEnv("SESSION_SECRET"); v != "" {
-		c.Session.Secret = v
+	switch c.SpeedTest.IPerf.Protocol {
+	case "", IperfProtocolTCP, IperfProtocolUDP:
+	default:
+		add("speedtest.iperf.protocol", fmt.Errorf("invalid protocol %q, expected %q or %q", c.SpeedTest.IPerf.Protocol, IperfProtocolTCP, IperfProtocolUDP))
+	}
+	if c.SpeedTest.IPerf.Bitrate != "" && !iperfBitratePattern.MatchString(c.SpeedTest.IPerf.Bitrate) {
+		add("speedtest.iperf.bitrate", fmt.Errorf("invalid bitrate %q, expected a value such as 100M", c.SpeedTest.IPerf.Bitrate))
 	}
-}
 
-func (c *Config) loadGeoIPFromEnv() {
-	if v := getEnv("GEOIP_COUNTRY_DATABASE_PATH"); v != "" {
-		c.GeoIP.CountryDatabasePath = v
+	if c.SpeedTest.Librespeed.ServersURL != "" && !isHTTPURL(c.SpeedTest.Librespeed.ServersURL) {
+		add("speedtest.librespeed.servers_url", fmt.Errorf("must be an http(s) URL, got %q", c.SpeedTest.Librespeed.ServersURL))
 	}
-	if v := getEnv("GEOIP_ASN_DATABASE_PATH"); v != "" {
-		c.GeoIP.ASNDatabasePath = v
+	if err := ValidateLibrespeedServers(c.SpeedTest.Librespeed.Servers); err != nil {
+		add("speedtest.librespeed.servers", err)
 	}
-}
 
-func (c *Config) loadPacketLossFromEnv() {
-	if v := getEnv("PACKETLOSS_ENABLED"); v != "" {
+	if hops := c.SpeedTest.Traceroute.MaxHops; hops < 1 || hops > MaxTracerouteHops {
+		add("speedtest.traceroute.max_hops", fmt.Errorf("must be between 1 and %d, got %d", MaxTracerouteHops, hops))
+	}
+	if queries := c.SpeedTest.Traceroute.Queries; queries < 1 || queries > MaxTracerouteQueries {
+		add("speedtest.traceroute.queries", fmt.Errorf("must be between 1 and %d, got %d", MaxTracerouteQueries, queries))
+	}
+	if retries := c.SpeedTest.Retries; retries < 0 || retries > MaxSpeedTestRetries {
+		add("speedtest.retries", fmt.Errorf("must be between 0 and %d, got %d", MaxSpeedTestRetries, retries))
+	}
+	if checkDuration("speedtest.retry_backoff", c.SpeedTest.RetryBackoff) && c.SpeedTest.RetryBackoff != "" {
+		if d, _ := time.ParseDuration(c.SpeedTest.RetryBackoff); d <= 0 {
+			add("speedtest.retry_backoff", fmt.Errorf("must be positive, got %q", c.SpeedTest.RetryBackoff))
+		}
+	}
+	// Whether the address exists is checked when the speedtest service starts
+	if ip := c.SpeedTest.SourceIP; ip != "" && net.ParseIP(ip) == nil {
+		add("speedtest.source_ip", fmt.Errorf("invalid IP address %q", ip))
+	}
+	if strings.ContainsAny(c.SpeedTest.UserAgent, "\r\n\"") {
+		add("speedtest.user_agent", errors.New("must not contain line breaks or quotes"))
+	}
+
+	for _, cidr := range c.GeoIP.PrivateHopRanges {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			add("geoip.private_hop_ranges", fmt.Errorf("invalid CIDR %q", cidr))
+		}
+	}
+	if c.GeoIP.CacheSize < 0 {
+		add("geoip.cache_size", fmt.Errorf("must not be negative, got %d", c.GeoIP.CacheSize))
+	}
+	if checkDuration("geoip.cache_ttl", c.GeoIP.CacheTTL) && c.GeoIP.CacheTTL != "" {
+		if d, _ := time.ParseDuration(c.GeoIP.CacheTTL); d <= 0 {
+			add("geoip.cache_ttl", fmt.Errorf("must be positive, got %s", c.GeoIP.CacheTTL))
+		}
+	}
+	if c.GeoIP.LicenseKey != "" {
+		if len(c.GeoIP.EditionIDs) == 0 {
+			add("geoip.edition_ids", errors.New("at least one edition is required with a license key"))
+		}
+		for _, edition := range c.GeoIP.EditionIDs {
+			if !strings.Contains(edition, "Country") && !strings.Contains(edition, "City") && !strings.Contains(edition, "ASN") {
+				add("geoip.edition_ids", fmt.Errorf("unsupported edition %q, expected a Country, City or ASN database", edition))
+			}
+		}
+		if c.GeoIP.DatabaseDir == "" {
+			add("geoip.database_dir", errors.New("directory is required with a license key"))
+		}
+		if checkDuration("geoip.update_interval", c.GeoIP.UpdateInterval) && c.GeoIP.UpdateInterval != "" {
+			if d, _ := time.ParseDuration(c.GeoIP.UpdateInterval); d < time.Hour {
+				add("geoip.update_interval", fmt.Errorf("must be at least 1h, got %s", c.GeoIP.UpdateInterval))
+			}
+		}
+	}
+
+	if c.PacketLoss.CompletedStatusWindow < 1 {
+		add("packetloss.completed_status_window", fmt.Errorf("must be at least 1 second, got %d", c.PacketLoss.CompletedStatusWindow))
+	}
+	if c.PacketLoss.CompletedRetention < c.PacketLoss.CompletedStatusWindow {
+		add("packetloss.completed_retention", fmt.Errorf("%d seconds is shorter than completed_status_window (%d)", c.PacketLoss.CompletedRetention, c.PacketLoss.CompletedStatusWindow))
+	}
+
+	checkDuration("monitor.reconnect_interval", c.Monitor.ReconnectInterval)
+	checkDuration("monitor.reconnect_initial", c.Monitor.ReconnectInitial)
+	checkDuration("monitor.reconnect_max", c.Monitor.ReconnectMax)
+	if c.Monitor.ReconnectJitter < 0 || c.Monitor.ReconnectJitter > 1 {
+		add("monitor.reconnect_jitter", fmt.Errorf("must be between 0 and 1, got %g", c.Monitor.ReconnectJitter))
+	}
+	checkDuration("monitor.idle_conn_timeout", c.Monitor.IdleConnTimeout)
+	checkDuration("monitor.keep_alive", c.Monitor.KeepAlive)
+	if checkDuration("monitor.notification_cooldown", c.Monitor.NotificationCooldown) && c.Monitor.NotificationCooldown != "" {
+		if d, _ := time.ParseDuration(c.Monitor.NotificationCooldown); d <= 0 {
+			add("monitor.notification_cooldown", fmt.Errorf("must be positive, got %s", c.Monitor.NotificationCooldown))
+		}
+	}
+	switch c.Monitor.Transport {
+	case "", MonitorTransportSSE, MonitorTransportWebSocket:
+	default:
+		add("monitor.transport", fmt.Errorf("must be %q or %q, got %q", MonitorTransportSSE, MonitorTransportWebSocket, c.Monitor.Transport))
+	}
+	if c.Monitor.MaxIdleConnsPerHost < 0 {
+		add("monitor.max_idle_conns_per_host", fmt.Errorf("must not be negative, got %d", c.Monitor.MaxIdleConnsPerHost))
+	}
+	if checkDuration("monitor.speedtest_sample_interval", c.Monitor.SpeedtestSampleInterval) && c.Monitor.SpeedtestSampleInterval != "" {
+		if d, _ := time.ParseDuration(c.Monitor.SpeedtestSampleInterval); d < 100*time.Millisecond || d >= time.Second {
+			add("monitor.speedtest_sample_interval", fmt.Errorf("must be at least 100ms and below 1s, got %s", d))
+		}
+	}
+	for _, retention := range []struct{ key, value string }{
+		{"monitor.retention.resource_stats", c.Monitor.Retention.ResourceStats},
+		{"monitor.retention.snapshots", c.Monitor.Retention.Snapshots},
+		{"monitor.retention.probe_results", c.Monitor.Retention.ProbeResults},
+	} {
+		if checkDuration(retention.key, retention.value) && retention.value != "" {
+			if d, _ := time.ParseDuration(retention.value); d < 0 {
+				add(retention.key, fmt.Errorf("must not be negative, got %s", retention.value))
+			}
+		}
+	}
+	checkDuration("agent.probe_interval", c.Agent.ProbeInterval)
+
+	if telemetry := c.Telemetry; telemetry.Enabled {
+		if telemetry.Endpoint != "" && !isHTTPURL(telemetry.Endpoint) {
+			add("telemetry.endpoint", fmt.Errorf("invalid URL %q, expected http:// or https://", telemetry.Endpoint))
+		}
+		switch telemetry.Protocol {
+		case TelemetryProtocolGRPC, TelemetryProtocolHTTP:
+		default:
+			add("telemetry.protocol", fmt.Errorf("invalid protocol %q, expected %q or %q", telemetry.Protocol, TelemetryProtocolGRPC, TelemetryProtocolHTTP))
+		}
+		if telemetry.SampleRatio < 0 || telemetry.SampleRatio > 1 {
+			add("telemetry.sample_ratio", fmt.Errorf("must be between 0 and 1, got %g", telemetry.SampleRatio))
+		}
+	}
+
+	// Tailscale's own validation also parses the discovery interval, so only run it
+	// when that passed to avoid reporting the same key twice
+	if checkDuration("tailscale.discovery_interval", c.Tailscale.DiscoveryInterval) {
+		if err := c.Tailscale.Validate(); err != nil {
+			add("tailscale", err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ApplyEnv loads configuration from environment variables.
+// This is useful when no config file is available and you want to apply
+// environment variable overrides to a default config. Invalid variables are
+// logged and skipped, and as in Load unusable timeouts fall back to their defaults
+// and file paths move under NETRONOME__DATA_DIR.
+func (c *Config) ApplyEnv() {
+	if err := c.loadFromEnv(); err != nil {
+		log.Warn().Err(err).Msg("Ignoring invalid environment variables")
+	}
+	c.applyTimeoutDefaults()
+	c.resolvePaths("")
+}
+
+// loadFromEnv loads configuration from environment variables. Variables that are
+// set but can't be parsed keep their previous value and are returned as an error.
+func (c *Config) loadFromEnv() error {
+	var errs envErrors
+	c.loadDatabaseFromEnv(&errs)
+	c.loadServerFromEnv(&errs)
+	c.loadLoggingFromEnv(&errs)
+	c.loadAuthFromEnv(&errs)
+	c.loadOIDCFromEnv(&errs)
+	c.loadSMTPFromEnv(&errs)
+	c.loadNotificationsFromEnv()
+	c.loadSpeedTestFromEnv(&errs)
+	c.loadPaginationFromEnv(&errs)
+	c.loadSessionFromEnv(&errs)
+	c.loadGeoIPFromEnv(&errs)
+	c.loadPacketLossFromEnv(&errs)
+	c.loadAgentFromEnv(&errs)
+	c.loadMonitorFromEnv(&errs)
+	c.loadTailscaleFromEnv(&errs)
+	c.loadTelemetryFromEnv(&errs)
+	return errors.Join(errs...)
+}
+
+// ErrSecretFile is returned when a NETRONOME__*_FILE secret can't be read. Unlike other
+// environment errors it fails loading even outside strict mode, since running without
+// the intended secret is never safe.
+var ErrSecretFile = errors.New("failed to read secret file")
+
+// envErrors collects environment variables that are set but invalid
+type envErrors []error
+
+func (e *envErrors) add(key, value string, err error) {
+	if e == nil {
+		return
+	}
+	*e = append(*e, fmt.Errorf("invalid value %q for %s%s: %w", value, EnvPrefix, key, err))
+}
+
+func (e *envErrors) addSecretFile(key string, err error) {
+	if e == nil {
+		return
+	}
+	*e = append(*e, fmt.Errorf("%w for %s%s_FILE: %v", ErrSecretFile, EnvPrefix, key, err))
+}
+
+func (c *Config) loadDatabaseFromEnv(errs *envErrors) {
+	if v := getEnv("DATA_DIR"); v != "" {
+		c.DataDir = v
+	}
+	if v := getEnv("DB_TYPE"); v != "" {
+		c.Database.Type = DatabaseType(v)
+	}
+	if v := getEnv("DB_HOST"); v != "" {
+		c.Database.Host = v
+	}
+	if v := getEnv("DB_PORT"); v != "" {
+		if port, err := strconv.Atoi(v); err == nil {
+			c.Database.Port = port
+		} else {
+			errs.add("DB_PORT", v, err)
+		}
+	}
+	if v := getEnv("DB_USER"); v != "" {
+		c.Database.User = v
+	}
+	if v, _ := getSecretEnv("DB_PASSWORD", errs); v != "" {
+		c.Database.Password = v
+	}
+	if v := getEnv("DB_NAME"); v != "" {
+		c.Database.DBName = v
+	}
+	if v := getEnv("DB_SSLMODE"); v != "" {
+		c.Database.SSLMode = v
+	}
+	if v := getEnv("DB_PATH"); v != "" {
+		c.Database.Path = v
+	}
+	if v := getEnv("DB_SQLITE_BUSY_TIMEOUT"); v != "" {
+		c.Database.SQLite.BusyTimeout = v
+	}
+	if v := getEnv("DB_SQLITE_JOURNAL_MODE"); v != "" {
+		c.Database.SQLite.JournalMode = strings.ToLower(v)
+	}
+	if v := getEnv("DB_SQLITE_SYNCHRONOUS"); v != "" {
+		c.Database.SQLite.Synchronous = strings.ToLower(v)
+	}
+	if v := getEnv("DB_SQLITE_CACHE_SIZE"); v != "" {
+		if size, err := strconv.Atoi(v); err == nil {
+			c.Database.SQLite.CacheSize = size
+		} else {
+			errs.add("DB_SQLITE_CACHE_SIZE", v, err)
+		}
+	}
+}
+
+func (c *Config) loadServerFromEnv(errs *envErrors) {
+	if v := getEnv("HOST"); v != "" {
+		c.Server.Host = v
+	}
+	if v := getEnv("PORT"); v != "" {
+		if port, err := strconv.Atoi(v); err == nil {
+			c.Server.Port = port
+		} else {
+			errs.add("PORT", v, err)
+		}
+	}
+	if v := getEnv("BASE_URL"); v != "" {
+		c.Server.BaseURL = strings.Trim(v, `"'`)
+	}
+	if v := getEnv("GIN_MODE"); v != "" {
+		c.Server.GinMode = v
+	}
+	if v := getEnv("METRICS_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Server.MetricsEnabled = enabled
+		} else {
+			errs.add("METRICS_ENABLED", v, err)
+		}
+	}
+	if v := getEnv("SERVER_READ_TIMEOUT"); v != "" {
+		c.Server.ReadTimeout = v
+	}
+	if v := getEnv("SERVER_READ_HEADER_TIMEOUT"); v != "" {
+		c.Server.ReadHeaderTimeout = v
+	}
+	if v := getEnv("SERVER_WRITE_TIMEOUT"); v != "" {
+		c.Server.WriteTimeout = v
+	}
+	if v := getEnv("SERVER_IDLE_TIMEOUT"); v != "" {
+		c.Server.IdleTimeout = v
+	}
+	if v := getEnv("SERVER_MAX_BODY_BYTES"); v != "" {
+		if size, err := strconv.ParseInt(v, 10, 64); err == nil {
+			c.Server.MaxBodyBytes = size
+		} else {
+			errs.add("SERVER_MAX_BODY_BYTES", v, err)
+		}
+	}
+	if v := getEnv("SERVER_RATELIMIT_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Server.RateLimit.Enabled = enabled
+		} else {
+			errs.add("SERVER_RATELIMIT_ENABLED", v, err)
+		}
+	}
+	if v := getEnv("SERVER_RATELIMIT_REQUESTS_PER_SECOND"); v != "" {
+		if rps, err := strconv.ParseFloat(v, 64); err == nil {
+			c.Server.RateLimit.RequestsPerSecond = rps
+		} else {
+			errs.add("SERVER_RATELIMIT_REQUESTS_PER_SECOND", v, err)
+		}
+	}
+	if v := getEnv("SERVER_RATELIMIT_BURST"); v != "" {
+		if burst, err := strconv.Atoi(v); err == nil {
+			c.Server.RateLimit.Burst = burst
+		} else {
+			errs.add("SERVER_RATELIMIT_BURST", v, err)
+		}
+	}
+	if v := getEnv("SERVER_CORS_ALLOWED_ORIGINS"); v != "" {
+		c.Server.CORS.AllowedOrigins = strings.Split(v, ",")
+	}
+	if v := getEnv("SERVER_CORS_ALLOWED_METHODS"); v != "" {
+		c.Server.CORS.AllowedMethods = strings.Split(v, ",")
+	}
+	if v := getEnv("SERVER_CORS_ALLOWED_HEADERS"); v != "" {
+		c.Server.CORS.AllowedHeaders = strings.Split(v, ",")
+	}
+	if v := getEnv("SERVER_CORS_ALLOW_CREDENTIALS"); v != "" {
+		if allow, err := strconv.ParseBool(v); err == nil {
+			c.Server.CORS.AllowCredentials = allow
+		} else {
+			errs.add("SERVER_CORS_ALLOW_CREDENTIALS", v, err)
+		}
+	}
+}
+
+func (c *Config) loadLoggingFromEnv(errs *envErrors) {
+	if v := getEnv("LOG_LEVEL"); v != "" {
+		c.Logging.Level = strings.ToLower(v)
+	}
+	if v := getEnv("LOG_SAMPLE_RATE"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			c.Logging.SampleRate = val
+		} else {
+			errs.add("LOG_SAMPLE_RATE", v, err)
+		}
+	}
+}
+
+func (c *Config) loadAuthFromEnv(errs *envErrors) {
+	if v := getEnv("AUTH_WHITELIST"); v != "" {
+		c.Auth.Whitelist = strings.Split(v, ",")
+	}
+}
+
+func (c *Config) loadOIDCFromEnv(errs *envErrors) {
+	if v := getEnv("OIDC_ISSUER"); v != "" {
+		c.OIDC.Issuer = v
+	}
+	if v := getEnv("OIDC_CLIENT_ID"); v != "" {
+		c.OIDC.ClientID = v
+	}
+	if v, _ := getSecretEnv("OIDC_CLIENT_SECRET", errs); v != "" {
+		c.OIDC.ClientSecret = v
+	}
+	if v := getEnv("OIDC_REDIRECT_URL"); v != "" {
+		c.OIDC.RedirectURL = v
+	}
+	if v := getEnv("OIDC_SCOPES"); v != "" {
+		scopes := strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+		c.OIDC.Scopes = nil
+		for _, scope := range scopes {
+			scope = strings.TrimSpace(scope)
+			if scope != "" {
+				c.OIDC.Scopes = append(c.OIDC.Scopes, scope)
+			}
+		}
+	}
+	if v := getEnv("OIDC_ROLE_CLAIM"); v != "" {
+		c.OIDC.RoleClaim = v
+	}
+	if v := getEnv("OIDC_ROLE_MAPPING"); v != "" {
+		if mapping, err := parseRoleMapping(v); err == nil {
+			c.OIDC.RoleMapping = mapping
+		} else {
+			errs.add("OIDC_ROLE_MAPPING", v, err)
+		}
+	}
+	if v := getEnv("OIDC_DEFAULT_ROLE"); v != "" {
+		c.OIDC.DefaultRole = v
+	}
+}
+
+// parseRoleMapping parses "netronome-admins=admin,staff=viewer" into groups and roles
+func parseRoleMapping(v string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(entry, "=")
+		group = strings.TrimSpace(group)
+		if !ok || group == "" {
+			return nil, fmt.Errorf("expected group=role, got %q", entry)
+		}
+		mapping[group] = strings.TrimSpace(role)
+	}
+	return mapping, nil
+}
+
+func (c *Config) loadSMTPFromEnv(errs *envErrors) {
+	if v := getEnv("SMTP_HOST"); v != "" {
+		c.SMTP.Host = v
+	}
+	if v := getEnv("SMTP_PORT"); v != "" {
+		if port, err := strconv.Atoi(v); err == nil {
+			c.SMTP.Port = port
+		} else {
+			errs.add("SMTP_PORT", v, err)
+		}
+	}
+	if v := getEnv("SMTP_USERNAME"); v != "" {
+		c.SMTP.Username = v
+	}
+	if v, _ := getSecretEnv("SMTP_PASSWORD", errs); v != "" {
+		c.SMTP.Password = v
+	}
+	if v := getEnv("SMTP_FROM"); v != "" {
+		c.SMTP.From = v
+	}
+	if v := getEnv("SMTP_TO"); v != "" {
+		c.SMTP.To = nil
+		for _, to := range strings.Split(v, ",") {
+			if to = strings.TrimSpace(to); to != "" {
+				c.SMTP.To = append(c.SMTP.To, to)
+			}
+		}
+	}
+	if v := getEnv("SMTP_TLS"); v != "" {
+		c.SMTP.TLS = strings.ToLower(v)
+	}
+}
+
+func (c *Config) loadNotificationsFromEnv() {
+	if v := getEnv("NOTIFICATIONS_QUIET_HOURS_START"); v != "" {
+		c.Notifications.QuietHours.Start = v
+	}
+	if v := getEnv("NOTIFICATIONS_QUIET_HOURS_END"); v != "" {
+		c.Notifications.QuietHours.End = v
+	}
+	if v := getEnv("NOTIFICATIONS_QUIET_HOURS_TIMEZONE"); v != "" {
+		c.Notifications.QuietHours.Timezone = v
+	}
+	if v := getEnv("NOTIFICATIONS_QUIET_HOURS_WEEKDAYS"); v != "" {
+		c.Notifications.QuietHours.Weekdays = nil
+		for _, day := range strings.Split(v, ",") {
+			if day = strings.TrimSpace(day); day != "" {
+				c.Notifications.QuietHours.Weekdays = append(c.Notifications.QuietHours.Weekdays, day)
+			}
+		}
+	}
+}
+
+func (c *Config) loadSpeedTestFromEnv(errs *envErrors) {
+	if v := getEnv("SPEEDTEST_TIMEOUT"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			c.SpeedTest.Timeout = val
+		} else {
+			errs.add("SPEEDTEST_TIMEOUT", v, err)
+		}
+	}
+	if v := getEnv("SPEEDTEST_TRACEROUTE_MAX_IPS"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			c.SpeedTest.TracerouteMaxIPs = val
+		} else {
+			errs.add("SPEEDTEST_TRACEROUTE_MAX_IPS", v, err)
+		}
+	}
+	if v := getEnv("SPEEDTEST_MTU_PROBE_HOST"); v != "" {
+		c.SpeedTest.MTUProbeHost = v
+	}
+	if v := getEnv("SPEEDTEST_MAX_CONCURRENT"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			c.SpeedTest.MaxConcurrent = val
+		} else {
+			errs.add("SPEEDTEST_MAX_CONCURRENT", v, err)
+		}
+	}
+	if v := getEnv("SPEEDTEST_RETRIES"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			c.SpeedTest.Retries = val
+		} else {
+			errs.add("SPEEDTEST_RETRIES", v, err)
+		}
+	}
+	if v := getEnv("SPEEDTEST_RETRY_BACKOFF"); v != "" {
+		c.SpeedTest.RetryBackoff = v
+	}
+	if v := getEnv("SPEEDTEST_SOURCE_INTERFACE"); v != "" {
+		c.SpeedTest.SourceInterface = v
+	}
+	if v := getEnv("SPEEDTEST_SOURCE_IP"); v != "" {
+		c.SpeedTest.SourceIP = v
+	}
+	if v := getEnv("SPEEDTEST_USER_AGENT"); v != "" {
+		c.SpeedTest.UserAgent = v
+	}
+	if v := getEnv("IPERF_TEST_DURATION"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			c.SpeedTest.IPerf.TestDuration = val
+		} else {
+			errs.add("IPERF_TEST_DURATION", v, err)
+		}
+	}
+	if v := getEnv("IPERF_PARALLEL_CONNS"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			c.SpeedTest.IPerf.ParallelConns = val
+		} else {
+			errs.add("IPERF_PARALLEL_CONNS", v, err)
+		}
+	}
+	if v := getEnv("IPERF_TIMEOUT"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			c.SpeedTest.IPerf.Timeout = val
+		} else {
+			errs.add("IPERF_TIMEOUT", v, err)
+		}
+	}
+	if v := getEnv("IPERF_PING_COUNT"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			c.SpeedTest.IPerf.Ping.Count = val
+		} else {
+			errs.add("IPERF_PING_COUNT", v, err)
+		}
+	}
+	if v := getEnv("IPERF_PING_INTERVAL"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			c.SpeedTest.IPerf.Ping.Interval = val
+		} else {
+			errs.add("IPERF_PING_INTERVAL", v, err)
+		}
+	}
+	if v := getEnv("IPERF_PING_TIMEOUT"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			c.SpeedTest.IPerf.Ping.Timeout = val
+		} else {
+			errs.add("IPERF_PING_TIMEOUT", v, err)
+		}
+	}
+	if v := getEnv("IPERF_PROTOCOL"); v != "" {
+		c.SpeedTest.IPerf.Protocol = strings.ToLower(v)
+	}
+	if v := getEnv("IPERF_BITRATE"); v != "" {
+		c.SpeedTest.IPerf.Bitrate = v
+	}
+	if v := getEnv("IPERF_REVERSE"); v != "" {
+		if val, err := strconv.ParseBool(v); err == nil {
+			c.SpeedTest.IPerf.Reverse = val
+		} else {
+			errs.add("IPERF_REVERSE", v, err)
+		}
+	}
+	if v := getEnv("IPERF_BIDIRECTIONAL"); v != "" {
+		if val, err := strconv.ParseBool(v); err == nil {
+			c.SpeedTest.IPerf.Bidirectional = val
+		} else {
+			errs.add("IPERF_BIDIRECTIONAL", v, err)
+		}
+	}
+	if v := getEnv("LIBRESPEED_SERVERS_URL"); v != "" {
+		c.SpeedTest.Librespeed.ServersURL = v
+	}
+	if v := getEnv("LIBRESPEED_TIMEOUT"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			c.SpeedTest.Librespeed.Timeout = val
+		} else {
+			errs.add("LIBRESPEED_TIMEOUT", v, err)
+		}
+	}
+	if v := getEnv("OOKLA_SERVER_ID"); v != "" {
+		c.SpeedTest.Ookla.ServerID = v
+	}
+	if v := getEnv("OOKLA_ACCEPT_LICENSE"); v != "" {
+		if val, err := strconv.ParseBool(v); err == nil {
+			c.SpeedTest.Ookla.AcceptLicense = val
+		} else {
+			errs.add("OOKLA_ACCEPT_LICENSE", v, err)
+		}
+	}
+	if v := getEnv("TRACEROUTE_MAX_HOPS"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			c.SpeedTest.Traceroute.MaxHops = val
+		} else {
+			errs.add("TRACEROUTE_MAX_HOPS", v, err)
+		}
+	}
+	if v := getEnv("TRACEROUTE_QUERIES"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			c.SpeedTest.Traceroute.Queries = val
+		} else {
+			errs.add("TRACEROUTE_QUERIES", v, err)
+		}
+	}
+	if v := getEnv("TRACEROUTE_NATIVE"); v != "" {
+		if val, err := strconv.ParseBool(v); err == nil {
+			c.SpeedTest.Traceroute.Native = val
+		} else {
+			errs.add("TRACEROUTE_NATIVE", v, err)
+		}
+	}
+	if v := getEnv("OOKLA_TIMEOUT"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			c.SpeedTest.Ookla.Timeout = val
+		} else {
+			errs.add("OOKLA_TIMEOUT", v, err)
+		}
+	}
+}
+
+func (c *Config) loadPaginationFromEnv(errs *envErrors) {
+	if v := getEnv("DEFAULT_PAGE"); v != "" {
+		if page, err := strconv.Atoi(v); err == nil {
+			c.Pagination.DefaultPage = page
+		} else {
+			errs.add("DEFAULT_PAGE", v, err)
+		}
+	}
+	if v := getEnv("DEFAULT_PAGE_SIZE"); v != "" {
+		if size, err := strconv.Atoi(v); err == nil {
+			c.Pagination.DefaultPageSize = size
+		} else {
+			errs.add("DEFAULT_PAGE_SIZE", v, err)
+		}
+	}
+	if v := getEnv("MAX_PAGE_SIZE"); v != "" {
+		if size, err := strconv.Atoi(v); err == nil {
+			c.Pagination.MaxPageSize = size
+		} else {
+			errs.add("MAX_PAGE_SIZE", v, err)
+		}
+	}
+	if v := getEnv("DEFAULT_TIME_RANGE"); v != "" {
+		c.Pagination.DefaultTimeRange = v
+	}
+	if v := getEnv("DEFAULT_LIMIT"); v != "" {
+		if limit, err := strconv.Atoi(v); err == nil {
+			c.Pagination.DefaultLimit = limit
+		} else {
+			errs.add("DEFAULT_LIMIT", v, err)
+		}
+	}
+}
+
+func (c *Config) loadSessionFromEnv(errs *envErrors) {
+	if v, _ := getSecretEnv("SESSION_SECRET", errs); v != "" {
+		c.Session.Secret = v
+	}
+}
+
+func (c *Config) loadGeoIPFromEnv(errs *envErrors) {
+	if v := getEnv("GEOIP_COUNTRY_DATABASE_PATH"); v != "" {
+		c.GeoIP.CountryDatabasePath = v
+	}
+	if v := getEnv("GEOIP_ASN_DATABASE_PATH"); v != "" {
+		c.GeoIP.ASNDatabasePath = v
+	}
+	if v := getEnv("GEOIP_SKIP_PRIVATE_HOPS"); v != "" {
+		if skip, err := strconv.ParseBool(v); err == nil {
+			c.GeoIP.SkipPrivateHops = skip
+		} else {
+			errs.add("GEOIP_SKIP_PRIVATE_HOPS", v, err)
+		}
+	}
+	if v := getEnv("GEOIP_PRIVATE_HOP_RANGES"); v != "" {
+		c.GeoIP.PrivateHopRanges = nil
+		for _, cidr := range strings.Split(v, ",") {
+			if cidr = strings.TrimSpace(cidr); cidr != "" {
+				c.GeoIP.PrivateHopRanges = append(c.GeoIP.PrivateHopRanges, cidr)
+			}
+		}
+	}
+	if v, _ := getSecretEnv("GEOIP_LICENSE_KEY", errs); v != "" {
+		c.GeoIP.LicenseKey = v
+	}
+	if v := getEnv("GEOIP_EDITION_IDS"); v != "" {
+		c.GeoIP.EditionIDs = nil
+		for _, edition := range strings.Split(v, ",") {
+			if edition = strings.TrimSpace(edition); edition != "" {
+				c.GeoIP.EditionIDs = append(c.GeoIP.EditionIDs, edition)
+			}
+		}
+	}
+	if v := getEnv("GEOIP_DATABASE_DIR"); v != "" {
+		c.GeoIP.DatabaseDir = v
+	}
+	if v := getEnv("GEOIP_UPDATE_INTERVAL"); v != "" {
+		if _, err := time.ParseDuration(v); err == nil {
+			c.GeoIP.UpdateInterval = v
+		} else {
+			errs.add("GEOIP_UPDATE_INTERVAL", v, err)
+		}
+	}
+	if v := getEnv("GEOIP_CACHE_SIZE"); v != "" {
+		if size, err := strconv.Atoi(v); err == nil {
+			c.GeoIP.CacheSize = size
+		} else {
+			errs.add("GEOIP_CACHE_SIZE", v, err)
+		}
+	}
+	if v := getEnv("GEOIP_CACHE_TTL"); v != "" {
+		if _, err := time.ParseDuration(v); err == nil {
+			c.GeoIP.CacheTTL = v
+		} else {
+			errs.add("GEOIP_CACHE_TTL", v, err)
+		}
+	}
+}
+
+func (c *Config) loadPacketLossFromEnv(errs *envErrors) {
+	if v := getEnv("PACKETLOSS_ENABLED"); v != "" {
 		if enabled, err := strconv.ParseBool(v); err == nil {
 			c.PacketLoss.Enabled = enabled
+		} else {
+			errs.add("PACKETLOSS_ENABLED", v, err)
 		}
 	}
 	if v := getEnv("PACKETLOSS_DEFAULT_INTERVAL"); v != "" {
 		if interval, err := strconv.Atoi(v); err == nil {
 			c.PacketLoss.DefaultInterval = interval
+		} else {
+			errs.add("PACKETLOSS_DEFAULT_INTERVAL", v, err)
 		}
 	}
 	if v := getEnv("PACKETLOSS_DEFAULT_PACKET_COUNT"); v != "" {
 		if count, err := strconv.Atoi(v); err == nil {
 			c.PacketLoss.DefaultPacketCount = count
+		} else {
+			errs.add("PACKETLOSS_DEFAULT_PACKET_COUNT", v, err)
 		}
 	}
 	if v := getEnv("PACKETLOSS_MAX_CONCURRENT_MONITORS"); v != "" {
 		if max, err := strconv.Atoi(v); err == nil {
 			c.PacketLoss.MaxConcurrentMonitors = max
+		} else {
+			errs.add("PACKETLOSS_MAX_CONCURRENT_MONITORS", v, err)
 		}
 	}
 	if v := getEnv("PACKETLOSS_PRIVILEGED_MODE"); v != "" {
 		if privileged, err := strconv.ParseBool(v); err == nil {
 			c.PacketLoss.PrivilegedMode = privileged
+		} else {
+			errs.add("PACKETLOSS_PRIVILEGED_MODE", v, err)
 		}
 	}
 	if v := getEnv("PACKETLOSS_MTR_ENABLE_DNS"); v != "" {
 		if enableDNS, err := strconv.ParseBool(v); err == nil {
 			c.PacketLoss.MTREnableDNS = enableDNS
+		} else {
+			errs.add("PACKETLOSS_MTR_ENABLE_DNS", v, err)
 		}
 	}
 	if v := getEnv("PACKETLOSS_RESTORE_MONITORS_ON_STARTUP"); v != "" {
 		if restore, err := strconv.ParseBool(v); err == nil {
 			c.PacketLoss.RestoreMonitorsOnStartup = restore
+		} else {
+			errs.add("PACKETLOSS_RESTORE_MONITORS_ON_STARTUP", v, err)
+		}
+	}
+	if v := getEnv("PACKETLOSS_STAGGER_MONITORS"); v != "" {
+		if stagger, err := strconv.ParseBool(v); err == nil {
+			c.PacketLoss.StaggerMonitors = stagger
+		} else {
+			errs.add("PACKETLOSS_STAGGER_MONITORS", v, err)
+		}
+	}
+	if v := getEnv("PACKETLOSS_TIMEOUT_MARGIN"); v != "" {
+		if margin, err := strconv.Atoi(v); err == nil {
+			c.PacketLoss.TimeoutMargin = margin
+		} else {
+			errs.add("PACKETLOSS_TIMEOUT_MARGIN", v, err)
+		}
+	}
+	if v := getEnv("PACKETLOSS_COMPLETED_STATUS_WINDOW"); v != "" {
+		if window, err := strconv.Atoi(v); err == nil {
+			c.PacketLoss.CompletedStatusWindow = window
+		} else {
+			errs.add("PACKETLOSS_COMPLETED_STATUS_WINDOW", v, err)
+		}
+	}
+	if v := getEnv("PACKETLOSS_COMPLETED_RETENTION"); v != "" {
+		if retention, err := strconv.Atoi(v); err == nil {
+			c.PacketLoss.CompletedRetention = retention
+		} else {
+			errs.add("PACKETLOSS_COMPLETED_RETENTION", v, err)
 		}
 	}
 }
 
-func (c *Config) loadAgentFromEnv() {
+func (c *Config) loadAgentFromEnv(errs *envErrors) {
 	if v := getEnv("AGENT_HOST"); v != "" {
 		c.Agent.Host = v
 	}
 	if v := getEnv("AGENT_PORT"); v != "" {
 		if port, err := strconv.Atoi(v); err == nil {
 			c.Agent.Port = port
+		} else {
+			errs.add("AGENT_PORT", v, err)
+		}
+	}
+	if v := getEnv("AGENT_SOCKET"); v != "" {
+		c.Agent.Socket = v
+	}
+	if v := getEnv("AGENT_INTERFACES"); v != "" {
+		c.Agent.Interfaces = strings.Split(v, ",")
+		for i := range c.Agent.Interfaces {
+			c.Agent.Interfaces[i] = strings.TrimSpace(c.Agent.Interfaces[i])
 		}
 	}
 	if v := getEnv("AGENT_INTERFACE"); v != "" {
 		c.Agent.Interface = v
 	}
-	if v := getEnv("AGENT_API_KEY"); v != "" {
+	if v, _ := getSecretEnv("AGENT_API_KEY", errs); v != "" {
 		c.Agent.APIKey = v
 	}
 	if v := getEnv("AGENT_DISK_INCLUDES"); v != "" {
@@ -651,90 +1965,371 @@ func (c *Config) loadAgentFromEnv() {
 	if v := getEnv("AGENT_DISABLE_SYSTEM_METRICS"); v != "" {
 		if disabled, err := strconv.ParseBool(v); err == nil {
 			c.Agent.DisableSystemMetrics = disabled
+		} else {
+			errs.add("AGENT_DISABLE_SYSTEM_METRICS", v, err)
+		}
+	}
+	if v := getEnv("AGENT_PROBE_TARGETS"); v != "" {
+		c.Agent.ProbeTargets = strings.Split(v, ",")
+		for i := range c.Agent.ProbeTargets {
+			c.Agent.ProbeTargets[i] = strings.TrimSpace(c.Agent.ProbeTargets[i])
+		}
+	}
+	if v := getEnv("AGENT_PROBE_INTERVAL"); v != "" {
+		if _, err := time.ParseDuration(v); err == nil {
+			c.Agent.ProbeInterval = v
+		} else {
+			errs.add("AGENT_PROBE_INTERVAL", v, err)
+		}
+	}
+	if v := getEnv("AGENT_PROBE_PACKET_COUNT"); v != "" {
+		if count, err := strconv.Atoi(v); err == nil {
+			c.Agent.ProbePacketCount = count
+		} else {
+			errs.add("AGENT_PROBE_PACKET_COUNT", v, err)
+		}
+	}
+	if v := getEnv("AGENT_PROBE_PRIVILEGED"); v != "" {
+		if privileged, err := strconv.ParseBool(v); err == nil {
+			c.Agent.ProbePrivileged = privileged
+		} else {
+			errs.add("AGENT_PROBE_PRIVILEGED", v, err)
+		}
+	}
+	if v := getEnv("AGENT_DISABLE_REMOTE_PROBES"); v != "" {
+		if disabled, err := strconv.ParseBool(v); err == nil {
+			c.Agent.DisableRemoteProbes = disabled
+		} else {
+			errs.add("AGENT_DISABLE_REMOTE_PROBES", v, err)
+		}
+	}
+	if v := getEnv("AGENT_MAINTENANCE"); v != "" {
+		if maintenance, err := strconv.ParseBool(v); err == nil {
+			c.Agent.Maintenance = maintenance
+		} else {
+			errs.add("AGENT_MAINTENANCE", v, err)
+		}
+	}
+}
+
+func (c *Config) loadMonitorFromEnv(errs *envErrors) {
+	if v := getEnv("MONITOR_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Monitor.Enabled = enabled
+		} else {
+			errs.add("MONITOR_ENABLED", v, err)
+		}
+	}
+	if v := getEnv("MONITOR_RECONNECT_INTERVAL"); v != "" {
+		c.Monitor.ReconnectInterval = v
+	}
+	if v := getEnv("MONITOR_RECONNECT_INITIAL"); v != "" {
+		c.Monitor.ReconnectInitial = v
+	}
+	if v := getEnv("MONITOR_RECONNECT_MAX"); v != "" {
+		c.Monitor.ReconnectMax = v
+	}
+	if v := getEnv("MONITOR_RECONNECT_JITTER"); v != "" {
+		if jitter, err := strconv.ParseFloat(v, 64); err == nil {
+			c.Monitor.ReconnectJitter = jitter
+		} else {
+			errs.add("MONITOR_RECONNECT_JITTER", v, err)
+		}
+	}
+	if v := getEnv("MONITOR_RESOURCE_INTERVAL"); v != "" {
+		c.Monitor.ResourceInterval = v
+	}
+	if v := getEnv("MONITOR_SNAPSHOT_INTERVAL"); v != "" {
+		c.Monitor.SnapshotInterval = v
+	}
+	if v := getEnv("MONITOR_CLEANUP_INTERVAL"); v != "" {
+		c.Monitor.CleanupInterval = v
+	}
+	if v := getEnv("MONITOR_RETENTION_RESOURCE_STATS"); v != "" {
+		c.Monitor.Retention.ResourceStats = v
+	}
+	if v := getEnv("MONITOR_RETENTION_SNAPSHOTS"); v != "" {
+		c.Monitor.Retention.Snapshots = v
+	}
+	if v := getEnv("MONITOR_RETENTION_PROBE_RESULTS"); v != "" {
+		c.Monitor.Retention.ProbeResults = v
+	}
+	if v := getEnv("MONITOR_MAX_IDLE_CONNS_PER_HOST"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			c.Monitor.MaxIdleConnsPerHost = n
+		} else {
+			errs.add("MONITOR_MAX_IDLE_CONNS_PER_HOST", v, err)
+		}
+	}
+	if v := getEnv("MONITOR_IDLE_CONN_TIMEOUT"); v != "" {
+		c.Monitor.IdleConnTimeout = v
+	}
+	if v := getEnv("MONITOR_KEEP_ALIVE"); v != "" {
+		c.Monitor.KeepAlive = v
+	}
+	if v := getEnv("MONITOR_NOTIFICATION_COOLDOWN"); v != "" {
+		c.Monitor.NotificationCooldown = v
+	}
+	if v := getEnv("MONITOR_TRANSPORT"); v != "" {
+		c.Monitor.Transport = v
+	}
+	if v := getEnv("MONITOR_MAX_AGENTS"); v != "" {
+		if max, err := strconv.Atoi(v); err == nil {
+			c.Monitor.MaxAgents = max
+		} else {
+			errs.add("MONITOR_MAX_AGENTS", v, err)
 		}
 	}
-}
-
-func (c *Config) loadMonitorFromEnv() {
-	if v := getEnv("MONITOR_ENABLED"); v != "" {
-		if enabled, err := strconv.ParseBool(v); err == nil {
-			c.Monitor.Enabled = enabled
-		}
+	if v := getEnv("MONITOR_PER_INTERFACE_THRESHOLDS"); v != "" {
+		if thresholds, err := parseInterfaceThresholds(v); err == nil {
+			c.Monitor.PerInterfaceThresholds = thresholds
+		} else {
+			errs.add("MONITOR_PER_INTERFACE_THRESHOLDS", v, err)
+		}
+	}
+	if v := getEnv("MONITOR_MAINTENANCE_PAUSE_COLLECTION"); v != "" {
+		if pause, err := strconv.ParseBool(v); err == nil {
+			c.Monitor.MaintenancePauseCollection = pause
+		} else {
+			errs.add("MONITOR_MAINTENANCE_PAUSE_COLLECTION", v, err)
+		}
+	}
+	if v := getEnv("MONITOR_SPEEDTEST_SAMPLE_INTERVAL"); v != "" {
+		c.Monitor.SpeedtestSampleInterval = v
+	}
+}
+
+// parseInterfaceThresholds parses "eth1=900,eth0=500" into interface names and Mbps limits
+func parseInterfaceThresholds(v string) (map[string]float64, error) {
+	thresholds := make(map[string]float64)
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, limit, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("expected interface=mbps, got %q", entry)
+		}
+		mbps, err := strconv.ParseFloat(strings.TrimSpace(limit), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold for %s: %w", name, err)
+		}
+		thresholds[name] = mbps
+	}
+	return thresholds, nil
+}
+
+func (c *Config) loadTailscaleFromEnv(errs *envErrors) {
+	c.Tailscale.loadFromEnv(errs)
+}
+
+// loadTelemetryFromEnv loads the OpenTelemetry tracing settings
+func (c *Config) loadTelemetryFromEnv(errs *envErrors) {
+	if v := getEnv("TELEMETRY_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Telemetry.Enabled = enabled
+		} else {
+			errs.add("TELEMETRY_ENABLED", v, err)
+		}
+	}
+	if v := getEnv("TELEMETRY_ENDPOINT"); v != "" {
+		c.Telemetry.Endpoint = v
+	}
+	if v := getEnv("TELEMETRY_PROTOCOL"); v != "" {
+		c.Telemetry.Protocol = strings.ToLower(v)
+	}
+	if v := getEnv("TELEMETRY_SERVICE_NAME"); v != "" {
+		c.Telemetry.ServiceName = v
+	}
+	if v := getEnv("TELEMETRY_SAMPLE_RATIO"); v != "" {
+		if ratio, err := strconv.ParseFloat(v, 64); err == nil {
+			c.Telemetry.SampleRatio = ratio
+		} else {
+			errs.add("TELEMETRY_SAMPLE_RATIO", v, err)
+		}
+	}
+}
+
+// generatedConfig returns the defaults written by generate-config, with a fresh session secret
+func generatedConfig() (*Config, error) {
+	cfg := New()
+	cfg.Database.Path = "netronome.db"
+
+	secret, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session secret: %w", err)
+	}
+	cfg.Session.Secret = secret
+
+	if isRunningInContainer() {
+		cfg.Server.Host = "0.0.0.0"
+	}
+	return cfg, nil
+}
+
+func (c *Config) WriteToml(w io.Writer) error {
+	cfg, err := generatedConfig()
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(w, "# Netronome Configuration"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, ""); err != nil {
+		return err
+	}
+
+	// Base directory for the database, tsnet state and GeoIP downloads (commented out)
+	if _, err := fmt.Fprintln(w, "# Keep all data in one directory, e.g. a single container volume. Relative paths"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "# below resolve against it instead of the config file's directory."); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "# data_dir = \"%s\"\n", cfg.DataDir); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, ""); err != nil {
+		return err
+	}
+
+	// Database section
+	if _, err := fmt.Fprintln(w, "[database]"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "type = \"%s\"\n", cfg.Database.Type); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "path = \"%s\"\n", cfg.Database.Path); err != nil {
+		return err
+	}
+	// Postgres options (commented out)
+	if _, err := fmt.Fprintln(w, "# PostgreSQL/MySQL options (uncomment and modify if using postgres or mysql)"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#host = \"%s\"\n", cfg.Database.Host); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#port = %d\n", cfg.Database.Port); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#user = \"%s\"\n", cfg.Database.User); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#password = \"%s\"\n", cfg.Database.Password); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#dbname = \"%s\"\n", cfg.Database.DBName); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#sslmode = \"%s\"\n\n", cfg.Database.SSLMode); err != nil {
+		return err
+	}
+
+	// SQLite tuning section
+	if _, err := fmt.Fprintln(w, "# SQLite connection tuning, ignored for postgres and mysql."); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "[database.sqlite]"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "busy_timeout = \"%s\" # How long a write waits for a lock before failing with \"database is locked\"\n", cfg.Database.SQLite.BusyTimeout); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "journal_mode = \"%s\" # delete, truncate, persist, memory, wal or off\n", cfg.Database.SQLite.JournalMode); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#synchronous = \"normal\" # off, normal, full or extra; normal is safe with wal and writes faster"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#cache_size = -20000 # Pages when positive, KiB when negative"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, ""); err != nil {
+		return err
+	}
+
+	// Server section
+	if _, err := fmt.Fprintln(w, "[server]"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "host = \"%s\"\n", cfg.Server.Host); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "port = %d\n", cfg.Server.Port); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#base_url = \"%s\"\n", cfg.Server.BaseURL); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "metrics_enabled = %t\n", cfg.Server.MetricsEnabled); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "read_timeout = \"%s\" # Time to read a whole request, \"0\" disables a timeout\n", cfg.Server.ReadTimeout); err != nil {
+		return err
 	}
-	if v := getEnv("MONITOR_RECONNECT_INTERVAL"); v != "" {
-		c.Monitor.ReconnectInterval = v
+	if _, err := fmt.Fprintf(w, "read_header_timeout = \"%s\" # Time to read request headers, guards against slow clients\n", cfg.Server.ReadHeaderTimeout); err != nil {
+		return err
 	}
-}
-
-func (c *Config) loadTailscaleFromEnv() {
-	c.Tailscale.loadFromEnv()
-}
-
-func (c *Config) WriteToml(w io.Writer) error {
-	cfg := New()
-	cfg.Database.Path = "netronome.db"
-
-	secret, err := utils.GenerateSecureToken(32)
-	if err != nil {
-		return fmt.Errorf("failed to generate session secret: %w", err)
+	if _, err := fmt.Fprintf(w, "write_timeout = \"%s\" # Time to answer a request, must cover a speed test started from the API\n", cfg.Server.WriteTimeout); err != nil {
+		return err
 	}
-	cfg.Session.Secret = secret
-
-	if isRunningInContainer() {
-		cfg.Server.Host = "0.0.0.0"
+	if _, err := fmt.Fprintf(w, "idle_timeout = \"%s\" # Time an idle keep-alive connection stays open\n", cfg.Server.IdleTimeout); err != nil {
+		return err
 	}
-
-	if _, err := fmt.Fprintln(w, "# Netronome Configuration"); err != nil {
+	if _, err := fmt.Fprintf(w, "max_body_bytes = %d # Largest API request body, 0 disables the limit\n", cfg.Server.MaxBodyBytes); err != nil {
 		return err
 	}
 	if _, err := fmt.Fprintln(w, ""); err != nil {
 		return err
 	}
 
-	// Database section
-	if _, err := fmt.Fprintln(w, "[database]"); err != nil {
+	// Rate limit section
+	if _, err := fmt.Fprintln(w, "# Limit requests per client IP; clients over the limit get 429 Too Many Requests."); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintf(w, "type = \"%s\"\n", cfg.Database.Type); err != nil {
+	if _, err := fmt.Fprintln(w, "# Networks in auth.whitelist are exempt."); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintf(w, "path = \"%s\"\n", cfg.Database.Path); err != nil {
+	if _, err := fmt.Fprintln(w, "[server.ratelimit]"); err != nil {
 		return err
 	}
-	// Postgres options (commented out)
-	if _, err := fmt.Fprintln(w, "# PostgreSQL options (uncomment and modify if using postgres)"); err != nil {
+	if _, err := fmt.Fprintf(w, "enabled = %t\n", cfg.Server.RateLimit.Enabled); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintf(w, "#host = \"%s\"\n", cfg.Database.Host); err != nil {
+	if _, err := fmt.Fprintf(w, "requests_per_second = %.1f # Sustained rate\n", cfg.Server.RateLimit.RequestsPerSecond); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintf(w, "#port = %d\n", cfg.Database.Port); err != nil {
+	if _, err := fmt.Fprintf(w, "burst = %d # Requests allowed at once before the rate applies\n", cfg.Server.RateLimit.Burst); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintf(w, "#user = \"%s\"\n", cfg.Database.User); err != nil {
+	if _, err := fmt.Fprintln(w, ""); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintf(w, "#password = \"%s\"\n", cfg.Database.Password); err != nil {
+
+	// CORS section
+	if _, err := fmt.Fprintln(w, "# Let browsers on other origins call the API. Without allowed origins only"); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintf(w, "#dbname = \"%s\"\n", cfg.Database.DBName); err != nil {
+	if _, err := fmt.Fprintln(w, "# same-origin requests work. \"*\" allows any origin, but not with allow_credentials."); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintf(w, "#sslmode = \"%s\"\n\n", cfg.Database.SSLMode); err != nil {
+	if _, err := fmt.Fprintln(w, "[server.cors]"); err != nil {
 		return err
 	}
-
-	// Server section
-	if _, err := fmt.Fprintln(w, "[server]"); err != nil {
+	if _, err := fmt.Fprintf(w, "allowed_origins = [%s] # Example: [\"https://app.example.com\"]\n", quoteList(cfg.Server.CORS.AllowedOrigins)); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintf(w, "host = \"%s\"\n", cfg.Server.Host); err != nil {
+	if _, err := fmt.Fprintf(w, "allowed_methods = [%s]\n", quoteList(cfg.Server.CORS.AllowedMethods)); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintf(w, "port = %d\n", cfg.Server.Port); err != nil {
+	if _, err := fmt.Fprintf(w, "allowed_headers = [%s] # Request headers the browser may send\n", quoteList(cfg.Server.CORS.AllowedHeaders)); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintf(w, "#base_url = \"%s\"\n", cfg.Server.BaseURL); err != nil {
+	if _, err := fmt.Fprintf(w, "allow_credentials = %t # Allow requests that carry cookies, such as the session cookie\n", cfg.Server.CORS.AllowCredentials); err != nil {
 		return err
 	}
 	if _, err := fmt.Fprintln(w, ""); err != nil {
@@ -748,6 +2343,9 @@ func (c *Config) WriteToml(w io.Writer) error {
 	if _, err := fmt.Fprintf(w, "level = \"%s\"  # trace, debug, info, warn, error, fatal, panic\n", cfg.Logging.Level); err != nil {
 		return err
 	}
+	if _, err := fmt.Fprintf(w, "sample_rate = %d  # log 1 in N per-packet/per-hop debug events, 1 logs all\n", cfg.Logging.SampleRate); err != nil {
+		return err
+	}
 	if _, err := fmt.Fprintln(w, ""); err != nil {
 		return err
 	}
@@ -788,6 +2386,76 @@ func (c *Config) WriteToml(w io.Writer) error {
 	if _, err := fmt.Fprintln(w, "#scopes = [\"openid\", \"profile\", \"offline_access\"]"); err != nil {
 		return err
 	}
+	if _, err := fmt.Fprintln(w, "#role_claim = \"groups\" # Map this ID token claim to roles, unset gives every OIDC user admin access"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#default_role = \"viewer\" # Role for users without a mapped group, unset denies them"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#[oidc.role_mapping]"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#netronome-admins = \"admin\""); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, ""); err != nil {
+		return err
+	}
+
+	// SMTP section
+	if _, err := fmt.Fprintln(w, "# Email notifications, sent whenever a notification rule fires"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#[smtp]"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#host = \"smtp.example.com\""); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#port = %d\n", cfg.SMTP.Port); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#username = \"\""); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#password = \"\""); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#from = \"netronome@example.com\""); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#to = [\"you@example.com\"]"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#tls = \"%s\" # starttls, tls (implicit TLS, usually port 465) or none\n", cfg.SMTP.TLS); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, ""); err != nil {
+		return err
+	}
+
+	// Notification quiet hours section
+	if _, err := fmt.Fprintln(w, "# Log agent and packet loss alerts as suppressed instead of sending them, e.g. during backups."); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "# Recovery notifications are always sent."); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#[notifications.quiet_hours]"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#start = \"01:00\""); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#end = \"04:00\" # Before start for windows that span midnight"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#timezone = \"Europe/Stockholm\" # Empty uses the server's local time"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#weekdays = [\"sat\", \"sun\"] # Days the window starts on, empty for every day"); err != nil {
+		return err
+	}
 	if _, err := fmt.Fprintln(w, ""); err != nil {
 		return err
 	}
@@ -799,6 +2467,30 @@ func (c *Config) WriteToml(w io.Writer) error {
 	if _, err := fmt.Fprintf(w, "timeout = %d\n", cfg.SpeedTest.Timeout); err != nil {
 		return err
 	}
+	if _, err := fmt.Fprintf(w, "traceroute_max_ips = %d\n", cfg.SpeedTest.TracerouteMaxIPs); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "mtu_probe_host = \"%s\"\n", cfg.SpeedTest.MTUProbeHost); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "max_concurrent = %d # Speedtests run at once; later ones queue\n", cfg.SpeedTest.MaxConcurrent); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "retries = %d # Run a failed scheduled test again up to this many times\n", cfg.SpeedTest.Retries); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "retry_backoff = \"%s\" # Wait before the first retry, doubled for each further one\n", cfg.SpeedTest.RetryBackoff); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "source_interface = \"%s\" # Send test traffic through this interface, e.g. \"eth1\"\n", cfg.SpeedTest.SourceInterface); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "source_ip = \"%s\" # Send test traffic from this local address\n", cfg.SpeedTest.SourceIP); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "user_agent = \"%s\" # User-Agent for librespeed and speedtest.net requests, empty keeps the default\n", cfg.SpeedTest.UserAgent); err != nil {
+		return err
+	}
 	if _, err := fmt.Fprintln(w, ""); err != nil {
 		return err
 	}
@@ -816,6 +2508,18 @@ func (c *Config) WriteToml(w io.Writer) error {
 	if _, err := fmt.Fprintf(w, "timeout = %d\n", cfg.SpeedTest.IPerf.Timeout); err != nil {
 		return err
 	}
+	if _, err := fmt.Fprintf(w, "protocol = \"%s\" # tcp or udp\n", cfg.SpeedTest.IPerf.Protocol); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#bitrate = \"100M\" # target bitrate, recommended for udp"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "reverse = %v # only measure server to client (-R)\n", cfg.SpeedTest.IPerf.Reverse); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "bidirectional = %v # measure download and upload at once (iperf3 3.7+)\n", cfg.SpeedTest.IPerf.Bidirectional); err != nil {
+		return err
+	}
 	if _, err := fmt.Fprintln(w, ""); err != nil {
 		return err
 	}
@@ -827,6 +2531,49 @@ func (c *Config) WriteToml(w io.Writer) error {
 	if _, err := fmt.Fprintf(w, "timeout = %d\n", cfg.SpeedTest.Librespeed.Timeout); err != nil {
 		return err
 	}
+	if _, err := fmt.Fprintln(w, "# Self-hosted servers: a server list URL, or [[speedtest.librespeed.servers]] tables"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "# with id, name and server; both replace librespeed-servers.json"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "# servers_url = \"%s\"\n", cfg.SpeedTest.Librespeed.ServersURL); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, ""); err != nil {
+		return err
+	}
+
+	// SpeedTest Ookla section
+	if _, err := fmt.Fprintln(w, "[speedtest.ookla]"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "server_id = \"%s\" # Empty lets the speedtest CLI pick the nearest server\n", cfg.SpeedTest.Ookla.ServerID); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "accept_license = %t # Accept the Ookla license and GDPR terms, required to run tests\n", cfg.SpeedTest.Ookla.AcceptLicense); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "timeout = %d\n", cfg.SpeedTest.Ookla.Timeout); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, ""); err != nil {
+		return err
+	}
+
+	// SpeedTest Traceroute section
+	if _, err := fmt.Fprintln(w, "[speedtest.traceroute]"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "max_hops = %d # 1-%d\n", cfg.SpeedTest.Traceroute.MaxHops, MaxTracerouteHops); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "queries = %d # Probes per hop, 1-%d\n", cfg.SpeedTest.Traceroute.Queries, MaxTracerouteQueries); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "native = %t # Trace with built-in ICMP instead of the traceroute binary (needs raw sockets)\n", cfg.SpeedTest.Traceroute.Native); err != nil {
+		return err
+	}
 	if _, err := fmt.Fprintln(w, ""); err != nil {
 		return err
 	}
@@ -875,6 +2622,30 @@ func (c *Config) WriteToml(w io.Writer) error {
 	if _, err := fmt.Fprintf(w, "#asn_database_path = \"/path/to/GeoLite2-ASN.mmdb\"\n"); err != nil {
 		return err
 	}
+	if _, err := fmt.Fprintf(w, "#skip_private_hops = %v # Skip lookups for private, loopback, CGNAT and multicast hop addresses\n", cfg.GeoIP.SkipPrivateHops); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#private_hop_ranges = [%s] # CIDRs skipped by skip_private_hops\n", quoteList(cfg.GeoIP.PrivateHopRanges)); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#license_key = \"\" # MaxMind license key; downloads the editions below instead of using the paths above"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#edition_ids = [%s]\n", quoteList(cfg.GeoIP.EditionIDs)); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#database_dir = \"%s\" # relative to data_dir or the config file\n", cfg.GeoIP.DatabaseDir); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#update_interval = \"%s\"\n", cfg.GeoIP.UpdateInterval); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#cache_size = %d # cached host and hop lookups, 0 disables the cache\n", cfg.GeoIP.CacheSize); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#cache_ttl = \"%s\"\n", cfg.GeoIP.CacheTTL); err != nil {
+		return err
+	}
 
 	// Packet Loss section
 	if _, err := fmt.Fprintln(w, ""); err != nil {
@@ -901,6 +2672,18 @@ func (c *Config) WriteToml(w io.Writer) error {
 	if _, err := fmt.Fprintf(w, "mtr_enable_dns = %v # Enable DNS resolution in MTR tests to show hostnames instead of IPs\n", cfg.PacketLoss.MTREnableDNS); err != nil {
 		return err
 	}
+	if _, err := fmt.Fprintf(w, "stagger_monitors = %v # Spread monitors sharing an interval evenly across it instead of running them together\n", cfg.PacketLoss.StaggerMonitors); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "timeout_margin = %d # Extra seconds allowed on top of packet_count x interval before a ping test times out\n", cfg.PacketLoss.TimeoutMargin); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "completed_status_window = %d # Seconds a finished test is reported as completed; raise for slow-polling dashboards\n", cfg.PacketLoss.CompletedStatusWindow); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "completed_retention = %d # Seconds completion times are kept; never shorter than completed_status_window\n", cfg.PacketLoss.CompletedRetention); err != nil {
+		return err
+	}
 
 	// Monitor section
 	if _, err := fmt.Fprintln(w, ""); err != nil {
@@ -912,7 +2695,73 @@ func (c *Config) WriteToml(w io.Writer) error {
 	if _, err := fmt.Fprintf(w, "enabled = %v\n", cfg.Monitor.Enabled); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintf(w, "reconnect_interval = \"%s\"\n", cfg.Monitor.ReconnectInterval); err != nil {
+	if _, err := fmt.Fprintf(w, "reconnect_interval = \"%s\" # First wait before reconnecting to a dropped agent, unless reconnect_initial is set\n", cfg.Monitor.ReconnectInterval); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "reconnect_max = \"%s\" # Longest wait between reconnect attempts, the wait doubles up to it\n", cfg.Monitor.ReconnectMax); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "reconnect_jitter = %.1f # Spread each reconnect wait by up to this fraction (0-1)\n", cfg.Monitor.ReconnectJitter); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "resource_interval = \"%s\" # How often hardware stats are polled from agents\n", cfg.Monitor.ResourceInterval); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "snapshot_interval = \"%s\" # How often historical bandwidth snapshots are stored\n", cfg.Monitor.SnapshotInterval); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "cleanup_interval = \"%s\" # How often old monitor data is pruned\n", cfg.Monitor.CleanupInterval); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "max_agents = %d # Max agents monitored at once, each holds a persistent SSE connection (0 = unlimited)\n", cfg.Monitor.MaxAgents); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "max_idle_conns_per_host = %d # Keep-alive connections kept open to each agent for reuse between polls\n", cfg.Monitor.MaxIdleConnsPerHost); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "idle_conn_timeout = \"%s\" # Close pooled agent connections unused this long\n", cfg.Monitor.IdleConnTimeout); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "keep_alive = \"%s\" # TCP keep-alive period for agent connections\n", cfg.Monitor.KeepAlive); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "notification_cooldown = \"%s\" # Minimum time between resource and bandwidth alerts per agent; rule cooldowns win\n", cfg.Monitor.NotificationCooldown); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "transport = \"%s\" # Live data transport, \"sse\" or \"ws\" (WebSocket) for proxies that buffer SSE\n", cfg.Monitor.Transport); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "maintenance_pause_collection = %v # Also stop polling system info and hardware stats while an agent reports maintenance\n", cfg.Monitor.MaintenancePauseCollection); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#speedtest_sample_interval = \"250ms\" # Sample local agents this often during speedtests to capture the spike (100ms-1s)\n"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "# Per-interface high bandwidth thresholds in Mbps (rx+tx); other interfaces use the notification rule threshold"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#[monitor.per_interface_thresholds]"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#eth1 = 900"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, ""); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "# How long each class of monitor data is kept, \"0\" keeps it forever"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "[monitor.retention]"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "resource_stats = \"%s\" # CPU, memory, disk and temperature samples\n", cfg.Monitor.Retention.ResourceStats); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "snapshots = \"%s\" # Historical bandwidth snapshots; the latest of each type is always kept\n", cfg.Monitor.Retention.Snapshots); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "probe_results = \"%s\" # Agent-side probe results\n", cfg.Monitor.Retention.ProbeResults); err != nil {
 		return err
 	}
 
@@ -977,10 +2826,45 @@ func (c *Config) WriteToml(w io.Writer) error {
 	if _, err := fmt.Fprintf(w, "discovery_port = %d # Port to probe for agents\n", cfg.Tailscale.DiscoveryPort); err != nil {
 		return err
 	}
+	if _, err := fmt.Fprintln(w, ""); err != nil {
+		return err
+	}
+
+	// Telemetry section
+	if _, err := fmt.Fprintln(w, "# OpenTelemetry traces of speed tests, traceroutes, MTR runs and database queries"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#[telemetry]"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#enabled = true"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "#endpoint = \"http://localhost:4317\" # OTLP collector"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#protocol = \"%s\" # grpc or http\n", cfg.Telemetry.Protocol); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#service_name = \"%s\"\n", cfg.Telemetry.ServiceName); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#sample_ratio = %g # Share of traces kept, 0 to 1\n", cfg.Telemetry.SampleRatio); err != nil {
+		return err
+	}
 
 	return nil
 }
 
+// quoteList formats strings as the items of a TOML array
+func quoteList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = strconv.Quote(item)
+	}
+	return strings.Join(quoted, ", ")
+}
+
 func GetDefaultConfigPath() string {
 	if configDir, err := os.UserConfigDir(); err == nil {
 		configPath := filepath.Join(configDir, AppName, "config.toml")
@@ -1089,6 +2973,21 @@ func getEnv(key string) string {
 	return os.Getenv(EnvPrefix + key)
 }
 
+// getSecretEnv returns a sensitive setting. When NETRONOME__<key>_FILE is set the value is
+// read from that file and trimmed, taking precedence over NETRONOME__<key>.
+// ok reports whether either variable was set.
+func getSecretEnv(key string, errs *envErrors) (value string, ok bool) {
+	if path := getEnv(key + "_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs.addSecretFile(key, err)
+			return "", false
+		}
+		return strings.TrimSpace(string(data)), true
+	}
+	return os.LookupEnv(EnvPrefix + key)
+}
+
 // GetEffectiveMethod returns the effective Tailscale method based on configuration
 func (t *TailscaleConfig) GetEffectiveMethod() (string, error) {
 	if !t.Enabled {
@@ -1209,18 +3108,20 @@ func (t *TailscaleConfig) IsServerDiscoveryMode() bool {
 }
 
 // loadFromEnv is a method to load environment variables for TailscaleConfig
-func (t *TailscaleConfig) loadFromEnv() {
+func (t *TailscaleConfig) loadFromEnv(errs *envErrors) {
 	// Core settings
 	if v := getEnv("TAILSCALE_ENABLED"); v != "" {
 		if enabled, err := strconv.ParseBool(v); err == nil {
 			t.Enabled = enabled
+		} else {
+			errs.add("TAILSCALE_ENABLED", v, err)
 		}
 	}
 	if v := getEnv("TAILSCALE_METHOD"); v != "" {
 		t.Method = v
 	}
 	// Always handle AUTH_KEY even if empty to allow clearing
-	if v, exists := os.LookupEnv(EnvPrefix + "TAILSCALE_AUTH_KEY"); exists {
+	if v, exists := getSecretEnv("TAILSCALE_AUTH_KEY", errs); exists {
 		t.AuthKey = v
 	}
 
@@ -1231,6 +3132,8 @@ func (t *TailscaleConfig) loadFromEnv() {
 	if v := getEnv("TAILSCALE_EPHEMERAL"); v != "" {
 		if ephemeral, err := strconv.ParseBool(v); err == nil {
 			t.Ephemeral = ephemeral
+		} else {
+			errs.add("TAILSCALE_EPHEMERAL", v, err)
 		}
 	}
 	if v := getEnv("TAILSCALE_STATE_DIR"); v != "" {
@@ -1244,6 +3147,8 @@ func (t *TailscaleConfig) loadFromEnv() {
 	if v := getEnv("TAILSCALE_AGENT_PORT"); v != "" {
 		if port, err := strconv.Atoi(v); err == nil {
 			t.AgentPort = port
+		} else {
+			errs.add("TAILSCALE_AGENT_PORT", v, err)
 		}
 	}
 
@@ -1251,6 +3156,8 @@ func (t *TailscaleConfig) loadFromEnv() {
 	if v := getEnv("TAILSCALE_AUTO_DISCOVER"); v != "" {
 		if auto, err := strconv.ParseBool(v); err == nil {
 			t.AutoDiscover = auto
+		} else {
+			errs.add("TAILSCALE_AUTO_DISCOVER", v, err)
 		}
 	}
 	if v := getEnv("TAILSCALE_DISCOVERY_INTERVAL"); v != "" {
@@ -1259,6 +3166,8 @@ func (t *TailscaleConfig) loadFromEnv() {
 	if v := getEnv("TAILSCALE_DISCOVERY_PORT"); v != "" {
 		if port, err := strconv.Atoi(v); err == nil {
 			t.DiscoveryPort = port
+		} else {
+			errs.add("TAILSCALE_DISCOVERY_PORT", v, err)
 		}
 	}
 	if v := getEnv("TAILSCALE_DISCOVERY_PREFIX"); v != "" {
@@ -1273,16 +3182,22 @@ func (t *TailscaleConfig) loadFromEnv() {
 			if preferHost && getEnv("TAILSCALE_METHOD") == "" {
 				t.Method = "host"
 			}
+		} else {
+			errs.add("TAILSCALE_PREFER_HOST", v, err)
 		}
 	}
 	if v := getEnv("TAILSCALE_AGENT_ENABLED"); v != "" {
 		if enabled, err := strconv.ParseBool(v); err == nil {
 			t.Agent.Enabled = enabled
+		} else {
+			errs.add("TAILSCALE_AGENT_ENABLED", v, err)
 		}
 	}
 	if v := getEnv("TAILSCALE_AGENT_ACCEPT_ROUTES"); v != "" {
 		if accept, err := strconv.ParseBool(v); err == nil {
 			t.Agent.AcceptRoutes = accept
+		} else {
+			errs.add("TAILSCALE_AGENT_ACCEPT_ROUTES", v, err)
 		}
 	}
 	if v := getEnv("TAILSCALE_AGENT_PORT"); v != "" {
@@ -1292,6 +3207,8 @@ func (t *TailscaleConfig) loadFromEnv() {
 			if t.AgentPort == 0 {
 				t.AgentPort = port
 			}
+		} else {
+			errs.add("TAILSCALE_AGENT_PORT", v, err)
 		}
 	}
 	if v := getEnv("TAILSCALE_MONITOR_AUTO_DISCOVER"); v != "" {
@@ -1299,6 +3216,8 @@ func (t *TailscaleConfig) loadFromEnv() {
 			t.Monitor.AutoDiscover = auto
 			// Also set new field
 			t.AutoDiscover = auto
+		} else {
+			errs.add("TAILSCALE_MONITOR_AUTO_DISCOVER", v, err)
 		}
 	}
 	if v := getEnv("TAILSCALE_MONITOR_DISCOVERY_INTERVAL"); v != "" {
@@ -1311,6 +3230,8 @@ func (t *TailscaleConfig) loadFromEnv() {
 			t.Monitor.DiscoveryPort = port
 			// Also set new field
 			t.DiscoveryPort = port
+		} else {
+			errs.add("TAILSCALE_MONITOR_DISCOVERY_PORT", v, err)
 		}
 	}
 	if v := getEnv("TAILSCALE_MONITOR_DISCOVERY_PREFIX"); v != "" {
@@ -0,0 +1,854 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_StrictMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		env        map[string]string
+		wantStrict string // expected substring of the strict error, empty for success
+		check      func(t *testing.T, cfg *Config)
+	}{
+		{
+			name:    "valid config",
+			content: "[monitor]\nmax_agents = 5\n",
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, 5, cfg.Monitor.MaxAgents)
+			},
+		},
+		{
+			name:       "unknown key",
+			content:    "[monitor]\nmax_agnts = 5\n",
+			wantStrict: "monitor.max_agnts",
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, 0, cfg.Monitor.MaxAgents)
+			},
+		},
+		{
+			name:       "invalid environment value",
+			content:    "[monitor]\nmax_agents = 5\n",
+			env:        map[string]string{"NETRONOME__MONITOR_MAX_AGENTS": "lots"},
+			wantStrict: "NETRONOME__MONITOR_MAX_AGENTS",
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, 5, cfg.Monitor.MaxAgents, "invalid env value should keep the file value")
+			},
+		},
+		{
+			name:    "maintenance from environment",
+			content: "[agent]\nmaintenance = false\n",
+			env: map[string]string{
+				"NETRONOME__AGENT_MAINTENANCE":                    "true",
+				"NETRONOME__MONITOR_MAINTENANCE_PAUSE_COLLECTION": "true",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				assert.True(t, cfg.Agent.Maintenance)
+				assert.True(t, cfg.Monitor.MaintenancePauseCollection)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			path := writeConfigFile(t, tt.content)
+
+			cfg, err := Load(path)
+			require.NoError(t, err, "non-strict load should tolerate the problem")
+			tt.check(t, cfg)
+
+			_, err = LoadStrict(path)
+			if tt.wantStrict == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantStrict)
+		})
+	}
+}
+
+func TestLoad_LibrespeedTimeoutDefaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+		want    int
+	}{
+		{name: "default", content: "", want: DefaultLibrespeedTimeout},
+		{name: "file value", content: "[speedtest.librespeed]\ntimeout = 90\n", want: 90},
+		{name: "zero in file", content: "[speedtest.librespeed]\ntimeout = 0\n", want: DefaultLibrespeedTimeout},
+		{name: "negative in file", content: "[speedtest.librespeed]\ntimeout = -5\n", want: DefaultLibrespeedTimeout},
+		{name: "env value", env: map[string]string{"NETRONOME__LIBRESPEED_TIMEOUT": "45"}, want: 45},
+		{name: "zero in env", env: map[string]string{"NETRONOME__LIBRESPEED_TIMEOUT": "0"}, want: DefaultLibrespeedTimeout},
+		{
+			name:    "negative in env overrides file",
+			content: "[speedtest.librespeed]\ntimeout = 90\n",
+			env:     map[string]string{"NETRONOME__LIBRESPEED_TIMEOUT": "-1"},
+			want:    DefaultLibrespeedTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := Load(writeConfigFile(t, tt.content))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.SpeedTest.Librespeed.Timeout)
+		})
+	}
+}
+
+func TestApplyEnv_LibrespeedTimeoutDefault(t *testing.T) {
+	t.Setenv("NETRONOME__LIBRESPEED_TIMEOUT", "0")
+
+	cfg := New()
+	cfg.ApplyEnv()
+	assert.Equal(t, DefaultLibrespeedTimeout, cfg.SpeedTest.Librespeed.Timeout)
+}
+
+func TestLoad_LibrespeedServers(t *testing.T) {
+	t.Setenv("NETRONOME__LIBRESPEED_SERVERS_URL", "https://example.com/servers.json")
+
+	content := `[speedtest.librespeed]
+[[speedtest.librespeed.servers]]
+id = 1
+name = "Home"
+server = "http://speed.lan/"
+dl_url = "garbage.php"
+ul_url = "empty.php"
+ping_url = "empty.php"
+get_ip_url = "getIP.php"
+`
+	cfg, err := LoadStrict(writeConfigFile(t, content))
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/servers.json", cfg.SpeedTest.Librespeed.ServersURL)
+	assert.Equal(t, []LibrespeedServer{{
+		ID:       1,
+		Name:     "Home",
+		Server:   "http://speed.lan/",
+		DlURL:    "garbage.php",
+		UlURL:    "empty.php",
+		PingURL:  "empty.php",
+		GetIpURL: "getIP.php",
+	}}, cfg.SpeedTest.Librespeed.Servers)
+}
+
+func TestValidateLibrespeedServers(t *testing.T) {
+	tests := []struct {
+		name    string
+		servers []LibrespeedServer
+		wantErr string
+	}{
+		{name: "empty", servers: nil},
+		{name: "valid", servers: []LibrespeedServer{{ID: 1, Name: "lan", Server: "http://speed.lan/"}}},
+		{name: "missing id", servers: []LibrespeedServer{{Name: "lan", Server: "http://speed.lan/"}}, wantErr: "id must be positive"},
+		{
+			name: "duplicate id",
+			servers: []LibrespeedServer{
+				{ID: 1, Name: "lan", Server: "http://speed.lan/"},
+				{ID: 1, Name: "wan", Server: "https://speed.example.com/"},
+			},
+			wantErr: "duplicate id 1",
+		},
+		{name: "missing name", servers: []LibrespeedServer{{ID: 1, Server: "http://speed.lan/"}}, wantErr: "name is required"},
+		{name: "relative server", servers: []LibrespeedServer{{ID: 1, Name: "lan", Server: "speed.lan"}}, wantErr: "http(s) URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLibrespeedServers(tt.servers)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLoad_OoklaConfig(t *testing.T) {
+	t.Run("file", func(t *testing.T) {
+		cfg, err := Load(writeConfigFile(t, "[speedtest.ookla]\nserver_id = \"12345\"\naccept_license = true\ntimeout = 0\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "12345", cfg.SpeedTest.Ookla.ServerID)
+		assert.True(t, cfg.SpeedTest.Ookla.AcceptLicense)
+		assert.Equal(t, DefaultOoklaTimeout, cfg.SpeedTest.Ookla.Timeout)
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("NETRONOME__OOKLA_SERVER_ID", "678")
+		t.Setenv("NETRONOME__OOKLA_ACCEPT_LICENSE", "true")
+		t.Setenv("NETRONOME__OOKLA_TIMEOUT", "120")
+
+		cfg, err := Load(writeConfigFile(t, ""))
+		require.NoError(t, err)
+		assert.Equal(t, "678", cfg.SpeedTest.Ookla.ServerID)
+		assert.True(t, cfg.SpeedTest.Ookla.AcceptLicense)
+		assert.Equal(t, 120, cfg.SpeedTest.Ookla.Timeout)
+	})
+
+	t.Run("env without a config file", func(t *testing.T) {
+		t.Setenv("NETRONOME__OOKLA_TIMEOUT", "-1")
+
+		cfg := New()
+		cfg.ApplyEnv()
+		assert.Equal(t, DefaultOoklaTimeout, cfg.SpeedTest.Ookla.Timeout)
+	})
+}
+
+func TestLoad_TypeMismatchNamesKey(t *testing.T) {
+	path := writeConfigFile(t, "[monitor]\nmax_agents = \"five\"\n")
+
+	_, err := Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max_agents")
+}
+
+func TestLoadStrict_GeneratedConfig(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, New().WriteToml(&buf))
+
+	path := writeConfigFile(t, buf.String())
+	_, err := LoadStrict(path)
+	assert.NoError(t, err, "generated config should only contain known keys")
+}
+
+func TestLoadYAML_GeneratedConfig(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, New().WriteYAML(&buf))
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+
+	_, err := LoadStrict(path)
+	require.NoError(t, err, "generated YAML should only contain known keys")
+
+	cfg, err := LoadYAML(path)
+	require.NoError(t, err)
+	defaults := New()
+	assert.Equal(t, defaults.Server.Port, cfg.Server.Port)
+	assert.Equal(t, defaults.Database.Type, cfg.Database.Type)
+	assert.Equal(t, defaults.Monitor.ReconnectInterval, cfg.Monitor.ReconnectInterval)
+	assert.Equal(t, filepath.Join(filepath.Dir(path), "netronome.db"), cfg.Database.Path)
+	assert.NotEmpty(t, cfg.Session.Secret)
+}
+
+func TestLoadYAML(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 9000\nmonitor:\n  max_agents: 5\n"), 0o600))
+	cfg, err := LoadYAML(path)
+	require.NoError(t, err)
+	assert.Equal(t, 9000, cfg.Server.Port)
+	assert.Equal(t, 5, cfg.Monitor.MaxAgents)
+
+	unknown := filepath.Join(dir, "unknown.yaml")
+	require.NoError(t, os.WriteFile(unknown, []byte("monitor:\n  max_agnts: 5\n"), 0o600))
+	_, err = LoadStrict(unknown)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "monitor.max_agnts")
+
+	_, err = LoadYAML(filepath.Join(dir, "config.toml"))
+	assert.Error(t, err, "LoadYAML should reject non-YAML paths")
+}
+
+func TestStrictFromEnv(t *testing.T) {
+	t.Setenv("NETRONOME__STRICT_CONFIG", "true")
+	assert.True(t, StrictFromEnv())
+
+	t.Setenv("NETRONOME__STRICT_CONFIG", "")
+	assert.False(t, StrictFromEnv())
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(cfg *Config)
+		wantKeys []string
+	}{
+		{
+			name:   "defaults are valid",
+			modify: func(cfg *Config) {},
+		},
+		{
+			name: "unsupported database type",
+			modify: func(cfg *Config) {
+				cfg.Database.Type = "oracle"
+			},
+			wantKeys: []string{"database.type"},
+		},
+		{
+			name: "sqlite tuning",
+			modify: func(cfg *Config) {
+				cfg.Database.SQLite = SQLiteConfig{BusyTimeout: "-1s", JournalMode: "wall", Synchronous: "sometimes"}
+			},
+			wantKeys: []string{"database.sqlite.busy_timeout", "database.sqlite.journal_mode", "database.sqlite.synchronous"},
+		},
+		{
+			name: "sqlite tuning ignored for postgres",
+			modify: func(cfg *Config) {
+				cfg.Database.Type = Postgres
+				cfg.Database.SQLite.JournalMode = "wall"
+			},
+		},
+		{
+			name: "mysql is valid",
+			modify: func(cfg *Config) {
+				cfg.Database.Type = MySQL
+				cfg.Database.Port = 3306
+			},
+		},
+		{
+			name: "postgres port out of range",
+			modify: func(cfg *Config) {
+				cfg.Database.Type = Postgres
+				cfg.Database.Port = 70000
+			},
+			wantKeys: []string{"database.port"},
+		},
+		{
+			name: "several problems reported together",
+			modify: func(cfg *Config) {
+				cfg.Server.Port = 0
+				cfg.Monitor.ReconnectInterval = "often"
+				cfg.Agent.ProbeInterval = "1 minute"
+			},
+			wantKeys: []string{"server.port", "monitor.reconnect_interval", "agent.probe_interval"},
+		},
+		{
+			name: "reconnect backoff",
+			modify: func(cfg *Config) {
+				cfg.Monitor.ReconnectMax = "forever"
+				cfg.Monitor.ReconnectJitter = 1.5
+			},
+			wantKeys: []string{"monitor.reconnect_max", "monitor.reconnect_jitter"},
+		},
+		{
+			name: "rate limit needs a positive rate and burst",
+			modify: func(cfg *Config) {
+				cfg.Server.RateLimit = RateLimitConfig{Enabled: true, RequestsPerSecond: 0, Burst: 0}
+			},
+			wantKeys: []string{"server.ratelimit.requests_per_second", "server.ratelimit.burst"},
+		},
+		{
+			name: "disabled rate limit isn't checked",
+			modify: func(cfg *Config) {
+				cfg.Server.RateLimit = RateLimitConfig{RequestsPerSecond: -1}
+			},
+		},
+		{
+			name: "wildcard CORS origin with credentials",
+			modify: func(cfg *Config) {
+				cfg.Server.CORS.AllowedOrigins = []string{"*"}
+				cfg.Server.CORS.AllowCredentials = true
+			},
+			wantKeys: []string{"server.cors.allowed_origins"},
+		},
+		{
+			name: "wildcard CORS origin without credentials",
+			modify: func(cfg *Config) {
+				cfg.Server.CORS.AllowedOrigins = []string{"*"}
+			},
+		},
+		{
+			name: "CORS origin with a path and unknown method",
+			modify: func(cfg *Config) {
+				cfg.Server.CORS.AllowedOrigins = []string{"https://app.example.com/"}
+				cfg.Server.CORS.AllowedMethods = []string{"GET", "FETCH"}
+			},
+			wantKeys: []string{"server.cors.allowed_origins", "server.cors.allowed_methods"},
+		},
+		{
+			name: "invalid and negative server timeouts",
+			modify: func(cfg *Config) {
+				cfg.Server.ReadTimeout = "30"
+				cfg.Server.WriteTimeout = "-1m"
+				cfg.Server.IdleTimeout = "0"
+			},
+			wantKeys: []string{"server.read_timeout", "server.write_timeout"},
+		},
+		{
+			name: "negative max body bytes",
+			modify: func(cfg *Config) {
+				cfg.Server.MaxBodyBytes = -1
+			},
+			wantKeys: []string{"server.max_body_bytes"},
+		},
+		{
+			name: "invalid speedtest source IP and user agent",
+			modify: func(cfg *Config) {
+				cfg.SpeedTest.SourceIP = "eth0"
+				cfg.SpeedTest.UserAgent = "netronome\r\nX-Injected: 1"
+			},
+			wantKeys: []string{"speedtest.source_ip", "speedtest.user_agent"},
+		},
+		{
+			name: "completed retention shorter than status window",
+			modify: func(cfg *Config) {
+				cfg.PacketLoss.CompletedStatusWindow = 30
+				cfg.PacketLoss.CompletedRetention = 10
+			},
+			wantKeys: []string{"packetloss.completed_retention"},
+		},
+		{
+			name: "non-positive interface threshold",
+			modify: func(cfg *Config) {
+				cfg.Monitor.PerInterfaceThresholds = map[string]float64{"eth1": 900, "eth0": 0}
+			},
+			wantKeys: []string{"monitor.per_interface_thresholds.eth0"},
+		},
+		{
+			name: "invalid iperf settings",
+			modify: func(cfg *Config) {
+				cfg.SpeedTest.IPerf.Protocol = "sctp"
+				cfg.SpeedTest.IPerf.Bitrate = "fast"
+			},
+			wantKeys: []string{"speedtest.iperf.protocol", "speedtest.iperf.bitrate"},
+		},
+		{
+			name: "traceroute out of range",
+			modify: func(cfg *Config) {
+				cfg.SpeedTest.Traceroute.MaxHops = 65
+				cfg.SpeedTest.Traceroute.Queries = 0
+			},
+			wantKeys: []string{"speedtest.traceroute.max_hops", "speedtest.traceroute.queries"},
+		},
+		{
+			name: "iperf udp with bitrate",
+			modify: func(cfg *Config) {
+				cfg.SpeedTest.IPerf.Protocol = IperfProtocolUDP
+				cfg.SpeedTest.IPerf.Bitrate = "1.5G"
+			},
+		},
+		{
+			name: "invalid monitor retention",
+			modify: func(cfg *Config) {
+				cfg.Monitor.Retention.ResourceStats = "-1h"
+				cfg.Monitor.Retention.Snapshots = "0"
+				cfg.Monitor.Retention.ProbeResults = "a week"
+			},
+			wantKeys: []string{"monitor.retention.resource_stats", "monitor.retention.probe_results"},
+		},
+		{
+			name: "invalid private hop range",
+			modify: func(cfg *Config) {
+				cfg.GeoIP.PrivateHopRanges = []string{"10.0.0.0/8", "192.168.1.1"}
+			},
+			wantKeys: []string{"geoip.private_hop_ranges"},
+		},
+		{
+			name: "invalid geoip download settings",
+			modify: func(cfg *Config) {
+				cfg.GeoIP.LicenseKey = "key"
+				cfg.GeoIP.EditionIDs = []string{"GeoLite2-Country", "GeoIP2-Domain"}
+				cfg.GeoIP.UpdateInterval = "10m"
+			},
+			wantKeys: []string{"geoip.edition_ids", "geoip.update_interval"},
+		},
+		{
+			name: "invalid geoip cache settings",
+			modify: func(cfg *Config) {
+				cfg.GeoIP.CacheSize = -1
+				cfg.GeoIP.CacheTTL = "0s"
+			},
+			wantKeys: []string{"geoip.cache_size", "geoip.cache_ttl"},
+		},
+		{
+			name: "geoip download settings ignored without license key",
+			modify: func(cfg *Config) {
+				cfg.GeoIP.EditionIDs = nil
+				cfg.GeoIP.UpdateInterval = "10m"
+			},
+		},
+		{
+			name: "incomplete smtp settings",
+			modify: func(cfg *Config) {
+				cfg.SMTP.Host = "smtp.example.com"
+				cfg.SMTP.TLS = "ssl"
+			},
+			wantKeys: []string{"smtp.from", "smtp.to", "smtp.tls"},
+		},
+		{
+			name: "invalid quiet hours",
+			modify: func(cfg *Config) {
+				cfg.Notifications.QuietHours = QuietHoursConfig{Start: "1am", End: "04:00", Timezone: "Mars/Olympus", Weekdays: []string{"sat", "caturday"}}
+			},
+			wantKeys: []string{"notifications.quiet_hours.start", "notifications.quiet_hours.timezone", "notifications.quiet_hours.weekdays"},
+		},
+		{
+			name: "empty quiet hours window",
+			modify: func(cfg *Config) {
+				cfg.Notifications.QuietHours = QuietHoursConfig{Start: "02:00", End: "02:00"}
+			},
+			wantKeys: []string{"notifications.quiet_hours.end"},
+		},
+		{
+			name: "unknown oidc roles",
+			modify: func(cfg *Config) {
+				cfg.OIDC.RoleMapping = map[string]string{"admins": "admin", "staff": "editor"}
+				cfg.OIDC.DefaultRole = "guest"
+			},
+			wantKeys: []string{"oidc.role_mapping.staff", "oidc.default_role"},
+		},
+		{
+			name: "speedtest sample interval out of range",
+			modify: func(cfg *Config) {
+				cfg.Monitor.SpeedtestSampleInterval = "2s"
+			},
+			wantKeys: []string{"monitor.speedtest_sample_interval"},
+		},
+		{
+			name: "speedtest sample interval invalid",
+			modify: func(cfg *Config) {
+				cfg.Monitor.SpeedtestSampleInterval = "fast"
+			},
+			wantKeys: []string{"monitor.speedtest_sample_interval"},
+		},
+		{
+			name: "notification cooldown not positive",
+			modify: func(cfg *Config) {
+				cfg.Monitor.NotificationCooldown = "0s"
+			},
+			wantKeys: []string{"monitor.notification_cooldown"},
+		},
+		{
+			name: "monitor transport unknown",
+			modify: func(cfg *Config) {
+				cfg.Monitor.Transport = "websocket"
+			},
+			wantKeys: []string{"monitor.transport"},
+		},
+		{
+			name: "tailscale discovery interval reported once",
+			modify: func(cfg *Config) {
+				cfg.Tailscale.Enabled = true
+				cfg.Tailscale.AutoDiscover = true
+				cfg.Tailscale.DiscoveryInterval = "soon"
+			},
+			wantKeys: []string{"tailscale.discovery_interval"},
+		},
+		{
+			name: "librespeed servers",
+			modify: func(cfg *Config) {
+				cfg.SpeedTest.Librespeed.ServersURL = "ftp://example.com/servers.json"
+				cfg.SpeedTest.Librespeed.Servers = []LibrespeedServer{
+					{ID: 1, Name: "lan", Server: "http://speed.lan/"},
+					{ID: 1, Name: "wan", Server: "https://speed.example.com/"},
+				}
+			},
+			wantKeys: []string{"speedtest.librespeed.servers_url", "speedtest.librespeed.servers"},
+		},
+		{
+			name: "speedtest retries",
+			modify: func(cfg *Config) {
+				cfg.SpeedTest.Retries = MaxSpeedTestRetries + 1
+				cfg.SpeedTest.RetryBackoff = "0s"
+			},
+			wantKeys: []string{"speedtest.retries", "speedtest.retry_backoff"},
+		},
+		{
+			name: "telemetry",
+			modify: func(cfg *Config) {
+				cfg.Telemetry = TelemetryConfig{Enabled: true, Endpoint: "localhost:4317", Protocol: "zipkin", SampleRatio: 1.5}
+			},
+			wantKeys: []string{"telemetry.endpoint", "telemetry.protocol", "telemetry.sample_ratio"},
+		},
+		{
+			name: "telemetry disabled is not checked",
+			modify: func(cfg *Config) {
+				cfg.Telemetry.Protocol = "zipkin"
+			},
+		},
+		{
+			name: "tailscale validation included",
+			modify: func(cfg *Config) {
+				cfg.Tailscale.Enabled = true
+				cfg.Tailscale.Method = "tsnet"
+				cfg.Tailscale.AuthKey = ""
+			},
+			wantKeys: []string{"tailscale"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := New()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if len(tt.wantKeys) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var problems ValidationErrors
+			require.ErrorAs(t, err, &problems)
+			keys := make([]string, len(problems))
+			for i, problem := range problems {
+				keys[i] = problem.Key
+			}
+			assert.Equal(t, tt.wantKeys, keys)
+		})
+	}
+}
+
+func TestParseInterfaceThresholds(t *testing.T) {
+	thresholds, err := parseInterfaceThresholds(" eth1=900, eth0 = 500.5 ,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"eth1": 900, "eth0": 500.5}, thresholds)
+
+	_, err = parseInterfaceThresholds("eth1")
+	assert.Error(t, err)
+
+	_, err = parseInterfaceThresholds("eth1=fast")
+	assert.Error(t, err)
+}
+
+func TestParseRoleMapping(t *testing.T) {
+	mapping, err := parseRoleMapping(" netronome-admins=admin, staff = viewer ,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"netronome-admins": "admin", "staff": "viewer"}, mapping)
+
+	_, err = parseRoleMapping("staff")
+	assert.Error(t, err)
+}
+
+func TestLoad_AgentInterfaces(t *testing.T) {
+	t.Setenv("NETRONOME__AGENT_INTERFACES", "bond0, vlan10")
+	t.Setenv("NETRONOME__AGENT_INTERFACE", "eth0")
+
+	cfg, err := Load(writeConfigFile(t, ""))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eth0", "bond0", "vlan10"}, cfg.Agent.Interfaces, "deprecated interface migrates into the list")
+	assert.Empty(t, cfg.Agent.Interface)
+
+	agent := AgentConfig{Interface: "bond0", Interfaces: []string{"bond0", "", "vlan10"}}
+	assert.Equal(t, []string{"bond0", "vlan10"}, agent.MonitoredInterfaces())
+}
+
+func TestLoad_GeoIPDownload(t *testing.T) {
+	t.Setenv("NETRONOME__GEOIP_LICENSE_KEY", "key")
+	t.Setenv("NETRONOME__GEOIP_EDITION_IDS", "GeoLite2-City, GeoLite2-ASN")
+
+	path := writeConfigFile(t, "[geoip]\ndatabase_dir = \"mmdb\"\n")
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "key", cfg.GeoIP.LicenseKey)
+	assert.Equal(t, []string{"GeoLite2-City", "GeoLite2-ASN"}, cfg.GeoIP.EditionIDs)
+	assert.Equal(t, filepath.Join(filepath.Dir(path), "mmdb"), cfg.GeoIP.DatabaseDir, "relative dir resolves next to the config file")
+	assert.Equal(t, "168h", cfg.GeoIP.UpdateInterval)
+}
+
+func TestLoad_QuietHours(t *testing.T) {
+	t.Setenv("NETRONOME__NOTIFICATIONS_QUIET_HOURS_WEEKDAYS", "Saturday, sun")
+
+	cfg, err := Load(writeConfigFile(t, "[notifications.quiet_hours]\nstart = \"23:30\"\nend = \"02:00\"\ntimezone = \"Europe/Stockholm\"\n"))
+	require.NoError(t, err)
+	assert.True(t, cfg.Notifications.QuietHours.Enabled())
+	assert.Equal(t, "23:30", cfg.Notifications.QuietHours.Start)
+	assert.Equal(t, "Europe/Stockholm", cfg.Notifications.QuietHours.Timezone)
+	assert.Equal(t, []string{"Saturday", "sun"}, cfg.Notifications.QuietHours.Weekdays)
+	assert.False(t, New().Notifications.QuietHours.Enabled())
+}
+
+func TestLoad_RateLimit(t *testing.T) {
+	t.Setenv("NETRONOME__SERVER_RATELIMIT_REQUESTS_PER_SECOND", "2.5")
+
+	cfg, err := LoadStrict(writeConfigFile(t, "[server.ratelimit]\nenabled = true\nburst = 20\n"))
+	require.NoError(t, err)
+	assert.Equal(t, RateLimitConfig{Enabled: true, RequestsPerSecond: 2.5, Burst: 20}, cfg.Server.RateLimit)
+	assert.False(t, New().Server.RateLimit.Enabled, "rate limiting is opt-in")
+}
+
+func TestLoad_CORS(t *testing.T) {
+	t.Setenv("NETRONOME__SERVER_CORS_ALLOWED_ORIGINS", "https://app.example.com,http://localhost:5173")
+
+	cfg, err := LoadStrict(writeConfigFile(t, "[server.cors]\nallowed_headers = [\"Content-Type\"]\nallow_credentials = true\n"))
+	require.NoError(t, err)
+	assert.Equal(t, CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "http://localhost:5173"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowedHeaders:   []string{"Content-Type"},
+		AllowCredentials: true,
+	}, cfg.Server.CORS)
+	assert.False(t, New().Server.CORS.Enabled(), "cross-origin requests are opt-in")
+}
+
+func TestLoad_ServerTimeouts(t *testing.T) {
+	t.Setenv("NETRONOME__SERVER_WRITE_TIMEOUT", "10m")
+	t.Setenv("NETRONOME__SERVER_MAX_BODY_BYTES", "65536")
+
+	cfg, err := LoadStrict(writeConfigFile(t, "[server]\nread_timeout = \"1m\"\nidle_timeout = \"0\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "1m", cfg.Server.ReadTimeout)
+	assert.Equal(t, "10s", cfg.Server.ReadHeaderTimeout)
+	assert.Equal(t, "10m", cfg.Server.WriteTimeout)
+	assert.Equal(t, "0", cfg.Server.IdleTimeout)
+	assert.Equal(t, int64(65536), cfg.Server.MaxBodyBytes)
+	assert.Equal(t, int64(DefaultMaxBodyBytes), New().Server.MaxBodyBytes)
+}
+
+func TestLoad_SpeedTestSource(t *testing.T) {
+	t.Setenv("NETRONOME__SPEEDTEST_SOURCE_IP", "192.0.2.10")
+
+	cfg, err := LoadStrict(writeConfigFile(t, "[speedtest]\nsource_interface = \"eth1\"\nuser_agent = \"netronome-probe/1.0\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "eth1", cfg.SpeedTest.SourceInterface)
+	assert.Equal(t, "192.0.2.10", cfg.SpeedTest.SourceIP)
+	assert.Equal(t, "netronome-probe/1.0", cfg.SpeedTest.UserAgent)
+}
+
+func TestLoad_SQLite(t *testing.T) {
+	t.Setenv("NETRONOME__DB_SQLITE_SYNCHRONOUS", "NORMAL")
+
+	cfg, err := LoadStrict(writeConfigFile(t, "[database.sqlite]\nbusy_timeout = \"30s\"\ncache_size = -20000\n"))
+	require.NoError(t, err)
+	assert.Equal(t, SQLiteConfig{BusyTimeout: "30s", JournalMode: "wal", Synchronous: "normal", CacheSize: -20000}, cfg.Database.SQLite)
+}
+
+func TestLoad_Telemetry(t *testing.T) {
+	t.Setenv("NETRONOME__TELEMETRY_PROTOCOL", "HTTP")
+
+	cfg, err := LoadStrict(writeConfigFile(t, "[telemetry]\nenabled = true\nendpoint = \"https://otel.example.com:4318\"\nsample_ratio = 0.25\n\n[telemetry.headers]\nauthorization = \"Bearer token\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, TelemetryConfig{
+		Enabled:     true,
+		Endpoint:    "https://otel.example.com:4318",
+		Protocol:    TelemetryProtocolHTTP,
+		Headers:     map[string]string{"authorization": "Bearer token"},
+		ServiceName: "netronome",
+		SampleRatio: 0.25,
+	}, cfg.Telemetry)
+	assert.False(t, New().Telemetry.Enabled, "telemetry is opt-in")
+}
+
+func TestLoad_Traceroute(t *testing.T) {
+	t.Setenv("NETRONOME__TRACEROUTE_QUERIES", "1")
+	t.Setenv("NETRONOME__TRACEROUTE_NATIVE", "true")
+
+	cfg, err := LoadStrict(writeConfigFile(t, "[speedtest.traceroute]\nmax_hops = 48\n"))
+	require.NoError(t, err)
+	assert.Equal(t, TracerouteConfig{MaxHops: 48, Queries: 1, Native: true}, cfg.SpeedTest.Traceroute)
+
+	cfg, err = Load(writeConfigFile(t, "[speedtest]\ntimeout = 30\n"))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTracerouteMaxHops, cfg.SpeedTest.Traceroute.MaxHops, "configs without the section keep the defaults")
+}
+
+func TestLoad_DataDir(t *testing.T) {
+	t.Run("defaults resolve next to the config file", func(t *testing.T) {
+		path := writeConfigFile(t, "")
+		cfg, err := Load(path)
+		require.NoError(t, err)
+		dir := filepath.Dir(path)
+		assert.Equal(t, filepath.Join(dir, "netronome.db"), cfg.Database.Path)
+		assert.Equal(t, filepath.Join(dir, "librespeed-servers.json"), cfg.SpeedTest.Librespeed.ServersPath)
+		assert.Equal(t, DefaultTailscaleStateDir, cfg.Tailscale.StateDir)
+		assert.Equal(t, filepath.Join(dir, "geoip"), cfg.GeoIP.DatabaseDir)
+	})
+
+	t.Run("data_dir becomes the base", func(t *testing.T) {
+		path := writeConfigFile(t, "data_dir = \"data\"\n[database]\npath = \"db/netronome.db\"\n")
+		cfg, err := LoadStrict(path)
+		require.NoError(t, err)
+		dataDir := filepath.Join(filepath.Dir(path), "data")
+		assert.Equal(t, dataDir, cfg.DataDir, "relative data_dir resolves next to the config file")
+		assert.Equal(t, filepath.Join(dataDir, "db", "netronome.db"), cfg.Database.Path)
+		assert.Equal(t, filepath.Join(dataDir, "librespeed-servers.json"), cfg.SpeedTest.Librespeed.ServersPath)
+		assert.Equal(t, filepath.Join(dataDir, "tsnet"), cfg.Tailscale.StateDir)
+		assert.Equal(t, filepath.Join(dataDir, "geoip"), cfg.GeoIP.DatabaseDir)
+	})
+
+	t.Run("explicit paths win", func(t *testing.T) {
+		dataDir := t.TempDir()
+		t.Setenv("NETRONOME__DATA_DIR", dataDir)
+		t.Setenv("NETRONOME__TAILSCALE_STATE_DIR", "/var/lib/tsnet")
+
+		cfg, err := Load(writeConfigFile(t, "[database]\npath = \"/srv/netronome.db\"\n[geoip]\ndatabase_dir = \"mmdb\"\n"))
+		require.NoError(t, err)
+		assert.Equal(t, dataDir, cfg.DataDir)
+		assert.Equal(t, "/srv/netronome.db", cfg.Database.Path)
+		assert.Equal(t, "/var/lib/tsnet", cfg.Tailscale.StateDir)
+		assert.Equal(t, filepath.Join(dataDir, "mmdb"), cfg.GeoIP.DatabaseDir, "relative paths resolve against data_dir")
+	})
+
+	t.Run("env without a config file", func(t *testing.T) {
+		dataDir := t.TempDir()
+		t.Setenv("NETRONOME__DATA_DIR", dataDir)
+
+		cfg := New()
+		cfg.ApplyEnv()
+		assert.Equal(t, filepath.Join(dataDir, "netronome.db"), cfg.Database.Path)
+		assert.Equal(t, filepath.Join(dataDir, "librespeed-servers.json"), cfg.SpeedTest.Librespeed.ServersPath)
+		assert.Equal(t, filepath.Join(dataDir, "tsnet"), cfg.Tailscale.StateDir)
+		assert.Equal(t, filepath.Join(dataDir, "geoip"), cfg.GeoIP.DatabaseDir)
+	})
+}
+
+func TestParseWeekday(t *testing.T) {
+	for name, want := range map[string]time.Weekday{"mon": time.Monday, "Sunday": time.Sunday, " SAT ": time.Saturday} {
+		got, err := ParseWeekday(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+
+	for _, name := range []string{"", "m", "mo", "funday", "mondays"} {
+		_, err := ParseWeekday(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLoad_SecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "session_secret")
+	require.NoError(t, os.WriteFile(secretPath, []byte("  from-file\n"), 0o600))
+	keyPath := filepath.Join(dir, "auth_key")
+	require.NoError(t, os.WriteFile(keyPath, []byte("tskey-file\n"), 0o600))
+
+	t.Setenv("NETRONOME__SESSION_SECRET", "from-env")
+	t.Setenv("NETRONOME__SESSION_SECRET_FILE", secretPath)
+	t.Setenv("NETRONOME__TAILSCALE_AUTH_KEY_FILE", keyPath)
+	t.Setenv("NETRONOME__DB_PASSWORD", "plain")
+
+	cfg, err := Load(writeConfigFile(t, ""))
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", cfg.Session.Secret, "file should win over the plain variable and be trimmed")
+	assert.Equal(t, "tskey-file", cfg.Tailscale.AuthKey)
+	assert.Equal(t, "plain", cfg.Database.Password, "plain variables still work without _FILE")
+}
+
+func TestLoad_MissingSecretFile(t *testing.T) {
+	t.Setenv("NETRONOME__OIDC_CLIENT_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	_, err := Load(writeConfigFile(t, ""))
+	require.Error(t, err, "a missing secret file should fail even outside strict mode")
+	assert.ErrorIs(t, err, ErrSecretFile)
+	assert.Contains(t, err.Error(), "NETRONOME__OIDC_CLIENT_SECRET_FILE")
+}
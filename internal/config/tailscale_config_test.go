@@ -271,7 +271,7 @@ func TestTailscaleConfig_EnvironmentOverrides(t *testing.T) {
 			
 			// Apply env overrides
 			cfg := tt.initial
-			cfg.loadFromEnv()
+			cfg.loadFromEnv(nil)
 			
 			// Compare
 			assert.Equal(t, tt.expected.Enabled, cfg.Enabled, "Enabled field mismatch")
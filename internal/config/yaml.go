@@ -0,0 +1,84 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// YAML configs use the same keys as TOML. Rather than keeping a second set of struct
+// tags in sync, values pass through a generic map and the toml tags stay authoritative.
+
+// isYAMLPath reports whether path names a YAML config file
+func isYAMLPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// LoadYAML loads a YAML config file, such as one written by generate-config --format yaml,
+// then applies environment variables like Load does.
+func LoadYAML(configPath string) (*Config, error) {
+	if !isYAMLPath(configPath) {
+		return nil, fmt.Errorf("YAML config file %s must have a .yaml or .yml extension", configPath)
+	}
+	return Load(configPath)
+}
+
+// WriteYAML writes the generated default configuration as YAML
+func (c *Config) WriteYAML(w io.Writer) error {
+	cfg, err := generatedConfig()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(cfg); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	values := make(map[string]any)
+	if _, err := toml.Decode(buf.String(), &values); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	if _, err := fmt.Fprintln(w, "# Netronome Configuration"); err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(values); err != nil {
+		return fmt.Errorf("failed to write YAML config: %w", err)
+	}
+	return enc.Close()
+}
+
+// decodeYAMLFile decodes a YAML config file into v, returning TOML metadata so
+// unknown keys are reported the same way as for TOML files
+func decodeYAMLFile(path string, v any) (toml.MetaData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return toml.MetaData{}, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	values := make(map[string]any)
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return toml.MetaData{}, err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(values); err != nil {
+		return toml.MetaData{}, err
+	}
+	return toml.Decode(buf.String(), v)
+}
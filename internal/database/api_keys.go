@@ -0,0 +1,314 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+// API key scopes. Read keys may only make GET requests; write keys may make any request.
+const (
+	APIKeyScopeRead  = "read"
+	APIKeyScopeWrite = "write"
+)
+
+const (
+	// APIKeyTokenPrefix starts every API key, so keys are recognizable in configs and logs
+	APIKeyTokenPrefix = "ntr_"
+	// apiKeyLookupLength is how much of the key, after the token prefix, is stored in
+	// plain text to find its row
+	apiKeyLookupLength = 12
+	apiKeySecretBytes  = 32
+
+	// apiKeyCacheTTL is how long a verified key is accepted without checking its hash
+	// again. Revoking a key drops it from the cache right away.
+	apiKeyCacheTTL = time.Minute
+	// apiKeyUseInterval is how often the last use of a key is written
+	apiKeyUseInterval = time.Minute
+)
+
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// APIKey is a key for programmatic access. The key itself is only returned when it is
+// created; afterwards only its prefix is known.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// Role returns the role requests made with the key get
+func (k *APIKey) Role() string {
+	if slices.Contains(k.Scopes, APIKeyScopeWrite) {
+		return config.RoleAdmin
+	}
+	return config.RoleViewer
+}
+
+// NormalizeAPIKeyScopes lowercases, sorts and deduplicates scopes and checks that each is known
+func NormalizeAPIKeyScopes(scopes []string) ([]string, error) {
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope != APIKeyScopeRead && scope != APIKeyScopeWrite {
+			return nil, fmt.Errorf("%w: unknown scope %q, expected %q or %q", ErrInvalidInput, scope, APIKeyScopeRead, APIKeyScopeWrite)
+		}
+		normalized = append(normalized, scope)
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidInput)
+	}
+
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
+
+// apiKeyPrefix returns the stored prefix of a key, or false when it isn't an API key
+func apiKeyPrefix(key string) (string, bool) {
+	if !strings.HasPrefix(key, APIKeyTokenPrefix) || len(key) <= len(APIKeyTokenPrefix)+apiKeyLookupLength {
+		return "", false
+	}
+	return key[:len(APIKeyTokenPrefix)+apiKeyLookupLength], true
+}
+
+// CreateAPIKey stores a new API key and returns it together with the key itself, which
+// can't be recovered later
+func (s *service) CreateAPIKey(ctx context.Context, name string, scopes []string) (*APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: name is required", ErrInvalidInput)
+	}
+	scopes, err := NormalizeAPIKeyScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := APIKeyTokenPrefix + hex.EncodeToString(secret)
+	prefix, _ := apiKeyPrefix(key)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(key), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to hash API key: %w", err)
+	}
+
+	apiKey := &APIKey{
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   string(hash),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := s.sqlBuilder.
+		Insert("api_keys").
+		Columns("name", "key_prefix", "key_hash", "scopes", "created_at").
+		Values(apiKey.Name, apiKey.Prefix, apiKey.KeyHash, strings.Join(apiKey.Scopes, ","), apiKey.CreatedAt)
+	if apiKey.ID, err = s.insertReturningID(ctx, tx, query); err != nil {
+		return nil, "", fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, "", fmt.Errorf("failed to commit API key: %w", err)
+	}
+
+	return apiKey, key, nil
+}
+
+// GetAPIKeys returns all API keys, newest first
+func (s *service) GetAPIKeys(ctx context.Context) ([]APIKey, error) {
+	rows, err := s.sqlBuilder.
+		Select("id", "name", "key_prefix", "key_hash", "scopes", "created_at", "last_used_at").
+		From("api_keys").
+		OrderBy("created_at DESC", "id DESC").
+		RunWith(s.db).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// DeleteAPIKey revokes an API key
+func (s *service) DeleteAPIKey(ctx context.Context, id int64) error {
+	result, err := s.sqlBuilder.
+		Delete("api_keys").
+		Where(sq.Eq{"id": id}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete API key: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	s.apiKeys.forget(id)
+
+	return nil
+}
+
+// AuthenticateAPIKey returns the API key matching key and records that it was used,
+// at most once per apiKeyUseInterval. Keys verified in the last apiKeyCacheTTL skip
+// the bcrypt check. It returns ErrInvalidAPIKey when no stored key matches.
+func (s *service) AuthenticateAPIKey(ctx context.Context, key string) (*APIKey, error) {
+	prefix, ok := apiKeyPrefix(key)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	digest := sha256.Sum256([]byte(key))
+	apiKey, verifiedAt, ok := s.apiKeys.get(digest, now)
+	if !ok {
+		row := s.sqlBuilder.
+			Select("id", "name", "key_prefix", "key_hash", "scopes", "created_at", "last_used_at").
+			From("api_keys").
+			Where(sq.Eq{"key_prefix": prefix}).
+			RunWith(s.db).
+			QueryRowContext(ctx)
+		stored, err := scanAPIKey(row)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidAPIKey
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if bcrypt.CompareHashAndPassword([]byte(stored.KeyHash), []byte(key)) != nil {
+			return nil, ErrInvalidAPIKey
+		}
+		apiKey, verifiedAt = *stored, now
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyUseInterval {
+		if _, err := s.sqlBuilder.
+			Update("api_keys").
+			Set("last_used_at", now).
+			Where(sq.Eq{"id": apiKey.ID}).
+			RunWith(s.db).
+			ExecContext(ctx); err != nil {
+			return nil, fmt.Errorf("failed to record API key use: %w", err)
+		}
+		apiKey.LastUsedAt = &now
+	}
+
+	s.apiKeys.put(digest, apiKey, verifiedAt)
+	return &apiKey, nil
+}
+
+// apiKeyCache holds recently verified API keys by the SHA-256 of the key
+type apiKeyCache struct {
+	mu   sync.Mutex
+	keys map[[sha256.Size]byte]cachedAPIKey
+}
+
+type cachedAPIKey struct {
+	key        APIKey
+	verifiedAt time.Time
+}
+
+// get returns a key verified less than apiKeyCacheTTL before now
+func (c *apiKeyCache) get(digest [sha256.Size]byte, now time.Time) (APIKey, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.keys[digest]
+	if !ok || now.Sub(cached.verifiedAt) >= apiKeyCacheTTL {
+		return APIKey{}, time.Time{}, false
+	}
+	return cached.key, cached.verifiedAt, true
+}
+
+func (c *apiKeyCache) put(digest [sha256.Size]byte, key APIKey, verifiedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.keys == nil {
+		c.keys = make(map[[sha256.Size]byte]cachedAPIKey)
+	}
+	for d, cached := range c.keys {
+		if time.Since(cached.verifiedAt) >= apiKeyCacheTTL {
+			delete(c.keys, d)
+		}
+	}
+	c.keys[digest] = cachedAPIKey{key: key, verifiedAt: verifiedAt}
+}
+
+// forget drops the cached entries of a revoked key
+func (c *apiKeyCache) forget(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for d, cached := range c.keys {
+		if cached.key.ID == id {
+			delete(c.keys, d)
+		}
+	}
+}
+
+func scanAPIKey(row sq.RowScanner) (*APIKey, error) {
+	var key APIKey
+	var scopes string
+	var lastUsedAt sql.NullTime
+
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.KeyHash, &scopes, &key.CreatedAt, &lastUsedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan API key: %w", err)
+	}
+
+	key.Scopes = strings.Split(scopes, ",")
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	return &key, nil
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+func TestAPIKey_Lifecycle(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		created, key, err := td.Service.CreateAPIKey(ctx, " ci ", []string{"Write", "read", "read"})
+		require.NoError(t, err)
+		assert.Equal(t, "ci", created.Name)
+		assert.Equal(t, []string{APIKeyScopeRead, APIKeyScopeWrite}, created.Scopes)
+		assert.True(t, strings.HasPrefix(key, created.Prefix))
+		assert.NotContains(t, created.KeyHash, key, "only the hash is stored")
+		assert.Equal(t, config.RoleAdmin, created.Role())
+
+		keys, err := td.Service.GetAPIKeys(ctx)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.Nil(t, keys[0].LastUsedAt)
+
+		data, err := json.Marshal(keys[0])
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), keys[0].KeyHash)
+
+		authenticated, err := td.Service.AuthenticateAPIKey(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, authenticated.ID)
+
+		keys, err = td.Service.GetAPIKeys(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, keys[0].LastUsedAt, "use is recorded")
+		firstUse := *keys[0].LastUsedAt
+
+		// Used again right away, the key comes from the cache and its use isn't written
+		authenticated, err = td.Service.AuthenticateAPIKey(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, authenticated.ID)
+		keys, err = td.Service.GetAPIKeys(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, keys[0].LastUsedAt)
+		assert.True(t, firstUse.Equal(*keys[0].LastUsedAt))
+
+		// Same prefix, different secret
+		_, err = td.Service.AuthenticateAPIKey(ctx, key[:len(key)-1]+"x")
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		_, err = td.Service.AuthenticateAPIKey(ctx, "ntr_short")
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+		require.NoError(t, td.Service.DeleteAPIKey(ctx, created.ID))
+		_, err = td.Service.AuthenticateAPIKey(ctx, key)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey, "revoked keys stop working")
+		assert.ErrorIs(t, td.Service.DeleteAPIKey(ctx, created.ID), ErrNotFound)
+	})
+}
+
+func TestAPIKey_InvalidInput(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		_, _, err := td.Service.CreateAPIKey(ctx, "", []string{APIKeyScopeRead})
+		assert.ErrorIs(t, err, ErrInvalidInput)
+		_, _, err = td.Service.CreateAPIKey(ctx, "ci", nil)
+		assert.ErrorIs(t, err, ErrInvalidInput)
+		_, _, err = td.Service.CreateAPIKey(ctx, "ci", []string{"admin"})
+		assert.ErrorIs(t, err, ErrInvalidInput)
+
+		created, _, err := td.Service.CreateAPIKey(ctx, "readonly", []string{APIKeyScopeRead})
+		require.NoError(t, err)
+		assert.Equal(t, config.RoleViewer, created.Role())
+	})
+}
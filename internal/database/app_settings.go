@@ -10,13 +10,15 @@ import (
 	"fmt"
 
 	sq "github.com/Masterminds/squirrel"
+
+	"github.com/autobrr/netronome/internal/config"
 )
 
 func (s *service) GetAppSetting(ctx context.Context, key string) (string, error) {
 	query := s.sqlBuilder.
 		Select("value").
 		From("app_settings").
-		Where(sq.Eq{"key": key})
+		Where(sq.Eq{keyColumn: key})
 
 	var value string
 	err := query.RunWith(s.db).QueryRowContext(ctx).Scan(&value)
@@ -33,9 +35,14 @@ func (s *service) GetAppSetting(ctx context.Context, key string) (string, error)
 func (s *service) SetAppSetting(ctx context.Context, key, value string) error {
 	query := s.sqlBuilder.
 		Insert("app_settings").
-		Columns("key", "value").
-		Values(key, value).
-		Suffix("ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value")
+		Columns(keyColumn, "value").
+		Values(key, value)
+
+	if s.config.Type == config.MySQL {
+		query = query.Suffix("ON DUPLICATE KEY UPDATE value = VALUES(value)")
+	} else {
+		query = query.Suffix(`ON CONFLICT ("key") DO UPDATE SET value = EXCLUDED.value`)
+	}
 
 	if _, err := query.RunWith(s.db).ExecContext(ctx); err != nil {
 		return fmt.Errorf("failed to upsert app setting %q: %w", key, err)
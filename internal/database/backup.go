@@ -0,0 +1,94 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+// ErrBackupExists is returned when the backup destination is already taken
+var ErrBackupExists = errors.New("backup file already exists")
+
+// Backup writes a consistent snapshot of the database to dest. SQLite is copied with
+// VACUUM INTO after checkpointing the WAL; PostgreSQL and MySQL are dumped with
+// pg_dump and mysqldump, which must be installed.
+func (s *service) Backup(ctx context.Context, dest string) error {
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("%w: %s", ErrBackupExists, dest)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to check backup destination: %w", err)
+	}
+
+	switch s.config.Type {
+	case config.SQLite:
+		if err := checkpointSQLite(ctx, s.db.DB); err != nil {
+			return err
+		}
+		if _, err := s.db.ExecContext(ctx, "VACUUM INTO ?", dest); err != nil {
+			return fmt.Errorf("failed to back up SQLite database: %w", err)
+		}
+		if err := os.Chmod(dest, 0o640); err != nil {
+			return fmt.Errorf("failed to set backup file permissions: %w", err)
+		}
+		return nil
+	case config.Postgres:
+		// Custom format is compressed and restores with pg_restore
+		cmd := exec.CommandContext(ctx, "pg_dump",
+			"--host", s.config.Host,
+			"--port", strconv.Itoa(s.config.Port),
+			"--username", s.config.User,
+			"--dbname", s.config.DBName,
+			"--format", "custom",
+			"--no-password",
+			"--file", dest,
+		)
+		cmd.Env = append(os.Environ(), "PGPASSWORD="+s.config.Password)
+		if s.config.SSLMode != "" {
+			cmd.Env = append(cmd.Env, "PGSSLMODE="+s.config.SSLMode)
+		}
+		return runDumpCommand(cmd, dest)
+	case config.MySQL:
+		// --single-transaction takes a consistent snapshot of InnoDB tables without locking them
+		cmd := exec.CommandContext(ctx, "mysqldump",
+			"--host", s.config.Host,
+			"--port", strconv.Itoa(s.config.Port),
+			"--user", s.config.User,
+			"--single-transaction",
+			"--result-file", dest,
+			s.config.DBName,
+		)
+		cmd.Env = append(os.Environ(), "MYSQL_PWD="+s.config.Password)
+		return runDumpCommand(cmd, dest)
+	default:
+		return fmt.Errorf("unsupported database type: %s", s.config.Type)
+	}
+}
+
+// runDumpCommand runs an external dump tool and removes its partial output on failure
+func runDumpCommand(cmd *exec.Cmd, dest string) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		_ = os.Remove(dest)
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s failed: %w: %s", cmd.Args[0], err, msg)
+		}
+		return fmt.Errorf("%s failed: %w", cmd.Args[0], err)
+	}
+
+	if err := os.Chmod(dest, 0o640); err != nil {
+		return fmt.Errorf("failed to set backup file permissions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestBackup_SQLite(t *testing.T) {
+	RunTestWithSQLiteOnly(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		_, err := td.Service.SaveSpeedTest(ctx, types.SpeedTestResult{
+			ServerName:    "Test Server",
+			ServerID:      "test-123",
+			TestType:      "speedtest",
+			DownloadSpeed: 100,
+			UploadSpeed:   50,
+			Latency:       "10ms",
+		})
+		require.NoError(t, err)
+
+		dest := filepath.Join(t.TempDir(), "netronome-backup.bak")
+		require.NoError(t, td.Service.Backup(ctx, dest))
+
+		backup, err := sql.Open("sqlite", "file:"+dest+"?mode=ro")
+		require.NoError(t, err)
+		defer backup.Close()
+
+		var count int
+		require.NoError(t, backup.QueryRow("SELECT COUNT(*) FROM speed_tests").Scan(&count))
+		assert.Equal(t, 1, count)
+
+		// An existing file is never overwritten
+		err = td.Service.Backup(ctx, dest)
+		assert.ErrorIs(t, err, ErrBackupExists)
+
+		info, err := os.Stat(dest)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+	})
+}
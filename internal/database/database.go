@@ -7,6 +7,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -14,6 +16,7 @@ import (
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/go-sql-driver/mysql"
 	_ "github.com/joho/godotenv/autoload"
 	_ "github.com/lib/pq"
 	"github.com/rs/zerolog"
@@ -32,6 +35,13 @@ var (
 	ErrInvalidInput = fmt.Errorf("invalid input")
 )
 
+// Columns named after MySQL reserved words are always quoted. Double quotes work on
+// every backend since MySQL connections enable ANSI_QUOTES.
+const (
+	intervalColumn = `"interval"`
+	keyColumn      = `"key"`
+)
+
 // ZerologAdapter adapts zerolog.Logger to migrator.Logger
 type ZerologAdapter struct {
 	logger zerolog.Logger
@@ -52,8 +62,10 @@ func (z *ZerologAdapter) Printf(format string, args ...interface{}) {
 // Service represents the core database functionality
 type Service interface {
 	Health() map[string]string
+	Ping(ctx context.Context) error
 	Close() error
 	InitializeTables(ctx context.Context) error
+	Backup(ctx context.Context, dest string) error
 	QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row
 
 	// User operations
@@ -62,9 +74,24 @@ type Service interface {
 	ValidatePassword(user *User, password string) bool
 	UpdatePassword(ctx context.Context, username, newPassword string) error
 
+	// API key operations
+	CreateAPIKey(ctx context.Context, name string, scopes []string) (*APIKey, string, error)
+	GetAPIKeys(ctx context.Context) ([]APIKey, error)
+	DeleteAPIKey(ctx context.Context, id int64) error
+	AuthenticateAPIKey(ctx context.Context, key string) (*APIKey, error)
+
 	// SpeedTest operations
 	SaveSpeedTest(ctx context.Context, result types.SpeedTestResult) (*types.SpeedTestResult, error)
-	GetSpeedTests(ctx context.Context, timeRange string, page int, limit int) (*types.PaginatedSpeedTests, error)
+	GetSpeedTests(ctx context.Context, timeRange string, filter types.SpeedTestFilter, page int, limit int) (*types.PaginatedSpeedTests, error)
+	GetSpeedTestRatios(ctx context.Context, testType string, from, to time.Time, limit int) ([]types.SpeedTestRatio, error)
+	UpdateSpeedTestNote(ctx context.Context, id int64, note string) error
+	ExportSpeedTests(ctx context.Context, from, to time.Time, afterID int64, limit int) ([]types.SpeedTestResult, error)
+
+	// Speed test batch operations
+	CreateSpeedTestBatch(ctx context.Context, batch *types.SpeedTestBatch) (*types.SpeedTestBatch, error)
+	UpdateSpeedTestBatchStatus(ctx context.Context, batchID int64, status string, completedAt *time.Time) error
+	UpdateSpeedTestBatchRun(ctx context.Context, run *types.SpeedTestBatchRun) error
+	GetSpeedTestBatch(ctx context.Context, batchID int64) (*types.SpeedTestBatch, error)
 
 	// App settings operations
 	GetAppSetting(ctx context.Context, key string) (string, error)
@@ -74,6 +101,7 @@ type Service interface {
 	CreateSchedule(ctx context.Context, schedule types.Schedule) (*types.Schedule, error)
 	GetSchedules(ctx context.Context) ([]types.Schedule, error)
 	UpdateSchedule(ctx context.Context, schedule types.Schedule) error
+	UpdateScheduleRotation(ctx context.Context, id int64, rotationIndex int, lastServerID string) error
 	DeleteSchedule(ctx context.Context, id int64) error
 
 	// IPerf operations
@@ -92,7 +120,27 @@ type Service interface {
 	GetPacketLossMonitors() ([]*types.PacketLossMonitor, error)
 	GetPacketLossResults(monitorID int64, page int, limit int) (*types.PaginatedPacketLossResults, error)
 	GetPacketLossResultDetail(monitorID int64, resultID int64) (*types.PacketLossResult, error)
+	GetPacketLossHopCountTrend(monitorID int64, since time.Time) (*types.HopCountTrend, error)
+	GetPacketLossSparkline(monitorID int64, limit int) (*types.PacketLossSparkline, error)
+	GetLatestPacketLossHopCount(monitorID int64) (int, error)
+	GetPacketLossMTRData(ctx context.Context, from, to time.Time) ([]types.MTRDataRecord, error)
+	UpdatePacketLossMTRData(ctx context.Context, resultID int64, mtrData string) error
 	UpdatePacketLossMonitorState(monitorID int64, state string) error
+	UpdatePacketLossResultNote(ctx context.Context, monitorID, resultID int64, note string) error
+	ExportPacketLossResults(ctx context.Context, from, to time.Time, afterID int64, limit int) ([]PacketLossExportRow, error)
+
+	// Traceroute monitor operations
+	CreateTracerouteMonitor(ctx context.Context, monitor *types.TracerouteMonitor) (*types.TracerouteMonitor, error)
+	GetTracerouteMonitor(ctx context.Context, monitorID int64) (*types.TracerouteMonitor, error)
+	GetTracerouteMonitors(ctx context.Context) ([]*types.TracerouteMonitor, error)
+	UpdateTracerouteMonitor(ctx context.Context, monitor *types.TracerouteMonitor) error
+	DeleteTracerouteMonitor(ctx context.Context, monitorID int64) error
+	SaveTracerouteMonitorResult(ctx context.Context, result *types.TracerouteMonitorResult) error
+	GetLatestTracerouteMonitorResult(ctx context.Context, monitorID int64) (*types.TracerouteMonitorResult, error)
+	GetTracerouteMonitorResults(ctx context.Context, monitorID int64, page int, limit int) (*types.PaginatedTracerouteMonitorResults, error)
+	GetTracerouteHopCountTrend(ctx context.Context, monitorID int64, since time.Time) (*types.HopCountTrend, error)
+	GetTracerouteMonitorHops(ctx context.Context, from, to time.Time) ([]types.TracerouteHopsRecord, error)
+	UpdateTracerouteMonitorResultHops(ctx context.Context, resultID int64, hops string) error
 
 	// Monitor operations
 	CreateMonitorAgent(ctx context.Context, agent *types.MonitorAgent) (*types.MonitorAgent, error)
@@ -113,20 +161,36 @@ type Service interface {
 
 	SaveMonitorResourceStats(ctx context.Context, agentID int64, stats *types.MonitorResourceStats) error
 	GetMonitorResourceStats(ctx context.Context, agentID int64, hours int) ([]types.MonitorResourceStats, error)
+	GetMonitorFleetResourceStats(ctx context.Context, start, end time.Time) (*types.FleetResourceStats, error)
+	GetMonitorLatestResourceStats(ctx context.Context) (map[int64]*types.MonitorResourceStats, error)
+
+	SaveMonitorProcessSnapshot(ctx context.Context, snapshot *types.MonitorProcessSnapshot) error
+	GetMonitorProcessSnapshot(ctx context.Context, agentID int64) (*types.MonitorProcessSnapshot, error)
+
+	SaveMonitorSmartStats(ctx context.Context, agentID int64, devices []types.MonitorSmartDevice) error
+	GetMonitorSmartStats(ctx context.Context, agentID int64) ([]types.MonitorSmartDevice, error)
 
 	SaveMonitorHistoricalSnapshot(ctx context.Context, agentID int64, snapshot *types.MonitorHistoricalSnapshot) error
 	GetMonitorLatestSnapshot(ctx context.Context, agentID int64, periodType string) (*types.MonitorHistoricalSnapshot, error)
+	GetVnstatBandwidthRange(ctx context.Context, agentID int64, start, end time.Time) (*types.MonitorBandwidthUsage, error)
+	GetVnstatInterfaceHistory(ctx context.Context, agentID int64, iface, period string, limit int) (*types.MonitorInterfaceHistory, error)
 
-	CleanupMonitorData(ctx context.Context) error
+	GetMonitorAgentProbes(ctx context.Context, agentID int64) ([]types.MonitorAgentProbe, error)
+	SetMonitorAgentProbes(ctx context.Context, agentID int64, probes []types.MonitorAgentProbe) error
+	SaveMonitorAgentProbeResults(ctx context.Context, agentID int64, results []types.MonitorAgentProbeResult) error
+	GetMonitorAgentProbeResults(ctx context.Context, agentID int64, host string, since time.Time, limit int) ([]types.MonitorAgentProbeResult, error)
+
+	CleanupMonitorData(ctx context.Context, retention types.MonitorRetention) error
 
 	// Embed NotificationService interface
 	NotificationService
 }
 
 type service struct {
-	db         *sql.DB
+	db         *tracedDB
 	config     config.DatabaseConfig
 	sqlBuilder sq.StatementBuilderType
+	apiKeys    apiKeyCache
 }
 
 // Common query building methods
@@ -210,6 +274,12 @@ func New(cfg config.DatabaseConfig) Service {
 			log.Fatal().Err(err).Msg("Failed to open PostgreSQL database")
 		}
 		builder = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	case config.MySQL:
+		db, err = sql.Open("mysql", mysqlDSN(cfg))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to open MySQL database")
+		}
+		builder = sq.StatementBuilder.PlaceholderFormat(sq.Question)
 	case config.SQLite:
 		absPath, err := filepath.Abs(cfg.Path)
 		if err != nil {
@@ -226,7 +296,7 @@ func New(cfg config.DatabaseConfig) Service {
 			log.Fatal().Err(err).Msg("Failed to set database directory permissions")
 		}
 
-		db, err = sql.Open("sqlite", fmt.Sprintf("file:%s?cache=shared&mode=rwc&_foreign_keys=on&_time_format=sqlite", cfg.Path))
+		db, err = sql.Open("sqlite", sqliteDSN(cfg.Path, cfg.SQLite))
 		if err != nil {
 			log.Fatal().Err(err).Msg("Failed to open SQLite database")
 		}
@@ -241,10 +311,11 @@ func New(cfg config.DatabaseConfig) Service {
 		builder = sq.StatementBuilder.PlaceholderFormat(sq.Question)
 	}
 
-	builder = builder.RunWith(db)
+	traced := newTracedDB(db, cfg.Type)
+	builder = builder.RunWith(traced)
 
 	dbInstance = &service{
-		db:         db,
+		db:         traced,
 		config:     cfg,
 		sqlBuilder: builder,
 	}
@@ -252,14 +323,136 @@ func New(cfg config.DatabaseConfig) Service {
 	return dbInstance
 }
 
+// Check opens the configured database read-only and checks that it answers. Unlike New
+// it doesn't create a missing SQLite file or run migrations, and reports failures as
+// errors instead of exiting.
+func Check(ctx context.Context, cfg config.DatabaseConfig) error {
+	var driver, dsn, query string
+	switch cfg.Type {
+	case config.Postgres:
+		driver = "postgres"
+		dsn = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+			cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode)
+		query = "SELECT 1"
+	case config.MySQL:
+		driver, dsn, query = "mysql", mysqlDSN(cfg), "SELECT 1"
+	case config.SQLite:
+		absPath, err := filepath.Abs(cfg.Path)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute database path: %w", err)
+		}
+		if _, err := os.Stat(absPath); err != nil {
+			return fmt.Errorf("failed to find database file: %w", err)
+		}
+		// Reading the schema makes the driver open the file and check that it is a database
+		driver, dsn, query = "sqlite", "file:"+absPath+"?mode=ro", "SELECT COUNT(*) FROM sqlite_master"
+	default:
+		return fmt.Errorf("unsupported database type %q", cfg.Type)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	var result int
+	if err := db.QueryRowContext(ctx, query).Scan(&result); err != nil {
+		return fmt.Errorf("failed to query database: %w", err)
+	}
+	return nil
+}
+
+// mysqlDSN builds the connection string for MySQL and MariaDB. Migrations run
+// several statements at once, timestamps are scanned as time.Time, and
+// ANSI_QUOTES lets double-quoted identifiers mean the same as on the other backends.
+// Updates report matched rather than changed rows like the other backends do, so
+// saving an unchanged value isn't mistaken for a missing row.
+func mysqlDSN(cfg config.DatabaseConfig) string {
+	c := mysql.NewConfig()
+	c.User = cfg.User
+	c.Passwd = cfg.Password
+	c.Net = "tcp"
+	c.Addr = net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	c.DBName = cfg.DBName
+	c.ParseTime = true
+	c.MultiStatements = true
+	c.ClientFoundRows = true
+	c.Params = map[string]string{
+		"sql_mode": "CONCAT(@@sql_mode, ',ANSI_QUOTES')",
+	}
+
+	// Map the Postgres style sslmode onto the driver's tls option
+	switch cfg.SSLMode {
+	case "", "disable":
+		c.TLSConfig = "false"
+	case "require":
+		c.TLSConfig = "skip-verify"
+	case "verify-ca", "verify-full":
+		c.TLSConfig = "true"
+	default:
+		c.TLSConfig = "preferred"
+	}
+
+	return c.FormatDSN()
+}
+
+// Defaults for the SQLite settings left empty in the config
+const (
+	defaultSQLiteBusyTimeout = 5 * time.Second
+	defaultSQLiteJournalMode = "wal"
+)
+
+// sqliteDSN builds the connection string for SQLite. The tuning PRAGMAs go in the DSN
+// so the driver runs them on every connection it opens; busy_timeout, synchronous and
+// cache_size only last for the connection they are run on.
+func sqliteDSN(path string, cfg config.SQLiteConfig) string {
+	params := url.Values{}
+	params.Set("cache", "shared")
+	params.Set("mode", "rwc")
+	params.Set("_foreign_keys", "on")
+	params.Set("_time_format", "sqlite")
+	for _, pragma := range sqlitePragmas(cfg) {
+		params.Add("_pragma", pragma)
+	}
+	return "file:" + path + "?" + params.Encode()
+}
+
+// sqlitePragmas returns the connection PRAGMAs for cfg in the driver's name(value) form
+func sqlitePragmas(cfg config.SQLiteConfig) []string {
+	busyTimeout := defaultSQLiteBusyTimeout
+	if cfg.BusyTimeout != "" {
+		if d, err := time.ParseDuration(cfg.BusyTimeout); err == nil && d >= 0 {
+			busyTimeout = d
+		} else {
+			log.Warn().Str("key", "database.sqlite.busy_timeout").Str("value", cfg.BusyTimeout).Dur("default", defaultSQLiteBusyTimeout).Msg("Invalid busy timeout, using default")
+		}
+	}
+
+	journalMode := defaultSQLiteJournalMode
+	if cfg.JournalMode != "" {
+		journalMode = strings.ToLower(cfg.JournalMode)
+	}
+
+	pragmas := []string{
+		fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()),
+		fmt.Sprintf("journal_mode(%s)", journalMode),
+	}
+	if cfg.Synchronous != "" {
+		pragmas = append(pragmas, fmt.Sprintf("synchronous(%s)", strings.ToLower(cfg.Synchronous)))
+	}
+	if cfg.CacheSize != 0 {
+		pragmas = append(pragmas, fmt.Sprintf("cache_size(%d)", cfg.CacheSize))
+	}
+	return pragmas
+}
+
 func initializeSQLite(db *sql.DB) error {
 	if err := db.Ping(); err != nil {
 		return fmt.Errorf("failed to create database file: %w", err)
 	}
 
 	pragmas := []string{
-		"PRAGMA busy_timeout = 5000",
-		"PRAGMA journal_mode = wal",
 		"PRAGMA analysis_limit = 400",
 		"PRAGMA wal_checkpoint(TRUNCATE)",
 		"PRAGMA foreign_keys = ON",
@@ -274,6 +467,14 @@ func initializeSQLite(db *sql.DB) error {
 	return nil
 }
 
+// checkpointSQLite moves everything in the WAL into the main database file and truncates the WAL
+func checkpointSQLite(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("failed to checkpoint WAL: %w", err)
+	}
+	return nil
+}
+
 func (s *service) Health() map[string]string {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
@@ -305,6 +506,16 @@ func (s *service) Health() map[string]string {
 	return stats
 }
 
+// Ping runs a trivial query, so a database that accepts connections but can't answer
+// queries is reported as down
+func (s *service) Ping(ctx context.Context) error {
+	var one int
+	if err := s.db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return fmt.Errorf("database ping failed: %w", err)
+	}
+	return nil
+}
+
 func (s *service) evaluateHealthStats(dbStats sql.DBStats, stats map[string]string) {
 	if dbStats.OpenConnections > 40 {
 		stats["message"] = "The database is experiencing heavy load."
@@ -321,6 +532,12 @@ func (s *service) evaluateHealthStats(dbStats sql.DBStats, stats map[string]stri
 }
 
 func (s *service) Close() error {
+	if s.config.Type == config.SQLite {
+		if err := checkpointSQLite(context.Background(), s.db.DB); err != nil {
+			log.Warn().Err(err).Msg("Failed to checkpoint SQLite WAL")
+		}
+	}
+
 	log.Info().
 		Str("type", string(s.config.Type)).
 		Msg("Disconnected from database")
@@ -346,7 +563,7 @@ func (s *service) InitializeTables(ctx context.Context) error {
 	// Detect if we're in a test environment to reduce logging verbosity
 	isTest := strings.Contains(os.Args[0], ".test") || strings.HasSuffix(os.Args[0], "/test")
 	logger := &ZerologAdapter{logger: log.Logger, quiet: isTest}
-	m := migrator.NewMigrate(s.db,
+	m := migrator.NewMigrate(s.db.DB,
 		migrator.WithLogger(logger),
 		migrator.WithEmbedFS(migrations.SchemaMigrations),
 	)
@@ -183,14 +183,22 @@ var testTablesToClear = []string{
 	"app_settings",
 	"packet_loss_results",
 	"packet_loss_monitors",
+	"traceroute_monitor_results",
+	"traceroute_monitors",
+	"monitor_process_snapshots",
+	"monitor_agent_probe_results",
+	"monitor_agent_probes",
 	"monitor_historical_snapshots",
 	"monitor_resource_stats",
 	"monitor_peak_stats",
 	"monitor_agent_interfaces",
 	"monitor_agent_system_info",
 	"monitor_agents",
+	"speedtest_batch_runs",
+	"speedtest_batches",
 	"speed_tests",
 	"saved_iperf_servers",
+	"api_keys",
 	"users",
 	// Keep: notification_events, notification_categories, schema_migrations, registration_status
 }
@@ -318,7 +326,7 @@ func SetupTestDatabase(t *testing.T, dbType config.DatabaseType) *TestDatabase {
 
 	// Get the underlying *sql.DB for direct access if needed
 	if svc, ok := td.Service.(*service); ok {
-		td.DB = svc.db
+		td.DB = svc.db.DB
 	}
 
 	// Initialize or clean tables based on database type
@@ -0,0 +1,124 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// PacketLossExportRow is a packet loss result together with the monitor it belongs to
+type PacketLossExportRow struct {
+	types.PacketLossResultSummary
+	MonitorHost string
+	MonitorName string
+}
+
+// ExportSpeedTests returns up to limit speed test results with an ID above afterID, in
+// ID order, so exports can page through all results without holding them in memory.
+// Zero from/to leave that end of the range open.
+func (s *service) ExportSpeedTests(ctx context.Context, from, to time.Time, afterID int64, limit int) ([]types.SpeedTestResult, error) {
+	query := s.sqlBuilder.
+		Select(speedTestColumns...).
+		From("speed_tests").
+		Where(sq.Gt{"id": afterID}).
+		OrderBy("id ASC").
+		Limit(uint64(limit))
+
+	if !from.IsZero() {
+		query = query.Where(sq.GtOrEq{"created_at": from.UTC()})
+	}
+	if !to.IsZero() {
+		query = query.Where(sq.LtOrEq{"created_at": to.UTC()})
+	}
+
+	rows, err := query.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query speed tests: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]types.SpeedTestResult, 0, limit)
+	for rows.Next() {
+		result, err := scanSpeedTest(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating speed test results: %w", err)
+	}
+
+	return results, nil
+}
+
+// ExportPacketLossResults returns up to limit packet loss results of all monitors with an
+// ID above afterID, in ID order. Zero from/to leave that end of the range open.
+func (s *service) ExportPacketLossResults(ctx context.Context, from, to time.Time, afterID int64, limit int) ([]PacketLossExportRow, error) {
+	query := s.sqlBuilder.
+		Select(
+			"r.id", "r.monitor_id", "COALESCE(m.host, '')", "COALESCE(m.name, '')",
+			"r.packet_loss", "r.min_rtt", "r.max_rtt", "r.avg_rtt", "r.std_dev_rtt", "r.jitter",
+			"r.packets_sent", "r.packets_recv", "r.used_mtr", "r.hop_count", "r.privileged_mode",
+			"r.probed_ip", "r.note", "r.created_at",
+		).
+		From("packet_loss_results r").
+		LeftJoin("packet_loss_monitors m ON m.id = r.monitor_id").
+		Where(sq.Gt{"r.id": afterID}).
+		OrderBy("r.id ASC").
+		Limit(uint64(limit))
+
+	if !from.IsZero() {
+		query = query.Where(sq.GtOrEq{"r.created_at": from.UTC()})
+	}
+	if !to.IsZero() {
+		query = query.Where(sq.LtOrEq{"r.created_at": to.UTC()})
+	}
+
+	rows, err := query.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query packet loss results: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]PacketLossExportRow, 0, limit)
+	for rows.Next() {
+		var row PacketLossExportRow
+		if err := rows.Scan(
+			&row.ID,
+			&row.MonitorID,
+			&row.MonitorHost,
+			&row.MonitorName,
+			&row.PacketLoss,
+			&row.MinRTT,
+			&row.MaxRTT,
+			&row.AvgRTT,
+			&row.StdDevRTT,
+			&row.Jitter,
+			&row.PacketsSent,
+			&row.PacketsRecv,
+			&row.UsedMTR,
+			&row.HopCount,
+			&row.PrivilegedMode,
+			&row.ProbedIP,
+			&row.Note,
+			&row.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan packet loss result: %w", err)
+		}
+		row.CreatedAt = row.CreatedAt.UTC()
+		results = append(results, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating packet loss results: %w", err)
+	}
+
+	return results, nil
+}
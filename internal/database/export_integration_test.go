@@ -0,0 +1,96 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestExportSpeedTests(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+		base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+		for i := range 5 {
+			_, err := td.Service.SaveSpeedTest(ctx, types.SpeedTestResult{
+				ServerName:    "Test Server",
+				ServerID:      "test-123",
+				TestType:      "speedtest",
+				DownloadSpeed: float64(100 + i),
+				UploadSpeed:   50,
+				Latency:       "10ms",
+				CreatedAt:     base.Add(time.Duration(i) * time.Hour),
+			})
+			require.NoError(t, err)
+		}
+
+		// Page through everything two at a time
+		var all []types.SpeedTestResult
+		var afterID int64
+		for {
+			page, err := td.Service.ExportSpeedTests(ctx, time.Time{}, time.Time{}, afterID, 2)
+			require.NoError(t, err)
+			all = append(all, page...)
+			if len(page) < 2 {
+				break
+			}
+			afterID = page[len(page)-1].ID
+		}
+		require.Len(t, all, 5)
+		for i, result := range all {
+			assert.Equal(t, float64(100+i), result.DownloadSpeed, "results are in ID order")
+		}
+
+		ranged, err := td.Service.ExportSpeedTests(ctx, base.Add(time.Hour), base.Add(3*time.Hour), 0, 100)
+		require.NoError(t, err)
+		require.Len(t, ranged, 3)
+		assert.Equal(t, base.Add(time.Hour), ranged[0].CreatedAt)
+	})
+}
+
+func TestExportPacketLossResults(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+		base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+		monitor, err := td.Service.CreatePacketLossMonitor(&types.PacketLossMonitor{
+			Name:        "Gateway",
+			Host:        "192.168.1.1",
+			Interval:    "60s",
+			PacketCount: 10,
+			Threshold:   5.0,
+		})
+		require.NoError(t, err)
+
+		for i := range 3 {
+			require.NoError(t, td.Service.SavePacketLossResult(&types.PacketLossResult{
+				MonitorID:   monitor.ID,
+				PacketLoss:  float64(i),
+				AvgRTT:      12.5,
+				PacketsSent: 10,
+				PacketsRecv: 10 - i,
+				CreatedAt:   base.Add(time.Duration(i) * time.Hour),
+			}))
+		}
+
+		rows, err := td.Service.ExportPacketLossResults(ctx, base.Add(time.Hour), time.Time{}, 0, 100)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		assert.Equal(t, "192.168.1.1", rows[0].MonitorHost)
+		assert.Equal(t, "Gateway", rows[0].MonitorName)
+		assert.Equal(t, float64(1), rows[0].PacketLoss)
+		assert.Equal(t, 9, rows[0].PacketsRecv)
+
+		rows, err = td.Service.ExportPacketLossResults(ctx, time.Time{}, time.Time{}, rows[1].ID, 100)
+		require.NoError(t, err)
+		assert.Empty(t, rows)
+	})
+}
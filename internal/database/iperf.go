@@ -44,7 +44,7 @@ func (s *service) SaveIperfServer(ctx context.Context, name, host string, port i
 			return nil, fmt.Errorf("failed to save iperf server: %w", err)
 		}
 
-	case config.SQLite:
+	case config.SQLite, config.MySQL:
 		res, err := s.insert(ctx, "saved_iperf_servers", data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to save iperf server: %w", err)
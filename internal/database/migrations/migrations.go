@@ -13,7 +13,7 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-//go:embed postgres/*.sql sqlite/*.sql
+//go:embed postgres/*.sql sqlite/*.sql mysql/*.sql
 var SchemaMigrations embed.FS
 
 type DatabaseType string
@@ -21,6 +21,7 @@ type DatabaseType string
 const (
 	SQLite   DatabaseType = "sqlite"
 	Postgres DatabaseType = "postgres"
+	MySQL    DatabaseType = "mysql"
 )
 
 // GetMigrationFiles returns the appropriate migration files for the given database type
@@ -34,6 +35,9 @@ func GetMigrationFiles(dbType DatabaseType) ([]string, error) {
 	case SQLite:
 		basePath = "sqlite"
 		suffix = ".sql"
+	case MySQL:
+		basePath = "mysql"
+		suffix = "_mysql.sql"
 	default:
 		return nil, fmt.Errorf("unsupported database type: %s", dbType)
 	}
@@ -0,0 +1,388 @@
+-- MySQL/MariaDB baseline schema, equivalent to sqlite and postgres migrations 001-039.
+-- Later migrations are added to this directory with the same number as their sqlite
+-- and postgres counterparts.
+--
+-- interval and key are reserved words in MySQL. The connection enables ANSI_QUOTES,
+-- so they are quoted with double quotes like any other identifier.
+
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INT PRIMARY KEY,
+    applied_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    username VARCHAR(255) NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+-- Table to track if registration is allowed
+CREATE TABLE IF NOT EXISTS registration_status (
+    is_registration_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    CONSTRAINT registration_status_single_row UNIQUE (is_registration_enabled)
+);
+
+INSERT INTO registration_status (is_registration_enabled) VALUES (TRUE);
+
+CREATE TABLE IF NOT EXISTS speed_tests (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    server_name VARCHAR(255) NOT NULL,
+    server_id VARCHAR(255) NOT NULL,
+    download_speed DOUBLE NOT NULL,
+    upload_speed DOUBLE NOT NULL,
+    latency VARCHAR(50) NOT NULL,
+    jitter DOUBLE,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    test_type VARCHAR(50) NOT NULL DEFAULT 'speedtest',
+    is_scheduled BOOLEAN NOT NULL DEFAULT FALSE,
+    server_host VARCHAR(255),
+    ttfb DOUBLE,
+    load_rx_bytes_per_second BIGINT,
+    load_tx_bytes_per_second BIGINT,
+    load_cpu_percent DOUBLE,
+    down_up_ratio DOUBLE,
+    path_mtu INT,
+    mtu_warning TEXT,
+    note TEXT
+);
+
+CREATE INDEX idx_speed_tests_test_type ON speed_tests(test_type);
+
+CREATE TABLE IF NOT EXISTS schedules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    server_ids TEXT NOT NULL,
+    "interval" VARCHAR(255) NOT NULL,
+    last_run DATETIME(6),
+    next_run DATETIME(6) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    options TEXT NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    user_id INT,
+    rotation_index INT NOT NULL DEFAULT 0,
+    last_server_id TEXT,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS saved_iperf_servers (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    host VARCHAR(255) NOT NULL,
+    port INT NOT NULL DEFAULT 5201,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX idx_saved_iperf_servers_host ON saved_iperf_servers(host);
+
+-- Packet loss monitoring
+CREATE TABLE IF NOT EXISTS packet_loss_monitors (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    host VARCHAR(255) NOT NULL,
+    name VARCHAR(255),
+    "interval" VARCHAR(50) NOT NULL DEFAULT '60s',
+    packet_count INT DEFAULT 10,
+    enabled BOOLEAN DEFAULT TRUE,
+    threshold DOUBLE DEFAULT 5.0,
+    last_run DATETIME(6),
+    next_run DATETIME(6),
+    last_state VARCHAR(20) DEFAULT 'unknown',
+    last_state_change DATETIME(6),
+    probe_mode VARCHAR(10) NOT NULL DEFAULT 'icmp',
+    probe_port INT NOT NULL DEFAULT 0,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX idx_packet_loss_monitors_host ON packet_loss_monitors(host);
+CREATE INDEX idx_packet_loss_monitors_enabled ON packet_loss_monitors(enabled);
+CREATE INDEX idx_packet_loss_monitors_next_run ON packet_loss_monitors(next_run);
+
+CREATE TABLE IF NOT EXISTS packet_loss_results (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    monitor_id INT NOT NULL,
+    packet_loss DOUBLE NOT NULL,
+    min_rtt DOUBLE,
+    max_rtt DOUBLE,
+    avg_rtt DOUBLE,
+    std_dev_rtt DOUBLE,
+    jitter DOUBLE NOT NULL DEFAULT 0,
+    packets_sent INT,
+    packets_recv INT,
+    used_mtr BOOLEAN DEFAULT FALSE,
+    hop_count INT DEFAULT 0,
+    mtr_data LONGTEXT,
+    privileged_mode BOOLEAN DEFAULT FALSE,
+    note TEXT,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (monitor_id) REFERENCES packet_loss_monitors(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_packet_loss_results_monitor_id ON packet_loss_results(monitor_id);
+CREATE INDEX idx_packet_loss_results_created_at ON packet_loss_results(created_at);
+CREATE INDEX idx_packet_loss_results_used_mtr ON packet_loss_results(used_mtr);
+CREATE INDEX idx_packet_loss_results_monitor_created_at ON packet_loss_results(monitor_id, created_at DESC, id DESC);
+
+-- Monitor agents
+CREATE TABLE IF NOT EXISTS monitor_agents (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    url VARCHAR(500) NOT NULL,
+    enabled BOOLEAN DEFAULT TRUE,
+    interface VARCHAR(50),
+    api_key VARCHAR(255),
+    is_tailscale BOOLEAN NOT NULL DEFAULT FALSE,
+    tailscale_hostname TEXT,
+    discovered_at DATETIME(6),
+    auth_mode VARCHAR(20) NOT NULL DEFAULT 'api_key',
+    token_url TEXT,
+    token_client_id TEXT,
+    token_client_secret TEXT,
+    token_scope TEXT,
+    insecure_skip_verify BOOLEAN NOT NULL DEFAULT FALSE,
+    ca_cert TEXT,
+    interface_include TEXT,
+    interface_exclude TEXT,
+    include_virtual_interfaces BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX idx_monitor_agents_enabled ON monitor_agents(enabled);
+
+CREATE TABLE IF NOT EXISTS monitor_agent_system_info (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    agent_id INT NOT NULL,
+    hostname VARCHAR(255),
+    kernel VARCHAR(255),
+    vnstat_version VARCHAR(50),
+    agent_version VARCHAR(50),
+    cpu_model VARCHAR(255),
+    cpu_cores INT,
+    cpu_threads INT,
+    total_memory BIGINT,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE (agent_id),
+    FOREIGN KEY (agent_id) REFERENCES monitor_agents(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS monitor_agent_interfaces (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    agent_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    alias VARCHAR(255),
+    ip_address VARCHAR(45),
+    link_speed INT, -- Mbps
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE (agent_id, name),
+    FOREIGN KEY (agent_id) REFERENCES monitor_agents(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS monitor_peak_stats (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    agent_id INT NOT NULL,
+    peak_rx_bytes BIGINT,
+    peak_tx_bytes BIGINT,
+    peak_rx_timestamp DATETIME(6),
+    peak_tx_timestamp DATETIME(6),
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (agent_id) REFERENCES monitor_agents(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS monitor_resource_stats (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    agent_id INT NOT NULL,
+    cpu_usage_percent DOUBLE,
+    memory_used_percent DOUBLE,
+    swap_used_percent DOUBLE,
+    disk_used_percent DOUBLE,
+    disk_usage_json LONGTEXT, -- JSON array of disk usage
+    temperature_json LONGTEXT, -- JSON array of temperature sensors
+    uptime_seconds BIGINT,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (agent_id) REFERENCES monitor_agents(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS monitor_historical_snapshots (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    agent_id INT NOT NULL,
+    interface_name VARCHAR(255),
+    period_type VARCHAR(10), -- 'hourly', 'daily', 'monthly'
+    data_json LONGTEXT, -- Compressed JSON of vnstat native data
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (agent_id) REFERENCES monitor_agents(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_peak_stats_agent_time ON monitor_peak_stats(agent_id, created_at DESC);
+CREATE INDEX idx_resource_stats_agent_time ON monitor_resource_stats(agent_id, created_at DESC);
+CREATE INDEX idx_historical_snapshots ON monitor_historical_snapshots(agent_id, period_type, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS monitor_agent_probes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    agent_id INT NOT NULL,
+    host VARCHAR(255) NOT NULL,
+    "interval" VARCHAR(50) NOT NULL DEFAULT '1m',
+    packet_count INT NOT NULL DEFAULT 10,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE (agent_id, host),
+    FOREIGN KEY (agent_id) REFERENCES monitor_agents(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS monitor_agent_probe_results (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    agent_id INT NOT NULL,
+    host VARCHAR(255) NOT NULL,
+    packet_loss DOUBLE NOT NULL DEFAULT 0,
+    min_rtt DOUBLE NOT NULL DEFAULT 0,
+    avg_rtt DOUBLE NOT NULL DEFAULT 0,
+    max_rtt DOUBLE NOT NULL DEFAULT 0,
+    std_dev_rtt DOUBLE NOT NULL DEFAULT 0,
+    packets_sent INT NOT NULL DEFAULT 0,
+    packets_recv INT NOT NULL DEFAULT 0,
+    error TEXT,
+    measured_at DATETIME(6) NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (agent_id) REFERENCES monitor_agents(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_agent_probe_results_agent_time ON monitor_agent_probe_results(agent_id, host, measured_at DESC);
+
+-- Notifications
+CREATE TABLE IF NOT EXISTS notification_channels (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6)
+);
+
+CREATE TABLE IF NOT EXISTS notification_events (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    category VARCHAR(50) NOT NULL, -- speedtest, packetloss, agent, etc.
+    event_type VARCHAR(50) NOT NULL, -- complete, threshold_exceeded, offline, etc.
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    default_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    supports_threshold BOOLEAN NOT NULL DEFAULT FALSE,
+    threshold_unit VARCHAR(20), -- Mbps, ms, %, etc.
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE TABLE IF NOT EXISTS notification_rules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    channel_id INT NOT NULL,
+    event_id INT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    threshold_value DOUBLE,
+    threshold_operator VARCHAR(10), -- 'gt', 'lt', 'eq', 'gte', 'lte'
+    cooldown_seconds INT,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (channel_id) REFERENCES notification_channels(id) ON DELETE CASCADE,
+    FOREIGN KEY (event_id) REFERENCES notification_events(id) ON DELETE CASCADE,
+    UNIQUE (channel_id, event_id)
+);
+
+CREATE TABLE IF NOT EXISTS notification_history (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    channel_id INT NOT NULL,
+    event_id INT NOT NULL,
+    success BOOLEAN NOT NULL,
+    error_message TEXT,
+    payload TEXT, -- JSON payload that was sent
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (channel_id) REFERENCES notification_channels(id) ON DELETE CASCADE,
+    FOREIGN KEY (event_id) REFERENCES notification_events(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_notification_history_created ON notification_history(created_at);
+
+INSERT INTO notification_events (category, event_type, name, description, supports_threshold, threshold_unit) VALUES
+-- Speedtest events
+('speedtest', 'complete', 'Speed Test Complete', 'Notification when any speed test completes', FALSE, NULL),
+('speedtest', 'ping_high', 'High Ping', 'Ping exceeds threshold', TRUE, 'ms'),
+('speedtest', 'download_low', 'Low Download Speed', 'Download speed below threshold', TRUE, 'Mbps'),
+('speedtest', 'upload_low', 'Low Upload Speed', 'Upload speed below threshold', TRUE, 'Mbps'),
+('speedtest', 'failed', 'Speed Test Failed', 'Notification when a speed test fails', FALSE, NULL),
+-- Packet loss events
+('packetloss', 'threshold_exceeded', 'High Packet Loss', 'Packet loss exceeds threshold', TRUE, '%'),
+('packetloss', 'monitor_down', 'Monitor Unreachable', 'Packet loss monitor target is unreachable', FALSE, NULL),
+('packetloss', 'monitor_recovered', 'Monitor Recovered', 'Previously unreachable monitor is back online', FALSE, NULL),
+-- Agent events
+('agent', 'offline', 'Agent Offline', 'Agent has gone offline', FALSE, NULL),
+('agent', 'online', 'Agent Online', 'Agent has come back online', FALSE, NULL),
+('agent', 'high_bandwidth', 'High Bandwidth Usage', 'Bandwidth usage exceeds threshold', TRUE, 'Mbps'),
+('agent', 'disk_space_low', 'Low Disk Space', 'Available disk space below threshold', TRUE, '%'),
+('agent', 'cpu_high', 'High CPU Usage', 'CPU usage exceeds threshold', TRUE, '%'),
+('agent', 'memory_high', 'High Memory Usage', 'Memory usage exceeds threshold', TRUE, '%'),
+('agent', 'temperature_high', 'High Temperature', 'System temperature exceeds threshold', TRUE, '°C'),
+('agent', 'high_swap', 'High Swap Usage', 'Swap usage exceeds threshold', TRUE, '%'),
+('speedtest', 'ratio_shift', 'Download/Upload Ratio Shift', 'Download/upload ratio changed from recent results by more than threshold', TRUE, '%'),
+-- Hidden event used to record channel test sends
+('system', 'test', 'Test Notification', 'Test message sent from the channel settings', FALSE, NULL);
+
+-- Application settings
+CREATE TABLE IF NOT EXISTS app_settings (
+    "key" VARCHAR(255) PRIMARY KEY,
+    value TEXT NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6)
+);
+
+-- Scheduled traceroutes
+CREATE TABLE IF NOT EXISTS traceroute_monitors (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    host VARCHAR(255) NOT NULL,
+    name VARCHAR(255),
+    "interval" VARCHAR(50) NOT NULL DEFAULT '1h',
+    family VARCHAR(10) NOT NULL DEFAULT 'auto',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    last_run DATETIME(6),
+    next_run DATETIME(6),
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE TABLE IF NOT EXISTS traceroute_monitor_results (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    monitor_id INT NOT NULL,
+    destination VARCHAR(255) NOT NULL,
+    ip VARCHAR(45),
+    total_hops INT NOT NULL DEFAULT 0,
+    complete BOOLEAN NOT NULL DEFAULT FALSE,
+    path_changed BOOLEAN NOT NULL DEFAULT FALSE,
+    hops LONGTEXT NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (monitor_id) REFERENCES traceroute_monitors(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_traceroute_monitors_next_run ON traceroute_monitors(next_run);
+CREATE INDEX idx_traceroute_monitor_results_monitor_time ON traceroute_monitor_results(monitor_id, created_at DESC);
+
+-- Speed test matrix batches
+CREATE TABLE IF NOT EXISTS speedtest_batches (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    completed_at DATETIME(6)
+);
+
+CREATE TABLE IF NOT EXISTS speedtest_batch_runs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    batch_id INT NOT NULL,
+    test_type VARCHAR(20) NOT NULL,
+    server VARCHAR(255) NOT NULL DEFAULT '',
+    direction VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    speed_test_id INT,
+    error TEXT,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    completed_at DATETIME(6),
+    FOREIGN KEY (batch_id) REFERENCES speedtest_batches(id) ON DELETE CASCADE,
+    FOREIGN KEY (speed_test_id) REFERENCES speed_tests(id) ON DELETE SET NULL
+);
@@ -0,0 +1,2 @@
+-- Percent of datagrams lost in iperf3 UDP tests
+ALTER TABLE speed_tests ADD COLUMN datagram_loss DOUBLE;
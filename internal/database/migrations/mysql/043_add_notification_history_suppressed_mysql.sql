@@ -0,0 +1,2 @@
+-- Notifications withheld during quiet hours are still recorded
+ALTER TABLE notification_history ADD COLUMN suppressed BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,7 @@
+-- Latest top-process snapshot per agent, served while the agent is offline
+CREATE TABLE IF NOT EXISTS monitor_process_snapshots (
+    agent_id INT PRIMARY KEY,
+    processes MEDIUMTEXT NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    FOREIGN KEY (agent_id) REFERENCES monitor_agents(id) ON DELETE CASCADE
+);
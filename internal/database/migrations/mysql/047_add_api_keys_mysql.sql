@@ -0,0 +1,11 @@
+-- API keys for programmatic access. Only a bcrypt hash of each key is stored; the
+-- prefix identifies the key so a request only has to be checked against one hash.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    key_prefix VARCHAR(32) NOT NULL UNIQUE,
+    key_hash VARCHAR(255) NOT NULL,
+    scopes VARCHAR(255) NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    last_used_at DATETIME(6) NULL
+);
@@ -0,0 +1,2 @@
+-- Custom headers sent with every request to an agent, as a JSON object
+ALTER TABLE monitor_agents ADD COLUMN headers TEXT;
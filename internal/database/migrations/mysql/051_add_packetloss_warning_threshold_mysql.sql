@@ -0,0 +1,9 @@
+-- Separate warning and critical packet loss thresholds per monitor. The existing single
+-- threshold becomes the critical level, and its state is renamed to match.
+ALTER TABLE packet_loss_monitors ADD COLUMN warning_threshold DOUBLE NOT NULL DEFAULT 0;
+ALTER TABLE packet_loss_monitors ADD COLUMN critical_threshold DOUBLE NOT NULL DEFAULT 5.0;
+UPDATE packet_loss_monitors SET critical_threshold = threshold WHERE threshold IS NOT NULL;
+UPDATE packet_loss_monitors SET last_state = 'critical' WHERE last_state = 'threshold_exceeded';
+
+INSERT INTO notification_events (category, event_type, name, description, supports_threshold, threshold_unit) VALUES
+('packetloss', 'threshold_warning', 'Packet Loss Warning', 'Packet loss exceeds the monitor warning threshold', TRUE, '%');
@@ -0,0 +1,18 @@
+-- Latest SMART health per agent disk, served while the agent is offline
+CREATE TABLE IF NOT EXISTS monitor_smart_stats (
+    agent_id INT NOT NULL,
+    device VARCHAR(255) NOT NULL,
+    model VARCHAR(255) NOT NULL DEFAULT '',
+    serial VARCHAR(255) NOT NULL DEFAULT '',
+    health VARCHAR(32) NOT NULL,
+    reallocated_sectors BIGINT NOT NULL DEFAULT 0,
+    pending_sectors BIGINT NOT NULL DEFAULT 0,
+    temperature DOUBLE NOT NULL DEFAULT 0,
+    created_at DATETIME(6) NOT NULL,
+    PRIMARY KEY (agent_id, device),
+    FOREIGN KEY (agent_id) REFERENCES monitor_agents(id) ON DELETE CASCADE
+);
+
+-- Agent disk failing fires when a disk's SMART health turns pre-fail or failing
+INSERT INTO notification_events (category, event_type, name, description, supports_threshold, threshold_unit) VALUES
+('agent', 'disk_failing', 'Agent Disk Failing', 'A disk reports a failing or pre-fail SMART state', FALSE, NULL);
@@ -0,0 +1,2 @@
+-- Add time-to-first-byte column for HTTP-based speed tests (librespeed)
+ALTER TABLE speed_tests ADD COLUMN ttfb REAL;
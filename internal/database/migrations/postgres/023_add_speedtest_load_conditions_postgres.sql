@@ -0,0 +1,4 @@
+-- Record host load at test time so slow results can be correlated with local traffic
+ALTER TABLE speed_tests ADD COLUMN load_rx_bytes_per_second BIGINT;
+ALTER TABLE speed_tests ADD COLUMN load_tx_bytes_per_second BIGINT;
+ALTER TABLE speed_tests ADD COLUMN load_cpu_percent REAL;
@@ -0,0 +1,6 @@
+-- Token auth for agents behind OAuth-style gateways issuing short-lived tokens
+ALTER TABLE monitor_agents ADD COLUMN auth_mode TEXT NOT NULL DEFAULT 'api_key';
+ALTER TABLE monitor_agents ADD COLUMN token_url TEXT;
+ALTER TABLE monitor_agents ADD COLUMN token_client_id TEXT;
+ALTER TABLE monitor_agents ADD COLUMN token_client_secret TEXT;
+ALTER TABLE monitor_agents ADD COLUMN token_scope TEXT;
@@ -0,0 +1,2 @@
+-- Highest disk usage per sample so fleet summaries can aggregate disk load in SQL
+ALTER TABLE monitor_resource_stats ADD COLUMN disk_used_percent REAL;
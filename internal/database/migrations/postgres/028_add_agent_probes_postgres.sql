@@ -0,0 +1,31 @@
+-- Probes run by agents from their own vantage point, configured from the server
+CREATE TABLE monitor_agent_probes (
+    id SERIAL PRIMARY KEY,
+    agent_id INTEGER NOT NULL REFERENCES monitor_agents(id) ON DELETE CASCADE,
+    host TEXT NOT NULL,
+    interval TEXT NOT NULL DEFAULT '1m',
+    packet_count INTEGER NOT NULL DEFAULT 10,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(agent_id, host)
+);
+
+-- Results reported back by agents
+CREATE TABLE monitor_agent_probe_results (
+    id SERIAL PRIMARY KEY,
+    agent_id INTEGER NOT NULL REFERENCES monitor_agents(id) ON DELETE CASCADE,
+    host TEXT NOT NULL,
+    packet_loss REAL NOT NULL DEFAULT 0,
+    min_rtt REAL NOT NULL DEFAULT 0,
+    avg_rtt REAL NOT NULL DEFAULT 0,
+    max_rtt REAL NOT NULL DEFAULT 0,
+    std_dev_rtt REAL NOT NULL DEFAULT 0,
+    packets_sent INTEGER NOT NULL DEFAULT 0,
+    packets_recv INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    measured_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_agent_probes_agent ON monitor_agent_probes(agent_id);
+CREATE INDEX idx_agent_probe_results_agent_time ON monitor_agent_probe_results(agent_id, host, measured_at DESC);
@@ -0,0 +1,3 @@
+-- Probe mode for hosts that block ICMP: icmp (MTR/ping), udp (MTR UDP) or tcp (connects to probe_port)
+ALTER TABLE packet_loss_monitors ADD COLUMN probe_mode TEXT NOT NULL DEFAULT 'icmp';
+ALTER TABLE packet_loss_monitors ADD COLUMN probe_port INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- Per-agent interface filtering so container and VM interfaces aren't stored
+ALTER TABLE monitor_agents ADD COLUMN interface_include TEXT;
+ALTER TABLE monitor_agents ADD COLUMN interface_exclude TEXT;
+ALTER TABLE monitor_agents ADD COLUMN include_virtual_interfaces BOOLEAN NOT NULL DEFAULT FALSE;
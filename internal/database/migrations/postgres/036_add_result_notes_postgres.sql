@@ -0,0 +1,3 @@
+-- Free-form investigation notes attached to individual results
+ALTER TABLE speed_tests ADD COLUMN note TEXT;
+ALTER TABLE packet_loss_results ADD COLUMN note TEXT;
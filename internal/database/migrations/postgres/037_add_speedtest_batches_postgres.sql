@@ -0,0 +1,24 @@
+-- A batch runs a matrix of speed tests (servers x test types x directions) as one job
+CREATE TABLE speedtest_batches (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255),
+    status TEXT NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+-- One row per matrix cell, linked to the stored result once the test finishes
+CREATE TABLE speedtest_batch_runs (
+    id SERIAL PRIMARY KEY,
+    batch_id INTEGER NOT NULL REFERENCES speedtest_batches(id) ON DELETE CASCADE,
+    test_type TEXT NOT NULL,
+    server VARCHAR(255) NOT NULL DEFAULT '',
+    direction TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    speed_test_id INTEGER REFERENCES speed_tests(id) ON DELETE SET NULL,
+    error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX idx_speedtest_batch_runs_batch ON speedtest_batch_runs(batch_id);
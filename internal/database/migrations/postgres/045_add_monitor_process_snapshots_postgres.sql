@@ -0,0 +1,6 @@
+-- Latest top-process snapshot per agent, served while the agent is offline
+CREATE TABLE monitor_process_snapshots (
+    agent_id INTEGER PRIMARY KEY REFERENCES monitor_agents(id) ON DELETE CASCADE,
+    processes TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
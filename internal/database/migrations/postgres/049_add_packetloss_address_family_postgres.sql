@@ -0,0 +1,3 @@
+-- IP version a packet loss monitor probes over, and the address each result probed
+ALTER TABLE packet_loss_monitors ADD COLUMN address_family TEXT NOT NULL DEFAULT 'auto';
+ALTER TABLE packet_loss_results ADD COLUMN probed_ip TEXT;
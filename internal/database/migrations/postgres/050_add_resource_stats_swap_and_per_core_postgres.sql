@@ -0,0 +1,4 @@
+-- Swap totals and per-core CPU usage reported by agents
+ALTER TABLE monitor_resource_stats ADD COLUMN swap_total BIGINT;
+ALTER TABLE monitor_resource_stats ADD COLUMN swap_used BIGINT;
+ALTER TABLE monitor_resource_stats ADD COLUMN cpu_per_core_json TEXT;
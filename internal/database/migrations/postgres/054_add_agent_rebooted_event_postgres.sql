@@ -0,0 +1,4 @@
+-- Agent rebooted fires when an agent's uptime goes backwards between collections
+INSERT INTO notification_events (category, event_type, name, description, supports_threshold, threshold_unit) VALUES
+('agent', 'rebooted', 'Agent Rebooted', 'Agent host rebooted, detected by its uptime going backwards', false, NULL)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,18 @@
+-- Latest SMART health per agent disk, served while the agent is offline
+CREATE TABLE monitor_smart_stats (
+    agent_id INTEGER NOT NULL REFERENCES monitor_agents(id) ON DELETE CASCADE,
+    device VARCHAR(255) NOT NULL,
+    model TEXT NOT NULL DEFAULT '',
+    serial TEXT NOT NULL DEFAULT '',
+    health VARCHAR(32) NOT NULL,
+    reallocated_sectors BIGINT NOT NULL DEFAULT 0,
+    pending_sectors BIGINT NOT NULL DEFAULT 0,
+    temperature DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (agent_id, device)
+);
+
+-- Agent disk failing fires when a disk's SMART health turns pre-fail or failing
+INSERT INTO notification_events (category, event_type, name, description, supports_threshold, threshold_unit) VALUES
+('agent', 'disk_failing', 'Agent Disk Failing', 'A disk reports a failing or pre-fail SMART state', false, NULL)
+ON CONFLICT DO NOTHING;
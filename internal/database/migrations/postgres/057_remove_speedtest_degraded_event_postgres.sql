@@ -0,0 +1,6 @@
+-- The speed test threshold events now only fire when a result crosses their threshold,
+-- which replaces the separate degraded event
+DELETE FROM notification_rules WHERE event_id IN (SELECT id FROM notification_events WHERE category = 'speedtest' AND event_type = 'degraded');
+DELETE FROM notification_history WHERE event_id IN (SELECT id FROM notification_events WHERE category = 'speedtest' AND event_type = 'degraded');
+DELETE FROM notification_events WHERE category = 'speedtest' AND event_type = 'degraded';
+UPDATE notification_events SET description = 'Speed test results are back within the thresholds of the ping, download and upload rules' WHERE category = 'speedtest' AND event_type = 'recovered';
@@ -0,0 +1,3 @@
+-- Add swap usage notification event
+INSERT INTO notification_events (category, event_type, name, description, supports_threshold, threshold_unit) VALUES
+('agent', 'high_swap', 'High Swap Usage', 'Swap usage exceeds threshold', 1, '%');
@@ -0,0 +1,3 @@
+-- Track the rotation cursor and the server used by the latest run of rotating schedules
+ALTER TABLE schedules ADD COLUMN rotation_index INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE schedules ADD COLUMN last_server_id TEXT;
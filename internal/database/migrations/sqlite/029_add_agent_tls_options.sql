@@ -0,0 +1,3 @@
+-- Per-agent TLS settings for agents served with self-signed or internal CA certificates
+ALTER TABLE monitor_agents ADD COLUMN insecure_skip_verify BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE monitor_agents ADD COLUMN ca_cert TEXT;
@@ -0,0 +1,6 @@
+-- Store the download/upload ratio so asymmetry changes can be tracked over time
+ALTER TABLE speed_tests ADD COLUMN down_up_ratio REAL;
+UPDATE speed_tests SET down_up_ratio = download_speed / upload_speed WHERE upload_speed > 0 AND download_speed > 0;
+
+INSERT INTO notification_events (category, event_type, name, description, supports_threshold, threshold_unit) VALUES
+('speedtest', 'ratio_shift', 'Download/Upload Ratio Shift', 'Download/upload ratio changed from recent results by more than threshold', 1, '%');
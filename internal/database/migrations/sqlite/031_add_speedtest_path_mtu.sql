@@ -0,0 +1,3 @@
+-- Path MTU detected by the optional probe run before a speedtest
+ALTER TABLE speed_tests ADD COLUMN path_mtu INTEGER;
+ALTER TABLE speed_tests ADD COLUMN mtu_warning TEXT;
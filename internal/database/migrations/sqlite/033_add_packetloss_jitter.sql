@@ -0,0 +1,2 @@
+-- Jitter is the mean variation between consecutive RTTs in ms; MTR results use the last hop's StDev
+ALTER TABLE packet_loss_results ADD COLUMN jitter REAL NOT NULL DEFAULT 0;
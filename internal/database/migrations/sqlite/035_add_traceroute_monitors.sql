@@ -0,0 +1,29 @@
+-- Traceroutes run on a schedule to track routing changes over time
+CREATE TABLE traceroute_monitors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    host VARCHAR(255) NOT NULL,
+    name VARCHAR(255),
+    interval TEXT NOT NULL DEFAULT '1h',
+    family TEXT NOT NULL DEFAULT 'auto',
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    last_run TIMESTAMP,
+    next_run TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One row per run, with the hop list stored as JSON
+CREATE TABLE traceroute_monitor_results (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    monitor_id INTEGER NOT NULL REFERENCES traceroute_monitors(id) ON DELETE CASCADE,
+    destination VARCHAR(255) NOT NULL,
+    ip VARCHAR(45),
+    total_hops INTEGER NOT NULL DEFAULT 0,
+    complete BOOLEAN NOT NULL DEFAULT 0,
+    path_changed BOOLEAN NOT NULL DEFAULT 0,
+    hops TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_traceroute_monitors_next_run ON traceroute_monitors(next_run);
+CREATE INDEX idx_traceroute_monitor_results_monitor_time ON traceroute_monitor_results(monitor_id, created_at DESC);
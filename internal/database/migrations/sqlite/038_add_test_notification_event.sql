@@ -0,0 +1,3 @@
+-- Channel tests are logged to the notification history under this event
+INSERT INTO notification_events (category, event_type, name, description, supports_threshold, threshold_unit) VALUES
+('system', 'test', 'Test Notification', 'Test message sent from the channel settings', 0, NULL);
@@ -0,0 +1,2 @@
+-- Per-rule notification cooldown in seconds, NULL uses monitor.notification_cooldown
+ALTER TABLE notification_rules ADD COLUMN cooldown_seconds INTEGER;
@@ -0,0 +1,5 @@
+-- Per-agent resource notification thresholds, NULL uses the notification rule threshold
+ALTER TABLE monitor_agents ADD COLUMN cpu_threshold REAL;
+ALTER TABLE monitor_agents ADD COLUMN memory_threshold REAL;
+ALTER TABLE monitor_agents ADD COLUMN disk_threshold REAL;
+ALTER TABLE monitor_agents ADD COLUMN temp_threshold REAL;
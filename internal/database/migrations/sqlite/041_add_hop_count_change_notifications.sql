@@ -0,0 +1,3 @@
+-- Add route hop count change notification event
+INSERT INTO notification_events (category, event_type, name, description, supports_threshold, threshold_unit) VALUES
+('packetloss', 'hop_count_change', 'Hop Count Change', 'Route hop count to the monitored host changed by at least the threshold', 1, 'hops');
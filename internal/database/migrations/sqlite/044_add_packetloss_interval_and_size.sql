@@ -0,0 +1,3 @@
+-- Delay between probe packets and ICMP payload size, 0 uses the defaults (1s, 24 bytes)
+ALTER TABLE packet_loss_monitors ADD COLUMN interval_ms INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE packet_loss_monitors ADD COLUMN packet_size INTEGER NOT NULL DEFAULT 0;
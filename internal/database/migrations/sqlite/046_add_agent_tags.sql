@@ -0,0 +1,3 @@
+-- Agent tags as a JSON array, and the tag a notification rule is limited to (NULL matches every agent)
+ALTER TABLE monitor_agents ADD COLUMN tags TEXT;
+ALTER TABLE notification_rules ADD COLUMN agent_tag TEXT;
@@ -0,0 +1,11 @@
+-- API keys for programmatic access. Only a bcrypt hash of each key is stored; the
+-- prefix identifies the key so a request only has to be checked against one hash.
+CREATE TABLE api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    key_prefix VARCHAR(32) NOT NULL UNIQUE,
+    key_hash TEXT NOT NULL,
+    scopes TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- Optional Go text/template that renders the body posted to a webhook channel
+ALTER TABLE notification_channels ADD COLUMN payload_template TEXT;
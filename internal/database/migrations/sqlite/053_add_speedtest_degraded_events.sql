@@ -0,0 +1,5 @@
+-- Speed test degraded/recovered events only fire when results cross the configured
+-- thresholds, instead of on every result outside them
+INSERT INTO notification_events (category, event_type, name, description, supports_threshold, threshold_unit) VALUES
+('speedtest', 'degraded', 'Speed Test Degraded', 'A speed test result crossed a configured threshold that the previous result was within', 0, NULL),
+('speedtest', 'recovered', 'Speed Test Recovered', 'Speed test results are back within the configured thresholds', 0, NULL);
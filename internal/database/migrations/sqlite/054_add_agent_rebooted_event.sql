@@ -0,0 +1,3 @@
+-- Agent rebooted fires when an agent's uptime goes backwards between collections
+INSERT INTO notification_events (category, event_type, name, description, supports_threshold, threshold_unit) VALUES
+('agent', 'rebooted', 'Agent Rebooted', 'Agent host rebooted, detected by its uptime going backwards', 0, NULL);
@@ -0,0 +1,2 @@
+-- Attempts a speed test took, including retries of failed scheduled runs
+ALTER TABLE speed_tests ADD COLUMN attempts INTEGER NOT NULL DEFAULT 1;
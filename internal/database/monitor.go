@@ -6,6 +6,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -16,16 +17,128 @@ import (
 	"github.com/autobrr/netronome/internal/types"
 )
 
+// monitorAgentColumns lists the monitor_agents columns in the order scanMonitorAgent expects
+var monitorAgentColumns = []string{
+	"id", "name", "url", "api_key", "enabled", "interface", "is_tailscale", "tailscale_hostname", "discovered_at",
+	"auth_mode", "token_url", "token_client_id", "token_client_secret", "token_scope",
+	"insecure_skip_verify", "ca_cert",
+	"interface_include", "interface_exclude", "include_virtual_interfaces",
+	"cpu_threshold", "memory_threshold", "disk_threshold", "temp_threshold",
+	"tags", "headers",
+	"created_at", "updated_at",
+}
+
+// scanMonitorAgent scans a row selected with monitorAgentColumns
+func scanMonitorAgent(row sq.RowScanner, agent *types.MonitorAgent) error {
+	var tags, headers sql.NullString
+	err := row.Scan(
+		&agent.ID,
+		&agent.Name,
+		&agent.URL,
+		&agent.APIKey,
+		&agent.Enabled,
+		&agent.Interface,
+		&agent.IsTailscale,
+		&agent.TailscaleHostname,
+		&agent.DiscoveredAt,
+		&agent.AuthMode,
+		&agent.TokenURL,
+		&agent.TokenClientID,
+		&agent.TokenClientSecret,
+		&agent.TokenScope,
+		&agent.InsecureSkipVerify,
+		&agent.CACert,
+		&agent.InterfaceInclude,
+		&agent.InterfaceExclude,
+		&agent.IncludeVirtualInterfaces,
+		&agent.CPUThreshold,
+		&agent.MemoryThreshold,
+		&agent.DiskThreshold,
+		&agent.TempThreshold,
+		&tags,
+		&headers,
+		&agent.CreatedAt,
+		&agent.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	agent.Tags = []string{}
+	if tags.Valid && tags.String != "" {
+		if err := json.Unmarshal([]byte(tags.String), &agent.Tags); err != nil {
+			return fmt.Errorf("failed to unmarshal agent tags: %w", err)
+		}
+	}
+	if headers.Valid && headers.String != "" {
+		if err := json.Unmarshal([]byte(headers.String), &agent.Headers); err != nil {
+			return fmt.Errorf("failed to unmarshal agent headers: %w", err)
+		}
+	}
+	return nil
+}
+
+// encodeAgentTags stores agent tags as a JSON array
+func encodeAgentTags(tags []string) (string, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	encoded, err := json.Marshal(tags)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal agent tags: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// encodeAgentHeaders stores custom agent headers as a JSON object, NULL when there are none
+func encodeAgentHeaders(headers map[string]string) (*string, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal agent headers: %w", err)
+	}
+	value := string(encoded)
+	return &value, nil
+}
+
 // CreateMonitorAgent creates a new monitoring agent
 func (s *service) CreateMonitorAgent(ctx context.Context, agent *types.MonitorAgent) (*types.MonitorAgent, error) {
 	now := time.Now()
 	agent.CreatedAt = now
 	agent.UpdatedAt = now
+	if agent.AuthMode == "" {
+		agent.AuthMode = types.AgentAuthModeAPIKey
+	}
+	if agent.Tags == nil {
+		agent.Tags = []string{}
+	}
+	tags, err := encodeAgentTags(agent.Tags)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := encodeAgentHeaders(agent.Headers)
+	if err != nil {
+		return nil, err
+	}
 
 	query := s.sqlBuilder.
 		Insert("monitor_agents").
-		Columns("name", "url", "api_key", "enabled", "interface", "is_tailscale", "tailscale_hostname", "discovered_at", "created_at", "updated_at").
-		Values(agent.Name, agent.URL, agent.APIKey, agent.Enabled, agent.Interface, agent.IsTailscale, agent.TailscaleHostname, agent.DiscoveredAt, agent.CreatedAt, agent.UpdatedAt)
+		Columns("name", "url", "api_key", "enabled", "interface", "is_tailscale", "tailscale_hostname", "discovered_at",
+			"auth_mode", "token_url", "token_client_id", "token_client_secret", "token_scope",
+			"insecure_skip_verify", "ca_cert",
+			"interface_include", "interface_exclude", "include_virtual_interfaces",
+			"cpu_threshold", "memory_threshold", "disk_threshold", "temp_threshold",
+			"tags", "headers",
+			"created_at", "updated_at").
+		Values(agent.Name, agent.URL, agent.APIKey, agent.Enabled, agent.Interface, agent.IsTailscale, agent.TailscaleHostname, agent.DiscoveredAt,
+			agent.AuthMode, agent.TokenURL, agent.TokenClientID, agent.TokenClientSecret, agent.TokenScope,
+			agent.InsecureSkipVerify, agent.CACert,
+			agent.InterfaceInclude, agent.InterfaceExclude, agent.IncludeVirtualInterfaces,
+			agent.CPUThreshold, agent.MemoryThreshold, agent.DiskThreshold, agent.TempThreshold,
+			tags, headers,
+			agent.CreatedAt, agent.UpdatedAt)
 
 	if s.config.Type == config.Postgres {
 		query = query.Suffix("RETURNING id")
@@ -51,24 +164,12 @@ func (s *service) CreateMonitorAgent(ctx context.Context, agent *types.MonitorAg
 // GetMonitorAgent retrieves a monitoring agent by ID
 func (s *service) GetMonitorAgent(ctx context.Context, agentID int64) (*types.MonitorAgent, error) {
 	query := s.sqlBuilder.
-		Select("id", "name", "url", "api_key", "enabled", "interface", "is_tailscale", "tailscale_hostname", "discovered_at", "created_at", "updated_at").
+		Select(monitorAgentColumns...).
 		From("monitor_agents").
 		Where(sq.Eq{"id": agentID})
 
 	var agent types.MonitorAgent
-	err := query.RunWith(s.db).QueryRowContext(ctx).Scan(
-		&agent.ID,
-		&agent.Name,
-		&agent.URL,
-		&agent.APIKey,
-		&agent.Enabled,
-		&agent.Interface,
-		&agent.IsTailscale,
-		&agent.TailscaleHostname,
-		&agent.DiscoveredAt,
-		&agent.CreatedAt,
-		&agent.UpdatedAt,
-	)
+	err := scanMonitorAgent(query.RunWith(s.db).QueryRowContext(ctx), &agent)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFound
@@ -82,7 +183,7 @@ func (s *service) GetMonitorAgent(ctx context.Context, agentID int64) (*types.Mo
 // GetMonitorAgents retrieves all monitoring agents
 func (s *service) GetMonitorAgents(ctx context.Context, enabledOnly bool) ([]*types.MonitorAgent, error) {
 	query := s.sqlBuilder.
-		Select("id", "name", "url", "api_key", "enabled", "interface", "is_tailscale", "tailscale_hostname", "discovered_at", "created_at", "updated_at").
+		Select(monitorAgentColumns...).
 		From("monitor_agents").
 		OrderBy("created_at DESC")
 
@@ -99,20 +200,7 @@ func (s *service) GetMonitorAgents(ctx context.Context, enabledOnly bool) ([]*ty
 	agents := make([]*types.MonitorAgent, 0)
 	for rows.Next() {
 		var agent types.MonitorAgent
-		err := rows.Scan(
-			&agent.ID,
-			&agent.Name,
-			&agent.URL,
-			&agent.APIKey,
-			&agent.Enabled,
-			&agent.Interface,
-			&agent.IsTailscale,
-			&agent.TailscaleHostname,
-			&agent.DiscoveredAt,
-			&agent.CreatedAt,
-			&agent.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanMonitorAgent(rows, &agent); err != nil {
 			return nil, fmt.Errorf("failed to scan monitor agent: %w", err)
 		}
 		agents = append(agents, &agent)
@@ -124,6 +212,20 @@ func (s *service) GetMonitorAgents(ctx context.Context, enabledOnly bool) ([]*ty
 // UpdateMonitorAgent updates a monitoring agent
 func (s *service) UpdateMonitorAgent(ctx context.Context, agent *types.MonitorAgent) error {
 	agent.UpdatedAt = time.Now()
+	if agent.AuthMode == "" {
+		agent.AuthMode = types.AgentAuthModeAPIKey
+	}
+	if agent.Tags == nil {
+		agent.Tags = []string{}
+	}
+	tags, err := encodeAgentTags(agent.Tags)
+	if err != nil {
+		return err
+	}
+	headers, err := encodeAgentHeaders(agent.Headers)
+	if err != nil {
+		return err
+	}
 
 	query := s.sqlBuilder.
 		Update("monitor_agents").
@@ -135,10 +237,26 @@ func (s *service) UpdateMonitorAgent(ctx context.Context, agent *types.MonitorAg
 		Set("is_tailscale", agent.IsTailscale).
 		Set("tailscale_hostname", agent.TailscaleHostname).
 		Set("discovered_at", agent.DiscoveredAt).
+		Set("auth_mode", agent.AuthMode).
+		Set("token_url", agent.TokenURL).
+		Set("token_client_id", agent.TokenClientID).
+		Set("token_client_secret", agent.TokenClientSecret).
+		Set("token_scope", agent.TokenScope).
+		Set("insecure_skip_verify", agent.InsecureSkipVerify).
+		Set("ca_cert", agent.CACert).
+		Set("interface_include", agent.InterfaceInclude).
+		Set("interface_exclude", agent.InterfaceExclude).
+		Set("include_virtual_interfaces", agent.IncludeVirtualInterfaces).
+		Set("cpu_threshold", agent.CPUThreshold).
+		Set("memory_threshold", agent.MemoryThreshold).
+		Set("disk_threshold", agent.DiskThreshold).
+		Set("temp_threshold", agent.TempThreshold).
+		Set("tags", tags).
+		Set("headers", headers).
 		Set("updated_at", agent.UpdatedAt).
 		Where(sq.Eq{"id": agent.ID})
 
-	_, err := query.RunWith(s.db).ExecContext(ctx)
+	_, err = query.RunWith(s.db).ExecContext(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to update monitor agent: %w", err)
 	}
@@ -0,0 +1,230 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// Interface history periods, named after the snapshot period types they read
+const (
+	InterfaceHistoryHourly  = "hourly"
+	InterfaceHistoryDaily   = "daily"
+	InterfaceHistoryMonthly = "monthly"
+)
+
+// vnstatTrafficEntry is one hour, day or month bucket of a vnstat JSON export
+type vnstatTrafficEntry struct {
+	Date struct {
+		Year  int `json:"year"`
+		Month int `json:"month"`
+		Day   int `json:"day"`
+	} `json:"date"`
+	Time struct {
+		Hour int `json:"hour"`
+	} `json:"time"`
+	RX int64 `json:"rx"`
+	TX int64 `json:"tx"`
+}
+
+// vnstatExport is the part of the stored agent export needed to sum traffic
+type vnstatExport struct {
+	TimezoneOffset int `json:"timezone_offset"`
+	Interfaces     []struct {
+		Traffic struct {
+			Hour  []vnstatTrafficEntry `json:"hour"`
+			Day   []vnstatTrafficEntry `json:"day"`
+			Month []vnstatTrafficEntry `json:"month"`
+		} `json:"traffic"`
+	} `json:"interfaces"`
+}
+
+// GetVnstatBandwidthRange sums the traffic of an agent between start and end from its
+// latest vnstat snapshot, across all stored interfaces.
+//
+// vnstat keeps a limited number of hour and day buckets, so each part of the range is
+// counted with the largest bucket that lies entirely inside it: whole months, then whole
+// days, then hours. A bucket still in progress (the current hour, day or month) ends
+// after now and is only counted through its smaller buckets. Hours are counted when
+// they start inside the range.
+//
+// Parts of the range older than vnstat's retention have no bucket left. The usage notes
+// from when every interface has data, and flags the totals as partial when that is
+// after start.
+func (s *service) GetVnstatBandwidthRange(ctx context.Context, agentID int64, start, end time.Time) (*types.MonitorBandwidthUsage, error) {
+	snapshot, err := s.GetMonitorLatestSnapshot(ctx, agentID, "vnstat")
+	if err != nil {
+		return nil, err
+	}
+
+	var export vnstatExport
+	if err := json.Unmarshal([]byte(snapshot.DataJSON), &export); err != nil {
+		return nil, fmt.Errorf("failed to parse vnstat snapshot: %w", err)
+	}
+
+	// Buckets are in the agent's local time
+	loc := time.FixedZone("agent", export.TimezoneOffset)
+	inRange := func(from, to time.Time) bool {
+		return !from.Before(start) && !to.After(end)
+	}
+
+	usage := &types.MonitorBandwidthUsage{
+		AgentID: agentID,
+		Start:   start,
+		End:     end,
+	}
+	// earliest is the start of the oldest bucket counted for the current interface
+	var earliest time.Time
+	add := func(from time.Time, entry vnstatTrafficEntry) {
+		usage.Download += entry.RX
+		usage.Upload += entry.TX
+		if earliest.IsZero() || from.Before(earliest) {
+			earliest = from
+		}
+	}
+
+	var coveredFrom time.Time
+	for _, iface := range export.Interfaces {
+		countedMonths := make(map[time.Time]bool)
+		countedDays := make(map[time.Time]bool)
+		earliest = time.Time{}
+
+		for _, entry := range iface.Traffic.Month {
+			month := time.Date(entry.Date.Year, time.Month(entry.Date.Month), 1, 0, 0, 0, 0, loc)
+			if inRange(month, month.AddDate(0, 1, 0)) {
+				countedMonths[month] = true
+				add(month, entry)
+			}
+		}
+
+		for _, entry := range iface.Traffic.Day {
+			day := time.Date(entry.Date.Year, time.Month(entry.Date.Month), entry.Date.Day, 0, 0, 0, 0, loc)
+			month := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, loc)
+			if countedMonths[month] {
+				continue
+			}
+			if inRange(day, day.AddDate(0, 0, 1)) {
+				countedDays[day] = true
+				add(day, entry)
+			}
+		}
+
+		for _, entry := range iface.Traffic.Hour {
+			hour := time.Date(entry.Date.Year, time.Month(entry.Date.Month), entry.Date.Day, entry.Time.Hour, 0, 0, 0, loc)
+			day := time.Date(hour.Year(), hour.Month(), hour.Day(), 0, 0, 0, 0, loc)
+			month := time.Date(hour.Year(), hour.Month(), 1, 0, 0, 0, 0, loc)
+			if countedMonths[month] || countedDays[day] {
+				continue
+			}
+			if !hour.Before(start) && hour.Before(end) {
+				add(hour, entry)
+			}
+		}
+
+		// The range is only covered from where the interface with the shortest
+		// history starts
+		if earliest.IsZero() {
+			coveredFrom = end
+		} else if earliest.After(coveredFrom) {
+			coveredFrom = earliest
+		}
+	}
+
+	usage.Total = usage.Download + usage.Upload
+	if !coveredFrom.IsZero() && coveredFrom.Before(end) {
+		coveredFrom = coveredFrom.UTC()
+		usage.CoveredFrom = &coveredFrom
+	}
+	usage.Partial = usage.CoveredFrom == nil || usage.CoveredFrom.After(start)
+	return usage, nil
+}
+
+// GetVnstatInterfaceHistory returns the traffic of one interface per bucket of period
+// from the interface's latest snapshot of that period, keeping the newest limit buckets
+// when limit is positive.
+//
+// Buckets start in the agent's local time, taken from the latest full vnstat snapshot;
+// without one they are read as UTC.
+func (s *service) GetVnstatInterfaceHistory(ctx context.Context, agentID int64, iface, period string, limit int) (*types.MonitorInterfaceHistory, error) {
+	switch period {
+	case InterfaceHistoryHourly, InterfaceHistoryDaily, InterfaceHistoryMonthly:
+	default:
+		return nil, fmt.Errorf("unknown interface history period %q", period)
+	}
+
+	var dataJSON string
+	var createdAt time.Time
+	err := s.sqlBuilder.
+		Select("data_json", "created_at").
+		From("monitor_historical_snapshots").
+		Where(sq.Eq{"agent_id": agentID, "interface_name": iface, "period_type": period}).
+		OrderBy("created_at DESC", "id DESC").
+		Limit(1).
+		RunWith(s.db).QueryRowContext(ctx).
+		Scan(&dataJSON, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []vnstatTrafficEntry
+	if err := json.Unmarshal([]byte(dataJSON), &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s snapshot of %s: %w", period, iface, err)
+	}
+
+	loc := time.UTC
+	if snapshot, err := s.GetMonitorLatestSnapshot(ctx, agentID, "vnstat"); err == nil {
+		var export vnstatExport
+		if err := json.Unmarshal([]byte(snapshot.DataJSON), &export); err == nil {
+			loc = time.FixedZone("agent", export.TimezoneOffset)
+		}
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	buckets := make([]types.MonitorInterfaceBucket, 0, len(entries))
+	for _, entry := range entries {
+		var start time.Time
+		switch period {
+		case InterfaceHistoryHourly:
+			start = time.Date(entry.Date.Year, time.Month(entry.Date.Month), entry.Date.Day, entry.Time.Hour, 0, 0, 0, loc)
+		case InterfaceHistoryDaily:
+			start = time.Date(entry.Date.Year, time.Month(entry.Date.Month), entry.Date.Day, 0, 0, 0, 0, loc)
+		case InterfaceHistoryMonthly:
+			start = time.Date(entry.Date.Year, time.Month(entry.Date.Month), 1, 0, 0, 0, 0, loc)
+		}
+		buckets = append(buckets, types.MonitorInterfaceBucket{
+			Start: start,
+			RX:    entry.RX,
+			TX:    entry.TX,
+			Total: entry.RX + entry.TX,
+		})
+	}
+
+	// Order explicitly instead of relying on the order of the vnstat export
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Start.Before(buckets[j].Start) })
+	if limit > 0 && len(buckets) > limit {
+		buckets = buckets[len(buckets)-limit:]
+	}
+
+	return &types.MonitorInterfaceHistory{
+		AgentID:   agentID,
+		Interface: iface,
+		Period:    period,
+		UpdatedAt: createdAt,
+		Buckets:   buckets,
+	}, nil
+}
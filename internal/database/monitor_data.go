@@ -6,11 +6,13 @@ package database
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/rs/zerolog/log"
 
+	"github.com/autobrr/netronome/internal/config"
 	"github.com/autobrr/netronome/internal/types"
 )
 
@@ -229,8 +231,8 @@ func (s *service) GetMonitorPeakStats(ctx context.Context, agentID int64) (*type
 func (s *service) SaveMonitorResourceStats(ctx context.Context, agentID int64, stats *types.MonitorResourceStats) error {
 	query := s.sqlBuilder.
 		Insert("monitor_resource_stats").
-		Columns("agent_id", "cpu_usage_percent", "memory_used_percent", "swap_used_percent", "disk_usage_json", "temperature_json", "uptime_seconds").
-		Values(agentID, stats.CPUUsagePercent, stats.MemoryUsedPercent, stats.SwapUsedPercent, stats.DiskUsageJSON, stats.TemperatureJSON, stats.UptimeSeconds)
+		Columns("agent_id", "cpu_usage_percent", "memory_used_percent", "swap_used_percent", "disk_usage_json", "temperature_json", "uptime_seconds", "disk_used_percent", "swap_total", "swap_used", "cpu_per_core_json").
+		Values(agentID, stats.CPUUsagePercent, stats.MemoryUsedPercent, stats.SwapUsedPercent, stats.DiskUsageJSON, stats.TemperatureJSON, stats.UptimeSeconds, stats.DiskUsedPercent, stats.SwapTotal, stats.SwapUsed, stats.CPUPerCoreJSON)
 
 	_, err := query.RunWith(s.db).ExecContext(ctx)
 	return err
@@ -241,7 +243,7 @@ func (s *service) GetMonitorResourceStats(ctx context.Context, agentID int64, ho
 	since := time.Now().Add(-time.Duration(hours) * time.Hour)
 
 	query := s.sqlBuilder.
-		Select("id", "agent_id", "cpu_usage_percent", "memory_used_percent", "swap_used_percent", "disk_usage_json", "temperature_json", "uptime_seconds", "created_at").
+		Select("id", "agent_id", "cpu_usage_percent", "memory_used_percent", "swap_used_percent", "disk_usage_json", "temperature_json", "uptime_seconds", "COALESCE(disk_used_percent, 0)", "COALESCE(swap_total, 0)", "COALESCE(swap_used, 0)", "COALESCE(cpu_per_core_json, '')", "created_at").
 		From("monitor_resource_stats").
 		Where(sq.And{
 			sq.Eq{"agent_id": agentID},
@@ -261,7 +263,8 @@ func (s *service) GetMonitorResourceStats(ctx context.Context, agentID int64, ho
 		if err := rows.Scan(
 			&stat.ID, &stat.AgentID, &stat.CPUUsagePercent, &stat.MemoryUsedPercent,
 			&stat.SwapUsedPercent, &stat.DiskUsageJSON, &stat.TemperatureJSON,
-			&stat.UptimeSeconds, &stat.CreatedAt,
+			&stat.UptimeSeconds, &stat.DiskUsedPercent, &stat.SwapTotal, &stat.SwapUsed,
+			&stat.CPUPerCoreJSON, &stat.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -271,6 +274,222 @@ func (s *service) GetMonitorResourceStats(ctx context.Context, agentID int64, ho
 	return stats, rows.Err()
 }
 
+// GetMonitorLatestResourceStats retrieves the most recent resource stats for every agent, keyed by agent ID
+func (s *service) GetMonitorLatestResourceStats(ctx context.Context) (map[int64]*types.MonitorResourceStats, error) {
+	query := s.sqlBuilder.
+		Select("id", "agent_id", "cpu_usage_percent", "memory_used_percent", "swap_used_percent", "disk_usage_json", "temperature_json", "uptime_seconds", "COALESCE(disk_used_percent, 0)", "COALESCE(swap_total, 0)", "COALESCE(swap_used, 0)", "COALESCE(cpu_per_core_json, '')", "created_at").
+		From("monitor_resource_stats").
+		Where("id IN (SELECT MAX(id) FROM monitor_resource_stats GROUP BY agent_id)")
+
+	rows, err := query.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[int64]*types.MonitorResourceStats)
+	for rows.Next() {
+		var stat types.MonitorResourceStats
+		if err := rows.Scan(
+			&stat.ID, &stat.AgentID, &stat.CPUUsagePercent, &stat.MemoryUsedPercent,
+			&stat.SwapUsedPercent, &stat.DiskUsageJSON, &stat.TemperatureJSON,
+			&stat.UptimeSeconds, &stat.DiskUsedPercent, &stat.SwapTotal, &stat.SwapUsed,
+			&stat.CPUPerCoreJSON, &stat.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		stats[stat.AgentID] = &stat
+	}
+
+	return stats, rows.Err()
+}
+
+// resourceSummaryColumns are the aggregates scanned by scanResourceSummary
+var resourceSummaryColumns = []string{
+	"COUNT(*)",
+	"COALESCE(AVG(rs.cpu_usage_percent), 0)", "COALESCE(MAX(rs.cpu_usage_percent), 0)",
+	"COALESCE(AVG(rs.memory_used_percent), 0)", "COALESCE(MAX(rs.memory_used_percent), 0)",
+	"COALESCE(AVG(rs.swap_used_percent), 0)", "COALESCE(MAX(rs.swap_used_percent), 0)",
+	"AVG(rs.disk_used_percent)", "MAX(rs.disk_used_percent)",
+}
+
+func scanResourceSummary(row sq.RowScanner, summary *types.MonitorResourceSummary, prefix ...any) error {
+	var avgDisk, maxDisk sql.NullFloat64
+	dest := append(prefix,
+		&summary.Samples,
+		&summary.AvgCPUPercent, &summary.MaxCPUPercent,
+		&summary.AvgMemoryPercent, &summary.MaxMemoryPercent,
+		&summary.AvgSwapPercent, &summary.MaxSwapPercent,
+		&avgDisk, &maxDisk,
+	)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	if avgDisk.Valid {
+		summary.AvgDiskPercent = &avgDisk.Float64
+	}
+	if maxDisk.Valid {
+		summary.MaxDiskPercent = &maxDisk.Float64
+	}
+	return nil
+}
+
+// GetMonitorFleetResourceStats aggregates resource stats per agent and fleet-wide between start and end.
+// Agents are ordered by average CPU usage, busiest first.
+func (s *service) GetMonitorFleetResourceStats(ctx context.Context, start, end time.Time) (*types.FleetResourceStats, error) {
+	window := sq.And{
+		sq.GtOrEq{"rs.created_at": start},
+		sq.LtOrEq{"rs.created_at": end},
+	}
+
+	agentQuery := s.sqlBuilder.
+		Select(append([]string{"rs.agent_id", "a.name"}, resourceSummaryColumns...)...).
+		From("monitor_resource_stats rs").
+		Join("monitor_agents a ON a.id = rs.agent_id").
+		Where(window).
+		GroupBy("rs.agent_id", "a.name").
+		OrderBy("COALESCE(AVG(rs.cpu_usage_percent), 0) DESC", "rs.agent_id")
+
+	rows, err := agentQuery.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query agent resource summaries: %w", err)
+	}
+	defer rows.Close()
+
+	fleet := &types.FleetResourceStats{
+		Start:  start,
+		End:    end,
+		Agents: []types.MonitorResourceSummary{},
+	}
+	for rows.Next() {
+		var summary types.MonitorResourceSummary
+		if err := scanResourceSummary(rows, &summary, &summary.AgentID, &summary.AgentName); err != nil {
+			return nil, fmt.Errorf("failed to scan agent resource summary: %w", err)
+		}
+		fleet.Agents = append(fleet.Agents, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	fleet.AgentCount = len(fleet.Agents)
+
+	fleetQuery := s.sqlBuilder.
+		Select(resourceSummaryColumns...).
+		From("monitor_resource_stats rs").
+		Join("monitor_agents a ON a.id = rs.agent_id").
+		Where(window)
+
+	if err := scanResourceSummary(fleetQuery.RunWith(s.db).QueryRowContext(ctx), &fleet.Fleet); err != nil {
+		return nil, fmt.Errorf("failed to query fleet resource summary: %w", err)
+	}
+
+	return fleet, nil
+}
+
+// SaveMonitorProcessSnapshot replaces the stored top-process snapshot of an agent
+func (s *service) SaveMonitorProcessSnapshot(ctx context.Context, snapshot *types.MonitorProcessSnapshot) error {
+	if snapshot.CreatedAt.IsZero() {
+		snapshot.CreatedAt = time.Now()
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	deleteQuery := s.sqlBuilder.Delete("monitor_process_snapshots").Where(sq.Eq{"agent_id": snapshot.AgentID})
+	if _, err := deleteQuery.RunWith(tx).ExecContext(ctx); err != nil {
+		return err
+	}
+
+	insertQuery := s.sqlBuilder.
+		Insert("monitor_process_snapshots").
+		Columns("agent_id", "processes", "created_at").
+		Values(snapshot.AgentID, snapshot.ProcessesJSON, snapshot.CreatedAt)
+	if _, err := insertQuery.RunWith(tx).ExecContext(ctx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetMonitorProcessSnapshot retrieves the stored top-process snapshot of an agent
+func (s *service) GetMonitorProcessSnapshot(ctx context.Context, agentID int64) (*types.MonitorProcessSnapshot, error) {
+	query := s.sqlBuilder.
+		Select("agent_id", "processes", "created_at").
+		From("monitor_process_snapshots").
+		Where(sq.Eq{"agent_id": agentID})
+
+	var snapshot types.MonitorProcessSnapshot
+	err := query.RunWith(s.db).QueryRowContext(ctx).Scan(&snapshot.AgentID, &snapshot.ProcessesJSON, &snapshot.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// SaveMonitorSmartStats replaces the stored SMART health of an agent's disks
+func (s *service) SaveMonitorSmartStats(ctx context.Context, agentID int64, devices []types.MonitorSmartDevice) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	deleteQuery := s.sqlBuilder.Delete("monitor_smart_stats").Where(sq.Eq{"agent_id": agentID})
+	if _, err := deleteQuery.RunWith(tx).ExecContext(ctx); err != nil {
+		return err
+	}
+
+	if len(devices) > 0 {
+		now := time.Now()
+		insertQuery := s.sqlBuilder.
+			Insert("monitor_smart_stats").
+			Columns("agent_id", "device", "model", "serial", "health", "reallocated_sectors", "pending_sectors", "temperature", "created_at")
+		for _, device := range devices {
+			createdAt := device.CreatedAt
+			if createdAt.IsZero() {
+				createdAt = now
+			}
+			insertQuery = insertQuery.Values(agentID, device.Device, device.Model, device.Serial, device.Health, device.ReallocatedSectors, device.PendingSectors, device.Temperature, createdAt)
+		}
+		if _, err := insertQuery.RunWith(tx).ExecContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetMonitorSmartStats retrieves the stored SMART health of an agent's disks, ordered by device
+func (s *service) GetMonitorSmartStats(ctx context.Context, agentID int64) ([]types.MonitorSmartDevice, error) {
+	query := s.sqlBuilder.
+		Select("agent_id", "device", "model", "serial", "health", "reallocated_sectors", "pending_sectors", "temperature", "created_at").
+		From("monitor_smart_stats").
+		Where(sq.Eq{"agent_id": agentID}).
+		OrderBy("device")
+
+	rows, err := query.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []types.MonitorSmartDevice
+	for rows.Next() {
+		var device types.MonitorSmartDevice
+		if err := rows.Scan(&device.AgentID, &device.Device, &device.Model, &device.Serial, &device.Health, &device.ReallocatedSectors, &device.PendingSectors, &device.Temperature, &device.CreatedAt); err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+	return devices, rows.Err()
+}
+
 // SaveMonitorHistoricalSnapshot saves a bandwidth monitoring data snapshot
 func (s *service) SaveMonitorHistoricalSnapshot(ctx context.Context, agentID int64, snapshot *types.MonitorHistoricalSnapshot) error {
 	query := s.sqlBuilder.
@@ -305,8 +524,10 @@ func (s *service) GetMonitorLatestSnapshot(ctx context.Context, agentID int64, p
 	return &snapshot, err
 }
 
-// CleanupMonitorData removes old data based on retention policies
-func (s *service) CleanupMonitorData(ctx context.Context) error {
+// CleanupMonitorData removes monitor data older than its class's retention. A zero
+// retention keeps that class forever, and the latest snapshot of each agent and period
+// type is always kept.
+func (s *service) CleanupMonitorData(ctx context.Context, retention types.MonitorRetention) error {
 	log.Info().Msg("Starting monitor data cleanup")
 
 	// Start transaction
@@ -316,54 +537,73 @@ func (s *service) CleanupMonitorData(ctx context.Context) error {
 	}
 	defer tx.Rollback()
 
-	// Clean up resource stats older than 2 hours
-	// We collect every 30 seconds, so 2 hours gives us ~240 data points which is plenty
-	resourceCutoff := time.Now().Add(-2 * time.Hour)
-	log.Debug().Time("cutoff", resourceCutoff).Msg("Cleaning up resource stats")
+	now := time.Now()
 
-	deleteQuery := s.sqlBuilder.Delete("monitor_resource_stats").Where(sq.Lt{"created_at": resourceCutoff})
-	result, err := deleteQuery.RunWith(tx).ExecContext(ctx)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to cleanup resource stats")
-		return err
-	}
+	if retention.ResourceStats > 0 {
+		resourceCutoff := now.Add(-retention.ResourceStats)
+		log.Debug().Time("cutoff", resourceCutoff).Msg("Cleaning up resource stats")
+
+		deleteQuery := s.sqlBuilder.Delete("monitor_resource_stats").Where(sq.Lt{"created_at": resourceCutoff})
+		result, err := deleteQuery.RunWith(tx).ExecContext(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to cleanup resource stats")
+			return err
+		}
 
-	rowsDeleted, _ := result.RowsAffected()
-	log.Info().Int64("rows_deleted", rowsDeleted).Msg("Cleaned up resource stats")
+		rowsDeleted, _ := result.RowsAffected()
+		log.Info().Int64("rows_deleted", rowsDeleted).Msg("Cleaned up resource stats")
+	}
 
-	// Clean up old historical snapshots - keep only the latest of each type per agent
-	// This is more complex and requires a subquery
-	if s.config.Type == "sqlite" {
-		query := `
-			DELETE FROM monitor_historical_snapshots
-			WHERE id NOT IN (
+	if retention.Snapshots > 0 {
+		// The latest snapshot of each type per agent backs the bandwidth views, so it
+		// survives regardless of age
+		var latest string
+		switch s.config.Type {
+		case config.Postgres:
+			latest = `id NOT IN (
+				SELECT DISTINCT ON (agent_id, period_type) id
+				FROM monitor_historical_snapshots
+				ORDER BY agent_id, period_type, created_at DESC
+			)`
+		case config.MySQL:
+			// MySQL can't select from the table it deletes from, so the subquery is
+			// wrapped in a derived table
+			latest = `id NOT IN (
+				SELECT id FROM (
+					SELECT MAX(id) AS id
+					FROM monitor_historical_snapshots
+					GROUP BY agent_id, period_type
+				) AS latest
+			)`
+		default:
+			latest = `id NOT IN (
 				SELECT MAX(id)
 				FROM monitor_historical_snapshots
 				GROUP BY agent_id, period_type
 			)`
-		result, err := tx.ExecContext(ctx, query)
+		}
+
+		snapshotDelete := s.sqlBuilder.Delete("monitor_historical_snapshots").
+			Where(sq.Lt{"created_at": now.Add(-retention.Snapshots)}).
+			Where(sq.Expr(latest))
+		result, err := snapshotDelete.RunWith(tx).ExecContext(ctx)
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to cleanup historical snapshots")
 			return err
 		}
 		rowsDeleted, _ := result.RowsAffected()
 		log.Info().Int64("snapshots_deleted", rowsDeleted).Msg("Cleaned up historical snapshots")
-	} else {
-		// PostgreSQL version
-		query := `
-			DELETE FROM monitor_historical_snapshots
-			WHERE id NOT IN (
-				SELECT DISTINCT ON (agent_id, period_type) id
-				FROM monitor_historical_snapshots
-				ORDER BY agent_id, period_type, created_at DESC
-			)`
-		result, err := tx.ExecContext(ctx, query)
+	}
+
+	if retention.ProbeResults > 0 {
+		probeDelete := s.sqlBuilder.Delete("monitor_agent_probe_results").Where(sq.Lt{"measured_at": now.Add(-retention.ProbeResults)})
+		result, err := probeDelete.RunWith(tx).ExecContext(ctx)
 		if err != nil {
-			log.Error().Err(err).Msg("Failed to cleanup historical snapshots")
+			log.Error().Err(err).Msg("Failed to cleanup agent probe results")
 			return err
 		}
 		rowsDeleted, _ := result.RowsAffected()
-		log.Info().Int64("snapshots_deleted", rowsDeleted).Msg("Cleaned up historical snapshots")
+		log.Info().Int64("rows_deleted", rowsDeleted).Msg("Cleaned up agent probe results")
 	}
 
 	if err := tx.Commit(); err != nil {
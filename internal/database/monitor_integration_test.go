@@ -75,6 +75,57 @@ func TestMonitorAgent_CRUD(t *testing.T) {
 	})
 }
 
+func TestMonitorAgent_Tags(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		created, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{
+			Name:    "Tagged Agent",
+			URL:     "http://db1.example.com",
+			Enabled: true,
+			Tags:    []string{"eu", "prod-db"},
+		})
+		require.NoError(t, err)
+
+		retrieved, err := td.Service.GetMonitorAgent(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"eu", "prod-db"}, retrieved.Tags)
+
+		retrieved.Tags = nil
+		require.NoError(t, td.Service.UpdateMonitorAgent(ctx, retrieved))
+
+		updated, err := td.Service.GetMonitorAgent(ctx, created.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, updated.Tags, "tags are an empty list rather than null")
+		assert.Empty(t, updated.Tags)
+	})
+}
+
+func TestMonitorAgent_Headers(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		created, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{
+			Name:    "Proxied Agent",
+			URL:     "https://agent.example.com",
+			Enabled: true,
+			Headers: map[string]string{"Remote-User": "netronome", "Proxy-Authorization": "Basic abc"},
+		})
+		require.NoError(t, err)
+
+		retrieved, err := td.Service.GetMonitorAgent(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"Remote-User": "netronome", "Proxy-Authorization": "Basic abc"}, retrieved.Headers)
+
+		retrieved.Headers = nil
+		require.NoError(t, td.Service.UpdateMonitorAgent(ctx, retrieved))
+
+		updated, err := td.Service.GetMonitorAgent(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Empty(t, updated.Headers)
+	})
+}
+
 func TestMonitorAgent_GetEnabledOnly(t *testing.T) {
 	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
 		ctx := context.Background()
@@ -174,6 +225,83 @@ func TestMonitorAgent_SystemInfo(t *testing.T) {
 	})
 }
 
+func TestMonitorAgent_ProcessSnapshot(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		created, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{
+			Name:    "Process Test Agent",
+			URL:     "http://agent.example.com",
+			Enabled: true,
+		})
+		require.NoError(t, err)
+
+		_, err = td.Service.GetMonitorProcessSnapshot(ctx, created.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		first := `{"top_cpu":[{"pid":1,"name":"init"}]}`
+		require.NoError(t, td.Service.SaveMonitorProcessSnapshot(ctx, &types.MonitorProcessSnapshot{AgentID: created.ID, ProcessesJSON: first}))
+
+		second := `{"top_cpu":[{"pid":42,"name":"restic"}]}`
+		require.NoError(t, td.Service.SaveMonitorProcessSnapshot(ctx, &types.MonitorProcessSnapshot{AgentID: created.ID, ProcessesJSON: second}))
+
+		snapshot, err := td.Service.GetMonitorProcessSnapshot(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, second, snapshot.ProcessesJSON, "only the latest snapshot is kept")
+		assert.False(t, snapshot.CreatedAt.IsZero())
+
+		require.NoError(t, td.Service.DeleteMonitorAgent(ctx, created.ID))
+		_, err = td.Service.GetMonitorProcessSnapshot(ctx, created.ID)
+		assert.ErrorIs(t, err, ErrNotFound, "snapshots are deleted with the agent")
+	})
+}
+
+func TestMonitorAgent_SmartStats(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		created, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{
+			Name:    "SMART Test Agent",
+			URL:     "http://agent.example.com",
+			Enabled: true,
+		})
+		require.NoError(t, err)
+
+		devices, err := td.Service.GetMonitorSmartStats(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Empty(t, devices)
+
+		require.NoError(t, td.Service.SaveMonitorSmartStats(ctx, created.ID, []types.MonitorSmartDevice{
+			{Device: "/dev/sdb", Health: types.SmartHealthPassed},
+			{Device: "/dev/sda", Health: types.SmartHealthPassed},
+		}))
+		require.NoError(t, td.Service.SaveMonitorSmartStats(ctx, created.ID, []types.MonitorSmartDevice{
+			{Device: "/dev/sdb", Model: "WDC WD40EFRX", Serial: "WD-123", Health: types.SmartHealthPrefail, ReallocatedSectors: 24, PendingSectors: 8, Temperature: 41},
+			{Device: "/dev/nvme0", Health: types.SmartHealthPassed, Temperature: 38.5},
+		}))
+
+		devices, err = td.Service.GetMonitorSmartStats(ctx, created.ID)
+		require.NoError(t, err)
+		require.Len(t, devices, 2, "only the latest devices are kept")
+		assert.Equal(t, "/dev/nvme0", devices[0].Device)
+		assert.Equal(t, 38.5, devices[0].Temperature)
+
+		sdb := devices[1]
+		assert.Equal(t, created.ID, sdb.AgentID)
+		assert.Equal(t, "WDC WD40EFRX", sdb.Model)
+		assert.Equal(t, "WD-123", sdb.Serial)
+		assert.Equal(t, types.SmartHealthPrefail, sdb.Health)
+		assert.Equal(t, int64(24), sdb.ReallocatedSectors)
+		assert.Equal(t, int64(8), sdb.PendingSectors)
+		assert.False(t, sdb.CreatedAt.IsZero())
+
+		require.NoError(t, td.Service.DeleteMonitorAgent(ctx, created.ID))
+		devices, err = td.Service.GetMonitorSmartStats(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Empty(t, devices, "SMART stats are deleted with the agent")
+	})
+}
+
 func TestMonitorAgent_Interfaces(t *testing.T) {
 	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
 		ctx := context.Background()
@@ -332,6 +460,52 @@ func TestMonitorAgent_ResourceStats(t *testing.T) {
 	})
 }
 
+func TestMonitorAgent_ResourceStatsSwapAndPerCore(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		created, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{
+			Name:    "Per-Core Agent",
+			URL:     "http://agent.example.com",
+			Enabled: true,
+		})
+		require.NoError(t, err)
+
+		// A sample from an older agent leaves the new columns empty
+		require.NoError(t, td.Service.SaveMonitorResourceStats(ctx, created.ID, &types.MonitorResourceStats{
+			CPUUsagePercent: 10,
+		}))
+		require.NoError(t, td.Service.SaveMonitorResourceStats(ctx, created.ID, &types.MonitorResourceStats{
+			CPUUsagePercent: 30,
+			SwapUsedPercent: 25,
+			SwapTotal:       8 << 30,
+			SwapUsed:        2 << 30,
+			CPUPerCoreJSON:  `[20,40]`,
+		}))
+
+		stats, err := td.Service.GetMonitorResourceStats(ctx, created.ID, 24)
+		require.NoError(t, err)
+		require.Len(t, stats, 2)
+
+		// Both samples can share a created_at second, so match them by CPU usage
+		byCPU := make(map[float64]types.MonitorResourceStats)
+		for _, stat := range stats {
+			byCPU[stat.CPUUsagePercent] = stat
+		}
+		assert.Equal(t, int64(8<<30), byCPU[30].SwapTotal)
+		assert.Equal(t, int64(2<<30), byCPU[30].SwapUsed)
+		assert.Equal(t, `[20,40]`, byCPU[30].CPUPerCoreJSON)
+		assert.Zero(t, byCPU[10].SwapTotal)
+		assert.Empty(t, byCPU[10].CPUPerCoreJSON)
+
+		latest, err := td.Service.GetMonitorLatestResourceStats(ctx)
+		require.NoError(t, err)
+		require.Contains(t, latest, created.ID)
+		assert.Equal(t, int64(8<<30), latest[created.ID].SwapTotal)
+		assert.Equal(t, `[20,40]`, latest[created.ID].CPUPerCoreJSON)
+	})
+}
+
 func TestMonitorAgent_HistoricalSnapshot(t *testing.T) {
 	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
 		ctx := context.Background()
@@ -404,10 +578,51 @@ func TestMonitorAgent_CleanupData(t *testing.T) {
 	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
 		ctx := context.Background()
 
-		// This test verifies the cleanup function doesn't error
-		// In a real scenario, we'd need to create old data and verify it's cleaned
-		err := td.Service.CleanupMonitorData(ctx)
-		assert.NoError(t, err)
+		agent, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{
+			Name:    "Retention Agent",
+			URL:     "http://retention.example.com",
+			Enabled: true,
+		})
+		require.NoError(t, err)
+
+		for range 2 {
+			require.NoError(t, td.Service.SaveMonitorResourceStats(ctx, agent.ID, &types.MonitorResourceStats{CPUUsagePercent: 10}))
+			require.NoError(t, td.Service.SaveMonitorHistoricalSnapshot(ctx, agent.ID, &types.MonitorHistoricalSnapshot{
+				InterfaceName: "eth0",
+				PeriodType:    "hourly",
+				DataJSON:      `{}`,
+			}))
+		}
+
+		count := func(table string) int {
+			var n int
+			require.NoError(t, td.DB.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&n))
+			return n
+		}
+		age := func(table string) {
+			query := "UPDATE " + table + " SET created_at = ?"
+			if td.Config.Type == "postgres" {
+				query = "UPDATE " + table + " SET created_at = $1"
+			}
+			_, err := td.DB.Exec(query, time.Now().Add(-48*time.Hour))
+			require.NoError(t, err)
+		}
+		age("monitor_resource_stats")
+		age("monitor_historical_snapshots")
+
+		// Zero retention disables cleanup for every class
+		require.NoError(t, td.Service.CleanupMonitorData(ctx, types.MonitorRetention{}))
+		assert.Equal(t, 2, count("monitor_resource_stats"))
+		assert.Equal(t, 2, count("monitor_historical_snapshots"))
+
+		// Data inside the retention window is kept
+		require.NoError(t, td.Service.CleanupMonitorData(ctx, types.MonitorRetention{ResourceStats: 72 * time.Hour, Snapshots: 72 * time.Hour}))
+		assert.Equal(t, 2, count("monitor_resource_stats"))
+		assert.Equal(t, 2, count("monitor_historical_snapshots"))
+
+		require.NoError(t, td.Service.CleanupMonitorData(ctx, types.MonitorRetention{ResourceStats: time.Hour, Snapshots: time.Hour, ProbeResults: time.Hour}))
+		assert.Equal(t, 0, count("monitor_resource_stats"))
+		assert.Equal(t, 1, count("monitor_historical_snapshots"), "the latest snapshot is always kept")
 	})
 }
 
@@ -440,3 +655,367 @@ func TestMonitorAgent_TailscaleFields(t *testing.T) {
 		assert.Equal(t, *agent.Interface, *retrieved.Interface)
 	})
 }
+
+func TestMonitorAgent_TLSFields(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		created, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{
+			Name:    "HTTPS Agent",
+			URL:     "https://agent.internal:8200",
+			Enabled: true,
+			CACert:  stringPtr("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"),
+		})
+		require.NoError(t, err)
+
+		retrieved, err := td.Service.GetMonitorAgent(ctx, created.ID)
+		require.NoError(t, err)
+		assert.False(t, retrieved.InsecureSkipVerify, "verification should be on by default")
+		require.NotNil(t, retrieved.CACert)
+		assert.Contains(t, *retrieved.CACert, "BEGIN CERTIFICATE")
+
+		retrieved.InsecureSkipVerify = true
+		retrieved.CACert = nil
+		require.NoError(t, td.Service.UpdateMonitorAgent(ctx, retrieved))
+
+		updated, err := td.Service.GetMonitorAgent(ctx, created.ID)
+		require.NoError(t, err)
+		assert.True(t, updated.InsecureSkipVerify)
+		assert.Nil(t, updated.CACert)
+	})
+}
+
+func TestMonitorAgent_InterfaceFilterFields(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		created, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{
+			Name:             "Docker Host",
+			URL:              "http://docker-host:8200",
+			Enabled:          true,
+			InterfaceExclude: stringPtr(`^veth`),
+		})
+		require.NoError(t, err)
+
+		retrieved, err := td.Service.GetMonitorAgent(ctx, created.ID)
+		require.NoError(t, err)
+		assert.False(t, retrieved.IncludeVirtualInterfaces, "virtual interfaces should be filtered by default")
+		assert.Nil(t, retrieved.InterfaceInclude)
+		require.NotNil(t, retrieved.InterfaceExclude)
+		assert.Equal(t, `^veth`, *retrieved.InterfaceExclude)
+
+		retrieved.IncludeVirtualInterfaces = true
+		retrieved.InterfaceInclude = stringPtr(`^eth`)
+		retrieved.InterfaceExclude = nil
+		require.NoError(t, td.Service.UpdateMonitorAgent(ctx, retrieved))
+
+		updated, err := td.Service.GetMonitorAgent(ctx, created.ID)
+		require.NoError(t, err)
+		assert.True(t, updated.IncludeVirtualInterfaces)
+		require.NotNil(t, updated.InterfaceInclude)
+		assert.Equal(t, `^eth`, *updated.InterfaceInclude)
+		assert.Nil(t, updated.InterfaceExclude)
+	})
+}
+
+func TestMonitorAgent_NotificationThresholds(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		created, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{
+			Name:         "Build Server",
+			URL:          "http://build:8200",
+			Enabled:      true,
+			CPUThreshold: float64Ptr(95),
+		})
+		require.NoError(t, err)
+
+		retrieved, err := td.Service.GetMonitorAgent(ctx, created.ID)
+		require.NoError(t, err)
+		require.NotNil(t, retrieved.CPUThreshold)
+		assert.Equal(t, 95.0, *retrieved.CPUThreshold)
+		assert.Nil(t, retrieved.MemoryThreshold)
+		assert.Nil(t, retrieved.DiskThreshold)
+		assert.Nil(t, retrieved.TempThreshold)
+
+		retrieved.CPUThreshold = nil
+		retrieved.DiskThreshold = float64Ptr(90)
+		retrieved.TempThreshold = float64Ptr(85.5)
+		require.NoError(t, td.Service.UpdateMonitorAgent(ctx, retrieved))
+
+		updated, err := td.Service.GetMonitorAgent(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Nil(t, updated.CPUThreshold)
+		require.NotNil(t, updated.DiskThreshold)
+		assert.Equal(t, 90.0, *updated.DiskThreshold)
+		require.NotNil(t, updated.TempThreshold)
+		assert.Equal(t, 85.5, *updated.TempThreshold)
+	})
+}
+
+func TestMonitorAgent_BandwidthRange(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		created, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{Name: "Usage Agent", URL: "http://usage.local", Enabled: true})
+		require.NoError(t, err)
+
+		_, err = td.Service.GetVnstatBandwidthRange(ctx, created.ID, time.Now().Add(-time.Hour), time.Now())
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		require.NoError(t, td.Service.SaveMonitorHistoricalSnapshot(ctx, created.ID, &types.MonitorHistoricalSnapshot{
+			InterfaceName: "all",
+			PeriodType:    "vnstat",
+			DataJSON: `{"timezone_offset": 0, "interfaces": [{"name": "eth0", "traffic": {
+				"month": [
+					{"date": {"year": 2024, "month": 1}, "rx": 1000, "tx": 100},
+					{"date": {"year": 2024, "month": 2}, "rx": 5000, "tx": 500}
+				],
+				"day": [
+					{"date": {"year": 2024, "month": 1, "day": 31}, "rx": 70, "tx": 7},
+					{"date": {"year": 2024, "month": 2, "day": 10}, "rx": 300, "tx": 30},
+					{"date": {"year": 2024, "month": 2, "day": 11}, "rx": 200, "tx": 20}
+				],
+				"hour": [
+					{"date": {"year": 2024, "month": 2, "day": 11}, "time": {"hour": 10, "minute": 0}, "rx": 50, "tx": 5},
+					{"date": {"year": 2024, "month": 2, "day": 11}, "time": {"hour": 11, "minute": 0}, "rx": 60, "tx": 6},
+					{"date": {"year": 2024, "month": 2, "day": 11}, "time": {"hour": 12, "minute": 0}, "rx": 90, "tx": 9}
+				]
+			}}]}`,
+		}))
+
+		// A whole month is counted once, from its month total
+		usage, err := td.Service.GetVnstatBandwidthRange(ctx, created.ID,
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), usage.Download)
+		assert.Equal(t, int64(100), usage.Upload)
+		assert.Equal(t, int64(1100), usage.Total)
+		assert.False(t, usage.Partial)
+
+		// Half of January lies beyond the day buckets vnstat kept
+		usage, err = td.Service.GetVnstatBandwidthRange(ctx, created.ID,
+			time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Equal(t, int64(77), usage.Total)
+		assert.True(t, usage.Partial)
+		require.NotNil(t, usage.CoveredFrom)
+		assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), *usage.CoveredFrom)
+
+		// Nothing vnstat kept lies in the range
+		usage, err = td.Service.GetVnstatBandwidthRange(ctx, created.ID,
+			time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Zero(t, usage.Total)
+		assert.True(t, usage.Partial)
+		assert.Nil(t, usage.CoveredFrom)
+
+		// A whole day plus the hours of a partial one
+		usage, err = td.Service.GetVnstatBandwidthRange(ctx, created.ID,
+			time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 11, 12, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Equal(t, int64(410), usage.Download)
+		assert.Equal(t, int64(41), usage.Upload)
+		assert.Equal(t, int64(451), usage.Total)
+		assert.False(t, usage.Partial)
+	})
+}
+
+func TestMonitorAgent_InterfaceHistory(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		created, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{Name: "History Agent", URL: "http://history.local", Enabled: true})
+		require.NoError(t, err)
+
+		_, err = td.Service.GetVnstatInterfaceHistory(ctx, created.ID, "eth0", InterfaceHistoryDaily, 0)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		_, err = td.Service.GetVnstatInterfaceHistory(ctx, created.ID, "eth0", "weekly", 0)
+		assert.Error(t, err)
+
+		require.NoError(t, td.Service.SaveMonitorHistoricalSnapshot(ctx, created.ID, &types.MonitorHistoricalSnapshot{
+			InterfaceName: "all",
+			PeriodType:    "vnstat",
+			DataJSON:      `{"timezone_offset": 3600, "interfaces": []}`,
+		}))
+		require.NoError(t, td.Service.SaveMonitorHistoricalSnapshot(ctx, created.ID, &types.MonitorHistoricalSnapshot{
+			InterfaceName: "eth0",
+			PeriodType:    "daily",
+			DataJSON: `[
+				{"date": {"year": 2024, "month": 2, "day": 11}, "rx": 200, "tx": 20},
+				{"date": {"year": 2024, "month": 2, "day": 9}, "rx": 100, "tx": 10},
+				{"date": {"year": 2024, "month": 2, "day": 10}, "rx": 300, "tx": 30}
+			]`,
+		}))
+		require.NoError(t, td.Service.SaveMonitorHistoricalSnapshot(ctx, created.ID, &types.MonitorHistoricalSnapshot{
+			InterfaceName: "eth1",
+			PeriodType:    "daily",
+			DataJSON:      `[{"date": {"year": 2024, "month": 2, "day": 11}, "rx": 9, "tx": 9}]`,
+		}))
+
+		history, err := td.Service.GetVnstatInterfaceHistory(ctx, created.ID, "eth0", InterfaceHistoryDaily, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "eth0", history.Interface)
+		assert.Equal(t, InterfaceHistoryDaily, history.Period)
+		require.Len(t, history.Buckets, 3)
+
+		// Oldest first, starting at midnight in the agent's zone
+		first := history.Buckets[0]
+		assert.True(t, first.Start.Equal(time.Date(2024, 2, 8, 23, 0, 0, 0, time.UTC)), first.Start)
+		assert.Equal(t, int64(100), first.RX)
+		assert.Equal(t, int64(10), first.TX)
+		assert.Equal(t, int64(110), first.Total)
+		assert.Equal(t, int64(200), history.Buckets[2].RX)
+
+		// The limit keeps the newest buckets
+		history, err = td.Service.GetVnstatInterfaceHistory(ctx, created.ID, "eth0", InterfaceHistoryDaily, 2)
+		require.NoError(t, err)
+		require.Len(t, history.Buckets, 2)
+		assert.Equal(t, int64(300), history.Buckets[0].RX)
+		assert.Equal(t, int64(200), history.Buckets[1].RX)
+
+		_, err = td.Service.GetVnstatInterfaceHistory(ctx, created.ID, "eth0", InterfaceHistoryHourly, 0)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestMonitorAgent_FleetResourceStats(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		busy, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{Name: "Busy Agent", URL: "http://busy.local", Enabled: true})
+		require.NoError(t, err)
+		idle, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{Name: "Idle Agent", URL: "http://idle.local", Enabled: true})
+		require.NoError(t, err)
+
+		for _, cpu := range []float64{80, 90} {
+			require.NoError(t, td.Service.SaveMonitorResourceStats(ctx, busy.ID, &types.MonitorResourceStats{
+				CPUUsagePercent:   cpu,
+				MemoryUsedPercent: 60,
+				SwapUsedPercent:   5,
+				DiskUsedPercent:   70,
+			}))
+		}
+		require.NoError(t, td.Service.SaveMonitorResourceStats(ctx, idle.ID, &types.MonitorResourceStats{
+			CPUUsagePercent:   10,
+			MemoryUsedPercent: 30,
+			DiskUsedPercent:   40,
+		}))
+
+		now := time.Now()
+		stats, err := td.Service.GetMonitorFleetResourceStats(ctx, now.Add(-time.Hour), now.Add(time.Hour))
+		require.NoError(t, err)
+
+		require.Len(t, stats.Agents, 2)
+		assert.Equal(t, 2, stats.AgentCount)
+
+		// Busiest agent first
+		assert.Equal(t, busy.ID, stats.Agents[0].AgentID)
+		assert.Equal(t, "Busy Agent", stats.Agents[0].AgentName)
+		assert.Equal(t, int64(2), stats.Agents[0].Samples)
+		assert.InDelta(t, 85.0, stats.Agents[0].AvgCPUPercent, 0.01)
+		assert.InDelta(t, 90.0, stats.Agents[0].MaxCPUPercent, 0.01)
+		require.NotNil(t, stats.Agents[0].MaxDiskPercent)
+		assert.InDelta(t, 70.0, *stats.Agents[0].MaxDiskPercent, 0.01)
+
+		assert.Equal(t, idle.ID, stats.Agents[1].AgentID)
+		assert.InDelta(t, 10.0, stats.Agents[1].AvgCPUPercent, 0.01)
+
+		// Fleet-wide summary covers every sample
+		assert.Equal(t, int64(3), stats.Fleet.Samples)
+		assert.InDelta(t, 60.0, stats.Fleet.AvgCPUPercent, 0.01)
+		assert.InDelta(t, 90.0, stats.Fleet.MaxCPUPercent, 0.01)
+		assert.InDelta(t, 50.0, stats.Fleet.AvgMemoryPercent, 0.01)
+		require.NotNil(t, stats.Fleet.AvgDiskPercent)
+		assert.InDelta(t, 60.0, *stats.Fleet.AvgDiskPercent, 0.01)
+
+		// Empty window returns no agents and zero samples
+		empty, err := td.Service.GetMonitorFleetResourceStats(ctx, now.Add(-48*time.Hour), now.Add(-24*time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, empty.Agents)
+		assert.Equal(t, int64(0), empty.Fleet.Samples)
+		assert.Nil(t, empty.Fleet.AvgDiskPercent)
+	})
+}
+
+func TestMonitorAgent_LatestResourceStats(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		first, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{Name: "First Agent", URL: "http://first.local", Enabled: true})
+		require.NoError(t, err)
+		second, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{Name: "Second Agent", URL: "http://second.local", Enabled: true})
+		require.NoError(t, err)
+		_, err = td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{Name: "No Stats Agent", URL: "http://none.local", Enabled: true})
+		require.NoError(t, err)
+
+		for _, cpu := range []float64{10, 20, 30} {
+			require.NoError(t, td.Service.SaveMonitorResourceStats(ctx, first.ID, &types.MonitorResourceStats{CPUUsagePercent: cpu}))
+		}
+		require.NoError(t, td.Service.SaveMonitorResourceStats(ctx, second.ID, &types.MonitorResourceStats{CPUUsagePercent: 55, DiskUsedPercent: 42}))
+
+		latest, err := td.Service.GetMonitorLatestResourceStats(ctx)
+		require.NoError(t, err)
+		require.Len(t, latest, 2)
+
+		require.Contains(t, latest, first.ID)
+		assert.InDelta(t, 30.0, latest[first.ID].CPUUsagePercent, 0.01)
+		require.Contains(t, latest, second.ID)
+		assert.InDelta(t, 55.0, latest[second.ID].CPUUsagePercent, 0.01)
+		assert.InDelta(t, 42.0, latest[second.ID].DiskUsedPercent, 0.01)
+	})
+}
+
+func TestMonitorAgent_Probes(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		agent, err := td.Service.CreateMonitorAgent(ctx, &types.MonitorAgent{Name: "Remote Site", URL: "http://remote.local", Enabled: true})
+		require.NoError(t, err)
+
+		probes := []types.MonitorAgentProbe{
+			{Host: "8.8.8.8", Interval: "1m", PacketCount: 10, Enabled: true},
+			{Host: "1.1.1.1", Interval: "30s", PacketCount: 5, Enabled: false},
+		}
+		require.NoError(t, td.Service.SetMonitorAgentProbes(ctx, agent.ID, probes))
+
+		stored, err := td.Service.GetMonitorAgentProbes(ctx, agent.ID)
+		require.NoError(t, err)
+		require.Len(t, stored, 2)
+		assert.Equal(t, "1.1.1.1", stored[0].Host)
+		assert.False(t, stored[0].Enabled)
+		assert.Equal(t, "8.8.8.8", stored[1].Host)
+		assert.Equal(t, 10, stored[1].PacketCount)
+
+		// Setting probes replaces the previous set
+		require.NoError(t, td.Service.SetMonitorAgentProbes(ctx, agent.ID, probes[:1]))
+		stored, err = td.Service.GetMonitorAgentProbes(ctx, agent.ID)
+		require.NoError(t, err)
+		assert.Len(t, stored, 1)
+
+		err = td.Service.SetMonitorAgentProbes(ctx, agent.ID, []types.MonitorAgentProbe{{Host: "8.8.8.8"}, {Host: "8.8.8.8"}})
+		assert.ErrorIs(t, err, ErrInvalidInput)
+
+		now := time.Now().UTC().Truncate(time.Second)
+		results := []types.MonitorAgentProbeResult{
+			{Host: "8.8.8.8", PacketLoss: 0, AvgRTT: 12.5, PacketsSent: 10, PacketsRecv: 10, MeasuredAt: now.Add(-2 * time.Minute)},
+			{Host: "8.8.8.8", PacketLoss: 20, AvgRTT: 30, PacketsSent: 10, PacketsRecv: 8, MeasuredAt: now.Add(-time.Minute)},
+			{Host: "9.9.9.9", Error: stringPtr("timeout"), MeasuredAt: now},
+		}
+		require.NoError(t, td.Service.SaveMonitorAgentProbeResults(ctx, agent.ID, results))
+
+		all, err := td.Service.GetMonitorAgentProbeResults(ctx, agent.ID, "", now.Add(-time.Hour), 0)
+		require.NoError(t, err)
+		require.Len(t, all, 3)
+		assert.Equal(t, "9.9.9.9", all[0].Host, "results are newest first")
+		require.NotNil(t, all[0].Error)
+		assert.Equal(t, "timeout", *all[0].Error)
+
+		filtered, err := td.Service.GetMonitorAgentProbeResults(ctx, agent.ID, "8.8.8.8", now.Add(-time.Hour), 1)
+		require.NoError(t, err)
+		require.Len(t, filtered, 1)
+		assert.InDelta(t, 20.0, filtered[0].PacketLoss, 0.01)
+		assert.Nil(t, filtered[0].Error)
+	})
+}
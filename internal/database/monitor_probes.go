@@ -0,0 +1,138 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// GetMonitorAgentProbes retrieves the probes configured for an agent
+func (s *service) GetMonitorAgentProbes(ctx context.Context, agentID int64) ([]types.MonitorAgentProbe, error) {
+	query := s.sqlBuilder.
+		Select("id", "agent_id", "host", intervalColumn, "packet_count", "enabled", "created_at").
+		From("monitor_agent_probes").
+		Where(sq.Eq{"agent_id": agentID}).
+		OrderBy("host")
+
+	rows, err := query.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	probes := []types.MonitorAgentProbe{}
+	for rows.Next() {
+		var probe types.MonitorAgentProbe
+		if err := rows.Scan(
+			&probe.ID, &probe.AgentID, &probe.Host, &probe.Interval,
+			&probe.PacketCount, &probe.Enabled, &probe.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		probes = append(probes, probe)
+	}
+
+	return probes, rows.Err()
+}
+
+// SetMonitorAgentProbes replaces the probes configured for an agent
+func (s *service) SetMonitorAgentProbes(ctx context.Context, agentID int64, probes []types.MonitorAgentProbe) error {
+	seen := make(map[string]bool, len(probes))
+	for _, probe := range probes {
+		if probe.Host == "" {
+			return fmt.Errorf("%w: probe host is required", ErrInvalidInput)
+		}
+		if seen[probe.Host] {
+			return fmt.Errorf("%w: duplicate probe host %q", ErrInvalidInput, probe.Host)
+		}
+		seen[probe.Host] = true
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	deleteQuery := s.sqlBuilder.Delete("monitor_agent_probes").Where(sq.Eq{"agent_id": agentID})
+	if _, err := deleteQuery.RunWith(tx).ExecContext(ctx); err != nil {
+		return err
+	}
+
+	for _, probe := range probes {
+		insertQuery := s.sqlBuilder.
+			Insert("monitor_agent_probes").
+			Columns("agent_id", "host", intervalColumn, "packet_count", "enabled").
+			Values(agentID, probe.Host, probe.Interval, probe.PacketCount, probe.Enabled)
+
+		if _, err := insertQuery.RunWith(tx).ExecContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SaveMonitorAgentProbeResults stores probe results reported by an agent
+func (s *service) SaveMonitorAgentProbeResults(ctx context.Context, agentID int64, results []types.MonitorAgentProbeResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	query := s.sqlBuilder.
+		Insert("monitor_agent_probe_results").
+		Columns("agent_id", "host", "packet_loss", "min_rtt", "avg_rtt", "max_rtt", "std_dev_rtt", "packets_sent", "packets_recv", "error", "measured_at")
+	for _, r := range results {
+		query = query.Values(agentID, r.Host, r.PacketLoss, r.MinRTT, r.AvgRTT, r.MaxRTT, r.StdDevRTT, r.PacketsSent, r.PacketsRecv, r.Error, r.MeasuredAt)
+	}
+
+	_, err := query.RunWith(s.db).ExecContext(ctx)
+	return err
+}
+
+// GetMonitorAgentProbeResults retrieves probe results for an agent, optionally filtered by host
+func (s *service) GetMonitorAgentProbeResults(ctx context.Context, agentID int64, host string, since time.Time, limit int) ([]types.MonitorAgentProbeResult, error) {
+	where := sq.And{
+		sq.Eq{"agent_id": agentID},
+		sq.GtOrEq{"measured_at": since},
+	}
+	if host != "" {
+		where = append(where, sq.Eq{"host": host})
+	}
+
+	query := s.sqlBuilder.
+		Select("id", "agent_id", "host", "packet_loss", "min_rtt", "avg_rtt", "max_rtt", "std_dev_rtt", "packets_sent", "packets_recv", "error", "measured_at", "created_at").
+		From("monitor_agent_probe_results").
+		Where(where).
+		OrderBy("measured_at DESC")
+	if limit > 0 {
+		query = query.Limit(uint64(limit))
+	}
+
+	rows, err := query.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []types.MonitorAgentProbeResult{}
+	for rows.Next() {
+		var r types.MonitorAgentProbeResult
+		if err := rows.Scan(
+			&r.ID, &r.AgentID, &r.Host, &r.PacketLoss, &r.MinRTT, &r.AvgRTT, &r.MaxRTT,
+			&r.StdDevRTT, &r.PacketsSent, &r.PacketsRecv, &r.Error, &r.MeasuredAt, &r.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
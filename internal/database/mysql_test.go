@@ -0,0 +1,95 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestMySQLDSN(t *testing.T) {
+	dsn := mysqlDSN(config.DatabaseConfig{
+		Type:     config.MySQL,
+		Host:     "db.example.com",
+		Port:     3306,
+		User:     "netronome",
+		Password: "p@ss:word",
+		DBName:   "netronome",
+		SSLMode:  "require",
+	})
+
+	cfg, err := mysql.ParseDSN(dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "netronome", cfg.User)
+	assert.Equal(t, "p@ss:word", cfg.Passwd)
+	assert.Equal(t, "tcp", cfg.Net)
+	assert.Equal(t, "db.example.com:3306", cfg.Addr)
+	assert.Equal(t, "netronome", cfg.DBName)
+	assert.True(t, cfg.ParseTime)
+	assert.True(t, cfg.MultiStatements)
+	assert.True(t, cfg.ClientFoundRows, "unchanged updates still count as matched")
+	assert.Equal(t, "skip-verify", cfg.TLSConfig)
+	assert.Contains(t, cfg.Params["sql_mode"], "ANSI_QUOTES")
+
+	cfg, err = mysql.ParseDSN(mysqlDSN(config.DatabaseConfig{Host: "localhost", Port: 3306, SSLMode: "disable"}))
+	require.NoError(t, err)
+	assert.Equal(t, "false", cfg.TLSConfig)
+}
+
+// TestSavePacketLossResult_MySQLLastInsertId verifies MySQL takes the LastInsertId path
+func TestSavePacketLossResult_MySQLLastInsertId(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := &service{
+		db:         newTracedDB(db, config.MySQL),
+		config:     config.DatabaseConfig{Type: config.MySQL},
+		sqlBuilder: sq.StatementBuilder,
+	}
+
+	result := &types.PacketLossResult{
+		MonitorID:  1,
+		PacketLoss: 5.5,
+		CreatedAt:  time.Now(),
+	}
+
+	mock.ExpectExec(`INSERT INTO packet_loss_results`).
+		WillReturnResult(sqlmock.NewResult(42, 1))
+
+	err = s.SavePacketLossResult(result)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), result.ID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetAppSetting_MySQLUpsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := &service{
+		db:         newTracedDB(db, config.MySQL),
+		config:     config.DatabaseConfig{Type: config.MySQL},
+		sqlBuilder: sq.StatementBuilder,
+	}
+
+	mock.ExpectExec(`INSERT INTO app_settings \("key",value\) VALUES \(\?,\?\) ON DUPLICATE KEY UPDATE value = VALUES\(value\)`).
+		WithArgs("theme", "dark").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, s.SetAppSetting(context.Background(), "theme", "dark"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
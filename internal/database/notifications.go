@@ -21,15 +21,17 @@ func (s *service) CreateChannel(input NotificationChannelInput) (*NotificationCh
 		enabled = *input.Enabled
 	}
 
+	payloadTemplate := payloadTemplateValue(input.PayloadTemplate)
+
 	query := s.sqlBuilder.Insert("notification_channels").
-		Columns("name", "url", "enabled", "created_at", "updated_at").
-		Values(input.Name, input.URL, enabled, now, now)
+		Columns("name", "url", "payload_template", "enabled", "created_at", "updated_at").
+		Values(input.Name, input.URL, payloadTemplate, enabled, now, now)
 
 	if s.config.Type == config.Postgres {
 		query = query.Suffix("RETURNING id")
 	}
 
-	if s.config.Type == config.SQLite {
+	if s.config.Type != config.Postgres {
 		result, err := query.RunWith(s.db).Exec()
 		if err != nil {
 			return nil, fmt.Errorf("failed to create notification channel: %w", err)
@@ -41,12 +43,13 @@ func (s *service) CreateChannel(input NotificationChannelInput) (*NotificationCh
 		}
 
 		return &NotificationChannel{
-			ID:        id,
-			Name:      input.Name,
-			URL:       input.URL,
-			Enabled:   enabled,
-			CreatedAt: now,
-			UpdatedAt: now,
+			ID:              id,
+			Name:            input.Name,
+			URL:             input.URL,
+			PayloadTemplate: payloadTemplate,
+			Enabled:         enabled,
+			CreatedAt:       now,
+			UpdatedAt:       now,
 		}, nil
 	} else {
 		// PostgreSQL
@@ -57,21 +60,30 @@ func (s *service) CreateChannel(input NotificationChannelInput) (*NotificationCh
 		}
 
 		return &NotificationChannel{
-			ID:        id,
-			Name:      input.Name,
-			URL:       input.URL,
-			Enabled:   enabled,
-			CreatedAt: now,
-			UpdatedAt: now,
+			ID:              id,
+			Name:            input.Name,
+			URL:             input.URL,
+			PayloadTemplate: payloadTemplate,
+			Enabled:         enabled,
+			CreatedAt:       now,
+			UpdatedAt:       now,
 		}, nil
 	}
 }
 
+// payloadTemplateValue stores an empty payload template as NULL
+func payloadTemplateValue(template *string) *string {
+	if template == nil || *template == "" {
+		return nil
+	}
+	return template
+}
+
 // GetChannels retrieves all notification channels
 func (s *service) GetChannels() ([]NotificationChannel, error) {
 	var channels []NotificationChannel
 
-	rows, err := s.sqlBuilder.Select("id", "name", "url", "enabled", "created_at", "updated_at").
+	rows, err := s.sqlBuilder.Select("id", "name", "url", "payload_template", "enabled", "created_at", "updated_at").
 		From("notification_channels").
 		OrderBy("created_at DESC").
 		RunWith(s.db).
@@ -83,7 +95,7 @@ func (s *service) GetChannels() ([]NotificationChannel, error) {
 
 	for rows.Next() {
 		var channel NotificationChannel
-		if err := rows.Scan(&channel.ID, &channel.Name, &channel.URL, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt); err != nil {
+		if err := rows.Scan(&channel.ID, &channel.Name, &channel.URL, &channel.PayloadTemplate, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan channel: %w", err)
 		}
 		channels = append(channels, channel)
@@ -96,7 +108,7 @@ func (s *service) GetChannels() ([]NotificationChannel, error) {
 func (s *service) GetEnabledChannels() ([]NotificationChannel, error) {
 	var channels []NotificationChannel
 
-	rows, err := s.sqlBuilder.Select("id", "name", "url", "enabled", "created_at", "updated_at").
+	rows, err := s.sqlBuilder.Select("id", "name", "url", "payload_template", "enabled", "created_at", "updated_at").
 		From("notification_channels").
 		Where(sq.Eq{"enabled": true}).
 		OrderBy("created_at DESC").
@@ -109,7 +121,7 @@ func (s *service) GetEnabledChannels() ([]NotificationChannel, error) {
 
 	for rows.Next() {
 		var channel NotificationChannel
-		if err := rows.Scan(&channel.ID, &channel.Name, &channel.URL, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt); err != nil {
+		if err := rows.Scan(&channel.ID, &channel.Name, &channel.URL, &channel.PayloadTemplate, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan channel: %w", err)
 		}
 		channels = append(channels, channel)
@@ -122,12 +134,12 @@ func (s *service) GetEnabledChannels() ([]NotificationChannel, error) {
 func (s *service) GetChannel(id int64) (*NotificationChannel, error) {
 	var channel NotificationChannel
 
-	err := s.sqlBuilder.Select("id", "name", "url", "enabled", "created_at", "updated_at").
+	err := s.sqlBuilder.Select("id", "name", "url", "payload_template", "enabled", "created_at", "updated_at").
 		From("notification_channels").
 		Where(sq.Eq{"id": id}).
 		RunWith(s.db).
 		QueryRow().
-		Scan(&channel.ID, &channel.Name, &channel.URL, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt)
+		Scan(&channel.ID, &channel.Name, &channel.URL, &channel.PayloadTemplate, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
@@ -146,14 +158,22 @@ func (s *service) UpdateChannel(id int64, input NotificationChannelInput) (*Noti
 		enabled = *input.Enabled
 	}
 
-	result, err := s.sqlBuilder.Update("notification_channels").
+	query := s.sqlBuilder.Update("notification_channels").
 		Set("name", input.Name).
-		Set("url", input.URL).
 		Set("enabled", enabled).
 		Set("updated_at", time.Now()).
-		Where(sq.Eq{"id": id}).
-		RunWith(s.db).
-		Exec()
+		Where(sq.Eq{"id": id})
+
+	// A missing URL keeps the stored one
+	if input.URL != "" {
+		query = query.Set("url", input.URL)
+	}
+	// A missing template keeps the stored one, an empty one clears it
+	if input.PayloadTemplate != nil {
+		query = query.Set("payload_template", payloadTemplateValue(input.PayloadTemplate))
+	}
+
+	result, err := query.RunWith(s.db).Exec()
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to update notification channel: %w", err)
@@ -194,12 +214,13 @@ func (s *service) DeleteChannel(id int64) error {
 	return nil
 }
 
-// GetEvents retrieves all notification events
+// GetEvents retrieves all notification events rules can subscribe to
 func (s *service) GetEvents() ([]NotificationEvent, error) {
 	var events []NotificationEvent
 
 	rows, err := s.sqlBuilder.Select("id", "category", "event_type", "name", "description", "default_enabled", "supports_threshold", "threshold_unit", "created_at").
 		From("notification_events").
+		Where(sq.NotEq{"category": NotificationCategorySystem}).
 		OrderBy("category", "name").
 		RunWith(s.db).
 		Query()
@@ -335,14 +356,14 @@ func (s *service) CreateRule(input NotificationRuleInput) (*NotificationRule, er
 	}
 
 	query := s.sqlBuilder.Insert("notification_rules").
-		Columns("channel_id", "event_id", "enabled", "threshold_value", "threshold_operator", "created_at", "updated_at").
-		Values(input.ChannelID, input.EventID, enabled, input.ThresholdValue, input.ThresholdOperator, now, now)
+		Columns("channel_id", "event_id", "enabled", "threshold_value", "threshold_operator", "cooldown_seconds", "agent_tag", "created_at", "updated_at").
+		Values(input.ChannelID, input.EventID, enabled, input.ThresholdValue, input.ThresholdOperator, ruleCooldown(input.CooldownSeconds), ruleAgentTag(input.AgentTag), now, now)
 
 	if s.config.Type == config.Postgres {
 		query = query.Suffix("RETURNING id")
 	}
 
-	if s.config.Type == config.SQLite {
+	if s.config.Type != config.Postgres {
 		result, err := query.RunWith(s.db).Exec()
 		if err != nil {
 			return nil, fmt.Errorf("failed to create notification rule: %w", err)
@@ -360,6 +381,8 @@ func (s *service) CreateRule(input NotificationRuleInput) (*NotificationRule, er
 			Enabled:           enabled,
 			ThresholdValue:    input.ThresholdValue,
 			ThresholdOperator: input.ThresholdOperator,
+			CooldownSeconds:   ruleCooldown(input.CooldownSeconds),
+			AgentTag:          ruleAgentTag(input.AgentTag),
 			CreatedAt:         now,
 			UpdatedAt:         now,
 		}, nil
@@ -378,6 +401,8 @@ func (s *service) CreateRule(input NotificationRuleInput) (*NotificationRule, er
 			Enabled:           enabled,
 			ThresholdValue:    input.ThresholdValue,
 			ThresholdOperator: input.ThresholdOperator,
+			CooldownSeconds:   ruleCooldown(input.CooldownSeconds),
+			AgentTag:          ruleAgentTag(input.AgentTag),
 			CreatedAt:         now,
 			UpdatedAt:         now,
 		}, nil
@@ -389,8 +414,8 @@ func (s *service) GetRules() ([]NotificationRule, error) {
 	var rules []NotificationRule
 
 	rows, err := s.sqlBuilder.Select(
-		"r.id", "r.channel_id", "r.event_id", "r.enabled", "r.threshold_value", "r.threshold_operator", "r.created_at", "r.updated_at",
-		"c.id", "c.name", "c.url", "c.enabled", "c.created_at", "c.updated_at",
+		"r.id", "r.channel_id", "r.event_id", "r.enabled", "r.threshold_value", "r.threshold_operator", "r.cooldown_seconds", "r.agent_tag", "r.created_at", "r.updated_at",
+		"c.id", "c.name", "c.url", "c.payload_template", "c.enabled", "c.created_at", "c.updated_at",
 		"e.id", "e.category", "e.event_type", "e.name", "e.description", "e.default_enabled", "e.supports_threshold", "e.threshold_unit", "e.created_at",
 	).
 		From("notification_rules r").
@@ -412,8 +437,8 @@ func (s *service) GetRules() ([]NotificationRule, error) {
 		var thresholdOperator, eventDescription, eventThresholdUnit sql.NullString
 
 		err := rows.Scan(
-			&rule.ID, &rule.ChannelID, &rule.EventID, &rule.Enabled, &thresholdValue, &thresholdOperator, &rule.CreatedAt, &rule.UpdatedAt,
-			&channel.ID, &channel.Name, &channel.URL, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt,
+			&rule.ID, &rule.ChannelID, &rule.EventID, &rule.Enabled, &thresholdValue, &thresholdOperator, &rule.CooldownSeconds, &rule.AgentTag, &rule.CreatedAt, &rule.UpdatedAt,
+			&channel.ID, &channel.Name, &channel.URL, &channel.PayloadTemplate, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt,
 			&event.ID, &event.Category, &event.EventType, &event.Name, &eventDescription, &event.DefaultEnabled, &event.SupportsThreshold, &eventThresholdUnit, &event.CreatedAt,
 		)
 		if err != nil {
@@ -447,7 +472,7 @@ func (s *service) GetRulesByChannel(channelID int64) ([]NotificationRule, error)
 	var rules []NotificationRule
 
 	rows, err := s.sqlBuilder.Select(
-		"r.id", "r.channel_id", "r.event_id", "r.enabled", "r.threshold_value", "r.threshold_operator", "r.created_at", "r.updated_at",
+		"r.id", "r.channel_id", "r.event_id", "r.enabled", "r.threshold_value", "r.threshold_operator", "r.cooldown_seconds", "r.agent_tag", "r.created_at", "r.updated_at",
 		"e.id", "e.category", "e.event_type", "e.name", "e.description", "e.default_enabled", "e.supports_threshold", "e.threshold_unit", "e.created_at",
 	).
 		From("notification_rules r").
@@ -468,7 +493,7 @@ func (s *service) GetRulesByChannel(channelID int64) ([]NotificationRule, error)
 		var thresholdOperator, eventDescription, eventThresholdUnit sql.NullString
 
 		err := rows.Scan(
-			&rule.ID, &rule.ChannelID, &rule.EventID, &rule.Enabled, &thresholdValue, &thresholdOperator, &rule.CreatedAt, &rule.UpdatedAt,
+			&rule.ID, &rule.ChannelID, &rule.EventID, &rule.Enabled, &thresholdValue, &thresholdOperator, &rule.CooldownSeconds, &rule.AgentTag, &rule.CreatedAt, &rule.UpdatedAt,
 			&event.ID, &event.Category, &event.EventType, &event.Name, &eventDescription, &event.DefaultEnabled, &event.SupportsThreshold, &eventThresholdUnit, &event.CreatedAt,
 		)
 		if err != nil {
@@ -511,6 +536,12 @@ func (s *service) UpdateRule(id int64, input NotificationRuleInput) (*Notificati
 	if input.ThresholdOperator != nil {
 		update = update.Set("threshold_operator", *input.ThresholdOperator)
 	}
+	if input.CooldownSeconds != nil {
+		update = update.Set("cooldown_seconds", ruleCooldown(input.CooldownSeconds))
+	}
+	if input.AgentTag != nil {
+		update = update.Set("agent_tag", ruleAgentTag(input.AgentTag))
+	}
 
 	result, err := update.RunWith(s.db).Exec()
 	if err != nil {
@@ -528,12 +559,12 @@ func (s *service) UpdateRule(id int64, input NotificationRuleInput) (*Notificati
 
 	// Get the updated rule
 	var rule NotificationRule
-	err = s.sqlBuilder.Select("id", "channel_id", "event_id", "enabled", "threshold_value", "threshold_operator", "created_at", "updated_at").
+	err = s.sqlBuilder.Select("id", "channel_id", "event_id", "enabled", "threshold_value", "threshold_operator", "cooldown_seconds", "agent_tag", "created_at", "updated_at").
 		From("notification_rules").
 		Where(sq.Eq{"id": id}).
 		RunWith(s.db).
 		QueryRow().
-		Scan(&rule.ID, &rule.ChannelID, &rule.EventID, &rule.Enabled, &rule.ThresholdValue, &rule.ThresholdOperator, &rule.CreatedAt, &rule.UpdatedAt)
+		Scan(&rule.ID, &rule.ChannelID, &rule.EventID, &rule.Enabled, &rule.ThresholdValue, &rule.ThresholdOperator, &rule.CooldownSeconds, &rule.AgentTag, &rule.CreatedAt, &rule.UpdatedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get updated rule: %w", err)
@@ -548,12 +579,12 @@ func (s *service) GetRule(id int64) (*NotificationRule, error) {
 	var thresholdValue sql.NullFloat64
 	var thresholdOperator sql.NullString
 
-	err := s.sqlBuilder.Select("id", "channel_id", "event_id", "enabled", "threshold_value", "threshold_operator", "created_at", "updated_at").
+	err := s.sqlBuilder.Select("id", "channel_id", "event_id", "enabled", "threshold_value", "threshold_operator", "cooldown_seconds", "agent_tag", "created_at", "updated_at").
 		From("notification_rules").
 		Where(sq.Eq{"id": id}).
 		RunWith(s.db).
 		QueryRow().
-		Scan(&rule.ID, &rule.ChannelID, &rule.EventID, &rule.Enabled, &thresholdValue, &thresholdOperator, &rule.CreatedAt, &rule.UpdatedAt)
+		Scan(&rule.ID, &rule.ChannelID, &rule.EventID, &rule.Enabled, &thresholdValue, &thresholdOperator, &rule.CooldownSeconds, &rule.AgentTag, &rule.CreatedAt, &rule.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
@@ -572,6 +603,22 @@ func (s *service) GetRule(id int64) (*NotificationRule, error) {
 	return &rule, nil
 }
 
+// ruleCooldown stores a cooldown of 0 as NULL so the rule falls back to the global cooldown
+func ruleCooldown(seconds *int) *int {
+	if seconds == nil || *seconds <= 0 {
+		return nil
+	}
+	return seconds
+}
+
+// ruleAgentTag stores an empty agent tag as NULL so the rule matches every agent
+func ruleAgentTag(tag *string) *string {
+	if tag == nil || *tag == "" {
+		return nil
+	}
+	return tag
+}
+
 // DeleteRule deletes a notification rule
 func (s *service) DeleteRule(id int64) error {
 	result, err := s.sqlBuilder.Delete("notification_rules").
@@ -600,8 +647,8 @@ func (s *service) GetRulesByEvent(eventID int64) ([]NotificationRule, error) {
 	var rules []NotificationRule
 
 	rows, err := s.sqlBuilder.Select(
-		"r.id", "r.channel_id", "r.event_id", "r.enabled", "r.threshold_value", "r.threshold_operator", "r.created_at", "r.updated_at",
-		"c.id", "c.name", "c.url", "c.enabled", "c.created_at", "c.updated_at",
+		"r.id", "r.channel_id", "r.event_id", "r.enabled", "r.threshold_value", "r.threshold_operator", "r.cooldown_seconds", "r.agent_tag", "r.created_at", "r.updated_at",
+		"c.id", "c.name", "c.url", "c.payload_template", "c.enabled", "c.created_at", "c.updated_at",
 	).
 		From("notification_rules r").
 		LeftJoin("notification_channels c ON r.channel_id = c.id").
@@ -621,8 +668,8 @@ func (s *service) GetRulesByEvent(eventID int64) ([]NotificationRule, error) {
 		var thresholdOperator sql.NullString
 
 		err := rows.Scan(
-			&rule.ID, &rule.ChannelID, &rule.EventID, &rule.Enabled, &thresholdValue, &thresholdOperator, &rule.CreatedAt, &rule.UpdatedAt,
-			&channel.ID, &channel.Name, &channel.URL, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt,
+			&rule.ID, &rule.ChannelID, &rule.EventID, &rule.Enabled, &thresholdValue, &thresholdOperator, &rule.CooldownSeconds, &rule.AgentTag, &rule.CreatedAt, &rule.UpdatedAt,
+			&channel.ID, &channel.Name, &channel.URL, &channel.PayloadTemplate, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan rule: %w", err)
@@ -647,8 +694,8 @@ func (s *service) GetEnabledRulesForEvent(category, eventType string) ([]Notific
 	var rules []NotificationRule
 
 	rows, err := s.sqlBuilder.Select(
-		"r.id", "r.channel_id", "r.event_id", "r.enabled", "r.threshold_value", "r.threshold_operator", "r.created_at", "r.updated_at",
-		"c.id", "c.name", "c.url", "c.enabled", "c.created_at", "c.updated_at",
+		"r.id", "r.channel_id", "r.event_id", "r.enabled", "r.threshold_value", "r.threshold_operator", "r.cooldown_seconds", "r.agent_tag", "r.created_at", "r.updated_at",
+		"c.id", "c.name", "c.url", "c.payload_template", "c.enabled", "c.created_at", "c.updated_at",
 	).
 		From("notification_rules r").
 		Join("notification_channels c ON r.channel_id = c.id").
@@ -673,8 +720,8 @@ func (s *service) GetEnabledRulesForEvent(category, eventType string) ([]Notific
 		var thresholdOperator sql.NullString
 
 		err := rows.Scan(
-			&rule.ID, &rule.ChannelID, &rule.EventID, &rule.Enabled, &thresholdValue, &thresholdOperator, &rule.CreatedAt, &rule.UpdatedAt,
-			&channel.ID, &channel.Name, &channel.URL, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt,
+			&rule.ID, &rule.ChannelID, &rule.EventID, &rule.Enabled, &thresholdValue, &thresholdOperator, &rule.CooldownSeconds, &rule.AgentTag, &rule.CreatedAt, &rule.UpdatedAt,
+			&channel.ID, &channel.Name, &channel.URL, &channel.PayloadTemplate, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan rule: %w", err)
@@ -709,6 +756,21 @@ func (s *service) LogNotification(channelID, eventID int64, success bool, errorM
 	return nil
 }
 
+// LogSuppressedNotification records a notification that was withheld during quiet hours
+func (s *service) LogSuppressedNotification(channelID, eventID int64, payload *string) error {
+	_, err := s.sqlBuilder.Insert("notification_history").
+		Columns("channel_id", "event_id", "success", "suppressed", "payload", "created_at").
+		Values(channelID, eventID, false, true, payload, time.Now()).
+		RunWith(s.db).
+		Exec()
+
+	if err != nil {
+		return fmt.Errorf("failed to log suppressed notification: %w", err)
+	}
+
+	return nil
+}
+
 // CheckThreshold checks if a value meets the threshold criteria
 func (s *service) CheckThreshold(rule *NotificationRule, value float64) bool {
 	if rule.ThresholdValue == nil || rule.ThresholdOperator == nil {
@@ -737,7 +799,7 @@ func (s *service) CheckThreshold(rule *NotificationRule, value float64) bool {
 // GetNotificationHistory retrieves notification history with optional limit
 func (s *service) GetNotificationHistory(limit int) ([]NotificationHistory, error) {
 	query := s.sqlBuilder.Select(
-		"h.id", "h.channel_id", "h.event_id", "h.success", "h.error_message", "h.payload", "h.created_at",
+		"h.id", "h.channel_id", "h.event_id", "h.success", "h.suppressed", "h.error_message", "h.payload", "h.created_at",
 		"c.name", "c.url",
 		"e.category", "e.event_type", "e.name",
 	).
@@ -764,7 +826,7 @@ func (s *service) GetNotificationHistory(limit int) ([]NotificationHistory, erro
 		var eventCategory, eventType, eventName string
 
 		err := rows.Scan(
-			&h.ID, &h.ChannelID, &h.EventID, &h.Success, &errorMessage, &payload, &h.CreatedAt,
+			&h.ID, &h.ChannelID, &h.EventID, &h.Success, &h.Suppressed, &errorMessage, &payload, &h.CreatedAt,
 			&channelName, &channelURL,
 			&eventCategory, &eventType, &eventName,
 		)
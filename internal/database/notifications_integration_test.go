@@ -63,6 +63,45 @@ func TestNotificationChannel_CRUD(t *testing.T) {
 	})
 }
 
+func TestNotificationChannel_PayloadTemplate(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		tmpl := `{"text": {{json .Message}}}`
+		created, err := td.Service.CreateChannel(NotificationChannelInput{
+			Name:            "Templated",
+			URL:             "https://example.com/hook",
+			PayloadTemplate: &tmpl,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, created.PayloadTemplate)
+		assert.Equal(t, tmpl, *created.PayloadTemplate)
+
+		// A missing template keeps the stored one
+		updated, err := td.Service.UpdateChannel(created.ID, NotificationChannelInput{
+			Name: "Renamed",
+			URL:  "https://example.com/hook",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, updated.PayloadTemplate)
+		assert.Equal(t, tmpl, *updated.PayloadTemplate)
+
+		channels, err := td.Service.GetChannels()
+		require.NoError(t, err)
+		require.Len(t, channels, 1)
+		require.NotNil(t, channels[0].PayloadTemplate)
+		assert.Equal(t, tmpl, *channels[0].PayloadTemplate)
+
+		// An empty template clears it
+		empty := ""
+		updated, err = td.Service.UpdateChannel(created.ID, NotificationChannelInput{
+			Name:            "Renamed",
+			URL:             "https://example.com/hook",
+			PayloadTemplate: &empty,
+		})
+		require.NoError(t, err)
+		assert.Nil(t, updated.PayloadTemplate)
+	})
+}
+
 func TestNotificationChannel_GetAll(t *testing.T) {
 	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
 		ctx := context.Background()
@@ -177,6 +216,45 @@ func TestNotificationRule_CRUD(t *testing.T) {
 	})
 }
 
+func TestNotificationRule_AgentTag(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		channel, err := td.Service.CreateChannel(NotificationChannelInput{
+			Name:    "Test Channel for Tag Rules",
+			URL:     "https://example.com/webhook",
+			Enabled: boolPtr(true),
+		})
+		require.NoError(t, err)
+
+		event, err := td.Service.GetEventByType(NotificationCategoryAgent, NotificationEventAgentOffline)
+		require.NoError(t, err)
+
+		created, err := td.Service.CreateRule(NotificationRuleInput{
+			ChannelID: channel.ID,
+			EventID:   event.ID,
+			Enabled:   boolPtr(true),
+			AgentTag:  stringPtr("prod-db"),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, created.AgentTag)
+		assert.Equal(t, "prod-db", *created.AgentTag)
+
+		rules, err := td.Service.GetEnabledRulesForEvent(NotificationCategoryAgent, NotificationEventAgentOffline)
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+		require.NotNil(t, rules[0].AgentTag)
+		assert.Equal(t, "prod-db", *rules[0].AgentTag)
+
+		// An empty tag clears it
+		updated, err := td.Service.UpdateRule(created.ID, NotificationRuleInput{
+			ChannelID: channel.ID,
+			EventID:   event.ID,
+			AgentTag:  stringPtr(""),
+		})
+		require.NoError(t, err)
+		assert.Nil(t, updated.AgentTag)
+	})
+}
+
 func TestNotificationRule_GetByChannel(t *testing.T) {
 	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
 		ctx := context.Background()
@@ -390,6 +468,33 @@ func TestNotificationHistory_Create(t *testing.T) {
 		assert.False(t, failEntry.Success)
 		assert.NotNil(t, failEntry.ErrorMessage)
 		assert.Equal(t, errorMsg, *failEntry.ErrorMessage)
+		assert.False(t, failEntry.Suppressed)
+	})
+}
+
+func TestNotificationHistory_Suppressed(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		channel, err := td.Service.CreateChannel(NotificationChannelInput{
+			Name:    "Quiet Hours Channel",
+			URL:     "https://example.com/webhook",
+			Enabled: boolPtr(true),
+		})
+		require.NoError(t, err)
+
+		event, err := td.Service.GetEventByType(NotificationCategoryAgent, NotificationEventAgentHighCPU)
+		require.NoError(t, err)
+
+		payload := "[CPU] High CPU Usage - Agent: **backup** | CPU: **97.0%**"
+		require.NoError(t, td.Service.LogSuppressedNotification(channel.ID, event.ID, &payload))
+
+		history, err := td.Service.GetNotificationHistory(10)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.True(t, history[0].Suppressed)
+		assert.False(t, history[0].Success)
+		assert.Nil(t, history[0].ErrorMessage)
+		require.NotNil(t, history[0].Payload)
+		assert.Equal(t, payload, *history[0].Payload)
 	})
 }
 
@@ -8,12 +8,14 @@ import (
 )
 
 type NotificationChannel struct {
-	ID        int64     `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	URL       string    `json:"url" db:"url"`
-	Enabled   bool      `json:"enabled" db:"enabled"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID   int64  `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	URL  string `json:"url" db:"url"`
+	// PayloadTemplate renders the body posted to a webhook channel, nil posts the default JSON
+	PayloadTemplate *string   `json:"payload_template,omitempty" db:"payload_template"`
+	Enabled         bool      `json:"enabled" db:"enabled"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type NotificationEvent struct {
@@ -35,6 +37,8 @@ type NotificationRule struct {
 	Enabled           bool      `json:"enabled" db:"enabled"`
 	ThresholdValue    *float64  `json:"threshold_value" db:"threshold_value"`
 	ThresholdOperator *string   `json:"threshold_operator" db:"threshold_operator"`
+	CooldownSeconds   *int      `json:"cooldown_seconds,omitempty" db:"cooldown_seconds"` // nil uses the global cooldown
+	AgentTag          *string   `json:"agent_tag,omitempty" db:"agent_tag"`               // agent events only fire for agents with this tag, nil matches all
 	CreatedAt         time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
 
@@ -48,6 +52,7 @@ type NotificationHistory struct {
 	ChannelID    int64     `json:"channel_id" db:"channel_id"`
 	EventID      int64     `json:"event_id" db:"event_id"`
 	Success      bool      `json:"success" db:"success"`
+	Suppressed   bool      `json:"suppressed" db:"suppressed"`
 	ErrorMessage *string   `json:"error_message" db:"error_message"`
 	Payload      *string   `json:"payload" db:"payload"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
@@ -57,6 +62,8 @@ type NotificationChannelInput struct {
 	Name    string `json:"name" validate:"required"`
 	URL     string `json:"url" validate:"required"`
 	Enabled *bool  `json:"enabled"`
+	// PayloadTemplate sets the webhook payload template, "" clears it and nil keeps it
+	PayloadTemplate *string `json:"payload_template"`
 }
 
 type NotificationRuleInput struct {
@@ -65,6 +72,10 @@ type NotificationRuleInput struct {
 	Enabled           *bool    `json:"enabled"`
 	ThresholdValue    *float64 `json:"threshold_value"`
 	ThresholdOperator *string  `json:"threshold_operator" validate:"omitempty,oneof=gt lt eq gte lte"`
+	// CooldownSeconds overrides the global notification cooldown, 0 clears the override
+	CooldownSeconds *int `json:"cooldown_seconds" validate:"omitempty,min=0"`
+	// AgentTag limits an agent event rule to agents with the tag, "" clears it
+	AgentTag *string `json:"agent_tag"`
 }
 
 // NotificationEventCategory constants
@@ -72,6 +83,9 @@ const (
 	NotificationCategorySpeedtest  = "speedtest"
 	NotificationCategoryPacketLoss = "packetloss"
 	NotificationCategoryAgent      = "agent"
+
+	// NotificationCategorySystem holds internal events that rules can't subscribe to
+	NotificationCategorySystem = "system"
 )
 
 // NotificationEventType constants
@@ -82,11 +96,15 @@ const (
 	NotificationEventSpeedtestDownloadLow = "download_low"
 	NotificationEventSpeedtestUploadLow   = "upload_low"
 	NotificationEventSpeedtestFailed      = "failed"
+	NotificationEventSpeedtestRatioShift  = "ratio_shift"
+	NotificationEventSpeedtestRecovered   = "recovered"
 
 	// Packet loss events
+	NotificationEventPacketLossWarning   = "threshold_warning"
 	NotificationEventPacketLossHigh      = "threshold_exceeded"
 	NotificationEventPacketLossDown      = "monitor_down"
 	NotificationEventPacketLossRecovered = "monitor_recovered"
+	NotificationEventPacketLossHopChange = "hop_count_change"
 
 	// Agent events
 	NotificationEventAgentOffline       = "offline"
@@ -96,6 +114,12 @@ const (
 	NotificationEventAgentHighCPU       = "cpu_high"
 	NotificationEventAgentHighMemory    = "memory_high"
 	NotificationEventAgentHighTemp      = "temperature_high"
+	NotificationEventAgentHighSwap      = "high_swap"
+	NotificationEventAgentRebooted      = "rebooted"
+	NotificationEventAgentDiskFailing   = "disk_failing"
+
+	// System events
+	NotificationEventSystemTest = "test"
 )
 
 // ThresholdOperator constants
@@ -135,6 +159,7 @@ type NotificationService interface {
 
 	// History
 	LogNotification(channelID, eventID int64, success bool, errorMessage *string, payload *string) error
+	LogSuppressedNotification(channelID, eventID int64, payload *string) error
 	GetNotificationHistory(limit int) ([]NotificationHistory, error)
 
 	// Utility
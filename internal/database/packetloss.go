@@ -4,8 +4,10 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -18,7 +20,7 @@ import (
 // GetPacketLossMonitor retrieves a packet loss monitor by ID
 func (s *service) GetPacketLossMonitor(monitorID int64) (*types.PacketLossMonitor, error) {
 	query := s.sqlBuilder.
-		Select("id", "host", "name", "interval", "packet_count", "enabled", "threshold", "last_run", "next_run", "last_state", "last_state_change", "created_at", "updated_at").
+		Select("id", "host", "name", intervalColumn, "packet_count", "enabled", "threshold", "warning_threshold", "critical_threshold", "probe_mode", "probe_port", "interval_ms", "packet_size", "address_family", "last_run", "next_run", "last_state", "last_state_change", "created_at", "updated_at").
 		From("packet_loss_monitors").
 		Where(sq.Eq{"id": monitorID})
 
@@ -31,6 +33,13 @@ func (s *service) GetPacketLossMonitor(monitorID int64) (*types.PacketLossMonito
 		&monitor.PacketCount,
 		&monitor.Enabled,
 		&monitor.Threshold,
+		&monitor.WarningThreshold,
+		&monitor.CriticalThreshold,
+		&monitor.ProbeMode,
+		&monitor.ProbePort,
+		&monitor.IntervalMs,
+		&monitor.PacketSize,
+		&monitor.AddressFamily,
 		&monitor.LastRun,
 		&monitor.NextRun,
 		&monitor.LastState,
@@ -52,7 +61,7 @@ func (s *service) GetPacketLossMonitor(monitorID int64) (*types.PacketLossMonito
 // GetEnabledPacketLossMonitors retrieves all enabled packet loss monitors
 func (s *service) GetEnabledPacketLossMonitors() ([]*types.PacketLossMonitor, error) {
 	query := s.sqlBuilder.
-		Select("id", "host", "name", "interval", "packet_count", "enabled", "threshold", "last_run", "next_run", "last_state", "last_state_change", "created_at", "updated_at").
+		Select("id", "host", "name", intervalColumn, "packet_count", "enabled", "threshold", "warning_threshold", "critical_threshold", "probe_mode", "probe_port", "interval_ms", "packet_size", "address_family", "last_run", "next_run", "last_state", "last_state_change", "created_at", "updated_at").
 		From("packet_loss_monitors").
 		Where(sq.Eq{"enabled": true}).
 		OrderBy("created_at ASC")
@@ -74,6 +83,13 @@ func (s *service) GetEnabledPacketLossMonitors() ([]*types.PacketLossMonitor, er
 			&monitor.PacketCount,
 			&monitor.Enabled,
 			&monitor.Threshold,
+			&monitor.WarningThreshold,
+			&monitor.CriticalThreshold,
+			&monitor.ProbeMode,
+			&monitor.ProbePort,
+			&monitor.IntervalMs,
+			&monitor.PacketSize,
+			&monitor.AddressFamily,
 			&monitor.LastRun,
 			&monitor.NextRun,
 			&monitor.LastState,
@@ -99,8 +115,8 @@ func (s *service) SavePacketLossResult(result *types.PacketLossResult) error {
 	case config.Postgres:
 		query := s.sqlBuilder.
 			Insert("packet_loss_results").
-			Columns("monitor_id", "packet_loss", "min_rtt", "max_rtt", "avg_rtt", "std_dev_rtt", "packets_sent", "packets_recv", "used_mtr", "hop_count", "mtr_data", "privileged_mode", "created_at").
-			Values(result.MonitorID, result.PacketLoss, result.MinRTT, result.MaxRTT, result.AvgRTT, result.StdDevRTT, result.PacketsSent, result.PacketsRecv, result.UsedMTR, result.HopCount, result.MTRData, result.PrivilegedMode, result.CreatedAt).
+			Columns("monitor_id", "packet_loss", "min_rtt", "max_rtt", "avg_rtt", "std_dev_rtt", "jitter", "packets_sent", "packets_recv", "used_mtr", "hop_count", "mtr_data", "privileged_mode", "probed_ip", "created_at").
+			Values(result.MonitorID, result.PacketLoss, result.MinRTT, result.MaxRTT, result.AvgRTT, result.StdDevRTT, result.Jitter, result.PacketsSent, result.PacketsRecv, result.UsedMTR, result.HopCount, result.MTRData, result.PrivilegedMode, result.ProbedIP, result.CreatedAt).
 			Suffix("RETURNING id")
 
 		sqlStr, args, err := query.ToSql()
@@ -113,11 +129,11 @@ func (s *service) SavePacketLossResult(result *types.PacketLossResult) error {
 			return fmt.Errorf("failed to save packet loss result: %w", err)
 		}
 
-	case config.SQLite:
+	case config.SQLite, config.MySQL:
 		query := s.sqlBuilder.
 			Insert("packet_loss_results").
-			Columns("monitor_id", "packet_loss", "min_rtt", "max_rtt", "avg_rtt", "std_dev_rtt", "packets_sent", "packets_recv", "used_mtr", "hop_count", "mtr_data", "privileged_mode", "created_at").
-			Values(result.MonitorID, result.PacketLoss, result.MinRTT, result.MaxRTT, result.AvgRTT, result.StdDevRTT, result.PacketsSent, result.PacketsRecv, result.UsedMTR, result.HopCount, result.MTRData, result.PrivilegedMode, result.CreatedAt)
+			Columns("monitor_id", "packet_loss", "min_rtt", "max_rtt", "avg_rtt", "std_dev_rtt", "jitter", "packets_sent", "packets_recv", "used_mtr", "hop_count", "mtr_data", "privileged_mode", "probed_ip", "created_at").
+			Values(result.MonitorID, result.PacketLoss, result.MinRTT, result.MaxRTT, result.AvgRTT, result.StdDevRTT, result.Jitter, result.PacketsSent, result.PacketsRecv, result.UsedMTR, result.HopCount, result.MTRData, result.PrivilegedMode, result.ProbedIP, result.CreatedAt)
 
 		res, err := query.RunWith(s.db).Exec()
 		if err != nil {
@@ -140,7 +156,7 @@ func (s *service) SavePacketLossResult(result *types.PacketLossResult) error {
 // GetLatestPacketLossResult retrieves the most recent packet loss result for a monitor
 func (s *service) GetLatestPacketLossResult(monitorID int64) (*types.PacketLossResult, error) {
 	query := s.sqlBuilder.
-		Select("id", "monitor_id", "packet_loss", "min_rtt", "max_rtt", "avg_rtt", "std_dev_rtt", "packets_sent", "packets_recv", "used_mtr", "hop_count", "mtr_data", "privileged_mode", "created_at").
+		Select("id", "monitor_id", "packet_loss", "min_rtt", "max_rtt", "avg_rtt", "std_dev_rtt", "jitter", "packets_sent", "packets_recv", "used_mtr", "hop_count", "mtr_data", "privileged_mode", "probed_ip", "note", "created_at").
 		From("packet_loss_results").
 		Where(sq.Eq{"monitor_id": monitorID}).
 		OrderBy("created_at DESC").
@@ -155,12 +171,15 @@ func (s *service) GetLatestPacketLossResult(monitorID int64) (*types.PacketLossR
 		&result.MaxRTT,
 		&result.AvgRTT,
 		&result.StdDevRTT,
+		&result.Jitter,
 		&result.PacketsSent,
 		&result.PacketsRecv,
 		&result.UsedMTR,
 		&result.HopCount,
 		&result.MTRData,
 		&result.PrivilegedMode,
+		&result.ProbedIP,
+		&result.Note,
 		&result.CreatedAt,
 	)
 
@@ -181,8 +200,8 @@ func (s *service) CreatePacketLossMonitor(monitor *types.PacketLossMonitor) (*ty
 
 	query := s.sqlBuilder.
 		Insert("packet_loss_monitors").
-		Columns("host", "name", "interval", "packet_count", "enabled", "threshold", "created_at", "updated_at").
-		Values(monitor.Host, monitor.Name, monitor.Interval, monitor.PacketCount, monitor.Enabled, monitor.Threshold, monitor.CreatedAt, monitor.UpdatedAt)
+		Columns("host", "name", intervalColumn, "packet_count", "enabled", "threshold", "warning_threshold", "critical_threshold", "probe_mode", "probe_port", "interval_ms", "packet_size", "address_family", "created_at", "updated_at").
+		Values(monitor.Host, monitor.Name, monitor.Interval, monitor.PacketCount, monitor.Enabled, monitor.Threshold, monitor.WarningThreshold, monitor.CriticalThreshold, monitor.ProbeMode, monitor.ProbePort, monitor.IntervalMs, monitor.PacketSize, monitor.AddressFamily, monitor.CreatedAt, monitor.UpdatedAt)
 
 	if s.config.Type == config.Postgres {
 		query = query.Suffix("RETURNING id")
@@ -210,15 +229,22 @@ func (s *service) UpdatePacketLossMonitor(monitor *types.PacketLossMonitor) erro
 	monitor.UpdatedAt = time.Now()
 
 	data := map[string]interface{}{
-		"host":         monitor.Host,
-		"name":         monitor.Name,
-		"interval":     monitor.Interval,
-		"packet_count": monitor.PacketCount,
-		"enabled":      monitor.Enabled,
-		"threshold":    monitor.Threshold,
-		"last_run":     monitor.LastRun,
-		"next_run":     monitor.NextRun,
-		"updated_at":   monitor.UpdatedAt,
+		"host":               monitor.Host,
+		"name":               monitor.Name,
+		intervalColumn:       monitor.Interval,
+		"packet_count":       monitor.PacketCount,
+		"enabled":            monitor.Enabled,
+		"threshold":          monitor.Threshold,
+		"warning_threshold":  monitor.WarningThreshold,
+		"critical_threshold": monitor.CriticalThreshold,
+		"probe_mode":         monitor.ProbeMode,
+		"probe_port":         monitor.ProbePort,
+		"interval_ms":        monitor.IntervalMs,
+		"packet_size":        monitor.PacketSize,
+		"address_family":     monitor.AddressFamily,
+		"last_run":           monitor.LastRun,
+		"next_run":           monitor.NextRun,
+		"updated_at":         monitor.UpdatedAt,
 	}
 
 	query := s.sqlBuilder.
@@ -285,7 +311,7 @@ func (s *service) DeletePacketLossMonitor(monitorID int64) error {
 // GetPacketLossMonitors retrieves all packet loss monitors
 func (s *service) GetPacketLossMonitors() ([]*types.PacketLossMonitor, error) {
 	query := s.sqlBuilder.
-		Select("id", "host", "name", "interval", "packet_count", "enabled", "threshold", "last_run", "next_run", "last_state", "last_state_change", "created_at", "updated_at").
+		Select("id", "host", "name", intervalColumn, "packet_count", "enabled", "threshold", "warning_threshold", "critical_threshold", "probe_mode", "probe_port", "interval_ms", "packet_size", "address_family", "last_run", "next_run", "last_state", "last_state_change", "created_at", "updated_at").
 		From("packet_loss_monitors").
 		OrderBy("created_at DESC")
 
@@ -306,6 +332,13 @@ func (s *service) GetPacketLossMonitors() ([]*types.PacketLossMonitor, error) {
 			&monitor.PacketCount,
 			&monitor.Enabled,
 			&monitor.Threshold,
+			&monitor.WarningThreshold,
+			&monitor.CriticalThreshold,
+			&monitor.ProbeMode,
+			&monitor.ProbePort,
+			&monitor.IntervalMs,
+			&monitor.PacketSize,
+			&monitor.AddressFamily,
 			&monitor.LastRun,
 			&monitor.NextRun,
 			&monitor.LastState,
@@ -343,7 +376,7 @@ func (s *service) GetPacketLossResults(monitorID int64, page int, limit int) (*t
 	}
 
 	query := s.sqlBuilder.
-		Select("id", "monitor_id", "packet_loss", "min_rtt", "max_rtt", "avg_rtt", "std_dev_rtt", "packets_sent", "packets_recv", "used_mtr", "hop_count", "privileged_mode", "created_at").
+		Select("id", "monitor_id", "packet_loss", "min_rtt", "max_rtt", "avg_rtt", "std_dev_rtt", "jitter", "packets_sent", "packets_recv", "used_mtr", "hop_count", "privileged_mode", "probed_ip", "note", "created_at").
 		From("packet_loss_results").
 		Where(sq.Eq{"monitor_id": monitorID}).
 		OrderBy("created_at DESC", "id DESC").
@@ -367,11 +400,14 @@ func (s *service) GetPacketLossResults(monitorID int64, page int, limit int) (*t
 			&result.MaxRTT,
 			&result.AvgRTT,
 			&result.StdDevRTT,
+			&result.Jitter,
 			&result.PacketsSent,
 			&result.PacketsRecv,
 			&result.UsedMTR,
 			&result.HopCount,
 			&result.PrivilegedMode,
+			&result.ProbedIP,
+			&result.Note,
 			&result.CreatedAt,
 		)
 		if err != nil {
@@ -392,7 +428,7 @@ func (s *service) GetPacketLossResults(monitorID int64, page int, limit int) (*t
 // GetPacketLossResultDetail retrieves a single packet loss result including full MTR data.
 func (s *service) GetPacketLossResultDetail(monitorID int64, resultID int64) (*types.PacketLossResult, error) {
 	query := s.sqlBuilder.
-		Select("id", "monitor_id", "packet_loss", "min_rtt", "max_rtt", "avg_rtt", "std_dev_rtt", "packets_sent", "packets_recv", "used_mtr", "hop_count", "mtr_data", "privileged_mode", "created_at").
+		Select("id", "monitor_id", "packet_loss", "min_rtt", "max_rtt", "avg_rtt", "std_dev_rtt", "jitter", "packets_sent", "packets_recv", "used_mtr", "hop_count", "mtr_data", "privileged_mode", "probed_ip", "note", "created_at").
 		From("packet_loss_results").
 		Where(sq.Eq{"monitor_id": monitorID, "id": resultID}).
 		Limit(1)
@@ -406,12 +442,15 @@ func (s *service) GetPacketLossResultDetail(monitorID int64, resultID int64) (*t
 		&result.MaxRTT,
 		&result.AvgRTT,
 		&result.StdDevRTT,
+		&result.Jitter,
 		&result.PacketsSent,
 		&result.PacketsRecv,
 		&result.UsedMTR,
 		&result.HopCount,
 		&result.MTRData,
 		&result.PrivilegedMode,
+		&result.ProbedIP,
+		&result.Note,
 		&result.CreatedAt,
 	)
 
@@ -425,6 +464,209 @@ func (s *service) GetPacketLossResultDetail(monitorID int64, resultID int64) (*t
 	return result, nil
 }
 
+// GetPacketLossHopCountTrend returns MTR hop counts for a monitor since the given time, oldest first.
+func (s *service) GetPacketLossHopCountTrend(monitorID int64, since time.Time) (*types.HopCountTrend, error) {
+	query := s.sqlBuilder.
+		Select("id", "hop_count", "created_at").
+		From("packet_loss_results").
+		Where(sq.Eq{"monitor_id": monitorID, "used_mtr": true}).
+		Where(sq.Gt{"hop_count": 0}).
+		Where(sq.GtOrEq{"created_at": since}).
+		OrderBy("created_at ASC", "id ASC")
+
+	rows, err := query.RunWith(s.db).Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hop count trend: %w", err)
+	}
+	defer rows.Close()
+
+	points := make([]types.HopCountPoint, 0)
+	for rows.Next() {
+		var point types.HopCountPoint
+		if err := rows.Scan(&point.ResultID, &point.HopCount, &point.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan hop count: %w", err)
+		}
+		points = append(points, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating hop counts: %w", err)
+	}
+
+	trend := summarizeHopCounts(points)
+	trend.MonitorID = monitorID
+	return trend, nil
+}
+
+// GetPacketLossSparkline returns the latest limit results of a monitor, oldest first
+func (s *service) GetPacketLossSparkline(monitorID int64, limit int) (*types.PacketLossSparkline, error) {
+	query := s.sqlBuilder.
+		Select("id", "packet_loss", "avg_rtt", "jitter", "used_mtr", "created_at").
+		From("packet_loss_results").
+		Where(sq.Eq{"monitor_id": monitorID}).
+		OrderBy("created_at DESC", "id DESC").
+		Limit(uint64(limit))
+
+	rows, err := query.RunWith(s.db).Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get packet loss sparkline: %w", err)
+	}
+	defer rows.Close()
+
+	points := make([]types.PacketLossSparklinePoint, 0, limit)
+	for rows.Next() {
+		var point types.PacketLossSparklinePoint
+		if err := rows.Scan(&point.ResultID, &point.PacketLoss, &point.AvgRTT, &point.Jitter, &point.UsedMTR, &point.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan packet loss sparkline point: %w", err)
+		}
+		points = append(points, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating packet loss sparkline: %w", err)
+	}
+
+	// Fetched newest first to apply the limit, returned oldest first for charting
+	slices.Reverse(points)
+	return &types.PacketLossSparkline{MonitorID: monitorID, Points: points}, nil
+}
+
+// GetPacketLossMTRData returns the stored MTR data of results created within [from, to], oldest first.
+// A zero from or to leaves that side of the range open.
+func (s *service) GetPacketLossMTRData(ctx context.Context, from, to time.Time) ([]types.MTRDataRecord, error) {
+	query := s.sqlBuilder.
+		Select("id", "monitor_id", "mtr_data", "created_at").
+		From("packet_loss_results").
+		Where(sq.Eq{"used_mtr": true}).
+		Where(sq.NotEq{"mtr_data": nil}).
+		OrderBy("created_at ASC", "id ASC")
+	if !from.IsZero() {
+		query = query.Where(sq.GtOrEq{"created_at": from})
+	}
+	if !to.IsZero() {
+		query = query.Where(sq.LtOrEq{"created_at": to})
+	}
+
+	rows, err := query.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MTR data: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]types.MTRDataRecord, 0)
+	for rows.Next() {
+		var record types.MTRDataRecord
+		if err := rows.Scan(&record.ResultID, &record.MonitorID, &record.MTRData, &record.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan MTR data: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating MTR data: %w", err)
+	}
+
+	return records, nil
+}
+
+// UpdatePacketLossMTRData replaces the stored MTR data of a result
+func (s *service) UpdatePacketLossMTRData(ctx context.Context, resultID int64, mtrData string) error {
+	result, err := s.sqlBuilder.
+		Update("packet_loss_results").
+		Set("mtr_data", mtrData).
+		Where(sq.Eq{"id": resultID}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update MTR data: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdatePacketLossResultNote sets the note of a monitor's result, an empty note clears it
+func (s *service) UpdatePacketLossResultNote(ctx context.Context, monitorID, resultID int64, note string) error {
+	result, err := s.sqlBuilder.
+		Update("packet_loss_results").
+		Set("note", nullableNote(note)).
+		Where(sq.Eq{"monitor_id": monitorID, "id": resultID}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update packet loss result note: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetLatestPacketLossHopCount returns the hop count of the monitor's newest MTR result,
+// or 0 when it has none
+func (s *service) GetLatestPacketLossHopCount(monitorID int64) (int, error) {
+	query := s.sqlBuilder.
+		Select("hop_count").
+		From("packet_loss_results").
+		Where(sq.Eq{"monitor_id": monitorID, "used_mtr": true}).
+		Where(sq.Gt{"hop_count": 0}).
+		OrderBy("created_at DESC", "id DESC").
+		Limit(1)
+
+	var hopCount int
+	err := query.RunWith(s.db).QueryRow().Scan(&hopCount)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest hop count: %w", err)
+	}
+	return hopCount, nil
+}
+
+// summarizeHopCounts flags route length changes and computes min/max/avg over ordered samples
+func summarizeHopCounts(points []types.HopCountPoint) *types.HopCountTrend {
+	trend := &types.HopCountTrend{Points: points}
+	if len(points) == 0 {
+		return trend
+	}
+
+	total := 0
+	trend.Min = points[0].HopCount
+	trend.Max = points[0].HopCount
+	for i := range points {
+		hops := points[i].HopCount
+		total += hops
+		if hops < trend.Min {
+			trend.Min = hops
+		}
+		if hops > trend.Max {
+			trend.Max = hops
+		}
+		if i > 0 && hops != points[i-1].HopCount {
+			points[i].Changed = true
+			trend.Changes++
+		}
+	}
+
+	trend.Avg = float64(total) / float64(len(points))
+	trend.Latest = points[len(points)-1].HopCount
+	return trend
+}
+
 // UpdatePacketLossMonitorState updates the monitor state and timestamp
 func (s *service) UpdatePacketLossMonitorState(monitorID int64, state string) error {
 	query := s.sqlBuilder.
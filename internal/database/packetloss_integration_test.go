@@ -4,6 +4,8 @@
 package database
 
 import (
+	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -65,6 +67,164 @@ func TestPacketLossMonitor_CRUD(t *testing.T) {
 	})
 }
 
+func TestPacketLossMonitor_ProbeMode(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		created, err := td.Service.CreatePacketLossMonitor(&types.PacketLossMonitor{
+			Name:        "TCP Monitor",
+			Host:        "example.com",
+			Interval:    "60s",
+			PacketCount: 10,
+			Threshold:   5.0,
+			ProbeMode:   types.PacketLossProbeTCP,
+			ProbePort:   8443,
+		})
+		require.NoError(t, err)
+
+		retrieved, err := td.Service.GetPacketLossMonitor(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, types.PacketLossProbeTCP, retrieved.ProbeMode)
+		assert.Equal(t, 8443, retrieved.ProbePort)
+
+		retrieved.ProbeMode = types.PacketLossProbeUDP
+		retrieved.ProbePort = 0
+		require.NoError(t, td.Service.UpdatePacketLossMonitor(retrieved))
+
+		monitors, err := td.Service.GetPacketLossMonitors()
+		require.NoError(t, err)
+		require.Len(t, monitors, 1)
+		assert.Equal(t, types.PacketLossProbeUDP, monitors[0].ProbeMode)
+		assert.Zero(t, monitors[0].ProbePort)
+	})
+}
+
+func TestPacketLossMonitor_IntervalAndSize(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		created, err := td.Service.CreatePacketLossMonitor(&types.PacketLossMonitor{
+			Name:        "Burst Monitor",
+			Host:        "example.com",
+			Interval:    "60s",
+			PacketCount: 50,
+			Enabled:     true,
+			Threshold:   5.0,
+			IntervalMs:  200,
+			PacketSize:  1472,
+		})
+		require.NoError(t, err)
+
+		retrieved, err := td.Service.GetPacketLossMonitor(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 200, retrieved.IntervalMs)
+		assert.Equal(t, 1472, retrieved.PacketSize)
+
+		retrieved.IntervalMs = 0
+		retrieved.PacketSize = 56
+		require.NoError(t, td.Service.UpdatePacketLossMonitor(retrieved))
+
+		monitors, err := td.Service.GetEnabledPacketLossMonitors()
+		require.NoError(t, err)
+		require.Len(t, monitors, 1)
+		assert.Zero(t, monitors[0].IntervalMs)
+		assert.Equal(t, 56, monitors[0].PacketSize)
+	})
+}
+
+func TestPacketLossMonitor_Thresholds(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		created, err := td.Service.CreatePacketLossMonitor(&types.PacketLossMonitor{
+			Name:              "Tiered Monitor",
+			Host:              "example.com",
+			Interval:          "60s",
+			PacketCount:       10,
+			Enabled:           true,
+			Threshold:         10.0,
+			WarningThreshold:  2.0,
+			CriticalThreshold: 10.0,
+		})
+		require.NoError(t, err)
+
+		retrieved, err := td.Service.GetPacketLossMonitor(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2.0, retrieved.WarningThreshold)
+		assert.Equal(t, 10.0, retrieved.CriticalThreshold)
+
+		retrieved.WarningThreshold = 0
+		retrieved.CriticalThreshold = 20.0
+		require.NoError(t, td.Service.UpdatePacketLossMonitor(retrieved))
+		require.NoError(t, td.Service.UpdatePacketLossMonitorState(created.ID, "warning"))
+
+		monitors, err := td.Service.GetEnabledPacketLossMonitors()
+		require.NoError(t, err)
+		require.Len(t, monitors, 1)
+		assert.Zero(t, monitors[0].WarningThreshold)
+		assert.Equal(t, 20.0, monitors[0].CriticalThreshold)
+		assert.Equal(t, "warning", monitors[0].LastState)
+
+		event, err := td.Service.GetEventByType(NotificationCategoryPacketLoss, NotificationEventPacketLossWarning)
+		require.NoError(t, err)
+		assert.True(t, event.SupportsThreshold)
+	})
+}
+
+func TestPacketLossMonitor_AddressFamily(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		created, err := td.Service.CreatePacketLossMonitor(&types.PacketLossMonitor{
+			Name:          "IPv6 Monitor",
+			Host:          "example.com",
+			Interval:      "60s",
+			PacketCount:   10,
+			Enabled:       true,
+			Threshold:     5.0,
+			AddressFamily: "ipv6",
+		})
+		require.NoError(t, err)
+
+		retrieved, err := td.Service.GetPacketLossMonitor(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "ipv6", retrieved.AddressFamily)
+
+		retrieved.AddressFamily = "ipv4"
+		require.NoError(t, td.Service.UpdatePacketLossMonitor(retrieved))
+
+		monitors, err := td.Service.GetPacketLossMonitors()
+		require.NoError(t, err)
+		require.Len(t, monitors, 1)
+		assert.Equal(t, "ipv4", monitors[0].AddressFamily)
+
+		probedIP := "93.184.215.14"
+		result := &types.PacketLossResult{
+			MonitorID:   created.ID,
+			PacketsSent: 10,
+			PacketsRecv: 10,
+			ProbedIP:    &probedIP,
+			CreatedAt:   time.Now(),
+		}
+		require.NoError(t, td.Service.SavePacketLossResult(result))
+		require.NoError(t, td.Service.SavePacketLossResult(&types.PacketLossResult{
+			MonitorID:   created.ID,
+			PacketsSent: 10,
+			PacketsRecv: 10,
+			CreatedAt:   time.Now().Add(-time.Minute),
+		}))
+
+		latest, err := td.Service.GetLatestPacketLossResult(created.ID)
+		require.NoError(t, err)
+		require.NotNil(t, latest.ProbedIP)
+		assert.Equal(t, probedIP, *latest.ProbedIP)
+
+		detail, err := td.Service.GetPacketLossResultDetail(created.ID, result.ID)
+		require.NoError(t, err)
+		require.NotNil(t, detail.ProbedIP)
+		assert.Equal(t, probedIP, *detail.ProbedIP)
+
+		page, err := td.Service.GetPacketLossResults(created.ID, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, page.Data, 2)
+		require.NotNil(t, page.Data[0].ProbedIP)
+		assert.Equal(t, probedIP, *page.Data[0].ProbedIP)
+		assert.Nil(t, page.Data[1].ProbedIP, "results without a probed address stay nil")
+	})
+}
+
 func TestGetEnabledPacketLossMonitors(t *testing.T) {
 	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
 		// Create multiple monitors
@@ -150,6 +310,35 @@ func TestSavePacketLossResult(t *testing.T) {
 	})
 }
 
+func TestPacketLossResult_Jitter(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		monitor := CreateTestPacketLossMonitor(t, td)
+
+		result := &types.PacketLossResult{
+			MonitorID:   monitor.ID,
+			AvgRTT:      25.0,
+			Jitter:      3.25,
+			PacketsSent: 10,
+			PacketsRecv: 10,
+			CreatedAt:   time.Now(),
+		}
+		require.NoError(t, td.Service.SavePacketLossResult(result))
+
+		latest, err := td.Service.GetLatestPacketLossResult(monitor.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 3.25, latest.Jitter)
+
+		detail, err := td.Service.GetPacketLossResultDetail(monitor.ID, result.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 3.25, detail.Jitter)
+
+		page, err := td.Service.GetPacketLossResults(monitor.ID, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, page.Data, 1)
+		assert.Equal(t, 3.25, page.Data[0].Jitter)
+	})
+}
+
 func TestGetLatestPacketLossResult(t *testing.T) {
 	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
 		// Create a monitor
@@ -380,3 +569,169 @@ func TestGetPacketLossResultDetail(t *testing.T) {
 		assert.Contains(t, *detail.MTRData, "192.168.1.1")
 	})
 }
+
+func TestGetPacketLossHopCountTrend(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		monitor := CreateTestPacketLossMonitor(t, td)
+
+		hopCounts := []int{11, 11, 13, 0}
+		for i, hops := range hopCounts {
+			result := &types.PacketLossResult{
+				MonitorID:   monitor.ID,
+				PacketsSent: 10,
+				PacketsRecv: 10,
+				UsedMTR:     hops > 0,
+				HopCount:    hops,
+				CreatedAt:   time.Now().Add(time.Duration(i-len(hopCounts)) * time.Hour),
+			}
+			require.NoError(t, td.Service.SavePacketLossResult(result))
+		}
+
+		// Outside the requested window
+		old := &types.PacketLossResult{
+			MonitorID: monitor.ID,
+			UsedMTR:   true,
+			HopCount:  20,
+			CreatedAt: time.Now().Add(-48 * time.Hour),
+		}
+		require.NoError(t, td.Service.SavePacketLossResult(old))
+
+		trend, err := td.Service.GetPacketLossHopCountTrend(monitor.ID, time.Now().Add(-24*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, trend.Points, 3)
+		assert.Equal(t, monitor.ID, trend.MonitorID)
+		assert.Equal(t, 11, trend.Min)
+		assert.Equal(t, 13, trend.Max)
+		assert.Equal(t, 13, trend.Latest)
+		assert.Equal(t, 1, trend.Changes)
+
+		// Ping-only results without a hop count don't replace the latest MTR hop count
+		latest, err := td.Service.GetLatestPacketLossHopCount(monitor.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 13, latest)
+	})
+}
+
+func TestGetPacketLossSparkline(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		monitor := CreateTestPacketLossMonitor(t, td)
+
+		// Alternating ping and MTR runs, oldest first
+		base := time.Now().Add(-time.Hour).Truncate(time.Second)
+		for i := range 5 {
+			require.NoError(t, td.Service.SavePacketLossResult(&types.PacketLossResult{
+				MonitorID:   monitor.ID,
+				PacketLoss:  float64(i),
+				AvgRTT:      10 + float64(i),
+				Jitter:      0.5 * float64(i),
+				PacketsSent: 10,
+				PacketsRecv: 10,
+				UsedMTR:     i%2 == 1,
+				CreatedAt:   base.Add(time.Duration(i) * time.Minute),
+			}))
+		}
+
+		sparkline, err := td.Service.GetPacketLossSparkline(monitor.ID, 3)
+		require.NoError(t, err)
+		assert.Equal(t, monitor.ID, sparkline.MonitorID)
+		require.Len(t, sparkline.Points, 3)
+		for i, point := range sparkline.Points {
+			run := i + 2
+			assert.Equal(t, float64(run), point.PacketLoss)
+			assert.Equal(t, 10+float64(run), point.AvgRTT)
+			assert.Equal(t, 0.5*float64(run), point.Jitter)
+			assert.Equal(t, run%2 == 1, point.UsedMTR)
+			assert.Equal(t, base.Add(time.Duration(run)*time.Minute).Unix(), point.CreatedAt.Unix())
+		}
+
+		empty, err := td.Service.GetPacketLossSparkline(monitor.ID+1, 3)
+		require.NoError(t, err)
+		assert.Empty(t, empty.Points)
+	})
+}
+
+func TestGetLatestPacketLossHopCount_NoResults(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		monitor := CreateTestPacketLossMonitor(t, td)
+
+		hops, err := td.Service.GetLatestPacketLossHopCount(monitor.ID)
+		require.NoError(t, err)
+		assert.Zero(t, hops)
+	})
+}
+
+func TestPacketLossMTRData_RangeAndUpdate(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+		monitor := CreateTestPacketLossMonitor(t, td)
+		base := time.Now().Add(-72 * time.Hour).Truncate(time.Second)
+
+		for i, hours := range []int{0, 24, 48} {
+			mtrData := fmt.Sprintf(`{"hops":[{"number":%d,"host":"8.8.8.8","ip":"8.8.8.8"}]}`, i+1)
+			require.NoError(t, td.Service.SavePacketLossResult(&types.PacketLossResult{
+				MonitorID: monitor.ID,
+				UsedMTR:   true,
+				HopCount:  1,
+				MTRData:   &mtrData,
+				CreatedAt: base.Add(time.Duration(hours) * time.Hour),
+			}))
+		}
+		// Ping-only results have nothing to enrich
+		require.NoError(t, td.Service.SavePacketLossResult(&types.PacketLossResult{
+			MonitorID: monitor.ID,
+			CreatedAt: base.Add(time.Hour),
+		}))
+
+		all, err := td.Service.GetPacketLossMTRData(ctx, time.Time{}, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, all, 3)
+		assert.Contains(t, all[0].MTRData, `"number":1`, "results should be oldest first")
+
+		ranged, err := td.Service.GetPacketLossMTRData(ctx, base.Add(12*time.Hour), base.Add(36*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, ranged, 1)
+		assert.Equal(t, monitor.ID, ranged[0].MonitorID)
+
+		updated := `{"hops":[{"number":2,"host":"8.8.8.8","ip":"8.8.8.8","countryCode":"US"}]}`
+		require.NoError(t, td.Service.UpdatePacketLossMTRData(ctx, ranged[0].ResultID, updated))
+
+		detail, err := td.Service.GetPacketLossResultDetail(monitor.ID, ranged[0].ResultID)
+		require.NoError(t, err)
+		require.NotNil(t, detail.MTRData)
+		assert.Equal(t, updated, *detail.MTRData)
+
+		assert.ErrorIs(t, td.Service.UpdatePacketLossMTRData(ctx, 99999, updated), ErrNotFound)
+	})
+}
+
+func TestPacketLossResult_Note(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		monitor, err := td.Service.CreatePacketLossMonitor(&types.PacketLossMonitor{
+			Host:        "1.1.1.1",
+			Interval:    "60s",
+			PacketCount: 10,
+			Threshold:   5.0,
+		})
+		require.NoError(t, err)
+
+		result := &types.PacketLossResult{MonitorID: monitor.ID, PacketLoss: 40, CreatedAt: time.Now()}
+		require.NoError(t, td.Service.SavePacketLossResult(result))
+
+		require.NoError(t, td.Service.UpdatePacketLossResultNote(ctx, monitor.ID, result.ID, "Upstream fiber cut"))
+
+		history, err := td.Service.GetPacketLossResults(monitor.ID, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, history.Data, 1)
+		require.NotNil(t, history.Data[0].Note)
+		assert.Equal(t, "Upstream fiber cut", *history.Data[0].Note)
+
+		detail, err := td.Service.GetPacketLossResultDetail(monitor.ID, result.ID)
+		require.NoError(t, err)
+		require.NotNil(t, detail.Note)
+
+		// The result must belong to the monitor
+		assert.ErrorIs(t, td.Service.UpdatePacketLossResultNote(ctx, monitor.ID+1, result.ID, "wrong monitor"), ErrNotFound)
+	})
+}
@@ -45,7 +45,7 @@ func TestSavePacketLossResult_PostgreSQLReturning(t *testing.T) {
 	defer db.Close()
 
 	s := &service{
-		db:         db,
+		db:         newTracedDB(db, config.Postgres),
 		config:     config.DatabaseConfig{Type: config.Postgres},
 		sqlBuilder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
 	}
@@ -65,12 +65,14 @@ func TestSavePacketLossResult_PostgreSQLReturning(t *testing.T) {
 			sqlmock.AnyArg(), // MaxRTT
 			sqlmock.AnyArg(), // AvgRTT
 			sqlmock.AnyArg(), // StdDevRTT
+			sqlmock.AnyArg(), // Jitter
 			sqlmock.AnyArg(), // PacketsSent
 			sqlmock.AnyArg(), // PacketsRecv
 			sqlmock.AnyArg(), // UsedMTR
 			sqlmock.AnyArg(), // HopCount
 			sqlmock.AnyArg(), // MTRData
 			sqlmock.AnyArg(), // PrivilegedMode
+			sqlmock.AnyArg(), // ProbedIP
 			result.CreatedAt,
 		).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
@@ -89,7 +91,7 @@ func TestSavePacketLossResult_SQLiteLastInsertId(t *testing.T) {
 	defer db.Close()
 
 	s := &service{
-		db:         db,
+		db:         newTracedDB(db, config.SQLite),
 		config:     config.DatabaseConfig{Type: config.SQLite},
 		sqlBuilder: sq.StatementBuilder,
 	}
@@ -109,12 +111,14 @@ func TestSavePacketLossResult_SQLiteLastInsertId(t *testing.T) {
 			sqlmock.AnyArg(), // MaxRTT
 			sqlmock.AnyArg(), // AvgRTT
 			sqlmock.AnyArg(), // StdDevRTT
+			sqlmock.AnyArg(), // Jitter
 			sqlmock.AnyArg(), // PacketsSent
 			sqlmock.AnyArg(), // PacketsRecv
 			sqlmock.AnyArg(), // UsedMTR
 			sqlmock.AnyArg(), // HopCount
 			sqlmock.AnyArg(), // MTRData
 			sqlmock.AnyArg(), // PrivilegedMode
+			sqlmock.AnyArg(), // ProbedIP
 			sqlmock.AnyArg(), // CreatedAt
 		).
 		WillReturnResult(sqlmock.NewResult(42, 1))
@@ -153,7 +157,7 @@ func TestSavePacketLossResult_UnsupportedDatabase(t *testing.T) {
 	defer db.Close()
 
 	s := &service{
-		db:         db,
+		db:         newTracedDB(db, "unsupported"),
 		config:     config.DatabaseConfig{Type: "unsupported"},
 		sqlBuilder: sq.StatementBuilder,
 	}
@@ -175,7 +179,7 @@ func TestSavePacketLossResult_QueryError(t *testing.T) {
 	defer db.Close()
 
 	s := &service{
-		db:         db,
+		db:         newTracedDB(db, config.Postgres),
 		config:     config.DatabaseConfig{Type: config.Postgres},
 		sqlBuilder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
 	}
@@ -193,3 +197,34 @@ func TestSavePacketLossResult_QueryError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "database connection lost")
 }
+
+func TestSummarizeHopCounts(t *testing.T) {
+	now := time.Now()
+
+	t.Run("empty", func(t *testing.T) {
+		trend := summarizeHopCounts([]types.HopCountPoint{})
+		assert.Empty(t, trend.Points)
+		assert.Zero(t, trend.Changes)
+		assert.Zero(t, trend.Avg)
+	})
+
+	t.Run("route changes are flagged", func(t *testing.T) {
+		points := []types.HopCountPoint{
+			{ResultID: 1, HopCount: 10, CreatedAt: now.Add(-4 * time.Hour)},
+			{ResultID: 2, HopCount: 10, CreatedAt: now.Add(-3 * time.Hour)},
+			{ResultID: 3, HopCount: 14, CreatedAt: now.Add(-2 * time.Hour)},
+			{ResultID: 4, HopCount: 12, CreatedAt: now.Add(-1 * time.Hour)},
+		}
+
+		trend := summarizeHopCounts(points)
+		assert.Equal(t, 10, trend.Min)
+		assert.Equal(t, 14, trend.Max)
+		assert.Equal(t, 11.5, trend.Avg)
+		assert.Equal(t, 12, trend.Latest)
+		assert.Equal(t, 2, trend.Changes)
+		assert.False(t, trend.Points[0].Changed)
+		assert.False(t, trend.Points[1].Changed)
+		assert.True(t, trend.Points[2].Changed)
+		assert.True(t, trend.Points[3].Changed)
+	})
+}
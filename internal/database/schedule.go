@@ -11,6 +11,7 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 
+	"github.com/autobrr/netronome/internal/config"
 	"github.com/autobrr/netronome/internal/types"
 )
 
@@ -25,6 +26,11 @@ func validateScheduleServerIDs(schedule types.Schedule) error {
 	if schedule.Options.UseLibrespeed && !hasServerIDs(schedule) {
 		return fmt.Errorf("%w: librespeed schedules require at least one server ID", ErrInvalidInput)
 	}
+	switch schedule.Options.ServerRotation {
+	case "", types.ServerRotationRoundRobin, types.ServerRotationRandom:
+	default:
+		return fmt.Errorf("%w: unknown server rotation %q", ErrInvalidInput, schedule.Options.ServerRotation)
+	}
 	return nil
 }
 
@@ -48,20 +54,41 @@ func (s *service) CreateSchedule(ctx context.Context, schedule types.Schedule) (
 	}
 
 	data := map[string]interface{}{
-		"server_ids": string(serverIDs),
-		"interval":   schedule.Interval,
-		"next_run":   schedule.NextRun,
-		"enabled":    schedule.Enabled,
-		"options":    string(options),
-		"created_at": sq.Expr("CURRENT_TIMESTAMP"),
+		"server_ids":   string(serverIDs),
+		intervalColumn: schedule.Interval,
+		"next_run":     schedule.NextRun,
+		"enabled":      schedule.Enabled,
+		"options":      string(options),
+		"created_at":   sq.Expr("CURRENT_TIMESTAMP"),
 	}
 
 	query := s.sqlBuilder.
 		Insert("schedules").
-		SetMap(data).
-		Suffix("RETURNING id, created_at")
+		SetMap(data)
+
+	// MySQL has no RETURNING, so read created_at back after the insert
+	if s.config.Type == config.MySQL {
+		res, err := query.RunWith(s.db).ExecContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create schedule: %w", err)
+		}
+		if schedule.ID, err = res.LastInsertId(); err != nil {
+			return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+		}
+
+		err = s.sqlBuilder.
+			Select("created_at").
+			From("schedules").
+			Where(sq.Eq{"id": schedule.ID}).
+			RunWith(s.db).QueryRowContext(ctx).Scan(&schedule.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read created schedule: %w", err)
+		}
+
+		return &schedule, nil
+	}
 
-	err = query.RunWith(s.db).QueryRowContext(ctx).Scan(&schedule.ID, &schedule.CreatedAt)
+	err = query.Suffix("RETURNING id, created_at").RunWith(s.db).QueryRowContext(ctx).Scan(&schedule.ID, &schedule.CreatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create schedule: %w", err)
 	}
@@ -74,12 +101,14 @@ func (s *service) GetSchedules(ctx context.Context) ([]types.Schedule, error) {
 		Select(
 			"id",
 			"server_ids",
-			"interval",
+			intervalColumn,
 			"last_run",
 			"next_run",
 			"enabled",
 			"options",
 			"created_at",
+			"rotation_index",
+			"last_server_id",
 		).
 		From("schedules").
 		OrderBy("created_at DESC")
@@ -95,6 +124,7 @@ func (s *service) GetSchedules(ctx context.Context) ([]types.Schedule, error) {
 		var schedule types.Schedule
 		var serverIDsJSON, optionsJSON string
 		var lastRun sql.NullTime
+		var lastServerID sql.NullString
 
 		err := rows.Scan(
 			&schedule.ID,
@@ -105,6 +135,8 @@ func (s *service) GetSchedules(ctx context.Context) ([]types.Schedule, error) {
 			&schedule.Enabled,
 			&optionsJSON,
 			&schedule.CreatedAt,
+			&schedule.RotationIndex,
+			&lastServerID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan schedule: %w", err)
@@ -113,6 +145,9 @@ func (s *service) GetSchedules(ctx context.Context) ([]types.Schedule, error) {
 		if lastRun.Valid {
 			schedule.LastRun = &lastRun.Time
 		}
+		if lastServerID.Valid {
+			schedule.LastServerID = &lastServerID.String
+		}
 
 		if err := json.Unmarshal([]byte(serverIDsJSON), &schedule.ServerIDs); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal server IDs: %w", err)
@@ -132,6 +167,9 @@ func (s *service) GetSchedules(ctx context.Context) ([]types.Schedule, error) {
 	return schedules, nil
 }
 
+// UpdateSchedule saves a schedule's settings and next run. The rotation position is
+// left alone so edits that don't carry it can't reset it; the scheduler records it
+// with UpdateScheduleRotation.
 func (s *service) UpdateSchedule(ctx context.Context, schedule types.Schedule) error {
 	if schedule.ID <= 0 {
 		return fmt.Errorf("%w: invalid schedule ID", ErrInvalidInput)
@@ -156,11 +194,11 @@ func (s *service) UpdateSchedule(ctx context.Context, schedule types.Schedule) e
 	}
 
 	data := map[string]interface{}{
-		"server_ids": string(serverIDs),
-		"interval":   schedule.Interval,
-		"next_run":   schedule.NextRun,
-		"enabled":    schedule.Enabled,
-		"options":    string(options),
+		"server_ids":   string(serverIDs),
+		intervalColumn: schedule.Interval,
+		"next_run":     schedule.NextRun,
+		"enabled":      schedule.Enabled,
+		"options":      string(options),
 	}
 
 	query := s.sqlBuilder.
@@ -185,6 +223,36 @@ func (s *service) UpdateSchedule(ctx context.Context, schedule types.Schedule) e
 	return nil
 }
 
+// UpdateScheduleRotation records the server a rotating schedule last ran against and
+// the rotation position of its next run
+func (s *service) UpdateScheduleRotation(ctx context.Context, id int64, rotationIndex int, lastServerID string) error {
+	if id <= 0 {
+		return fmt.Errorf("%w: invalid schedule ID", ErrInvalidInput)
+	}
+
+	result, err := s.sqlBuilder.
+		Update("schedules").
+		Set("rotation_index", rotationIndex).
+		Set("last_server_id", lastServerID).
+		Where(sq.Eq{"id": id}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update schedule rotation: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
 func (s *service) DeleteSchedule(ctx context.Context, id int64) error {
 	if id <= 0 {
 		return fmt.Errorf("%w: invalid schedule ID", ErrInvalidInput)
@@ -497,3 +497,55 @@ func TestSchedule_ServerIDValidation(t *testing.T) {
 		})
 	})
 }
+
+func TestSchedule_ServerRotation(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		schedule := types.Schedule{
+			ServerIDs: []string{"1", "2", "3"},
+			Interval:  "1h",
+			NextRun:   time.Now().Add(time.Hour),
+			Enabled:   true,
+			Options: types.TestOptions{
+				UseLibrespeed:  true,
+				ServerIDs:      []string{"1", "2", "3"},
+				ServerRotation: types.ServerRotationRoundRobin,
+			},
+		}
+
+		created, err := td.Service.CreateSchedule(ctx, schedule)
+		require.NoError(t, err)
+
+		require.NoError(t, td.Service.UpdateScheduleRotation(ctx, created.ID, 2, "2"))
+
+		schedules, err := td.Service.GetSchedules(ctx)
+		require.NoError(t, err)
+		require.Len(t, schedules, 1)
+		assert.Equal(t, types.ServerRotationRoundRobin, schedules[0].Options.ServerRotation)
+		assert.Equal(t, 2, schedules[0].RotationIndex)
+		require.NotNil(t, schedules[0].LastServerID)
+		assert.Equal(t, "2", *schedules[0].LastServerID)
+
+		// Edits that don't carry the rotation state keep the recorded one
+		updated := schedules[0]
+		updated.RotationIndex = 0
+		updated.LastServerID = nil
+		updated.Interval = "2h"
+		require.NoError(t, td.Service.UpdateSchedule(ctx, updated))
+
+		schedules, err = td.Service.GetSchedules(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "2h", schedules[0].Interval)
+		assert.Equal(t, 2, schedules[0].RotationIndex)
+		require.NotNil(t, schedules[0].LastServerID)
+		assert.Equal(t, "2", *schedules[0].LastServerID)
+
+		assert.ErrorIs(t, td.Service.UpdateScheduleRotation(ctx, created.ID+100, 1, "1"), ErrNotFound)
+
+		invalid := schedule
+		invalid.Options.ServerRotation = "sequential"
+		_, err = td.Service.CreateSchedule(ctx, invalid)
+		assert.ErrorIs(t, err, ErrInvalidInput)
+	})
+}
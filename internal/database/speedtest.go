@@ -6,8 +6,11 @@ package database
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	sq "github.com/Masterminds/squirrel"
+
 	"github.com/autobrr/netronome/internal/config"
 	"github.com/autobrr/netronome/internal/types"
 )
@@ -22,7 +25,17 @@ func (s *service) SaveSpeedTest(ctx context.Context, result types.SpeedTestResul
 		"upload_speed":   result.UploadSpeed,
 		"latency":        result.Latency,
 		"jitter":         result.Jitter,
+		"ttfb":           result.TTFB,
+		"down_up_ratio":  result.DownUpRatio,
+		"path_mtu":       result.PathMTU,
+		"mtu_warning":    result.MTUWarning,
+		"datagram_loss":  result.DatagramLoss,
 		"is_scheduled":   result.IsScheduled,
+		"attempts":       max(result.Attempts, 1),
+
+		"load_rx_bytes_per_second": result.LoadRxBytesPerSecond,
+		"load_tx_bytes_per_second": result.LoadTxBytesPerSecond,
+		"load_cpu_percent":         result.LoadCPUPercent,
 	}
 
 	// Use provided created_at if available, otherwise default to current UTC time
@@ -51,7 +64,7 @@ func (s *service) SaveSpeedTest(ctx context.Context, result types.SpeedTestResul
 			return nil, fmt.Errorf("failed to save speed test: %w", err)
 		}
 
-	case config.SQLite:
+	case config.SQLite, config.MySQL:
 		res, err := s.insert(ctx, "speed_tests", data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to save speed test: %w", err)
@@ -67,7 +80,7 @@ func (s *service) SaveSpeedTest(ctx context.Context, result types.SpeedTestResul
 	return &result, nil
 }
 
-func (s *service) GetSpeedTests(ctx context.Context, timeRange string, page, limit int) (*types.PaginatedSpeedTests, error) {
+func (s *service) GetSpeedTests(ctx context.Context, timeRange string, filter types.SpeedTestFilter, page, limit int) (*types.PaginatedSpeedTests, error) {
 	baseQuery := s.sqlBuilder.Select().From("speed_tests")
 
 	if timeRange != "all" {
@@ -95,12 +108,37 @@ func (s *service) GetSpeedTests(ctx context.Context, timeRange string, page, lim
 			case "month", "1m":
 				timeExpr = "datetime('now', '-1 month')"
 			}
+		case config.MySQL:
+			switch timeRange {
+			case "24h", "1d":
+				timeExpr = "UTC_TIMESTAMP() - INTERVAL 1 DAY"
+			case "3d":
+				timeExpr = "UTC_TIMESTAMP() - INTERVAL 3 DAY"
+			case "week", "1w":
+				timeExpr = "UTC_TIMESTAMP() - INTERVAL 7 DAY"
+			case "month", "1m":
+				timeExpr = "UTC_TIMESTAMP() - INTERVAL 1 MONTH"
+			}
 		}
 		if timeExpr != "" {
 			baseQuery = baseQuery.Where("created_at >= " + timeExpr)
 		}
 	}
 
+	if filter.Provider != "" {
+		baseQuery = baseQuery.Where(sq.Eq{"test_type": filter.Provider})
+	}
+	if filter.Server != "" {
+		baseQuery = baseQuery.Where(sq.Or{
+			sq.Eq{"server_name": filter.Server},
+			sq.Eq{"server_id": filter.Server},
+			sq.Eq{"server_host": filter.Server},
+		})
+	}
+	if filter.Since != nil {
+		baseQuery = baseQuery.Where(sq.GtOrEq{"created_at": filter.Since.UTC()})
+	}
+
 	countQuery := baseQuery.Columns("COUNT(*)")
 	var total int
 	err := countQuery.RunWith(s.db).QueryRowContext(ctx).Scan(&total)
@@ -109,19 +147,7 @@ func (s *service) GetSpeedTests(ctx context.Context, timeRange string, page, lim
 	}
 
 	// Get paginated results
-	dataQuery := baseQuery.Columns(
-		"id",
-		"server_name",
-		"server_id",
-		"server_host",
-		"test_type",
-		"download_speed",
-		"upload_speed",
-		"latency",
-		"jitter",
-		"is_scheduled",
-		"created_at",
-	).
+	dataQuery := baseQuery.Columns(speedTestColumns...).
 		OrderBy("created_at DESC").
 		Limit(uint64(limit)).
 		Offset(uint64((page - 1) * limit))
@@ -134,26 +160,11 @@ func (s *service) GetSpeedTests(ctx context.Context, timeRange string, page, lim
 
 	results := make([]types.SpeedTestResult, 0)
 	for rows.Next() {
-		var result types.SpeedTestResult
-		err := rows.Scan(
-			&result.ID,
-			&result.ServerName,
-			&result.ServerID,
-			&result.ServerHost,
-			&result.TestType,
-			&result.DownloadSpeed,
-			&result.UploadSpeed,
-			&result.Latency,
-			&result.Jitter,
-			&result.IsScheduled,
-			&result.CreatedAt,
-		)
+		result, err := scanSpeedTest(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan speed test result: %w", err)
+			return nil, err
 		}
-
-		result.CreatedAt = result.CreatedAt.UTC()
-		results = append(results, result)
+		results = append(results, *result)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -161,9 +172,145 @@ func (s *service) GetSpeedTests(ctx context.Context, timeRange string, page, lim
 	}
 
 	return &types.PaginatedSpeedTests{
-		Data:  results,
-		Total: total,
-		Page:  page,
-		Limit: limit,
+		Data:    results,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+		Filters: filter,
 	}, nil
 }
+
+// speedTestColumns are the speed_tests columns read by scanSpeedTest, in order
+var speedTestColumns = []string{
+	"id",
+	"server_name",
+	"server_id",
+	"server_host",
+	"test_type",
+	"download_speed",
+	"upload_speed",
+	"latency",
+	"jitter",
+	"ttfb",
+	"down_up_ratio",
+	"path_mtu",
+	"mtu_warning",
+	"datagram_loss",
+	"note",
+	"is_scheduled",
+	"attempts",
+	"created_at",
+	"load_rx_bytes_per_second",
+	"load_tx_bytes_per_second",
+	"load_cpu_percent",
+}
+
+func scanSpeedTest(row sq.RowScanner) (*types.SpeedTestResult, error) {
+	var result types.SpeedTestResult
+	err := row.Scan(
+		&result.ID,
+		&result.ServerName,
+		&result.ServerID,
+		&result.ServerHost,
+		&result.TestType,
+		&result.DownloadSpeed,
+		&result.UploadSpeed,
+		&result.Latency,
+		&result.Jitter,
+		&result.TTFB,
+		&result.DownUpRatio,
+		&result.PathMTU,
+		&result.MTUWarning,
+		&result.DatagramLoss,
+		&result.Note,
+		&result.IsScheduled,
+		&result.Attempts,
+		&result.CreatedAt,
+		&result.LoadRxBytesPerSecond,
+		&result.LoadTxBytesPerSecond,
+		&result.LoadCPUPercent,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan speed test result: %w", err)
+	}
+
+	result.CreatedAt = result.CreatedAt.UTC()
+	return &result, nil
+}
+
+// UpdateSpeedTestNote sets the note of a speed test result, an empty note clears it
+func (s *service) UpdateSpeedTestNote(ctx context.Context, id int64, note string) error {
+	result, err := s.sqlBuilder.
+		Update("speed_tests").
+		Set("note", nullableNote(note)).
+		Where(sq.Eq{"id": id}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update speed test note: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// nullableNote stores blank notes as NULL so cleared notes are omitted from results
+func nullableNote(note string) *string {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil
+	}
+	return &note
+}
+
+// GetSpeedTestRatios returns results with a stored download/upload ratio, newest first.
+// An empty testType matches all types, zero from/to leave that end of the range open
+// and a limit of 0 returns every match.
+func (s *service) GetSpeedTestRatios(ctx context.Context, testType string, from, to time.Time, limit int) ([]types.SpeedTestRatio, error) {
+	query := s.sqlBuilder.
+		Select("id", "server_name", "test_type", "download_speed", "upload_speed", "down_up_ratio", "created_at").
+		From("speed_tests").
+		Where(sq.NotEq{"down_up_ratio": nil}).
+		OrderBy("created_at DESC", "id DESC")
+
+	if testType != "" {
+		query = query.Where(sq.Eq{"test_type": testType})
+	}
+	if !from.IsZero() {
+		query = query.Where(sq.GtOrEq{"created_at": from.UTC()})
+	}
+	if !to.IsZero() {
+		query = query.Where(sq.LtOrEq{"created_at": to.UTC()})
+	}
+	if limit > 0 {
+		query = query.Limit(uint64(limit))
+	}
+
+	rows, err := query.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query speed test ratios: %w", err)
+	}
+	defer rows.Close()
+
+	ratios := make([]types.SpeedTestRatio, 0)
+	for rows.Next() {
+		var r types.SpeedTestRatio
+		if err := rows.Scan(&r.ID, &r.ServerName, &r.TestType, &r.DownloadSpeed, &r.UploadSpeed, &r.DownUpRatio, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan speed test ratio: %w", err)
+		}
+		r.CreatedAt = r.CreatedAt.UTC()
+		ratios = append(ratios, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating speed test ratios: %w", err)
+	}
+
+	return ratios, nil
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// CreateSpeedTestBatch stores a batch together with its pending runs
+func (s *service) CreateSpeedTestBatch(ctx context.Context, batch *types.SpeedTestBatch) (*types.SpeedTestBatch, error) {
+	batch.CreatedAt = time.Now()
+	if batch.Status == "" {
+		batch.Status = types.BatchStatusPending
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	batchQuery := s.sqlBuilder.
+		Insert("speedtest_batches").
+		Columns("name", "status", "created_at").
+		Values(batch.Name, batch.Status, batch.CreatedAt)
+	if batch.ID, err = s.insertReturningID(ctx, tx, batchQuery); err != nil {
+		return nil, fmt.Errorf("failed to create speedtest batch: %w", err)
+	}
+
+	for i := range batch.Runs {
+		run := &batch.Runs[i]
+		run.BatchID = batch.ID
+		run.CreatedAt = batch.CreatedAt
+		if run.Status == "" {
+			run.Status = types.BatchStatusPending
+		}
+
+		runQuery := s.sqlBuilder.
+			Insert("speedtest_batch_runs").
+			Columns("batch_id", "test_type", "server", "direction", "status", "created_at").
+			Values(run.BatchID, run.TestType, run.Server, run.Direction, run.Status, run.CreatedAt)
+		if run.ID, err = s.insertReturningID(ctx, tx, runQuery); err != nil {
+			return nil, fmt.Errorf("failed to create speedtest batch run: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit speedtest batch: %w", err)
+	}
+
+	return batch, nil
+}
+
+// insertReturningID runs an insert inside tx and returns the new row ID
+func (s *service) insertReturningID(ctx context.Context, tx *sql.Tx, query sq.InsertBuilder) (int64, error) {
+	if s.config.Type == config.Postgres {
+		var id int64
+		err := query.Suffix("RETURNING id").RunWith(tx).QueryRowContext(ctx).Scan(&id)
+		return id, err
+	}
+
+	res, err := query.RunWith(tx).ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// UpdateSpeedTestBatchStatus sets the status of a batch and when it finished
+func (s *service) UpdateSpeedTestBatchStatus(ctx context.Context, batchID int64, status string, completedAt *time.Time) error {
+	res, err := s.sqlBuilder.
+		Update("speedtest_batches").
+		Set("status", status).
+		Set("completed_at", completedAt).
+		Where(sq.Eq{"id": batchID}).
+		RunWith(s.db).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update speedtest batch: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateSpeedTestBatchRun records the status, result and error of a batch run
+func (s *service) UpdateSpeedTestBatchRun(ctx context.Context, run *types.SpeedTestBatchRun) error {
+	var runError *string
+	if run.Error != "" {
+		runError = &run.Error
+	}
+
+	res, err := s.sqlBuilder.
+		Update("speedtest_batch_runs").
+		SetMap(map[string]interface{}{
+			"status":        run.Status,
+			"speed_test_id": run.SpeedTestID,
+			"error":         runError,
+			"completed_at":  run.CompletedAt,
+		}).
+		Where(sq.Eq{"id": run.ID}).
+		RunWith(s.db).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update speedtest batch run: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetSpeedTestBatch retrieves a batch with its runs in matrix order and the stored
+// result of every finished run
+func (s *service) GetSpeedTestBatch(ctx context.Context, batchID int64) (*types.SpeedTestBatch, error) {
+	batch := &types.SpeedTestBatch{}
+	var name sql.NullString
+	err := s.sqlBuilder.
+		Select("id", "name", "status", "created_at", "completed_at").
+		From("speedtest_batches").
+		Where(sq.Eq{"id": batchID}).
+		RunWith(s.db).QueryRowContext(ctx).
+		Scan(&batch.ID, &name, &batch.Status, &batch.CreatedAt, &batch.CompletedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get speedtest batch: %w", err)
+	}
+	batch.Name = name.String
+
+	rows, err := s.sqlBuilder.
+		Select("id", "batch_id", "test_type", "server", "direction", "status", "speed_test_id", "error", "created_at", "completed_at").
+		From("speedtest_batch_runs").
+		Where(sq.Eq{"batch_id": batchID}).
+		OrderBy("id").
+		RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get speedtest batch runs: %w", err)
+	}
+	defer rows.Close()
+
+	batch.Runs = []types.SpeedTestBatchRun{}
+	var resultIDs []int64
+	for rows.Next() {
+		var run types.SpeedTestBatchRun
+		var runError sql.NullString
+		if err := rows.Scan(
+			&run.ID,
+			&run.BatchID,
+			&run.TestType,
+			&run.Server,
+			&run.Direction,
+			&run.Status,
+			&run.SpeedTestID,
+			&runError,
+			&run.CreatedAt,
+			&run.CompletedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan speedtest batch run: %w", err)
+		}
+		run.Error = runError.String
+		if run.SpeedTestID != nil {
+			resultIDs = append(resultIDs, *run.SpeedTestID)
+		}
+		batch.Runs = append(batch.Runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read speedtest batch runs: %w", err)
+	}
+
+	if len(resultIDs) == 0 {
+		return batch, nil
+	}
+
+	results, err := s.getSpeedTestsByID(ctx, resultIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range batch.Runs {
+		if id := batch.Runs[i].SpeedTestID; id != nil {
+			batch.Runs[i].Result = results[*id]
+		}
+	}
+
+	return batch, nil
+}
+
+// getSpeedTestsByID loads the speed test results with the given IDs, keyed by ID
+func (s *service) getSpeedTestsByID(ctx context.Context, ids []int64) (map[int64]*types.SpeedTestResult, error) {
+	rows, err := s.sqlBuilder.
+		Select("id", "server_name", "server_id", "server_host", "test_type", "download_speed", "upload_speed", "latency", "jitter", "ttfb", "is_scheduled", "created_at").
+		From("speed_tests").
+		Where(sq.Eq{"id": ids}).
+		RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch speed tests: %w", err)
+	}
+	defer rows.Close()
+
+	results := make(map[int64]*types.SpeedTestResult, len(ids))
+	for rows.Next() {
+		result := &types.SpeedTestResult{}
+		if err := rows.Scan(
+			&result.ID,
+			&result.ServerName,
+			&result.ServerID,
+			&result.ServerHost,
+			&result.TestType,
+			&result.DownloadSpeed,
+			&result.UploadSpeed,
+			&result.Latency,
+			&result.Jitter,
+			&result.TTFB,
+			&result.IsScheduled,
+			&result.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan batch speed test: %w", err)
+		}
+		result.CreatedAt = result.CreatedAt.UTC()
+		results[result.ID] = result
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch speed tests: %w", err)
+	}
+
+	return results, nil
+}
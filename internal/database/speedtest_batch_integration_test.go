@@ -0,0 +1,77 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestSpeedTestBatch_Lifecycle(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		batch, err := td.Service.CreateSpeedTestBatch(ctx, &types.SpeedTestBatch{
+			Name: "Nightly matrix",
+			Runs: []types.SpeedTestBatchRun{
+				{TestType: "speedtest", Server: "1234", Direction: types.BatchDirectionDownload},
+				{TestType: "iperf3", Server: "iperf.example.com:5201", Direction: types.BatchDirectionUpload},
+			},
+		})
+		require.NoError(t, err)
+		assert.Greater(t, batch.ID, int64(0))
+		require.Len(t, batch.Runs, 2)
+		assert.Greater(t, batch.Runs[0].ID, int64(0))
+		assert.Equal(t, types.BatchStatusPending, batch.Runs[1].Status)
+
+		saved, err := td.Service.SaveSpeedTest(ctx, types.SpeedTestResult{
+			ServerName:    "Test Server",
+			ServerID:      "1234",
+			TestType:      "speedtest",
+			DownloadSpeed: 250,
+			Latency:       "12.0ms",
+		})
+		require.NoError(t, err)
+
+		completedAt := time.Now().UTC().Truncate(time.Second)
+		first := batch.Runs[0]
+		first.Status = types.BatchStatusCompleted
+		first.SpeedTestID = &saved.ID
+		first.CompletedAt = &completedAt
+		require.NoError(t, td.Service.UpdateSpeedTestBatchRun(ctx, &first))
+
+		second := batch.Runs[1]
+		second.Status = types.BatchStatusFailed
+		second.Error = "connection refused"
+		second.CompletedAt = &completedAt
+		require.NoError(t, td.Service.UpdateSpeedTestBatchRun(ctx, &second))
+
+		require.NoError(t, td.Service.UpdateSpeedTestBatchStatus(ctx, batch.ID, types.BatchStatusCompleted, &completedAt))
+
+		retrieved, err := td.Service.GetSpeedTestBatch(ctx, batch.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Nightly matrix", retrieved.Name)
+		assert.Equal(t, types.BatchStatusCompleted, retrieved.Status)
+		require.NotNil(t, retrieved.CompletedAt)
+		require.Len(t, retrieved.Runs, 2)
+
+		assert.Equal(t, types.BatchStatusCompleted, retrieved.Runs[0].Status)
+		require.NotNil(t, retrieved.Runs[0].Result)
+		assert.Equal(t, 250.0, retrieved.Runs[0].Result.DownloadSpeed)
+
+		assert.Equal(t, types.BatchStatusFailed, retrieved.Runs[1].Status)
+		assert.Equal(t, "connection refused", retrieved.Runs[1].Error)
+		assert.Nil(t, retrieved.Runs[1].Result)
+
+		_, err = td.Service.GetSpeedTestBatch(ctx, batch.ID+1000)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, td.Service.UpdateSpeedTestBatchStatus(ctx, batch.ID+1000, types.BatchStatusFailed, nil), ErrNotFound)
+	})
+}
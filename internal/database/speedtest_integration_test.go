@@ -46,7 +46,7 @@ func TestSpeedTest_Save(t *testing.T) {
 		AssertRecordExists(t, td, "speed_tests", "id", saved.ID)
 
 		// Query back to verify created_at was set
-		results, err := td.Service.GetSpeedTests(ctx, "all", 1, 10)
+		results, err := td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{}, 1, 10)
 		require.NoError(t, err)
 		require.Len(t, results.Data, 1)
 		assert.NotZero(t, results.Data[0].CreatedAt)
@@ -76,7 +76,7 @@ func TestSpeedTest_GetWithPagination(t *testing.T) {
 		}
 
 		// Test pagination - first page
-		page1, err := td.Service.GetSpeedTests(ctx, "all", 1, 10)
+		page1, err := td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{}, 1, 10)
 		require.NoError(t, err)
 		assert.Len(t, page1.Data, 10)
 		assert.Equal(t, 25, page1.Total)
@@ -86,13 +86,13 @@ func TestSpeedTest_GetWithPagination(t *testing.T) {
 		assert.Equal(t, 1, page1.Page)
 
 		// Test pagination - second page
-		page2, err := td.Service.GetSpeedTests(ctx, "all", 2, 10)
+		page2, err := td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{}, 2, 10)
 		require.NoError(t, err)
 		assert.Len(t, page2.Data, 10)
 		assert.Equal(t, 2, page2.Page)
 
 		// Test pagination - last page
-		page3, err := td.Service.GetSpeedTests(ctx, "all", 3, 10)
+		page3, err := td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{}, 3, 10)
 		require.NoError(t, err)
 		assert.Len(t, page3.Data, 5)
 		assert.Equal(t, 3, page3.Page)
@@ -135,28 +135,76 @@ func TestSpeedTest_TimeRangeFilters(t *testing.T) {
 		}
 
 		// Test "24h" filter
-		results24h, err := td.Service.GetSpeedTests(ctx, "24h", 1, 100)
+		results24h, err := td.Service.GetSpeedTests(ctx, "24h", types.SpeedTestFilter{}, 1, 100)
 		require.NoError(t, err)
 
 		assert.Equal(t, 1, results24h.Total) // Only the 1 hour ago test
 
 		// Test "week" filter
-		resultsWeek, err := td.Service.GetSpeedTests(ctx, "week", 1, 100)
+		resultsWeek, err := td.Service.GetSpeedTests(ctx, "week", types.SpeedTestFilter{}, 1, 100)
 		require.NoError(t, err)
 		assert.Equal(t, 2, resultsWeek.Total) // 1 hour and 1.5 days ago
 
 		// Test "month" filter
-		resultsMonth, err := td.Service.GetSpeedTests(ctx, "month", 1, 100)
+		resultsMonth, err := td.Service.GetSpeedTests(ctx, "month", types.SpeedTestFilter{}, 1, 100)
 		require.NoError(t, err)
 		assert.Equal(t, 3, resultsMonth.Total) // All except the oldest
 
 		// Test "all" filter
-		resultsAll, err := td.Service.GetSpeedTests(ctx, "all", 1, 100)
+		resultsAll, err := td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{}, 1, 100)
 		require.NoError(t, err)
 		assert.Equal(t, 4, resultsAll.Total) // All tests
 	})
 }
 
+func TestSpeedTest_HistoryFilters(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		now := time.Now()
+		tests := []types.SpeedTestResult{
+			{ServerName: "Home iperf", ServerID: "iperf-1", ServerHost: stringPtr("iperf.lan"), TestType: "iperf3", CreatedAt: now.Add(-time.Hour)},
+			{ServerName: "Home iperf", ServerID: "iperf-1", ServerHost: stringPtr("iperf.lan"), TestType: "iperf3", CreatedAt: now.Add(-2 * time.Hour)},
+			{ServerName: "Home iperf", ServerID: "iperf-1", ServerHost: stringPtr("iperf.lan"), TestType: "iperf3", CreatedAt: now.Add(-48 * time.Hour)},
+			{ServerName: "Other iperf", ServerID: "iperf-2", TestType: "iperf3", CreatedAt: now.Add(-time.Hour)},
+			{ServerName: "Stockholm", ServerID: "42", TestType: "librespeed", CreatedAt: now.Add(-time.Hour)},
+		}
+		for _, test := range tests {
+			_, err := td.Service.SaveSpeedTest(ctx, test)
+			require.NoError(t, err)
+		}
+
+		results, err := td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{Provider: "iperf3"}, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 4, results.Total)
+		assert.Equal(t, "iperf3", results.Filters.Provider)
+
+		for _, server := range []string{"Home iperf", "iperf-1", "iperf.lan"} {
+			results, err = td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{Server: server}, 1, 10)
+			require.NoError(t, err)
+			assert.Equal(t, 3, results.Total, "server %q matches by name, ID or host", server)
+		}
+
+		since := now.Add(-24 * time.Hour)
+		filter := types.SpeedTestFilter{Provider: "iperf3", Server: "iperf-1", Since: &since}
+		page1, err := td.Service.GetSpeedTests(ctx, "all", filter, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, page1.Total)
+		require.Len(t, page1.Data, 1)
+		assert.Equal(t, filter, page1.Filters)
+
+		page2, err := td.Service.GetSpeedTests(ctx, "all", filter, 2, 1)
+		require.NoError(t, err)
+		require.Len(t, page2.Data, 1)
+		assert.True(t, page2.Data[0].CreatedAt.Before(page1.Data[0].CreatedAt))
+
+		results, err = td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{Provider: "ookla"}, 1, 10)
+		require.NoError(t, err)
+		assert.Zero(t, results.Total)
+		assert.Empty(t, results.Data)
+	})
+}
+
 func TestSpeedTest_DifferentTestTypes(t *testing.T) {
 	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
 		ctx := context.Background()
@@ -180,7 +228,7 @@ func TestSpeedTest_DifferentTestTypes(t *testing.T) {
 		}
 
 		// Verify all test types were saved
-		results, err := td.Service.GetSpeedTests(ctx, "all", 1, 100)
+		results, err := td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{}, 1, 100)
 		require.NoError(t, err)
 		assert.GreaterOrEqual(t, results.Total, len(testTypes))
 
@@ -267,7 +315,7 @@ func TestSpeedTest_NullableFields(t *testing.T) {
 		require.NoError(t, err)
 
 		// Retrieve and verify
-		results, err := td.Service.GetSpeedTests(ctx, "all", 1, 10)
+		results, err := td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{}, 1, 10)
 		require.NoError(t, err)
 		require.Greater(t, len(results.Data), 0)
 
@@ -289,3 +337,179 @@ func TestSpeedTest_NullableFields(t *testing.T) {
 		assert.True(t, found, "Should find the saved test")
 	})
 }
+
+func TestSpeedTest_Ratios(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+		base := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+		ratio := func(v float64) *float64 { return &v }
+
+		for i, r := range []struct {
+			testType string
+			ratio    *float64
+		}{
+			{"speedtest", ratio(10)},
+			{"iperf3", ratio(1)},
+			{"speedtest", nil},
+			{"speedtest", ratio(5)},
+		} {
+			_, err := td.Service.SaveSpeedTest(ctx, types.SpeedTestResult{
+				ServerName:    "Ratio Server",
+				ServerID:      "ratio-1",
+				TestType:      r.testType,
+				DownloadSpeed: 500,
+				UploadSpeed:   50,
+				DownUpRatio:   r.ratio,
+				CreatedAt:     base.Add(time.Duration(i) * time.Minute),
+			})
+			require.NoError(t, err)
+		}
+
+		all, err := td.Service.GetSpeedTestRatios(ctx, "", time.Time{}, time.Time{}, 0)
+		require.NoError(t, err)
+		require.Len(t, all, 3, "results without a ratio are skipped")
+		assert.Equal(t, 5.0, all[0].DownUpRatio, "newest first")
+
+		speedtests, err := td.Service.GetSpeedTestRatios(ctx, "speedtest", time.Time{}, base.Add(2*time.Minute), 1)
+		require.NoError(t, err)
+		require.Len(t, speedtests, 1)
+		assert.Equal(t, 10.0, speedtests[0].DownUpRatio)
+
+		results, err := td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{}, 1, 10)
+		require.NoError(t, err)
+		var withRatio int
+		for _, r := range results.Data {
+			if r.DownUpRatio != nil {
+				withRatio++
+			}
+		}
+		assert.Equal(t, 3, withRatio)
+	})
+}
+
+func TestSpeedTest_PathMTU(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+		mtu := 1492
+		warning := "Path MTU is 1492"
+
+		_, err := td.Service.SaveSpeedTest(ctx, types.SpeedTestResult{
+			ServerName:    "MTU Server",
+			ServerID:      "mtu-1",
+			TestType:      "iperf3",
+			DownloadSpeed: 400,
+			UploadSpeed:   40,
+			PathMTU:       &mtu,
+			MTUWarning:    &warning,
+		})
+		require.NoError(t, err)
+
+		results, err := td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{}, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, results.Data, 1)
+		require.NotNil(t, results.Data[0].PathMTU)
+		assert.Equal(t, 1492, *results.Data[0].PathMTU)
+		require.NotNil(t, results.Data[0].MTUWarning)
+		assert.Equal(t, warning, *results.Data[0].MTUWarning)
+	})
+}
+
+func TestSpeedTest_DatagramLoss(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+		loss := 0.35
+		jitter := 0.8
+
+		_, err := td.Service.SaveSpeedTest(ctx, types.SpeedTestResult{
+			ServerName:    "UDP Server",
+			ServerID:      "iperf3-udp",
+			TestType:      "iperf3",
+			DownloadSpeed: 95,
+			Jitter:        &jitter,
+			DatagramLoss:  &loss,
+		})
+		require.NoError(t, err)
+		_, err = td.Service.SaveSpeedTest(ctx, types.SpeedTestResult{
+			ServerName:    "TCP Server",
+			ServerID:      "iperf3-tcp",
+			TestType:      "iperf3",
+			DownloadSpeed: 900,
+		})
+		require.NoError(t, err)
+
+		results, err := td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{}, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, results.Data, 2)
+		for _, result := range results.Data {
+			if result.ServerID == "iperf3-udp" {
+				require.NotNil(t, result.DatagramLoss)
+				assert.InDelta(t, 0.35, *result.DatagramLoss, 0.0001)
+			} else {
+				assert.Nil(t, result.DatagramLoss, "TCP tests have no datagram loss")
+			}
+		}
+	})
+}
+
+func TestSpeedTest_Attempts(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		_, err := td.Service.SaveSpeedTest(ctx, types.SpeedTestResult{
+			ServerName:  "Retried Server",
+			ServerID:    "retried",
+			TestType:    "speedtest",
+			IsScheduled: true,
+			Attempts:    3,
+		})
+		require.NoError(t, err)
+		_, err = td.Service.SaveSpeedTest(ctx, types.SpeedTestResult{
+			ServerName: "First Try Server",
+			ServerID:   "first-try",
+			TestType:   "speedtest",
+		})
+		require.NoError(t, err)
+
+		results, err := td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{}, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, results.Data, 2)
+		for _, result := range results.Data {
+			if result.ServerID == "retried" {
+				assert.Equal(t, 3, result.Attempts)
+			} else {
+				assert.Equal(t, 1, result.Attempts, "results without attempts count as one")
+			}
+		}
+	})
+}
+
+func TestSpeedTest_Note(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		saved, err := td.Service.SaveSpeedTest(ctx, types.SpeedTestResult{
+			ServerName:    "Note Server",
+			ServerID:      "note-1",
+			TestType:      "speedtest",
+			DownloadSpeed: 20,
+			UploadSpeed:   5,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, td.Service.UpdateSpeedTestNote(ctx, saved.ID, " ISP confirmed maintenance "))
+
+		results, err := td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{}, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, results.Data, 1)
+		require.NotNil(t, results.Data[0].Note)
+		assert.Equal(t, "ISP confirmed maintenance", *results.Data[0].Note)
+
+		// An empty note clears it
+		require.NoError(t, td.Service.UpdateSpeedTestNote(ctx, saved.ID, ""))
+		results, err = td.Service.GetSpeedTests(ctx, "all", types.SpeedTestFilter{}, 1, 10)
+		require.NoError(t, err)
+		assert.Nil(t, results.Data[0].Note)
+
+		assert.ErrorIs(t, td.Service.UpdateSpeedTestNote(ctx, 99999, "missing"), ErrNotFound)
+	})
+}
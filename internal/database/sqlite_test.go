@@ -0,0 +1,73 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+func TestSQLitePragmas(t *testing.T) {
+	assert.Equal(t, []string{"busy_timeout(5000)", "journal_mode(wal)"}, sqlitePragmas(config.SQLiteConfig{}))
+	assert.Equal(t, []string{"busy_timeout(5000)", "journal_mode(wal)"}, sqlitePragmas(config.SQLiteConfig{BusyTimeout: "soon"}))
+
+	assert.Equal(t,
+		[]string{"busy_timeout(30000)", "journal_mode(delete)", "synchronous(normal)", "cache_size(-20000)"},
+		sqlitePragmas(config.SQLiteConfig{BusyTimeout: "30s", JournalMode: "DELETE", Synchronous: "Normal", CacheSize: -20000}),
+	)
+}
+
+func TestSQLiteDSN_AppliesPragmasToEveryConnection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pragmas.db")
+	db, err := sql.Open("sqlite", sqliteDSN(path, config.SQLiteConfig{BusyTimeout: "2500ms", Synchronous: "normal", CacheSize: -4000}))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Hold one connection so the queries on db need a second one
+	held, err := db.Conn(t.Context())
+	require.NoError(t, err)
+	defer held.Close()
+
+	var busyTimeout, synchronous, cacheSize int
+	var journalMode string
+	require.NoError(t, db.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout))
+	require.NoError(t, db.QueryRow("PRAGMA synchronous").Scan(&synchronous))
+	require.NoError(t, db.QueryRow("PRAGMA cache_size").Scan(&cacheSize))
+	require.NoError(t, db.QueryRow("PRAGMA journal_mode").Scan(&journalMode))
+	assert.Equal(t, 2500, busyTimeout)
+	assert.Equal(t, 1, synchronous, "normal")
+	assert.Equal(t, -4000, cacheSize)
+	assert.Equal(t, "wal", journalMode)
+
+	require.NoError(t, held.QueryRowContext(t.Context(), "PRAGMA busy_timeout").Scan(&busyTimeout))
+	assert.Equal(t, 2500, busyTimeout)
+}
+
+func TestCheck_SQLite(t *testing.T) {
+	dir := t.TempDir()
+
+	missing := filepath.Join(dir, "missing.db")
+	require.Error(t, Check(t.Context(), config.DatabaseConfig{Type: config.SQLite, Path: missing}))
+	assert.NoFileExists(t, missing, "a missing database isn't created")
+
+	notDB := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(notDB, []byte("not a database, just some text that is long enough"), 0o600))
+	assert.Error(t, Check(t.Context(), config.DatabaseConfig{Type: config.SQLite, Path: notDB}))
+
+	path := filepath.Join(dir, "netronome.db")
+	db, err := sql.Open("sqlite", sqliteDSN(path, config.SQLiteConfig{}))
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE example (id INTEGER)")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	assert.NoError(t, Check(t.Context(), config.DatabaseConfig{Type: config.SQLite, Path: path}))
+}
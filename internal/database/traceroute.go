@@ -0,0 +1,414 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// tracerouteMonitorColumns lists the traceroute_monitors columns in the order scanTracerouteMonitor expects
+var tracerouteMonitorColumns = []string{
+	"id", "host", "name", intervalColumn, "family", "enabled", "last_run", "next_run", "created_at", "updated_at",
+}
+
+// tracerouteResultColumns lists the traceroute_monitor_results columns in the order scanTracerouteResult expects
+var tracerouteResultColumns = []string{
+	"id", "monitor_id", "destination", "ip", "total_hops", "complete", "path_changed", "hops", "created_at",
+}
+
+// scanTracerouteMonitor scans a row selected with tracerouteMonitorColumns
+func scanTracerouteMonitor(row sq.RowScanner, monitor *types.TracerouteMonitor) error {
+	var name sql.NullString
+	if err := row.Scan(
+		&monitor.ID,
+		&monitor.Host,
+		&name,
+		&monitor.Interval,
+		&monitor.Family,
+		&monitor.Enabled,
+		&monitor.LastRun,
+		&monitor.NextRun,
+		&monitor.CreatedAt,
+		&monitor.UpdatedAt,
+	); err != nil {
+		return err
+	}
+	monitor.Name = name.String
+	return nil
+}
+
+// scanTracerouteResult scans a row selected with tracerouteResultColumns
+func scanTracerouteResult(row sq.RowScanner, result *types.TracerouteMonitorResult) error {
+	var ip sql.NullString
+	var hops string
+	if err := row.Scan(
+		&result.ID,
+		&result.MonitorID,
+		&result.Destination,
+		&ip,
+		&result.TotalHops,
+		&result.Complete,
+		&result.PathChanged,
+		&hops,
+		&result.CreatedAt,
+	); err != nil {
+		return err
+	}
+	result.IP = ip.String
+	result.Hops = json.RawMessage(hops)
+	return nil
+}
+
+// CreateTracerouteMonitor creates a new traceroute monitor
+func (s *service) CreateTracerouteMonitor(ctx context.Context, monitor *types.TracerouteMonitor) (*types.TracerouteMonitor, error) {
+	now := time.Now()
+	monitor.CreatedAt = now
+	monitor.UpdatedAt = now
+
+	query := s.sqlBuilder.
+		Insert("traceroute_monitors").
+		Columns("host", "name", intervalColumn, "family", "enabled", "next_run", "created_at", "updated_at").
+		Values(monitor.Host, monitor.Name, monitor.Interval, monitor.Family, monitor.Enabled, monitor.NextRun, monitor.CreatedAt, monitor.UpdatedAt)
+
+	if s.config.Type == config.Postgres {
+		if err := query.Suffix("RETURNING id").RunWith(s.db).QueryRowContext(ctx).Scan(&monitor.ID); err != nil {
+			return nil, fmt.Errorf("failed to create traceroute monitor: %w", err)
+		}
+	} else {
+		res, err := query.RunWith(s.db).ExecContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create traceroute monitor: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+		}
+		monitor.ID = id
+	}
+
+	return monitor, nil
+}
+
+// GetTracerouteMonitor retrieves a traceroute monitor by ID
+func (s *service) GetTracerouteMonitor(ctx context.Context, monitorID int64) (*types.TracerouteMonitor, error) {
+	query := s.sqlBuilder.
+		Select(tracerouteMonitorColumns...).
+		From("traceroute_monitors").
+		Where(sq.Eq{"id": monitorID})
+
+	monitor := &types.TracerouteMonitor{}
+	err := scanTracerouteMonitor(query.RunWith(s.db).QueryRowContext(ctx), monitor)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get traceroute monitor: %w", err)
+	}
+
+	return monitor, nil
+}
+
+// GetTracerouteMonitors retrieves all traceroute monitors
+func (s *service) GetTracerouteMonitors(ctx context.Context) ([]*types.TracerouteMonitor, error) {
+	query := s.sqlBuilder.
+		Select(tracerouteMonitorColumns...).
+		From("traceroute_monitors").
+		OrderBy("created_at DESC")
+
+	rows, err := query.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get traceroute monitors: %w", err)
+	}
+	defer rows.Close()
+
+	monitors := []*types.TracerouteMonitor{}
+	for rows.Next() {
+		monitor := &types.TracerouteMonitor{}
+		if err := scanTracerouteMonitor(rows, monitor); err != nil {
+			log.Error().Err(err).Msg("Failed to scan traceroute monitor")
+			continue
+		}
+		monitors = append(monitors, monitor)
+	}
+
+	return monitors, rows.Err()
+}
+
+// UpdateTracerouteMonitor updates an existing traceroute monitor
+func (s *service) UpdateTracerouteMonitor(ctx context.Context, monitor *types.TracerouteMonitor) error {
+	monitor.UpdatedAt = time.Now()
+
+	query := s.sqlBuilder.
+		Update("traceroute_monitors").
+		SetMap(map[string]interface{}{
+			"host":         monitor.Host,
+			"name":         monitor.Name,
+			intervalColumn: monitor.Interval,
+			"family":       monitor.Family,
+			"enabled":      monitor.Enabled,
+			"last_run":     monitor.LastRun,
+			"next_run":     monitor.NextRun,
+			"updated_at":   monitor.UpdatedAt,
+		}).
+		Where(sq.Eq{"id": monitor.ID})
+
+	res, err := query.RunWith(s.db).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update traceroute monitor: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteTracerouteMonitor deletes a traceroute monitor and its results
+func (s *service) DeleteTracerouteMonitor(ctx context.Context, monitorID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Delete results first, SQLite only cascades with foreign keys enabled
+	if _, err := s.sqlBuilder.
+		Delete("traceroute_monitor_results").
+		Where(sq.Eq{"monitor_id": monitorID}).
+		RunWith(tx).ExecContext(ctx); err != nil {
+		return fmt.Errorf("failed to delete traceroute results: %w", err)
+	}
+
+	res, err := s.sqlBuilder.
+		Delete("traceroute_monitors").
+		Where(sq.Eq{"id": monitorID}).
+		RunWith(tx).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete traceroute monitor: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+// SaveTracerouteMonitorResult stores the result of a scheduled traceroute
+func (s *service) SaveTracerouteMonitorResult(ctx context.Context, result *types.TracerouteMonitorResult) error {
+	if result.CreatedAt.IsZero() {
+		result.CreatedAt = time.Now()
+	}
+	hops := string(result.Hops)
+	if hops == "" {
+		hops = "[]"
+	}
+
+	query := s.sqlBuilder.
+		Insert("traceroute_monitor_results").
+		Columns("monitor_id", "destination", "ip", "total_hops", "complete", "path_changed", "hops", "created_at").
+		Values(result.MonitorID, result.Destination, result.IP, result.TotalHops, result.Complete, result.PathChanged, hops, result.CreatedAt)
+
+	if s.config.Type == config.Postgres {
+		if err := query.Suffix("RETURNING id").RunWith(s.db).QueryRowContext(ctx).Scan(&result.ID); err != nil {
+			return fmt.Errorf("failed to save traceroute result: %w", err)
+		}
+		return nil
+	}
+
+	res, err := query.RunWith(s.db).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to save traceroute result: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	result.ID = id
+	return nil
+}
+
+// GetLatestTracerouteMonitorResult retrieves the most recent result for a traceroute monitor
+func (s *service) GetLatestTracerouteMonitorResult(ctx context.Context, monitorID int64) (*types.TracerouteMonitorResult, error) {
+	query := s.sqlBuilder.
+		Select(tracerouteResultColumns...).
+		From("traceroute_monitor_results").
+		Where(sq.Eq{"monitor_id": monitorID}).
+		OrderBy("created_at DESC", "id DESC").
+		Limit(1)
+
+	result := &types.TracerouteMonitorResult{}
+	err := scanTracerouteResult(query.RunWith(s.db).QueryRowContext(ctx), result)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest traceroute result: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetTracerouteHopCountTrend returns hop counts for a traceroute monitor since the given time, oldest first
+func (s *service) GetTracerouteHopCountTrend(ctx context.Context, monitorID int64, since time.Time) (*types.HopCountTrend, error) {
+	query := s.sqlBuilder.
+		Select("id", "total_hops", "created_at").
+		From("traceroute_monitor_results").
+		Where(sq.Eq{"monitor_id": monitorID}).
+		Where(sq.Gt{"total_hops": 0}).
+		Where(sq.GtOrEq{"created_at": since}).
+		OrderBy("created_at ASC", "id ASC")
+
+	rows, err := query.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get traceroute hop count trend: %w", err)
+	}
+	defer rows.Close()
+
+	points := make([]types.HopCountPoint, 0)
+	for rows.Next() {
+		var point types.HopCountPoint
+		if err := rows.Scan(&point.ResultID, &point.HopCount, &point.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan traceroute hop count: %w", err)
+		}
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read traceroute hop counts: %w", err)
+	}
+
+	trend := summarizeHopCounts(points)
+	trend.MonitorID = monitorID
+	return trend, nil
+}
+
+// GetTracerouteMonitorResults retrieves paginated results for a traceroute monitor, newest first
+func (s *service) GetTracerouteMonitorResults(ctx context.Context, monitorID int64, page int, limit int) (*types.PaginatedTracerouteMonitorResults, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var total int
+	if err := s.sqlBuilder.
+		Select("COUNT(*)").
+		From("traceroute_monitor_results").
+		Where(sq.Eq{"monitor_id": monitorID}).
+		RunWith(s.db).QueryRowContext(ctx).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count traceroute results: %w", err)
+	}
+
+	query := s.sqlBuilder.
+		Select(tracerouteResultColumns...).
+		From("traceroute_monitor_results").
+		Where(sq.Eq{"monitor_id": monitorID}).
+		OrderBy("created_at DESC", "id DESC").
+		Limit(uint64(limit)).
+		Offset(uint64((page - 1) * limit))
+
+	rows, err := query.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get traceroute results: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]types.TracerouteMonitorResult, 0, limit)
+	for rows.Next() {
+		var result types.TracerouteMonitorResult
+		if err := scanTracerouteResult(rows, &result); err != nil {
+			log.Error().Err(err).Msg("Failed to scan traceroute result")
+			continue
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read traceroute results: %w", err)
+	}
+
+	return &types.PaginatedTracerouteMonitorResults{
+		Data:  results,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	}, nil
+}
+
+// GetTracerouteMonitorHops returns the stored hop JSON of traceroute monitor results
+// created within [from, to], oldest first. Zero times leave the range open.
+func (s *service) GetTracerouteMonitorHops(ctx context.Context, from, to time.Time) ([]types.TracerouteHopsRecord, error) {
+	query := s.sqlBuilder.
+		Select("id", "monitor_id", "hops", "created_at").
+		From("traceroute_monitor_results").
+		OrderBy("created_at ASC", "id ASC")
+	if !from.IsZero() {
+		query = query.Where(sq.GtOrEq{"created_at": from})
+	}
+	if !to.IsZero() {
+		query = query.Where(sq.LtOrEq{"created_at": to})
+	}
+
+	rows, err := query.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get traceroute hops: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]types.TracerouteHopsRecord, 0)
+	for rows.Next() {
+		var record types.TracerouteHopsRecord
+		if err := rows.Scan(&record.ResultID, &record.MonitorID, &record.Hops, &record.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan traceroute hops: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read traceroute hops: %w", err)
+	}
+
+	return records, nil
+}
+
+// UpdateTracerouteMonitorResultHops replaces the stored hops of a traceroute monitor result
+func (s *service) UpdateTracerouteMonitorResultHops(ctx context.Context, resultID int64, hops string) error {
+	result, err := s.sqlBuilder.
+		Update("traceroute_monitor_results").
+		Set("hops", hops).
+		Where(sq.Eq{"id": resultID}).
+		RunWith(s.db).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update traceroute hops: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
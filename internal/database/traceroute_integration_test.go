@@ -0,0 +1,195 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestTracerouteMonitor_CRUD(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+		nextRun := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+		// Create
+		created, err := td.Service.CreateTracerouteMonitor(ctx, &types.TracerouteMonitor{
+			Name:     "Cloudflare",
+			Host:     "1.1.1.1",
+			Interval: "1h",
+			Family:   "ipv4",
+			Enabled:  true,
+			NextRun:  &nextRun,
+		})
+		require.NoError(t, err)
+		assert.Greater(t, created.ID, int64(0))
+
+		// Read
+		retrieved, err := td.Service.GetTracerouteMonitor(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Cloudflare", retrieved.Name)
+		assert.Equal(t, "ipv4", retrieved.Family)
+		require.NotNil(t, retrieved.NextRun)
+
+		// Update
+		lastRun := time.Now().UTC().Truncate(time.Second)
+		retrieved.Enabled = false
+		retrieved.LastRun = &lastRun
+		require.NoError(t, td.Service.UpdateTracerouteMonitor(ctx, retrieved))
+
+		monitors, err := td.Service.GetTracerouteMonitors(ctx)
+		require.NoError(t, err)
+		require.Len(t, monitors, 1)
+		assert.False(t, monitors[0].Enabled)
+		require.NotNil(t, monitors[0].LastRun)
+
+		// Delete
+		require.NoError(t, td.Service.DeleteTracerouteMonitor(ctx, created.ID))
+		_, err = td.Service.GetTracerouteMonitor(ctx, created.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, td.Service.DeleteTracerouteMonitor(ctx, created.ID), ErrNotFound)
+	})
+}
+
+func TestTracerouteMonitor_Results(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		monitor, err := td.Service.CreateTracerouteMonitor(ctx, &types.TracerouteMonitor{
+			Host:     "example.com",
+			Interval: "1h",
+			Family:   "auto",
+			Enabled:  true,
+		})
+		require.NoError(t, err)
+
+		_, err = td.Service.GetLatestTracerouteMonitorResult(ctx, monitor.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		base := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+		for i := 0; i < 3; i++ {
+			hops, err := json.Marshal([]map[string]interface{}{{"number": 1, "ip": "10.0.0.1"}, {"number": 2, "ip": "10.0.1.1"}})
+			require.NoError(t, err)
+			require.NoError(t, td.Service.SaveTracerouteMonitorResult(ctx, &types.TracerouteMonitorResult{
+				MonitorID:   monitor.ID,
+				Destination: "example.com",
+				IP:          "93.184.216.34",
+				TotalHops:   2,
+				Complete:    true,
+				PathChanged: i == 2,
+				Hops:        hops,
+				CreatedAt:   base.Add(time.Duration(i) * time.Minute),
+			}))
+		}
+
+		latest, err := td.Service.GetLatestTracerouteMonitorResult(ctx, monitor.ID)
+		require.NoError(t, err)
+		assert.True(t, latest.PathChanged)
+		assert.JSONEq(t, `[{"number":1,"ip":"10.0.0.1"},{"number":2,"ip":"10.0.1.1"}]`, string(latest.Hops))
+
+		page, err := td.Service.GetTracerouteMonitorResults(ctx, monitor.ID, 1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 3, page.Total)
+		require.Len(t, page.Data, 2)
+		assert.Equal(t, latest.ID, page.Data[0].ID, "results are newest first")
+
+		// Deleting the monitor removes its results
+		require.NoError(t, td.Service.DeleteTracerouteMonitor(ctx, monitor.ID))
+		page, err = td.Service.GetTracerouteMonitorResults(ctx, monitor.ID, 1, 25)
+		require.NoError(t, err)
+		assert.Equal(t, 0, page.Total)
+	})
+}
+
+func TestGetTracerouteHopCountTrend(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		monitor, err := td.Service.CreateTracerouteMonitor(ctx, &types.TracerouteMonitor{
+			Host:     "example.com",
+			Interval: "1h",
+			Family:   "auto",
+			Enabled:  true,
+		})
+		require.NoError(t, err)
+
+		hopCounts := []int{9, 12, 12, 0}
+		for i, hops := range hopCounts {
+			require.NoError(t, td.Service.SaveTracerouteMonitorResult(ctx, &types.TracerouteMonitorResult{
+				MonitorID:   monitor.ID,
+				Destination: "example.com",
+				TotalHops:   hops,
+				CreatedAt:   time.Now().Add(time.Duration(i-len(hopCounts)) * time.Hour),
+			}))
+		}
+
+		// Outside the requested window
+		require.NoError(t, td.Service.SaveTracerouteMonitorResult(ctx, &types.TracerouteMonitorResult{
+			MonitorID:   monitor.ID,
+			Destination: "example.com",
+			TotalHops:   20,
+			CreatedAt:   time.Now().Add(-48 * time.Hour),
+		}))
+
+		trend, err := td.Service.GetTracerouteHopCountTrend(ctx, monitor.ID, time.Now().Add(-24*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, trend.Points, 3)
+		assert.Equal(t, monitor.ID, trend.MonitorID)
+		assert.Equal(t, 9, trend.Min)
+		assert.Equal(t, 12, trend.Max)
+		assert.Equal(t, 12, trend.Latest)
+		assert.Equal(t, 1, trend.Changes)
+	})
+}
+
+func TestTracerouteMonitorHops_Update(t *testing.T) {
+	RunTestWithBothDatabases(t, func(t *testing.T, td *TestDatabase) {
+		ctx := context.Background()
+
+		monitor, err := td.Service.CreateTracerouteMonitor(ctx, &types.TracerouteMonitor{
+			Host:     "example.com",
+			Interval: "1h",
+			Family:   "auto",
+			Enabled:  true,
+		})
+		require.NoError(t, err)
+
+		old := &types.TracerouteMonitorResult{
+			MonitorID: monitor.ID,
+			Hops:      json.RawMessage(`[{"number":1,"ip":"203.0.113.1"}]`),
+			CreatedAt: time.Now().Add(-48 * time.Hour),
+		}
+		recent := &types.TracerouteMonitorResult{
+			MonitorID: monitor.ID,
+			Hops:      json.RawMessage(`[{"number":1,"ip":"203.0.113.2"}]`),
+		}
+		require.NoError(t, td.Service.SaveTracerouteMonitorResult(ctx, old))
+		require.NoError(t, td.Service.SaveTracerouteMonitorResult(ctx, recent))
+
+		records, err := td.Service.GetTracerouteMonitorHops(ctx, time.Time{}, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, old.ID, records[0].ResultID, "records are oldest first")
+
+		records, err = td.Service.GetTracerouteMonitorHops(ctx, time.Now().Add(-time.Hour), time.Time{})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, recent.ID, records[0].ResultID)
+
+		updated := `[{"number":1,"ip":"203.0.113.2","countryCode":"NL"}]`
+		require.NoError(t, td.Service.UpdateTracerouteMonitorResultHops(ctx, recent.ID, updated))
+		latest, err := td.Service.GetLatestTracerouteMonitorResult(ctx, monitor.ID)
+		require.NoError(t, err)
+		assert.JSONEq(t, updated, string(latest.Hops))
+
+		assert.ErrorIs(t, td.Service.UpdateTracerouteMonitorResultHops(ctx, 999999, "[]"), ErrNotFound)
+	})
+}
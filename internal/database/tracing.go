@@ -0,0 +1,104 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/telemetry"
+)
+
+// tracedDB wraps the connection pool so every query run through squirrel's RunWith(s.db)
+// gets a span. Transactions, migrations and pool management use the embedded *sql.DB
+// directly and aren't traced.
+type tracedDB struct {
+	*sql.DB
+	system attribute.KeyValue
+}
+
+func newTracedDB(db *sql.DB, dbType config.DatabaseType) *tracedDB {
+	system := semconv.DBSystemNameSQLite
+	switch dbType {
+	case config.Postgres:
+		system = semconv.DBSystemNamePostgreSQL
+	case config.MySQL:
+		system = semconv.DBSystemNameMySQL
+	}
+	return &tracedDB{DB: db, system: system}
+}
+
+func (db *tracedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if !telemetry.Enabled() {
+		return db.DB.QueryContext(ctx, query, args...)
+	}
+	ctx, span := db.start(ctx, query)
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	telemetry.End(span, err)
+	return rows, err
+}
+
+func (db *tracedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if !telemetry.Enabled() {
+		return db.DB.QueryRowContext(ctx, query, args...)
+	}
+	ctx, span := db.start(ctx, query)
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	telemetry.End(span, row.Err())
+	return row
+}
+
+func (db *tracedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if !telemetry.Enabled() {
+		return db.DB.ExecContext(ctx, query, args...)
+	}
+	ctx, span := db.start(ctx, query)
+	result, err := db.DB.ExecContext(ctx, query, args...)
+	if err == nil {
+		if affected, err := result.RowsAffected(); err == nil {
+			span.SetAttributes(attribute.Int64("db.rows_affected", affected))
+		}
+	}
+	telemetry.End(span, err)
+	return result, err
+}
+
+// Query, QueryRow and Exec cover squirrel calls made without a context
+
+func (db *tracedDB) Query(query string, args ...any) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+func (db *tracedDB) QueryRow(query string, args ...any) *sql.Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+func (db *tracedDB) Exec(query string, args ...any) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+// start starts a span named after the statement's operation, such as "db SELECT"
+func (db *tracedDB) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation := queryOperation(query)
+	return telemetry.Start(ctx, "db "+operation,
+		db.system,
+		semconv.DBOperationName(operation),
+		semconv.DBQueryText(query),
+	)
+}
+
+// queryOperation returns the leading keyword of a statement in upper case
+func queryOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+func TestQueryOperation(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "SELECT id FROM monitor_agents WHERE id = ?", want: "SELECT"},
+		{query: "\n\t insert into speed_tests (server_name) VALUES ($1)", want: "INSERT"},
+		{query: "WITH latest AS (SELECT 1) SELECT * FROM latest", want: "WITH"},
+		{query: "   ", want: "QUERY"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, queryOperation(tt.query), tt.query)
+	}
+}
+
+func TestTracedDB_Disabled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	traced := newTracedDB(db, config.SQLite)
+	mock.ExpectExec("DELETE FROM app_settings").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("SELECT value FROM app_settings").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("true"))
+
+	result, err := traced.ExecContext(context.Background(), "DELETE FROM app_settings")
+	require.NoError(t, err)
+	affected, err := result.RowsAffected()
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, affected)
+
+	var value string
+	require.NoError(t, traced.QueryRow("SELECT value FROM app_settings").Scan(&value))
+	assert.Equal(t, "true", value)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
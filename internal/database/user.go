@@ -98,7 +98,7 @@ func (s *service) CreateUser(ctx context.Context, username, password string) (*U
 
 	// Disable registration
 	var disableRegQuery string
-	if s.config.Type == config.Postgres {
+	if s.config.Type == config.Postgres || s.config.Type == config.MySQL {
 		disableRegQuery = `
 			DELETE FROM registration_status;
 			INSERT INTO registration_status (is_registration_enabled) VALUES (false);`
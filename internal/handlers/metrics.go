@@ -0,0 +1,242 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/monitor"
+	"github.com/autobrr/netronome/internal/scheduler"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+const metricsCollectTimeout = 10 * time.Second
+
+var (
+	agentLabels = []string{"agent_id", "agent_name"}
+
+	agentConnectedDesc = prometheus.NewDesc(
+		"netronome_agent_connected",
+		"Whether the monitor agent is connected (1) or not (0).",
+		agentLabels, nil,
+	)
+	agentCPUDesc = prometheus.NewDesc(
+		"netronome_agent_cpu_percent",
+		"Latest CPU usage reported by the monitor agent.",
+		agentLabels, nil,
+	)
+	agentMemoryDesc = prometheus.NewDesc(
+		"netronome_agent_memory_percent",
+		"Latest memory usage reported by the monitor agent.",
+		agentLabels, nil,
+	)
+	agentRxDesc = prometheus.NewDesc(
+		"netronome_agent_rx_bytes_per_second",
+		"Current receive rate of the monitor agent's interface.",
+		agentLabels, nil,
+	)
+	agentTxDesc = prometheus.NewDesc(
+		"netronome_agent_tx_bytes_per_second",
+		"Current transmit rate of the monitor agent's interface.",
+		agentLabels, nil,
+	)
+	packetLossDesc = prometheus.NewDesc(
+		"netronome_packetloss_percent",
+		"Packet loss of the latest run of the packet loss monitor.",
+		[]string{"monitor_id", "monitor"}, nil,
+	)
+
+	queueLabels = []string{"queue"}
+
+	queueLimitDesc = prometheus.NewDesc(
+		"netronome_scheduler_queue_limit",
+		"Maximum scheduled jobs of the queue that run at once.",
+		queueLabels, nil,
+	)
+	queueQueuedDesc = prometheus.NewDesc(
+		"netronome_scheduler_queue_queued",
+		"Scheduled jobs waiting for a free slot.",
+		queueLabels, nil,
+	)
+	queueRunningDesc = prometheus.NewDesc(
+		"netronome_scheduler_queue_running",
+		"Scheduled jobs currently running.",
+		queueLabels, nil,
+	)
+	queueStartedDesc = prometheus.NewDesc(
+		"netronome_scheduler_queue_started_total",
+		"Scheduled jobs that have left the queue since startup.",
+		queueLabels, nil,
+	)
+	queueWaitDesc = prometheus.NewDesc(
+		"netronome_scheduler_queue_wait_seconds_total",
+		"Total time scheduled jobs spent waiting for a slot.",
+		queueLabels, nil,
+	)
+	queueLastWaitDesc = prometheus.NewDesc(
+		"netronome_scheduler_queue_last_wait_seconds",
+		"Time the most recently started job waited for a slot.",
+		queueLabels, nil,
+	)
+	queueLastDelayDesc = prometheus.NewDesc(
+		"netronome_scheduler_queue_last_delay_seconds",
+		"How far past its scheduled time the most recently started job began.",
+		queueLabels, nil,
+	)
+)
+
+// agentStatusSource reports live agent state, implemented by the monitor service
+type agentStatusSource interface {
+	GetAgentStatus(agentID int64) (bool, *types.MonitorLiveData)
+}
+
+// queueStatsSource reports scheduler queue state, implemented by the scheduler service
+type queueStatsSource interface {
+	QueueStats() scheduler.QueueSnapshot
+}
+
+// MetricsHandler serves collected agent and packet loss data for Prometheus
+type MetricsHandler struct {
+	handler http.Handler
+}
+
+// NewMetricsHandler creates a metrics handler. service may be nil when agent monitoring is disabled.
+func NewMetricsHandler(db database.Service, service *monitor.Service, queues queueStatsSource) *MetricsHandler {
+	collector := &metricsCollector{db: db, queues: queues}
+	if service != nil {
+		collector.agents = service
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	return &MetricsHandler{
+		handler: promhttp.HandlerFor(registry, promhttp.HandlerOpts{}),
+	}
+}
+
+// ServeMetrics writes all metrics in the Prometheus exposition format
+func (h *MetricsHandler) ServeMetrics(c *gin.Context) {
+	h.handler.ServeHTTP(c.Writer, c.Request)
+}
+
+// metricsCollector builds the metrics from current state on every scrape
+type metricsCollector struct {
+	db     database.Service
+	agents agentStatusSource
+	queues queueStatsSource
+}
+
+func (m *metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- agentConnectedDesc
+	ch <- agentCPUDesc
+	ch <- agentMemoryDesc
+	ch <- agentRxDesc
+	ch <- agentTxDesc
+	ch <- packetLossDesc
+	ch <- queueLimitDesc
+	ch <- queueQueuedDesc
+	ch <- queueRunningDesc
+	ch <- queueStartedDesc
+	ch <- queueWaitDesc
+	ch <- queueLastWaitDesc
+	ch <- queueLastDelayDesc
+}
+
+func (m *metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsCollectTimeout)
+	defer cancel()
+
+	if m.agents != nil {
+		m.collectAgents(ctx, ch)
+	}
+	m.collectPacketLoss(ch)
+	if m.queues != nil {
+		m.collectQueues(ch)
+	}
+}
+
+func (m *metricsCollector) collectAgents(ctx context.Context, ch chan<- prometheus.Metric) {
+	agents, err := m.db.GetMonitorAgents(ctx, false)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get monitor agents for metrics")
+		return
+	}
+
+	resourceStats, err := m.db.GetMonitorLatestResourceStats(ctx)
+	if err != nil {
+		// Connection and bandwidth metrics are still useful without resource stats
+		log.Warn().Err(err).Msg("Failed to get latest resource stats for metrics")
+	}
+
+	for _, agent := range agents {
+		labels := []string{strconv.FormatInt(agent.ID, 10), agent.Name}
+		connected, liveData := m.agents.GetAgentStatus(agent.ID)
+
+		ch <- prometheus.MustNewConstMetric(agentConnectedDesc, prometheus.GaugeValue, boolToFloat(connected), labels...)
+
+		if stats := resourceStats[agent.ID]; stats != nil {
+			ch <- prometheus.MustNewConstMetric(agentCPUDesc, prometheus.GaugeValue, stats.CPUUsagePercent, labels...)
+			ch <- prometheus.MustNewConstMetric(agentMemoryDesc, prometheus.GaugeValue, stats.MemoryUsedPercent, labels...)
+		}
+
+		if connected && liveData != nil {
+			ch <- prometheus.MustNewConstMetric(agentRxDesc, prometheus.GaugeValue, float64(liveData.Rx.Bytespersecond), labels...)
+			ch <- prometheus.MustNewConstMetric(agentTxDesc, prometheus.GaugeValue, float64(liveData.Tx.Bytespersecond), labels...)
+		}
+	}
+}
+
+func (m *metricsCollector) collectPacketLoss(ch chan<- prometheus.Metric) {
+	monitors, err := m.db.GetPacketLossMonitors()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get packet loss monitors for metrics")
+		return
+	}
+
+	for _, mon := range monitors {
+		result, err := m.db.GetLatestPacketLossResult(mon.ID)
+		if err != nil || result == nil {
+			continue
+		}
+
+		name := mon.Name
+		if name == "" {
+			name = mon.Host
+		}
+		ch <- prometheus.MustNewConstMetric(packetLossDesc, prometheus.GaugeValue, result.PacketLoss, strconv.FormatInt(mon.ID, 10), name)
+	}
+}
+
+func (m *metricsCollector) collectQueues(ch chan<- prometheus.Metric) {
+	snapshot := m.queues.QueueStats()
+	for name, stats := range map[string]types.QueueStats{
+		"speedtest":  snapshot.Speedtest,
+		"packetloss": snapshot.PacketLoss,
+	} {
+		ch <- prometheus.MustNewConstMetric(queueLimitDesc, prometheus.GaugeValue, float64(stats.Limit), name)
+		ch <- prometheus.MustNewConstMetric(queueQueuedDesc, prometheus.GaugeValue, float64(stats.Queued), name)
+		ch <- prometheus.MustNewConstMetric(queueRunningDesc, prometheus.GaugeValue, float64(stats.Running), name)
+		ch <- prometheus.MustNewConstMetric(queueStartedDesc, prometheus.CounterValue, float64(stats.Started), name)
+		ch <- prometheus.MustNewConstMetric(queueWaitDesc, prometheus.CounterValue, stats.TotalWaitSeconds, name)
+		ch <- prometheus.MustNewConstMetric(queueLastWaitDesc, prometheus.GaugeValue, stats.LastWaitSeconds, name)
+		ch <- prometheus.MustNewConstMetric(queueLastDelayDesc, prometheus.GaugeValue, stats.LastDelaySeconds, name)
+	}
+}
+
+func boolToFloat(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
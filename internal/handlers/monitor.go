@@ -5,9 +5,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -38,8 +42,129 @@ func NewMonitorHandler(db database.Service, service *monitor.Service, cfg *confi
 	}
 }
 
-// GetAgents returns all monitoring agents
+// maskAgentSecrets hides stored credentials before an agent is returned to the frontend
+func maskAgentSecrets(agent *types.MonitorAgent) {
+	masked := "configured"
+	agent.URL = monitor.MaskAgentURLPassword(agent.URL, masked)
+	if agent.APIKey != nil && *agent.APIKey != "" {
+		agent.APIKey = &masked
+	}
+	if agent.TokenClientSecret != nil && *agent.TokenClientSecret != "" {
+		agent.TokenClientSecret = &masked
+	}
+	if len(agent.Headers) > 0 {
+		headers := make(map[string]string, len(agent.Headers))
+		for name, value := range agent.Headers {
+			if value != "" && monitor.IsSecretAgentHeader(name) {
+				value = masked
+			}
+			headers[name] = value
+		}
+		agent.Headers = headers
+	}
+}
+
+// validateAgentAuth checks the auth mode and its required token endpoint settings
+func validateAgentAuth(agent *types.MonitorAgent) error {
+	switch agent.AuthMode {
+	case "", types.AgentAuthModeAPIKey:
+		agent.AuthMode = types.AgentAuthModeAPIKey
+		return nil
+	case types.AgentAuthModeToken:
+		// Both would go in the Authorization header
+		if monitor.HasAgentURLCredentials(agent.URL) {
+			return errors.New("URL credentials can't be combined with token auth")
+		}
+		if agent.TokenURL == nil || strings.TrimSpace(*agent.TokenURL) == "" {
+			return errors.New("Token URL is required for token auth")
+		}
+		if agent.TokenClientID == nil || strings.TrimSpace(*agent.TokenClientID) == "" {
+			return errors.New("Token client ID is required for token auth")
+		}
+		return nil
+	default:
+		return fmt.Errorf("Unsupported auth mode: %s", agent.AuthMode)
+	}
+}
+
+// validateAgentTLS normalizes the pinned CA certificate and checks that it parses
+func validateAgentTLS(agent *types.MonitorAgent) error {
+	if agent.CACert != nil && strings.TrimSpace(*agent.CACert) == "" {
+		agent.CACert = nil
+	}
+	if err := monitor.ValidateAgentTLS(agent); err != nil {
+		return fmt.Errorf("Invalid CA certificate: %w", err)
+	}
+	return nil
+}
+
+// validateAgentInterfaceFilter normalizes the interface patterns and checks that they compile
+func validateAgentInterfaceFilter(agent *types.MonitorAgent) error {
+	if agent.InterfaceInclude != nil && strings.TrimSpace(*agent.InterfaceInclude) == "" {
+		agent.InterfaceInclude = nil
+	}
+	if agent.InterfaceExclude != nil && strings.TrimSpace(*agent.InterfaceExclude) == "" {
+		agent.InterfaceExclude = nil
+	}
+	if err := monitor.ValidateInterfaceFilter(agent); err != nil {
+		return fmt.Errorf("Invalid interface filter: %w", err)
+	}
+	return nil
+}
+
+// validateAgentThresholds checks the per-agent notification threshold overrides
+func validateAgentThresholds(agent *types.MonitorAgent) error {
+	percentages := []struct {
+		name  string
+		value *float64
+	}{
+		{"CPU", agent.CPUThreshold},
+		{"Memory", agent.MemoryThreshold},
+		{"Disk", agent.DiskThreshold},
+	}
+	for _, p := range percentages {
+		if p.value != nil && (*p.value < 0 || *p.value > 100) {
+			return fmt.Errorf("%s threshold must be between 0 and 100", p.name)
+		}
+	}
+	if agent.TempThreshold != nil && *agent.TempThreshold < 0 {
+		return errors.New("Temperature threshold must not be negative")
+	}
+	return nil
+}
+
+// validateAgentTags normalizes the agent's tags
+func validateAgentTags(agent *types.MonitorAgent) error {
+	tags, err := monitor.NormalizeAgentTags(agent.Tags)
+	if err != nil {
+		return fmt.Errorf("Invalid tags: %w", err)
+	}
+	agent.Tags = tags
+	return nil
+}
+
+// validateAgentHeaders normalizes the custom headers sent to the agent
+func validateAgentHeaders(agent *types.MonitorAgent) error {
+	headers, err := monitor.NormalizeAgentHeaders(agent.Headers)
+	if err != nil {
+		return fmt.Errorf("Invalid headers: %w", err)
+	}
+	agent.Headers = headers
+	return nil
+}
+
+// GetAgents returns all monitoring agents, limited to those with a tag when ?tag= is set
 func (h *MonitorHandler) GetAgents(c *gin.Context) {
+	var tag string
+	if v := c.Query("tag"); v != "" {
+		normalized, err := monitor.NormalizeAgentTag(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid tag: %v", err)})
+			return
+		}
+		tag = normalized
+	}
+
 	agents, err := h.db.GetMonitorAgents(c.Request.Context(), false)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get monitor agents")
@@ -47,12 +172,15 @@ func (h *MonitorHandler) GetAgents(c *gin.Context) {
 		return
 	}
 
-	// Don't expose API keys to frontend
+	if tag != "" {
+		agents = slices.DeleteFunc(agents, func(agent *types.MonitorAgent) bool {
+			return !slices.Contains(agent.Tags, tag)
+		})
+	}
+
+	// Don't expose credentials to frontend
 	for _, agent := range agents {
-		if agent.APIKey != nil && *agent.APIKey != "" {
-			masked := "configured"
-			agent.APIKey = &masked
-		}
+		maskAgentSecrets(agent)
 	}
 
 	c.JSON(http.StatusOK, agents)
@@ -78,11 +206,8 @@ func (h *MonitorHandler) GetAgent(c *gin.Context) {
 		return
 	}
 
-	// Don't expose API key to frontend
-	if agent.APIKey != nil && *agent.APIKey != "" {
-		masked := "configured"
-		agent.APIKey = &masked
-	}
+	// Don't expose credentials to frontend
+	maskAgentSecrets(agent)
 
 	c.JSON(http.StatusOK, agent)
 }
@@ -107,6 +232,30 @@ func (h *MonitorHandler) CreateAgent(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "URL is required"})
 		return
 	}
+	if err := validateAgentAuth(&agent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateAgentTLS(&agent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateAgentInterfaceFilter(&agent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateAgentThresholds(&agent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateAgentTags(&agent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateAgentHeaders(&agent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Ensure URL has the correct SSE endpoint
 	if !strings.HasSuffix(agent.URL, "/events?stream=live-data") {
@@ -120,7 +269,7 @@ func (h *MonitorHandler) CreateAgent(c *gin.Context) {
 	// Auto-detect Tailscale agents based on URL (if not already set by discovery)
 	if !agent.IsTailscale && utils.IsTailscaleURL(agent.URL) {
 		agent.IsTailscale = true
-		log.Debug().Str("url", agent.URL).Msg("Auto-detected Tailscale agent based on URL")
+		log.Debug().Str("url", monitor.StripAgentURLCredentials(agent.URL)).Msg("Auto-detected Tailscale agent based on URL")
 	}
 
 	// Create agent in database
@@ -139,11 +288,8 @@ func (h *MonitorHandler) CreateAgent(c *gin.Context) {
 		}
 	}
 
-	// Don't expose API key to frontend
-	if createdAgent.APIKey != nil && *createdAgent.APIKey != "" {
-		masked := "configured"
-		createdAgent.APIKey = &masked
-	}
+	// Don't expose credentials to frontend
+	maskAgentSecrets(createdAgent)
 
 	c.JSON(http.StatusCreated, createdAgent)
 }
@@ -179,11 +325,33 @@ func (h *MonitorHandler) UpdateAgent(c *gin.Context) {
 	if agent.APIKey != nil && *agent.APIKey == "configured" {
 		agent.APIKey = existingAgent.APIKey
 	}
+	if agent.TokenClientSecret != nil && *agent.TokenClientSecret == "configured" {
+		agent.TokenClientSecret = existingAgent.TokenClientSecret
+	}
+	agent.URL = monitor.RestoreAgentURLPassword(agent.URL, existingAgent.URL, "configured")
 
 	// Preserve discovery-specific fields that should never be modified for auto-discovered agents
 	agent.TailscaleHostname = existingAgent.TailscaleHostname
 	agent.DiscoveredAt = existingAgent.DiscoveredAt
 	agent.Interface = existingAgent.Interface
+
+	// Tags are kept when the request doesn't include them
+	if agent.Tags == nil {
+		agent.Tags = existingAgent.Tags
+	}
+
+	// Headers are kept when the request doesn't include them, and masked values keep the stored one
+	if agent.Headers == nil {
+		agent.Headers = existingAgent.Headers
+	}
+	for name, value := range agent.Headers {
+		if value != "configured" {
+			continue
+		}
+		if stored, ok := existingAgent.Headers[http.CanonicalHeaderKey(strings.TrimSpace(name))]; ok {
+			agent.Headers[name] = stored
+		}
+	}
 	
 	// Handle IsTailscale field: preserve if auto-discovered, otherwise auto-detect
 	if existingAgent.DiscoveredAt != nil {
@@ -193,9 +361,9 @@ func (h *MonitorHandler) UpdateAgent(c *gin.Context) {
 		// This is a manually created agent, auto-detect based on URL
 		agent.IsTailscale = utils.IsTailscaleURL(agent.URL)
 		if agent.IsTailscale && !existingAgent.IsTailscale {
-			log.Debug().Str("url", agent.URL).Msg("Auto-detected Tailscale agent based on URL during update")
+			log.Debug().Str("url", monitor.StripAgentURLCredentials(agent.URL)).Msg("Auto-detected Tailscale agent based on URL during update")
 		} else if !agent.IsTailscale && existingAgent.IsTailscale {
-			log.Debug().Str("url", agent.URL).Msg("Agent URL no longer appears to be Tailscale during update")
+			log.Debug().Str("url", monitor.StripAgentURLCredentials(agent.URL)).Msg("Agent URL no longer appears to be Tailscale during update")
 		}
 	}
 
@@ -214,6 +382,30 @@ func (h *MonitorHandler) UpdateAgent(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "URL is required"})
 		return
 	}
+	if err := validateAgentAuth(&agent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateAgentTLS(&agent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateAgentInterfaceFilter(&agent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateAgentThresholds(&agent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateAgentTags(&agent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateAgentHeaders(&agent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Ensure URL has the correct SSE endpoint
 	if !strings.HasSuffix(agent.URL, "/events?stream=live-data") {
@@ -240,11 +432,8 @@ func (h *MonitorHandler) UpdateAgent(c *gin.Context) {
 		}
 	}
 
-	// Don't expose API key to frontend
-	if agent.APIKey != nil && *agent.APIKey != "" {
-		masked := "configured"
-		agent.APIKey = &masked
-	}
+	// Don't expose credentials to frontend
+	maskAgentSecrets(&agent)
 
 	c.JSON(http.StatusOK, agent)
 }
@@ -283,7 +472,8 @@ func (h *MonitorHandler) GetAgentStatus(c *gin.Context) {
 	connected, liveData := h.service.GetAgentStatus(id)
 
 	status := gin.H{
-		"connected": connected,
+		"connected":   connected,
+		"maintenance": h.service.AgentInMaintenance(id),
 	}
 
 	if liveData != nil {
@@ -299,6 +489,12 @@ func (h *MonitorHandler) GetAgentStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, status)
 }
 
+// GetAgentStatuses returns the status of every monitored agent in one response, keyed by
+// agent ID. Agents that aren't being monitored are left out.
+func (h *MonitorHandler) GetAgentStatuses(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.GetAgentStatuses())
+}
+
 // StartAgent manually starts monitoring for an agent
 func (h *MonitorHandler) StartAgent(c *gin.Context) {
 	idStr := c.Param("id")
@@ -310,6 +506,10 @@ func (h *MonitorHandler) StartAgent(c *gin.Context) {
 
 	if err := h.service.StartAgent(id); err != nil {
 		log.Error().Err(err).Int64("agent_id", id).Msg("Failed to start agent")
+		if errors.Is(err, monitor.ErrAgentLimitReached) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -330,6 +530,33 @@ func (h *MonitorHandler) StopAgent(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Agent stopped successfully"})
 }
 
+// RefreshAgent collects resource stats and bandwidth history from an agent right away
+// and returns the resource stats collected
+func (h *MonitorHandler) RefreshAgent(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	stats, err := h.service.RefreshAgent(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, monitor.ErrAgentNotMonitored):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Agent is not being monitored"})
+		case errors.Is(err, monitor.ErrAgentNotConnected), errors.Is(err, monitor.ErrRefreshInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Int64("agent_id", id).Msg("Failed to refresh agent")
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
 // GetAgentNativeVnstat returns the native bandwidth monitor JSON output from an agent for validation
 func (h *MonitorHandler) GetAgentNativeVnstat(c *gin.Context) {
 	idStr := c.Param("id")
@@ -352,7 +579,7 @@ func (h *MonitorHandler) GetAgentNativeVnstat(c *gin.Context) {
 	}
 
 	// Build the historical export URL from the agent's base URL
-	baseURL := strings.TrimSuffix(agent.URL, "/events?stream=live-data")
+	baseURL := monitor.AgentBaseURL(agent.URL)
 	exportURL := baseURL + "/export/historical"
 
 	// Get the interface parameter if provided
@@ -369,13 +596,15 @@ func (h *MonitorHandler) GetAgentNativeVnstat(c *gin.Context) {
 		return
 	}
 
-	// Add API key if configured
-	if agent.APIKey != nil && *agent.APIKey != "" {
-		req.Header.Set("X-API-Key", *agent.APIKey)
+	// Add API key or bearer token if configured
+	if err := h.service.AuthorizeAgentRequest(req, agent); err != nil {
+		log.Error().Err(err).Int64("agent_id", agent.ID).Msg("Failed to authorize agent request")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to authenticate with agent"})
+		return
 	}
 
 	// Make HTTP request to agent's export endpoint
-	client := &http.Client{}
+	client := monitor.AgentHTTPClient(agent, 0)
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Error().Err(err).Str("url", exportURL).Msg("Failed to fetch native bandwidth data from agent, falling back to cached data")
@@ -572,7 +801,7 @@ func (h *MonitorHandler) GetAgentSystemInfo(c *gin.Context) {
 	}
 
 	// Build the system info URL from the agent's base URL
-	baseURL := strings.TrimSuffix(agent.URL, "/events?stream=live-data")
+	baseURL := monitor.AgentBaseURL(agent.URL)
 	systemURL := baseURL + "/system/info"
 
 	// Create HTTP request with API key if configured
@@ -583,13 +812,15 @@ func (h *MonitorHandler) GetAgentSystemInfo(c *gin.Context) {
 		return
 	}
 
-	// Add API key if configured
-	if agent.APIKey != nil && *agent.APIKey != "" {
-		req.Header.Set("X-API-Key", *agent.APIKey)
+	// Add API key or bearer token if configured
+	if err := h.service.AuthorizeAgentRequest(req, agent); err != nil {
+		log.Error().Err(err).Int64("agent_id", agent.ID).Msg("Failed to authorize agent request")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to authenticate with agent"})
+		return
 	}
 
 	// Make HTTP request to agent's system endpoint
-	client := &http.Client{}
+	client := monitor.AgentHTTPClient(agent, 0)
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Error().Err(err).Str("url", systemURL).Msg("Failed to fetch system info from agent, falling back to cached data")
@@ -665,12 +896,24 @@ func (h *MonitorHandler) GetAgentSystemInfo(c *gin.Context) {
 		return
 	}
 
+	// Drop interfaces the agent's filter excludes
+	if ifaces, ok := systemData["interfaces"].(map[string]interface{}); ok {
+		if filter, err := monitor.NewInterfaceFilter(agent); err == nil {
+			for name := range ifaces {
+				if !filter.Allow(name) {
+					delete(ifaces, name)
+				}
+			}
+		}
+	}
+
 	// Fetch agent version from /netronome/info endpoint  
-	agentBaseURL := strings.TrimSuffix(agent.URL, "/events?stream=live-data")
+	agentBaseURL := monitor.AgentBaseURL(agent.URL)
 	infoURL := agentBaseURL + "/netronome/info"
 	infoReq, infoErr := http.NewRequestWithContext(c.Request.Context(), "GET", infoURL, nil)
 	if infoErr == nil {
-		infoClient := &http.Client{Timeout: 5 * time.Second}
+		monitor.SetAgentBasicAuth(infoReq, agent)
+		infoClient := monitor.AgentHTTPClient(agent, 5*time.Second)
 		infoResp, infoRespErr := infoClient.Do(infoReq)
 		if infoRespErr == nil && infoResp.StatusCode == http.StatusOK {
 			defer infoResp.Body.Close()
@@ -709,7 +952,7 @@ func (h *MonitorHandler) GetAgentHardwareStats(c *gin.Context) {
 	}
 
 	// Build the hardware stats URL from the agent's base URL
-	baseURL := strings.TrimSuffix(agent.URL, "/events?stream=live-data")
+	baseURL := monitor.AgentBaseURL(agent.URL)
 	hardwareURL := baseURL + "/system/hardware"
 
 	// Create HTTP request with API key if configured
@@ -720,13 +963,15 @@ func (h *MonitorHandler) GetAgentHardwareStats(c *gin.Context) {
 		return
 	}
 
-	// Add API key if configured
-	if agent.APIKey != nil && *agent.APIKey != "" {
-		req.Header.Set("X-API-Key", *agent.APIKey)
+	// Add API key or bearer token if configured
+	if err := h.service.AuthorizeAgentRequest(req, agent); err != nil {
+		log.Error().Err(err).Int64("agent_id", agent.ID).Msg("Failed to authorize agent request")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to authenticate with agent"})
+		return
 	}
 
 	// Make HTTP request to agent's hardware endpoint
-	client := &http.Client{}
+	client := monitor.AgentHTTPClient(agent, 0)
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Error().Err(err).Str("url", hardwareURL).Msg("Failed to fetch hardware stats from agent, falling back to cached data")
@@ -793,6 +1038,12 @@ func (h *MonitorHandler) GetAgentHardwareStats(c *gin.Context) {
 			json.Unmarshal([]byte(latestStats.TemperatureJSON), &temperature)
 		}
 
+		// Parse per-core CPU usage JSON (empty for samples from older agents)
+		var perCore []float64
+		if latestStats.CPUPerCoreJSON != "" {
+			json.Unmarshal([]byte(latestStats.CPUPerCoreJSON), &perCore)
+		}
+
 		cpuModel := "Unknown"
 		cpuCores := 0
 		cpuThreads := 0
@@ -807,31 +1058,33 @@ func (h *MonitorHandler) GetAgentHardwareStats(c *gin.Context) {
 		if systemInfo != nil {
 			totalMemory = systemInfo.TotalMemory
 		}
+		if totalMemory <= 0 {
+			log.Debug().Int64("agent_id", id).Msg("Total memory unknown, cached memory figures limited to percentages")
+		}
+
+		cpuStats := map[string]interface{}{
+			"usage_percent": latestStats.CPUUsagePercent,
+			"cores":         cpuCores,
+			"threads":       cpuThreads,
+			"model":         cpuModel,
+			"frequency":     0, // Not stored in database
+		}
+		if len(perCore) > 0 {
+			cpuStats["per_core"] = perCore
+		}
 
 		response := map[string]interface{}{
-			"cpu": map[string]interface{}{
-				"usage_percent": latestStats.CPUUsagePercent,
-				"cores":         cpuCores,
-				"threads":       cpuThreads,
-				"model":         cpuModel,
-				"frequency":     0, // Not stored in database
-			},
-			"memory": map[string]interface{}{
-				"total":        totalMemory,
-				"used":         int64(float64(totalMemory) * latestStats.MemoryUsedPercent / 100),
-				"free":         int64(float64(totalMemory) * (100 - latestStats.MemoryUsedPercent) / 100),
-				"available":    int64(float64(totalMemory) * (100 - latestStats.MemoryUsedPercent) / 100),
-				"used_percent": latestStats.MemoryUsedPercent,
-				"swap_total":   0, // Not directly stored
-				"swap_used":    0, // Not directly stored
-				"swap_percent": latestStats.SwapUsedPercent,
-			},
+			"cpu":         cpuStats,
+			"memory":      cachedMemoryStats(totalMemory, latestStats),
 			"disks":       diskUsage,
 			"temperature": temperature,
 			"uptime":      latestStats.UptimeSeconds,
 			"updated_at":  latestStats.CreatedAt.Format(time.RFC3339),
 			"from_cache":  true,
 		}
+		if latestStats.UptimeSeconds > 0 {
+			response["boot_time"] = bootTime(latestStats.UptimeSeconds, latestStats.CreatedAt)
+		}
 
 		c.JSON(http.StatusOK, response)
 		return
@@ -860,11 +1113,266 @@ func (h *MonitorHandler) GetAgentHardwareStats(c *gin.Context) {
 		return
 	}
 
+	// Agents reporting their uptime get the boot time alongside it
+	if stats, ok := hardwareData.(map[string]interface{}); ok {
+		if uptime, ok := stats["uptime"].(float64); ok && uptime > 0 {
+			stats["boot_time"] = bootTime(int64(uptime), time.Now())
+			c.JSON(http.StatusOK, stats)
+			return
+		}
+	}
+
 	// Return the hardware stats JSON data
 	c.Header("Content-Type", "application/json")
 	c.Data(http.StatusOK, "application/json", body)
 }
 
+// bootTime returns when a host booted, from its uptime in seconds at a point in time
+func bootTime(uptime int64, at time.Time) string {
+	return at.Add(-time.Duration(uptime) * time.Second).UTC().Format(time.RFC3339)
+}
+
+// GetAgentProcesses returns the top processes by CPU and memory from an agent, or the
+// last stored snapshot while the agent is unreachable
+func (h *MonitorHandler) GetAgentProcesses(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	// Get the agent to retrieve its URL
+	agent, err := h.db.GetMonitorAgent(c.Request.Context(), id)
+	if err != nil {
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to get monitor agent")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agent"})
+		return
+	}
+
+	// Build the processes URL from the agent's base URL, passing the limit through
+	baseURL := monitor.AgentBaseURL(agent.URL)
+	processesURL := baseURL + "/system/processes"
+	if limit := c.Query("limit"); limit != "" {
+		processesURL += "?" + url.Values{"limit": {limit}}.Encode()
+	}
+
+	// Create HTTP request with API key if configured
+	req, err := http.NewRequest("GET", processesURL, nil)
+	if err != nil {
+		log.Error().Err(err).Str("url", processesURL).Msg("Failed to create request")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		return
+	}
+
+	// Add API key or bearer token if configured
+	if err := h.service.AuthorizeAgentRequest(req, agent); err != nil {
+		log.Error().Err(err).Int64("agent_id", agent.ID).Msg("Failed to authorize agent request")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to authenticate with agent"})
+		return
+	}
+
+	// Make HTTP request to agent's processes endpoint
+	client := monitor.AgentHTTPClient(agent, 0)
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Error().Err(err).Str("url", processesURL).Msg("Failed to fetch processes from agent, falling back to cached data")
+		h.cachedProcesses(c, id)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Error().Int("status", resp.StatusCode).Str("url", processesURL).Msg("Agent returned error status")
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("Agent returned status %d", resp.StatusCode)})
+		return
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read response body")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response"})
+		return
+	}
+
+	if !json.Valid(body) {
+		log.Error().Msg("Invalid JSON response from agent")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Invalid JSON response from agent"})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", body)
+}
+
+// cachedProcesses serves the last stored process snapshot of an agent, marked from_cache
+func (h *MonitorHandler) cachedProcesses(c *gin.Context, agentID int64) {
+	snapshot, err := h.db.GetMonitorProcessSnapshot(c.Request.Context(), agentID)
+	if err != nil {
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Agent is offline and no cached data available"})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to get cached processes")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get processes"})
+		return
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(snapshot.ProcessesJSON), &response); err != nil {
+		log.Error().Err(err).Int64("agent_id", agentID).Msg("Invalid cached process snapshot")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get processes"})
+		return
+	}
+	response["from_cache"] = true
+	if _, ok := response["updated_at"]; !ok {
+		response["updated_at"] = snapshot.CreatedAt.Format(time.RFC3339)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetAgentSmart returns the SMART health of an agent's disks, or the last stored health
+// while the agent is unreachable
+func (h *MonitorHandler) GetAgentSmart(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	// Get the agent to retrieve its URL
+	agent, err := h.db.GetMonitorAgent(c.Request.Context(), id)
+	if err != nil {
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to get monitor agent")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agent"})
+		return
+	}
+
+	smartURL := monitor.AgentBaseURL(agent.URL) + "/system/smart"
+
+	// Create HTTP request with API key if configured
+	req, err := http.NewRequest("GET", smartURL, nil)
+	if err != nil {
+		log.Error().Err(err).Str("url", smartURL).Msg("Failed to create request")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		return
+	}
+
+	// Add API key or bearer token if configured
+	if err := h.service.AuthorizeAgentRequest(req, agent); err != nil {
+		log.Error().Err(err).Int64("agent_id", agent.ID).Msg("Failed to authorize agent request")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to authenticate with agent"})
+		return
+	}
+
+	// smartctl is run once per disk, so allow more time than the default
+	client := monitor.AgentHTTPClient(agent, time.Minute)
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Error().Err(err).Str("url", smartURL).Msg("Failed to fetch SMART stats from agent, falling back to cached data")
+		h.cachedSmart(c, id)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Error().Int("status", resp.StatusCode).Str("url", smartURL).Msg("Agent returned error status")
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("Agent returned status %d", resp.StatusCode)})
+		return
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read response body")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response"})
+		return
+	}
+
+	if !json.Valid(body) {
+		log.Error().Msg("Invalid JSON response from agent")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Invalid JSON response from agent"})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", body)
+}
+
+// cachedSmart serves the last stored SMART health of an agent's disks in the agent's
+// response format, marked from_cache
+func (h *MonitorHandler) cachedSmart(c *gin.Context, agentID int64) {
+	stored, err := h.db.GetMonitorSmartStats(c.Request.Context(), agentID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get cached SMART stats")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get SMART stats"})
+		return
+	}
+	if len(stored) == 0 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Agent is offline and no cached data available"})
+		return
+	}
+
+	devices := make([]map[string]interface{}, 0, len(stored))
+	var updatedAt time.Time
+	for _, device := range stored {
+		devices = append(devices, map[string]interface{}{
+			"device":              device.Device,
+			"model":               device.Model,
+			"serial":              device.Serial,
+			"health":              device.Health,
+			"reallocated_sectors": device.ReallocatedSectors,
+			"pending_sectors":     device.PendingSectors,
+			"temperature":         device.Temperature,
+		})
+		if device.CreatedAt.After(updatedAt) {
+			updatedAt = device.CreatedAt
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"available":  true,
+		"devices":    devices,
+		"updated_at": updatedAt.Format(time.RFC3339),
+		"from_cache": true,
+	})
+}
+
+// cachedMemoryStats rebuilds the agent's memory stats from a stored usage percentage.
+// Swap totals are stored as reported. Absolute memory figures are only derived from a
+// known total and a percentage within 0-100, otherwise they stay zero rather than
+// reporting nonsense.
+func cachedMemoryStats(totalMemory int64, stats types.MonitorResourceStats) map[string]interface{} {
+	usedPercent := stats.MemoryUsedPercent
+	memory := map[string]interface{}{
+		"total":        int64(0),
+		"used":         int64(0),
+		"free":         int64(0),
+		"available":    int64(0),
+		"used_percent": usedPercent,
+		"swap_total":   stats.SwapTotal,
+		"swap_used":    stats.SwapUsed,
+		"swap_percent": stats.SwapUsedPercent,
+	}
+	if totalMemory <= 0 || math.IsNaN(usedPercent) || usedPercent < 0 || usedPercent > 100 {
+		return memory
+	}
+
+	used := int64(float64(totalMemory) * usedPercent / 100)
+	memory["total"] = totalMemory
+	memory["used"] = used
+	memory["free"] = totalMemory - used
+	memory["available"] = totalMemory - used
+	return memory
+}
+
 // GetAgentPeakStats returns peak bandwidth statistics from an agent
 func (h *MonitorHandler) GetAgentPeakStats(c *gin.Context) {
 	idStr := c.Param("id")
@@ -887,7 +1395,7 @@ func (h *MonitorHandler) GetAgentPeakStats(c *gin.Context) {
 	}
 
 	// Build the peak stats URL from the agent's base URL
-	baseURL := strings.TrimSuffix(agent.URL, "/events?stream=live-data")
+	baseURL := monitor.AgentBaseURL(agent.URL)
 	peaksURL := baseURL + "/stats/peaks"
 
 	// Create HTTP request with API key if configured
@@ -898,13 +1406,15 @@ func (h *MonitorHandler) GetAgentPeakStats(c *gin.Context) {
 		return
 	}
 
-	// Add API key if configured
-	if agent.APIKey != nil && *agent.APIKey != "" {
-		req.Header.Set("X-API-Key", *agent.APIKey)
+	// Add API key or bearer token if configured
+	if err := h.service.AuthorizeAgentRequest(req, agent); err != nil {
+		log.Error().Err(err).Int64("agent_id", agent.ID).Msg("Failed to authorize agent request")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to authenticate with agent"})
+		return
 	}
 
 	// Make HTTP request to agent's peaks endpoint
-	client := &http.Client{}
+	client := monitor.AgentHTTPClient(agent, 0)
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Error().Err(err).Str("url", peaksURL).Msg("Failed to fetch peak stats")
@@ -940,6 +1450,296 @@ func (h *MonitorHandler) GetAgentPeakStats(c *gin.Context) {
 	c.Data(http.StatusOK, "application/json", body)
 }
 
+// GetAgentProbes returns the agent-side probes configured for an agent
+func (h *MonitorHandler) GetAgentProbes(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	probes, err := h.db.GetMonitorAgentProbes(c.Request.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Int64("agent_id", id).Msg("Failed to get agent probes")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agent probes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, probes)
+}
+
+// UpdateAgentProbes replaces the agent-side probes for an agent and pushes them to it
+func (h *MonitorHandler) UpdateAgentProbes(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	var probes []types.MonitorAgentProbe
+	if err := c.ShouldBindJSON(&probes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	for i := range probes {
+		probes[i].Host = strings.TrimSpace(probes[i].Host)
+		if probes[i].Interval == "" {
+			probes[i].Interval = "1m"
+		}
+		if interval, err := time.ParseDuration(probes[i].Interval); err != nil || interval < 10*time.Second {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid interval for %s, must be a duration of at least 10s", probes[i].Host)})
+			return
+		}
+		if probes[i].PacketCount == 0 {
+			probes[i].PacketCount = 10
+		}
+		if probes[i].PacketCount < 1 || probes[i].PacketCount > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Packet count for %s must be between 1 and 100", probes[i].Host)})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	if _, err := h.db.GetMonitorAgent(ctx, id); err != nil {
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to get monitor agent")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agent"})
+		return
+	}
+
+	if err := h.db.SetMonitorAgentProbes(ctx, id, probes); err != nil {
+		if errors.Is(err, database.ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error().Err(err).Int64("agent_id", id).Msg("Failed to update agent probes")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update agent probes"})
+		return
+	}
+
+	h.service.RefreshAgentProbes(id)
+
+	updated, err := h.db.GetMonitorAgentProbes(ctx, id)
+	if err != nil {
+		log.Error().Err(err).Int64("agent_id", id).Msg("Failed to get agent probes")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agent probes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// GetAgentProbeResults returns probe results reported by an agent.
+// Optional query parameters: host, hours (default 24) and limit.
+func (h *MonitorHandler) GetAgentProbeResults(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	hours := 24
+	if v := c.Query("hours"); v != "" {
+		hours, err = strconv.Atoi(v)
+		if err != nil || hours <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hours parameter"})
+			return
+		}
+	}
+
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+			return
+		}
+	}
+
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	results, err := h.db.GetMonitorAgentProbeResults(c.Request.Context(), id, c.Query("host"), since, limit)
+	if err != nil {
+		log.Error().Err(err).Int64("agent_id", id).Msg("Failed to get agent probe results")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agent probe results"})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// GetSnapshot returns the current live state and latest resource stats of every agent in one response
+func (h *MonitorHandler) GetSnapshot(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	agents, err := h.db.GetMonitorAgents(ctx, false)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get monitor agents")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agents"})
+		return
+	}
+
+	resourceStats, err := h.db.GetMonitorLatestResourceStats(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get latest resource stats")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get resource stats"})
+		return
+	}
+
+	snapshot := types.MonitorSnapshot{
+		CapturedAt: time.Now().UTC(),
+		Agents:     make([]types.MonitorAgentSnapshot, 0, len(agents)),
+	}
+	for _, agent := range agents {
+		connected, liveData := h.service.GetAgentStatus(agent.ID)
+		snapshot.Agents = append(snapshot.Agents, types.MonitorAgentSnapshot{
+			AgentID:       agent.ID,
+			AgentName:     agent.Name,
+			Enabled:       agent.Enabled,
+			Connected:     connected,
+			LiveData:      liveData,
+			ResourceStats: resourceStats[agent.ID],
+		})
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// GetFleetResourceStats returns per-agent and fleet-wide resource usage summaries.
+// start and end are RFC3339 timestamps and default to the last 24 hours.
+func (h *MonitorHandler) GetFleetResourceStats(c *gin.Context) {
+	end := time.Now().UTC()
+	if v := c.Query("end"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end time, expected RFC3339"})
+			return
+		}
+		end = parsed.UTC()
+	}
+
+	start := end.Add(-24 * time.Hour)
+	if v := c.Query("start"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start time, expected RFC3339"})
+			return
+		}
+		start = parsed.UTC()
+	}
+
+	if !start.Before(end) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start time must be before end time"})
+		return
+	}
+
+	stats, err := h.db.GetMonitorFleetResourceStats(c.Request.Context(), start, end)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get fleet resource stats")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get fleet resource stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetAgentBandwidthUsage returns how much an agent transferred between start and end.
+// start and end are RFC3339 timestamps and default to the last 24 hours.
+func (h *MonitorHandler) GetAgentBandwidthUsage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	end := time.Now().UTC()
+	if v := c.Query("end"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end time, expected RFC3339"})
+			return
+		}
+		end = parsed.UTC()
+	}
+
+	start := end.Add(-24 * time.Hour)
+	if v := c.Query("start"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start time, expected RFC3339"})
+			return
+		}
+		start = parsed.UTC()
+	}
+
+	if !start.Before(end) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start time must be before end time"})
+		return
+	}
+
+	usage, err := h.db.GetVnstatBandwidthRange(c.Request.Context(), id, start, end)
+	if err != nil {
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No bandwidth data for agent"})
+			return
+		}
+		log.Error().Err(err).Int64("agent_id", id).Msg("Failed to get agent bandwidth usage")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get bandwidth usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// GetAgentInterfaceHistory returns the stored traffic of one interface per hour, day or
+// month. period defaults to hourly and limit to every bucket vnstat kept.
+func (h *MonitorHandler) GetAgentInterfaceHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	iface := c.Query("interface")
+	if iface == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interface parameter is required"})
+		return
+	}
+
+	period := c.DefaultQuery("period", database.InterfaceHistoryHourly)
+	switch period {
+	case database.InterfaceHistoryHourly, database.InterfaceHistoryDaily, database.InterfaceHistoryMonthly:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period parameter, expected hourly, daily or monthly"})
+		return
+	}
+
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+			return
+		}
+	}
+
+	history, err := h.db.GetVnstatInterfaceHistory(c.Request.Context(), id, iface, period, limit)
+	if err != nil {
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No history for interface"})
+			return
+		}
+		log.Error().Err(err).Int64("agent_id", id).Str("interface", iface).Msg("Failed to get interface history")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get interface history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
 // GetTailscaleStatus returns the Tailscale discovery status
 func (h *MonitorHandler) GetTailscaleStatus(c *gin.Context) {
 	status, err := h.service.GetTailscaleStatus()
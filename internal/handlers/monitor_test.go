@@ -0,0 +1,62 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package handlers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestCachedMemoryStats(t *testing.T) {
+	const gib = int64(1 << 30)
+
+	tests := []struct {
+		name          string
+		totalMemory   int64
+		usedPercent   float64
+		wantTotal     int64
+		wantUsed      int64
+		wantAvailable int64
+	}{
+		{name: "quarter used", totalMemory: 16 * gib, usedPercent: 25, wantTotal: 16 * gib, wantUsed: 4 * gib, wantAvailable: 12 * gib},
+		{name: "fully used", totalMemory: 8 * gib, usedPercent: 100, wantTotal: 8 * gib, wantUsed: 8 * gib},
+		{name: "idle", totalMemory: 8 * gib, usedPercent: 0, wantTotal: 8 * gib, wantAvailable: 8 * gib},
+		{name: "unknown total", totalMemory: 0, usedPercent: 50},
+		{name: "negative total", totalMemory: -1, usedPercent: 50},
+		{name: "negative percent", totalMemory: 8 * gib, usedPercent: -5},
+		{name: "percent above 100", totalMemory: 8 * gib, usedPercent: 150},
+		{name: "NaN percent", totalMemory: 8 * gib, usedPercent: math.NaN()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := types.MonitorResourceStats{
+				MemoryUsedPercent: tt.usedPercent,
+				SwapUsedPercent:   12.5,
+				SwapTotal:         2 * gib,
+				SwapUsed:          gib / 4,
+			}
+			memory := cachedMemoryStats(tt.totalMemory, stats)
+
+			assert.Equal(t, tt.wantTotal, memory["total"])
+			assert.Equal(t, tt.wantUsed, memory["used"])
+			assert.Equal(t, tt.wantAvailable, memory["free"])
+			assert.Equal(t, tt.wantAvailable, memory["available"])
+			if math.IsNaN(tt.usedPercent) {
+				assert.True(t, math.IsNaN(memory["used_percent"].(float64)))
+			} else {
+				assert.Equal(t, tt.usedPercent, memory["used_percent"])
+			}
+
+			// Swap is reported as stored whatever the memory figures
+			assert.Equal(t, 2*gib, memory["swap_total"])
+			assert.Equal(t, gib/4, memory["swap_used"])
+			assert.Equal(t, 12.5, memory["swap_percent"])
+		})
+	}
+}
@@ -4,6 +4,7 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -34,6 +35,61 @@ func NewPacketLossHandler(db database.Service, service *speedtest.PacketLossServ
 	}
 }
 
+// normalizeProbeSettings defaults the probe mode to icmp and the address family to auto,
+// and validates the mode, TCP port, packet interval, packet size and family
+func normalizeProbeSettings(monitor *types.PacketLossMonitor) error {
+	monitor.ProbeMode = strings.ToLower(strings.TrimSpace(monitor.ProbeMode))
+	switch monitor.ProbeMode {
+	case "":
+		monitor.ProbeMode = types.PacketLossProbeICMP
+	case types.PacketLossProbeICMP, types.PacketLossProbeUDP, types.PacketLossProbeTCP:
+	default:
+		return fmt.Errorf("invalid probe mode %q, expected icmp, udp or tcp", monitor.ProbeMode)
+	}
+
+	if monitor.ProbePort < 0 || monitor.ProbePort > 65535 {
+		return fmt.Errorf("invalid probe port %d", monitor.ProbePort)
+	}
+	if monitor.ProbeMode != types.PacketLossProbeTCP {
+		monitor.ProbePort = 0
+	}
+
+	// Zero keeps the defaults
+	if monitor.IntervalMs != 0 && (monitor.IntervalMs < types.MinPacketLossIntervalMs || monitor.IntervalMs > types.MaxPacketLossIntervalMs) {
+		return fmt.Errorf("invalid packet interval %dms, expected %d-%d", monitor.IntervalMs, types.MinPacketLossIntervalMs, types.MaxPacketLossIntervalMs)
+	}
+	if monitor.PacketSize != 0 && (monitor.PacketSize < types.MinPacketLossPacketSize || monitor.PacketSize > types.MaxPacketLossPacketSize) {
+		return fmt.Errorf("invalid packet size %d bytes, expected %d-%d", monitor.PacketSize, types.MinPacketLossPacketSize, types.MaxPacketLossPacketSize)
+	}
+
+	family, err := speedtest.ParseAddressFamily(monitor.AddressFamily)
+	if err != nil {
+		return err
+	}
+	monitor.AddressFamily = string(family)
+	return nil
+}
+
+// normalizeThresholds fills the critical threshold from the legacy single threshold
+// for clients that only send that, defaults it to 5%, keeps the two in sync and checks
+// that the warning level, if set, sits below the critical one
+func normalizeThresholds(monitor *types.PacketLossMonitor) error {
+	if monitor.CriticalThreshold <= 0 {
+		monitor.CriticalThreshold = monitor.Threshold
+	}
+	if monitor.CriticalThreshold <= 0 {
+		monitor.CriticalThreshold = 5.0 // Default to 5% packet loss threshold
+	}
+	if monitor.CriticalThreshold > 100 {
+		return fmt.Errorf("invalid critical threshold %.1f%%, expected at most 100%%", monitor.CriticalThreshold)
+	}
+	if monitor.WarningThreshold < 0 || (monitor.WarningThreshold > 0 && monitor.WarningThreshold >= monitor.CriticalThreshold) {
+		return fmt.Errorf("invalid warning threshold %.1f%%, expected 0 (off) or below the critical threshold of %.1f%%", monitor.WarningThreshold, monitor.CriticalThreshold)
+	}
+	monitor.Threshold = monitor.CriticalThreshold
+	return nil
+}
+
 // GetMonitors returns all packet loss monitors
 func (h *PacketLossHandler) GetMonitors(c *gin.Context) {
 	monitors, err := h.db.GetPacketLossMonitors()
@@ -66,13 +122,18 @@ func (h *PacketLossHandler) CreateMonitor(c *gin.Context) {
 	if monitor.PacketCount <= 0 {
 		monitor.PacketCount = 10 // Default to 10 packets
 	}
-	if monitor.Threshold <= 0 {
-		monitor.Threshold = 5.0 // Default to 5% packet loss threshold
+	if err := normalizeThresholds(&monitor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := normalizeProbeSettings(&monitor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	// Calculate initial next_run time
 	now := time.Now()
-	nextRun := h.scheduler.CalculateNextRun(monitor.Interval, now)
+	nextRun := h.scheduler.CalculateMonitorNextRun(&monitor, now)
 	if !nextRun.IsZero() {
 		monitor.NextRun = &nextRun
 		log.Debug().
@@ -94,6 +155,14 @@ func (h *PacketLossHandler) CreateMonitor(c *gin.Context) {
 	c.JSON(http.StatusCreated, createdMonitor)
 }
 
+// packetLossMonitorUpdate is the body of a monitor update. WarningThreshold is a pointer
+// so clients that don't send one, such as those that predate the warning level, keep
+// the stored value; 0 still turns the warning off.
+type packetLossMonitorUpdate struct {
+	types.PacketLossMonitor
+	WarningThreshold *float64 `json:"warningThreshold"`
+}
+
 // UpdateMonitor updates an existing packet loss monitor
 func (h *PacketLossHandler) UpdateMonitor(c *gin.Context) {
 	idStr := c.Param("id")
@@ -103,7 +172,7 @@ func (h *PacketLossHandler) UpdateMonitor(c *gin.Context) {
 		return
 	}
 
-	var updateData types.PacketLossMonitor
+	var updateData packetLossMonitorUpdate
 	if err := c.ShouldBindJSON(&updateData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
@@ -136,13 +205,38 @@ func (h *PacketLossHandler) UpdateMonitor(c *gin.Context) {
 	existingMonitor.PacketCount = updateData.PacketCount
 	existingMonitor.Enabled = updateData.Enabled
 	existingMonitor.Threshold = updateData.Threshold
+	if updateData.WarningThreshold != nil {
+		existingMonitor.WarningThreshold = *updateData.WarningThreshold
+	}
+	existingMonitor.CriticalThreshold = updateData.CriticalThreshold
+	if err := normalizeThresholds(existingMonitor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Clients that predate probe modes don't send one, keep the stored mode for them
+	if updateData.ProbeMode == "" {
+		updateData.ProbeMode = existingMonitor.ProbeMode
+	}
+	if updateData.AddressFamily == "" {
+		updateData.AddressFamily = existingMonitor.AddressFamily
+	}
+	if err := normalizeProbeSettings(&updateData.PacketLossMonitor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	existingMonitor.ProbeMode = updateData.ProbeMode
+	existingMonitor.ProbePort = updateData.ProbePort
+	existingMonitor.IntervalMs = updateData.IntervalMs
+	existingMonitor.PacketSize = updateData.PacketSize
+	existingMonitor.AddressFamily = updateData.AddressFamily
 
 	// If the interval changed, recalculate next_run using server timezone
 	if existingMonitor.Interval != updateData.Interval {
 		existingMonitor.Interval = updateData.Interval
 		// Calculate next_run on the server side using server's timezone
 		now := time.Now()
-		nextRun := h.scheduler.CalculateNextRun(existingMonitor.Interval, now)
+		nextRun := h.scheduler.CalculateMonitorNextRun(existingMonitor, now)
 		if !nextRun.IsZero() {
 			existingMonitor.NextRun = &nextRun
 		} else {
@@ -281,6 +375,96 @@ func (h *PacketLossHandler) GetMonitorHistoryDetail(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// UpdateResultNote attaches a note to a historical result of a monitor
+func (h *PacketLossHandler) UpdateResultNote(c *gin.Context) {
+	monitorID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid monitor ID"})
+		return
+	}
+
+	resultID, err := strconv.ParseInt(c.Param("resultId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid result ID"})
+		return
+	}
+
+	var req types.ResultNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if len(req.Note) > types.MaxResultNoteLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("note must be at most %d characters", types.MaxResultNoteLength)})
+		return
+	}
+
+	if err := h.db.UpdatePacketLossResultNote(c.Request.Context(), monitorID, resultID, req.Note); err != nil {
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Result not found"})
+			return
+		}
+		log.Error().Err(err).Int64("monitorID", monitorID).Int64("resultID", resultID).Msg("Failed to update packet loss result note")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update result note"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Note updated successfully"})
+}
+
+// GetMonitorHopTrend returns the MTR hop count trend for a monitor over the requested window
+func (h *PacketLossHandler) GetMonitorHopTrend(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid monitor ID"})
+		return
+	}
+
+	hours, err := strconv.Atoi(c.DefaultQuery("hours", "168"))
+	if err != nil || hours <= 0 {
+		hours = 168
+	}
+	if hours > 24*90 {
+		hours = 24 * 90
+	}
+
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	trend, err := h.db.GetPacketLossHopCountTrend(id, since)
+	if err != nil {
+		log.Error().Err(err).Int64("monitorID", id).Msg("Failed to get hop count trend")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get hop count trend"})
+		return
+	}
+
+	c.JSON(http.StatusOK, trend)
+}
+
+// GetMonitorSparkline returns the latest results of a monitor, oldest first, for a small trend chart
+func (h *PacketLossHandler) GetMonitorSparkline(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid monitor ID"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "30"))
+	if err != nil || limit <= 0 {
+		limit = 30
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	sparkline, err := h.db.GetPacketLossSparkline(id, limit)
+	if err != nil {
+		log.Error().Err(err).Int64("monitorID", id).Msg("Failed to get packet loss sparkline")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get monitor sparkline"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sparkline)
+}
+
 // StartMonitor manually starts monitoring for a specific monitor
 func (h *PacketLossHandler) StartMonitor(c *gin.Context) {
 	idStr := c.Param("id")
@@ -290,6 +474,11 @@ func (h *PacketLossHandler) StartMonitor(c *gin.Context) {
 		return
 	}
 
+	if h.scheduler.Paused() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Monitoring is paused"})
+		return
+	}
+
 	// Get the monitor first
 	monitor, err := h.db.GetPacketLossMonitor(id)
 	if err != nil {
@@ -329,7 +518,7 @@ func (h *PacketLossHandler) StartMonitor(c *gin.Context) {
 	}
 
 	// Run a test immediately
-	h.service.RunScheduledTest(monitor)
+	h.service.RunScheduledTest(c.Request.Context(), monitor)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Test started successfully"})
 }
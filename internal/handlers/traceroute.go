@@ -0,0 +1,176 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/scheduler"
+	"github.com/autobrr/netronome/internal/speedtest"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// TracerouteHandler handles scheduled traceroute monitor endpoints
+type TracerouteHandler struct {
+	db        database.Service
+	scheduler scheduler.Service
+}
+
+// NewTracerouteHandler creates a new traceroute monitor handler
+func NewTracerouteHandler(db database.Service, scheduler scheduler.Service) *TracerouteHandler {
+	return &TracerouteHandler{
+		db:        db,
+		scheduler: scheduler,
+	}
+}
+
+// GetMonitors returns all traceroute monitors
+func (h *TracerouteHandler) GetMonitors(c *gin.Context) {
+	monitors, err := h.db.GetTracerouteMonitors(c.Request.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get traceroute monitors")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get monitors"})
+		return
+	}
+
+	c.JSON(http.StatusOK, monitors)
+}
+
+// CreateMonitor creates a new traceroute monitor
+func (h *TracerouteHandler) CreateMonitor(c *gin.Context) {
+	var monitor types.TracerouteMonitor
+	if err := c.ShouldBindJSON(&monitor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	monitor.Host = strings.TrimSpace(monitor.Host)
+	if monitor.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Host is required"})
+		return
+	}
+	if monitor.Interval == "" {
+		monitor.Interval = "1h" // Routes change slowly, default to hourly
+	}
+	family, err := speedtest.ParseAddressFamily(monitor.Family)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	monitor.Family = string(family)
+
+	nextRun := h.scheduler.CalculateNextRun(monitor.Interval, time.Now())
+	if nextRun.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interval"})
+		return
+	}
+	monitor.NextRun = &nextRun
+
+	createdMonitor, err := h.db.CreateTracerouteMonitor(c.Request.Context(), &monitor)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create traceroute monitor")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create monitor"})
+		return
+	}
+
+	// Note: The scheduler will run the monitor based on its next_run time
+
+	c.JSON(http.StatusCreated, createdMonitor)
+}
+
+// DeleteMonitor deletes a traceroute monitor and its results
+func (h *TracerouteHandler) DeleteMonitor(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid monitor ID"})
+		return
+	}
+
+	if err := h.db.DeleteTracerouteMonitor(c.Request.Context(), id); err != nil {
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Monitor not found"})
+		} else {
+			log.Error().Err(err).Msg("Failed to delete traceroute monitor")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete monitor"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetMonitorHistory returns historical results for a monitor, newest first, so
+// consecutive hop lists can be diffed
+func (h *TracerouteHandler) GetMonitorHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid monitor ID"})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "25"))
+	if err != nil || limit <= 0 {
+		limit = 25
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	if _, err := h.db.GetTracerouteMonitor(c.Request.Context(), id); err != nil {
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Monitor not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get monitor"})
+		}
+		return
+	}
+
+	results, err := h.db.GetTracerouteMonitorResults(c.Request.Context(), id, page, limit)
+	if err != nil {
+		log.Error().Err(err).Int64("monitorID", id).Msg("Failed to get traceroute results")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get monitor history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// GetMonitorHopTrend returns the hop count trend for a monitor over the requested window
+func (h *TracerouteHandler) GetMonitorHopTrend(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid monitor ID"})
+		return
+	}
+
+	hours, err := strconv.Atoi(c.DefaultQuery("hours", "168"))
+	if err != nil || hours <= 0 {
+		hours = 168
+	}
+	if hours > 24*90 {
+		hours = 24 * 90
+	}
+
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	trend, err := h.db.GetTracerouteHopCountTrend(c.Request.Context(), id, since)
+	if err != nil {
+		log.Error().Err(err).Int64("monitorID", id).Msg("Failed to get traceroute hop count trend")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get hop count trend"})
+		return
+	}
+
+	c.JSON(http.StatusOK, trend)
+}
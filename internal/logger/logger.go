@@ -6,6 +6,7 @@ package logger
 import (
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -14,6 +15,10 @@ import (
 	"github.com/autobrr/netronome/internal/config"
 )
 
+// hotPathSampler thins out high-frequency debug and trace events; nil logs every event.
+// It is atomic so Reload can swap it while hot paths are logging.
+var hotPathSampler atomic.Pointer[zerolog.LevelSampler]
+
 func Init(cfg config.LoggingConfig, serverCfg config.ServerConfig, silent bool) {
 	output := zerolog.ConsoleWriter{
 		Out:        os.Stdout,
@@ -50,6 +55,24 @@ func Init(cfg config.LoggingConfig, serverCfg config.ServerConfig, silent bool)
 		Caller().
 		Logger()
 
+	applyLevel(cfg, serverCfg)
+}
+
+// Reload applies a new log level and sample rate without replacing the output writer,
+// so it is safe to call while other goroutines are logging
+func Reload(cfg config.LoggingConfig, serverCfg config.ServerConfig) {
+	applyLevel(cfg, serverCfg)
+}
+
+func applyLevel(cfg config.LoggingConfig, serverCfg config.ServerConfig) {
+	// Only debug and trace are sampled so warnings and errors are never dropped
+	if cfg.SampleRate > 1 {
+		sampler := &zerolog.BasicSampler{N: uint32(cfg.SampleRate)}
+		hotPathSampler.Store(&zerolog.LevelSampler{TraceSampler: sampler, DebugSampler: sampler})
+	} else {
+		hotPathSampler.Store(nil)
+	}
+
 	// Get log level from config, default to "info"
 	logLevel := strings.ToLower(cfg.Level)
 
@@ -81,3 +104,13 @@ func Init(cfg config.LoggingConfig, serverCfg config.ServerConfig, silent bool)
 func Get() zerolog.Logger {
 	return log.Logger
 }
+
+// Sampled returns a logger for per-packet and per-hop events that writes only
+// 1 in logging.sample_rate debug and trace messages
+func Sampled() zerolog.Logger {
+	sampler := hotPathSampler.Load()
+	if sampler == nil {
+		return log.Logger
+	}
+	return log.Logger.Sample(*sampler)
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+const (
+	// tokenRefreshMargin is how long before expiry a token is considered stale,
+	// capped at half the token's lifetime for short-lived tokens
+	tokenRefreshMargin = 30 * time.Second
+	// defaultTokenLifetime is assumed when the token endpoint omits expires_in
+	defaultTokenLifetime = 5 * time.Minute
+	tokenRequestTimeout  = 15 * time.Second
+)
+
+// errTokenRefresh signals that the SSE stream was closed to rotate its bearer token
+var errTokenRefresh = errors.New("bearer token refresh required")
+
+// tokenSource fetches and caches short-lived bearer tokens using the OAuth2 client credentials grant
+type tokenSource struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scope        string
+	httpClient   *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+	margin    time.Duration
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// newTokenSource returns nil unless the agent is configured for token auth
+func newTokenSource(agent *types.MonitorAgent) *tokenSource {
+	if agent == nil || agent.AuthMode != types.AgentAuthModeToken || agent.TokenURL == nil || *agent.TokenURL == "" {
+		return nil
+	}
+
+	ts := &tokenSource{
+		tokenURL:   *agent.TokenURL,
+		httpClient: &http.Client{Timeout: tokenRequestTimeout},
+	}
+	if agent.TokenClientID != nil {
+		ts.clientID = *agent.TokenClientID
+	}
+	if agent.TokenClientSecret != nil {
+		ts.clientSecret = *agent.TokenClientSecret
+	}
+	if agent.TokenScope != nil {
+		ts.scope = *agent.TokenScope
+	}
+	return ts
+}
+
+// Token returns a valid access token and its expiry, fetching a new one when needed
+func (ts *tokenSource) Token(ctx context.Context) (string, time.Time, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.token != "" && time.Until(ts.expiresAt) > ts.margin {
+		return ts.token, ts.expiresAt, nil
+	}
+
+	token, lifetime, err := ts.fetch(ctx)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	ts.token = token
+	ts.expiresAt = time.Now().Add(lifetime)
+	ts.margin = refreshMargin(lifetime)
+	return token, ts.expiresAt, nil
+}
+
+// Invalidate drops the cached token so the next call fetches a fresh one
+func (ts *tokenSource) Invalidate() {
+	ts.mu.Lock()
+	ts.token = ""
+	ts.expiresAt = time.Time{}
+	ts.mu.Unlock()
+}
+
+// RefreshAt returns when a token expiring at expiresAt should be rotated
+func (ts *tokenSource) RefreshAt(expiresAt time.Time) time.Time {
+	ts.mu.Lock()
+	margin := ts.margin
+	ts.mu.Unlock()
+	return expiresAt.Add(-margin)
+}
+
+// refreshMargin keeps tokens that live shorter than twice the margin usable for
+// half their lifetime instead of treating them as stale on arrival
+func refreshMargin(lifetime time.Duration) time.Duration {
+	return min(tokenRefreshMargin, lifetime/2)
+}
+
+func (ts *tokenSource) fetch(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if ts.scope != "" {
+		form.Set("scope", ts.scope)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", ts.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if ts.clientID != "" {
+		req.SetBasicAuth(url.QueryEscape(ts.clientID), url.QueryEscape(ts.clientSecret))
+	}
+
+	resp, err := ts.httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to request token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", 0, fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tr tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return "", 0, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tr.AccessToken == "" {
+		return "", 0, fmt.Errorf("token response missing access_token")
+	}
+
+	lifetime := defaultTokenLifetime
+	if tr.ExpiresIn > 0 {
+		lifetime = time.Duration(tr.ExpiresIn) * time.Second
+	}
+	return tr.AccessToken, lifetime, nil
+}
+
+// authorize adds the agent's credentials to an outgoing request. It returns the
+// bearer token expiry for token-authenticated agents, or the zero time otherwise.
+func authorize(ctx context.Context, req *http.Request, agent *types.MonitorAgent, ts *tokenSource) (time.Time, error) {
+	SetAgentBasicAuth(req, agent)
+	if agent.APIKey != nil && *agent.APIKey != "" {
+		req.Header.Set("X-API-Key", *agent.APIKey)
+	}
+	if ts == nil {
+		return time.Time{}, nil
+	}
+
+	token, expiresAt, err := ts.Token(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to obtain bearer token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return expiresAt, nil
+}
+
+// AuthorizeAgentRequest adds the agent's credentials to a request made outside the
+// SSE client, reusing the running client's cached token when available
+func (s *Service) AuthorizeAgentRequest(req *http.Request, agent *types.MonitorAgent) error {
+	var ts *tokenSource
+	if s != nil {
+		s.clientsMu.RLock()
+		if client, ok := s.clients[agent.ID]; ok {
+			ts = client.tokens
+		}
+		s.clientsMu.RUnlock()
+	}
+	if ts == nil {
+		ts = newTokenSource(agent)
+	}
+
+	_, err := authorize(req.Context(), req, agent, ts)
+	return err
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestNewTokenSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		agent *types.MonitorAgent
+		want  bool
+	}{
+		{name: "api key mode", agent: &types.MonitorAgent{AuthMode: types.AgentAuthModeAPIKey, TokenURL: strPtr("http://idp/token")}, want: false},
+		{name: "token mode without url", agent: &types.MonitorAgent{AuthMode: types.AgentAuthModeToken}, want: false},
+		{name: "token mode", agent: &types.MonitorAgent{AuthMode: types.AgentAuthModeToken, TokenURL: strPtr("http://idp/token")}, want: true},
+		{name: "nil agent", agent: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newTokenSource(tt.agent) != nil; got != tt.want {
+				t.Fatalf("newTokenSource() returned source = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenSourceCachesAndRefreshes(t *testing.T) {
+	var calls atomic.Int32
+	expiresIn := int64(3600)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		if got := r.PostForm.Get("grant_type"); got != "client_credentials" {
+			t.Errorf("grant_type = %q, want client_credentials", got)
+		}
+		if got := r.PostForm.Get("scope"); got != "agents.read" {
+			t.Errorf("scope = %q, want agents.read", got)
+		}
+		if id, secret, ok := r.BasicAuth(); !ok || id != "netronome" || secret != "s3cret" {
+			t.Errorf("unexpected basic auth %q/%q (ok=%v)", id, secret, ok)
+		}
+
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+	defer srv.Close()
+
+	ts := newTokenSource(&types.MonitorAgent{
+		AuthMode:          types.AgentAuthModeToken,
+		TokenURL:          strPtr(srv.URL),
+		TokenClientID:     strPtr("netronome"),
+		TokenClientSecret: strPtr("s3cret"),
+		TokenScope:        strPtr("agents.read"),
+	})
+
+	ctx := context.Background()
+	token, expiresAt, err := ts.Token(ctx)
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if token != "token-1" {
+		t.Fatalf("Token() = %q, want token-1", token)
+	}
+	if time.Until(expiresAt) < 59*time.Minute {
+		t.Fatalf("unexpected expiry %v", expiresAt)
+	}
+
+	if token, _, _ := ts.Token(ctx); token != "token-1" || calls.Load() != 1 {
+		t.Fatalf("expected cached token, got %q after %d calls", token, calls.Load())
+	}
+
+	// A token inside the refresh margin is replaced
+	ts.expiresAt = time.Now().Add(tokenRefreshMargin / 2)
+	if token, _, _ := ts.Token(ctx); token != "token-2" {
+		t.Fatalf("expected refreshed token, got %q", token)
+	}
+
+	ts.Invalidate()
+	if token, _, _ := ts.Token(ctx); token != "token-3" {
+		t.Fatalf("expected new token after Invalidate, got %q", token)
+	}
+}
+
+func TestTokenSourceShortLivedToken(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"expires_in":   20,
+		})
+	}))
+	defer srv.Close()
+
+	ts := newTokenSource(&types.MonitorAgent{AuthMode: types.AgentAuthModeToken, TokenURL: strPtr(srv.URL)})
+
+	ctx := context.Background()
+	_, expiresAt, err := ts.Token(ctx)
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	// A token shorter-lived than the margin is still reused instead of refetched
+	if token, _, _ := ts.Token(ctx); token != "token-1" || calls.Load() != 1 {
+		t.Fatalf("expected cached short-lived token, got %q after %d calls", token, calls.Load())
+	}
+
+	// The stream is rotated halfway through the lifetime rather than immediately
+	if refresh := time.Until(ts.RefreshAt(expiresAt)); refresh < 9*time.Second || refresh > 10*time.Second {
+		t.Fatalf("RefreshAt() in %v, want about 10s", refresh)
+	}
+}
+
+func TestRefreshMargin(t *testing.T) {
+	tests := []struct {
+		lifetime time.Duration
+		want     time.Duration
+	}{
+		{lifetime: time.Hour, want: tokenRefreshMargin},
+		{lifetime: time.Minute, want: tokenRefreshMargin},
+		{lifetime: 30 * time.Second, want: 15 * time.Second},
+		{lifetime: time.Second, want: 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := refreshMargin(tt.lifetime); got != tt.want {
+			t.Errorf("refreshMargin(%v) = %v, want %v", tt.lifetime, got, tt.want)
+		}
+	}
+}
+
+func TestTokenSourceErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		payload string
+	}{
+		{name: "unauthorized", status: http.StatusUnauthorized, payload: `{"error":"invalid_client"}`},
+		{name: "missing access token", status: http.StatusOK, payload: `{"token_type":"Bearer"}`},
+		{name: "invalid json", status: http.StatusOK, payload: `not json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.payload))
+			}))
+			defer srv.Close()
+
+			ts := newTokenSource(&types.MonitorAgent{AuthMode: types.AgentAuthModeToken, TokenURL: strPtr(srv.URL)})
+			if _, _, err := ts.Token(context.Background()); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestAuthorizeSetsHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"access_token":"abc"}`))
+	}))
+	defer srv.Close()
+
+	agent := &types.MonitorAgent{
+		APIKey:   strPtr("key"),
+		AuthMode: types.AgentAuthModeToken,
+		TokenURL: strPtr(srv.URL),
+	}
+
+	req := httptest.NewRequest("GET", "http://agent:8200/system/info", nil)
+	expiresAt, err := authorize(context.Background(), req, agent, newTokenSource(agent))
+	if err != nil {
+		t.Fatalf("authorize() error = %v", err)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer abc" {
+		t.Fatalf("Authorization = %q, want Bearer abc", got)
+	}
+	if got := req.Header.Get("X-API-Key"); got != "key" {
+		t.Fatalf("X-API-Key = %q, want key", got)
+	}
+	if time.Until(expiresAt) <= 0 || time.Until(expiresAt) > defaultTokenLifetime {
+		t.Fatalf("expected default lifetime expiry, got %v", expiresAt)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+const (
+	defaultReconnectInitial = time.Second
+	defaultReconnectMax     = time.Minute
+
+	// legacyReconnectInterval is the reconnect_interval that configs generated by older
+	// versions contain. Those versions ignored it and reconnected after 1 second, so it
+	// keeps the default rather than slowing reconnects down to 30 seconds.
+	legacyReconnectInterval = "30s"
+)
+
+// reconnectBackoff is how long a client waits between attempts to reach its agent. The
+// delay starts at initial and doubles up to max.
+type reconnectBackoff struct {
+	initial time.Duration
+	max     time.Duration
+	jitter  float64 // fraction of the delay each wait may be shortened or lengthened by
+}
+
+// newReconnectBackoff parses monitor.reconnect_*. reconnect_interval, which predates them,
+// is used as the initial delay when reconnect_initial is unset, unless it is the old
+// generated default.
+func newReconnectBackoff(cfg *config.MonitorConfig) reconnectBackoff {
+	b := reconnectBackoff{initial: defaultReconnectInitial, max: defaultReconnectMax}
+	if cfg == nil {
+		return b
+	}
+
+	key, initial := "monitor.reconnect_initial", cfg.ReconnectInitial
+	if initial == "" && cfg.ReconnectInterval != legacyReconnectInterval {
+		key, initial = "monitor.reconnect_interval", cfg.ReconnectInterval
+	}
+	b.initial = parseCollectorInterval(key, initial, defaultReconnectInitial)
+	b.max = max(parseCollectorInterval("monitor.reconnect_max", cfg.ReconnectMax, defaultReconnectMax), b.initial)
+	b.jitter = min(max(cfg.ReconnectJitter, 0), 1)
+	return b
+}
+
+// next returns the delay to use after delay was waited out
+func (b reconnectBackoff) next(delay time.Duration) time.Duration {
+	return min(delay*2, b.max)
+}
+
+// wait spreads delay by the jitter, so agents that dropped together don't reconnect together
+func (b reconnectBackoff) wait(delay time.Duration) time.Duration {
+	if b.jitter == 0 {
+		return delay
+	}
+	spread := float64(delay) * b.jitter
+	return delay + time.Duration((rand.Float64()*2-1)*spread)
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+func TestNewReconnectBackoff(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         *config.MonitorConfig
+		wantInitial time.Duration
+		wantMax     time.Duration
+		wantJitter  float64
+	}{
+		{name: "no config", wantInitial: time.Second, wantMax: time.Minute},
+		{name: "reconnect_interval is the initial delay", cfg: &config.MonitorConfig{ReconnectInterval: "5s"}, wantInitial: 5 * time.Second, wantMax: time.Minute},
+		{name: "old generated reconnect_interval is ignored", cfg: &config.MonitorConfig{ReconnectInterval: "30s"}, wantInitial: time.Second, wantMax: time.Minute},
+		{name: "reconnect_initial can still be 30s", cfg: &config.MonitorConfig{ReconnectInterval: "30s", ReconnectInitial: "30s"}, wantInitial: 30 * time.Second, wantMax: time.Minute},
+		{name: "reconnect_initial wins", cfg: &config.MonitorConfig{ReconnectInterval: "5s", ReconnectInitial: "2s", ReconnectMax: "10m", ReconnectJitter: 0.2}, wantInitial: 2 * time.Second, wantMax: 10 * time.Minute, wantJitter: 0.2},
+		{name: "max raised to initial", cfg: &config.MonitorConfig{ReconnectInitial: "2m", ReconnectMax: "1m"}, wantInitial: 2 * time.Minute, wantMax: 2 * time.Minute},
+		{name: "invalid values use defaults", cfg: &config.MonitorConfig{ReconnectInitial: "soon", ReconnectMax: "-1s", ReconnectJitter: 3}, wantInitial: time.Second, wantMax: time.Minute, wantJitter: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newReconnectBackoff(tt.cfg)
+			if b.initial != tt.wantInitial || b.max != tt.wantMax || b.jitter != tt.wantJitter {
+				t.Errorf("newReconnectBackoff() = %+v, want initial %s, max %s, jitter %g", b, tt.wantInitial, tt.wantMax, tt.wantJitter)
+			}
+		})
+	}
+}
+
+func TestReconnectBackoffDelays(t *testing.T) {
+	b := reconnectBackoff{initial: time.Second, max: 5 * time.Second}
+
+	var got []time.Duration
+	for delay := b.initial; len(got) < 5; delay = b.next(delay) {
+		got = append(got, b.wait(delay))
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("delays = %v, want %v", got, want)
+		}
+	}
+
+	b.jitter = 0.5
+	for range 100 {
+		if wait := b.wait(4 * time.Second); wait < 2*time.Second || wait > 6*time.Second {
+			t.Fatalf("wait(4s) with 50%% jitter = %s, want 2s-6s", wait)
+		}
+	}
+}
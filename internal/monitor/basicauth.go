@@ -0,0 +1,97 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// splitURLCredentials splits user:pass@ credentials off the host of rawURL. It works on
+// the string rather than url.Parse, so a URL that doesn't parse still loses them.
+func splitURLCredentials(rawURL string) (userinfo, stripped string) {
+	scheme, rest, ok := strings.Cut(rawURL, "://")
+	if !ok {
+		return "", rawURL
+	}
+	authority := rest
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		authority = rest[:i]
+	}
+	at := strings.LastIndex(authority, "@")
+	if at < 0 {
+		return "", rawURL
+	}
+	return authority[:at], scheme + "://" + rest[at+1:]
+}
+
+// StripAgentURLCredentials removes user:pass@ credentials from an agent URL, for requests
+// and logs
+func StripAgentURLCredentials(rawURL string) string {
+	_, stripped := splitURLCredentials(rawURL)
+	return stripped
+}
+
+// HasAgentURLCredentials reports whether an agent URL carries user:pass@ credentials
+func HasAgentURLCredentials(rawURL string) bool {
+	userinfo, _ := splitURLCredentials(rawURL)
+	return userinfo != ""
+}
+
+// MaskAgentURLPassword replaces the password in an agent URL's credentials with masked,
+// keeping the user name visible
+func MaskAgentURLPassword(rawURL, masked string) string {
+	userinfo, stripped := splitURLCredentials(rawURL)
+	user, _, ok := strings.Cut(userinfo, ":")
+	if !ok {
+		return rawURL
+	}
+	return withURLCredentials(stripped, user+":"+masked)
+}
+
+// RestoreAgentURLPassword puts the password of storedURL back into an agent URL whose
+// password is masked, so an agent saved from the masked URL keeps its credentials
+func RestoreAgentURLPassword(rawURL, storedURL, masked string) string {
+	userinfo, stripped := splitURLCredentials(rawURL)
+	user, password, ok := strings.Cut(userinfo, ":")
+	if !ok || password != masked {
+		return rawURL
+	}
+	storedUserinfo, _ := splitURLCredentials(storedURL)
+	_, storedPassword, ok := strings.Cut(storedUserinfo, ":")
+	if !ok {
+		return rawURL
+	}
+	return withURLCredentials(stripped, user+":"+storedPassword)
+}
+
+func withURLCredentials(rawURL, userinfo string) string {
+	scheme, rest, _ := strings.Cut(rawURL, "://")
+	return scheme + "://" + userinfo + "@" + rest
+}
+
+// SetAgentBasicAuth adds the user:pass@ credentials of the agent's URL to req as HTTP Basic
+// auth, for agents behind a reverse proxy that asks for it. Percent-encoded characters in
+// the credentials are decoded first.
+func SetAgentBasicAuth(req *http.Request, agent *types.MonitorAgent) {
+	if agent == nil {
+		return
+	}
+	userinfo, _ := splitURLCredentials(agent.URL)
+	if userinfo == "" {
+		return
+	}
+
+	user, password, _ := strings.Cut(userinfo, ":")
+	if decoded, err := url.PathUnescape(user); err == nil {
+		user = decoded
+	}
+	if decoded, err := url.PathUnescape(password); err == nil {
+		password = decoded
+	}
+	req.SetBasicAuth(user, password)
+}
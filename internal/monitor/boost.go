@@ -0,0 +1,103 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// speedtestBoostDuration lets the agent end a boost on its own if the restore request is lost
+	speedtestBoostDuration = 5 * time.Minute
+	boostRequestTimeout    = 5 * time.Second
+)
+
+// BoostLocalSampling asks connected agents on this host to sample live bandwidth at
+// monitor.speedtest_sample_interval while a speedtest runs, so the chart catches the spike.
+// The returned func restores normal sampling.
+func (s *Service) BoostLocalSampling() func() {
+	s.clientsMu.RLock()
+	interval := ""
+	if s.config != nil {
+		interval = s.config.SpeedtestSampleInterval
+	}
+	var local []*Client
+	if interval != "" {
+		for _, client := range s.clients {
+			if connected, _ := client.IsConnected(); connected && isLocalAgentURL(client.agent.URL) {
+				local = append(local, client)
+			}
+		}
+	}
+	s.clientsMu.RUnlock()
+
+	var boosted []*Client
+	for _, client := range local {
+		if err := setSamplingBoost(client, interval); err != nil {
+			// Agents predating the endpoint keep their normal sampling
+			log.Debug().Err(err).Int64("agent_id", client.agent.ID).Msg("Failed to boost agent sampling for speedtest")
+			continue
+		}
+		boosted = append(boosted, client)
+	}
+
+	return func() {
+		for _, client := range boosted {
+			if err := setSamplingBoost(client, ""); err != nil {
+				log.Warn().Err(err).Int64("agent_id", client.agent.ID).Msg("Failed to restore agent sampling, it resets on its own")
+			}
+		}
+	}
+}
+
+// setSamplingBoost starts boosted sampling at interval, or stops it when interval is empty
+func setSamplingBoost(client *Client, interval string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), boostRequestTimeout)
+	defer cancel()
+
+	boostURL := strings.TrimRight(client.baseURL(), "/") + "/sampling/boost"
+
+	method := http.MethodDelete
+	var body []byte
+	if interval != "" {
+		method = http.MethodPost
+		var err error
+		body, err = json.Marshal(map[string]string{
+			"interval": interval,
+			"duration": speedtestBoostDuration.String(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode boost request: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, boostURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if _, err := authorize(ctx, req, client.agent, client.tokens); err != nil {
+		return err
+	}
+
+	resp, err := AgentHTTPClient(client.agent, boostRequestTimeout).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach agent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &httpStatusError{StatusCode: resp.StatusCode, URL: boostURL}
+	}
+	return nil
+}
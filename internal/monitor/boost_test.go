@@ -0,0 +1,71 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestBoostLocalSampling(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sampling/boost" {
+			http.NotFound(w, r)
+			return
+		}
+		call := r.Method
+		if r.Method == http.MethodPost {
+			var req struct {
+				Interval string `json:"interval"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("decode boost request: %v", err)
+			}
+			call += " " + req.Interval
+		}
+		mu.Lock()
+		calls = append(calls, call)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	newService := func(interval string) *Service {
+		s := NewService(nil, &config.MonitorConfig{SpeedtestSampleInterval: interval}, nil, nil)
+		s.clients[1] = &Client{
+			agent:     &types.MonitorAgent{ID: 1, URL: srv.URL + "/events?stream=live-data"},
+			connected: true,
+		}
+		// Remote agents don't share the server's link
+		s.clients[2] = &Client{
+			agent:     &types.MonitorAgent{ID: 2, URL: "http://192.0.2.10:8200/events?stream=live-data"},
+			connected: true,
+		}
+		return s
+	}
+
+	restore := newService("250ms").BoostLocalSampling()
+	restore()
+
+	mu.Lock()
+	got := append([]string(nil), calls...)
+	calls = nil
+	mu.Unlock()
+	if len(got) != 2 || got[0] != "POST 250ms" || got[1] != http.MethodDelete {
+		t.Fatalf("boost calls = %v, want [POST 250ms DELETE]", got)
+	}
+
+	newService("").BoostLocalSampling()()
+	if len(calls) != 0 {
+		t.Fatalf("expected no boost calls when disabled, got %v", calls)
+	}
+}
@@ -9,7 +9,8 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
+
+	"github.com/autobrr/netronome/internal/types"
 )
 
 type agentEndpoint int
@@ -17,6 +18,9 @@ type agentEndpoint int
 const (
 	endpointSystemInfo agentEndpoint = iota
 	endpointHardwareStats
+	endpointProbes
+	endpointProcesses
+	endpointSmart
 )
 
 type endpointSupport struct {
@@ -27,6 +31,9 @@ type endpointSupport struct {
 type agentCapabilities struct {
 	systemInfo    endpointSupport
 	hardwareStats endpointSupport
+	probes        endpointSupport
+	processes     endpointSupport
+	smart         endpointSupport
 }
 
 type httpStatusError struct {
@@ -41,15 +48,15 @@ func (e *httpStatusError) Error() string {
 	return fmt.Sprintf("unexpected status code: %d (url=%s)", e.StatusCode, e.URL)
 }
 
-func detectAgentCapabilities(ctx context.Context, baseURL string) (agentCapabilities, error) {
-	rootURL := strings.TrimRight(baseURL, "/") + "/"
+func detectAgentCapabilities(ctx context.Context, httpClient *http.Client, agent *types.MonitorAgent) (agentCapabilities, error) {
+	rootURL := strings.TrimRight(AgentBaseURL(agent.URL), "/") + "/"
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rootURL, nil)
 	if err != nil {
 		return agentCapabilities{}, fmt.Errorf("create root request: %w", err)
 	}
+	SetAgentBasicAuth(req, agent)
 
-	httpClient := &http.Client{Timeout: 5 * time.Second}
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return agentCapabilities{}, fmt.Errorf("fetch root: %w", err)
@@ -72,9 +79,15 @@ func detectAgentCapabilities(ctx context.Context, baseURL string) (agentCapabili
 
 	_, hasSystem := root.Endpoints["system"]
 	_, hasHardware := root.Endpoints["hardware"]
+	_, hasProbes := root.Endpoints["probes"]
+	_, hasProcesses := root.Endpoints["processes"]
+	_, hasSmart := root.Endpoints["smart"]
 
 	return agentCapabilities{
 		systemInfo:    endpointSupport{known: true, supported: hasSystem},
 		hardwareStats: endpointSupport{known: true, supported: hasHardware},
+		probes:        endpointSupport{known: true, supported: hasProbes},
+		processes:     endpointSupport{known: true, supported: hasProcesses},
+		smart:         endpointSupport{known: true, supported: hasSmart},
 	}, nil
 }
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/autobrr/netronome/internal/types"
 )
@@ -13,11 +14,11 @@ func TestDetectAgentCapabilities_FromRootEndpoints(t *testing.T) {
 	t.Run("has system+hardware", func(t *testing.T) {
 		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "application/json")
-			_, _ = w.Write([]byte(`{"endpoints":{"system":"/system/info","hardware":"/system/hardware"}}`))
+			_, _ = w.Write([]byte(`{"endpoints":{"system":"/system/info","hardware":"/system/hardware","processes":"/system/processes","smart":"/system/smart"}}`))
 		}))
 		t.Cleanup(srv.Close)
 
-		caps, err := detectAgentCapabilities(context.Background(), srv.URL)
+		caps, err := detectAgentCapabilities(context.Background(), srv.Client(), &types.MonitorAgent{URL: srv.URL})
 		if err != nil {
 			t.Fatalf("detectAgentCapabilities error: %v", err)
 		}
@@ -27,6 +28,12 @@ func TestDetectAgentCapabilities_FromRootEndpoints(t *testing.T) {
 		if !caps.hardwareStats.known || !caps.hardwareStats.supported {
 			t.Fatalf("hardwareStats expected known+supported, got %+v", caps.hardwareStats)
 		}
+		if !caps.processes.known || !caps.processes.supported {
+			t.Fatalf("processes expected known+supported, got %+v", caps.processes)
+		}
+		if !caps.smart.known || !caps.smart.supported {
+			t.Fatalf("smart expected known+supported, got %+v", caps.smart)
+		}
 	})
 
 	t.Run("missing system+hardware", func(t *testing.T) {
@@ -36,7 +43,7 @@ func TestDetectAgentCapabilities_FromRootEndpoints(t *testing.T) {
 		}))
 		t.Cleanup(srv.Close)
 
-		caps, err := detectAgentCapabilities(context.Background(), srv.URL)
+		caps, err := detectAgentCapabilities(context.Background(), srv.Client(), &types.MonitorAgent{URL: srv.URL})
 		if err != nil {
 			t.Fatalf("detectAgentCapabilities error: %v", err)
 		}
@@ -46,6 +53,12 @@ func TestDetectAgentCapabilities_FromRootEndpoints(t *testing.T) {
 		if !caps.hardwareStats.known || caps.hardwareStats.supported {
 			t.Fatalf("hardwareStats expected known+unsupported, got %+v", caps.hardwareStats)
 		}
+		if !caps.processes.known || caps.processes.supported {
+			t.Fatalf("processes expected known+unsupported, got %+v", caps.processes)
+		}
+		if !caps.smart.known || caps.smart.supported {
+			t.Fatalf("smart expected known+unsupported, got %+v", caps.smart)
+		}
 	})
 
 	t.Run("missing endpoints field", func(t *testing.T) {
@@ -55,7 +68,7 @@ func TestDetectAgentCapabilities_FromRootEndpoints(t *testing.T) {
 		}))
 		t.Cleanup(srv.Close)
 
-		_, err := detectAgentCapabilities(context.Background(), srv.URL)
+		_, err := detectAgentCapabilities(context.Background(), srv.Client(), &types.MonitorAgent{URL: srv.URL})
 		if err == nil {
 			t.Fatalf("expected error, got nil")
 		}
@@ -81,3 +94,21 @@ func TestClient_HandleEndpointNotFound_DisablesPolling(t *testing.T) {
 		t.Fatalf("expected system info polling disabled after 404")
 	}
 }
+
+func TestShouldPollProcesses(t *testing.T) {
+	c := &Client{}
+	if !c.shouldPollProcesses() {
+		t.Fatalf("expected a never-polled agent to be polled")
+	}
+
+	c.lastProcessPoll = time.Now()
+	if c.shouldPollProcesses() {
+		t.Fatalf("expected an agent polled just now to wait for processPollInterval")
+	}
+
+	c.lastProcessPoll = time.Now().Add(-processPollInterval)
+	c.caps.processes = endpointSupport{known: true, supported: false}
+	if c.shouldPollProcesses() {
+		t.Fatalf("expected an agent without the processes endpoint not to be polled")
+	}
+}
@@ -10,21 +10,52 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/autobrr/netronome/internal/config"
 	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/notifications"
 	"github.com/autobrr/netronome/internal/types"
 )
 
+// ErrAgentLimitReached is returned by StartAgent when the configured max_agents limit is reached
+var ErrAgentLimitReached = errors.New("monitor agent limit reached")
+
+// ErrAgentNotMonitored is returned by RefreshAgent for an agent that isn't being monitored
+var ErrAgentNotMonitored = errors.New("monitor agent is not being monitored")
+
+// ErrAgentNotConnected is returned by RefreshAgent while the agent is unreachable
+var ErrAgentNotConnected = errors.New("monitor agent is not connected")
+
+// ErrRefreshInProgress is returned by RefreshAgent while a forced refresh of the same agent runs
+var ErrRefreshInProgress = errors.New("monitor agent refresh already in progress")
+
+// ErrRefreshNoStats is returned by RefreshAgent when the agent returned no resource stats
+var ErrRefreshNoStats = errors.New("monitor agent returned no resource stats")
+
 // Notifier interface for sending notifications
 type Notifier interface {
-	SendAgentNotification(agentName string, eventType string, value *float64) error
+	// The agent's tags select the notification rules limited to a tag. Alerts held back
+	// by quiet hours return notifications.ErrSuppressed and don't start a cooldown.
+	SendAgentNotification(agentName string, tags []string, eventType string, value *float64) error
+	SendAgentBandwidthNotification(agentName string, tags []string, iface string, mbps, threshold float64) error
+	SendAgentThresholdNotification(agentName string, tags []string, eventType string, value, threshold float64) error
+	// GetAgentCooldown returns the cooldown set on the notification rules of an agent
+	// event that apply to the agent's tags, false when the rules don't override the
+	// global cooldown
+	GetAgentCooldown(eventType string, tags []string) (time.Duration, bool)
 }
 
 // Client represents an SSE client connection to a monitor agent
@@ -33,6 +64,14 @@ type Client struct {
 	db            database.Service
 	broadcastFunc func(types.MonitorUpdate)
 	notifier      Notifier
+	tokens        *tokenSource // nil unless the agent uses token auth
+
+	// Live data transport from monitor.transport; ws:// agent URLs use WebSocket regardless
+	transport            string
+	webSocketUnsupported bool // set after the agent rejected a WebSocket handshake
+
+	// Decides which interfaces are stored; nil stores all of them
+	interfaceFilter *InterfaceFilter
 
 	mu        sync.Mutex
 	connected bool
@@ -40,6 +79,38 @@ type Client struct {
 	ctx       context.Context
 	cancel    context.CancelFunc
 
+	// Set while the agent reports maintenance; notifications are held back
+	maintenance bool
+
+	// Reports the service's global pause; the client doesn't reconnect while it is set
+	paused func() bool
+	// Closes the open live data stream, nil while disconnected
+	streamCancel context.CancelFunc
+
+	// Bandwidth limits in Mbps by interface name, from monitor.per_interface_thresholds
+	interfaceThresholds map[string]float64
+
+	// Minimum time between alerts of one type, from monitor.notification_cooldown
+	notificationCooldown time.Duration
+
+	// Wait between reconnect attempts, from monitor.reconnect_*
+	backoff reconnectBackoff
+
+	// Latest CPU sample from hardware stats polling
+	lastCPUPercent float64
+	lastCPUAt      time.Time
+
+	// Latest resource stats stored, returned by a forced refresh
+	lastResourceStats *types.MonitorResourceStats
+
+	// Set while RefreshAgent collects from the agent
+	refreshing atomic.Bool
+
+	// Uptime tracking for reboot detection, in seconds
+	systemUptime int64 // from system info, for agents whose hardware stats lack it
+	lastUptime   int64 // last uptime collected, 0 until known
+	uptimeSeeded bool  // lastUptime was loaded from the stored stats
+
 	capsOnce sync.Once
 	caps     agentCapabilities
 
@@ -49,11 +120,21 @@ type Client struct {
 	peakRxTimestamp time.Time
 	peakTxTimestamp time.Time
 
+	// Agent-side probe sync state
+	probeConfig       string    // last probe config pushed to the agent
+	lastProbeResultAt time.Time // newest probe result stored
+
+	// Last SMART poll; disks are read every smartPollInterval
+	lastSmartPoll time.Time
+	// Last process snapshot; processes are stored every processPollInterval
+	lastProcessPoll time.Time
+
 	// Resource state tracking for notifications
 	lastCPUNotificationTime       time.Time
 	lastMemoryNotificationTime    time.Time
+	lastSwapNotificationTime      time.Time
 	lastDiskNotificationTime      time.Time
-	lastBandwidthNotificationTime time.Time
+	lastBandwidthNotificationTime map[string]time.Time // by interface, "" when unknown
 	lastTempNotificationTime      time.Time
 }
 
@@ -69,17 +150,40 @@ type Service struct {
 	clientsMu   sync.RWMutex
 	clients     map[int64]*Client
 	agentStates map[int64]bool // Track connection state per agent
+	started     bool           // Set once Start succeeds, cleared by Stop
+	paused      atomic.Bool    // Set by SetPaused during planned maintenance
 
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 
-	// Background collection tickers
-	resourceTicker *time.Ticker
-	snapshotTicker *time.Ticker
-	cleanupTicker  *time.Ticker
+	// Background collection tickers and their intervals, parsed from the config in Start
+	resourceTicker   *time.Ticker
+	snapshotTicker   *time.Ticker
+	cleanupTicker    *time.Ticker
+	resourceInterval time.Duration
+	snapshotInterval time.Duration
+	cleanupInterval  time.Duration
 }
 
+const (
+	defaultResourceInterval     = 30 * time.Second
+	defaultSnapshotInterval     = time.Hour
+	defaultCleanupInterval      = time.Hour
+	defaultNotificationCooldown = time.Hour
+
+	defaultResourceRetention    = 2 * time.Hour
+	defaultSnapshotRetention    = time.Hour
+	defaultProbeResultRetention = 7 * 24 * time.Hour
+
+	// maxProcessSnapshotSize bounds a stored /system/processes response
+	maxProcessSnapshotSize = 1 << 20
+	// processPollInterval is how often an agent's top processes are stored. The agent
+	// samples CPU time for a second per request and the snapshot is only a fallback for
+	// offline agents, so it isn't taken with every resource stats collection.
+	processPollInterval = 5 * time.Minute
+)
+
 // NewService creates a new monitor service
 func NewService(db database.Service, cfg *config.MonitorConfig, broadcastFunc func(types.MonitorUpdate), notifier Notifier) *Service {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -144,6 +248,9 @@ func (s *Service) Start() error {
 	}
 
 	// Start background collection tasks
+	s.resourceInterval = parseCollectorInterval("monitor.resource_interval", s.config.ResourceInterval, defaultResourceInterval)
+	s.snapshotInterval = parseCollectorInterval("monitor.snapshot_interval", s.config.SnapshotInterval, defaultSnapshotInterval)
+	s.cleanupInterval = parseCollectorInterval("monitor.cleanup_interval", s.config.CleanupInterval, defaultCleanupInterval)
 	s.startBackgroundCollectors()
 
 	// Start Tailscale discovery if enabled
@@ -163,14 +270,56 @@ func (s *Service) Start() error {
 		s.collectHistoricalSnapshots()
 	}()
 
+	s.clientsMu.Lock()
+	s.started = true
+	s.clientsMu.Unlock()
+
 	log.Info().Int("agents", len(agents)).Msg("Monitor service started")
 	return nil
 }
 
+// Started reports whether the monitor service started and hasn't been stopped
+func (s *Service) Started() bool {
+	s.clientsMu.RLock()
+	defer s.clientsMu.RUnlock()
+	return s.started
+}
+
+// Reload applies a reloaded configuration. monitor.max_agents, per_interface_thresholds
+// and notification_cooldown are hot-reloadable; the thresholds and cooldown are pushed
+// into every running agent. Lowering max_agents stops no running agents but blocks new
+// ones until the count drops below the limit. Enabling or disabling the service, the
+// collection intervals, the reconnect backoff and Tailscale settings need a restart.
+func (s *Service) Reload(cfg *config.Config) {
+	s.clientsMu.Lock()
+	monitorCfg := cfg.Monitor
+	if s.config != nil {
+		monitorCfg = *s.config
+		monitorCfg.MaxAgents = cfg.Monitor.MaxAgents
+		monitorCfg.PerInterfaceThresholds = cfg.Monitor.PerInterfaceThresholds
+		monitorCfg.NotificationCooldown = cfg.Monitor.NotificationCooldown
+	}
+	s.config = &monitorCfg
+	running := len(s.clients)
+	for _, client := range s.clients {
+		client.applyNotificationSettings(&monitorCfg)
+	}
+	s.clientsMu.Unlock()
+
+	log.Info().
+		Int("max_agents", monitorCfg.MaxAgents).
+		Int("running_agents", running).
+		Msg("Reloaded monitor settings")
+}
+
 // Stop stops the monitor service
 func (s *Service) Stop() {
 	log.Info().Msg("Stopping monitor service")
 
+	s.clientsMu.Lock()
+	s.started = false
+	s.clientsMu.Unlock()
+
 	s.cancel()
 
 	// Stop Tailscale discovery if running
@@ -194,7 +343,7 @@ func (s *Service) Stop() {
 	log.Info().Msg("Running monitor data cleanup before shutdown")
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	if err := s.db.CleanupMonitorData(ctx); err != nil {
+	if err := s.db.CleanupMonitorData(ctx, s.retention()); err != nil {
 		log.Error().Err(err).Msg("Failed to cleanup monitor data on shutdown")
 	} else {
 		log.Info().Msg("Monitor data cleanup completed")
@@ -214,6 +363,13 @@ func (s *Service) broadcastWithNotification(update types.MonitorUpdate) {
 	default:
 	}
 
+	// Agents dropping off while monitoring is paused are expected. Their state stays
+	// connected, so one that can't be reached after resuming still alerts.
+	if !update.Connected && s.paused.Load() {
+		s.broadcastFunc(update)
+		return
+	}
+
 	// Get previous connection state
 	s.clientsMu.Lock()
 	wasConnected, exists := s.agentStates[update.AgentID]
@@ -222,8 +378,16 @@ func (s *Service) broadcastWithNotification(update types.MonitorUpdate) {
 		wasConnected = true
 	}
 	s.agentStates[update.AgentID] = update.Connected
+	client := s.clients[update.AgentID]
 	s.clientsMu.Unlock()
 
+	// An agent host rebooting during maintenance is expected, not an outage
+	notify := s.notifier != nil && (client == nil || !client.inMaintenance())
+	var tags []string
+	if client != nil {
+		tags = client.agent.Tags
+	}
+
 	// Check if agent went offline or came back online
 	if wasConnected && !update.Connected {
 		// Agent went offline
@@ -232,10 +396,10 @@ func (s *Service) broadcastWithNotification(update types.MonitorUpdate) {
 			Str("agentName", update.AgentName).
 			Msg("Agent went offline")
 
-		if s.notifier != nil {
-			err := s.notifier.SendAgentNotification(update.AgentName, database.NotificationEventAgentOffline, nil)
+		if notify {
+			err := s.notifier.SendAgentNotification(update.AgentName, tags, database.NotificationEventAgentOffline, nil)
 			if err != nil {
-				log.Error().Err(err).Msg("Failed to send agent offline notification")
+				notifyErrorLog(err).Msg("Failed to send agent offline notification")
 			}
 		}
 	} else if !wasConnected && update.Connected {
@@ -245,8 +409,8 @@ func (s *Service) broadcastWithNotification(update types.MonitorUpdate) {
 			Str("agentName", update.AgentName).
 			Msg("Agent came back online")
 
-		if s.notifier != nil {
-			err := s.notifier.SendAgentNotification(update.AgentName, database.NotificationEventAgentOnline, nil)
+		if notify {
+			err := s.notifier.SendAgentNotification(update.AgentName, tags, database.NotificationEventAgentOnline, nil)
 			if err != nil {
 				log.Error().Err(err).Msg("Failed to send agent online notification")
 			}
@@ -278,20 +442,53 @@ func (s *Service) StartAgent(agentID int64) error {
 		db:            s.db,
 		broadcastFunc: s.broadcastWithNotification,
 		notifier:      s.notifier,
+		tokens:        newTokenSource(agent),
+		paused:        s.Paused,
+	}
+	// Reload swaps s.config under clientsMu, so read it under the same lock
+	s.clientsMu.RLock()
+	cfg := s.config
+	s.clientsMu.RUnlock()
+	if cfg != nil {
+		client.transport = cfg.Transport
+		client.backoff = newReconnectBackoff(cfg)
+		client.applyNotificationSettings(cfg)
+	}
+	if client.interfaceFilter, err = NewInterfaceFilter(agent); err != nil {
+		// Patterns are validated on save, so only a hand-edited row gets here; keep the virtual default
+		log.Warn().Err(err).Int64("agent_id", agentID).Msg("Invalid interface filter, ignoring patterns")
+		client.interfaceFilter = &InterfaceFilter{includeVirtual: agent.IncludeVirtualInterfaces}
 	}
 
-	// Start monitoring
-	client.Start()
-
-	// Store client
+	// Reserve a slot before starting so concurrent starts cannot exceed the limit
 	s.clientsMu.Lock()
+	if s.atAgentLimit() {
+		maxAgents := s.config.MaxAgents
+		s.clientsMu.Unlock()
+		log.Warn().
+			Int64("agent_id", agentID).
+			Int("max_agents", maxAgents).
+			Msg("Monitor agent limit reached, not starting agent")
+		return fmt.Errorf("%w: %d agents already monitored (max_agents = %d)", ErrAgentLimitReached, maxAgents, maxAgents)
+	}
 	s.clients[agentID] = client
 	s.clientsMu.Unlock()
 
-	log.Info().Int64("agent_id", agentID).Str("url", agent.URL).Msg("Started monitor agent")
+	// Start monitoring
+	client.Start()
+
+	log.Info().Int64("agent_id", agentID).Str("url", StripAgentURLCredentials(agent.URL)).Msg("Started monitor agent")
 	return nil
 }
 
+// atAgentLimit reports whether another agent can be started. Caller must hold clientsMu.
+func (s *Service) atAgentLimit() bool {
+	if s.config == nil || s.config.MaxAgents <= 0 {
+		return false
+	}
+	return len(s.clients) >= s.config.MaxAgents
+}
+
 // StopAgent stops monitoring a specific agent
 func (s *Service) StopAgent(agentID int64) {
 	s.clientsMu.Lock()
@@ -305,6 +502,47 @@ func (s *Service) StopAgent(agentID int64) {
 		client.Stop()
 		log.Info().Int64("agent_id", agentID).Msg("Stopped monitor agent")
 	}
+	closeAgentTransports(agentID)
+}
+
+// RefreshAgent collects resource stats and vnstat history from one agent right away,
+// outside the regular collection intervals, and returns the resource stats stored. Only
+// one forced refresh runs per agent at a time; an overlapping call gets
+// ErrRefreshInProgress.
+func (s *Service) RefreshAgent(agentID int64) (*types.MonitorResourceStats, error) {
+	s.clientsMu.RLock()
+	client, exists := s.clients[agentID]
+	s.clientsMu.RUnlock()
+
+	if !exists {
+		return nil, ErrAgentNotMonitored
+	}
+	if connected, _ := client.IsConnected(); !connected {
+		return nil, ErrAgentNotConnected
+	}
+	if !client.refreshing.CompareAndSwap(false, true) {
+		return nil, ErrRefreshInProgress
+	}
+	defer client.refreshing.Store(false)
+
+	client.mu.Lock()
+	previous := client.lastResourceStats
+	client.mu.Unlock()
+
+	s.fetchAndStoreResourceStats(client)
+	s.fetchAndStoreHistoricalData(client)
+
+	client.mu.Lock()
+	stats := client.lastResourceStats
+	client.mu.Unlock()
+
+	// Errors were logged by the fetches; unchanged stats mean none were collected
+	if stats == nil || stats == previous {
+		return nil, ErrRefreshNoStats
+	}
+
+	log.Debug().Int64("agent_id", agentID).Msg("Refreshed monitor agent")
+	return stats, nil
 }
 
 // GetAgentStatus returns the status of an agent
@@ -320,6 +558,43 @@ func (s *Service) GetAgentStatus(agentID int64) (bool, *types.MonitorLiveData) {
 	return client.IsConnected()
 }
 
+// GetAgentStatuses returns the status of every monitored agent by agent ID. The clients
+// are copied under a single read lock and queried after it is released.
+func (s *Service) GetAgentStatuses() map[int64]types.MonitorAgentStatus {
+	s.clientsMu.RLock()
+	clients := maps.Clone(s.clients)
+	s.clientsMu.RUnlock()
+
+	statuses := make(map[int64]types.MonitorAgentStatus, len(clients))
+	for id, client := range clients {
+		connected, liveData := client.IsConnected()
+		statuses[id] = types.MonitorAgentStatus{
+			Connected:   connected,
+			Maintenance: client.inMaintenance(),
+			LiveData:    liveData,
+		}
+	}
+	return statuses
+}
+
+// LocalNetworkConditions returns the current load reported by a connected agent
+// running on this host, or nil when no such agent is being monitored.
+func (s *Service) LocalNetworkConditions() *types.NetworkConditions {
+	s.clientsMu.RLock()
+	defer s.clientsMu.RUnlock()
+
+	for _, client := range s.clients {
+		if !isLocalAgentURL(client.agent.URL) {
+			continue
+		}
+		if conditions := client.networkConditions(); conditions != nil {
+			return conditions
+		}
+	}
+
+	return nil
+}
+
 // Client methods
 
 // Start starts the client connection
@@ -351,8 +626,49 @@ func (c *Client) IsConnected() (bool, *types.MonitorLiveData) {
 	return c.connected, c.lastData
 }
 
+// networkConditions builds a load snapshot from the latest live data and CPU sample
+func (c *Client) networkConditions() *types.NetworkConditions {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.connected || c.lastData == nil {
+		return nil
+	}
+
+	conditions := &types.NetworkConditions{
+		RxBytesPerSecond: int64(c.lastData.Rx.Bytespersecond),
+		TxBytesPerSecond: int64(c.lastData.Tx.Bytespersecond),
+	}
+	// Resource stats are polled every 30s; ignore samples too old to describe "now"
+	if !c.lastCPUAt.IsZero() && time.Since(c.lastCPUAt) < 2*time.Minute {
+		cpu := c.lastCPUPercent
+		conditions.CPUUsagePercent = &cpu
+	}
+
+	return conditions
+}
+
+// isLocalAgentURL reports whether an agent URL points at this host
+func isLocalAgentURL(rawURL string) bool {
+	if agentSocketPath(rawURL) != "" {
+		return true
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 func (c *Client) baseURL() string {
-	return strings.TrimSuffix(c.agent.URL, "/events?stream=live-data")
+	return AgentBaseURL(c.agent.URL)
 }
 
 func (c *Client) ensureCapabilities() {
@@ -360,7 +676,7 @@ func (c *Client) ensureCapabilities() {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		caps, err := detectAgentCapabilities(ctx, c.baseURL())
+		caps, err := detectAgentCapabilities(ctx, AgentHTTPClient(c.agent, 5*time.Second), c.agent)
 		if err != nil {
 			// Unknown capabilities -> keep legacy behavior (poll endpoints).
 			log.Debug().Err(err).Int64("agent_id", c.agent.ID).Msg("Failed to detect agent capabilities")
@@ -391,6 +707,24 @@ func (c *Client) shouldPollHardwareStats() bool {
 	return true
 }
 
+func (c *Client) shouldPollProbes() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.caps.probes.known {
+		return c.caps.probes.supported
+	}
+	return true
+}
+
+func (c *Client) shouldPollProcesses() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.caps.processes.known && !c.caps.processes.supported {
+		return false
+	}
+	return time.Since(c.lastProcessPoll) >= processPollInterval
+}
+
 func (c *Client) handleEndpointNotFound(err error, ep agentEndpoint) bool {
 	var statusErr *httpStatusError
 	if !errors.As(err, &statusErr) {
@@ -417,6 +751,27 @@ func (c *Client) handleEndpointNotFound(err error, ep agentEndpoint) bool {
 		}
 		c.caps.hardwareStats = endpointSupport{known: true, supported: false}
 		msg = "Hardware stats endpoint not available (404); disabling polling for this agent"
+	case endpointProbes:
+		if c.caps.probes.known && !c.caps.probes.supported {
+			c.mu.Unlock()
+			return true
+		}
+		c.caps.probes = endpointSupport{known: true, supported: false}
+		msg = "Probe endpoints not available (404); agent-side probes disabled for this agent"
+	case endpointProcesses:
+		if c.caps.processes.known && !c.caps.processes.supported {
+			c.mu.Unlock()
+			return true
+		}
+		c.caps.processes = endpointSupport{known: true, supported: false}
+		msg = "Process endpoint not available (404); disabling process snapshots for this agent"
+	case endpointSmart:
+		if c.caps.smart.known && !c.caps.smart.supported {
+			c.mu.Unlock()
+			return true
+		}
+		c.caps.smart = endpointSupport{known: true, supported: false}
+		msg = "SMART endpoint not available (404); disabling SMART polling for this agent"
 	default:
 		c.mu.Unlock()
 		return false
@@ -427,10 +782,13 @@ func (c *Client) handleEndpointNotFound(err error, ep agentEndpoint) bool {
 	return true
 }
 
-// monitor connects to the SSE endpoint and processes data
+// monitor connects to the live data stream and processes data
 func (c *Client) monitor() {
-	reconnectDelay := time.Second
-	maxReconnectDelay := time.Minute
+	backoff := c.backoff
+	if backoff.initial <= 0 {
+		backoff = newReconnectBackoff(nil)
+	}
+	reconnectDelay := backoff.initial
 
 	for {
 		select {
@@ -439,8 +797,37 @@ func (c *Client) monitor() {
 		default:
 		}
 
-		// Connect to SSE endpoint
-		err := c.connectAndStream()
+		if !c.waitWhilePaused() {
+			return
+		}
+
+		// Connect to the SSE or WebSocket endpoint
+		err := c.connect()
+		if errors.Is(err, errTokenRefresh) {
+			// Planned rotation, reconnect straight away without reporting the agent offline
+			log.Debug().
+				Int64("agent_id", c.agent.ID).
+				Msg("Reconnecting to monitor agent with refreshed bearer token")
+			reconnectDelay = backoff.initial
+			continue
+		}
+		if errors.Is(err, errPaused) {
+			// Closed on purpose, waitWhilePaused holds the reconnect until resumed
+			log.Info().
+				Int64("agent_id", c.agent.ID).
+				Msg("Disconnected from monitor agent while monitoring is paused")
+			c.mu.Lock()
+			c.connected = false
+			c.mu.Unlock()
+			c.broadcastFunc(types.MonitorUpdate{
+				Type:      "monitor",
+				AgentID:   c.agent.ID,
+				AgentName: c.agent.Name,
+				Connected: false,
+			})
+			reconnectDelay = backoff.initial
+			continue
+		}
 		if err != nil {
 			// Don't log error if context was cancelled (normal shutdown)
 			if errors.Is(err, context.Canceled) {
@@ -451,7 +838,7 @@ func (c *Client) monitor() {
 				log.Error().
 					Err(err).
 					Int64("agent_id", c.agent.ID).
-					Str("url", c.agent.URL).
+					Str("url", StripAgentURLCredentials(c.agent.URL)).
 					Msg("Failed to connect to monitor agent")
 			}
 
@@ -472,16 +859,13 @@ func (c *Client) monitor() {
 			select {
 			case <-c.ctx.Done():
 				return
-			case <-time.After(reconnectDelay):
+			case <-time.After(backoff.wait(reconnectDelay)):
 				// Exponential backoff
-				reconnectDelay *= 2
-				if reconnectDelay > maxReconnectDelay {
-					reconnectDelay = maxReconnectDelay
-				}
+				reconnectDelay = backoff.next(reconnectDelay)
 			}
 		} else {
 			// Reset reconnect delay on successful connection
-			reconnectDelay = time.Second
+			reconnectDelay = backoff.initial
 		}
 	}
 }
@@ -489,7 +873,8 @@ func (c *Client) monitor() {
 // connectAndStream connects to the SSE endpoint and streams data
 func (c *Client) connectAndStream() error {
 	// Create request with context
-	req, err := http.NewRequestWithContext(c.ctx, "GET", c.agent.URL, nil)
+	streamURL := c.baseURL() + sseStreamPath
+	req, err := http.NewRequestWithContext(c.ctx, "GET", streamURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -498,47 +883,46 @@ func (c *Client) connectAndStream() error {
 	req.Header.Set("Accept", "text/event-stream")
 	req.Header.Set("Cache-Control", "no-cache")
 
-	// Add API key if configured
-	if c.agent.APIKey != nil && *c.agent.APIKey != "" {
-		req.Header.Set("X-API-Key", *c.agent.APIKey)
+	// Add API key or bearer token if configured
+	tokenExpiry, err := authorize(c.ctx, req, c.agent, c.tokens)
+	if err != nil {
+		return err
+	}
+
+	// Bearer tokens can't be swapped on an open stream, so close it before expiry and reconnect
+	streamCtx, streamCancel := context.WithCancel(c.ctx)
+	if !tokenExpiry.IsZero() {
+		streamCtx, streamCancel = context.WithDeadline(c.ctx, c.tokens.RefreshAt(tokenExpiry))
 	}
+	defer streamCancel()
+	req = req.WithContext(streamCtx)
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 0, // No timeout for SSE connections
+	if err := c.trackStream(streamCancel); err != nil {
+		return err
 	}
+	defer c.untrackStream()
+
+	// No timeout for SSE connections
+	client := AgentHTTPClient(c.agent, 0)
 
 	// Make request
 	resp, err := client.Do(req)
 	if err != nil {
+		if c.streamClosedByPause(streamCtx) {
+			return errPaused
+		}
 		return fmt.Errorf("failed to connect: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusUnauthorized && c.tokens != nil {
+			c.tokens.Invalidate()
+		}
 		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	// Update connection status
-	c.mu.Lock()
-	c.connected = true
-	c.mu.Unlock()
-
-	// Broadcast connection
-	c.broadcastFunc(types.MonitorUpdate{
-		Type:      "monitor",
-		AgentID:   c.agent.ID,
-		AgentName: c.agent.Name,
-		Connected: true,
-	})
-
-	log.Info().
-		Int64("agent_id", c.agent.ID).
-		Str("url", c.agent.URL).
-		Msg("Connected to monitor agent")
-
-	// Fetch initial peak stats after connection
-	go c.fetchInitialPeakStats()
+	c.markConnected(streamURL)
 
 	// Read SSE stream
 	scanner := bufio.NewScanner(resp.Body)
@@ -566,6 +950,13 @@ func (c *Client) connectAndStream() error {
 		}
 	}
 
+	if c.ctx.Err() == nil && errors.Is(streamCtx.Err(), context.DeadlineExceeded) {
+		return errTokenRefresh
+	}
+	if c.streamClosedByPause(streamCtx) {
+		return errPaused
+	}
+
 	if err := scanner.Err(); err != nil {
 		return fmt.Errorf("scanner error: %w", err)
 	}
@@ -573,6 +964,32 @@ func (c *Client) connectAndStream() error {
 	return fmt.Errorf("connection closed")
 }
 
+// markConnected records and broadcasts a new live data connection
+func (c *Client) markConnected(streamURL string) {
+	// Update connection status
+	c.mu.Lock()
+	c.connected = true
+	// The agent may have restarted and lost server-managed probes, so push them again
+	c.probeConfig = ""
+	c.mu.Unlock()
+
+	// Broadcast connection
+	c.broadcastFunc(types.MonitorUpdate{
+		Type:      "monitor",
+		AgentID:   c.agent.ID,
+		AgentName: c.agent.Name,
+		Connected: true,
+	})
+
+	log.Info().
+		Int64("agent_id", c.agent.ID).
+		Str("url", streamURL).
+		Msg("Connected to monitor agent")
+
+	// Fetch initial peak stats after connection
+	go c.fetchInitialPeakStats()
+}
+
 // processData processes incoming bandwidth monitor data
 func (c *Client) processData(data string) {
 	// Parse JSON data
@@ -600,8 +1017,7 @@ func (c *Client) processData(data string) {
 	rxBytes := int64(liveData.Rx.Bytespersecond)
 	txBytes := int64(liveData.Tx.Bytespersecond)
 
-	// Broadcast update
-	c.broadcastFunc(types.MonitorUpdate{
+	update := types.MonitorUpdate{
 		Type:             "monitor",
 		AgentID:          c.agent.ID,
 		AgentName:        c.agent.Name,
@@ -610,32 +1026,193 @@ func (c *Client) processData(data string) {
 		RxRateString:     liveData.Rx.Ratestring,
 		TxRateString:     liveData.Tx.Ratestring,
 		Connected:        true,
-	})
+	}
+	if len(liveData.Interfaces) > 0 {
+		update.Interfaces = make(map[string]types.MonitorInterfaceRate, len(liveData.Interfaces))
+		for iface, data := range liveData.Interfaces {
+			update.Interfaces[iface] = types.MonitorInterfaceRate{
+				RxBytesPerSecond: int64(data.Rx.Bytespersecond),
+				TxBytesPerSecond: int64(data.Tx.Bytespersecond),
+				RxRateString:     data.Rx.Ratestring,
+				TxRateString:     data.Tx.Ratestring,
+			}
+		}
+	}
+
+	// Broadcast update
+	c.broadcastFunc(update)
 
 	// Update peak stats if this is a new peak
 	c.updatePeakStats(rxBytes, txBytes)
 
-	// Check bandwidth threshold for notifications
-	if c.notifier != nil {
-		// Convert bytes per second to Mbps for threshold checking
-		totalBandwidthMbps := float64(rxBytes+txBytes) * 8 / 1_000_000
+	// Check bandwidth threshold for notifications, per interface when the agent reports several
+	if c.notifier != nil && !c.inMaintenance() {
+		if len(liveData.Interfaces) == 0 {
+			c.checkBandwidthThreshold(c.liveInterface(&liveData), &liveData)
+		}
+		for _, iface := range slices.Sorted(maps.Keys(liveData.Interfaces)) {
+			data := liveData.Interfaces[iface]
+			c.checkBandwidthThreshold(iface, &data)
+		}
+	}
+}
 
-		// Rate limit notifications to once per hour
-		notificationCooldown := 1 * time.Hour
-		now := time.Now()
+// checkBandwidthThreshold notifies when a sample exceeds the interface's limit, or the
+// rule threshold for interfaces without one
+func (c *Client) checkBandwidthThreshold(iface string, data *types.MonitorLiveData) {
+	// Convert bytes per second to Mbps for threshold checking
+	totalBandwidthMbps := float64(data.Rx.Bytespersecond+data.Tx.Bytespersecond) * 8 / 1_000_000
 
-		if totalBandwidthMbps > 0 && now.Sub(c.lastBandwidthNotificationTime) > notificationCooldown {
-			if err := c.notifier.SendAgentNotification(
-				c.agent.Name,
-				database.NotificationEventAgentHighBandwidth,
-				&totalBandwidthMbps,
-			); err != nil {
-				log.Error().Err(err).Msg("Failed to send high bandwidth notification")
+	// Rate limit notifications per interface
+	now := time.Now()
+	if now.Sub(c.lastBandwidthNotificationTime[iface]) <= c.cooldownFor(database.NotificationEventAgentHighBandwidth) {
+		return
+	}
+
+	// A per-interface threshold replaces the rule threshold for that interface
+	if limit, ok := c.interfaceThreshold(iface); ok && iface != "" {
+		if totalBandwidthMbps > limit {
+			if err := c.notifier.SendAgentBandwidthNotification(c.agent.Name, c.agent.Tags, iface, totalBandwidthMbps, limit); err != nil {
+				notifyErrorLog(err).Str("interface", iface).Msg("Failed to send high bandwidth notification")
 			} else {
-				c.lastBandwidthNotificationTime = now
+				c.markBandwidthNotified(iface, now)
 			}
 		}
+	} else if totalBandwidthMbps > 0 {
+		agentName := c.agent.Name
+		if iface != "" {
+			agentName = fmt.Sprintf("%s|%s", c.agent.Name, iface)
+		}
+		if err := c.notifier.SendAgentNotification(
+			agentName,
+			c.agent.Tags,
+			database.NotificationEventAgentHighBandwidth,
+			&totalBandwidthMbps,
+		); err != nil {
+			notifyErrorLog(err).Msg("Failed to send high bandwidth notification")
+		} else {
+			c.markBandwidthNotified(iface, now)
+		}
+	}
+}
+
+// notifyErrorLog returns the event a failed notification is logged with. An alert held
+// back by quiet hours isn't a failure, so it gets a disabled event.
+func notifyErrorLog(err error) *zerolog.Event {
+	if errors.Is(err, notifications.ErrSuppressed) {
+		return nil
+	}
+	return log.Error().Err(err)
+}
+
+// notifyResource sends a resource notification, checking value against the agent's own
+// threshold when one is set and leaving the check to the notification rules otherwise.
+// It reports whether a notification was sent.
+func (c *Client) notifyResource(agentName, eventType string, value float64, threshold *float64) (bool, error) {
+	if threshold == nil {
+		return true, c.notifier.SendAgentNotification(agentName, c.agent.Tags, eventType, &value)
+	}
+	if value <= *threshold {
+		return false, nil
+	}
+	return true, c.notifier.SendAgentThresholdNotification(agentName, c.agent.Tags, eventType, value, *threshold)
+}
+
+// seedUptime loads the uptime of the agent's latest stored stats once, so a reboot
+// while the server was down is still noticed
+func (s *Service) seedUptime(client *Client) {
+	client.mu.Lock()
+	seeded := client.uptimeSeeded
+	client.uptimeSeeded = true
+	client.mu.Unlock()
+	if seeded {
+		return
+	}
+
+	stats, err := s.db.GetMonitorResourceStats(client.ctx, client.agent.ID, 24)
+	if err != nil {
+		log.Debug().Err(err).Int64("agent_id", client.agent.ID).Msg("Failed to load stored agent uptime")
+		return
+	}
+	if len(stats) == 0 {
+		return
+	}
+
+	client.mu.Lock()
+	if client.lastUptime == 0 {
+		client.lastUptime = stats[0].UptimeSeconds
+	}
+	client.mu.Unlock()
+}
+
+// observeUptime records a collected uptime and reports whether it went backwards since
+// the previous collection, meaning the agent's host rebooted. Unknown uptimes (0) are
+// ignored.
+func (c *Client) observeUptime(uptime int64) bool {
+	if uptime <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rebooted := c.lastUptime > 0 && uptime < c.lastUptime
+	c.lastUptime = uptime
+	return rebooted
+}
+
+// cooldownFor returns the minimum time between notifications of an event: the rule
+// cooldown when one is set, otherwise monitor.notification_cooldown
+func (c *Client) cooldownFor(eventType string) time.Duration {
+	if c.notifier != nil {
+		if cooldown, ok := c.notifier.GetAgentCooldown(eventType, c.agent.Tags); ok {
+			return cooldown
+		}
+	}
+	c.mu.Lock()
+	cooldown := c.notificationCooldown
+	c.mu.Unlock()
+	if cooldown > 0 {
+		return cooldown
+	}
+	return defaultNotificationCooldown
+}
+
+// applyNotificationSettings copies the alert thresholds and cooldown from the monitor
+// config; Reload calls it on running clients so SIGHUP takes effect without a reconnect
+func (c *Client) applyNotificationSettings(cfg *config.MonitorConfig) {
+	cooldown := parseCollectorInterval("monitor.notification_cooldown", cfg.NotificationCooldown, defaultNotificationCooldown)
+	c.mu.Lock()
+	c.interfaceThresholds = cfg.PerInterfaceThresholds
+	c.notificationCooldown = cooldown
+	c.mu.Unlock()
+}
+
+// interfaceThreshold returns the per-interface bandwidth limit in Mbps, if one is set
+func (c *Client) interfaceThreshold(iface string) (float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	limit, ok := c.interfaceThresholds[iface]
+	return limit, ok
+}
+
+func (c *Client) markBandwidthNotified(iface string, at time.Time) {
+	if c.lastBandwidthNotificationTime == nil {
+		c.lastBandwidthNotificationTime = make(map[string]time.Time)
+	}
+	c.lastBandwidthNotificationTime[iface] = at
+}
+
+// liveInterface returns the interface a live sample was measured on: the agent's tag,
+// else the interface stored for the agent, else empty when unknown
+func (c *Client) liveInterface(data *types.MonitorLiveData) string {
+	if data.Interface != "" {
+		return data.Interface
+	}
+	if c.agent.Interface != nil {
+		return *c.agent.Interface
 	}
+	return ""
 }
 
 // updatePeakStats updates peak bandwidth statistics if current values are higher
@@ -673,12 +1250,57 @@ func (c *Client) updatePeakStats(rxBytes, txBytes int64) {
 	}
 }
 
+// parseCollectorInterval parses a background collector interval, falling back to def
+// with a warning when the value is empty, invalid or not positive
+func parseCollectorInterval(key, value string, def time.Duration) time.Duration {
+	if value == "" {
+		return def
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		log.Warn().Str("key", key).Str("value", value).Dur("default", def).Msg("Invalid collection interval, using default")
+		return def
+	}
+	return interval
+}
+
+// retention returns how long each class of monitor data is kept, from monitor.retention
+func (s *Service) retention() types.MonitorRetention {
+	// Reload swaps the config under clientsMu
+	s.clientsMu.RLock()
+	var cfg config.MonitorRetentionConfig
+	if s.config != nil {
+		cfg = s.config.Retention
+	}
+	s.clientsMu.RUnlock()
+
+	return types.MonitorRetention{
+		ResourceStats: parseRetention("monitor.retention.resource_stats", cfg.ResourceStats, defaultResourceRetention),
+		Snapshots:     parseRetention("monitor.retention.snapshots", cfg.Snapshots, defaultSnapshotRetention),
+		ProbeResults:  parseRetention("monitor.retention.probe_results", cfg.ProbeResults, defaultProbeResultRetention),
+	}
+}
+
+// parseRetention parses a retention duration, where zero disables cleanup. Empty and
+// invalid values fall back to the default.
+func parseRetention(key, value string, def time.Duration) time.Duration {
+	if value == "" {
+		return def
+	}
+	retention, err := time.ParseDuration(value)
+	if err != nil || retention < 0 {
+		log.Warn().Str("key", key).Str("value", value).Dur("default", def).Msg("Invalid retention, using default")
+		return def
+	}
+	return retention
+}
+
 // startBackgroundCollectors starts background data collection tasks
 func (s *Service) startBackgroundCollectors() {
 	// Bandwidth samples are collected in real-time via SSE, no separate ticker needed
 
-	// Resource stats collection every 30 seconds
-	s.resourceTicker = time.NewTicker(30 * time.Second)
+	// Resource stats collection, every 30 seconds by default
+	s.resourceTicker = time.NewTicker(s.resourceInterval)
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
@@ -692,8 +1314,8 @@ func (s *Service) startBackgroundCollectors() {
 		}
 	}()
 
-	// Historical snapshot collection every hour
-	s.snapshotTicker = time.NewTicker(1 * time.Hour)
+	// Historical snapshot collection, hourly by default
+	s.snapshotTicker = time.NewTicker(s.snapshotInterval)
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
@@ -707,8 +1329,8 @@ func (s *Service) startBackgroundCollectors() {
 		}
 	}()
 
-	// Data cleanup every hour
-	s.cleanupTicker = time.NewTicker(1 * time.Hour)
+	// Data cleanup, hourly by default
+	s.cleanupTicker = time.NewTicker(s.cleanupInterval)
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
@@ -717,7 +1339,7 @@ func (s *Service) startBackgroundCollectors() {
 			case <-s.ctx.Done():
 				return
 			case <-s.cleanupTicker.C:
-				if err := s.db.CleanupMonitorData(s.ctx); err != nil {
+				if err := s.db.CleanupMonitorData(s.ctx, s.retention()); err != nil {
 					log.Error().Err(err).Msg("Failed to cleanup monitor data")
 				}
 			}
@@ -758,7 +1380,12 @@ func (s *Service) collectResourceStats() {
 func (s *Service) fetchAndStoreResourceStats(client *Client) {
 	client.ensureCapabilities()
 
-	if client.shouldPollSystemInfo() {
+	if err := s.fetchMaintenanceState(client); err != nil {
+		log.Debug().Err(err).Int64("agent_id", client.agent.ID).Msg("Failed to fetch agent maintenance state")
+	}
+	pauseCollection := client.inMaintenance() && s.pauseCollectionInMaintenance()
+
+	if client.shouldPollSystemInfo() && !pauseCollection {
 		if err := s.fetchSystemInfo(client); err != nil {
 			if !client.handleEndpointNotFound(err, endpointSystemInfo) {
 				log.Error().Err(err).Int64("agent_id", client.agent.ID).Msg("Failed to fetch system info")
@@ -766,13 +1393,37 @@ func (s *Service) fetchAndStoreResourceStats(client *Client) {
 		}
 	}
 
-	if client.shouldPollHardwareStats() {
+	if client.shouldPollHardwareStats() && !pauseCollection {
 		if err := s.fetchHardwareStats(client); err != nil {
 			if !client.handleEndpointNotFound(err, endpointHardwareStats) {
 				log.Error().Err(err).Int64("agent_id", client.agent.ID).Msg("Failed to fetch hardware stats")
 			}
 		}
 	}
+
+	if client.shouldPollProcesses() && !pauseCollection {
+		if err := s.fetchProcesses(client); err != nil {
+			if !client.handleEndpointNotFound(err, endpointProcesses) {
+				log.Error().Err(err).Int64("agent_id", client.agent.ID).Msg("Failed to fetch processes")
+			}
+		}
+	}
+
+	if client.shouldPollSmart() && !pauseCollection {
+		if err := s.fetchSmartStats(client); err != nil {
+			if !client.handleEndpointNotFound(err, endpointSmart) {
+				log.Error().Err(err).Int64("agent_id", client.agent.ID).Msg("Failed to fetch SMART stats")
+			}
+		}
+	}
+
+	if client.shouldPollProbes() {
+		if err := s.syncAgentProbes(client); err != nil {
+			if !client.handleEndpointNotFound(err, endpointProbes) {
+				log.Error().Err(err).Int64("agent_id", client.agent.ID).Msg("Failed to sync agent probes")
+			}
+		}
+	}
 }
 
 // fetchSystemInfo fetches and stores system information from an agent
@@ -784,11 +1435,11 @@ func (s *Service) fetchSystemInfo(client *Client) error {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	if client.agent.APIKey != nil && *client.agent.APIKey != "" {
-		req.Header.Set("X-API-Key", *client.agent.APIKey)
+	if _, err := authorize(client.ctx, req, client.agent, client.tokens); err != nil {
+		return err
 	}
 
-	httpClient := &http.Client{Timeout: 30 * time.Second}
+	httpClient := AgentHTTPClient(client.agent, 30*time.Second)
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to fetch system info: %w", err)
@@ -829,7 +1480,8 @@ func (s *Service) fetchSystemInfo(client *Client) error {
 	infoURL := strings.TrimRight(client.baseURL(), "/") + "/netronome/info"
 	infoReq, err := http.NewRequestWithContext(client.ctx, "GET", infoURL, nil)
 	if err == nil {
-		// Don't add API key for this endpoint as it's public
+		// Don't add API key for this endpoint as it's public, but a proxy in front may ask for basic auth
+		SetAgentBasicAuth(infoReq, client.agent)
 		infoResp, err := httpClient.Do(infoReq)
 		if err == nil && infoResp.StatusCode == http.StatusOK {
 			defer infoResp.Body.Close()
@@ -865,9 +1517,16 @@ func (s *Service) fetchSystemInfo(client *Client) error {
 		return fmt.Errorf("failed to store system info: %w", err)
 	}
 
+	client.mu.Lock()
+	client.systemUptime = systemInfo.Uptime
+	client.mu.Unlock()
+
 	// Store interfaces
 	var interfaces []types.MonitorInterface
 	for ifaceName, ifaceData := range systemInfo.Interfaces {
+		if !client.interfaceFilter.Allow(ifaceName) {
+			continue
+		}
 		if ifaceMap, ok := ifaceData.(map[string]interface{}); ok {
 			iface := types.MonitorInterface{
 				AgentID: client.agent.ID,
@@ -895,6 +1554,55 @@ func (s *Service) fetchSystemInfo(client *Client) error {
 	return nil
 }
 
+// fetchProcesses stores the agent's current top processes, so they can be shown while
+// the agent is offline
+func (s *Service) fetchProcesses(client *Client) error {
+	// Counted from the attempt, so a failing agent isn't asked again every collection
+	client.mu.Lock()
+	client.lastProcessPoll = time.Now()
+	client.mu.Unlock()
+
+	processesURL := strings.TrimRight(client.baseURL(), "/") + "/system/processes"
+
+	req, err := http.NewRequestWithContext(client.ctx, "GET", processesURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if _, err := authorize(client.ctx, req, client.agent, client.tokens); err != nil {
+		return err
+	}
+
+	httpClient := AgentHTTPClient(client.agent, 30*time.Second)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch processes: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &httpStatusError{StatusCode: resp.StatusCode, URL: processesURL}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProcessSnapshotSize))
+	if err != nil {
+		return fmt.Errorf("failed to read processes: %w", err)
+	}
+	if !json.Valid(body) {
+		return fmt.Errorf("invalid processes response from agent")
+	}
+
+	snapshot := &types.MonitorProcessSnapshot{
+		AgentID:       client.agent.ID,
+		ProcessesJSON: string(body),
+		CreatedAt:     time.Now(),
+	}
+	if err := s.db.SaveMonitorProcessSnapshot(client.ctx, snapshot); err != nil {
+		return fmt.Errorf("failed to store process snapshot: %w", err)
+	}
+	return nil
+}
+
 // fetchHardwareStats fetches and stores hardware statistics from an agent
 func (s *Service) fetchHardwareStats(client *Client) error {
 	hardwareURL := strings.TrimRight(client.baseURL(), "/") + "/system/hardware"
@@ -904,11 +1612,11 @@ func (s *Service) fetchHardwareStats(client *Client) error {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	if client.agent.APIKey != nil && *client.agent.APIKey != "" {
-		req.Header.Set("X-API-Key", *client.agent.APIKey)
+	if _, err := authorize(client.ctx, req, client.agent, client.tokens); err != nil {
+		return err
 	}
 
-	httpClient := &http.Client{Timeout: 30 * time.Second}
+	httpClient := AgentHTTPClient(client.agent, 30*time.Second)
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to fetch hardware stats: %w", err)
@@ -921,13 +1629,17 @@ func (s *Service) fetchHardwareStats(client *Client) error {
 
 	var hardwareStats struct {
 		CPU struct {
-			UsagePercent float64 `json:"usage_percent"`
-			Model        string  `json:"model"`
-			Cores        int     `json:"cores"`
-			Threads      int     `json:"threads"`
+			UsagePercent float64   `json:"usage_percent"`
+			Model        string    `json:"model"`
+			Cores        int       `json:"cores"`
+			Threads      int       `json:"threads"`
+			PerCore      []float64 `json:"per_core"`
 		} `json:"cpu"`
 		Memory struct {
+			Total       uint64  `json:"total"`
 			UsedPercent float64 `json:"used_percent"`
+			SwapTotal   uint64  `json:"swap_total"`
+			SwapUsed    uint64  `json:"swap_used"`
 			SwapPercent float64 `json:"swap_percent"`
 		} `json:"memory"`
 		Disks []struct {
@@ -944,28 +1656,59 @@ func (s *Service) fetchHardwareStats(client *Client) error {
 			Temperature float64 `json:"temperature"`
 			Label       string  `json:"label"`
 		} `json:"temperature"`
+		Uptime int64 `json:"uptime"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&hardwareStats); err != nil {
 		return fmt.Errorf("failed to decode hardware stats: %w", err)
 	}
 
-	// Update system info with CPU details
-	if hardwareStats.CPU.Model != "" {
+	// Update system info with CPU details and total memory. vnstat does not always
+	// report memory, so this keeps the cached hardware view able to convert percentages.
+	totalMemory := validMemoryBytes(hardwareStats.Memory.Total)
+	if hardwareStats.CPU.Model != "" || totalMemory > 0 {
 		sysInfo := &types.MonitorSystemInfo{
-			AgentID:    client.agent.ID,
-			CPUModel:   hardwareStats.CPU.Model,
-			CPUCores:   hardwareStats.CPU.Cores,
-			CPUThreads: hardwareStats.CPU.Threads,
+			AgentID:     client.agent.ID,
+			CPUModel:    hardwareStats.CPU.Model,
+			CPUCores:    hardwareStats.CPU.Cores,
+			CPUThreads:  hardwareStats.CPU.Threads,
+			TotalMemory: totalMemory,
 		}
 		if err := s.db.UpsertMonitorSystemInfo(client.ctx, client.agent.ID, sysInfo); err != nil {
-			log.Warn().Err(err).Msg("Failed to update CPU info")
+			log.Warn().Err(err).Msg("Failed to update CPU and memory info")
 		}
 	}
 
+	client.mu.Lock()
+	client.lastCPUPercent = hardwareStats.CPU.UsagePercent
+	client.lastCPUAt = time.Now()
+	// Older agents only report uptime in system info
+	uptime := hardwareStats.Uptime
+	if uptime <= 0 {
+		uptime = client.systemUptime
+	}
+	client.mu.Unlock()
+
+	s.seedUptime(client)
+	rebooted := client.observeUptime(uptime)
+
 	// Store resource stats
 	diskJSON, _ := json.Marshal(hardwareStats.Disks)
 	tempJSON, _ := json.Marshal(hardwareStats.Temperature)
+	// Older agents don't report per-core usage; leave the column empty rather than storing "null"
+	var perCoreJSON string
+	if len(hardwareStats.CPU.PerCore) > 0 {
+		data, _ := json.Marshal(hardwareStats.CPU.PerCore)
+		perCoreJSON = string(data)
+	}
+
+	// Highest disk usage is stored alongside the JSON for aggregation and used for notifications
+	var highestDiskUsage float64
+	for _, disk := range hardwareStats.Disks {
+		if disk.UsedPercent > highestDiskUsage {
+			highestDiskUsage = disk.UsedPercent
+		}
+	}
 
 	stats := &types.MonitorResourceStats{
 		AgentID:           client.agent.ID,
@@ -974,62 +1717,92 @@ func (s *Service) fetchHardwareStats(client *Client) error {
 		SwapUsedPercent:   hardwareStats.Memory.SwapPercent,
 		DiskUsageJSON:     string(diskJSON),
 		TemperatureJSON:   string(tempJSON),
-		UptimeSeconds:     0, // Will be set from system info
+		UptimeSeconds:     uptime,
+		DiskUsedPercent:   highestDiskUsage,
+		SwapTotal:         validMemoryBytes(hardwareStats.Memory.SwapTotal),
+		SwapUsed:          validMemoryBytes(hardwareStats.Memory.SwapUsed),
+		CPUPerCoreJSON:    perCoreJSON,
 	}
 
 	if err := s.db.SaveMonitorResourceStats(client.ctx, client.agent.ID, stats); err != nil {
 		return fmt.Errorf("failed to store resource stats: %w", err)
 	}
+	stats.CreatedAt = time.Now()
+	client.mu.Lock()
+	client.lastResourceStats = stats
+	client.mu.Unlock()
 
 	// Check thresholds and send notifications if needed
-	if client.notifier != nil {
-		// Rate limit notifications to once per hour per type
-		notificationCooldown := 1 * time.Hour
+	if client.notifier != nil && !client.inMaintenance() {
+		// Rate limit notifications per type
 		now := time.Now()
 
-		// Check CPU usage threshold
-		// The notification service will check if CPU exceeds the configured threshold
-		if hardwareStats.CPU.UsagePercent > 0 && now.Sub(client.lastCPUNotificationTime) > notificationCooldown {
+		// A reboot is a one-off event, so it isn't rate limited
+		if rebooted {
+			uptimeSeconds := float64(uptime)
 			if err := client.notifier.SendAgentNotification(
+				client.agent.Name,
+				client.agent.Tags,
+				database.NotificationEventAgentRebooted,
+				&uptimeSeconds,
+			); err != nil {
+				notifyErrorLog(err).Msg("Failed to send agent rebooted notification")
+			}
+		}
+
+		// Check CPU usage threshold
+		// The agent's own threshold wins; otherwise the notification service checks the rule threshold
+		if hardwareStats.CPU.UsagePercent > 0 && now.Sub(client.lastCPUNotificationTime) > client.cooldownFor(database.NotificationEventAgentHighCPU) {
+			if sent, err := client.notifyResource(
 				client.agent.Name,
 				database.NotificationEventAgentHighCPU,
-				&hardwareStats.CPU.UsagePercent,
+				hardwareStats.CPU.UsagePercent,
+				client.agent.CPUThreshold,
 			); err != nil {
-				log.Error().Err(err).Msg("Failed to send high CPU notification")
-			} else {
+				notifyErrorLog(err).Msg("Failed to send high CPU notification")
+			} else if sent {
 				client.lastCPUNotificationTime = now
 			}
 		}
 
 		// Check memory usage threshold
-		if hardwareStats.Memory.UsedPercent > 0 && now.Sub(client.lastMemoryNotificationTime) > notificationCooldown {
-			if err := client.notifier.SendAgentNotification(
+		if hardwareStats.Memory.UsedPercent > 0 && now.Sub(client.lastMemoryNotificationTime) > client.cooldownFor(database.NotificationEventAgentHighMemory) {
+			if sent, err := client.notifyResource(
 				client.agent.Name,
 				database.NotificationEventAgentHighMemory,
-				&hardwareStats.Memory.UsedPercent,
+				hardwareStats.Memory.UsedPercent,
+				client.agent.MemoryThreshold,
 			); err != nil {
-				log.Error().Err(err).Msg("Failed to send high memory notification")
-			} else {
+				notifyErrorLog(err).Msg("Failed to send high memory notification")
+			} else if sent {
 				client.lastMemoryNotificationTime = now
 			}
 		}
 
-		// Check disk usage thresholds - find highest usage
-		var highestDiskUsage float64
-		for _, disk := range hardwareStats.Disks {
-			if disk.UsedPercent > highestDiskUsage {
-				highestDiskUsage = disk.UsedPercent
+		// Check swap usage threshold - heavy swapping signals memory pressure even when RAM% looks fine
+		if hardwareStats.Memory.SwapPercent > 0 && now.Sub(client.lastSwapNotificationTime) > client.cooldownFor(database.NotificationEventAgentHighSwap) {
+			if err := client.notifier.SendAgentNotification(
+				client.agent.Name,
+				client.agent.Tags,
+				database.NotificationEventAgentHighSwap,
+				&hardwareStats.Memory.SwapPercent,
+			); err != nil {
+				notifyErrorLog(err).Msg("Failed to send high swap notification")
+			} else {
+				client.lastSwapNotificationTime = now
 			}
 		}
 
-		if highestDiskUsage > 0 && now.Sub(client.lastDiskNotificationTime) > notificationCooldown {
-			if err := client.notifier.SendAgentNotification(
+		// Check disk usage thresholds against the highest usage
+		if highestDiskUsage > 0 && now.Sub(client.lastDiskNotificationTime) > client.cooldownFor(database.NotificationEventAgentLowDisk) {
+			if sent, err := client.notifyResource(
 				client.agent.Name,
 				database.NotificationEventAgentLowDisk,
-				&highestDiskUsage,
+				highestDiskUsage,
+				client.agent.DiskThreshold,
 			); err != nil {
-				log.Error().Err(err).Msg("Failed to send low disk notification")
-			} else {
+				notifyErrorLog(err).Msg("Failed to send low disk notification")
+			} else if sent {
 				client.lastDiskNotificationTime = now
 			}
 		}
@@ -1059,7 +1832,7 @@ func (s *Service) fetchHardwareStats(client *Client) error {
 			}
 		}
 
-		if highestTemp > 0 && now.Sub(client.lastTempNotificationTime) > notificationCooldown {
+		if highestTemp > 0 && now.Sub(client.lastTempNotificationTime) > client.cooldownFor(database.NotificationEventAgentHighTemp) {
 			// Build sensor info for notification
 			sensorInfo := highestTempSensor
 			if highestTempLabel != "" {
@@ -1092,13 +1865,14 @@ func (s *Service) fetchHardwareStats(client *Client) error {
 				Msg("Sending temperature notification with sensor details")
 
 			// Send notification with sensor info embedded in agent name
-			if err := client.notifier.SendAgentNotification(
+			if sent, err := client.notifyResource(
 				agentNameWithSensor,
 				database.NotificationEventAgentHighTemp,
-				&highestTemp,
+				highestTemp,
+				client.agent.TempThreshold,
 			); err != nil {
-				log.Error().Err(err).Msg("Failed to send high temperature notification")
-			} else {
+				notifyErrorLog(err).Msg("Failed to send high temperature notification")
+			} else if sent {
 				client.lastTempNotificationTime = now
 				log.Info().
 					Str("agent", client.agent.Name).
@@ -1130,7 +1904,7 @@ func (s *Service) collectHistoricalSnapshots() {
 
 // fetchAndStoreHistoricalData fetches and stores vnstat historical data from an agent
 func (s *Service) fetchAndStoreHistoricalData(client *Client) {
-	baseURL := strings.TrimSuffix(client.agent.URL, "/events?stream=live-data")
+	baseURL := client.baseURL()
 	historicalURL := baseURL + "/export/historical"
 
 	req, err := http.NewRequestWithContext(client.ctx, "GET", historicalURL, nil)
@@ -1139,11 +1913,12 @@ func (s *Service) fetchAndStoreHistoricalData(client *Client) {
 		return
 	}
 
-	if client.agent.APIKey != nil && *client.agent.APIKey != "" {
-		req.Header.Set("X-API-Key", *client.agent.APIKey)
+	if _, err := authorize(client.ctx, req, client.agent, client.tokens); err != nil {
+		log.Error().Err(err).Int64("agent_id", client.agent.ID).Msg("Failed to authorize historical request")
+		return
 	}
 
-	httpClient := &http.Client{Timeout: 60 * time.Second}
+	httpClient := AgentHTTPClient(client.agent, 60*time.Second)
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		log.Error().Err(err).Int64("agent_id", client.agent.ID).Msg("Failed to fetch historical data")
@@ -1163,6 +1938,20 @@ func (s *Service) fetchAndStoreHistoricalData(client *Client) {
 		return
 	}
 
+	// Drop filtered interfaces before anything is stored
+	if all, ok := vnstatData["interfaces"].([]interface{}); ok {
+		kept := all[:0]
+		for _, ifaceData := range all {
+			if iface, ok := ifaceData.(map[string]interface{}); ok {
+				if name, _ := iface["name"].(string); !client.interfaceFilter.Allow(name) {
+					continue
+				}
+			}
+			kept = append(kept, ifaceData)
+		}
+		vnstatData["interfaces"] = kept
+	}
+
 	// First, save the complete vnstat data snapshot
 	vnstatJSON, err := json.Marshal(vnstatData)
 	if err == nil {
@@ -1270,7 +2059,7 @@ func (s *Service) fetchAndStoreHistoricalData(client *Client) {
 
 		// Also check for and update total memory if available from vnstat data
 		if created, ok := iface["created"].(map[string]interface{}); ok {
-			if memory, ok := created["memory"].(float64); ok && memory > 0 {
+			if memory, ok := created["memory"].(float64); ok && memory > 0 && memory < math.MaxInt64/(1024*1024) {
 				// Convert MB to bytes
 				totalMemory := int64(memory * 1024 * 1024)
 				sysInfo := &types.MonitorSystemInfo{
@@ -1287,9 +2076,18 @@ func (s *Service) fetchAndStoreHistoricalData(client *Client) {
 	log.Debug().Int64("agent_id", client.agent.ID).Msg("Successfully collected historical snapshots")
 }
 
+// validMemoryBytes converts a reported memory or swap size in bytes, returning 0 for values
+// that can't be real
+func validMemoryBytes(size uint64) int64 {
+	if size == 0 || size > math.MaxInt64 {
+		return 0
+	}
+	return int64(size)
+}
+
 // fetchInitialPeakStats fetches and stores initial peak bandwidth statistics from an agent
 func (c *Client) fetchInitialPeakStats() {
-	baseURL := strings.TrimSuffix(c.agent.URL, "/events?stream=live-data")
+	baseURL := c.baseURL()
 	peaksURL := baseURL + "/stats/peaks"
 
 	req, err := http.NewRequestWithContext(c.ctx, "GET", peaksURL, nil)
@@ -1298,11 +2096,12 @@ func (c *Client) fetchInitialPeakStats() {
 		return
 	}
 
-	if c.agent.APIKey != nil && *c.agent.APIKey != "" {
-		req.Header.Set("X-API-Key", *c.agent.APIKey)
+	if _, err := authorize(c.ctx, req, c.agent, c.tokens); err != nil {
+		log.Warn().Err(err).Int64("agent_id", c.agent.ID).Msg("Failed to authorize peaks request")
+		return
 	}
 
-	httpClient := &http.Client{Timeout: 30 * time.Second}
+	httpClient := AgentHTTPClient(c.agent, 30*time.Second)
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		log.Warn().Err(err).Int64("agent_id", c.agent.ID).Msg("Failed to fetch peak stats")
@@ -0,0 +1,550 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/notifications"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestServiceAtAgentLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxAgents int
+		running   int
+		want      bool
+	}{
+		{name: "unlimited", maxAgents: 0, running: 500, want: false},
+		{name: "negative treated as unlimited", maxAgents: -1, running: 10, want: false},
+		{name: "below limit", maxAgents: 3, running: 2, want: false},
+		{name: "at limit", maxAgents: 3, running: 3, want: true},
+		{name: "above limit", maxAgents: 3, running: 4, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{
+				config:  &config.MonitorConfig{MaxAgents: tt.maxAgents},
+				clients: make(map[int64]*Client),
+			}
+			for i := 0; i < tt.running; i++ {
+				s.clients[int64(i)] = &Client{}
+			}
+
+			if got := s.atAgentLimit(); got != tt.want {
+				t.Fatalf("atAgentLimit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceReloadMaxAgents(t *testing.T) {
+	startup := &config.MonitorConfig{Enabled: true, MaxAgents: 2}
+	s := &Service{
+		config:  startup,
+		clients: map[int64]*Client{1: {}, 2: {}},
+	}
+	if !s.atAgentLimit() {
+		t.Fatal("expected limit to be reached before reload")
+	}
+
+	s.Reload(&config.Config{Monitor: config.MonitorConfig{Enabled: false, MaxAgents: 5}})
+
+	if s.atAgentLimit() {
+		t.Fatal("expected raised limit to allow more agents after reload")
+	}
+	if !s.config.Enabled {
+		t.Fatal("Reload must not change monitor.enabled")
+	}
+	if startup.MaxAgents != 2 {
+		t.Fatalf("Reload mutated the startup config: max_agents = %d", startup.MaxAgents)
+	}
+}
+
+func TestServiceReloadNotificationSettings(t *testing.T) {
+	client := &Client{}
+	client.applyNotificationSettings(&config.MonitorConfig{
+		PerInterfaceThresholds: map[string]float64{"eth0": 100},
+		NotificationCooldown:   "30m",
+	})
+	s := &Service{
+		config:  &config.MonitorConfig{Enabled: true},
+		clients: map[int64]*Client{1: client},
+	}
+
+	s.Reload(&config.Config{Monitor: config.MonitorConfig{
+		PerInterfaceThresholds: map[string]float64{"eth0": 250, "eth1": 50},
+		NotificationCooldown:   "5m",
+	}})
+
+	if limit, ok := client.interfaceThreshold("eth0"); !ok || limit != 250 {
+		t.Errorf("eth0 threshold = %v (set %v), want 250", limit, ok)
+	}
+	if limit, ok := client.interfaceThreshold("eth1"); !ok || limit != 50 {
+		t.Errorf("eth1 threshold = %v (set %v), want 50", limit, ok)
+	}
+	if got := client.cooldownFor(database.NotificationEventAgentHighCPU); got != 5*time.Minute {
+		t.Errorf("cooldown = %v, want 5m", got)
+	}
+}
+
+func TestServiceGetAgentStatuses(t *testing.T) {
+	liveData := &types.MonitorLiveData{}
+	s := &Service{
+		clients: map[int64]*Client{
+			1: {connected: true, lastData: liveData},
+			2: {maintenance: true},
+		},
+	}
+
+	statuses := s.GetAgentStatuses()
+	if len(statuses) != 2 {
+		t.Fatalf("GetAgentStatuses() returned %d agents, want 2", len(statuses))
+	}
+	if got := statuses[1]; !got.Connected || got.Maintenance || got.LiveData != liveData {
+		t.Errorf("agent 1 status = %+v, want connected with live data", got)
+	}
+	if got := statuses[2]; got.Connected || !got.Maintenance || got.LiveData != nil {
+		t.Errorf("agent 2 status = %+v, want disconnected in maintenance", got)
+	}
+}
+
+func TestServiceRefreshAgentErrors(t *testing.T) {
+	busy := &Client{connected: true}
+	busy.refreshing.Store(true)
+	s := &Service{
+		clients: map[int64]*Client{
+			1: {},
+			2: busy,
+		},
+	}
+
+	if _, err := s.RefreshAgent(3); !errors.Is(err, ErrAgentNotMonitored) {
+		t.Errorf("RefreshAgent(unknown) error = %v, want ErrAgentNotMonitored", err)
+	}
+	if _, err := s.RefreshAgent(1); !errors.Is(err, ErrAgentNotConnected) {
+		t.Errorf("RefreshAgent(disconnected) error = %v, want ErrAgentNotConnected", err)
+	}
+	if _, err := s.RefreshAgent(2); !errors.Is(err, ErrRefreshInProgress) {
+		t.Errorf("RefreshAgent(refreshing) error = %v, want ErrRefreshInProgress", err)
+	}
+	// A rejected call must not clear the flag of the refresh that is running
+	if !busy.refreshing.Load() {
+		t.Error("overlapping refresh cleared the running refresh's flag")
+	}
+}
+
+func TestIsLocalAgentURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{url: "http://localhost:8200/events?stream=live-data", want: true},
+		{url: "http://LOCALHOST:8200", want: true},
+		{url: "http://127.0.0.1:8200/events?stream=live-data", want: true},
+		{url: "http://[::1]:8200", want: true},
+		{url: "http://192.168.1.10:8200", want: false},
+		{url: "http://agent.tailnet.ts.net:8200", want: false},
+		{url: "://bad", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := isLocalAgentURL(tt.url); got != tt.want {
+				t.Fatalf("isLocalAgentURL(%q) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCollectorInterval(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: time.Hour},
+		{value: "5m", want: 5 * time.Minute},
+		{value: "soon", want: time.Hour},
+		{value: "0s", want: time.Hour},
+		{value: "-1m", want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseCollectorInterval("monitor.snapshot_interval", tt.value, time.Hour); got != tt.want {
+				t.Fatalf("parseCollectorInterval(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetention(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: time.Hour},
+		{value: "720h", want: 720 * time.Hour},
+		{value: "0", want: 0},
+		{value: "0s", want: 0},
+		{value: "-1h", want: time.Hour},
+		{value: "a year", want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseRetention("monitor.retention.snapshots", tt.value, time.Hour); got != tt.want {
+				t.Fatalf("parseRetention(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceLocalNetworkConditions(t *testing.T) {
+	live := &types.MonitorLiveData{}
+	live.Rx.Bytespersecond = 1000
+	live.Tx.Bytespersecond = 250
+
+	s := &Service{
+		clients: map[int64]*Client{
+			1: {
+				agent:     &types.MonitorAgent{ID: 1, URL: "http://10.0.0.5:8200/events?stream=live-data"},
+				connected: true,
+				lastData:  live,
+			},
+			2: {
+				agent:          &types.MonitorAgent{ID: 2, URL: "http://127.0.0.1:8200/events?stream=live-data"},
+				connected:      true,
+				lastData:       live,
+				lastCPUPercent: 42.5,
+				lastCPUAt:      time.Now(),
+			},
+		},
+	}
+
+	conditions := s.LocalNetworkConditions()
+	if conditions == nil {
+		t.Fatal("expected conditions from local agent, got nil")
+	}
+	if conditions.RxBytesPerSecond != 1000 || conditions.TxBytesPerSecond != 250 {
+		t.Fatalf("unexpected bandwidth: %+v", conditions)
+	}
+	if conditions.CPUUsagePercent == nil || *conditions.CPUUsagePercent != 42.5 {
+		t.Fatalf("expected CPU 42.5, got %v", conditions.CPUUsagePercent)
+	}
+
+	s.clients[2].lastCPUAt = time.Now().Add(-10 * time.Minute)
+	if conditions := s.LocalNetworkConditions(); conditions == nil || conditions.CPUUsagePercent != nil {
+		t.Fatalf("expected stale CPU sample to be dropped, got %+v", conditions)
+	}
+
+	delete(s.clients, 2)
+	if conditions := s.LocalNetworkConditions(); conditions != nil {
+		t.Fatalf("expected nil without a local agent, got %+v", conditions)
+	}
+}
+
+type bandwidthCall struct {
+	agentName string
+	iface     string
+	mbps      float64
+	threshold float64
+}
+
+type thresholdCall struct {
+	eventType string
+	value     float64
+	threshold float64
+}
+
+// recordingNotifier records the notifications sent by a client
+type recordingNotifier struct {
+	generic   []string
+	perIface  []bandwidthCall
+	perAgent  []thresholdCall
+	tags      []string                 // agent tags of the last notification
+	cooldowns map[string]time.Duration // rule cooldowns by event type
+	err       error                    // returned by the send methods
+}
+
+func (n *recordingNotifier) SendAgentNotification(agentName string, tags []string, eventType string, value *float64) error {
+	n.generic = append(n.generic, agentName)
+	n.tags = tags
+	return n.err
+}
+
+func (n *recordingNotifier) SendAgentBandwidthNotification(agentName string, tags []string, iface string, mbps, threshold float64) error {
+	n.tags = tags
+	n.perIface = append(n.perIface, bandwidthCall{agentName, iface, mbps, threshold})
+	return n.err
+}
+
+func (n *recordingNotifier) SendAgentThresholdNotification(agentName string, tags []string, eventType string, value, threshold float64) error {
+	n.tags = tags
+	n.perAgent = append(n.perAgent, thresholdCall{eventType, value, threshold})
+	return nil
+}
+
+func (n *recordingNotifier) GetAgentCooldown(eventType string, tags []string) (time.Duration, bool) {
+	n.tags = tags
+	cooldown, ok := n.cooldowns[eventType]
+	return cooldown, ok
+}
+
+func TestCooldownFor(t *testing.T) {
+	notifier := &recordingNotifier{cooldowns: map[string]time.Duration{
+		database.NotificationEventAgentHighCPU: 5 * time.Minute,
+	}}
+	client := &Client{agent: &types.MonitorAgent{Name: "edge", Tags: []string{"prod"}}, notifier: notifier, notificationCooldown: 20 * time.Minute}
+
+	if got := client.cooldownFor(database.NotificationEventAgentHighCPU); got != 5*time.Minute {
+		t.Errorf("rule cooldown = %v, want 5m", got)
+	}
+	if len(notifier.tags) != 1 || notifier.tags[0] != "prod" {
+		t.Errorf("cooldown tags = %v, want the agent's", notifier.tags)
+	}
+	if got := client.cooldownFor(database.NotificationEventAgentHighMemory); got != 20*time.Minute {
+		t.Errorf("global cooldown = %v, want 20m", got)
+	}
+
+	client.notificationCooldown = 0
+	if got := client.cooldownFor(database.NotificationEventAgentHighMemory); got != defaultNotificationCooldown {
+		t.Errorf("unset cooldown = %v, want %v", got, defaultNotificationCooldown)
+	}
+}
+
+func TestNotifyResource(t *testing.T) {
+	threshold := 80.0
+	notifier := &recordingNotifier{}
+	client := &Client{agent: &types.MonitorAgent{Name: "edge", Tags: []string{"prod"}}, notifier: notifier}
+
+	// Without an agent threshold the rules decide
+	if sent, err := client.notifyResource("edge", database.NotificationEventAgentHighCPU, 50, nil); err != nil || !sent {
+		t.Errorf("rule notification sent = %v, err = %v", sent, err)
+	}
+	if len(notifier.generic) != 1 || len(notifier.perAgent) != 0 {
+		t.Errorf("rule notifications = %v, agent notifications = %+v", notifier.generic, notifier.perAgent)
+	}
+
+	// Under the agent threshold nothing is sent, not even to the rules
+	if sent, err := client.notifyResource("edge", database.NotificationEventAgentHighCPU, 80, &threshold); err != nil || sent {
+		t.Errorf("under threshold sent = %v, err = %v", sent, err)
+	}
+	if len(notifier.generic) != 1 || len(notifier.perAgent) != 0 {
+		t.Errorf("rule notifications = %v, agent notifications = %+v", notifier.generic, notifier.perAgent)
+	}
+
+	if sent, err := client.notifyResource("edge", database.NotificationEventAgentHighCPU, 95, &threshold); err != nil || !sent {
+		t.Errorf("over threshold sent = %v, err = %v", sent, err)
+	}
+	want := thresholdCall{database.NotificationEventAgentHighCPU, 95, 80}
+	if len(notifier.perAgent) != 1 || notifier.perAgent[0] != want {
+		t.Errorf("agent notifications = %+v, want %+v", notifier.perAgent, want)
+	}
+	if len(notifier.tags) != 1 || notifier.tags[0] != "prod" {
+		t.Errorf("notification tags = %v, want the agent's tags", notifier.tags)
+	}
+}
+
+func TestCheckBandwidthThresholdSuppressed(t *testing.T) {
+	notifier := &recordingNotifier{err: notifications.ErrSuppressed}
+	client := &Client{
+		agent:               &types.MonitorAgent{Name: "edge"},
+		notifier:            notifier,
+		interfaceThresholds: map[string]float64{"eth0": 100},
+	}
+	live := &types.MonitorLiveData{}
+	live.Rx.Bytespersecond = 25_000_000 // 200 Mbps
+
+	// An alert held back by quiet hours doesn't start the cooldown, so it's tried again
+	client.checkBandwidthThreshold("eth0", live)
+	client.checkBandwidthThreshold("eth0", live)
+	if len(notifier.perIface) != 2 {
+		t.Fatalf("suppressed notifications = %d, want 2", len(notifier.perIface))
+	}
+
+	notifier.err = nil
+	client.checkBandwidthThreshold("eth0", live)
+	client.checkBandwidthThreshold("eth0", live)
+	if len(notifier.perIface) != 3 {
+		t.Errorf("notifications = %d, want 3 since a sent alert starts the cooldown", len(notifier.perIface))
+	}
+}
+
+func TestObserveUptime(t *testing.T) {
+	client := &Client{}
+
+	steps := []struct {
+		uptime   int64
+		rebooted bool
+	}{
+		{uptime: 3600},               // first collection
+		{uptime: 3660},               // still up
+		{uptime: 0},                  // unknown uptime is ignored
+		{uptime: 3720},               // compared with the last known uptime
+		{uptime: 45, rebooted: true}, // went backwards
+		{uptime: 105},                // up since the reboot
+		{uptime: 10, rebooted: true}, // rebooted again
+	}
+
+	for i, step := range steps {
+		if got := client.observeUptime(step.uptime); got != step.rebooted {
+			t.Errorf("step %d: observeUptime(%d) = %v, want %v", i, step.uptime, got, step.rebooted)
+		}
+	}
+}
+
+// uptimeDB returns stored resource stats with a fixed uptime
+type uptimeDB struct {
+	database.Service
+	uptime int64
+	calls  int
+}
+
+func (d *uptimeDB) GetMonitorResourceStats(ctx context.Context, agentID int64, hours int) ([]types.MonitorResourceStats, error) {
+	d.calls++
+	return []types.MonitorResourceStats{{AgentID: agentID, UptimeSeconds: d.uptime}}, nil
+}
+
+func TestSeedUptime(t *testing.T) {
+	db := &uptimeDB{uptime: 86400}
+	s := &Service{db: db}
+	client := &Client{agent: &types.MonitorAgent{ID: 1}, ctx: context.Background()}
+
+	// The first collection after a restart is compared with the stored uptime
+	s.seedUptime(client)
+	if !client.observeUptime(120) {
+		t.Error("reboot while the server was down not detected")
+	}
+
+	// Stored stats are only loaded once
+	s.seedUptime(client)
+	if db.calls != 1 {
+		t.Errorf("stored stats loaded %d times, want 1", db.calls)
+	}
+	if client.observeUptime(180) {
+		t.Error("reboot detected without the uptime going backwards")
+	}
+}
+
+// peakStatsDB discards the peak stats written by processData
+type peakStatsDB struct {
+	database.Service
+}
+
+func (peakStatsDB) UpsertMonitorPeakStats(ctx context.Context, agentID int64, stats *types.MonitorPeakStats) error {
+	return nil
+}
+
+func TestProcessDataInterfaceThresholds(t *testing.T) {
+	// 100 Mbps rx + 25 Mbps tx
+	sample := func(iface string) string {
+		return `{"index":1,"seconds":1,"interface":"` + iface + `","rx":{"bytespersecond":12500000},"tx":{"bytespersecond":3125000}}`
+	}
+	stored := "eth0"
+
+	tests := []struct {
+		name         string
+		data         string
+		agentIface   *string
+		wantPerIface []bandwidthCall
+		wantGeneric  []string
+	}{
+		{
+			name:         "tagged interface over its threshold",
+			data:         sample("eth1"),
+			wantPerIface: []bandwidthCall{{"edge", "eth1", 125, 100}},
+		},
+		{
+			name: "tagged interface under its threshold",
+			data: sample("eth2"),
+		},
+		{
+			name:        "interface without threshold falls back to the rule",
+			data:        sample("wlan0"),
+			wantGeneric: []string{"edge|wlan0"},
+		},
+		{
+			name:         "untagged sample uses the stored agent interface",
+			data:         sample(""),
+			agentIface:   &stored,
+			wantPerIface: []bandwidthCall{{"edge", "eth0", 125, 50}},
+		},
+		{
+			name:        "unknown interface uses the rule",
+			data:        sample(""),
+			wantGeneric: []string{"edge"},
+		},
+		{
+			name: "each interface of a combined sample is checked",
+			data: `{"index":1,"seconds":1,"rx":{"bytespersecond":25000000},"tx":{"bytespersecond":6250000},"interfaces":{` +
+				`"eth1":{"index":1,"seconds":1,"interface":"eth1","rx":{"bytespersecond":12500000},"tx":{"bytespersecond":3125000}},` +
+				`"wlan0":{"index":1,"seconds":1,"interface":"wlan0","rx":{"bytespersecond":12500000},"tx":{"bytespersecond":3125000}}}}`,
+			wantPerIface: []bandwidthCall{{"edge", "eth1", 125, 100}},
+			wantGeneric:  []string{"edge|wlan0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &recordingNotifier{}
+			c := &Client{
+				agent:               &types.MonitorAgent{ID: 1, Name: "edge", Interface: tt.agentIface},
+				broadcastFunc:       func(types.MonitorUpdate) {},
+				notifier:            notifier,
+				db:                  peakStatsDB{},
+				interfaceThresholds: map[string]float64{"eth0": 50, "eth1": 100, "eth2": 500},
+			}
+
+			c.processData(tt.data)
+
+			if len(notifier.perIface) != len(tt.wantPerIface) {
+				t.Fatalf("per-interface notifications = %+v, want %+v", notifier.perIface, tt.wantPerIface)
+			}
+			for i, want := range tt.wantPerIface {
+				if notifier.perIface[i] != want {
+					t.Fatalf("per-interface notification = %+v, want %+v", notifier.perIface[i], want)
+				}
+			}
+			if len(notifier.generic) != len(tt.wantGeneric) {
+				t.Fatalf("rule notifications = %v, want %v", notifier.generic, tt.wantGeneric)
+			}
+			for i, want := range tt.wantGeneric {
+				if notifier.generic[i] != want {
+					t.Fatalf("rule notification agent = %q, want %q", notifier.generic[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidMemoryBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		size uint64
+		want int64
+	}{
+		{name: "unknown", size: 0, want: 0},
+		{name: "16 GiB", size: 16 << 30, want: 16 << 30},
+		{name: "largest int64", size: math.MaxInt64, want: math.MaxInt64},
+		{name: "overflows int64", size: math.MaxInt64 + 1, want: 0},
+		{name: "max uint64", size: math.MaxUint64, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validMemoryBytes(tt.size); got != tt.want {
+				t.Errorf("validMemoryBytes(%d) = %d, want %d", tt.size, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// MaxAgentHeaders bounds the custom headers sent to one agent
+const MaxAgentHeaders = 20
+
+// reservedAgentHeaders are managed by the HTTP client and can't be set per agent
+var reservedAgentHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+	"Keep-Alive":        true,
+	"Te":                true,
+	"Trailer":           true,
+	"Upgrade":           true,
+}
+
+// secretHeaderHints mark header names whose values are credentials
+var secretHeaderHints = []string{"auth", "token", "secret", "key", "password", "cookie", "session", "credential"}
+
+// NormalizeAgentHeaders canonicalizes the names of the custom headers sent to an agent
+// and checks that names and values are valid. The result is nil when there are none.
+func NormalizeAgentHeaders(headers map[string]string) (map[string]string, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	if len(headers) > MaxAgentHeaders {
+		return nil, fmt.Errorf("at most %d headers are allowed, got %d", MaxAgentHeaders, len(headers))
+	}
+
+	normalized := make(map[string]string, len(headers))
+	for name, value := range headers {
+		name = strings.TrimSpace(name)
+		if !httpguts.ValidHeaderFieldName(name) {
+			return nil, fmt.Errorf("invalid header name %q", name)
+		}
+		name = http.CanonicalHeaderKey(name)
+		if reservedAgentHeaders[name] {
+			return nil, fmt.Errorf("header %q can't be set", name)
+		}
+		if _, ok := normalized[name]; ok {
+			return nil, fmt.Errorf("header %q is set more than once", name)
+		}
+		value = strings.TrimSpace(value)
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return nil, fmt.Errorf("invalid value for header %q", name)
+		}
+		normalized[name] = value
+	}
+	return normalized, nil
+}
+
+// IsSecretAgentHeader reports whether a header name looks like it carries a credential,
+// such as Authorization, Cookie or X-Auth-Token
+func IsSecretAgentHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, hint := range secretHeaderHints {
+		if strings.Contains(name, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerTransport adds an agent's custom headers to every request sent through it. Headers
+// the request already has, such as the agent's credentials, are left alone.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestNormalizeAgentHeaders(t *testing.T) {
+	tooMany := make(map[string]string, MaxAgentHeaders+1)
+	for i := range MaxAgentHeaders + 1 {
+		tooMany[fmt.Sprintf("X-Header-%d", i)] = "v"
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "empty", headers: map[string]string{}, want: nil},
+		{name: "canonicalized", headers: map[string]string{" remote-user ": " netronome "}, want: map[string]string{"Remote-User": "netronome"}},
+		{name: "duplicate after canonicalizing", headers: map[string]string{"x-token": "a", "X-Token": "b"}, wantErr: true},
+		{name: "invalid name", headers: map[string]string{"X Token": "a"}, wantErr: true},
+		{name: "newline in value", headers: map[string]string{"X-Token": "a\r\nHost: evil"}, wantErr: true},
+		{name: "reserved", headers: map[string]string{"host": "agent.example.com"}, wantErr: true},
+		{name: "too many", headers: tooMany, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeAgentHeaders(tt.headers)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Fatalf("NormalizeAgentHeaders(%q) = %#v, want %#v", tt.headers, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSecretAgentHeader(t *testing.T) {
+	for _, name := range []string{"Authorization", "Proxy-Authorization", "X-Auth-Token", "Cookie", "X-Api-Key", "Cf-Access-Client-Secret"} {
+		if !IsSecretAgentHeader(name) {
+			t.Errorf("IsSecretAgentHeader(%q) = false, want true", name)
+		}
+	}
+	for _, name := range []string{"Remote-User", "X-Forwarded-Proto", "Accept-Language"} {
+		if IsSecretAgentHeader(name) {
+			t.Errorf("IsSecretAgentHeader(%q) = true, want false", name)
+		}
+	}
+}
+
+func TestAgentHTTPClientHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	agent := &types.MonitorAgent{
+		ID:      1,
+		Headers: map[string]string{"Remote-User": "netronome", "X-Api-Key": "from-headers"},
+	}
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-API-Key", "agent-key")
+
+	resp, err := AgentHTTPClient(agent, 0).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got.Get("Remote-User") != "netronome" {
+		t.Errorf("custom header not sent, got %v", got)
+	}
+	if got.Get("X-API-Key") != "agent-key" {
+		t.Errorf("X-API-Key = %q, the agent's own credentials should win", got.Get("X-API-Key"))
+	}
+	if req.Header.Get("Remote-User") != "" {
+		t.Error("the caller's request was modified")
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// virtualInterfacePrefixes are the container and VM interfaces skipped unless an agent opts in.
+// Host bridges such as br0 or vmbr0 often carry the uplink, so only Docker's br- bridges are listed.
+var virtualInterfacePrefixes = []string{
+	"veth", "docker", "br-", "virbr", "vnet", "tap", "lxc", "cali", "flannel", "cni", "kube-ipvs",
+}
+
+// InterfaceFilter decides which agent interfaces are stored. A nil filter allows everything.
+type InterfaceFilter struct {
+	include        *regexp.Regexp
+	exclude        *regexp.Regexp
+	includeVirtual bool
+	pinned         string
+}
+
+// NewInterfaceFilter builds the interface filter for an agent from its include/exclude patterns
+func NewInterfaceFilter(agent *types.MonitorAgent) (*InterfaceFilter, error) {
+	if agent == nil {
+		return nil, nil
+	}
+
+	f := &InterfaceFilter{includeVirtual: agent.IncludeVirtualInterfaces}
+	if agent.Interface != nil {
+		f.pinned = *agent.Interface
+	}
+
+	var err error
+	if f.include, err = compileInterfacePattern(agent.InterfaceInclude); err != nil {
+		return nil, fmt.Errorf("include pattern: %w", err)
+	}
+	if f.exclude, err = compileInterfacePattern(agent.InterfaceExclude); err != nil {
+		return nil, fmt.Errorf("exclude pattern: %w", err)
+	}
+	return f, nil
+}
+
+// ValidateInterfaceFilter checks that an agent's interface patterns compile
+func ValidateInterfaceFilter(agent *types.MonitorAgent) error {
+	_, err := NewInterfaceFilter(agent)
+	return err
+}
+
+func compileInterfacePattern(pattern *string) (*regexp.Regexp, error) {
+	if pattern == nil || strings.TrimSpace(*pattern) == "" {
+		return nil, nil
+	}
+	return regexp.Compile(*pattern)
+}
+
+// Allow reports whether an interface should be stored. The agent's pinned interface is always
+// allowed, otherwise the exclude pattern wins over the include pattern.
+func (f *InterfaceFilter) Allow(name string) bool {
+	if f == nil || name == "" || name == f.pinned {
+		return true
+	}
+	if f.exclude != nil && f.exclude.MatchString(name) {
+		return false
+	}
+	if f.include != nil {
+		return f.include.MatchString(name)
+	}
+	if !f.includeVirtual && isVirtualInterface(name) {
+		return false
+	}
+	return true
+}
+
+func isVirtualInterface(name string) bool {
+	for _, prefix := range virtualInterfacePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
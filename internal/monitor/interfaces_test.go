@@ -0,0 +1,75 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"testing"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestInterfaceFilterAllow(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name  string
+		agent *types.MonitorAgent
+		allow map[string]bool
+	}{
+		{
+			name:  "default skips virtual interfaces",
+			agent: &types.MonitorAgent{},
+			allow: map[string]bool{"eth0": true, "br0": true, "vmbr0": true, "veth1a2b": false, "docker0": false, "br-3f1e": false},
+		},
+		{
+			name:  "opt out keeps virtual interfaces",
+			agent: &types.MonitorAgent{IncludeVirtualInterfaces: true},
+			allow: map[string]bool{"eth0": true, "veth1a2b": true, "docker0": true},
+		},
+		{
+			name:  "include pattern replaces default",
+			agent: &types.MonitorAgent{InterfaceInclude: str(`^(eth|docker)\d+$`)},
+			allow: map[string]bool{"eth0": true, "docker0": true, "wg0": false, "veth1a2b": false},
+		},
+		{
+			name:  "exclude wins over include",
+			agent: &types.MonitorAgent{InterfaceInclude: str(`^eth`), InterfaceExclude: str(`^eth1$`)},
+			allow: map[string]bool{"eth0": true, "eth1": false},
+		},
+		{
+			name:  "pinned interface always allowed",
+			agent: &types.MonitorAgent{Interface: str("veth0"), InterfaceExclude: str(`^veth`)},
+			allow: map[string]bool{"veth0": true, "veth1": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewInterfaceFilter(tt.agent)
+			if err != nil {
+				t.Fatalf("NewInterfaceFilter() error = %v", err)
+			}
+			for name, want := range tt.allow {
+				if got := f.Allow(name); got != want {
+					t.Errorf("Allow(%q) = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateInterfaceFilter(t *testing.T) {
+	bad := "eth("
+	if err := ValidateInterfaceFilter(&types.MonitorAgent{InterfaceExclude: &bad}); err == nil {
+		t.Fatal("expected error for invalid exclude pattern")
+	}
+	if err := ValidateInterfaceFilter(&types.MonitorAgent{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var nilFilter *InterfaceFilter
+	if !nilFilter.Allow("veth0") {
+		t.Fatal("nil filter should allow every interface")
+	}
+}
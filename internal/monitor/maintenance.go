@@ -0,0 +1,163 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// inMaintenance reports whether the agent last declared maintenance on /netronome/info
+func (c *Client) inMaintenance() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.maintenance
+}
+
+func (c *Client) setMaintenance(enabled bool) {
+	c.mu.Lock()
+	changed := c.maintenance != enabled
+	c.maintenance = enabled
+	c.mu.Unlock()
+
+	if changed {
+		log.Info().
+			Int64("agent_id", c.agent.ID).
+			Str("agent_name", c.agent.Name).
+			Bool("maintenance", enabled).
+			Msg("Agent maintenance state changed")
+	}
+}
+
+// fetchMaintenanceState reads the maintenance flag from the agent's public info endpoint.
+// Agents that predate the flag never report it and are treated as not in maintenance.
+func (s *Service) fetchMaintenanceState(client *Client) error {
+	infoURL := strings.TrimRight(client.baseURL(), "/") + "/netronome/info"
+
+	req, err := http.NewRequestWithContext(client.ctx, "GET", infoURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	SetAgentBasicAuth(req, client.agent)
+
+	resp, err := AgentHTTPClient(client.agent, 10*time.Second).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch agent info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &httpStatusError{StatusCode: resp.StatusCode, URL: infoURL}
+	}
+
+	var info struct {
+		Maintenance bool `json:"maintenance"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return fmt.Errorf("failed to decode agent info: %w", err)
+	}
+
+	client.setMaintenance(info.Maintenance)
+	return nil
+}
+
+// pauseCollectionInMaintenance reports whether resource polling stops for agents in maintenance
+func (s *Service) pauseCollectionInMaintenance() bool {
+	s.clientsMu.RLock()
+	defer s.clientsMu.RUnlock()
+	return s.config != nil && s.config.MaintenancePauseCollection
+}
+
+// AgentInMaintenance reports whether a monitored agent has declared maintenance
+func (s *Service) AgentInMaintenance(agentID int64) bool {
+	s.clientsMu.RLock()
+	client, exists := s.clients[agentID]
+	s.clientsMu.RUnlock()
+
+	return exists && client.inMaintenance()
+}
+
+// pauseCheckInterval is how often a client held back by a global pause checks for resume
+const pauseCheckInterval = 5 * time.Second
+
+// errPaused signals that a live data stream was closed because monitoring was paused
+var errPaused = errors.New("monitoring paused")
+
+// SetPaused pauses or resumes monitoring of every agent. Pausing closes the open live
+// data streams; clients don't reconnect until resumed, and agents going offline send
+// no notification meanwhile.
+func (s *Service) SetPaused(paused bool) {
+	if s.paused.Swap(paused) == paused {
+		return
+	}
+	log.Info().Bool("paused", paused).Msg("Agent monitoring pause state changed")
+
+	if paused {
+		s.clientsMu.RLock()
+		for _, client := range s.clients {
+			client.closeStream()
+		}
+		s.clientsMu.RUnlock()
+	}
+}
+
+// trackStream records the cancel func of the live data stream being opened, so a pause
+// can close it. It returns errPaused when monitoring was paused in the meantime.
+func (c *Client) trackStream(cancel context.CancelFunc) error {
+	c.mu.Lock()
+	c.streamCancel = cancel
+	c.mu.Unlock()
+
+	if c.paused != nil && c.paused() {
+		return errPaused
+	}
+	return nil
+}
+
+// untrackStream forgets the stream once it closed
+func (c *Client) untrackStream() {
+	c.mu.Lock()
+	c.streamCancel = nil
+	c.mu.Unlock()
+}
+
+// closeStream closes the open live data stream, if any
+func (c *Client) closeStream() {
+	c.mu.Lock()
+	cancel := c.streamCancel
+	c.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// streamClosedByPause reports whether the stream ended because closeStream cancelled it
+func (c *Client) streamClosedByPause(streamCtx context.Context) bool {
+	return c.ctx.Err() == nil && errors.Is(streamCtx.Err(), context.Canceled)
+}
+
+// Paused reports whether monitoring is paused globally
+func (s *Service) Paused() bool {
+	return s.paused.Load()
+}
+
+// waitWhilePaused blocks while monitoring is paused, false when the client stops first
+func (c *Client) waitWhilePaused() bool {
+	for c.paused != nil && c.paused() {
+		select {
+		case <-c.ctx.Done():
+			return false
+		case <-time.After(pauseCheckInterval):
+		}
+	}
+	return true
+}
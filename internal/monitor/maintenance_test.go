@@ -0,0 +1,189 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestFetchMaintenanceState(t *testing.T) {
+	maintenance := `{"type":"netronome-agent","maintenance":true}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/netronome/info" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(maintenance))
+	}))
+	defer srv.Close()
+
+	s := &Service{}
+	c := &Client{
+		agent: &types.MonitorAgent{ID: 1, Name: "edge", URL: srv.URL + "/events?stream=live-data"},
+		ctx:   context.Background(),
+	}
+
+	if err := s.fetchMaintenanceState(c); err != nil {
+		t.Fatalf("fetchMaintenanceState: %v", err)
+	}
+	if !c.inMaintenance() {
+		t.Fatal("expected agent to be in maintenance")
+	}
+
+	// Older agents don't report the flag at all
+	maintenance = `{"type":"netronome-agent"}`
+	if err := s.fetchMaintenanceState(c); err != nil {
+		t.Fatalf("fetchMaintenanceState: %v", err)
+	}
+	if c.inMaintenance() {
+		t.Fatal("expected maintenance to clear when the flag is absent")
+	}
+}
+
+func TestProcessDataMaintenanceSuppressesNotifications(t *testing.T) {
+	notifier := &recordingNotifier{}
+	c := &Client{
+		agent:         &types.MonitorAgent{ID: 1, Name: "edge"},
+		broadcastFunc: func(types.MonitorUpdate) {},
+		notifier:      notifier,
+		db:            peakStatsDB{},
+		maintenance:   true,
+	}
+
+	c.processData(`{"index":1,"seconds":1,"rx":{"bytespersecond":12500000},"tx":{"bytespersecond":3125000}}`)
+
+	if len(notifier.generic) != 0 || len(notifier.perIface) != 0 {
+		t.Fatalf("expected no notifications during maintenance, got %v and %+v", notifier.generic, notifier.perIface)
+	}
+}
+
+func TestBroadcastWithNotificationMaintenance(t *testing.T) {
+	notifier := &recordingNotifier{}
+	var broadcasts int
+	s := NewService(nil, &config.MonitorConfig{}, func(types.MonitorUpdate) { broadcasts++ }, notifier)
+	defer s.cancel()
+
+	c := &Client{agent: &types.MonitorAgent{ID: 1, Name: "edge"}, maintenance: true}
+	s.clients[1] = c
+
+	s.broadcastWithNotification(types.MonitorUpdate{AgentID: 1, AgentName: "edge", Connected: false})
+	s.broadcastWithNotification(types.MonitorUpdate{AgentID: 1, AgentName: "edge", Connected: true})
+	if len(notifier.generic) != 0 {
+		t.Fatalf("expected no offline/online notifications during maintenance, got %v", notifier.generic)
+	}
+	if broadcasts != 2 {
+		t.Fatalf("broadcasts = %d, want 2", broadcasts)
+	}
+
+	c.setMaintenance(false)
+	s.broadcastWithNotification(types.MonitorUpdate{AgentID: 1, AgentName: "edge", Connected: false})
+	if len(notifier.generic) != 1 {
+		t.Fatalf("expected an offline notification after maintenance, got %v", notifier.generic)
+	}
+}
+
+func TestBroadcastWithNotificationPaused(t *testing.T) {
+	notifier := &recordingNotifier{}
+	var broadcasts int
+	s := NewService(nil, &config.MonitorConfig{}, func(types.MonitorUpdate) { broadcasts++ }, notifier)
+	defer s.cancel()
+	s.clients[1] = &Client{agent: &types.MonitorAgent{ID: 1, Name: "edge"}}
+
+	s.SetPaused(true)
+	s.broadcastWithNotification(types.MonitorUpdate{AgentID: 1, AgentName: "edge", Connected: false})
+	if len(notifier.generic) != 0 {
+		t.Fatalf("expected no offline notification while paused, got %v", notifier.generic)
+	}
+	if broadcasts != 1 {
+		t.Fatalf("broadcasts = %d, want 1", broadcasts)
+	}
+
+	// The drop wasn't recorded, so reconnecting after resume isn't reported as a recovery
+	s.SetPaused(false)
+	s.broadcastWithNotification(types.MonitorUpdate{AgentID: 1, AgentName: "edge", Connected: true})
+	if len(notifier.generic) != 0 {
+		t.Fatalf("expected no online notification after resume, got %v", notifier.generic)
+	}
+
+	s.broadcastWithNotification(types.MonitorUpdate{AgentID: 1, AgentName: "edge", Connected: false})
+	if len(notifier.generic) != 1 {
+		t.Fatalf("expected an offline notification after resume, got %v", notifier.generic)
+	}
+}
+
+func TestWaitWhilePaused(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Client{ctx: ctx, paused: func() bool { return false }}
+	if !c.waitWhilePaused() {
+		t.Fatal("waitWhilePaused() = false while not paused")
+	}
+
+	c.paused = func() bool { return true }
+	cancel()
+	if c.waitWhilePaused() {
+		t.Fatal("waitWhilePaused() = true for a stopped client")
+	}
+}
+
+func TestSetPausedClosesStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data:{\"index\":1,\"seconds\":1,\"rx\":{\"bytespersecond\":42}}\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	s := NewService(nil, &config.MonitorConfig{}, func(types.MonitorUpdate) {}, nil)
+	defer s.cancel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := &Client{
+		agent:         &types.MonitorAgent{ID: 1, Name: "edge", URL: srv.URL + "/events?stream=live-data"},
+		db:            peakStatsDB{},
+		broadcastFunc: func(types.MonitorUpdate) {},
+		paused:        s.Paused,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+	s.clients[1] = c
+
+	done := make(chan error, 1)
+	go func() { done <- c.connect() }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, data := c.IsConnected(); data != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for live data")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	s.SetPaused(true)
+	select {
+	case err := <-done:
+		if !errors.Is(err, errPaused) {
+			t.Fatalf("connect() = %v, want errPaused", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream stayed open after pausing")
+	}
+
+	// A stream opened while paused is refused straight away
+	if err := c.connect(); !errors.Is(err, errPaused) {
+		t.Fatalf("connect() while paused = %v, want errPaused", err)
+	}
+}
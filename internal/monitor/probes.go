@@ -0,0 +1,234 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// agentProbeTarget is the probe config format understood by the agent
+type agentProbeTarget struct {
+	Host        string `json:"host"`
+	Interval    string `json:"interval,omitempty"`
+	PacketCount int    `json:"packet_count,omitempty"`
+}
+
+// agentProbeResult is a probe result as reported by the agent
+type agentProbeResult struct {
+	Host        string    `json:"host"`
+	PacketLoss  float64   `json:"packet_loss"`
+	MinRTT      float64   `json:"min_rtt"`
+	AvgRTT      float64   `json:"avg_rtt"`
+	MaxRTT      float64   `json:"max_rtt"`
+	StdDevRTT   float64   `json:"std_dev_rtt"`
+	PacketsSent int       `json:"packets_sent"`
+	PacketsRecv int       `json:"packets_recv"`
+	Error       string    `json:"error,omitempty"`
+	MeasuredAt  time.Time `json:"measured_at"`
+}
+
+// probeTargetsFromConfig converts the stored probes into the agent's format, skipping disabled ones
+func probeTargetsFromConfig(probes []types.MonitorAgentProbe) []agentProbeTarget {
+	targets := []agentProbeTarget{}
+	for _, probe := range probes {
+		if !probe.Enabled {
+			continue
+		}
+		targets = append(targets, agentProbeTarget{
+			Host:        probe.Host,
+			Interval:    probe.Interval,
+			PacketCount: probe.PacketCount,
+		})
+	}
+	return targets
+}
+
+func (r agentProbeResult) toMonitorResult(agentID int64) types.MonitorAgentProbeResult {
+	result := types.MonitorAgentProbeResult{
+		AgentID:     agentID,
+		Host:        r.Host,
+		PacketLoss:  r.PacketLoss,
+		MinRTT:      r.MinRTT,
+		AvgRTT:      r.AvgRTT,
+		MaxRTT:      r.MaxRTT,
+		StdDevRTT:   r.StdDevRTT,
+		PacketsSent: r.PacketsSent,
+		PacketsRecv: r.PacketsRecv,
+		MeasuredAt:  r.MeasuredAt,
+	}
+	if r.Error != "" {
+		errMsg := r.Error
+		result.Error = &errMsg
+	}
+	return result
+}
+
+// syncAgentProbes pushes the stored probe config to the agent when it changed and
+// stores any probe results the agent collected since the last sync
+func (s *Service) syncAgentProbes(client *Client) error {
+	if err := s.pushAgentProbes(client); err != nil {
+		return err
+	}
+	return s.fetchAgentProbeResults(client)
+}
+
+func (s *Service) pushAgentProbes(client *Client) error {
+	probes, err := s.db.GetMonitorAgentProbes(client.ctx, client.agent.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load agent probes: %w", err)
+	}
+
+	payload, err := json.Marshal(map[string]any{"targets": probeTargetsFromConfig(probes)})
+	if err != nil {
+		return fmt.Errorf("failed to encode agent probes: %w", err)
+	}
+
+	client.mu.Lock()
+	unchanged := client.probeConfig == string(payload)
+	client.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+
+	probesURL := strings.TrimRight(client.baseURL(), "/") + "/probes"
+	req, err := http.NewRequestWithContext(client.ctx, http.MethodPut, probesURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if _, err := authorize(client.ctx, req, client.agent, client.tokens); err != nil {
+		return err
+	}
+
+	httpClient := AgentHTTPClient(client.agent, 30*time.Second)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push agent probes: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusForbidden:
+		// The agent refuses server-managed probes; remember the config so we don't retry every poll
+		log.Warn().Int64("agent_id", client.agent.ID).Msg("Agent has remote probes disabled; only its local probes will be reported")
+	default:
+		return &httpStatusError{StatusCode: resp.StatusCode, URL: probesURL}
+	}
+
+	client.mu.Lock()
+	client.probeConfig = string(payload)
+	client.mu.Unlock()
+
+	log.Debug().Int64("agent_id", client.agent.ID).Int("probes", len(probes)).Msg("Pushed probe config to agent")
+	return nil
+}
+
+func (s *Service) fetchAgentProbeResults(client *Client) error {
+	client.mu.Lock()
+	since := client.lastProbeResultAt
+	client.mu.Unlock()
+
+	// After a restart resume from the newest stored result so buffered results aren't stored twice
+	if since.IsZero() {
+		latest, err := s.db.GetMonitorAgentProbeResults(client.ctx, client.agent.ID, "", time.Time{}, 1)
+		if err != nil {
+			return fmt.Errorf("failed to load latest probe result: %w", err)
+		}
+		if len(latest) > 0 {
+			since = latest[0].MeasuredAt
+		}
+	}
+
+	resultsURL := strings.TrimRight(client.baseURL(), "/") + "/probes/results"
+	if !since.IsZero() {
+		resultsURL += "?since=" + url.QueryEscape(since.UTC().Format(time.RFC3339Nano))
+	}
+
+	req, err := http.NewRequestWithContext(client.ctx, http.MethodGet, resultsURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if _, err := authorize(client.ctx, req, client.agent, client.tokens); err != nil {
+		return err
+	}
+
+	httpClient := AgentHTTPClient(client.agent, 30*time.Second)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch probe results: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &httpStatusError{StatusCode: resp.StatusCode, URL: resultsURL}
+	}
+
+	var payload struct {
+		Results []agentProbeResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return fmt.Errorf("failed to decode probe results: %w", err)
+	}
+
+	results := make([]types.MonitorAgentProbeResult, 0, len(payload.Results))
+	newest := since
+	for _, r := range payload.Results {
+		if !r.MeasuredAt.After(since) {
+			continue
+		}
+		results = append(results, r.toMonitorResult(client.agent.ID))
+		if r.MeasuredAt.After(newest) {
+			newest = r.MeasuredAt
+		}
+	}
+
+	if err := s.db.SaveMonitorAgentProbeResults(client.ctx, client.agent.ID, results); err != nil {
+		return fmt.Errorf("failed to store probe results: %w", err)
+	}
+
+	client.mu.Lock()
+	client.lastProbeResultAt = newest
+	client.mu.Unlock()
+
+	return nil
+}
+
+// RefreshAgentProbes pushes updated probe config to a running agent without waiting for the next poll
+func (s *Service) RefreshAgentProbes(agentID int64) {
+	s.clientsMu.RLock()
+	client, ok := s.clients[agentID]
+	s.clientsMu.RUnlock()
+	if !ok {
+		return
+	}
+
+	client.mu.Lock()
+	client.probeConfig = ""
+	client.mu.Unlock()
+
+	if connected, _ := client.IsConnected(); !connected || !client.shouldPollProbes() {
+		return
+	}
+
+	go func() {
+		if err := s.pushAgentProbes(client); err != nil {
+			if !client.handleEndpointNotFound(err, endpointProbes) {
+				log.Error().Err(err).Int64("agent_id", agentID).Msg("Failed to push agent probes")
+			}
+		}
+	}()
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// probeDB stubs the database calls used by probe syncing
+type probeDB struct {
+	database.Service
+	probes []types.MonitorAgentProbe
+	saved  []types.MonitorAgentProbeResult
+}
+
+func (d *probeDB) GetMonitorAgentProbes(ctx context.Context, agentID int64) ([]types.MonitorAgentProbe, error) {
+	return d.probes, nil
+}
+
+func (d *probeDB) SaveMonitorAgentProbeResults(ctx context.Context, agentID int64, results []types.MonitorAgentProbeResult) error {
+	d.saved = append(d.saved, results...)
+	return nil
+}
+
+func (d *probeDB) GetMonitorAgentProbeResults(ctx context.Context, agentID int64, host string, since time.Time, limit int) ([]types.MonitorAgentProbeResult, error) {
+	if len(d.saved) == 0 {
+		return nil, nil
+	}
+	return d.saved[len(d.saved)-1:], nil
+}
+
+func TestSyncAgentProbes(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var puts atomic.Int32
+	var lastSince atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/probes":
+			puts.Add(1)
+			var body struct {
+				Targets []agentProbeTarget `json:"targets"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("failed to decode probe config: %v", err)
+			}
+			if len(body.Targets) != 1 || body.Targets[0].Host != "1.1.1.1" {
+				t.Errorf("unexpected probe config pushed: %+v", body.Targets)
+			}
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodGet && r.URL.Path == "/probes/results":
+			lastSince.Store(r.URL.Query().Get("since"))
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"results": []agentProbeResult{
+					{Host: "1.1.1.1", PacketLoss: 10, AvgRTT: 12.5, PacketsSent: 10, PacketsRecv: 9, MeasuredAt: base},
+					{Host: "1.1.1.1", Error: "timeout", MeasuredAt: base.Add(time.Minute)},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	db := &probeDB{probes: []types.MonitorAgentProbe{
+		{Host: "1.1.1.1", Interval: "1m", PacketCount: 10, Enabled: true},
+		{Host: "8.8.8.8", Interval: "1m", PacketCount: 10, Enabled: false},
+	}}
+	s := &Service{db: db}
+	client := &Client{
+		agent: &types.MonitorAgent{ID: 1, URL: srv.URL + "/events?stream=live-data"},
+		ctx:   context.Background(),
+	}
+
+	if err := s.syncAgentProbes(client); err != nil {
+		t.Fatalf("syncAgentProbes() error = %v", err)
+	}
+	if puts.Load() != 1 {
+		t.Fatalf("expected probe config to be pushed once, got %d", puts.Load())
+	}
+	if len(db.saved) != 2 {
+		t.Fatalf("expected 2 stored results, got %d", len(db.saved))
+	}
+	if db.saved[1].Error == nil || *db.saved[1].Error != "timeout" {
+		t.Fatalf("expected probe error to be stored, got %+v", db.saved[1].Error)
+	}
+	if db.saved[0].AgentID != 1 || db.saved[0].PacketLoss != 10 {
+		t.Fatalf("unexpected stored result %+v", db.saved[0])
+	}
+
+	// Unchanged config isn't pushed again, and only newer results are stored
+	if err := s.syncAgentProbes(client); err != nil {
+		t.Fatalf("second syncAgentProbes() error = %v", err)
+	}
+	if puts.Load() != 1 {
+		t.Fatalf("unchanged probe config should not be pushed again, got %d pushes", puts.Load())
+	}
+	if len(db.saved) != 2 {
+		t.Fatalf("already stored results should be skipped, got %d", len(db.saved))
+	}
+	if got := lastSince.Load(); got != base.Add(time.Minute).Format(time.RFC3339Nano) {
+		t.Fatalf("since = %v, want newest result time", got)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+const (
+	// smartPollInterval is how often an agent's disks are checked. SMART health changes
+	// slowly and smartctl takes a while per disk, so it isn't read with every resource
+	// stats collection.
+	smartPollInterval = 10 * time.Minute
+
+	// maxSmartResponseSize bounds a /system/smart response
+	maxSmartResponseSize = 1 << 20
+)
+
+// smartDisk is a disk in the agent's /system/smart response
+type smartDisk struct {
+	Device             string   `json:"device"`
+	Model              string   `json:"model"`
+	Serial             string   `json:"serial"`
+	Health             string   `json:"health"`
+	ReallocatedSectors int64    `json:"reallocated_sectors"`
+	PendingSectors     int64    `json:"pending_sectors"`
+	Temperature        float64  `json:"temperature"`
+	FailingAttributes  []string `json:"failing_attributes"`
+}
+
+func (c *Client) shouldPollSmart() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.caps.smart.known && !c.caps.smart.supported {
+		return false
+	}
+	return time.Since(c.lastSmartPoll) >= smartPollInterval
+}
+
+// fetchSmartStats stores the SMART health of an agent's disks and notifies about each
+// disk whose health got worse than the stored health
+func (s *Service) fetchSmartStats(client *Client) error {
+	// Counted from the attempt, so a failing agent isn't asked again every collection
+	client.mu.Lock()
+	client.lastSmartPoll = time.Now()
+	client.mu.Unlock()
+
+	smartURL := strings.TrimRight(client.baseURL(), "/") + "/system/smart"
+
+	req, err := http.NewRequestWithContext(client.ctx, "GET", smartURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if _, err := authorize(client.ctx, req, client.agent, client.tokens); err != nil {
+		return err
+	}
+
+	resp, err := AgentHTTPClient(client.agent, time.Minute).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch SMART stats: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &httpStatusError{StatusCode: resp.StatusCode, URL: smartURL}
+	}
+
+	var smart struct {
+		Available bool        `json:"available"`
+		Devices   []smartDisk `json:"devices"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxSmartResponseSize)).Decode(&smart); err != nil {
+		return fmt.Errorf("failed to decode SMART stats: %w", err)
+	}
+	if !smart.Available {
+		// smartctl isn't installed on the agent
+		return nil
+	}
+
+	previous, err := s.db.GetMonitorSmartStats(client.ctx, client.agent.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get stored SMART stats: %w", err)
+	}
+	stored := make(map[string]types.MonitorSmartDevice, len(previous))
+	for _, device := range previous {
+		stored[device.Device] = device
+	}
+
+	now := time.Now()
+	devices := make([]types.MonitorSmartDevice, 0, len(smart.Devices))
+	var worsened []smartDisk
+	for _, disk := range smart.Devices {
+		if disk.Health == "" {
+			disk.Health = types.SmartHealthUnknown
+		}
+
+		last, known := stored[disk.Device]
+		// A disk that couldn't be read, such as a sleeping one, keeps its last known health
+		if disk.Health == types.SmartHealthUnknown && known {
+			devices = append(devices, last)
+			continue
+		}
+
+		devices = append(devices, types.MonitorSmartDevice{
+			AgentID:            client.agent.ID,
+			Device:             disk.Device,
+			Model:              disk.Model,
+			Serial:             disk.Serial,
+			Health:             disk.Health,
+			ReallocatedSectors: disk.ReallocatedSectors,
+			PendingSectors:     disk.PendingSectors,
+			Temperature:        disk.Temperature,
+			CreatedAt:          now,
+		})
+		if smartHealthRank(disk.Health) > smartHealthRank(last.Health) {
+			worsened = append(worsened, disk)
+		}
+	}
+
+	if err := s.db.SaveMonitorSmartStats(client.ctx, client.agent.ID, devices); err != nil {
+		return fmt.Errorf("failed to store SMART stats: %w", err)
+	}
+
+	if client.notifier == nil || client.inMaintenance() {
+		return nil
+	}
+	for _, disk := range worsened {
+		log.Warn().
+			Int64("agent_id", client.agent.ID).
+			Str("device", disk.Device).
+			Str("model", disk.Model).
+			Str("health", disk.Health).
+			Strs("failing_attributes", disk.FailingAttributes).
+			Msg("Agent disk SMART health degraded")
+
+		// The disk details travel after the agent name, like temperature sensors
+		agentNameWithDisk := fmt.Sprintf("%s|%s", client.agent.Name, describeSmartDisk(disk))
+		if err := client.notifier.SendAgentNotification(
+			agentNameWithDisk,
+			client.agent.Tags,
+			database.NotificationEventAgentDiskFailing,
+			nil,
+		); err != nil {
+			notifyErrorLog(err).Str("device", disk.Device).Msg("Failed to send disk failing notification")
+		}
+	}
+	return nil
+}
+
+// smartHealthRank orders SMART health states from healthy to failing
+func smartHealthRank(health string) int {
+	switch health {
+	case types.SmartHealthFailing:
+		return 2
+	case types.SmartHealthPrefail:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// describeSmartDisk names a disk and why its health is bad, for notifications
+func describeSmartDisk(disk smartDisk) string {
+	name := disk.Device
+	if disk.Model != "" {
+		name = fmt.Sprintf("%s (%s)", disk.Model, disk.Device)
+	}
+
+	reason := "SMART health check failed"
+	if disk.Health == types.SmartHealthPrefail {
+		reason = "pre-fail"
+		if len(disk.FailingAttributes) > 0 {
+			reason += ": " + strings.Join(disk.FailingAttributes, ", ")
+		}
+	}
+
+	description := fmt.Sprintf("%s: %s", name, reason)
+	if disk.ReallocatedSectors > 0 || disk.PendingSectors > 0 {
+		description += fmt.Sprintf(" (reallocated sectors: %d, pending sectors: %d)", disk.ReallocatedSectors, disk.PendingSectors)
+	}
+	return description
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// smartDB keeps SMART stats in memory
+type smartDB struct {
+	database.Service
+	devices []types.MonitorSmartDevice
+}
+
+func (d *smartDB) GetMonitorSmartStats(ctx context.Context, agentID int64) ([]types.MonitorSmartDevice, error) {
+	return d.devices, nil
+}
+
+func (d *smartDB) SaveMonitorSmartStats(ctx context.Context, agentID int64, devices []types.MonitorSmartDevice) error {
+	d.devices = devices
+	return nil
+}
+
+func TestFetchSmartStats(t *testing.T) {
+	var response string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/system/smart" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	defer srv.Close()
+
+	db := &smartDB{}
+	notifier := &recordingNotifier{}
+	s := &Service{db: db}
+	c := &Client{
+		agent:    &types.MonitorAgent{ID: 1, Name: "nas", URL: srv.URL + "/events?stream=live-data"},
+		ctx:      context.Background(),
+		notifier: notifier,
+	}
+
+	steps := []struct {
+		name        string
+		response    string
+		wantHealth  map[string]string
+		wantNotices []string
+	}{
+		{
+			name:        "first poll notifies about a pre-fail disk",
+			response:    `{"available":true,"devices":[{"device":"/dev/sda","model":"WDC","health":"prefail","reallocated_sectors":24,"failing_attributes":["Reallocated_Sector_Ct"]},{"device":"/dev/sdb","health":"passed"}]}`,
+			wantHealth:  map[string]string{"/dev/sda": types.SmartHealthPrefail, "/dev/sdb": types.SmartHealthPassed},
+			wantNotices: []string{"nas|WDC (/dev/sda): pre-fail: Reallocated_Sector_Ct (reallocated sectors: 24, pending sectors: 0)"},
+		},
+		{
+			name:       "unchanged health is quiet",
+			response:   `{"available":true,"devices":[{"device":"/dev/sda","health":"prefail"},{"device":"/dev/sdb","health":"passed"}]}`,
+			wantHealth: map[string]string{"/dev/sda": types.SmartHealthPrefail, "/dev/sdb": types.SmartHealthPassed},
+		},
+		{
+			name:       "a sleeping disk keeps its last known health",
+			response:   `{"available":true,"devices":[{"device":"/dev/sda","health":"unknown","error":"Device is in STANDBY mode"},{"device":"/dev/sdb","health":"passed"}]}`,
+			wantHealth: map[string]string{"/dev/sda": types.SmartHealthPrefail, "/dev/sdb": types.SmartHealthPassed},
+		},
+		{
+			name:        "failing after pre-fail notifies again",
+			response:    `{"available":true,"devices":[{"device":"/dev/sda","health":"failing"},{"device":"/dev/sdb","health":"passed"}]}`,
+			wantHealth:  map[string]string{"/dev/sda": types.SmartHealthFailing, "/dev/sdb": types.SmartHealthPassed},
+			wantNotices: []string{"nas|/dev/sda: SMART health check failed"},
+		},
+		{
+			name:       "agents without smartctl leave the stored health alone",
+			response:   `{"available":false,"devices":[]}`,
+			wantHealth: map[string]string{"/dev/sda": types.SmartHealthFailing, "/dev/sdb": types.SmartHealthPassed},
+		},
+	}
+
+	for _, step := range steps {
+		response = step.response
+		notifier.generic = nil
+
+		if err := s.fetchSmartStats(c); err != nil {
+			t.Fatalf("%s: fetchSmartStats: %v", step.name, err)
+		}
+
+		health := make(map[string]string, len(db.devices))
+		for _, device := range db.devices {
+			health[device.Device] = device.Health
+		}
+		if len(health) != len(step.wantHealth) {
+			t.Errorf("%s: stored %v, want %v", step.name, health, step.wantHealth)
+		}
+		for device, want := range step.wantHealth {
+			if health[device] != want {
+				t.Errorf("%s: %s health = %q, want %q", step.name, device, health[device], want)
+			}
+		}
+		if !slices.Equal(notifier.generic, step.wantNotices) {
+			t.Errorf("%s: notified %q, want %q", step.name, notifier.generic, step.wantNotices)
+		}
+	}
+}
+
+func TestShouldPollSmart(t *testing.T) {
+	c := &Client{}
+	if !c.shouldPollSmart() {
+		t.Error("a never-polled agent should be polled")
+	}
+
+	c.lastSmartPoll = time.Now()
+	if c.shouldPollSmart() {
+		t.Error("an agent polled just now should wait for smartPollInterval")
+	}
+
+	c.lastSmartPoll = time.Now().Add(-smartPollInterval)
+	c.caps.smart = endpointSupport{known: true, supported: false}
+	if c.shouldPollSmart() {
+		t.Error("an agent without the SMART endpoint should not be polled")
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	// MaxAgentTags and MaxAgentTagLength bound the tags on one agent
+	MaxAgentTags      = 20
+	MaxAgentTagLength = 32
+)
+
+var agentTagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// NormalizeAgentTag lowercases and trims a tag and checks that it only uses letters,
+// digits, dots, dashes and underscores
+func NormalizeAgentTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if len(tag) > MaxAgentTagLength {
+		return "", fmt.Errorf("tag %q is longer than %d characters", tag, MaxAgentTagLength)
+	}
+	if !agentTagPattern.MatchString(tag) {
+		return "", fmt.Errorf("tag %q must start with a letter or digit and only contain letters, digits, '.', '-' and '_'", tag)
+	}
+	return tag, nil
+}
+
+// NormalizeAgentTags normalizes a tag list, dropping empty and duplicate tags. The result
+// is sorted and never nil.
+func NormalizeAgentTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) == "" {
+			continue
+		}
+		tag, err := NormalizeAgentTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, tag)
+	}
+
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+	if len(normalized) > MaxAgentTags {
+		return nil, fmt.Errorf("at most %d tags are allowed, got %d", MaxAgentTags, len(normalized))
+	}
+	return normalized, nil
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeAgentTags(t *testing.T) {
+	tooMany := make([]string, MaxAgentTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag%d", i)
+	}
+
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "nil", tags: nil, want: []string{}},
+		{name: "normalized", tags: []string{" Prod-DB ", "eu.west", "prod-db", ""}, want: []string{"eu.west", "prod-db"}},
+		{name: "underscore", tags: []string{"rack_2"}, want: []string{"rack_2"}},
+		{name: "leading dash", tags: []string{"-prod"}, wantErr: true},
+		{name: "space inside", tags: []string{"prod db"}, wantErr: true},
+		{name: "too long", tags: []string{strings.Repeat("a", MaxAgentTagLength+1)}, wantErr: true},
+		{name: "too many", tags: tooMany, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeAgentTags(tt.tags)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got == nil || !slices.Equal(got, tt.want) {
+				t.Fatalf("NormalizeAgentTags(%q) = %#v, want %#v", tt.tags, got, tt.want)
+			}
+		})
+	}
+}
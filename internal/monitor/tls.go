@@ -0,0 +1,109 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// ErrInvalidCACert is returned when an agent's CA certificate contains no usable PEM certificates
+var ErrInvalidCACert = errors.New("CA certificate contains no valid PEM certificates")
+
+// errTransport fails every request, used when an agent's TLS settings can't be applied
+type errTransport struct {
+	err error
+}
+
+func (t errTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
+// agentTLSConfig builds the TLS config for an agent, or returns nil when it uses default verification
+func agentTLSConfig(agent *types.MonitorAgent) (*tls.Config, error) {
+	if agent == nil {
+		return nil, nil
+	}
+
+	hasCA := agent.CACert != nil && *agent.CACert != ""
+	if !agent.InsecureSkipVerify && !hasCA {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if agent.InsecureSkipVerify {
+		cfg.InsecureSkipVerify = true
+		return cfg, nil
+	}
+
+	// Only the pinned CA is trusted, not the system roots
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(*agent.CACert)) {
+		return nil, ErrInvalidCACert
+	}
+	cfg.RootCAs = pool
+	return cfg, nil
+}
+
+// ValidateAgentTLS checks that an agent's TLS settings can be applied
+func ValidateAgentTLS(agent *types.MonitorAgent) error {
+	_, err := agentTLSConfig(agent)
+	return err
+}
+
+// agentTransport returns the pooled transport for requests to agent
+func agentTransport(agent *types.MonitorAgent) http.RoundTripper {
+	cfg, err := agentTLSConfig(agent)
+	if err != nil {
+		return errTransport{err: fmt.Errorf("agent %d TLS settings: %w", agent.ID, err)}
+	}
+
+	key := agentTransportKey{tls: "default"}
+	if agent != nil {
+		key.agentID = agent.ID
+		key.socket = agentSocketPath(agent.URL)
+	}
+	if cfg != nil {
+		key.tls = "skip-verify"
+		if !cfg.InsecureSkipVerify {
+			key.tls = "ca:" + *agent.CACert
+		}
+	}
+
+	agentTransportsMu.Lock()
+	defer agentTransportsMu.Unlock()
+	if transport, ok := agentTransports[key]; ok {
+		return transport
+	}
+
+	if cfg != nil && cfg.InsecureSkipVerify {
+		log.Warn().Int64("agent_id", agent.ID).Str("agent", agent.Name).Msg("TLS certificate verification is disabled for agent")
+	}
+
+	transport := newAgentTransport(cfg, key.socket, agentTransportOptions)
+	agentTransports[key] = transport
+	return transport
+}
+
+// AgentHTTPClient returns an HTTP client for requests to agent that applies its TLS settings
+// and custom headers.
+// A zero timeout means no timeout, as used for the SSE stream.
+func AgentHTTPClient(agent *types.MonitorAgent, timeout time.Duration) *http.Client {
+	transport := agentTransport(agent)
+	if agent != nil && len(agent.Headers) > 0 {
+		transport = headerTransport{base: transport, headers: agent.Headers}
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
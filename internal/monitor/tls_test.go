@@ -0,0 +1,96 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestAgentHTTPClient_TLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+	invalidCA := "not a certificate"
+
+	tests := []struct {
+		name    string
+		agent   *types.MonitorAgent
+		wantErr bool
+	}{
+		{name: "default verification rejects self-signed", agent: &types.MonitorAgent{ID: 1}, wantErr: true},
+		{name: "pinned CA", agent: &types.MonitorAgent{ID: 2, CACert: &caPEM}},
+		{name: "skip verify", agent: &types.MonitorAgent{ID: 3, InsecureSkipVerify: true}},
+		{name: "invalid CA", agent: &types.MonitorAgent{ID: 4, CACert: &invalidCA}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := AgentHTTPClient(tt.agent, 5*time.Second).Get(srv.URL)
+			if tt.wantErr {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatal("expected request to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestValidateAgentTLS(t *testing.T) {
+	invalidCA := "-----BEGIN CERTIFICATE-----\nbroken\n-----END CERTIFICATE-----\n"
+	if err := ValidateAgentTLS(&types.MonitorAgent{CACert: &invalidCA}); !errors.Is(err, ErrInvalidCACert) {
+		t.Fatalf("ValidateAgentTLS() error = %v, want ErrInvalidCACert", err)
+	}
+	if err := ValidateAgentTLS(&types.MonitorAgent{}); err != nil {
+		t.Fatalf("ValidateAgentTLS() without TLS settings error = %v", err)
+	}
+}
+
+func TestAgentTransportPooling(t *testing.T) {
+	SetTransportOptions(TransportOptions{MaxIdleConnsPerHost: 7, IdleConnTimeout: time.Minute})
+	defer SetTransportOptions(TransportOptions{})
+
+	agent := &types.MonitorAgent{ID: 42}
+	first := agentTransport(agent)
+	if second := agentTransport(agent); second != first {
+		t.Fatal("expected the same agent to reuse its transport")
+	}
+	if other := agentTransport(&types.MonitorAgent{ID: 43}); other == first {
+		t.Fatal("expected each agent to get its own transport")
+	}
+
+	transport, ok := first.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport is %T, want *http.Transport", first)
+	}
+	if transport.MaxIdleConnsPerHost != 7 {
+		t.Fatalf("MaxIdleConnsPerHost = %d, want 7", transport.MaxIdleConnsPerHost)
+	}
+	if transport.IdleConnTimeout != time.Minute {
+		t.Fatalf("IdleConnTimeout = %s, want 1m", transport.IdleConnTimeout)
+	}
+
+	closeAgentTransports(agent.ID)
+	if agentTransport(agent) == first {
+		t.Fatal("expected a new transport after the agent's pool was closed")
+	}
+}
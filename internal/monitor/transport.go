@@ -0,0 +1,143 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+const (
+	defaultMaxIdleConnsPerHost = 4
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultKeepAlive           = 30 * time.Second
+	agentDialTimeout           = 30 * time.Second
+)
+
+// TransportOptions controls connection reuse for requests to agents
+type TransportOptions struct {
+	// MaxIdleConnsPerHost bounds the keep-alive connections kept open to each agent
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes pooled connections that stay unused this long
+	IdleConnTimeout time.Duration
+	// KeepAlive is the TCP keep-alive period, negative disables it
+	KeepAlive time.Duration
+}
+
+// unixSocketBaseURL is the base URL of agents reached over a Unix socket. The host only
+// fills the Host header, the transport dials the socket whatever the address.
+const unixSocketBaseURL = "http://localhost"
+
+// agentTransportKey identifies a pooled transport, one per agent, TLS setting and socket
+// so a changed CA, skip-verify flag or socket path never reuses connections made under
+// the old setting
+type agentTransportKey struct {
+	agentID int64
+	tls     string
+	socket  string
+}
+
+// agentTransports pools one transport per agent so the frequent polls from the monitor
+// service and the proxying handlers reuse connections instead of handshaking each time
+var (
+	agentTransportsMu     sync.Mutex
+	agentTransports       = make(map[agentTransportKey]*http.Transport)
+	agentTransportOptions = TransportOptions{
+		MaxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     defaultIdleConnTimeout,
+		KeepAlive:           defaultKeepAlive,
+	}
+)
+
+// TransportOptionsFromConfig reads the connection pool settings, falling back to the
+// defaults with a warning for invalid values
+func TransportOptionsFromConfig(cfg *config.MonitorConfig) TransportOptions {
+	opts := TransportOptions{
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:     parseCollectorInterval("monitor.idle_conn_timeout", cfg.IdleConnTimeout, defaultIdleConnTimeout),
+		KeepAlive:           defaultKeepAlive,
+	}
+	if cfg.KeepAlive != "" {
+		if d, err := time.ParseDuration(cfg.KeepAlive); err == nil {
+			opts.KeepAlive = d
+		} else {
+			log.Warn().Str("key", "monitor.keep_alive").Str("value", cfg.KeepAlive).Dur("default", defaultKeepAlive).Msg("Invalid keep-alive period, using default")
+		}
+	}
+	return opts
+}
+
+// SetTransportOptions replaces the connection pool settings. Pooled transports are
+// closed so the next request to each agent picks up the new settings.
+func SetTransportOptions(opts TransportOptions) {
+	if opts.MaxIdleConnsPerHost <= 0 {
+		opts.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout <= 0 {
+		opts.IdleConnTimeout = defaultIdleConnTimeout
+	}
+
+	agentTransportsMu.Lock()
+	defer agentTransportsMu.Unlock()
+	agentTransportOptions = opts
+	for key, transport := range agentTransports {
+		transport.CloseIdleConnections()
+		delete(agentTransports, key)
+	}
+}
+
+// closeAgentTransports drops the pooled transports of an agent that is no longer monitored
+func closeAgentTransports(agentID int64) {
+	agentTransportsMu.Lock()
+	defer agentTransportsMu.Unlock()
+	for key, transport := range agentTransports {
+		if key.agentID == agentID {
+			transport.CloseIdleConnections()
+			delete(agentTransports, key)
+		}
+	}
+}
+
+// agentSocketPath returns the socket path of a unix:// agent URL, or "" for network agents
+func agentSocketPath(rawURL string) string {
+	base := strings.TrimSuffix(rawURL, sseStreamPath)
+	base = strings.TrimSuffix(base, webSocketStreamPath)
+
+	scheme, path, ok := strings.Cut(base, "://")
+	if !ok || !strings.EqualFold(scheme, "unix") {
+		return ""
+	}
+	return strings.TrimSuffix(path, "/")
+}
+
+// newAgentTransport builds a keep-alive transport with a bounded idle pool per host.
+// With a socket every connection goes to that Unix socket instead of the request's host.
+func newAgentTransport(tlsConfig *tls.Config, socket string, opts TransportOptions) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   agentDialTimeout,
+		KeepAlive: opts.KeepAlive,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	if socket != "" {
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+	}
+	transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = opts.IdleConnTimeout
+	transport.TLSClientConfig = tlsConfig
+	return transport
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestAgentSocketPath(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"unix:///run/netronome-agent.sock/events?stream=live-data", "/run/netronome-agent.sock"},
+		{"UNIX:///run/netronome-agent.sock/events/ws", "/run/netronome-agent.sock"},
+		{"unix:///run/netronome-agent.sock/", "/run/netronome-agent.sock"},
+		{"http://10.0.0.5:8200/events?stream=live-data", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := agentSocketPath(tt.url); got != tt.want {
+			t.Errorf("agentSocketPath(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestAgentHTTPClientUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen on socket: %v", err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path)
+	}))
+	srv.Listener = ln
+	srv.Start()
+	defer srv.Close()
+
+	agent := &types.MonitorAgent{ID: 7, URL: "unix://" + socket + "/events?stream=live-data"}
+	defer closeAgentTransports(agent.ID)
+
+	if !isLocalAgentURL(agent.URL) {
+		t.Fatal("expected a socket agent to count as local")
+	}
+
+	resp, err := AgentHTTPClient(agent, 5*time.Second).Get(AgentBaseURL(agent.URL) + "/system/info")
+	if err != nil {
+		t.Fatalf("request over socket: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "/system/info" {
+		t.Fatalf("body = %q, want /system/info", body)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+const (
+	// sseStreamPath and webSocketStreamPath are the agent's live data endpoints
+	sseStreamPath       = "/events?stream=live-data"
+	webSocketStreamPath = "/events/ws"
+
+	// wsPingInterval is how often the stream is pinged; a pong not arriving within
+	// wsPongTimeout drops the connection so it is reconnected
+	wsPingInterval = 15 * time.Second
+	wsPongTimeout  = 10 * time.Second
+	// wsReadLimit bounds a single live data message
+	wsReadLimit = 1 << 20
+)
+
+// errWebSocketUnsupported is returned when an agent predates the WebSocket endpoint
+var errWebSocketUnsupported = errors.New("agent has no websocket endpoint")
+
+// AgentBaseURL returns the HTTP base URL of an agent from its stored live data URL.
+// ws:// and wss:// URLs, which select the WebSocket transport, map to http:// and https://.
+// unix:// URLs map to a fixed http:// base; AgentHTTPClient dials their socket. user:pass@
+// credentials are left out; SetAgentBasicAuth sends them.
+func AgentBaseURL(rawURL string) string {
+	base := strings.TrimSuffix(StripAgentURLCredentials(rawURL), sseStreamPath)
+	base = strings.TrimSuffix(base, webSocketStreamPath)
+
+	switch scheme, rest, _ := strings.Cut(base, "://"); strings.ToLower(scheme) {
+	case "ws":
+		return "http://" + rest
+	case "wss":
+		return "https://" + rest
+	case "unix":
+		return unixSocketBaseURL
+	default:
+		return base
+	}
+}
+
+// isWebSocketURL reports whether an agent URL asks for the WebSocket transport
+func isWebSocketURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Scheme, "ws") || strings.EqualFold(u.Scheme, "wss")
+}
+
+// useWebSocket reports whether live data is streamed over WebSocket instead of SSE
+func (c *Client) useWebSocket() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.webSocketUnsupported {
+		return false
+	}
+	return c.transport == config.MonitorTransportWebSocket || isWebSocketURL(c.agent.URL)
+}
+
+// webSocketURL returns the agent's WebSocket live data endpoint
+func (c *Client) webSocketURL() string {
+	base := c.baseURL()
+	if rest, ok := strings.CutPrefix(base, "https://"); ok {
+		return "wss://" + rest + webSocketStreamPath
+	}
+	return "ws://" + strings.TrimPrefix(base, "http://") + webSocketStreamPath
+}
+
+// connect streams live data over WebSocket when asked to, and over SSE otherwise or
+// when the agent doesn't serve the WebSocket endpoint
+func (c *Client) connect() error {
+	if c.useWebSocket() {
+		err := c.connectAndStreamWebSocket()
+		if !errors.Is(err, errWebSocketUnsupported) {
+			return err
+		}
+
+		log.Warn().
+			Int64("agent_id", c.agent.ID).
+			Msg("Monitor agent doesn't support WebSocket, falling back to SSE")
+		c.mu.Lock()
+		c.webSocketUnsupported = true
+		c.mu.Unlock()
+	}
+	return c.connectAndStream()
+}
+
+// connectAndStreamWebSocket connects to the WebSocket endpoint and streams data. The
+// messages carry the same JSON as the SSE events.
+func (c *Client) connectAndStreamWebSocket() error {
+	wsURL := c.webSocketURL()
+
+	// The handshake carries the same credentials as the SSE request
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, wsURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	tokenExpiry, err := authorize(c.ctx, req, c.agent, c.tokens)
+	if err != nil {
+		return err
+	}
+
+	// Bearer tokens can't be swapped on an open stream, so close it before expiry and reconnect
+	streamCtx, streamCancel := context.WithCancel(c.ctx)
+	if !tokenExpiry.IsZero() {
+		streamCtx, streamCancel = context.WithDeadline(c.ctx, c.tokens.RefreshAt(tokenExpiry))
+	}
+	defer streamCancel()
+
+	if err := c.trackStream(streamCancel); err != nil {
+		return err
+	}
+	defer c.untrackStream()
+
+	conn, resp, err := websocket.Dial(streamCtx, wsURL, &websocket.DialOptions{
+		HTTPClient: AgentHTTPClient(c.agent, 0),
+		HTTPHeader: req.Header,
+	})
+	if err != nil {
+		if c.streamClosedByPause(streamCtx) {
+			return errPaused
+		}
+		if resp != nil {
+			switch resp.StatusCode {
+			case http.StatusNotFound:
+				return errWebSocketUnsupported
+			case http.StatusUnauthorized:
+				if c.tokens != nil {
+					c.tokens.Invalidate()
+				}
+			}
+		}
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(wsReadLimit)
+
+	c.markConnected(wsURL)
+
+	go c.keepAlive(streamCtx, conn)
+
+	for {
+		msgType, data, err := conn.Read(streamCtx)
+		if err != nil {
+			if c.ctx.Err() != nil {
+				return c.ctx.Err()
+			}
+			if errors.Is(streamCtx.Err(), context.DeadlineExceeded) {
+				_ = conn.Close(websocket.StatusNormalClosure, "token refresh")
+				return errTokenRefresh
+			}
+			if c.streamClosedByPause(streamCtx) {
+				return errPaused
+			}
+			return fmt.Errorf("websocket read error: %w", err)
+		}
+		if msgType == websocket.MessageText {
+			c.processData(string(data))
+		}
+	}
+}
+
+// keepAlive pings the agent until ctx ends, closing the connection when a pong doesn't
+// arrive in time so a dead link is noticed well before TCP gives up
+func (c *Client) keepAlive(ctx context.Context, conn *websocket.Conn) {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pingCtx, cancel := context.WithTimeout(ctx, wsPongTimeout)
+			err := conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Warn().
+						Err(err).
+						Int64("agent_id", c.agent.ID).
+						Msg("Monitor agent missed WebSocket ping, reconnecting")
+					_ = conn.CloseNow()
+				}
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/coder/websocket"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestAgentBaseURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"http://10.0.0.5:8200/events?stream=live-data", "http://10.0.0.5:8200"},
+		{"https://agent.example.com/events?stream=live-data", "https://agent.example.com"},
+		{"ws://10.0.0.5:8200/events?stream=live-data", "http://10.0.0.5:8200"},
+		{"wss://agent.example.com/netronome/events?stream=live-data", "https://agent.example.com/netronome"},
+		{"WSS://agent.example.com/events/ws", "https://agent.example.com"},
+		{"unix:///run/netronome-agent.sock/events?stream=live-data", "http://localhost"},
+	}
+
+	for _, tt := range tests {
+		if got := AgentBaseURL(tt.url); got != tt.want {
+			t.Errorf("AgentBaseURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestClientUseWebSocket(t *testing.T) {
+	c := &Client{agent: &types.MonitorAgent{URL: "https://agent.example.com/events?stream=live-data"}}
+	if c.useWebSocket() {
+		t.Fatal("expected SSE for an https URL")
+	}
+
+	c.transport = config.MonitorTransportWebSocket
+	if !c.useWebSocket() {
+		t.Fatal("expected WebSocket with transport = ws")
+	}
+	if got := c.webSocketURL(); got != "wss://agent.example.com/events/ws" {
+		t.Fatalf("webSocketURL = %q", got)
+	}
+
+	c.transport = config.MonitorTransportSSE
+	c.agent.URL = "ws://10.0.0.5:8200/events?stream=live-data"
+	if !c.useWebSocket() {
+		t.Fatal("expected WebSocket for a ws:// URL")
+	}
+	if got := c.webSocketURL(); got != "ws://10.0.0.5:8200/events/ws" {
+		t.Fatalf("webSocketURL = %q", got)
+	}
+
+	c.webSocketUnsupported = true
+	if c.useWebSocket() {
+		t.Fatal("expected SSE once the agent rejected WebSocket")
+	}
+}
+
+func TestClientWebSocketStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != webSocketStreamPath {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("X-API-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+
+		_ = conn.Write(r.Context(), websocket.MessageText, []byte(`{"index":1,"seconds":1,"rx":{"bytespersecond":1250},"tx":{"bytespersecond":625}}`))
+		_ = conn.Close(websocket.StatusNormalClosure, "")
+	}))
+	defer srv.Close()
+
+	apiKey := "secret"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var updates []types.MonitorUpdate
+	c := &Client{
+		agent: &types.MonitorAgent{
+			ID:     1,
+			Name:   "edge",
+			URL:    "ws://" + strings.TrimPrefix(srv.URL, "http://") + "/events?stream=live-data",
+			APIKey: &apiKey,
+		},
+		db:            peakStatsDB{},
+		broadcastFunc: func(u types.MonitorUpdate) { updates = append(updates, u) },
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+
+	if err := c.connect(); err == nil {
+		t.Fatal("expected an error once the agent closed the stream")
+	}
+
+	_, data := c.IsConnected()
+	if data == nil || data.Rx.Bytespersecond != 1250 || data.Tx.Bytespersecond != 625 {
+		t.Fatalf("expected live data from the WebSocket message, got %+v", data)
+	}
+	if len(updates) != 2 || !updates[0].Connected || updates[1].RxBytesPerSecond != 1250 {
+		t.Fatalf("unexpected broadcasts %+v", updates)
+	}
+}
+
+func TestClientWebSocketFallsBackToSSE(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/events" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("event:message\ndata:{\"index\":1,\"seconds\":1,\"rx\":{\"bytespersecond\":42}}\n\n"))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &Client{
+		agent:         &types.MonitorAgent{ID: 1, Name: "edge", URL: srv.URL + "/events?stream=live-data"},
+		db:            peakStatsDB{},
+		broadcastFunc: func(types.MonitorUpdate) {},
+		transport:     config.MonitorTransportWebSocket,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+
+	if err := c.connect(); err == nil {
+		t.Fatal("expected an error once the agent closed the stream")
+	}
+	if !c.webSocketUnsupported {
+		t.Fatal("expected the missing WebSocket endpoint to be remembered")
+	}
+	if _, data := c.IsConnected(); data == nil || data.Rx.Bytespersecond != 42 {
+		t.Fatalf("expected live data over SSE, got %+v", data)
+	}
+}
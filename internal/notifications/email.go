@@ -0,0 +1,197 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package notifications
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+// emailTimeout bounds a whole SMTP conversation so a dead server can't hold up other backends
+const emailTimeout = 30 * time.Second
+
+// EmailNotifier sends notifications to a fixed list of recipients over SMTP. It has no
+// rules of its own: every event it is given is emailed, so values are judged by the
+// caller, usually the Notifier fanning out to it.
+type EmailNotifier struct {
+	cfg config.SMTPConfig
+
+	// events formats the Sender events and delivers them through Send
+	events *Notifier
+}
+
+// NewEmailNotifier creates an email notifier for the [smtp] config section
+func NewEmailNotifier(cfg config.SMTPConfig) *EmailNotifier {
+	e := &EmailNotifier{cfg: cfg}
+	e.events = &Notifier{deliver: e.Send}
+	return e
+}
+
+// SendNotification emails a message
+func (e *EmailNotifier) SendNotification(category, eventType string, message string, value *float64) error {
+	return e.events.SendNotification(category, eventType, message, value)
+}
+
+// SendSpeedTestNotification emails a speed test result, or its failure. Threshold
+// events are sent through SendSpeedTestThresholdNotification.
+func (e *EmailNotifier) SendSpeedTestNotification(result *SpeedTestResult) error {
+	if result.Failed {
+		return e.events.sendDirect(speedTestFailedMessage(result))
+	}
+	return e.events.sendDirect(e.events.formatSpeedTestMessage(result))
+}
+
+// SendSpeedTestThresholdNotification emails the threshold event of a metric
+func (e *EmailNotifier) SendSpeedTestThresholdNotification(result *SpeedTestResult, metric string) error {
+	return e.events.SendSpeedTestThresholdNotification(result, metric)
+}
+
+// SendSpeedTestRecoveredNotification emails that speed test results are back within their thresholds
+func (e *EmailNotifier) SendSpeedTestRecoveredNotification(result *SpeedTestResult) error {
+	return e.events.SendSpeedTestRecoveredNotification(result)
+}
+
+// SendPacketLossNotification emails a monitor going down, recovering or losing packets
+func (e *EmailNotifier) SendPacketLossNotification(monitorName string, host string, packetLoss float64, isDown bool, isRecovered bool) error {
+	return e.events.SendPacketLossNotification(monitorName, host, packetLoss, isDown, isRecovered)
+}
+
+// SendPacketLossWarningNotification emails a packet loss warning
+func (e *EmailNotifier) SendPacketLossWarningNotification(monitorName string, host string, packetLoss, threshold float64) error {
+	return e.events.SendPacketLossWarningNotification(monitorName, host, packetLoss, threshold)
+}
+
+// SendHopCountChangeNotification emails a route length change
+func (e *EmailNotifier) SendHopCountChangeNotification(monitorName string, host string, previousHops, currentHops int) error {
+	return e.events.SendHopCountChangeNotification(monitorName, host, previousHops, currentHops)
+}
+
+// SendAgentNotification emails an agent event
+func (e *EmailNotifier) SendAgentNotification(agentName string, tags []string, eventType string, value *float64) error {
+	return e.events.SendAgentNotification(agentName, tags, eventType, value)
+}
+
+// SendAgentThresholdNotification emails a resource event for an agent past its own threshold
+func (e *EmailNotifier) SendAgentThresholdNotification(agentName string, tags []string, eventType string, value, threshold float64) error {
+	return e.events.SendAgentThresholdNotification(agentName, tags, eventType, value, threshold)
+}
+
+// SendAgentBandwidthNotification emails a high bandwidth event for an interface
+func (e *EmailNotifier) SendAgentBandwidthNotification(agentName string, tags []string, iface string, mbps, threshold float64) error {
+	return e.events.SendAgentBandwidthNotification(agentName, tags, iface, mbps, threshold)
+}
+
+// SendTestNotification emails the test message
+func (e *EmailNotifier) SendTestNotification() error {
+	return e.events.SendTestNotification()
+}
+
+// Name identifies the backend in logs
+func (e *EmailNotifier) Name() string {
+	return "email"
+}
+
+// Send emails a notification message, using its headline as the subject
+func (e *EmailNotifier) Send(message string) error {
+	body := strings.ReplaceAll(message, "**", "")
+	msg := buildEmail(e.cfg.From, e.cfg.To, emailSubject(body), body, time.Now())
+
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: e.cfg.Host, MinVersion: tls.VersionTLS12}
+	dialer := &net.Dialer{Timeout: emailTimeout}
+
+	var conn net.Conn
+	var err error
+	if e.cfg.TLS == config.SMTPTLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(emailTimeout)); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to set SMTP deadline: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, e.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if e.cfg.TLS == config.SMTPTLSStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if e.cfg.Username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted connection to a remote host
+		if err := client.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(e.cfg.From); err != nil {
+		return fmt.Errorf("SMTP server rejected sender: %w", err)
+	}
+	for _, to := range e.cfg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("SMTP server rejected recipient %s: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start SMTP data: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected email: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// emailSubject returns the headline of a message, e.g. "[DOWN] Monitor Down"
+func emailSubject(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	line, _, _ = strings.Cut(line, " - ")
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "Netronome notification"
+	}
+	return "Netronome: " + line
+}
+
+// buildEmail renders a plain text email. Header values have line breaks removed so a
+// message can't inject headers.
+func buildEmail(from string, to []string, subject, body string, date time.Time) []byte {
+	clean := strings.NewReplacer("\r", "", "\n", " ")
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", clean.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", clean.Replace(strings.Join(to, ", ")))
+	fmt.Fprintf(&b, "Subject: %s\r\n", clean.Replace(subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
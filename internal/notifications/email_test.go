@@ -0,0 +1,110 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package notifications
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/database"
+)
+
+func TestEmailSubject(t *testing.T) {
+	assert.Equal(t, "Netronome: [DOWN] Monitor Down", emailSubject("[DOWN] Monitor Down - edge | Host: 1.1.1.1 | Unreachable"))
+	assert.Equal(t, "Netronome: [OK] Speed Test Complete", emailSubject("[OK] Speed Test Complete\nDownload: 900 Mbps"))
+	assert.Equal(t, "Netronome notification", emailSubject(""))
+}
+
+func TestBuildEmail(t *testing.T) {
+	date := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	msg := string(buildEmail("netronome@example.com", []string{"a@example.com", "b@example.com"}, "Netronome: alert\r\nBcc: evil@example.com", "line one\nline two", date))
+
+	assert.Contains(t, msg, "To: a@example.com, b@example.com\r\n")
+	assert.Contains(t, msg, "Subject: Netronome: alert Bcc: evil@example.com\r\n", "line breaks in headers are removed")
+	assert.Contains(t, msg, "Date: Fri, 02 Jan 2026 03:04:05 +0000\r\n")
+	assert.Contains(t, msg, "\r\n\r\nline one\r\nline two\r\n")
+}
+
+type recordingBackend struct {
+	mu       sync.Mutex
+	name     string
+	err      error
+	messages []string
+}
+
+func (b *recordingBackend) Name() string { return b.name }
+
+func (b *recordingBackend) Send(message string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = append(b.messages, message)
+	return b.err
+}
+
+func (b *recordingBackend) received() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.Clone(b.messages)
+}
+
+func TestSendBackendsFansOut(t *testing.T) {
+	failing := &recordingBackend{name: "failing", err: errors.New("connection refused")}
+	working := &recordingBackend{name: "working"}
+	n := &Notifier{backends: []backend{failing, working}}
+
+	err := n.sendBackends("[!] High CPU Usage")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failing")
+	assert.Equal(t, []string{"[!] High CPU Usage"}, working.messages, "a failing backend must not stop the others")
+	assert.Len(t, failing.messages, 1)
+}
+
+func TestSendEventBackendsWithoutRules(t *testing.T) {
+	email := &recordingBackend{name: "email"}
+	n := &Notifier{db: &cooldownRulesDB{}, backends: []backend{email}}
+
+	// Without a rule a value can't be judged, but events without one or carrying their
+	// own threshold still go out
+	cpu := 95.0
+	require.NoError(t, n.SendAgentNotification("agent1", nil, database.NotificationEventAgentHighCPU, &cpu))
+	require.NoError(t, n.SendAgentThresholdNotification("agent1", nil, database.NotificationEventAgentHighMemory, 95, 90))
+	require.NoError(t, n.SendPacketLossNotification("monitor", "1.1.1.1", 100, true, false))
+
+	require.Eventually(t, func() bool { return len(email.received()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	messages := email.received()
+	slices.Sort(messages)
+	assert.True(t, strings.HasPrefix(messages[0], "[DOWN] Monitor Down"), messages[0])
+	assert.True(t, strings.HasPrefix(messages[1], "[MEM] High Memory Usage"), messages[1])
+}
+
+func TestEmailNotifierSender(t *testing.T) {
+	e := NewEmailNotifier(config.SMTPConfig{Host: "smtp.example.com"})
+	var sent []string
+	e.events.deliver = func(message string) error {
+		sent = append(sent, message)
+		return nil
+	}
+
+	cpu := 95.0
+	require.NoError(t, e.SendAgentNotification("agent1", nil, database.NotificationEventAgentHighCPU, &cpu))
+	require.NoError(t, e.SendPacketLossNotification("monitor", "1.1.1.1", 0, false, true))
+	require.NoError(t, e.SendSpeedTestNotification(&SpeedTestResult{ServerName: "edge", Download: 900, Ping: 5}))
+	require.NoError(t, e.SendSpeedTestNotification(&SpeedTestResult{ServerName: "edge", Failed: true}))
+	require.NoError(t, e.SendTestNotification())
+
+	require.Len(t, sent, 5, "a speed test is one email, without threshold events")
+	assert.Contains(t, sent[0], "[CPU] High CPU Usage")
+	assert.Contains(t, sent[1], "[OK] Monitor Recovered")
+	assert.Contains(t, sent[2], "[SPEEDTEST] Speed Test Results")
+	assert.Contains(t, sent[3], "[FAIL] Speed Test Failed")
+	assert.Equal(t, TestMessage, sent[4])
+}
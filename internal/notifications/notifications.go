@@ -4,29 +4,117 @@
 package notifications
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/containrrr/shoutrrr"
 	"github.com/containrrr/shoutrrr/pkg/router"
+	"github.com/containrrr/shoutrrr/pkg/types"
 	"github.com/rs/zerolog/log"
 
+	"github.com/autobrr/netronome/internal/config"
 	"github.com/autobrr/netronome/internal/database"
 )
 
+// Sender is the set of send methods shared by the Notifier and the EmailNotifier
+type Sender interface {
+	SendNotification(category, eventType string, message string, value *float64) error
+	SendSpeedTestNotification(result *SpeedTestResult) error
+	SendSpeedTestThresholdNotification(result *SpeedTestResult, metric string) error
+	SendSpeedTestRecoveredNotification(result *SpeedTestResult) error
+	SendPacketLossNotification(monitorName string, host string, packetLoss float64, isDown bool, isRecovered bool) error
+	SendPacketLossWarningNotification(monitorName string, host string, packetLoss, threshold float64) error
+	SendHopCountChangeNotification(monitorName string, host string, previousHops, currentHops int) error
+	SendAgentNotification(agentName string, tags []string, eventType string, value *float64) error
+	SendAgentThresholdNotification(agentName string, tags []string, eventType string, value, threshold float64) error
+	SendAgentBandwidthNotification(agentName string, tags []string, iface string, mbps, threshold float64) error
+	SendTestNotification() error
+}
+
+var (
+	_ Sender = (*Notifier)(nil)
+	_ Sender = (*EmailNotifier)(nil)
+)
+
 type Notifier struct {
-	db       database.NotificationService
-	router   *router.ServiceRouter
-	ntfyURLs []string
+	db          database.NotificationService
+	router      *router.ServiceRouter
+	ntfyURLs    []string
+	webhookURLs []string
+
+	// deliver replaces the direct endpoints of a notifier without a database, see
+	// NewEmailNotifier
+	deliver func(message string) error
+
+	// backendsMu guards backends and quietHours, which change on reload
+	backendsMu sync.RWMutex
+	backends   []backend
+	quietHours *quietHours
+
+	cooldownsMu sync.Mutex
+	cooldowns   map[string]cachedCooldown // by event type
+}
+
+// cooldownCacheTTL is how long rule cooldowns are cached; live bandwidth samples ask
+// for one every second per agent
+const cooldownCacheTTL = 30 * time.Second
+
+// cachedCooldown is the rules of an agent event that set a cooldown, as of fetchedAt
+type cachedCooldown struct {
+	rules     []ruleCooldown
+	fetchedAt time.Time
+}
+
+// ruleCooldown is the cooldown of a rule, limited to agents with agentTag when set
+type ruleCooldown struct {
+	agentTag *string
+	cooldown time.Duration
 }
 
-// NewNotifier creates a new notifier with database support
-func NewNotifier(db database.NotificationService) (*Notifier, error) {
+// backend receives every notification that fires, in addition to the channels
+// configured in the database
+type backend interface {
+	Name() string
+	Send(message string) error
+}
+
+// NewNotifier creates a new notifier with database support, fanning out to the
+// backends configured in cfg such as email
+func NewNotifier(db database.NotificationService, cfg *config.Config) (*Notifier, error) {
 	return &Notifier{
-		db: db,
+		db:         db,
+		backends:   configuredBackends(cfg),
+		quietHours: configuredQuietHours(cfg),
 	}, nil
 }
 
+// configuredBackends returns the notification backends enabled in the config file
+func configuredBackends(cfg *config.Config) []backend {
+	var backends []backend
+	if cfg != nil && cfg.SMTP.Host != "" {
+		backends = append(backends, NewEmailNotifier(cfg.SMTP))
+	}
+	return backends
+}
+
+// configuredQuietHours returns the quiet hours window from the config file, if any
+func configuredQuietHours(cfg *config.Config) *quietHours {
+	if cfg == nil {
+		return nil
+	}
+	q, err := newQuietHours(cfg.Notifications.QuietHours)
+	if err != nil {
+		log.Warn().Err(err).Msg("Invalid notifications.quiet_hours, notifications won't be suppressed")
+		return nil
+	}
+	return q
+}
+
 // NewNotifierFromURLs creates a temporary notifier for testing
 func NewNotifierFromURLs(urls []string) (*Notifier, error) {
 	if len(urls) == 0 {
@@ -35,12 +123,15 @@ func NewNotifierFromURLs(urls []string) (*Notifier, error) {
 
 	notifier := &Notifier{}
 
-	// Separate ntfy URLs from other URLs since we handle ntfy directly
+	// Separate ntfy and plain webhook URLs from other URLs since we handle them directly
 	var shoutrrrURLs []string
 	for _, u := range urls {
-		if isNtfyURL(u) {
+		switch {
+		case isNtfyURL(u):
 			notifier.ntfyURLs = append(notifier.ntfyURLs, u)
-		} else {
+		case isWebhookURL(u):
+			notifier.webhookURLs = append(notifier.webhookURLs, u)
+		default:
 			shoutrrrURLs = append(shoutrrrURLs, u)
 		}
 	}
@@ -69,12 +160,96 @@ func ValidateNotificationURL(rawURL string) error {
 		_, err := parseNtfyURL(rawURL)
 		return err
 	}
+	if isWebhookURL(rawURL) {
+		return validateWebhookURL(rawURL)
+	}
 
 	// Shoutrrr validation is primarily URL parsing/initialization, no network calls.
 	_, err := shoutrrr.CreateSender(rawURL)
 	return err
 }
 
+// Reload applies a reloaded configuration. Only the file-based backends such as email
+// and quiet hours change: notification channels, rules and thresholds live in the database and are
+// read on every send, so changes made in the UI already take effect without a reload.
+func (n *Notifier) Reload(cfg *config.Config) {
+	backends := configuredBackends(cfg)
+	quiet := configuredQuietHours(cfg)
+
+	n.backendsMu.Lock()
+	n.backends = backends
+	n.quietHours = quiet
+	n.backendsMu.Unlock()
+
+	log.Debug().Int("backends", len(backends)).Msg("Reloaded notification backends")
+}
+
+// hasBackends reports whether any backend is configured
+func (n *Notifier) hasBackends() bool {
+	n.backendsMu.RLock()
+	defer n.backendsMu.RUnlock()
+	return len(n.backends) > 0
+}
+
+// sendBackends delivers a message to every backend concurrently, so a slow or failing
+// backend doesn't hold up the others. Events are handed to it on a goroutine of their
+// own, so a slow mail server doesn't hold up the caller either.
+func (n *Notifier) sendBackends(message string) error {
+	n.backendsMu.RLock()
+	backends := n.backends
+	n.backendsMu.RUnlock()
+
+	errs := make([]error, len(backends))
+	var wg sync.WaitGroup
+	for i, b := range backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := b.Send(message); err != nil {
+				log.Error().Err(err).Str("backend", b.Name()).Msg("Failed to send notification")
+				errs[i] = fmt.Errorf("%s: %w", b.Name(), err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// SendRaw sends a message to a single channel URL. Shoutrrr service URLs such as
+// telegram://, slack://, gotify:// or discord:// go through Shoutrrr, ntfy:// and plain
+// http(s) webhooks are sent directly. The title is optional.
+func (n *Notifier) SendRaw(channelURL, title, body string) error {
+	channelURL = strings.TrimSpace(channelURL)
+
+	switch {
+	case isNtfyURL(channelURL):
+		if title != "" {
+			body = title + "\n\n" + body
+		}
+		return sendNtfy(channelURL, body)
+	case isWebhookURL(channelURL):
+		return sendWebhook(channelURL, title, body)
+	}
+
+	sender, err := shoutrrr.CreateSender(channelURL)
+	if err != nil {
+		return fmt.Errorf("failed to create notifier for channel: %w", err)
+	}
+
+	var params *types.Params
+	if title != "" {
+		params = &types.Params{"title": title}
+	}
+	for _, err := range sender.Send(body, params) {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // getThresholdForEvent retrieves the threshold value for a specific event from the database
 func (n *Notifier) getThresholdForEvent(category, eventType string) *float64 {
 	if n.db == nil {
@@ -98,8 +273,115 @@ func (n *Notifier) getThresholdForEvent(category, eventType string) *float64 {
 	return nil
 }
 
-// SendNotification sends a notification for a specific event
+// GetAgentCooldown returns the shortest cooldown set on the enabled rules of an agent
+// event that apply to an agent with these tags, so the noisiest rule decides, or false
+// when none of them sets one
+func (n *Notifier) GetAgentCooldown(eventType string, tags []string) (time.Duration, bool) {
+	if n == nil || n.db == nil {
+		return 0, false
+	}
+
+	rules, ok := n.cooldownRules(eventType)
+	if !ok {
+		return 0, false
+	}
+
+	var shortest time.Duration
+	found := false
+	for _, rule := range rules {
+		if rule.agentTag != nil && !slices.Contains(tags, *rule.agentTag) {
+			continue
+		}
+		if !found || rule.cooldown < shortest {
+			shortest = rule.cooldown
+			found = true
+		}
+	}
+	return shortest, found
+}
+
+// cooldownRules returns the enabled rules of an agent event that set a cooldown, from
+// the cache when it is fresh. The database is queried without holding cooldownsMu.
+func (n *Notifier) cooldownRules(eventType string) ([]ruleCooldown, bool) {
+	n.cooldownsMu.Lock()
+	cached, ok := n.cooldowns[eventType]
+	n.cooldownsMu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < cooldownCacheTTL {
+		return cached.rules, true
+	}
+
+	rules, err := n.db.GetEnabledRulesForEvent(database.NotificationCategoryAgent, eventType)
+	if err != nil {
+		log.Error().Err(err).Str("eventType", eventType).Msg("Failed to get notification rules for cooldown")
+		return nil, false
+	}
+
+	cached = cachedCooldown{fetchedAt: time.Now()}
+	for _, rule := range rules {
+		if rule.CooldownSeconds == nil || *rule.CooldownSeconds <= 0 {
+			continue
+		}
+		cached.rules = append(cached.rules, ruleCooldown{
+			agentTag: rule.AgentTag,
+			cooldown: time.Duration(*rule.CooldownSeconds) * time.Second,
+		})
+	}
+
+	n.cooldownsMu.Lock()
+	if n.cooldowns == nil {
+		n.cooldowns = make(map[string]cachedCooldown)
+	}
+	n.cooldowns[eventType] = cached
+	n.cooldownsMu.Unlock()
+
+	return cached.rules, true
+}
+
+// InvalidateCooldowns drops the cached rule cooldowns, so changed rules apply to the
+// next alert instead of after cooldownCacheTTL
+func (n *Notifier) InvalidateCooldowns() {
+	if n == nil {
+		return
+	}
+	n.cooldownsMu.Lock()
+	defer n.cooldownsMu.Unlock()
+	n.cooldowns = nil
+}
+
+// inQuietHours reports whether an event should be logged as suppressed instead of sent
+func (n *Notifier) inQuietHours(category, eventType string, now time.Time) bool {
+	if !suppressible(category, eventType) {
+		return false
+	}
+	n.backendsMu.RLock()
+	defer n.backendsMu.RUnlock()
+	return n.quietHours.contains(now)
+}
+
+// SendNotification sends a notification for a specific event. Agent and packet loss
+// alerts during quiet hours are only recorded in the history as suppressed, and
+// ErrSuppressed is returned.
 func (n *Notifier) SendNotification(category, eventType string, message string, value *float64) error {
+	return n.sendEvent(PayloadData{Category: category, EventType: eventType, Message: message, Value: value}, nil)
+}
+
+// sendEvent sends an event to its rules. Rules limited to an agent tag only fire when
+// agentTags contains it. Channels with a payload template get the rendered template,
+// the rest get the message. An event's value is checked against the rule thresholds
+// unless the event was already judged by its own threshold.
+//
+// Backends such as email don't need a rule: they get every event without a value to
+// judge, or judged by its own threshold, and an event judged by its value when a rule
+// fires for it.
+func (n *Notifier) sendEvent(data PayloadData, agentTags []string) error {
+	category, eventType, message, value := data.Category, data.EventType, data.Message, data.Value
+	if data.ownThreshold {
+		value = nil
+	}
+	if data.Timestamp.IsZero() {
+		data.Timestamp = time.Now()
+	}
+
 	if n.db == nil {
 		return n.sendDirect(message)
 	}
@@ -110,20 +392,25 @@ func (n *Notifier) SendNotification(category, eventType string, message string,
 		return fmt.Errorf("failed to get notification rules: %w", err)
 	}
 
-	if len(rules) == 0 {
-		return nil
-	}
+	quiet := n.inQuietHours(category, eventType, time.Now())
 
 	var lastError error
 	successCount := 0
+	suppressedCount := 0
+	fired := value == nil
 
 	for _, rule := range rules {
+		if rule.AgentTag != nil && !slices.Contains(agentTags, *rule.AgentTag) {
+			continue
+		}
+
 		// Check threshold if applicable
 		if value != nil && rule.ThresholdValue != nil {
 			if !n.db.CheckThreshold(&rule, *value) {
 				continue
 			}
 		}
+		fired = true
 
 		// Send notification
 		if rule.Channel == nil {
@@ -134,45 +421,16 @@ func (n *Notifier) SendNotification(category, eventType string, message string,
 			continue
 		}
 
-		if rule.Channel.URL != "" {
-			var sendErr error
-
-			if isNtfyURL(rule.Channel.URL) {
-				sendErr = sendNtfy(rule.Channel.URL, message)
-			} else {
-				tempNotifier, err := shoutrrr.CreateSender(rule.Channel.URL)
-				if err != nil {
-					lastError = err
-					errMsg := err.Error()
-					if logErr := n.db.LogNotification(rule.ChannelID, rule.EventID, false, &errMsg, &message); logErr != nil {
-						log.Error().Err(logErr).Msg("Failed to log notification error")
-					}
-					log.Error().
-						Err(err).
-						Int64("channelID", rule.ChannelID).
-						Msg("Failed to create notifier for channel")
-					continue
-				}
-
-				if tempNotifier == nil {
-					lastError = fmt.Errorf("notifier is nil")
-					errMsg := "notifier is nil"
-					if logErr := n.db.LogNotification(rule.ChannelID, rule.EventID, false, &errMsg, &message); logErr != nil {
-						log.Error().Err(logErr).Msg("Failed to log notification error")
-					}
-					continue
-				}
-
-				errs := tempNotifier.Send(message, nil)
-				for _, err := range errs {
-					if err != nil {
-						sendErr = err
-						break
-					}
-				}
+		if quiet {
+			suppressedCount++
+			if logErr := n.db.LogSuppressedNotification(rule.ChannelID, rule.EventID, &message); logErr != nil {
+				log.Error().Err(logErr).Msg("Failed to log suppressed notification")
 			}
+			continue
+		}
 
-			if sendErr != nil {
+		if rule.Channel.URL != "" {
+			if sendErr := n.sendToChannel(rule.Channel, data, rule.ThresholdValue); sendErr != nil {
 				lastError = sendErr
 				errMsg := sendErr.Error()
 				if logErr := n.db.LogNotification(rule.ChannelID, rule.EventID, false, &errMsg, &message); logErr != nil {
@@ -191,6 +449,25 @@ func (n *Notifier) SendNotification(category, eventType string, message string,
 		}
 	}
 
+	// Backends get the event once however many rules fired, in the background; their
+	// failures are logged and don't affect the channel result
+	if fired && n.hasBackends() {
+		if quiet {
+			suppressedCount++
+		} else {
+			go func() { _ = n.sendBackends(message) }()
+		}
+	}
+
+	if suppressedCount > 0 {
+		log.Debug().
+			Int("suppressed", suppressedCount).
+			Str("category", category).
+			Str("eventType", eventType).
+			Msg("Notifications suppressed during quiet hours")
+		return ErrSuppressed
+	}
+
 	if successCount == 0 && lastError != nil {
 		return fmt.Errorf("failed to send any notifications: %w", lastError)
 	}
@@ -207,60 +484,186 @@ func (n *Notifier) SendNotification(category, eventType string, message string,
 	return nil
 }
 
-// SendSpeedTestNotification sends a speed test notification
+// sendToChannel delivers an event to a channel, posting its rendered payload template
+// when it has one. ruleThreshold fills in the threshold when the event carries none.
+func (n *Notifier) sendToChannel(channel *database.NotificationChannel, data PayloadData, ruleThreshold *float64) error {
+	if channel.PayloadTemplate == nil || *channel.PayloadTemplate == "" {
+		return n.SendRaw(channel.URL, "", data.Message)
+	}
+
+	if err := validateWebhookURL(channel.URL); err != nil {
+		return err
+	}
+	if data.Threshold == nil {
+		data.Threshold = ruleThreshold
+	}
+	payload, err := renderPayload(*channel.PayloadTemplate, data)
+	if err != nil {
+		return err
+	}
+	return postWebhook(channel.URL, payload)
+}
+
+// SendSpeedTestNotification sends a speed test notification. The ping, download and
+// upload threshold events only go out here for metrics with no rule threshold; the
+// result handler sends the others when a result crosses one, see SpeedTestBreaches.
 func (n *Notifier) SendSpeedTestNotification(result *SpeedTestResult) error {
 	// Check for various conditions
 	if result.Failed {
-		message := fmt.Sprintf("[FAIL] Speed Test Failed - Provider: **%s** | Server: **%s**", result.Provider, result.ServerName)
-		return n.SendNotification(database.NotificationCategorySpeedtest, database.NotificationEventSpeedtestFailed, message, nil)
+		message := speedTestFailedMessage(result)
+		return n.sendEvent(PayloadData{Category: database.NotificationCategorySpeedtest, EventType: database.NotificationEventSpeedtestFailed, Name: result.ServerName, Message: message}, nil)
 	}
 
 	// Send test completion notification
 	completeMessage := n.formatSpeedTestMessage(result)
-	if err := n.SendNotification(database.NotificationCategorySpeedtest, database.NotificationEventSpeedtestComplete, completeMessage, nil); err != nil {
+	if err := n.sendEvent(PayloadData{Category: database.NotificationCategorySpeedtest, EventType: database.NotificationEventSpeedtestComplete, Name: result.ServerName, Message: completeMessage}, nil); err != nil {
 		log.Error().Err(err).Msg("Failed to send speed test complete notification")
 	}
 
-	// Check thresholds and send specific messages for violations
-	if result.Ping > 0 {
-		pingThreshold := n.getThresholdForEvent(database.NotificationCategorySpeedtest, database.NotificationEventSpeedtestPingHigh)
-		pingMessage := n.formatHighPingMessage(result, pingThreshold)
-		if err := n.SendNotification(database.NotificationCategorySpeedtest, database.NotificationEventSpeedtestPingHigh, pingMessage, &result.Ping); err != nil {
-			log.Error().Err(err).Msg("Failed to send high ping notification")
+	// Metrics whose rules set a threshold are sent on crossings by the result handler
+	for _, metric := range speedTestThresholdMetrics {
+		if speedTestMetricValue(result, metric) <= 0 || n.hasThresholdRules(speedTestThresholdEvents[metric]) {
+			continue
+		}
+		if err := n.SendSpeedTestThresholdNotification(result, metric); err != nil {
+			log.Error().Err(err).Str("metric", metric).Msg("Failed to send speed test threshold notification")
 		}
 	}
 
-	if result.Download > 0 {
-		downloadMbps := result.Download
-		downloadThreshold := n.getThresholdForEvent(database.NotificationCategorySpeedtest, database.NotificationEventSpeedtestDownloadLow)
-		downloadMessage := n.formatLowDownloadMessage(result, downloadThreshold)
-		if err := n.SendNotification(database.NotificationCategorySpeedtest, database.NotificationEventSpeedtestDownloadLow, downloadMessage, &downloadMbps); err != nil {
-			log.Error().Err(err).Msg("Failed to send low download notification")
+	if result.DownUpRatio > 0 && result.BaselineRatio > 0 {
+		changePercent := math.Abs(result.DownUpRatio-result.BaselineRatio) / result.BaselineRatio * 100
+		ratioThreshold := n.getThresholdForEvent(database.NotificationCategorySpeedtest, database.NotificationEventSpeedtestRatioShift)
+		ratioMessage := n.formatRatioShiftMessage(result, changePercent, ratioThreshold)
+		if err := n.sendEvent(PayloadData{Category: database.NotificationCategorySpeedtest, EventType: database.NotificationEventSpeedtestRatioShift, Name: result.ServerName, Message: ratioMessage, Value: &changePercent}, nil); err != nil {
+			log.Error().Err(err).Msg("Failed to send ratio shift notification")
 		}
 	}
 
-	if result.Upload > 0 {
-		uploadMbps := result.Upload
-		uploadThreshold := n.getThresholdForEvent(database.NotificationCategorySpeedtest, database.NotificationEventSpeedtestUploadLow)
-		uploadMessage := n.formatLowUploadMessage(result, uploadThreshold)
-		if err := n.SendNotification(database.NotificationCategorySpeedtest, database.NotificationEventSpeedtestUploadLow, uploadMessage, &uploadMbps); err != nil {
-			log.Error().Err(err).Msg("Failed to send low upload notification")
+	return nil
+}
+
+// speedTestFailedMessage formats the notification for a failed speed test
+func speedTestFailedMessage(result *SpeedTestResult) string {
+	return fmt.Sprintf("[FAIL] Speed Test Failed - Provider: **%s** | Server: **%s**", result.Provider, result.ServerName)
+}
+
+// Speed test metrics with a threshold event
+const (
+	SpeedTestMetricPing     = "ping"
+	SpeedTestMetricDownload = "download"
+	SpeedTestMetricUpload   = "upload"
+)
+
+var speedTestThresholdMetrics = []string{SpeedTestMetricPing, SpeedTestMetricDownload, SpeedTestMetricUpload}
+
+// speedTestThresholdEvents maps each metric onto its threshold event
+var speedTestThresholdEvents = map[string]string{
+	SpeedTestMetricPing:     database.NotificationEventSpeedtestPingHigh,
+	SpeedTestMetricDownload: database.NotificationEventSpeedtestDownloadLow,
+	SpeedTestMetricUpload:   database.NotificationEventSpeedtestUploadLow,
+}
+
+// speedTestMetricValue returns a metric of a result, 0 when the result didn't measure it
+func speedTestMetricValue(result *SpeedTestResult, metric string) float64 {
+	switch metric {
+	case SpeedTestMetricPing:
+		return result.Ping
+	case SpeedTestMetricDownload:
+		return result.Download
+	case SpeedTestMetricUpload:
+		return result.Upload
+	}
+	return 0
+}
+
+// hasThresholdRules reports whether an enabled rule of a speed test event sets a threshold
+func (n *Notifier) hasThresholdRules(eventType string) bool {
+	if n.db == nil {
+		return false
+	}
+	rules, err := n.db.GetEnabledRulesForEvent(database.NotificationCategorySpeedtest, eventType)
+	if err != nil {
+		log.Error().Err(err).Str("eventType", eventType).Msg("Failed to get notification rules for threshold")
+		return false
+	}
+	for _, rule := range rules {
+		if rule.ThresholdValue != nil {
+			return true
 		}
 	}
+	return false
+}
 
-	return nil
+// SpeedTestBreaches reports for each metric the result measured whether it is past the
+// threshold of an enabled rule of the metric's event. Metrics whose rules set no
+// threshold are left out, as are metrics the result didn't measure.
+func (n *Notifier) SpeedTestBreaches(result *SpeedTestResult) map[string]bool {
+	breaches := make(map[string]bool)
+	if n.db == nil {
+		return breaches
+	}
+
+	for _, metric := range speedTestThresholdMetrics {
+		value := speedTestMetricValue(result, metric)
+		if value <= 0 {
+			continue
+		}
+		rules, err := n.db.GetEnabledRulesForEvent(database.NotificationCategorySpeedtest, speedTestThresholdEvents[metric])
+		if err != nil {
+			log.Error().Err(err).Str("metric", metric).Msg("Failed to get notification rules for threshold")
+			continue
+		}
+		for _, rule := range rules {
+			if rule.ThresholdValue == nil {
+				continue
+			}
+			breaches[metric] = breaches[metric] || n.db.CheckThreshold(&rule, value)
+		}
+	}
+
+	return breaches
+}
+
+// SendSpeedTestThresholdNotification sends the threshold event of a metric: high ping,
+// low download or low upload. Rules whose threshold the value is within don't fire.
+func (n *Notifier) SendSpeedTestThresholdNotification(result *SpeedTestResult, metric string) error {
+	eventType, ok := speedTestThresholdEvents[metric]
+	if !ok {
+		return fmt.Errorf("unknown speed test metric %q", metric)
+	}
+
+	threshold := n.getThresholdForEvent(database.NotificationCategorySpeedtest, eventType)
+	var message string
+	switch metric {
+	case SpeedTestMetricPing:
+		message = n.formatHighPingMessage(result, threshold)
+	case SpeedTestMetricDownload:
+		message = n.formatLowDownloadMessage(result, threshold)
+	case SpeedTestMetricUpload:
+		message = n.formatLowUploadMessage(result, threshold)
+	}
+
+	value := speedTestMetricValue(result, metric)
+	return n.sendEvent(PayloadData{Category: database.NotificationCategorySpeedtest, EventType: eventType, Name: result.ServerName, Message: message, Value: &value}, nil)
+}
+
+// SendSpeedTestRecoveredNotification sends a notification when results are back within
+// the thresholds of every metric that crossed one
+func (n *Notifier) SendSpeedTestRecoveredNotification(result *SpeedTestResult) error {
+	message := fmt.Sprintf("[OK] Speed Test Recovered%s\n%s", speedTestSource(result), strings.Join(speedTestMetrics(result), " | "))
+	return n.sendEvent(PayloadData{Category: database.NotificationCategorySpeedtest, EventType: database.NotificationEventSpeedtestRecovered, Name: result.ServerName, Message: message}, nil)
 }
 
 // SendPacketLossNotification sends a packet loss notification
 func (n *Notifier) SendPacketLossNotification(monitorName string, host string, packetLoss float64, isDown bool, isRecovered bool) error {
 	if isRecovered {
 		message := fmt.Sprintf("[OK] Monitor Recovered - **%s** | Host: **%s** | Back Online", monitorName, host)
-		return n.SendNotification(database.NotificationCategoryPacketLoss, database.NotificationEventPacketLossRecovered, message, nil)
+		return n.sendEvent(PayloadData{Category: database.NotificationCategoryPacketLoss, EventType: database.NotificationEventPacketLossRecovered, Name: monitorName, Message: message}, nil)
 	}
 
 	if isDown {
 		message := fmt.Sprintf("[DOWN] Monitor Down - **%s** | Host: **%s** | Unreachable (100%% packet loss)", monitorName, host)
-		return n.SendNotification(database.NotificationCategoryPacketLoss, database.NotificationEventPacketLossDown, message, nil)
+		return n.sendEvent(PayloadData{Category: database.NotificationCategoryPacketLoss, EventType: database.NotificationEventPacketLossDown, Name: monitorName, Message: message}, nil)
 	}
 
 	// High packet loss
@@ -271,12 +674,70 @@ func (n *Notifier) SendPacketLossNotification(monitorName string, host string, p
 	} else {
 		message = fmt.Sprintf("[!] High Packet Loss - **%s** | Host: **%s** | Loss: **%.1f%%**", monitorName, host, packetLoss)
 	}
-	return n.SendNotification(database.NotificationCategoryPacketLoss, database.NotificationEventPacketLossHigh, message, &packetLoss)
+	return n.sendEvent(PayloadData{Category: database.NotificationCategoryPacketLoss, EventType: database.NotificationEventPacketLossHigh, Name: monitorName, Message: message, Value: &packetLoss}, nil)
+}
+
+// SendPacketLossWarningNotification sends a packet loss warning for a monitor whose loss
+// passed its warning threshold but not its critical one
+func (n *Notifier) SendPacketLossWarningNotification(monitorName string, host string, packetLoss, threshold float64) error {
+	message := fmt.Sprintf("[WARN] Packet Loss Warning - **%s** | Host: **%s** | Loss: **%.1f%%** (warning: %.0f%%)", monitorName, host, packetLoss, threshold)
+	return n.sendEvent(PayloadData{Category: database.NotificationCategoryPacketLoss, EventType: database.NotificationEventPacketLossWarning, Name: monitorName, Message: message, Value: &packetLoss, Threshold: &threshold}, nil)
+}
+
+// SendHopCountChangeNotification sends a route length change notification. The hop
+// difference is checked against the rule threshold, so rules can ignore small changes.
+func (n *Notifier) SendHopCountChangeNotification(monitorName string, host string, previousHops, currentHops int) error {
+	delta := math.Abs(float64(currentHops - previousHops))
+	message := fmt.Sprintf("[ROUTE] Hop Count Changed - **%s** | Host: **%s** | Hops: **%d** -> **%d**", monitorName, host, previousHops, currentHops)
+	return n.sendEvent(PayloadData{Category: database.NotificationCategoryPacketLoss, EventType: database.NotificationEventPacketLossHopChange, Name: monitorName, Message: message, Value: &delta}, nil)
+}
+
+// SendAgentNotification sends an agent-related notification to the rules matching the agent's tags.
+// For temperature notifications, agentName can include sensor info in format "agent|sensor",
+// and for bandwidth notifications the interface name in the same way
+func (n *Notifier) SendAgentNotification(agentName string, tags []string, eventType string, value *float64) error {
+	// Get threshold for the event type
+	threshold := n.getThresholdForEvent(database.NotificationCategoryAgent, eventType)
+
+	message, err := formatAgentMessage(agentName, eventType, value, threshold)
+	if err != nil {
+		return err
+	}
+
+	data := PayloadData{
+		Category:  database.NotificationCategoryAgent,
+		EventType: eventType,
+		Name:      strings.Split(agentName, "|")[0],
+		Value:     value,
+		Message:   message,
+	}
+	return n.sendEvent(data, tags)
+}
+
+// SendAgentThresholdNotification sends a resource notification for an agent whose own
+// threshold was exceeded. Like per-interface bandwidth thresholds, the agent threshold
+// replaces the rule threshold, so the rules aren't checked again.
+func (n *Notifier) SendAgentThresholdNotification(agentName string, tags []string, eventType string, value, threshold float64) error {
+	message, err := formatAgentMessage(agentName, eventType, &value, &threshold)
+	if err != nil {
+		return err
+	}
+
+	data := PayloadData{
+		Category:     database.NotificationCategoryAgent,
+		EventType:    eventType,
+		Name:         strings.Split(agentName, "|")[0],
+		Value:        &value,
+		Threshold:    &threshold,
+		Message:      message,
+		ownThreshold: true,
+	}
+	return n.sendEvent(data, tags)
 }
 
-// SendAgentNotification sends an agent-related notification
-// For temperature notifications, agentName can include sensor info in format "agent|sensor"
-func (n *Notifier) SendAgentNotification(agentName string, eventType string, value *float64) error {
+// formatAgentMessage formats an agent event. agentName may carry sensor or interface
+// details after a "|".
+func formatAgentMessage(agentName, eventType string, value, threshold *float64) (string, error) {
 	var message string
 
 	// Parse agent name and optional sensor info
@@ -287,24 +748,13 @@ func (n *Notifier) SendAgentNotification(agentName string, eventType string, val
 		sensorInfo = parts[1]
 	}
 
-	// Get threshold for the event type
-	threshold := n.getThresholdForEvent(database.NotificationCategoryAgent, eventType)
-
 	switch eventType {
 	case database.NotificationEventAgentOffline:
 		message = fmt.Sprintf("[OFFLINE] Agent Offline - **%s** | Connection lost", actualAgentName)
 	case database.NotificationEventAgentOnline:
 		message = fmt.Sprintf("[ONLINE] Agent Online - **%s** | Connection restored", actualAgentName)
 	case database.NotificationEventAgentHighBandwidth:
-		if value != nil {
-			if threshold != nil {
-				message = fmt.Sprintf("[!] High Bandwidth Usage - Agent: **%s** | Bandwidth: **%.1f Mbps** (threshold: %.0f Mbps)", actualAgentName, *value, *threshold)
-			} else {
-				message = fmt.Sprintf("[!] High Bandwidth Usage - Agent: **%s** | Bandwidth: **%.1f Mbps**", actualAgentName, *value)
-			}
-		} else {
-			message = fmt.Sprintf("[!] High Bandwidth Usage - Agent: **%s** | Bandwidth: **Unknown**", actualAgentName)
-		}
+		message = formatBandwidthMessage(actualAgentName, sensorInfo, value, threshold)
 	case database.NotificationEventAgentLowDisk:
 		if value != nil {
 			if threshold != nil {
@@ -335,6 +785,16 @@ func (n *Notifier) SendAgentNotification(agentName string, eventType string, val
 		} else {
 			message = fmt.Sprintf("[MEM] High Memory Usage - Agent: **%s** | Memory: **Unknown**", actualAgentName)
 		}
+	case database.NotificationEventAgentHighSwap:
+		if value != nil {
+			if threshold != nil {
+				message = fmt.Sprintf("[SWAP] High Swap Usage - Agent: **%s** | Swap: **%.1f%%** (threshold: %.0f%%)", actualAgentName, *value, *threshold)
+			} else {
+				message = fmt.Sprintf("[SWAP] High Swap Usage - Agent: **%s** | Swap: **%.1f%%**", actualAgentName, *value)
+			}
+		} else {
+			message = fmt.Sprintf("[SWAP] High Swap Usage - Agent: **%s** | Swap: **Unknown**", actualAgentName)
+		}
 	case database.NotificationEventAgentHighTemp:
 		if value != nil {
 			if sensorInfo != "" {
@@ -353,26 +813,79 @@ func (n *Notifier) SendAgentNotification(agentName string, eventType string, val
 		} else {
 			message = fmt.Sprintf("[TEMP] High Temperature - Agent: **%s** | Temperature: **Unknown**", actualAgentName)
 		}
+	case database.NotificationEventAgentRebooted:
+		// value is the uptime since the reboot, in seconds
+		if value != nil {
+			uptime := time.Duration(*value) * time.Second
+			message = fmt.Sprintf("[REBOOT] Agent Rebooted - **%s** | Up for **%s**", actualAgentName, uptime)
+		} else {
+			message = fmt.Sprintf("[REBOOT] Agent Rebooted - **%s**", actualAgentName)
+		}
+	case database.NotificationEventAgentDiskFailing:
+		// sensorInfo names the disk and its SMART state
+		if sensorInfo != "" {
+			message = fmt.Sprintf("[SMART] Disk Failing - Agent: **%s** | **%s**", actualAgentName, sensorInfo)
+		} else {
+			message = fmt.Sprintf("[SMART] Disk Failing - Agent: **%s**", actualAgentName)
+		}
 	default:
-		return fmt.Errorf("unknown agent event type: %s", eventType)
+		return "", fmt.Errorf("unknown agent event type: %s", eventType)
 	}
 
 	if message == "" {
-		return fmt.Errorf("empty notification message for event type: %s", eventType)
+		return "", fmt.Errorf("empty notification message for event type: %s", eventType)
 	}
 
-	return n.SendNotification(database.NotificationCategoryAgent, eventType, message, value)
+	return message, nil
 }
 
+// SendAgentBandwidthNotification sends a high bandwidth notification for an interface whose
+// per-interface threshold was exceeded. The rule threshold is not applied on top, since the
+// per-interface threshold replaces it for that interface.
+func (n *Notifier) SendAgentBandwidthNotification(agentName string, tags []string, iface string, mbps, threshold float64) error {
+	message := formatBandwidthMessage(agentName, iface, &mbps, &threshold)
+	data := PayloadData{
+		Category:     database.NotificationCategoryAgent,
+		EventType:    database.NotificationEventAgentHighBandwidth,
+		Name:         agentName,
+		Value:        &mbps,
+		Threshold:    &threshold,
+		Message:      message,
+		ownThreshold: true,
+	}
+	return n.sendEvent(data, tags)
+}
+
+// formatBandwidthMessage formats a high bandwidth message, naming the interface when known
+func formatBandwidthMessage(agentName, iface string, value, threshold *float64) string {
+	subject := "Bandwidth"
+	if iface != "" {
+		subject = iface
+	}
+	if value == nil {
+		return fmt.Sprintf("[!] High Bandwidth Usage - Agent: **%s** | %s: **Unknown**", agentName, subject)
+	}
+	if threshold != nil {
+		return fmt.Sprintf("[!] High Bandwidth Usage - Agent: **%s** | %s: **%.1f Mbps** (threshold: %.0f Mbps)", agentName, subject, *value, *threshold)
+	}
+	return fmt.Sprintf("[!] High Bandwidth Usage - Agent: **%s** | %s: **%.1f Mbps**", agentName, subject, *value)
+}
+
+// TestMessage is the synthetic message sent when testing a channel
+const TestMessage = "[TEST] Netronome Test - Your notifications are working correctly!"
+
 // SendTestNotification sends a test notification
 func (n *Notifier) SendTestNotification() error {
-	return n.sendDirect("[TEST] Netronome Test - Your notifications are working correctly!")
+	return n.sendDirect(TestMessage)
 }
 
-// sendDirect sends a message to all configured endpoints (ntfy and shoutrrr router)
+// sendDirect sends a message to all configured endpoints (ntfy, webhooks and shoutrrr router)
 // without involving the database. Used by temporary notifiers and test notifications.
 func (n *Notifier) sendDirect(message string) error {
-	if n.router == nil && len(n.ntfyURLs) == 0 {
+	if n.deliver != nil {
+		return n.deliver(message)
+	}
+	if n.router == nil && len(n.ntfyURLs) == 0 && len(n.webhookURLs) == 0 {
 		return fmt.Errorf("no database or router configured")
 	}
 
@@ -384,6 +897,12 @@ func (n *Notifier) sendDirect(message string) error {
 		}
 	}
 
+	for _, webhookURL := range n.webhookURLs {
+		if err := sendWebhook(webhookURL, "", message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	if n.router != nil {
 		for _, err := range n.router.Send(message, nil) {
 			if err != nil {
@@ -450,6 +969,33 @@ func (n *Notifier) formatSpeedTestMessage(result *SpeedTestResult) string {
 	return sb.String()
 }
 
+// speedTestSource formats the server and provider of a result for a message title
+func speedTestSource(result *SpeedTestResult) string {
+	var sb strings.Builder
+	if result.ServerName != "" {
+		sb.WriteString(fmt.Sprintf(" - **%s**", result.ServerName))
+	}
+	if result.Provider != "" {
+		sb.WriteString(fmt.Sprintf(" (%s)", result.Provider))
+	}
+	return sb.String()
+}
+
+// speedTestMetrics formats the measured metrics of a result
+func speedTestMetrics(result *SpeedTestResult) []string {
+	var metrics []string
+	if result.Download > 0 {
+		metrics = append(metrics, fmt.Sprintf("↓ %.2f Mbps", result.Download))
+	}
+	if result.Upload > 0 {
+		metrics = append(metrics, fmt.Sprintf("↑ %.2f Mbps", result.Upload))
+	}
+	if result.Ping > 0 {
+		metrics = append(metrics, fmt.Sprintf("Ping: %.2f ms", result.Ping))
+	}
+	return metrics
+}
+
 // formatLowDownloadMessage formats a notification message for download speed below threshold
 func (n *Notifier) formatLowDownloadMessage(result *SpeedTestResult, threshold *float64) string {
 	var sb strings.Builder
@@ -564,6 +1110,31 @@ func (n *Notifier) formatHighPingMessage(result *SpeedTestResult, threshold *flo
 	return sb.String()
 }
 
+// formatRatioShiftMessage formats a notification message for a download/upload ratio change
+func (n *Notifier) formatRatioShiftMessage(result *SpeedTestResult, changePercent float64, threshold *float64) string {
+	var sb strings.Builder
+	sb.WriteString("[!] Download/Upload Ratio Shift Detected")
+
+	if result.ServerName != "" {
+		sb.WriteString(fmt.Sprintf(" - **%s**", result.ServerName))
+	}
+	if result.Provider != "" {
+		sb.WriteString(fmt.Sprintf(" (%s)", result.Provider))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(fmt.Sprintf("Ratio: **%.2f:1** (recent: %.2f:1, change: %.0f%%", result.DownUpRatio, result.BaselineRatio, changePercent))
+	if threshold != nil {
+		sb.WriteString(fmt.Sprintf(", threshold: %.0f%%", *threshold))
+	}
+	sb.WriteString(")")
+
+	sb.WriteString(fmt.Sprintf(" | ↓ %.2f Mbps | ↑ %.2f Mbps", result.Download, result.Upload))
+	sb.WriteString("\nA lasting change can indicate the ISP reprovisioned the line")
+
+	return sb.String()
+}
+
 // MigrateDiscordWebhook converts an old Discord webhook URL to Shoutrrr format
 func MigrateDiscordWebhook(webhookURL string) string {
 	if webhookURL == "" {
@@ -601,6 +1172,10 @@ type SpeedTestResult struct {
 	Jitter     float64
 	ISP        string
 	Failed     bool
+
+	// Download/upload ratio and the median of recent results; zero when unknown
+	DownUpRatio   float64
+	BaselineRatio float64
 }
 
 // PacketLossNotification represents packet loss monitoring data for notifications
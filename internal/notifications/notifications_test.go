@@ -0,0 +1,164 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package notifications
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/database"
+)
+
+// cooldownRulesDB serves fixed enabled rules and counts lookups
+type cooldownRulesDB struct {
+	database.NotificationService
+	rules   []database.NotificationRule
+	lookups int
+}
+
+func (d *cooldownRulesDB) GetEnabledRulesForEvent(category, eventType string) ([]database.NotificationRule, error) {
+	d.lookups++
+	return d.rules, nil
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestGetAgentCooldown(t *testing.T) {
+	db := &cooldownRulesDB{rules: []database.NotificationRule{
+		{ID: 1},
+		{ID: 2, CooldownSeconds: intPtr(900)},
+		{ID: 3, CooldownSeconds: intPtr(300)},
+	}}
+	n := &Notifier{db: db}
+
+	cooldown, ok := n.GetAgentCooldown(database.NotificationEventAgentHighCPU, nil)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Minute, cooldown, "the shortest rule cooldown wins")
+
+	_, _ = n.GetAgentCooldown(database.NotificationEventAgentHighCPU, nil)
+	assert.Equal(t, 1, db.lookups, "second lookup is cached")
+
+	db.rules = []database.NotificationRule{{ID: 1}}
+	_, ok = n.GetAgentCooldown(database.NotificationEventAgentHighMemory, nil)
+	assert.False(t, ok, "rules without a cooldown use the global one")
+
+	n.InvalidateCooldowns()
+	_, ok = n.GetAgentCooldown(database.NotificationEventAgentHighCPU, nil)
+	assert.False(t, ok, "changed rules apply after invalidation")
+	assert.Equal(t, 3, db.lookups)
+
+	var nilNotifier *Notifier
+	_, ok = nilNotifier.GetAgentCooldown(database.NotificationEventAgentHighCPU, nil)
+	assert.False(t, ok)
+}
+
+func TestGetAgentCooldown_AgentTag(t *testing.T) {
+	tag := "prod-db"
+	db := &cooldownRulesDB{rules: []database.NotificationRule{
+		{ID: 1, CooldownSeconds: intPtr(60), AgentTag: &tag},
+		{ID: 2, CooldownSeconds: intPtr(900)},
+	}}
+	n := &Notifier{db: db}
+
+	cooldown, ok := n.GetAgentCooldown(database.NotificationEventAgentHighCPU, []string{"web"})
+	assert.True(t, ok)
+	assert.Equal(t, 15*time.Minute, cooldown, "rules for other tags don't apply")
+
+	cooldown, ok = n.GetAgentCooldown(database.NotificationEventAgentHighCPU, []string{"eu", "prod-db"})
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, cooldown)
+	assert.Equal(t, 1, db.lookups, "the cache serves every tag")
+}
+
+func TestFormatAgentMessage_AgentThreshold(t *testing.T) {
+	value, threshold := 91.5, 85.0
+
+	message, err := formatAgentMessage("build|Package id 0", database.NotificationEventAgentHighTemp, &value, &threshold)
+	assert.NoError(t, err)
+	assert.Equal(t, "[TEMP] High Temperature - Agent: **build** | **Package id 0: 91.5°C** (threshold: 85°C)", message)
+
+	_, err = formatAgentMessage("build", "agent_unknown", &value, &threshold)
+	assert.Error(t, err)
+}
+
+func TestFormatAgentMessage_Rebooted(t *testing.T) {
+	uptime := 200.0
+
+	message, err := formatAgentMessage("build", database.NotificationEventAgentRebooted, &uptime, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "[REBOOT] Agent Rebooted - **build** | Up for **3m20s**", message)
+
+	message, err = formatAgentMessage("build", database.NotificationEventAgentRebooted, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "[REBOOT] Agent Rebooted - **build**", message)
+}
+
+func TestFormatAgentMessage_DiskFailing(t *testing.T) {
+	message, err := formatAgentMessage("nas|WDC WD40EFRX (/dev/sda): SMART health check failed", database.NotificationEventAgentDiskFailing, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "[SMART] Disk Failing - Agent: **nas** | **WDC WD40EFRX (/dev/sda): SMART health check failed**", message)
+
+	message, err = formatAgentMessage("nas", database.NotificationEventAgentDiskFailing, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "[SMART] Disk Failing - Agent: **nas**", message)
+}
+
+func TestSendAgentNotification_TagRule(t *testing.T) {
+	received := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer srv.Close()
+
+	tag := "prod-db"
+	db := &historyDB{rule: database.NotificationRule{ID: 1, ChannelID: 2, EventID: 3, AgentTag: &tag, Channel: &database.NotificationChannel{ID: 2, URL: srv.URL}}}
+	n, err := NewNotifier(db, config.New())
+	require.NoError(t, err)
+
+	require.NoError(t, n.SendAgentNotification("web1", []string{"web"}, database.NotificationEventAgentOffline, nil))
+	require.NoError(t, n.SendAgentNotification("web2", nil, database.NotificationEventAgentOffline, nil))
+	assert.Equal(t, 0, received, "agents without the tag are skipped")
+
+	require.NoError(t, n.SendAgentNotification("db1", []string{"eu", "prod-db"}, database.NotificationEventAgentOffline, nil))
+	assert.Equal(t, 1, received)
+	assert.Equal(t, 1, db.sent)
+}
+
+// thresholdRuleDB is a historyDB whose rule only passes values above its threshold
+type thresholdRuleDB struct {
+	historyDB
+}
+
+func (d *thresholdRuleDB) CheckThreshold(rule *database.NotificationRule, value float64) bool {
+	return value > *rule.ThresholdValue
+}
+
+func TestSendEvent_RuleThreshold(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	ruleThreshold := 5.0
+	db := &thresholdRuleDB{historyDB{rule: database.NotificationRule{ID: 1, ChannelID: 2, EventID: 3, ThresholdValue: &ruleThreshold, Channel: &database.NotificationChannel{ID: 2, URL: srv.URL}}}}
+	n, err := NewNotifier(db, config.New())
+	require.NoError(t, err)
+
+	// The monitor's warning threshold doesn't replace the rule threshold
+	require.NoError(t, n.SendPacketLossWarningNotification("monitor", "example.com", 4, 2))
+	assert.Equal(t, 0, db.sent)
+	require.NoError(t, n.SendPacketLossWarningNotification("monitor", "example.com", 8, 2))
+	assert.Equal(t, 1, db.sent)
+
+	// An agent's own threshold does
+	require.NoError(t, n.SendAgentThresholdNotification("agent", nil, database.NotificationEventAgentHighCPU, 4, 2))
+	require.NoError(t, n.SendAgentBandwidthNotification("agent", nil, "eth0", 4, 2))
+	assert.Equal(t, 3, db.sent)
+}
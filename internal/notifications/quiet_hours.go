@@ -0,0 +1,104 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/database"
+)
+
+// ErrSuppressed is returned for an event that fired during quiet hours and was only
+// recorded in the history. Callers that rate limit an alert shouldn't count it as
+// sent, so the alert goes out once the window ends.
+var ErrSuppressed = errors.New("notification suppressed during quiet hours")
+
+// quietHours is a parsed daily quiet hours window
+type quietHours struct {
+	start, end int // minutes since midnight
+	loc        *time.Location
+	weekdays   map[time.Weekday]bool // empty means every day
+}
+
+// newQuietHours parses the quiet hours config, returning nil when none is configured
+func newQuietHours(cfg config.QuietHoursConfig) (*quietHours, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+
+	start, err := time.Parse(config.QuietHoursTimeFormat, cfg.Start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start %q: %w", cfg.Start, err)
+	}
+	end, err := time.Parse(config.QuietHoursTimeFormat, cfg.End)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end %q: %w", cfg.End, err)
+	}
+
+	loc := time.Local
+	if cfg.Timezone != "" {
+		if loc, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	q := &quietHours{
+		start:    start.Hour()*60 + start.Minute(),
+		end:      end.Hour()*60 + end.Minute(),
+		loc:      loc,
+		weekdays: make(map[time.Weekday]bool),
+	}
+	for _, name := range cfg.Weekdays {
+		day, err := config.ParseWeekday(name)
+		if err != nil {
+			return nil, err
+		}
+		q.weekdays[day] = true
+	}
+	return q, nil
+}
+
+// contains reports whether t falls in the window. Weekdays match the day a window
+// starts on, so a Friday 23:00-02:00 window still covers early Saturday.
+func (q *quietHours) contains(t time.Time) bool {
+	if q == nil {
+		return false
+	}
+
+	t = t.In(q.loc)
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+
+	switch {
+	case q.start < q.end:
+		if minute < q.start || minute >= q.end {
+			return false
+		}
+	case minute >= q.start:
+		// Evening part of an overnight window
+	case minute < q.end:
+		// Morning part of an overnight window, which started the day before
+		day = (day + 6) % 7
+	default:
+		return false
+	}
+
+	return len(q.weekdays) == 0 || q.weekdays[day]
+}
+
+// suppressible reports whether quiet hours apply to an event. Only agent and packet loss
+// alerts are held back; recoveries are always sent so the last known state isn't lost.
+func suppressible(category, eventType string) bool {
+	switch category {
+	case database.NotificationCategoryAgent:
+		return eventType != database.NotificationEventAgentOnline
+	case database.NotificationCategoryPacketLoss:
+		return eventType != database.NotificationEventPacketLossRecovered
+	default:
+		return false
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package notifications
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/database"
+)
+
+func TestQuietHoursContains(t *testing.T) {
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	require.NoError(t, err)
+
+	// 2026-10-16 is a Friday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, stockholm)
+	}
+
+	tests := []struct {
+		name string
+		cfg  config.QuietHoursConfig
+		at   time.Time
+		want bool
+	}{
+		{"daytime window", config.QuietHoursConfig{Start: "01:00", End: "04:00"}, at(16, 2, 30), true},
+		{"end is exclusive", config.QuietHoursConfig{Start: "01:00", End: "04:00"}, at(16, 4, 0), false},
+		{"before window", config.QuietHoursConfig{Start: "01:00", End: "04:00"}, at(16, 0, 59), false},
+		{"overnight evening", config.QuietHoursConfig{Start: "23:00", End: "02:00"}, at(16, 23, 15), true},
+		{"overnight morning", config.QuietHoursConfig{Start: "23:00", End: "02:00"}, at(17, 1, 59), true},
+		{"overnight gap", config.QuietHoursConfig{Start: "23:00", End: "02:00"}, at(16, 12, 0), false},
+		{"weekday match", config.QuietHoursConfig{Start: "01:00", End: "04:00", Weekdays: []string{"fri"}}, at(16, 2, 0), true},
+		{"weekday mismatch", config.QuietHoursConfig{Start: "01:00", End: "04:00", Weekdays: []string{"sat", "sun"}}, at(16, 2, 0), false},
+		{"overnight morning belongs to start day", config.QuietHoursConfig{Start: "23:00", End: "02:00", Weekdays: []string{"friday"}}, at(17, 1, 0), true},
+		{"overnight morning after other day", config.QuietHoursConfig{Start: "23:00", End: "02:00", Weekdays: []string{"saturday"}}, at(17, 1, 0), false},
+		{"timezone applied", config.QuietHoursConfig{Start: "01:00", End: "04:00", Timezone: "UTC"}, at(16, 2, 30), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.cfg.Timezone == "" {
+				tt.cfg.Timezone = "Europe/Stockholm"
+			}
+			q, err := newQuietHours(tt.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, q.contains(tt.at))
+		})
+	}
+
+	q, err := newQuietHours(config.QuietHoursConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, q, "no window configured")
+	assert.False(t, q.contains(at(16, 2, 0)))
+}
+
+func TestSuppressible(t *testing.T) {
+	assert.True(t, suppressible(database.NotificationCategoryAgent, database.NotificationEventAgentHighCPU))
+	assert.True(t, suppressible(database.NotificationCategoryAgent, database.NotificationEventAgentOffline))
+	assert.True(t, suppressible(database.NotificationCategoryPacketLoss, database.NotificationEventPacketLossDown))
+	assert.False(t, suppressible(database.NotificationCategoryAgent, database.NotificationEventAgentOnline), "recoveries are always sent")
+	assert.False(t, suppressible(database.NotificationCategoryPacketLoss, database.NotificationEventPacketLossRecovered), "recoveries are always sent")
+	assert.False(t, suppressible(database.NotificationCategorySpeedtest, database.NotificationEventSpeedtestFailed))
+}
+
+// historyDB serves a single rule and records the notification history
+type historyDB struct {
+	database.NotificationService
+	rule       database.NotificationRule
+	sent       int
+	suppressed int
+}
+
+func (d *historyDB) GetEnabledRulesForEvent(category, eventType string) ([]database.NotificationRule, error) {
+	return []database.NotificationRule{d.rule}, nil
+}
+
+func (d *historyDB) LogNotification(channelID, eventID int64, success bool, errorMessage, payload *string) error {
+	d.sent++
+	return nil
+}
+
+func (d *historyDB) LogSuppressedNotification(channelID, eventID int64, payload *string) error {
+	d.suppressed++
+	return nil
+}
+
+func TestSendNotificationQuietHours(t *testing.T) {
+	received := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer srv.Close()
+
+	db := &historyDB{rule: database.NotificationRule{ID: 1, ChannelID: 2, EventID: 3, Channel: &database.NotificationChannel{ID: 2, URL: srv.URL}}}
+
+	// A window around the current time
+	now := time.Now()
+	cfg := config.New()
+	cfg.Notifications.QuietHours = config.QuietHoursConfig{
+		Start: now.Add(-time.Hour).Format(config.QuietHoursTimeFormat),
+		End:   now.Add(time.Hour).Format(config.QuietHoursTimeFormat),
+	}
+	n, err := NewNotifier(db, cfg)
+	require.NoError(t, err)
+
+	cpu := 95.0
+	assert.ErrorIs(t, n.SendAgentNotification("agent1", nil, database.NotificationEventAgentHighCPU, &cpu), ErrSuppressed)
+	assert.ErrorIs(t, n.SendPacketLossNotification("monitor", "1.1.1.1", 100, true, false), ErrSuppressed)
+	assert.Equal(t, 2, db.suppressed)
+	assert.Equal(t, 0, db.sent)
+	assert.Equal(t, 0, received)
+
+	require.NoError(t, n.SendPacketLossNotification("monitor", "1.1.1.1", 0, false, true))
+	assert.Equal(t, 1, db.sent, "recovery is sent during quiet hours")
+	assert.Equal(t, 1, received)
+
+	// Quiet hours end on reload without a window
+	n.Reload(config.New())
+	require.NoError(t, n.SendAgentNotification("agent1", nil, database.NotificationEventAgentHighCPU, &cpu))
+	assert.Equal(t, 2, db.sent)
+	assert.Equal(t, 2, received)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+// PayloadData is what a channel payload template is executed with
+type PayloadData struct {
+	Category  string
+	EventType string
+	// Name is the monitor, agent or speed test server the event is about
+	Name  string
+	Value *float64
+	// Threshold is the threshold the value crossed: the monitor's or agent's own
+	// when it has one, otherwise the rule's
+	Threshold *float64
+	Message   string
+	Timestamp time.Time
+
+	// ownThreshold marks an event already judged by the agent's own threshold, which
+	// replaces the rule thresholds, so the value isn't checked against them
+	ownThreshold bool
+}
+
+// payloadTemplateFuncs are available in payload templates. json encodes any value,
+// so `{{json .Name}}` yields a quoted, escaped string and `{{json .Value}}` a number
+// or null.
+var payloadTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// parsePayloadTemplate compiles a channel payload template
+func parsePayloadTemplate(text string) (*template.Template, error) {
+	return template.New("payload").Funcs(payloadTemplateFuncs).Parse(text)
+}
+
+// ValidatePayloadTemplate checks that a payload template compiles and renders sample
+// event data, and that its channel is a plain http(s) webhook it can be posted to
+func ValidatePayloadTemplate(channelURL, text string) error {
+	if !isWebhookURL(channelURL) {
+		return fmt.Errorf("payload templates need an http(s) webhook URL")
+	}
+
+	tmpl, err := parsePayloadTemplate(text)
+	if err != nil {
+		return fmt.Errorf("invalid payload template: %w", err)
+	}
+
+	value, threshold := 12.5, 5.0
+	sample := PayloadData{
+		Category:  "packetloss",
+		EventType: "threshold_exceeded",
+		Name:      "example",
+		Value:     &value,
+		Threshold: &threshold,
+		Message:   "sample message",
+		Timestamp: time.Now(),
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, sample); err != nil {
+		return fmt.Errorf("invalid payload template: %w", err)
+	}
+	return nil
+}
+
+// renderPayload executes a channel payload template with an event
+func renderPayload(text string, data PayloadData) ([]byte, error) {
+	tmpl, err := parsePayloadTemplate(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render payload template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
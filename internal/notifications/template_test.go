@@ -0,0 +1,91 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package notifications
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/database"
+)
+
+func TestValidatePayloadTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		tmpl    string
+		wantErr string
+	}{
+		{name: "valid", url: "https://example.com/hook", tmpl: `{"name": {{json .Name}}, "value": {{json .Value}}}`},
+		{name: "not a webhook", url: "discord://token@id", tmpl: `{{.Name}}`, wantErr: "http(s) webhook URL"},
+		{name: "syntax error", url: "https://example.com/hook", tmpl: `{{.Name`, wantErr: "invalid payload template"},
+		{name: "unknown field", url: "https://example.com/hook", tmpl: `{{.Host}}`, wantErr: "invalid payload template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePayloadTemplate(tt.url, tt.tmpl)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestRenderPayload(t *testing.T) {
+	value := 12.5
+	data := PayloadData{
+		Category:  database.NotificationCategoryPacketLoss,
+		EventType: database.NotificationEventPacketLossHigh,
+		Name:      `edge "router"`,
+		Value:     &value,
+		Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	payload, err := renderPayload(`{"name":{{json .Name}},"value":{{json .Value}},"threshold":{{json .Threshold}},"at":"{{.Timestamp.Format "2006-01-02"}}"}`, data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"edge \"router\"","value":12.5,"threshold":null,"at":"2026-01-02"}`, string(payload))
+}
+
+func TestSendToChannel_PayloadTemplate(t *testing.T) {
+	var body string
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	tmpl := `{"event":"{{.EventType}}","agent":{{json .Name}},"threshold":{{json .Threshold}}}`
+	channel := &database.NotificationChannel{URL: server.URL, PayloadTemplate: &tmpl}
+	value, ruleThreshold := 95.0, 90.0
+	data := PayloadData{
+		Category:  database.NotificationCategoryAgent,
+		EventType: database.NotificationEventAgentHighCPU,
+		Name:      "nas",
+		Value:     &value,
+		Message:   "high cpu",
+	}
+
+	n := &Notifier{}
+	require.NoError(t, n.sendToChannel(channel, data, &ruleThreshold))
+	assert.Equal(t, "application/json", contentType)
+	assert.JSONEq(t, `{"event":"cpu_high","agent":"nas","threshold":90}`, body)
+
+	// Without a template the channel gets the usual webhook body
+	channel.PayloadTemplate = nil
+	require.NoError(t, n.sendToChannel(channel, data, &ruleThreshold))
+	assert.JSONEq(t, `{"message":"high cpu"}`, body)
+}
@@ -36,6 +36,15 @@ func TestValidateNotificationURL(t *testing.T) {
 			rawURL:  "ntfy:///alerts",
 			wantErr: "must include a host",
 		},
+		{
+			name:   "plain https webhook",
+			rawURL: "https://hooks.example.com/netronome",
+		},
+		{
+			name:    "webhook missing host",
+			rawURL:  "https:///netronome",
+			wantErr: "must include a host",
+		},
 		{
 			name:    "trims whitespace before validating",
 			rawURL:  "  pushover://API_TOKEN@USER_KEY  ",
@@ -0,0 +1,80 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// webhookHTTPClient is a dedicated HTTP client for plain webhook requests
+var webhookHTTPClient = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+// webhookPayload is the JSON body posted to plain http(s) webhook channels
+type webhookPayload struct {
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message"`
+}
+
+// isWebhookURL checks whether a notification URL is a plain http(s) endpoint rather
+// than a Shoutrrr service URL
+func isWebhookURL(u string) bool {
+	return strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://")
+}
+
+// validateWebhookURL checks that a webhook URL is usable without sending anything
+func validateWebhookURL(webhookURL string) error {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("webhook URL must include a host")
+	}
+	return nil
+}
+
+// sendWebhook posts a notification as JSON to a plain http(s) webhook
+func sendWebhook(webhookURL, title, body string) error {
+	if err := validateWebhookURL(webhookURL); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(webhookPayload{Title: title, Message: body})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	return postWebhook(webhookURL, payload)
+}
+
+// postWebhook posts a JSON payload to a plain http(s) webhook
+func postWebhook(webhookURL string, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := webhookHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook returned %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package notifications
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendRaw_Webhook(t *testing.T) {
+	var received webhookPayload
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	n := &Notifier{}
+	require.NoError(t, n.SendRaw(server.URL+"/hook", "Monitor Down", "example.com is down"))
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, webhookPayload{Title: "Monitor Down", Message: "example.com is down"}, received)
+}
+
+func TestSendRaw_WebhookError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := (&Notifier{}).SendRaw(server.URL, "", "message")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+}
+
+func TestSendRaw_Ntfy(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+	defer server.Close()
+
+	ntfyURL := "ntfy://" + server.Listener.Addr().String() + "/alerts?scheme=http"
+	require.NoError(t, (&Notifier{}).SendRaw(ntfyURL, "Title", "Body"))
+	assert.Equal(t, "Title\n\nBody", body)
+}
+
+func TestSendRaw_InvalidShoutrrrURL(t *testing.T) {
+	err := (&Notifier{}).SendRaw("unknownservice://token", "", "message")
+	assert.Error(t, err)
+}
+
+func TestNewNotifierFromURLs_Webhook(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+
+	n, err := NewNotifierFromURLs([]string{server.URL})
+	require.NoError(t, err)
+	require.NoError(t, n.SendTestNotification())
+	assert.Equal(t, 1, calls)
+}
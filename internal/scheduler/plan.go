@@ -0,0 +1,124 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of planned runs
+const (
+	PlannedSpeedTest  = "speedtest"
+	PlannedPacketLoss = "packetloss"
+	PlannedTraceroute = "traceroute"
+)
+
+// PlannedRun is a scheduled test or monitor the scheduler would start
+type PlannedRun struct {
+	Kind     string
+	ID       int64
+	Name     string
+	Host     string
+	Interval string
+	NextRun  time.Time
+}
+
+// Plan works out what the scheduler would run after starting at now, the same way Start
+// reschedules overdue entries, but without storing anything or running a probe. Next runs
+// of rescheduled entries include random jitter, so they are estimates.
+func (s *service) Plan(ctx context.Context, now time.Time) ([]PlannedRun, error) {
+	now = now.UTC()
+	var plan []PlannedRun
+
+	schedules, err := s.db.GetSchedules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedules: %w", err)
+	}
+	for _, schedule := range schedules {
+		if !schedule.Enabled || !s.isValidScheduleInterval(schedule.Interval) {
+			continue
+		}
+		nextRun := schedule.NextRun
+		if nextRun.Before(now) {
+			nextRun = s.calculateNextRun(schedule.Interval, now, false)
+		}
+		host := schedule.Options.ServerHost
+		if host == "" {
+			host = strings.Join(schedule.ServerIDs, ",")
+		}
+		plan = append(plan, PlannedRun{
+			Kind:     PlannedSpeedTest,
+			ID:       schedule.ID,
+			Name:     schedule.Options.ServerName,
+			Host:     host,
+			Interval: schedule.Interval,
+			NextRun:  nextRun,
+		})
+	}
+
+	if s.packetLoss != nil {
+		monitors, err := s.db.GetPacketLossMonitors()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get packet loss monitors: %w", err)
+		}
+		var staggered map[int64]time.Time
+		if s.staggerMonitors {
+			staggered = s.staggeredNextRuns(monitors, now)
+		}
+		for _, monitor := range monitors {
+			if !monitor.Enabled || !s.isValidScheduleInterval(monitor.Interval) {
+				continue
+			}
+			nextRun, ok := staggered[monitor.ID]
+			if !ok {
+				if monitor.NextRun != nil && !monitor.NextRun.Before(now) {
+					nextRun = *monitor.NextRun
+				} else {
+					nextRun = s.calculateNextRun(monitor.Interval, now, true)
+				}
+			}
+			plan = append(plan, PlannedRun{
+				Kind:     PlannedPacketLoss,
+				ID:       monitor.ID,
+				Name:     monitor.Name,
+				Host:     monitor.Host,
+				Interval: monitor.Interval,
+				NextRun:  nextRun,
+			})
+		}
+	}
+
+	if s.traceroute != nil {
+		monitors, err := s.db.GetTracerouteMonitors(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get traceroute monitors: %w", err)
+		}
+		for _, monitor := range monitors {
+			if !monitor.Enabled || !s.isValidScheduleInterval(monitor.Interval) {
+				continue
+			}
+			var nextRun time.Time
+			if monitor.NextRun != nil && !monitor.NextRun.Before(now) {
+				nextRun = *monitor.NextRun
+			} else {
+				nextRun = s.calculateNextRun(monitor.Interval, now, true)
+			}
+			plan = append(plan, PlannedRun{
+				Kind:     PlannedTraceroute,
+				ID:       monitor.ID,
+				Name:     monitor.Name,
+				Host:     monitor.Host,
+				Interval: monitor.Interval,
+				NextRun:  nextRun,
+			})
+		}
+	}
+
+	sort.SliceStable(plan, func(i, j int) bool { return plan[i].NextRun.Before(plan[j].NextRun) })
+	return plan, nil
+}
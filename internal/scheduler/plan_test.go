@@ -0,0 +1,80 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/speedtest"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+type planTestDB struct {
+	database.Service
+	schedules          []types.Schedule
+	packetLossMonitors []*types.PacketLossMonitor
+	tracerouteMonitors []*types.TracerouteMonitor
+}
+
+func (d *planTestDB) GetSchedules(ctx context.Context) ([]types.Schedule, error) {
+	return d.schedules, nil
+}
+
+func (d *planTestDB) GetPacketLossMonitors() ([]*types.PacketLossMonitor, error) {
+	return d.packetLossMonitors, nil
+}
+
+func (d *planTestDB) GetTracerouteMonitors(ctx context.Context) ([]*types.TracerouteMonitor, error) {
+	return d.tracerouteMonitors, nil
+}
+
+func TestPlan(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	upcoming := now.Add(10 * time.Minute)
+	overdue := now.Add(-time.Hour)
+
+	db := &planTestDB{
+		schedules: []types.Schedule{
+			{ID: 1, Interval: "1h", NextRun: upcoming, Enabled: true, Options: types.TestOptions{ServerHost: "iperf.example.com"}},
+			{ID: 2, Interval: "1h", NextRun: upcoming, Enabled: false},
+		},
+		packetLossMonitors: []*types.PacketLossMonitor{
+			{ID: 3, Host: "1.1.1.1", Interval: "30m", Enabled: true, NextRun: &overdue},
+			{ID: 4, Host: "8.8.8.8", Interval: "invalid", Enabled: true},
+		},
+		tracerouteMonitors: []*types.TracerouteMonitor{
+			{ID: 5, Host: "example.com", Interval: "5m", Enabled: true, NextRun: &upcoming},
+		},
+	}
+	s := &service{db: db, packetLoss: &speedtest.PacketLossService{}, traceroute: &speedtest.TracerouteService{}}
+
+	plan, err := s.Plan(context.Background(), now)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plan) != 3 {
+		t.Fatalf("Plan() returned %d runs, want 3: %+v", len(plan), plan)
+	}
+
+	byID := make(map[int64]PlannedRun, len(plan))
+	for i, run := range plan {
+		byID[run.ID] = run
+		if i > 0 && run.NextRun.Before(plan[i-1].NextRun) {
+			t.Errorf("plan is not ordered by next run: %+v", plan)
+		}
+	}
+
+	if run := byID[1]; run.Kind != PlannedSpeedTest || run.Host != "iperf.example.com" || !run.NextRun.Equal(upcoming) {
+		t.Errorf("speed test schedule planned as %+v", run)
+	}
+	if run := byID[3]; run.Kind != PlannedPacketLoss || run.NextRun.Before(now.Add(30*time.Minute)) {
+		t.Errorf("overdue packet loss monitor should be rescheduled from now, got %+v", run)
+	}
+	if run := byID[5]; run.Kind != PlannedTraceroute || !run.NextRun.Equal(upcoming) {
+		t.Errorf("traceroute monitor planned as %+v", run)
+	}
+}
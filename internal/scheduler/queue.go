@@ -0,0 +1,45 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package scheduler
+
+import (
+	"sync"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// QueueSnapshot holds the stats of the queues scheduled runs wait in
+type QueueSnapshot struct {
+	Speedtest  types.QueueStats `json:"speedtest"`
+	PacketLoss types.QueueStats `json:"packetLoss"`
+	Traceroute types.QueueStats `json:"traceroute"`
+}
+
+// pendingJobs holds the IDs of jobs of one kind that are queued or running, so a slow
+// run doesn't pile up duplicates on every tick
+type pendingJobs struct {
+	mu  sync.Mutex
+	ids map[int64]bool
+}
+
+// claim marks id as pending. It returns false while the job is already pending.
+func (p *pendingJobs) claim(id int64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ids[id] {
+		return false
+	}
+	if p.ids == nil {
+		p.ids = make(map[int64]bool)
+	}
+	p.ids[id] = true
+	return true
+}
+
+// release clears the claim on id
+func (p *pendingJobs) release(id int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.ids, id)
+}
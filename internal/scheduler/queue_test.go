@@ -0,0 +1,24 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package scheduler
+
+import "testing"
+
+func TestPendingJobsSkipsDuplicates(t *testing.T) {
+	var p pendingJobs
+
+	if !p.claim(7) {
+		t.Fatalf("expected job to be claimed")
+	}
+	if p.claim(7) {
+		t.Fatalf("expected duplicate of a pending job to be rejected")
+	}
+	if !p.claim(8) {
+		t.Fatalf("expected another job to be claimed")
+	}
+	p.release(7)
+	if !p.claim(7) {
+		t.Fatalf("expected job to be claimed again after finishing")
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"context"
 	"math"
 	"math/rand"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -23,29 +25,80 @@ import (
 type Service interface {
 	Start(ctx context.Context)
 	Stop()
+	Running() bool
 	UpdateMonitorSchedule(monitorID int64, interval string) error
 	CalculateNextRun(interval string, from time.Time) time.Time
+	CalculateMonitorNextRun(monitor *types.PacketLossMonitor, from time.Time) time.Time
+	QueueStats() QueueSnapshot
+	Plan(ctx context.Context, now time.Time) ([]PlannedRun, error)
+	// SetPaused holds back scheduled speed tests and packet loss and traceroute monitor
+	// runs until unpaused
+	SetPaused(paused bool)
+	Paused() bool
 }
 
 type service struct {
 	db         database.Service
 	speedtest  speedtest.Service
 	packetLoss *speedtest.PacketLossService
+	traceroute *speedtest.TracerouteService
 	notifier   *notifications.Notifier
 	ticker     *time.Ticker
 	done       chan bool
 	mu         sync.Mutex
 	running    bool
+
+	// staggerMonitors spreads packet loss monitors sharing an interval across that interval
+	staggerMonitors bool
+
+	// pendingSpeedtests, pendingPacketLoss and pendingTraceroutes hold the schedules and
+	// monitors whose run is queued or running. How many run at once is up to the
+	// speedtest, packet loss and traceroute services, which also count manual tests.
+	pendingSpeedtests  pendingJobs
+	pendingPacketLoss  pendingJobs
+	pendingTraceroutes pendingJobs
+
+	// paused skips due speed tests and packet loss and traceroute monitors, set during
+	// maintenance
+	paused atomic.Bool
 }
 
-func New(db database.Service, speedtest speedtest.Service, packetLoss *speedtest.PacketLossService, notifier *notifications.Notifier) Service {
+func New(db database.Service, speedtest speedtest.Service, packetLoss *speedtest.PacketLossService, traceroute *speedtest.TracerouteService, notifier *notifications.Notifier, staggerMonitors bool) Service {
 	return &service{
-		db:         db,
-		speedtest:  speedtest,
-		packetLoss: packetLoss,
-		notifier:   notifier,
-		done:       make(chan bool),
+		db:              db,
+		speedtest:       speedtest,
+		packetLoss:      packetLoss,
+		traceroute:      traceroute,
+		notifier:        notifier,
+		done:            make(chan bool),
+		staggerMonitors: staggerMonitors,
+	}
+}
+
+// QueueStats reports the depth and wait times of the speed test, packet loss and
+// traceroute queues
+func (s *service) QueueStats() QueueSnapshot {
+	snapshot := QueueSnapshot{Speedtest: s.speedtest.QueueStats()}
+	if s.packetLoss != nil {
+		snapshot.PacketLoss = s.packetLoss.QueueStats()
+	}
+	if s.traceroute != nil {
+		snapshot.Traceroute = s.traceroute.QueueStats()
 	}
+	return snapshot
+}
+
+// SetPaused pauses or resumes scheduled speed tests and monitor runs. Schedules and
+// monitors that came due while paused run on the first check after resuming.
+func (s *service) SetPaused(paused bool) {
+	if s.paused.Swap(paused) != paused {
+		log.Info().Bool("paused", paused).Msg("Scheduled monitoring pause state changed")
+	}
+}
+
+// Paused reports whether scheduled monitor runs are paused
+func (s *service) Paused() bool {
+	return s.paused.Load()
 }
 
 func (s *service) Start(ctx context.Context) {
@@ -62,6 +115,7 @@ func (s *service) Start(ctx context.Context) {
 	// Initialize schedules before starting
 	s.initializeSchedules(ctx)
 	s.initializePacketLossMonitors(ctx)
+	s.initializeTracerouteMonitors(ctx)
 
 	go func() {
 		for {
@@ -74,6 +128,7 @@ func (s *service) Start(ctx context.Context) {
 			case <-s.ticker.C:
 				s.checkAndRunScheduledTests(ctx)
 				s.checkAndRunPacketLossMonitors(ctx)
+				s.checkAndRunTracerouteMonitors(ctx)
 			}
 		}
 	}()
@@ -155,7 +210,19 @@ func (s *service) Stop() {
 	log.Info().Msg("Scheduler service stopped")
 }
 
+// Running reports whether the scheduler has been started and not stopped since
+func (s *service) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
 func (s *service) checkAndRunScheduledTests(ctx context.Context) {
+	if s.paused.Load() {
+		log.Debug().Msg("Monitoring paused, skipping scheduled speed tests")
+		return
+	}
+
 	schedules, err := s.db.GetSchedules(ctx)
 	if err != nil {
 		log.Error().
@@ -182,11 +249,30 @@ func (s *service) checkAndRunScheduledTests(ctx context.Context) {
 			Bool("is_iperf", schedule.Options.UseIperf).
 			Msg("Running scheduled test")
 
-		testCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-		go func(schedule types.Schedule, scheduledStart time.Time, ctx context.Context, cancel context.CancelFunc) {
-			defer cancel()
-			schedule.Options.IsScheduled = true
-			result, err := s.speedtest.RunTest(ctx, &schedule.Options)
+		if !s.pendingSpeedtests.claim(schedule.ID) {
+			log.Warn().
+				Int64("schedule_id", schedule.ID).
+				Msg("Scheduled test is still queued or running, skipping")
+			continue
+		}
+
+		go func(schedule types.Schedule, scheduledStart time.Time) {
+			defer s.pendingSpeedtests.release(schedule.ID)
+
+			// Each attempt is bounded by the speedtest service once it has a slot
+			ctx := speedtest.WithScheduledStart(context.Background(), scheduledStart)
+
+			opts, serverID, nextIndex := rotateScheduleServer(schedule)
+			opts.IsScheduled = true
+			if serverID != "" {
+				log.Info().
+					Int64("schedule_id", schedule.ID).
+					Str("server_id", serverID).
+					Str("rotation", opts.ServerRotation).
+					Msg("Selected rotating schedule server")
+			}
+
+			result, err := s.speedtest.RunTest(ctx, &opts)
 			if err != nil {
 				log.Error().
 					Err(err).
@@ -225,8 +311,52 @@ func (s *service) checkAndRunScheduledTests(ctx context.Context) {
 					Int64("schedule_id", schedule.ID).
 					Msg("Error updating schedule")
 			}
-		}(schedule, scheduledStart, testCtx, cancel)
+			if serverID != "" {
+				if err := s.db.UpdateScheduleRotation(ctx, schedule.ID, nextIndex, serverID); err != nil {
+					log.Error().
+						Err(err).
+						Int64("schedule_id", schedule.ID).
+						Msg("Error updating schedule rotation")
+				}
+			}
+		}(schedule, scheduledStart)
+	}
+}
+
+// rotateScheduleServer returns the test options for a schedule's next run. For
+// rotating schedules with several servers the options are narrowed to a single
+// server, which is returned along with the rotation index for the following run.
+func rotateScheduleServer(schedule types.Schedule) (types.TestOptions, string, int) {
+	opts := schedule.Options
+
+	serverIDs := opts.ServerIDs
+	if len(serverIDs) == 0 {
+		serverIDs = schedule.ServerIDs
 	}
+	if opts.ServerRotation == "" || len(serverIDs) < 2 {
+		return opts, "", schedule.RotationIndex
+	}
+
+	var index int
+	switch opts.ServerRotation {
+	case types.ServerRotationRandom:
+		index = rand.Intn(len(serverIDs))
+	default:
+		index = schedule.RotationIndex % len(serverIDs)
+		if index < 0 {
+			index = 0
+		}
+	}
+
+	serverID := serverIDs[index]
+	opts.ServerIDs = []string{serverID}
+	if opts.UseIperf && strings.HasPrefix(serverID, "iperf3-") {
+		opts.ServerHost = strings.TrimPrefix(serverID, "iperf3-")
+		// The stored name belongs to whichever server was picked in the UI
+		opts.ServerName = ""
+	}
+
+	return opts, serverID, (index + 1) % len(serverIDs)
 }
 
 // isValidScheduleInterval checks if the interval is valid (duration or exact time)
@@ -390,6 +520,9 @@ func (s *service) calculateNextRun(interval string, from time.Time, skipJitter b
 //
 // Example: If a monitor scheduled for "exact:14:00" starts at 15:00,
 // it will be scheduled for 14:00 the next day, not run immediately.
+//
+// With stagger_monitors enabled, every monitor with a duration interval is
+// rescheduled into its phase slot, since subsequent runs keep that phase.
 func (s *service) initializePacketLossMonitors(ctx context.Context) {
 	monitors, err := s.db.GetPacketLossMonitors()
 	if err != nil {
@@ -398,6 +531,12 @@ func (s *service) initializePacketLossMonitors(ctx context.Context) {
 	}
 
 	now := time.Now().UTC()
+
+	var staggered map[int64]time.Time
+	if s.staggerMonitors {
+		staggered = s.staggeredNextRuns(monitors, now)
+	}
+
 	for _, monitor := range monitors {
 		if !monitor.Enabled {
 			continue
@@ -412,6 +551,24 @@ func (s *service) initializePacketLossMonitors(ctx context.Context) {
 			continue
 		}
 
+		if nextRun, ok := staggered[monitor.ID]; ok {
+			monitor.NextRun = &nextRun
+
+			log.Info().
+				Int64("monitor_id", monitor.ID).
+				Time("next_run", nextRun).
+				Str("interval", monitor.Interval).
+				Msg("Staggering packet loss monitor")
+
+			if err := s.db.UpdatePacketLossMonitor(monitor); err != nil {
+				log.Error().
+					Err(err).
+					Int64("monitor_id", monitor.ID).
+					Msg("Error updating monitor during initialization")
+			}
+			continue
+		}
+
 		// If NextRun is nil or in the past, calculate new NextRun
 		if monitor.NextRun == nil || monitor.NextRun.Before(now) {
 			nextRun := s.calculateNextRun(monitor.Interval, now, true)
@@ -441,8 +598,42 @@ func (s *service) initializePacketLossMonitors(ctx context.Context) {
 	}
 }
 
+// staggeredNextRuns spreads enabled monitors that share a duration interval evenly
+// across that interval, ordered by ID, so their probes don't fire on the same tick.
+// Monitor i of n in a group first runs at from + interval*(i+1)/n. Exact-time
+// monitors are left out since they are pinned to wall-clock times.
+func (s *service) staggeredNextRuns(monitors []*types.PacketLossMonitor, from time.Time) map[int64]time.Time {
+	groups := make(map[time.Duration][]int64)
+	for _, monitor := range monitors {
+		if !monitor.Enabled || strings.HasPrefix(monitor.Interval, "exact:") {
+			continue
+		}
+		interval, err := time.ParseDuration(s.normalizeDuration(monitor.Interval))
+		if err != nil || interval <= 0 {
+			continue
+		}
+		groups[interval] = append(groups[interval], monitor.ID)
+	}
+
+	from = from.UTC()
+	nextRuns := make(map[int64]time.Time)
+	for interval, ids := range groups {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		step := interval / time.Duration(len(ids))
+		for i, id := range ids {
+			nextRuns[id] = from.Add(step * time.Duration(i+1))
+		}
+	}
+	return nextRuns
+}
+
 // checkAndRunPacketLossMonitors checks for due packet loss monitors and runs them
 func (s *service) checkAndRunPacketLossMonitors(ctx context.Context) {
+	if s.paused.Load() {
+		log.Debug().Msg("Monitoring paused, skipping packet loss monitors")
+		return
+	}
+
 	monitors, err := s.db.GetPacketLossMonitors()
 	if err != nil {
 		log.Error().
@@ -500,10 +691,16 @@ func (s *service) checkAndRunPacketLossMonitors(ctx context.Context) {
 			Str("interval", monitor.Interval).
 			Msg("Starting scheduled packet loss test")
 
-		// Create a timeout context for the test
-		testCtx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-		go func(monitor *types.PacketLossMonitor, scheduledStart time.Time, ctx context.Context, cancel context.CancelFunc) {
-			defer cancel()
+		if !s.pendingPacketLoss.claim(monitor.ID) {
+			log.Warn().
+				Int64("monitor_id", monitor.ID).
+				Str("host", monitor.Host).
+				Msg("Packet loss test is still queued or running, skipping")
+			continue
+		}
+
+		go func(monitor *types.PacketLossMonitor, scheduledStart time.Time) {
+			defer s.pendingPacketLoss.release(monitor.ID)
 
 			testStartTime := time.Now().UTC()
 			log.Info().
@@ -514,7 +711,7 @@ func (s *service) checkAndRunPacketLossMonitors(ctx context.Context) {
 
 			// Run the packet loss test
 			if s.packetLoss != nil {
-				s.packetLoss.RunScheduledTest(monitor)
+				s.packetLoss.RunScheduledTest(speedtest.WithScheduledStart(ctx, scheduledStart), monitor)
 			}
 
 			testCompletionTime := time.Now().UTC()
@@ -565,7 +762,7 @@ func (s *service) checkAndRunPacketLossMonitors(ctx context.Context) {
 					Int64("monitor_id", monitor.ID).
 					Msg("Error updating monitor schedule")
 			}
-		}(monitor, scheduledStartTime, testCtx, cancel)
+		}(monitor, scheduledStartTime)
 	}
 }
 
@@ -579,8 +776,12 @@ func (s *service) UpdateMonitorSchedule(monitorID int64, interval string) error
 		return err
 	}
 
-	// Calculate next run time
+	// Calculate next run time, keeping the monitor out of step with its peers
 	nextRun := s.calculateNextRun(interval, now, true)
+	if s.staggerMonitors {
+		monitor.Interval = interval
+		nextRun = s.CalculateMonitorNextRun(monitor, now)
+	}
 	if nextRun.IsZero() {
 		log.Error().
 			Int64("monitor_id", monitorID).
@@ -608,3 +809,70 @@ func (s *service) UpdateMonitorSchedule(monitorID int64, interval string) error
 func (s *service) CalculateNextRun(interval string, from time.Time) time.Time {
 	return s.calculateNextRun(interval, from, false)
 }
+
+// CalculateMonitorNextRun returns the first run of a new or edited packet loss monitor.
+// With stagger_monitors enabled, a monitor with a duration interval is placed in the
+// middle of the widest gap between the upcoming runs of the other enabled monitors
+// sharing its interval, so monitors added at runtime stay spread like those
+// staggered on startup. Otherwise it behaves like CalculateNextRun.
+func (s *service) CalculateMonitorNextRun(monitor *types.PacketLossMonitor, from time.Time) time.Time {
+	if !s.staggerMonitors || strings.HasPrefix(monitor.Interval, "exact:") {
+		return s.calculateNextRun(monitor.Interval, from, false)
+	}
+	interval, err := time.ParseDuration(s.normalizeDuration(monitor.Interval))
+	if err != nil || interval <= 0 {
+		return time.Time{}
+	}
+
+	monitors, err := s.db.GetPacketLossMonitors()
+	if err != nil {
+		log.Error().Err(err).Int64("monitor_id", monitor.ID).Msg("Error fetching packet loss monitors for staggering")
+		return s.calculateNextRun(monitor.Interval, from, true)
+	}
+
+	var peers []time.Time
+	for _, other := range monitors {
+		if other.ID == monitor.ID || !other.Enabled || other.NextRun == nil || strings.HasPrefix(other.Interval, "exact:") {
+			continue
+		}
+		if otherInterval, err := time.ParseDuration(s.normalizeDuration(other.Interval)); err == nil && otherInterval == interval {
+			peers = append(peers, *other.NextRun)
+		}
+	}
+
+	return staggeredSlot(peers, interval, from)
+}
+
+// staggeredSlot returns the first run, within one interval after from, that sits in
+// the middle of the widest gap between the peers' runs. The gaps wrap around the
+// interval since every peer repeats once per interval.
+func staggeredSlot(peers []time.Time, interval time.Duration, from time.Time) time.Time {
+	from = from.UTC()
+	if len(peers) == 0 {
+		return from.Add(interval)
+	}
+
+	offsets := make([]time.Duration, 0, len(peers))
+	for _, peer := range peers {
+		offset := peer.Sub(from) % interval
+		if offset < 0 {
+			offset += interval
+		}
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	gapStart := offsets[len(offsets)-1]
+	widest := offsets[0] + interval - gapStart
+	for i := 1; i < len(offsets); i++ {
+		if gap := offsets[i] - offsets[i-1]; gap > widest {
+			gapStart, widest = offsets[i-1], gap
+		}
+	}
+
+	slot := (gapStart + widest/2) % interval
+	if slot <= 0 {
+		slot += interval
+	}
+	return from.Add(slot)
+}
@@ -4,8 +4,12 @@
 package scheduler
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/autobrr/netronome/internal/types"
 )
 
 func TestCalculateNextRun(t *testing.T) {
@@ -123,4 +127,161 @@ func TestIsValidScheduleInterval(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestStaggeredNextRuns(t *testing.T) {
+	s := &service{}
+	from := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	monitors := []*types.PacketLossMonitor{
+		{ID: 7, Interval: "1h", Enabled: true},
+		{ID: 3, Interval: "60m", Enabled: true},
+		{ID: 5, Interval: "3600s", Enabled: true},
+		{ID: 4, Interval: "1h", Enabled: true},
+		{ID: 9, Interval: "5m", Enabled: true},
+		{ID: 10, Interval: "1h", Enabled: false},
+		{ID: 11, Interval: "exact:14:00", Enabled: true},
+		{ID: 12, Interval: "invalid", Enabled: true},
+	}
+
+	got := s.staggeredNextRuns(monitors, from)
+
+	want := map[int64]time.Time{
+		3: from.Add(15 * time.Minute),
+		4: from.Add(30 * time.Minute),
+		5: from.Add(45 * time.Minute),
+		7: from.Add(60 * time.Minute),
+		9: from.Add(5 * time.Minute),
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("staggeredNextRuns() returned %d monitors, want %d: %v", len(got), len(want), got)
+	}
+	for id, wantRun := range want {
+		if gotRun, ok := got[id]; !ok || !gotRun.Equal(wantRun) {
+			t.Errorf("monitor %d next run = %v, want %v", id, gotRun, wantRun)
+		}
+	}
+}
+
+func TestStaggeredSlot(t *testing.T) {
+	from := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		peers []time.Time
+		want  time.Time
+	}{
+		{
+			name: "no peers runs one interval later",
+			want: from.Add(time.Hour),
+		},
+		{
+			name:  "single peer gets the opposite phase",
+			peers: []time.Time{from.Add(10 * time.Minute)},
+			want:  from.Add(40 * time.Minute),
+		},
+		{
+			name:  "widest gap between evenly spread peers",
+			peers: []time.Time{from.Add(15 * time.Minute), from.Add(30 * time.Minute), from.Add(45 * time.Minute), from.Add(60 * time.Minute), from.Add(5 * time.Minute)},
+			want:  from.Add(52*time.Minute + 30*time.Second),
+		},
+		{
+			name:  "widest gap wraps around the interval",
+			peers: []time.Time{from.Add(20 * time.Minute), from.Add(30 * time.Minute)},
+			want:  from.Add(55 * time.Minute),
+		},
+		{
+			name:  "past runs are folded into the interval",
+			peers: []time.Time{from.Add(-50 * time.Minute)},
+			want:  from.Add(40 * time.Minute),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := staggeredSlot(tt.peers, time.Hour, from); !got.Equal(tt.want) {
+				t.Errorf("staggeredSlot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotateScheduleServer(t *testing.T) {
+	schedule := types.Schedule{
+		Options: types.TestOptions{
+			ServerIDs:      []string{"a", "b", "c"},
+			ServerRotation: types.ServerRotationRoundRobin,
+		},
+	}
+
+	var used []string
+	for i := 0; i < 4; i++ {
+		opts, serverID, next := rotateScheduleServer(schedule)
+		if len(opts.ServerIDs) != 1 || opts.ServerIDs[0] != serverID {
+			t.Fatalf("run %d: options server IDs = %v, want [%s]", i, opts.ServerIDs, serverID)
+		}
+		used = append(used, serverID)
+		schedule.RotationIndex = next
+	}
+	if got := strings.Join(used, ","); got != "a,b,c,a" {
+		t.Fatalf("round robin order = %s, want a,b,c,a", got)
+	}
+	if len(schedule.Options.ServerIDs) != 3 {
+		t.Fatalf("schedule server IDs were modified: %v", schedule.Options.ServerIDs)
+	}
+
+	iperf := types.Schedule{
+		ServerIDs: []string{"iperf3-one.example:5201", "iperf3-two.example:5202"},
+		Options: types.TestOptions{
+			UseIperf:       true,
+			ServerHost:     "one.example:5201",
+			ServerName:     "One",
+			ServerRotation: types.ServerRotationRoundRobin,
+		},
+		RotationIndex: 1,
+	}
+	opts, serverID, next := rotateScheduleServer(iperf)
+	if serverID != "iperf3-two.example:5202" || opts.ServerHost != "two.example:5202" || opts.ServerName != "" || next != 0 {
+		t.Fatalf("unexpected iperf rotation: id=%s host=%s name=%q next=%d", serverID, opts.ServerHost, opts.ServerName, next)
+	}
+
+	random := types.Schedule{
+		Options: types.TestOptions{
+			ServerIDs:      []string{"a", "b"},
+			ServerRotation: types.ServerRotationRandom,
+		},
+	}
+	for i := 0; i < 10; i++ {
+		if _, serverID, _ := rotateScheduleServer(random); serverID != "a" && serverID != "b" {
+			t.Fatalf("random rotation picked unknown server %q", serverID)
+		}
+	}
+
+	single := types.Schedule{
+		Options: types.TestOptions{
+			ServerIDs:      []string{"a"},
+			ServerRotation: types.ServerRotationRoundRobin,
+		},
+	}
+	if _, serverID, _ := rotateScheduleServer(single); serverID != "" {
+		t.Fatalf("single server schedule should not rotate, got %q", serverID)
+	}
+}
+
+func TestPausedSkipsMonitors(t *testing.T) {
+	// No database: a run that isn't skipped would panic fetching monitors
+	s := &service{}
+	s.SetPaused(true)
+	if !s.Paused() {
+		t.Fatal("Paused() = false after SetPaused(true)")
+	}
+
+	s.checkAndRunScheduledTests(context.Background())
+	s.checkAndRunPacketLossMonitors(context.Background())
+	s.checkAndRunTracerouteMonitors(context.Background())
+
+	s.SetPaused(false)
+	if s.Paused() {
+		t.Error("Paused() = true after SetPaused(false)")
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/speedtest"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// initializeTracerouteMonitors prepares traceroute monitors on startup. Like packet
+// loss monitors, missed runs are not caught up, overdue monitors are rescheduled from now.
+func (s *service) initializeTracerouteMonitors(ctx context.Context) {
+	monitors, err := s.db.GetTracerouteMonitors(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Error fetching traceroute monitors during initialization")
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, monitor := range monitors {
+		if !monitor.Enabled {
+			continue
+		}
+
+		if !s.isValidScheduleInterval(monitor.Interval) {
+			log.Error().
+				Int64("monitor_id", monitor.ID).
+				Str("interval", monitor.Interval).
+				Msg("Invalid traceroute monitor interval during initialization")
+			continue
+		}
+
+		if monitor.NextRun != nil && !monitor.NextRun.Before(now) {
+			continue
+		}
+
+		nextRun := s.calculateNextRun(monitor.Interval, now, true)
+		if nextRun.IsZero() {
+			log.Error().
+				Int64("monitor_id", monitor.ID).
+				Str("interval", monitor.Interval).
+				Msg("Could not calculate next run time for traceroute monitor")
+			continue
+		}
+		monitor.NextRun = &nextRun
+
+		log.Info().
+			Int64("monitor_id", monitor.ID).
+			Time("next_run", nextRun).
+			Str("interval", monitor.Interval).
+			Msg("Rescheduling traceroute monitor")
+
+		if err := s.db.UpdateTracerouteMonitor(ctx, monitor); err != nil {
+			log.Error().
+				Err(err).
+				Int64("monitor_id", monitor.ID).
+				Msg("Error updating traceroute monitor during initialization")
+		}
+	}
+}
+
+// checkAndRunTracerouteMonitors checks for due traceroute monitors and runs them
+func (s *service) checkAndRunTracerouteMonitors(ctx context.Context) {
+	if s.traceroute == nil || s.paused.Load() {
+		return
+	}
+
+	monitors, err := s.db.GetTracerouteMonitors(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Error fetching traceroute monitors")
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, monitor := range monitors {
+		if !monitor.Enabled || monitor.NextRun == nil {
+			continue
+		}
+
+		scheduledStart := monitor.NextRun.UTC()
+		if scheduledStart.After(now) {
+			continue
+		}
+
+		if !s.pendingTraceroutes.claim(monitor.ID) {
+			log.Warn().
+				Int64("monitor_id", monitor.ID).
+				Str("host", monitor.Host).
+				Msg("Traceroute is still queued or running, skipping")
+			continue
+		}
+
+		log.Info().
+			Int64("monitor_id", monitor.ID).
+			Str("host", monitor.Host).
+			Time("scheduled_start_time_utc", scheduledStart).
+			Str("interval", monitor.Interval).
+			Msg("Starting scheduled traceroute")
+
+		go func(monitor *types.TracerouteMonitor, scheduledStart time.Time) {
+			defer s.pendingTraceroutes.release(monitor.ID)
+
+			s.traceroute.RunScheduledTraceroute(speedtest.WithScheduledStart(ctx, scheduledStart), monitor)
+
+			// Calculate next run from the scheduled start, falling back to the
+			// completion time if the run overran its interval
+			completedAt := time.Now().UTC()
+			nextRun := s.calculateNextRun(monitor.Interval, scheduledStart, true)
+			if nextRun.IsZero() {
+				log.Error().
+					Int64("monitor_id", monitor.ID).
+					Str("interval", monitor.Interval).
+					Msg("Error calculating next run time for traceroute monitor")
+				return
+			}
+			if nextRun.Before(completedAt) {
+				nextRun = s.calculateNextRun(monitor.Interval, completedAt, true)
+			}
+
+			monitor.LastRun = &scheduledStart
+			monitor.NextRun = &nextRun
+
+			if err := s.db.UpdateTracerouteMonitor(context.Background(), monitor); err != nil {
+				log.Error().
+					Err(err).
+					Int64("monitor_id", monitor.ID).
+					Msg("Error updating traceroute monitor schedule")
+			}
+		}(monitor, scheduledStart)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/database"
+)
+
+// createAPIKeyRequest is the body of POST /api/keys
+type createAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// createAPIKeyResponse carries the key itself, which is only ever returned here
+type createAPIKeyResponse struct {
+	database.APIKey
+	Key string `json:"key"`
+}
+
+// requireSessionAuth rejects requests made with an API key, so a leaked key can't be
+// used to mint or revoke other keys
+func requireSessionAuth(c *gin.Context) bool {
+	if _, ok := c.Get(apiKeyIDContextKey); ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot manage API keys"})
+		return false
+	}
+	return true
+}
+
+// handleGetAPIKeys lists the API keys without their secrets
+func (s *Server) handleGetAPIKeys(c *gin.Context) {
+	if !requireSessionAuth(c) {
+		return
+	}
+
+	keys, err := s.db.GetAPIKeys(c.Request.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get API keys")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// handleCreateAPIKey creates an API key and returns it in full, once
+func (s *Server) handleCreateAPIKey(c *gin.Context) {
+	if !requireSessionAuth(c) {
+		return
+	}
+
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if len(req.Scopes) == 0 {
+		req.Scopes = []string{database.APIKeyScopeRead}
+	}
+
+	apiKey, key, err := s.db.CreateAPIKey(c.Request.Context(), req.Name, req.Scopes)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to create API key")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	log.Info().Int64("id", apiKey.ID).Str("name", apiKey.Name).Strs("scopes", apiKey.Scopes).Msg("API key created")
+	c.JSON(http.StatusCreated, createAPIKeyResponse{APIKey: *apiKey, Key: key})
+}
+
+// handleDeleteAPIKey revokes an API key
+func (s *Server) handleDeleteAPIKey(c *gin.Context) {
+	if !requireSessionAuth(c) {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	if err := s.db.DeleteAPIKey(c.Request.Context(), id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+			return
+		}
+		log.Error().Err(err).Int64("id", id).Msg("Failed to delete API key")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete API key"})
+		return
+	}
+
+	log.Info().Int64("id", id).Msg("API key revoked")
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/autobrr/netronome/internal/auth"
+	"github.com/autobrr/netronome/internal/config"
 	"github.com/autobrr/netronome/internal/database"
 	"github.com/autobrr/netronome/internal/utils"
 )
@@ -24,6 +25,7 @@ type AuthHandler struct {
 	db             database.Service
 	oidc           *auth.OIDCConfig
 	oidcConfigured bool                     // true when OIDC issuer is set in config, independent of provider state
+	oidcRoles      config.OIDCConfig        // role claim mapping for OIDC users
 	sessionTokens  map[string]SessionClaims // Track valid memory sessions
 	pkceVerifiers  map[string]string        // Track PKCE code verifiers by state
 	sessionMutex   sync.RWMutex
@@ -32,11 +34,12 @@ type AuthHandler struct {
 	whitelist      []string
 }
 
-func NewAuthHandler(db database.Service, oidc *auth.OIDCConfig, oidcConfigured bool, sessionSecret string, whitelist []string) *AuthHandler {
+func NewAuthHandler(db database.Service, oidc *auth.OIDCConfig, oidcConfigured bool, oidcRoles config.OIDCConfig, sessionSecret string, whitelist []string) *AuthHandler {
 	return &AuthHandler{
 		db:             db,
 		oidc:           oidc,
 		oidcConfigured: oidcConfigured,
+		oidcRoles:      oidcRoles,
 		sessionTokens:  make(map[string]SessionClaims),
 		pkceVerifiers:  make(map[string]string),
 		sessionSecret:  sessionSecret,
@@ -229,6 +232,12 @@ func (h *AuthHandler) maybeRefreshOIDCSession(c *gin.Context, signedToken string
 		claims.Subject = idClaims.Subject
 		claims.Username = pickOIDCUsername(idClaims)
 		claims.IDTokenExp = idClaims.Expiry
+		// Pick up group changes made at the IdP, dropping access if no role maps anymore
+		role, err := auth.ResolveRole(idClaims.Raw, h.oidcRoles)
+		if err != nil {
+			log.Info().Str("username", claims.Username).Msg("OIDC user no longer has a mapped role")
+		}
+		claims.Role = role
 	}
 
 	if token.RefreshToken != "" && token.RefreshToken != refreshToken {
@@ -276,6 +285,38 @@ func pickOIDCUsername(claims *auth.IDTokenClaims) string {
 	return claims.Subject
 }
 
+// sessionRole returns the role of an OIDC session. Sessions created before role
+// mapping was enabled carry no role and are denied until the user logs in again.
+func (h *AuthHandler) sessionRole(claims *SessionClaims) string {
+	if h.oidcRoles.RoleClaim == "" {
+		return config.RoleAdmin
+	}
+	return claims.Role
+}
+
+// authorizeRole stores the role on the context and aborts requests the role may
+// not make. Viewers are read-only, apart from logging out.
+func authorizeRole(c *gin.Context, role string) bool {
+	switch {
+	case role == "":
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "No role is assigned to this account"})
+		return false
+	case role == config.RoleViewer && !isReadOnlyRequest(c):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Viewers cannot make changes"})
+		return false
+	}
+	c.Set("role", role)
+	return true
+}
+
+func isReadOnlyRequest(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return strings.HasSuffix(c.FullPath(), "/auth/logout")
+}
+
 func sessionUsername(claims *SessionClaims) string {
 	if claims == nil {
 		return ""
@@ -428,6 +469,7 @@ func (h *AuthHandler) Verify(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"message": "IP is whitelisted",
 			"type":    "whitelist",
+			"role":    c.GetString("role"),
 		})
 		return
 	}
@@ -444,6 +486,7 @@ func (h *AuthHandler) Verify(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"message": "Token is valid",
 			"type":    "oidc",
+			"role":    c.GetString("role"),
 		})
 		return
 	}
@@ -471,6 +514,7 @@ func (h *AuthHandler) Verify(c *gin.Context) {
 				c.JSON(http.StatusOK, gin.H{
 					"message": "Token is valid",
 					"type":    "oidc",
+					"role":    c.GetString("role"),
 				})
 				return
 			}
@@ -506,6 +550,7 @@ func (h *AuthHandler) Verify(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Token is valid",
 		"type":    "session",
+		"role":    c.GetString("role"),
 	})
 }
 
@@ -543,6 +588,7 @@ func (h *AuthHandler) GetUserInfo(c *gin.Context) {
 			"user": gin.H{
 				"id":       0,
 				"username": "whitelisted",
+				"role":     c.GetString("role"),
 			},
 		})
 		return
@@ -560,6 +606,7 @@ func (h *AuthHandler) GetUserInfo(c *gin.Context) {
 			"user": gin.H{
 				"id":       0,
 				"username": username,
+				"role":     c.GetString("role"),
 			},
 		})
 		return
@@ -589,6 +636,7 @@ func (h *AuthHandler) GetUserInfo(c *gin.Context) {
 					"user": gin.H{
 						"id":       0, // OIDC users don't have local IDs
 						"username": claims.Subject,
+						"role":     c.GetString("role"),
 					},
 				})
 				return
@@ -629,6 +677,7 @@ func (h *AuthHandler) GetUserInfo(c *gin.Context) {
 		"user": gin.H{
 			"id":       user.ID,
 			"username": user.Username,
+			"role":     c.GetString("role"),
 		},
 	})
 }
@@ -660,9 +709,41 @@ func isWhitelisted(c *gin.Context, whitelist []string) bool {
 	return false
 }
 
+// apiKeyIDContextKey holds the ID of the API key a request was authenticated with
+const apiKeyIDContextKey = "api_key_id"
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
 func RequireAuth(db database.Service, oidc *auth.OIDCConfig, sessionSecret string, handler *AuthHandler, whitelist []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if isWhitelisted(c, whitelist) {
+			c.Set("role", config.RoleAdmin)
+			c.Next()
+			return
+		}
+
+		if key, ok := bearerToken(c); ok {
+			apiKey, err := db.AuthenticateAPIKey(c.Request.Context(), key)
+			if err != nil {
+				if !errors.Is(err, database.ErrInvalidAPIKey) {
+					log.Error().Err(err).Msg("Failed to check API key")
+				}
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+				return
+			}
+			if !authorizeRole(c, apiKey.Role()) {
+				return
+			}
+			c.Set("username", "api-key:"+apiKey.Name)
+			c.Set(apiKeyIDContextKey, apiKey.ID)
 			c.Next()
 			return
 		}
@@ -694,6 +775,10 @@ func RequireAuth(db database.Service, oidc *auth.OIDCConfig, sessionSecret strin
 		if claims, ok := handler.getSessionClaims(signedToken, rawToken); ok && claims.Type == sessionTypeOIDC {
 			handler.maybeRefreshOIDCSession(c, signedToken, claims)
 			username := sessionUsername(claims)
+			if !authorizeRole(c, handler.sessionRole(claims)) {
+				log.Debug().Str("username", username).Msg("OIDC user not authorized for request")
+				return
+			}
 			c.Set("username", username)
 			c.Next()
 			return
@@ -715,7 +800,11 @@ func RequireAuth(db database.Service, oidc *auth.OIDCConfig, sessionSecret strin
 
 			// Check if it's a JWT and verify with OIDC
 			if isJWT(actualToken) {
-				if err := oidc.VerifyToken(c.Request.Context(), actualToken); err == nil {
+				if idClaims, err := oidc.VerifyTokenWithClaims(c.Request.Context(), actualToken); err == nil {
+					role, _ := auth.ResolveRole(idClaims.Raw, handler.oidcRoles)
+					if !authorizeRole(c, role) {
+						return
+					}
 					c.Next()
 					return
 				}
@@ -737,6 +826,7 @@ func RequireAuth(db database.Service, oidc *auth.OIDCConfig, sessionSecret strin
 		//	Msg("User authenticated successfully")
 
 		c.Set("username", username)
+		c.Set("role", config.RoleAdmin)
 		c.Next()
 	}
 }
@@ -125,11 +125,20 @@ func (h *AuthHandler) handleOIDCCallback(c *gin.Context) {
 		return
 	}
 
+	username := pickOIDCUsername(idClaims)
+	role, err := auth.ResolveRole(idClaims.Raw, h.oidcRoles)
+	if err != nil {
+		log.Warn().Str("username", username).Str("claim", h.oidcRoles.RoleClaim).Msg("OIDC user has no mapped role, denying login")
+		c.Redirect(http.StatusTemporaryRedirect, loginErrorRedirectURL(baseURL, "access_denied"))
+		return
+	}
+
 	sessionClaims := SessionClaims{
 		Version:    sessionClaimsVersion,
 		Type:       sessionTypeOIDC,
 		Subject:    idClaims.Subject,
-		Username:   pickOIDCUsername(idClaims),
+		Username:   username,
+		Role:       role,
 		IDTokenExp: idClaims.Expiry,
 	}
 
@@ -17,6 +17,7 @@ import (
 	_ "modernc.org/sqlite"
 
 	"github.com/autobrr/netronome/internal/auth"
+	"github.com/autobrr/netronome/internal/config"
 	"github.com/autobrr/netronome/internal/database"
 )
 
@@ -137,7 +138,7 @@ func TestCheckRegistrationStatusReportsOIDCState(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			handler := NewAuthHandler(newAuthStatusTestDB(t), tt.oidc, tt.oidcConfigured, "", nil)
+			handler := NewAuthHandler(newAuthStatusTestDB(t), tt.oidc, tt.oidcConfigured, config.OIDCConfig{}, "", nil)
 
 			recorder := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(recorder)
@@ -163,7 +164,7 @@ func TestCheckRegistrationStatusReportsOIDCState(t *testing.T) {
 func TestHandleOIDCLoginRedirectUsesLoginPathUnderSubpathBaseURL(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	handler := NewAuthHandler(nil, nil, true, "", nil)
+	handler := NewAuthHandler(nil, nil, true, config.OIDCConfig{}, "", nil)
 
 	recorder := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(recorder)
@@ -179,7 +180,7 @@ func TestHandleOIDCLoginRedirectUsesLoginPathUnderSubpathBaseURL(t *testing.T) {
 func TestHandleOIDCCallbackRedirectUsesLoginPathUnderSubpathBaseURL(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	handler := NewAuthHandler(nil, nil, true, "", nil)
+	handler := NewAuthHandler(nil, nil, true, config.OIDCConfig{}, "", nil)
 
 	recorder := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(recorder)
@@ -191,3 +192,108 @@ func TestHandleOIDCCallbackRedirectUsesLoginPathUnderSubpathBaseURL(t *testing.T
 	require.Equal(t, http.StatusTemporaryRedirect, recorder.Code)
 	assert.Equal(t, "/netronome/login?error=oidc_unavailable", recorder.Header().Get("Location"))
 }
+
+func TestAuthorizeRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		role    string
+		method  string
+		path    string
+		allowed bool
+	}{
+		{name: "admin can write", role: config.RoleAdmin, method: http.MethodPost, path: "/api/schedules", allowed: true},
+		{name: "viewer can read", role: config.RoleViewer, method: http.MethodGet, path: "/api/schedules", allowed: true},
+		{name: "viewer cannot write", role: config.RoleViewer, method: http.MethodPost, path: "/api/schedules", allowed: false},
+		{name: "viewer can log out", role: config.RoleViewer, method: http.MethodPost, path: "/api/auth/logout", allowed: true},
+		{name: "no role denied", role: "", method: http.MethodGet, path: "/api/schedules", allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Handle(tt.method, tt.path, func(c *gin.Context) {
+				if authorizeRole(c, tt.role) {
+					c.String(http.StatusOK, c.GetString("role"))
+				}
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if tt.allowed {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, tt.role, w.Body.String())
+			} else {
+				assert.Equal(t, http.StatusForbidden, w.Code)
+			}
+		})
+	}
+}
+
+type apiKeyTestDB struct {
+	database.Service
+	keys map[string]*database.APIKey
+}
+
+func (d apiKeyTestDB) AuthenticateAPIKey(ctx context.Context, key string) (*database.APIKey, error) {
+	apiKey, ok := d.keys[key]
+	if !ok {
+		return nil, database.ErrInvalidAPIKey
+	}
+	return apiKey, nil
+}
+
+func TestRequireAuthAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := apiKeyTestDB{keys: map[string]*database.APIKey{
+		"ntr_read":  {ID: 1, Name: "ci", Scopes: []string{database.APIKeyScopeRead}},
+		"ntr_write": {ID: 2, Name: "deploy", Scopes: []string{database.APIKeyScopeRead, database.APIKeyScopeWrite}},
+	}}
+
+	router := gin.New()
+	router.Use(RequireAuth(db, nil, "", &AuthHandler{}, []string{"192.168.1.0/24"}))
+	handler := func(c *gin.Context) { c.String(http.StatusOK, c.GetString("role")) }
+	router.GET("/api/schedules", handler)
+	router.POST("/api/schedules", handler)
+	router.GET("/api/keys", (&Server{db: db}).handleGetAPIKeys)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		auth       string
+		remoteAddr string
+		wantStatus int
+		wantRole   string
+	}{
+		{name: "read key can read", method: http.MethodGet, path: "/api/schedules", auth: "Bearer ntr_read", wantStatus: http.StatusOK, wantRole: config.RoleViewer},
+		{name: "read key cannot write", method: http.MethodPost, path: "/api/schedules", auth: "Bearer ntr_read", wantStatus: http.StatusForbidden},
+		{name: "write key can write", method: http.MethodPost, path: "/api/schedules", auth: "bearer ntr_write", wantStatus: http.StatusOK, wantRole: config.RoleAdmin},
+		{name: "unknown key", method: http.MethodGet, path: "/api/schedules", auth: "Bearer ntr_nope", wantStatus: http.StatusUnauthorized},
+		{name: "no credentials", method: http.MethodGet, path: "/api/schedules", wantStatus: http.StatusUnauthorized},
+		{name: "whitelist is checked first", method: http.MethodPost, path: "/api/schedules", auth: "Bearer ntr_nope", remoteAddr: "192.168.1.10:1234", wantStatus: http.StatusOK, wantRole: config.RoleAdmin},
+		{name: "keys can't manage keys", method: http.MethodGet, path: "/api/keys", auth: "Bearer ntr_write", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantRole != "" {
+				assert.Equal(t, tt.wantRole, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxBodySizeMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(limit int64) *gin.Engine {
+		router := gin.New()
+		router.Use(MaxBodySizeMiddleware(limit))
+		router.POST("/api/agents", func(c *gin.Context) {
+			var body map[string]any
+			if err := c.ShouldBindJSON(&body); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.Status(http.StatusOK)
+		})
+		return router
+	}
+	request := func(router *gin.Engine, body string, chunked bool) *httptest.ResponseRecorder {
+		var reader io.Reader = strings.NewReader(body)
+		if chunked {
+			// Hide the length, as a chunked upload would
+			reader = io.MultiReader(reader)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/agents", reader)
+		if chunked {
+			req.ContentLength = -1
+		}
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	small := `{"name":"edge"}`
+	large := `{"name":"` + strings.Repeat("x", 100) + `"}`
+
+	router := newRouter(64)
+	assert.Equal(t, http.StatusOK, request(router, small, false).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, request(router, large, false).Code)
+	assert.Equal(t, http.StatusOK, request(router, small, true).Code)
+	assert.Equal(t, http.StatusBadRequest, request(router, large, true).Code, "reading stops at the limit")
+
+	assert.Equal(t, http.StatusOK, request(newRouter(0), large, false).Code, "0 disables the limit")
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// exportDateLayout is accepted for start/end next to RFC3339, an end date covers the whole day
+const exportDateLayout = "2006-01-02"
+
+var speedTestExportHeader = []string{
+	"id", "created_at", "test_type", "server_name", "server_id", "server_host",
+	"download_mbps", "upload_mbps", "latency", "jitter_ms", "ttfb_ms",
+	"datagram_loss_percent", "path_mtu", "is_scheduled", "note",
+}
+
+var packetLossExportHeader = []string{
+	"id", "created_at", "monitor_id", "monitor_host", "monitor_name", "packet_loss_percent",
+	"min_rtt_ms", "avg_rtt_ms", "max_rtt_ms", "std_dev_rtt_ms", "jitter_ms",
+	"packets_sent", "packets_recv", "hop_count", "used_mtr", "privileged_mode", "probed_ip", "note",
+}
+
+func (s *Server) handleExportSpeedTests(c *gin.Context) {
+	from, to, ok := parseExportRange(c)
+	if !ok {
+		return
+	}
+
+	streamCSV(c, "speedtests", s.exportPageSize(), speedTestExportHeader,
+		func(afterID int64, limit int) ([]types.SpeedTestResult, error) {
+			return s.db.ExportSpeedTests(c.Request.Context(), from, to, afterID, limit)
+		},
+		func(r types.SpeedTestResult) int64 { return r.ID },
+		speedTestExportRecord,
+	)
+}
+
+func (s *Server) handleExportPacketLoss(c *gin.Context) {
+	from, to, ok := parseExportRange(c)
+	if !ok {
+		return
+	}
+
+	streamCSV(c, "packetloss", s.exportPageSize(), packetLossExportHeader,
+		func(afterID int64, limit int) ([]database.PacketLossExportRow, error) {
+			return s.db.ExportPacketLossResults(c.Request.Context(), from, to, afterID, limit)
+		},
+		func(r database.PacketLossExportRow) int64 { return r.ID },
+		packetLossExportRecord,
+	)
+}
+
+func (s *Server) exportPageSize() int {
+	if s.config != nil && s.config.Pagination.MaxPageSize > 0 {
+		return s.config.Pagination.MaxPageSize
+	}
+	return 100
+}
+
+// parseExportRange reads the optional start and end query values, writing a 400 response
+// when either is invalid
+func parseExportRange(c *gin.Context) (time.Time, time.Time, bool) {
+	from, err := parseExportTime(c.Query("start"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start parameter, expected RFC3339 or YYYY-MM-DD"})
+		return time.Time{}, time.Time{}, false
+	}
+	to, err := parseExportTime(c.Query("end"), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end parameter, expected RFC3339 or YYYY-MM-DD"})
+		return time.Time{}, time.Time{}, false
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end must not be before start"})
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// parseExportTime parses an RFC3339 time or a UTC date, which resolves to the last
+// instant of that day when endOfDay is set
+func parseExportTime(v string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(exportDateLayout, v); err == nil {
+		if endOfDay {
+			return t.Add(24*time.Hour - time.Nanosecond), nil
+		}
+		return t, nil
+	}
+	return parseOptionalTime(v)
+}
+
+// streamCSV writes a CSV attachment page by page, so large exports never sit in memory.
+// The first page is read before any header is written, so an early database error
+// still turns into a proper error response.
+func streamCSV[T any](c *gin.Context, name string, pageSize int, header []string, fetch func(afterID int64, limit int) ([]T, error), id func(T) int64, record func(T) []string) {
+	page, err := fetch(0, pageSize)
+	if err != nil {
+		log.Error().Err(err).Str("export", name).Msg("Failed to export data")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to export %s", name)})
+		return
+	}
+
+	filename := fmt.Sprintf("netronome-%s-%s.csv", name, time.Now().UTC().Format("20060102"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	if err := w.Write(header); err != nil {
+		return
+	}
+
+	for len(page) > 0 {
+		for _, row := range page {
+			if err := w.Write(record(row)); err != nil {
+				return
+			}
+		}
+		w.Flush()
+		if w.Error() != nil {
+			return
+		}
+		c.Writer.Flush()
+
+		if len(page) < pageSize {
+			return
+		}
+		if page, err = fetch(id(page[len(page)-1]), pageSize); err != nil {
+			// The status line is already out, all that is left is to cut the file short
+			log.Error().Err(err).Str("export", name).Msg("Failed to export data")
+			return
+		}
+	}
+	w.Flush()
+}
+
+func speedTestExportRecord(r types.SpeedTestResult) []string {
+	return []string{
+		strconv.FormatInt(r.ID, 10),
+		r.CreatedAt.UTC().Format(time.RFC3339),
+		r.TestType,
+		csvText(r.ServerName),
+		csvText(r.ServerID),
+		csvText(optionalString(r.ServerHost)),
+		formatCSVFloat(r.DownloadSpeed),
+		formatCSVFloat(r.UploadSpeed),
+		csvText(r.Latency),
+		optionalCSVFloat(r.Jitter),
+		optionalCSVFloat(r.TTFB),
+		optionalCSVFloat(r.DatagramLoss),
+		optionalCSVInt(r.PathMTU),
+		strconv.FormatBool(r.IsScheduled),
+		csvText(optionalString(r.Note)),
+	}
+}
+
+func packetLossExportRecord(r database.PacketLossExportRow) []string {
+	return []string{
+		strconv.FormatInt(r.ID, 10),
+		r.CreatedAt.UTC().Format(time.RFC3339),
+		strconv.FormatInt(r.MonitorID, 10),
+		csvText(r.MonitorHost),
+		csvText(r.MonitorName),
+		formatCSVFloat(r.PacketLoss),
+		formatCSVFloat(r.MinRTT),
+		formatCSVFloat(r.AvgRTT),
+		formatCSVFloat(r.MaxRTT),
+		formatCSVFloat(r.StdDevRTT),
+		formatCSVFloat(r.Jitter),
+		strconv.Itoa(r.PacketsSent),
+		strconv.Itoa(r.PacketsRecv),
+		strconv.Itoa(r.HopCount),
+		strconv.FormatBool(r.UsedMTR),
+		strconv.FormatBool(r.PrivilegedMode),
+		optionalString(r.ProbedIP),
+		csvText(optionalString(r.Note)),
+	}
+}
+
+// csvText keeps user supplied text from being evaluated as a formula by spreadsheets
+func csvText(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+func formatCSVFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func optionalCSVFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return formatCSVFloat(*v)
+}
+
+func optionalCSVInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func optionalString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+type exportTestDB struct {
+	database.Service
+	results []types.SpeedTestResult
+	err     error
+	calls   int
+	from    time.Time
+	to      time.Time
+}
+
+func (d *exportTestDB) ExportSpeedTests(ctx context.Context, from, to time.Time, afterID int64, limit int) ([]types.SpeedTestResult, error) {
+	d.calls++
+	d.from, d.to = from, to
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	var page []types.SpeedTestResult
+	for _, result := range d.results {
+		if result.ID > afterID && len(page) < limit {
+			page = append(page, result)
+		}
+	}
+	return page, nil
+}
+
+func TestHandleExportSpeedTests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	note := "=HYPERLINK(\"x\")"
+	jitter := 1.5
+	db := &exportTestDB{}
+	for i := 1; i <= 5; i++ {
+		db.results = append(db.results, types.SpeedTestResult{
+			ID:            int64(i),
+			ServerName:    "Test Server",
+			TestType:      "iperf3",
+			DownloadSpeed: 100.25,
+			Jitter:        &jitter,
+			Note:          &note,
+			CreatedAt:     time.Date(2026, 3, 1, 12, i, 0, 0, time.FixedZone("CET", 3600)),
+		})
+	}
+
+	cfg := config.New()
+	cfg.Pagination.MaxPageSize = 2
+	s := &Server{db: db, config: cfg}
+	router := gin.New()
+	router.GET("/api/export/speedtests.csv", s.handleExportSpeedTests)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/export/speedtests.csv?start=2026-03-01&end=2026-03-01", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment; filename=\"netronome-speedtests-")
+	assert.Equal(t, 3, db.calls, "two full pages and a short one")
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), db.from)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond), db.to)
+
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 6)
+	assert.Equal(t, speedTestExportHeader, records[0])
+	assert.Equal(t, "1", records[1][0])
+	assert.Equal(t, "2026-03-01T11:01:00Z", records[1][1])
+	assert.Equal(t, "100.25", records[1][6])
+	assert.Equal(t, "1.5", records[1][9])
+	assert.Equal(t, "", records[1][10], "missing values stay empty")
+	assert.Equal(t, "'"+note, records[1][14], "formulas are defused")
+	assert.Equal(t, "5", records[5][0])
+}
+
+func TestHandleExportSpeedTestsInvalidRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := &Server{db: &exportTestDB{}, config: config.New()}
+	router := gin.New()
+	router.GET("/api/export/speedtests.csv", s.handleExportSpeedTests)
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "bad start", query: "start=yesterday"},
+		{name: "bad end", query: "end=2026-13-01"},
+		{name: "end before start", query: "start=2026-03-02&end=2026-03-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/export/speedtests.csv?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestHandleExportSpeedTestsQueryError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := &Server{db: &exportTestDB{err: errors.New("database is locked")}, config: config.New()}
+	router := gin.New()
+	router.GET("/api/export/speedtests.csv", s.handleExportSpeedTests)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/export/speedtests.csv", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Failed to export speedtests", body["error"])
+}
@@ -5,15 +5,20 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 
+	"github.com/autobrr/netronome/internal/database"
 	"github.com/autobrr/netronome/internal/notifications"
+	"github.com/autobrr/netronome/internal/speedtest"
 	"github.com/autobrr/netronome/internal/types"
 )
 
@@ -31,13 +36,8 @@ func (s *Server) handleSpeedTest(c *gin.Context) {
 	s.lastUpdate = &types.SpeedUpdate{}
 	s.mu.Unlock()
 
-	timeout := time.Duration(s.config.SpeedTest.Timeout) * time.Second
-	if opts.UseLibrespeed {
-		timeout = time.Duration(s.config.SpeedTest.Librespeed.Timeout) * time.Second
-	}
-
 	// Use configured timeout
-	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), s.speedTestTimeout(&opts))
 	defer cancel()
 
 	result, err := s.speedtest.RunTest(ctx, &opts)
@@ -61,6 +61,8 @@ func (s *Server) handleSpeedTest(c *gin.Context) {
 				failedResult.Provider = "iperf"
 			} else if opts.UseLibrespeed {
 				failedResult.Provider = "librespeed"
+			} else if opts.UseOokla {
+				failedResult.Provider = "ookla"
 			}
 			
 			notifyErr := s.notifier.SendSpeedTestNotification(failedResult)
@@ -89,15 +91,38 @@ func (s *Server) handleSpeedTest(c *gin.Context) {
 	}
 }
 
+// handleSpeedTestHistory serves a page of speed test results, optionally filtered by
+// provider, server and start time
 func (s *Server) handleSpeedTestHistory(c *gin.Context) {
 	timeRange := c.DefaultQuery("timeRange", s.config.Pagination.DefaultTimeRange)
-	page, _ := strconv.Atoi(c.DefaultQuery("page", strconv.Itoa(s.config.Pagination.DefaultPage)))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(s.config.Pagination.DefaultLimit)))
+	page, err := strconv.Atoi(c.DefaultQuery("page", strconv.Itoa(s.config.Pagination.DefaultPage)))
+	if err != nil || page < 1 {
+		page = s.config.Pagination.DefaultPage
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(s.config.Pagination.DefaultLimit)))
+	if err != nil || limit < 1 {
+		limit = s.config.Pagination.DefaultLimit
+	}
+
+	filter := types.SpeedTestFilter{
+		Provider: strings.TrimSpace(c.Query("provider")),
+		Server:   strings.TrimSpace(c.Query("server")),
+	}
+	since, err := parseOptionalTime(c.Query("since"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since parameter, expected RFC3339"})
+		return
+	}
+	if !since.IsZero() {
+		filter.Since = &since
+	}
 
-	results, err := s.db.GetSpeedTests(c.Request.Context(), timeRange, page, limit)
+	results, err := s.db.GetSpeedTests(c.Request.Context(), timeRange, filter, page, limit)
 	if err != nil {
 		log.Error().Err(err).
 			Str("timeRange", timeRange).
+			Str("provider", filter.Provider).
+			Str("server", filter.Server).
 			Int("page", page).
 			Int("limit", limit).
 			Msg("Failed to retrieve speed test history")
@@ -109,24 +134,77 @@ func (s *Server) handleSpeedTestHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, results)
 }
 
-func (s *Server) handlePublicSpeedTestHistory(c *gin.Context) {
-	timeRange := c.DefaultQuery("timeRange", s.config.Pagination.DefaultTimeRange)
-	page, _ := strconv.Atoi(c.DefaultQuery("page", strconv.Itoa(s.config.Pagination.DefaultPage)))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(s.config.Pagination.DefaultLimit)))
+// handleUpdateSpeedTestNote attaches a note to a speed test result
+func (s *Server) handleUpdateSpeedTestNote(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid result ID"})
+		return
+	}
 
-	results, err := s.db.GetSpeedTests(c.Request.Context(), timeRange, page, limit)
+	var req types.ResultNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if len(req.Note) > types.MaxResultNoteLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("note must be at most %d characters", types.MaxResultNoteLength)})
+		return
+	}
+
+	if err := s.db.UpdateSpeedTestNote(c.Request.Context(), id, req.Note); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Result not found"})
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		_ = c.Error(fmt.Errorf("failed to update speed test note: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Note updated successfully"})
+}
+
+// handleSpeedTestRatioHistory returns download/upload ratios oldest first, flagging
+// results whose ratio moved more than threshold percent from recent results
+func (s *Server) handleSpeedTestRatioHistory(c *gin.Context) {
+	from, err := parseOptionalTime(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from parameter, expected RFC3339"})
+		return
+	}
+	to, err := parseOptionalTime(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to parameter, expected RFC3339"})
+		return
+	}
+	threshold := speedtest.DefaultRatioShiftPercent
+	if v := c.Query("threshold"); v != "" {
+		threshold, err = strconv.ParseFloat(v, 64)
+		if err != nil || threshold < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "threshold must be a non-negative percentage"})
+			return
+		}
+	}
+	if from.IsZero() {
+		from = time.Now().AddDate(0, 0, -30)
+	}
+
+	ratios, err := s.db.GetSpeedTestRatios(c.Request.Context(), c.Query("testType"), from, to, 0)
 	if err != nil {
-		log.Error().Err(err).
-			Str("timeRange", timeRange).
-			Int("page", page).
-			Int("limit", limit).
-			Msg("Failed to retrieve speed test history")
 		c.Status(http.StatusInternalServerError)
-		_ = c.Error(fmt.Errorf("failed to retrieve speed test history: %w", err))
+		_ = c.Error(fmt.Errorf("failed to retrieve speed test ratios: %w", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, results)
+	slices.Reverse(ratios)
+	speedtest.AnnotateRatioShifts(ratios, threshold)
+
+	c.JSON(http.StatusOK, ratios)
+}
+
+func (s *Server) handlePublicSpeedTestHistory(c *gin.Context) {
+	s.handleSpeedTestHistory(c)
 }
 
 func (s *Server) handleGetServers(c *gin.Context) {
@@ -230,6 +308,11 @@ func (s *Server) handleDeleteSchedule(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Schedule deleted successfully"})
 }
 
+// handleGetSchedulerQueue reports queued and running scheduled tests and their wait times
+func (s *Server) handleGetSchedulerQueue(c *gin.Context) {
+	c.JSON(http.StatusOK, s.scheduler.QueueStats())
+}
+
 func (s *Server) handleTraceroute(c *gin.Context) {
 	host := c.Query("host")
 	if host == "" {
@@ -238,6 +321,14 @@ func (s *Server) handleTraceroute(c *gin.Context) {
 		return
 	}
 
+	family, err := speedtest.ParseAddressFamily(c.Query("family"))
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		_ = c.Error(err)
+		return
+	}
+	opts := speedtest.TracerouteOptions{Family: family, ResolveNames: c.Query("resolveNames") == "true"}
+
 	// Reset lastTracerouteUpdate before starting new traceroute
 	s.mu.Lock()
 	s.lastTracerouteUpdate = &types.TracerouteUpdate{
@@ -246,12 +337,12 @@ func (s *Server) handleTraceroute(c *gin.Context) {
 	}
 	s.mu.Unlock()
 
-	// Create a timeout context for the traceroute
-	timeout := 90 * time.Second // Extended timeout for traceroute
+	// Create a timeout context for the traceroute, allowing for one run per traced IP
+	timeout := 90 * time.Second * time.Duration(min(max(s.config.SpeedTest.TracerouteMaxIPs, 1), speedtest.MaxTracerouteIPs))
 	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
 	defer cancel()
 
-	result, err := s.speedtest.RunTraceroute(ctx, host)
+	result, err := s.speedtest.RunTraceroute(ctx, host, opts)
 	if err != nil {
 		// Update status with error
 		s.mu.Lock()
@@ -273,6 +364,46 @@ func (s *Server) handleTraceroute(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// handleGeoIPReenrich backfills GeoIP data into stored MTR results, optionally
+// limited to ?from= and ?to= (RFC3339)
+func (s *Server) handleGeoIPReenrich(c *gin.Context) {
+	from, err := parseOptionalTime(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from parameter, expected RFC3339"})
+		return
+	}
+	to, err := parseOptionalTime(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to parameter, expected RFC3339"})
+		return
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+
+	result, err := s.speedtest.ReenrichGeoIP(c.Request.Context(), from, to)
+	if err != nil {
+		if errors.Is(err, speedtest.ErrGeoIPUnavailable) {
+			c.JSON(http.StatusConflict, gin.H{"error": "GeoIP databases are not configured"})
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		_ = c.Error(fmt.Errorf("failed to re-enrich GeoIP data: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// parseOptionalTime parses an RFC3339 query value, returning the zero time when empty
+func parseOptionalTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
 func (s *Server) handleTracerouteStatus(c *gin.Context) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
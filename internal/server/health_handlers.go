@@ -0,0 +1,123 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessDBTimeout bounds the database check of /readyz
+const readinessDBTimeout = 2 * time.Second
+
+// Subsystem states reported by /readyz
+const (
+	subsystemUp       = "up"
+	subsystemDown     = "down"
+	subsystemStarting = "starting"
+	subsystemStopping = "stopping"
+	subsystemDisabled = "disabled"
+)
+
+type subsystemStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type readinessResponse struct {
+	Status     string                     `json:"status"`
+	Subsystems map[string]subsystemStatus `json:"subsystems"`
+}
+
+// registerHealthRoutes serves <base_url>/healthz and <base_url>/readyz without authentication
+func (s *Server) registerHealthRoutes() {
+	routeBase := strings.TrimSuffix(s.config.Server.BaseURL, "/")
+	if routeBase != "" && !strings.HasPrefix(routeBase, "/") {
+		routeBase = "/" + routeBase
+	}
+
+	s.Router.GET(routeBase+"/healthz", s.handleHealthz)
+	s.Router.GET(routeBase+"/readyz", s.handleReadyz)
+}
+
+// isHealthPath reports whether path is a probe endpoint, which are too frequent to log
+// or rate limit
+func isHealthPath(path string) bool {
+	return strings.HasSuffix(path, "/healthz") || strings.HasSuffix(path, "/readyz")
+}
+
+// handleHealthz is the liveness probe. It only shows the process still serves requests,
+// so a slow database doesn't get the container restarted.
+func (s *Server) handleHealthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// handleReadyz is the readiness probe. It returns 503 until startup finishes, once
+// shutdown starts, and while the database doesn't answer or an enabled subsystem isn't
+// running.
+func (s *Server) handleReadyz(c *gin.Context) {
+	s.mu.RLock()
+	ready := s.ready
+	shuttingDown := s.shuttingDown
+	schedulerStarted := s.schedulerStarted
+	monitorService := s.monitorService
+	s.mu.RUnlock()
+
+	subsystems := make(map[string]subsystemStatus, 4)
+
+	switch {
+	case shuttingDown:
+		subsystems["initialization"] = subsystemStatus{Status: subsystemStopping}
+	case ready:
+		subsystems["initialization"] = subsystemStatus{Status: subsystemUp}
+	default:
+		subsystems["initialization"] = subsystemStatus{Status: subsystemStarting}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessDBTimeout)
+	defer cancel()
+	if err := s.db.Ping(ctx); err != nil {
+		subsystems["database"] = subsystemStatus{Status: subsystemDown, Error: err.Error()}
+	} else {
+		subsystems["database"] = subsystemStatus{Status: subsystemUp}
+	}
+
+	// The scheduler is left off by serve --dry-run
+	switch {
+	case !schedulerStarted && ready:
+		subsystems["scheduler"] = subsystemStatus{Status: subsystemDisabled}
+	case s.scheduler.Running():
+		subsystems["scheduler"] = subsystemStatus{Status: subsystemUp}
+	case schedulerStarted:
+		subsystems["scheduler"] = subsystemStatus{Status: subsystemDown}
+	default:
+		subsystems["scheduler"] = subsystemStatus{Status: subsystemStarting}
+	}
+
+	switch {
+	case !s.config.Monitor.Enabled || monitorService == nil:
+		subsystems["monitor"] = subsystemStatus{Status: subsystemDisabled}
+	case monitorService != nil && monitorService.Started():
+		subsystems["monitor"] = subsystemStatus{Status: subsystemUp}
+	case ready:
+		subsystems["monitor"] = subsystemStatus{Status: subsystemDown, Error: "monitor service failed to start"}
+	default:
+		subsystems["monitor"] = subsystemStatus{Status: subsystemStarting}
+	}
+
+	resp := readinessResponse{Status: "ready", Subsystems: subsystems}
+	code := http.StatusOK
+	for _, subsystem := range subsystems {
+		if subsystem.Status != subsystemUp && subsystem.Status != subsystemDisabled {
+			resp.Status = "not ready"
+			code = http.StatusServiceUnavailable
+			break
+		}
+	}
+	c.JSON(code, resp)
+}
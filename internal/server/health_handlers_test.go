@@ -0,0 +1,141 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/scheduler"
+)
+
+type healthTestDB struct {
+	database.Service
+	err error
+}
+
+func (d *healthTestDB) Ping(ctx context.Context) error {
+	return d.err
+}
+
+type healthTestScheduler struct {
+	scheduler.Service
+	running bool
+}
+
+func (s *healthTestScheduler) Running() bool {
+	return s.running
+}
+
+func TestHandleHealthz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Liveness doesn't depend on the database
+	s := &Server{Router: gin.New(), db: &healthTestDB{err: errors.New("down")}, config: config.New()}
+	s.registerHealthRoutes()
+
+	w := httptest.NewRecorder()
+	s.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestHandleReadyz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name             string
+		ready            bool
+		shuttingDown     bool
+		schedulerStarted bool
+		schedulerRunning bool
+		dbErr            error
+		wantCode         int
+		wantStatuses     map[string]string
+	}{
+		{
+			name:         "starting",
+			wantCode:     http.StatusServiceUnavailable,
+			wantStatuses: map[string]string{"initialization": "starting", "database": "up", "scheduler": "starting", "monitor": "disabled"},
+		},
+		{
+			name:             "ready",
+			ready:            true,
+			schedulerStarted: true,
+			schedulerRunning: true,
+			wantCode:         http.StatusOK,
+			wantStatuses:     map[string]string{"initialization": "up", "database": "up", "scheduler": "up", "monitor": "disabled"},
+		},
+		{
+			name:         "dry run leaves the scheduler disabled",
+			ready:        true,
+			wantCode:     http.StatusOK,
+			wantStatuses: map[string]string{"scheduler": "disabled"},
+		},
+		{
+			name:             "database down",
+			ready:            true,
+			schedulerStarted: true,
+			schedulerRunning: true,
+			dbErr:            errors.New("connection refused"),
+			wantCode:         http.StatusServiceUnavailable,
+			wantStatuses:     map[string]string{"database": "down"},
+		},
+		{
+			name:             "shutting down",
+			ready:            true,
+			shuttingDown:     true,
+			schedulerStarted: true,
+			schedulerRunning: true,
+			wantCode:         http.StatusServiceUnavailable,
+			wantStatuses:     map[string]string{"initialization": "stopping", "database": "up", "scheduler": "up"},
+		},
+		{
+			name:             "scheduler stopped",
+			ready:            true,
+			schedulerStarted: true,
+			wantCode:         http.StatusServiceUnavailable,
+			wantStatuses:     map[string]string{"scheduler": "down"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Monitor.Enabled = false
+			s := &Server{
+				Router:           gin.New(),
+				db:               &healthTestDB{err: tt.dbErr},
+				scheduler:        &healthTestScheduler{running: tt.schedulerRunning},
+				config:           cfg,
+				ready:            tt.ready,
+				shuttingDown:     tt.shuttingDown,
+				schedulerStarted: tt.schedulerStarted,
+			}
+			s.registerHealthRoutes()
+
+			w := httptest.NewRecorder()
+			s.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			require.Equal(t, tt.wantCode, w.Code)
+
+			var resp readinessResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			for name, want := range tt.wantStatuses {
+				assert.Equal(t, want, resp.Subsystems[name].Status, name)
+			}
+			if tt.dbErr != nil {
+				assert.Contains(t, resp.Subsystems["database"].Error, "connection refused")
+			}
+		})
+	}
+}
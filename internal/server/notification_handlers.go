@@ -4,6 +4,8 @@
 package server
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -12,6 +14,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/monitor"
 	"github.com/autobrr/netronome/internal/notifications"
 )
 
@@ -47,6 +50,12 @@ func (s *Server) handleCreateNotificationChannel(c *gin.Context) {
 			return
 		}
 	}
+	if input.PayloadTemplate != nil && *input.PayloadTemplate != "" {
+		if err := notifications.ValidatePayloadTemplate(input.URL, *input.PayloadTemplate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload template", "details": err.Error()})
+			return
+		}
+	}
 
 	channel, err := s.db.CreateChannel(input)
 	if err != nil {
@@ -79,6 +88,27 @@ func (s *Server) handleUpdateNotificationChannel(c *gin.Context) {
 			return
 		}
 	}
+	if input.PayloadTemplate != nil && *input.PayloadTemplate != "" {
+		// A missing URL keeps the stored one, which the template is checked against
+		channelURL := input.URL
+		if channelURL == "" {
+			existing, err := s.db.GetChannel(channelID)
+			if errors.Is(err, database.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+				return
+			}
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to get notification channel")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification channel"})
+				return
+			}
+			channelURL = existing.URL
+		}
+		if err := notifications.ValidatePayloadTemplate(channelURL, *input.PayloadTemplate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload template", "details": err.Error()})
+			return
+		}
+	}
 
 	channel, err := s.db.UpdateChannel(channelID, input)
 	if err != nil {
@@ -86,6 +116,7 @@ func (s *Server) handleUpdateNotificationChannel(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification channel"})
 		return
 	}
+	s.notifier.InvalidateCooldowns()
 
 	c.JSON(http.StatusOK, channel)
 }
@@ -103,6 +134,7 @@ func (s *Server) handleDeleteNotificationChannel(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification channel"})
 		return
 	}
+	s.notifier.InvalidateCooldowns()
 
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
@@ -173,6 +205,14 @@ func (s *Server) handleCreateNotificationRule(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
+	if input.CooldownSeconds != nil && *input.CooldownSeconds < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cooldown_seconds must not be negative"})
+		return
+	}
+	if status, err := s.checkRuleAgentTag(&input, input.EventID); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
 
 	rule, err := s.db.CreateRule(input)
 	if err != nil {
@@ -180,6 +220,7 @@ func (s *Server) handleCreateNotificationRule(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification rule"})
 		return
 	}
+	s.notifier.InvalidateCooldowns()
 
 	c.JSON(http.StatusCreated, rule)
 }
@@ -197,6 +238,26 @@ func (s *Server) handleUpdateNotificationRule(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
+	if input.CooldownSeconds != nil && *input.CooldownSeconds < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cooldown_seconds must not be negative"})
+		return
+	}
+	if input.AgentTag != nil && strings.TrimSpace(*input.AgentTag) != "" {
+		existing, err := s.db.GetRule(ruleID)
+		if errors.Is(err, database.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification rule not found"})
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to get notification rule")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification rule"})
+			return
+		}
+		if status, err := s.checkRuleAgentTag(&input, existing.EventID); err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
 	rule, err := s.db.UpdateRule(ruleID, input)
 	if err != nil {
@@ -204,10 +265,43 @@ func (s *Server) handleUpdateNotificationRule(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification rule"})
 		return
 	}
+	s.notifier.InvalidateCooldowns()
 
 	c.JSON(http.StatusOK, rule)
 }
 
+// checkRuleAgentTag normalizes the agent tag of a rule for eventID. Tags only apply to
+// agent events; an empty tag clears it.
+func (s *Server) checkRuleAgentTag(input *database.NotificationRuleInput, eventID int64) (int, error) {
+	if input.AgentTag == nil {
+		return http.StatusOK, nil
+	}
+	if strings.TrimSpace(*input.AgentTag) == "" {
+		cleared := ""
+		input.AgentTag = &cleared
+		return http.StatusOK, nil
+	}
+
+	tag, err := monitor.NormalizeAgentTag(*input.AgentTag)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("invalid agent_tag: %w", err)
+	}
+	input.AgentTag = &tag
+
+	event, err := s.db.GetEvent(eventID)
+	if errors.Is(err, database.ErrNotFound) {
+		return http.StatusBadRequest, errors.New("unknown notification event")
+	}
+	if err != nil {
+		log.Error().Err(err).Int64("eventID", eventID).Msg("Failed to get notification event")
+		return http.StatusInternalServerError, errors.New("failed to get notification event")
+	}
+	if event.Category != database.NotificationCategoryAgent {
+		return http.StatusBadRequest, errors.New("agent_tag only applies to agent events")
+	}
+	return http.StatusOK, nil
+}
+
 // handleDeleteNotificationRule deletes a notification rule
 func (s *Server) handleDeleteNotificationRule(c *gin.Context) {
 	ruleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -221,6 +315,7 @@ func (s *Server) handleDeleteNotificationRule(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification rule"})
 		return
 	}
+	s.notifier.InvalidateCooldowns()
 
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
@@ -270,6 +365,55 @@ func (s *Server) handleTestNotification(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Test notification sent successfully"})
 }
 
+// handleTestNotificationChannel sends a test message to a saved channel and records the
+// attempt in the notification history
+func (s *Server) handleTestNotificationChannel(c *gin.Context) {
+	channelID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	channel, err := s.db.GetChannel(channelID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+			return
+		}
+		log.Error().Err(err).Int64("channelID", channelID).Msg("Failed to get notification channel")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notification channel"})
+		return
+	}
+	if channel.URL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Channel has no URL"})
+		return
+	}
+
+	message := notifications.TestMessage
+	sendErr := s.notifier.SendRaw(channel.URL, "", message)
+
+	if event, err := s.db.GetEventByType(database.NotificationCategorySystem, database.NotificationEventSystemTest); err != nil {
+		log.Warn().Err(err).Msg("Test notification event missing, not recording attempt")
+	} else {
+		var errMsg *string
+		if sendErr != nil {
+			msg := sendErr.Error()
+			errMsg = &msg
+		}
+		if err := s.db.LogNotification(channel.ID, event.ID, sendErr == nil, errMsg, &message); err != nil {
+			log.Error().Err(err).Msg("Failed to log test notification")
+		}
+	}
+
+	if sendErr != nil {
+		log.Error().Err(sendErr).Int64("channelID", channel.ID).Msg("Failed to send test notification")
+		c.JSON(http.StatusBadGateway, gin.H{"success": false, "error": "Failed to send test notification", "details": sendErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Test notification sent successfully"})
+}
+
 // handleGetNotificationHistory retrieves notification history
 func (s *Server) handleGetNotificationHistory(c *gin.Context) {
 	limit := 100 // Default limit
@@ -0,0 +1,125 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/database"
+)
+
+type notificationLog struct {
+	channelID, eventID int64
+	success            bool
+	errorMessage       *string
+}
+
+type channelTestDB struct {
+	database.Service
+	channels map[int64]*database.NotificationChannel
+	logged   []notificationLog
+}
+
+func (d *channelTestDB) GetChannel(id int64) (*database.NotificationChannel, error) {
+	channel, ok := d.channels[id]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	return channel, nil
+}
+
+func (d *channelTestDB) GetEventByType(category, eventType string) (*database.NotificationEvent, error) {
+	return &database.NotificationEvent{ID: 99, Category: category, EventType: eventType}, nil
+}
+
+func (d *channelTestDB) LogNotification(channelID, eventID int64, success bool, errorMessage, _ *string) error {
+	d.logged = append(d.logged, notificationLog{channelID, eventID, success, errorMessage})
+	return nil
+}
+
+func TestHandleTestNotificationChannel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer webhook.Close()
+
+	db := &channelTestDB{channels: map[int64]*database.NotificationChannel{
+		1: {ID: 1, URL: webhook.URL + "/ok"},
+		2: {ID: 2, URL: webhook.URL + "/broken"},
+	}}
+	s := &Server{db: db}
+
+	request := func(id string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/notifications/channels/"+id+"/test", nil)
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		s.handleTestNotificationChannel(c)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusOK, request("1").Code)
+	assert.Equal(t, http.StatusBadGateway, request("2").Code)
+	assert.Equal(t, http.StatusNotFound, request("3").Code)
+	assert.Equal(t, http.StatusBadRequest, request("abc").Code)
+
+	require.Len(t, db.logged, 2)
+	assert.Equal(t, notificationLog{channelID: 1, eventID: 99, success: true}, db.logged[0])
+	assert.False(t, db.logged[1].success)
+	require.NotNil(t, db.logged[1].errorMessage)
+	assert.Contains(t, *db.logged[1].errorMessage, "500")
+}
+
+func (d *channelTestDB) UpdateChannel(id int64, input database.NotificationChannelInput) (*database.NotificationChannel, error) {
+	channel, ok := d.channels[id]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	if input.URL != "" {
+		channel.URL = input.URL
+	}
+	if input.PayloadTemplate != nil {
+		channel.PayloadTemplate = input.PayloadTemplate
+	}
+	return channel, nil
+}
+
+func TestHandleUpdateNotificationChannel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := &channelTestDB{channels: map[int64]*database.NotificationChannel{
+		1: {ID: 1, URL: "https://example.com/hook"},
+		2: {ID: 2, URL: "discord://token@id"},
+	}}
+	s := &Server{db: db}
+
+	request := func(id, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = httptest.NewRequest(http.MethodPut, "/api/notifications/channels/"+id, strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		s.handleUpdateNotificationChannel(c)
+		return recorder
+	}
+
+	// Without a URL the template is checked against the stored one, which is kept
+	assert.Equal(t, http.StatusOK, request("1", `{"name":"hook","payload_template":"{\"text\":{{json .Message}}}"}`).Code)
+	assert.Equal(t, "https://example.com/hook", db.channels[1].URL)
+	require.NotNil(t, db.channels[1].PayloadTemplate)
+
+	assert.Equal(t, http.StatusBadRequest, request("2", `{"name":"discord","payload_template":"{}"}`).Code)
+	assert.Equal(t, http.StatusNotFound, request("3", `{"name":"missing","payload_template":"{}"}`).Code)
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+const (
+	// rateLimitIdleTimeout is how long a client's bucket is kept after its last request.
+	// A refilled bucket is the same as a new one, so dropping it loses nothing.
+	rateLimitIdleTimeout = 10 * time.Minute
+	// rateLimitSweepInterval is how often idle buckets are dropped
+	rateLimitSweepInterval = time.Minute
+)
+
+// rateLimiter keeps a token bucket per client IP
+type rateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	clients   map[string]*rateLimitClient
+	lastSweep time.Time
+}
+
+type rateLimitClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newRateLimiter(cfg config.RateLimitConfig) *rateLimiter {
+	return &rateLimiter{
+		limit:   rate.Limit(cfg.RequestsPerSecond),
+		burst:   cfg.Burst,
+		clients: make(map[string]*rateLimitClient),
+	}
+}
+
+// allow takes a token from the client's bucket. When the bucket is empty it returns
+// false and how long until a token is available.
+func (l *rateLimiter) allow(clientIP string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= rateLimitSweepInterval {
+		for ip, client := range l.clients {
+			if now.Sub(client.lastSeen) >= rateLimitIdleTimeout {
+				delete(l.clients, ip)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	client, ok := l.clients[clientIP]
+	if !ok {
+		client = &rateLimitClient{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[clientIP] = client
+	}
+	client.lastSeen = now
+
+	reservation := client.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// RateLimitMiddleware rejects clients that exceed the configured request rate with 429
+// Too Many Requests and a Retry-After header. Whitelisted networks and the health probes
+// are never limited.
+func RateLimitMiddleware(cfg config.RateLimitConfig, whitelist []string) gin.HandlerFunc {
+	limiter := newRateLimiter(cfg)
+
+	return func(c *gin.Context) {
+		if isWhitelisted(c, whitelist) || isHealthPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		clientIP := c.ClientIP()
+		allowed, retryAfter := limiter.allow(clientIP, time.Now())
+		if !allowed {
+			log.Debug().
+				Str("ip", clientIP).
+				Str("path", c.Request.URL.Path).
+				Dur("retry_after", retryAfter).
+				Msg("Rate limit exceeded")
+
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+
+		c.Next()
+	}
+}
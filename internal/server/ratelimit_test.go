@@ -0,0 +1,75 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	limiter := newRateLimiter(config.RateLimitConfig{RequestsPerSecond: 2, Burst: 2})
+	now := time.Now()
+
+	for i := range 2 {
+		allowed, _ := limiter.allow("10.0.0.1", now)
+		assert.True(t, allowed, "request %d is within the burst", i+1)
+	}
+
+	allowed, retryAfter := limiter.allow("10.0.0.1", now)
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	allowed, _ = limiter.allow("10.0.0.2", now)
+	assert.True(t, allowed, "clients have separate buckets")
+
+	allowed, _ = limiter.allow("10.0.0.1", now.Add(500*time.Millisecond))
+	assert.True(t, allowed, "rejected requests don't use up tokens")
+
+	// Idle buckets are dropped
+	limiter.allow("10.0.0.3", now.Add(rateLimitIdleTimeout+time.Second))
+	assert.Len(t, limiter.clients, 1)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RateLimitMiddleware(config.RateLimitConfig{Enabled: true, RequestsPerSecond: 0.5, Burst: 1}, []string{"192.168.1.0/24"}))
+	router.GET("/api/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/readyz", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		return requestPath(router, "/api/ping", remoteAddr)
+	}
+
+	assert.Equal(t, http.StatusOK, request("10.0.0.5:1234").Code)
+
+	w := request("10.0.0.5:1234")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	for range 3 {
+		assert.Equal(t, http.StatusOK, request("192.168.1.10:1234").Code, "whitelisted networks are exempt")
+	}
+	for range 3 {
+		assert.Equal(t, http.StatusOK, requestPath(router, "/readyz", "10.0.0.5:1234").Code, "health probes are exempt")
+	}
+}
+
+// requestPath sends a GET for path from remoteAddr through router
+func requestPath(router *gin.Engine, path, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
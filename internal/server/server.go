@@ -5,6 +5,7 @@ package server
 
 import (
 	"context"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -43,6 +44,22 @@ type Server struct {
 	lastPacketLossUpdate *types.PacketLossUpdate
 	lastMonitorUpdate    *types.MonitorUpdate
 	config               *config.Config
+
+	// runs holds on-demand speed test runs by ID, activeRunID is the one in progress
+	runs        map[string]*speedTestRun
+	activeRunID string
+
+	// activeBatchID is the speed test batch in progress, 0 when none is running
+	activeBatchID int64
+
+	// ready is set by MarkReady once startup finishes, shuttingDown by MarkShuttingDown
+	// and schedulerStarted by StartScheduler
+	ready            bool
+	shuttingDown     bool
+	schedulerStarted bool
+
+	// monitoringPaused mirrors the persisted global pause, see RestoreMonitoringPause
+	monitoringPaused bool
 }
 
 func NewServer(speedtest speedtest.Service, db database.Service, scheduler scheduler.Service, cfg *config.Config, packetLossService *speedtest.PacketLossService, monitorService *monitor.Service, notifier *notifications.Notifier) *Server {
@@ -67,20 +84,6 @@ func NewServer(speedtest speedtest.Service, db database.Service, scheduler sched
 	router.Use(gin.Recovery())
 	router.Use(ErrorHandlerMiddleware())
 
-	// CORS middleware with config
-	router.Use(func(c *gin.Context) {
-		if c.Request.Method == "OPTIONS" {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-			c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-			c.AbortWithStatus(204)
-			return
-		}
-
-		c.Next()
-	})
-
 	s := &Server{
 		Router:            router,
 		speedtest:         speedtest,
@@ -88,10 +91,11 @@ func NewServer(speedtest speedtest.Service, db database.Service, scheduler sched
 		monitorService:    monitorService,
 		db:                db,
 		scheduler:         scheduler,
-		auth:              NewAuthHandler(db, oidcConfig, cfg.OIDC.Issuer != "", cfg.Session.Secret, cfg.Auth.Whitelist),
+		auth:              NewAuthHandler(db, oidcConfig, cfg.OIDC.Issuer != "", cfg.OIDC, cfg.Session.Secret, cfg.Auth.Whitelist),
 		notifier:          notifier,
 		lastUpdate:        &types.SpeedUpdate{},
 		config:            cfg,
+		runs:              make(map[string]*speedTestRun),
 	}
 
 	// Don't register routes here - let the caller do it after setting up packet loss service
@@ -100,6 +104,11 @@ func NewServer(speedtest speedtest.Service, db database.Service, scheduler sched
 
 func (s *Server) BroadcastUpdate(update types.SpeedUpdate) {
 	s.mu.Lock()
+	// Attach progress to the on-demand run the test belongs to, if any
+	if run := s.runs[update.RunID]; run != nil && update.RunID != "" {
+		progress := update
+		run.Progress = &progress
+	}
 	s.lastUpdate = &update
 	s.mu.Unlock()
 
@@ -163,10 +172,41 @@ func (s *Server) SetPacketLossService(service *speedtest.PacketLossService) {
 func (s *Server) SetMonitorService(service *monitor.Service) {
 	s.mu.Lock()
 	s.monitorService = service
+	paused := s.monitoringPaused
 	s.mu.Unlock()
+
+	if service != nil {
+		service.SetPaused(paused)
+	}
 }
 
 func (s *Server) Initialize() {
+	// Before rate limiting, so 429 responses still carry the CORS headers browsers need to read them
+	if cors := s.config.Server.CORS; cors.Enabled() {
+		s.Router.Use(CORSMiddleware(cors))
+		log.Info().
+			Strs("origins", cors.AllowedOrigins).
+			Bool("credentials", cors.AllowCredentials).
+			Msg("CORS enabled")
+	}
+
+	// Registered before the routes so it covers every one
+	if limit := s.config.Server.RateLimit; limit.Enabled {
+		s.Router.Use(RateLimitMiddleware(limit, s.config.Auth.Whitelist))
+		log.Info().
+			Float64("requests_per_second", limit.RequestsPerSecond).
+			Int("burst", limit.Burst).
+			Msg("Rate limiting enabled")
+	}
+
+	// Register before the API routes, which end with the SPA catch-all
+	if s.config.Server.MetricsEnabled {
+		s.registerMetrics()
+	}
+
+	// Probes stay outside the authenticated API so orchestrators can reach them
+	s.registerHealthRoutes()
+
 	// Register API routes
 	s.RegisterRoutes()
 
@@ -174,11 +214,63 @@ func (s *Server) Initialize() {
 	web.ServeStatic(s.Router)
 }
 
+// NewHTTPServer returns the http.Server serving the router on addr, with the configured timeouts
+func (s *Server) NewHTTPServer(addr string) *http.Server {
+	cfg := s.config.Server
+	return &http.Server{
+		Addr:              addr,
+		Handler:           s.Router,
+		ReadTimeout:       serverTimeout(cfg.ReadTimeout),
+		ReadHeaderTimeout: serverTimeout(cfg.ReadHeaderTimeout),
+		WriteTimeout:      serverTimeout(cfg.WriteTimeout),
+		IdleTimeout:       serverTimeout(cfg.IdleTimeout),
+	}
+}
+
+// serverTimeout parses a timeout checked by config.Validate. Empty and "0" disable it.
+func serverTimeout(value string) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// registerMetrics serves Prometheus metrics on <base_url>/metrics, outside the authenticated API
+func (s *Server) registerMetrics() {
+	routeBase := strings.TrimSuffix(s.config.Server.BaseURL, "/")
+	if routeBase != "" && !strings.HasPrefix(routeBase, "/") {
+		routeBase = "/" + routeBase
+	}
+
+	metricsHandler := handlers.NewMetricsHandler(s.db, s.monitorService, s.scheduler)
+	s.Router.GET(routeBase+"/metrics", metricsHandler.ServeMetrics)
+	log.Info().Str("path", routeBase+"/metrics").Msg("Prometheus metrics enabled")
+}
+
 func (s *Server) StartScheduler(ctx context.Context) {
 	s.scheduler.Start(ctx)
+	s.mu.Lock()
+	s.schedulerStarted = true
+	s.mu.Unlock()
 	log.Info().Msg("Scheduler service started")
 }
 
+// MarkReady reports startup as finished, /readyz fails until it is called
+func (s *Server) MarkReady() {
+	s.mu.Lock()
+	s.ready = true
+	s.mu.Unlock()
+}
+
+// MarkShuttingDown makes /readyz fail from the start of shutdown, so load balancers stop
+// sending requests while the server drains
+func (s *Server) MarkShuttingDown() {
+	s.mu.Lock()
+	s.shuttingDown = true
+	s.mu.Unlock()
+}
+
 func (s *Server) RegisterRoutes() {
 	baseURL := s.config.Server.BaseURL
 	if baseURL == "" {
@@ -205,6 +297,8 @@ func (s *Server) RegisterRoutes() {
 		apiGroup = apiGroup.Group("")
 	}
 	api := apiGroup.Group("/api")
+	// Every API handler takes JSON, so one cap covers them all
+	api.Use(MaxBodySizeMiddleware(s.config.Server.MaxBodyBytes))
 	{
 		// public auth routes
 		auth := api.Group("/auth")
@@ -232,13 +326,27 @@ func (s *Server) RegisterRoutes() {
 			protected.GET("/servers", s.handleGetServers)
 			protected.POST("/speedtest", s.handleSpeedTest)
 			protected.GET("/speedtest/status", s.handleSpeedTestStatus)
+			protected.POST("/speedtest/run", s.handleStartSpeedTestRun)
+			protected.GET("/speedtest/run/:id", s.handleGetSpeedTestRun)
+			protected.POST("/speedtest/batch", s.handleStartSpeedTestBatch)
+			protected.GET("/speedtest/batch/:id", s.handleGetSpeedTestBatch)
 			protected.GET("/speedtest/history", s.handleSpeedTestHistory)
+			protected.PATCH("/speedtest/results/:id", s.handleUpdateSpeedTestNote)
+			protected.GET("/speedtest/ratio", s.handleSpeedTestRatioHistory)
+			protected.GET("/export/speedtests.csv", s.handleExportSpeedTests)
+			protected.GET("/export/packetloss.csv", s.handleExportPacketLoss)
 			protected.GET("/traceroute", s.handleTraceroute)
 			protected.GET("/traceroute/status", s.handleTracerouteStatus)
+			protected.POST("/geoip/reenrich", s.handleGeoIPReenrich)
 			protected.GET("/schedules", s.handleGetSchedules)
 			protected.POST("/schedules", s.handleCreateSchedule)
 			protected.PUT("/schedules/:id", s.handleUpdateSchedule)
 			protected.DELETE("/schedules/:id", s.handleDeleteSchedule)
+			protected.GET("/scheduler/queue", s.handleGetSchedulerQueue)
+
+			protected.GET("/system/status", s.handleSystemStatus)
+			protected.POST("/system/pause", s.handlePauseMonitoring)
+			protected.POST("/system/resume", s.handleResumeMonitoring)
 
 			iperfHandler := handlers.NewIperfHandler(s.db)
 			protected.POST("/iperf/servers", iperfHandler.SaveServer)
@@ -255,25 +363,47 @@ func (s *Server) RegisterRoutes() {
 				protected.GET("/packetloss/monitors/:id/status", packetLossHandler.GetMonitorStatus)
 				protected.GET("/packetloss/monitors/:id/history", packetLossHandler.GetMonitorHistory)
 				protected.GET("/packetloss/monitors/:id/history/:resultId", packetLossHandler.GetMonitorHistoryDetail)
+				protected.PATCH("/packetloss/monitors/:id/history/:resultId", packetLossHandler.UpdateResultNote)
+				protected.GET("/packetloss/monitors/:id/hops", packetLossHandler.GetMonitorHopTrend)
+				protected.GET("/packetloss/monitors/:id/sparkline", packetLossHandler.GetMonitorSparkline)
 				protected.POST("/packetloss/monitors/:id/start", packetLossHandler.StartMonitor)
 				protected.POST("/packetloss/monitors/:id/stop", packetLossHandler.StopMonitor)
 			}
 
+			// Scheduled traceroute monitor routes
+			tracerouteHandler := handlers.NewTracerouteHandler(s.db, s.scheduler)
+			protected.GET("/traceroute/monitors", tracerouteHandler.GetMonitors)
+			protected.POST("/traceroute/monitors", tracerouteHandler.CreateMonitor)
+			protected.DELETE("/traceroute/monitors/:id", tracerouteHandler.DeleteMonitor)
+			protected.GET("/traceroute/monitors/:id/history", tracerouteHandler.GetMonitorHistory)
+			protected.GET("/traceroute/monitors/:id/hops", tracerouteHandler.GetMonitorHopTrend)
+
 			// Vnstat monitoring routes
 			if s.monitorService != nil {
 				monitorHandler := handlers.NewMonitorHandler(s.db, s.monitorService, &s.config.Monitor)
 				protected.GET("/monitor/agents", monitorHandler.GetAgents)
 				protected.POST("/monitor/agents", monitorHandler.CreateAgent)
+				protected.GET("/monitor/agents/status", monitorHandler.GetAgentStatuses)
 				protected.GET("/monitor/agents/:id", monitorHandler.GetAgent)
 				protected.PUT("/monitor/agents/:id", monitorHandler.UpdateAgent)
 				protected.DELETE("/monitor/agents/:id", monitorHandler.DeleteAgent)
 				protected.GET("/monitor/agents/:id/status", monitorHandler.GetAgentStatus)
 				protected.POST("/monitor/agents/:id/start", monitorHandler.StartAgent)
 				protected.POST("/monitor/agents/:id/stop", monitorHandler.StopAgent)
+				protected.POST("/monitor/agents/:id/refresh", monitorHandler.RefreshAgent)
 				protected.GET("/monitor/agents/:id/native", monitorHandler.GetAgentNativeVnstat)
 				protected.GET("/monitor/agents/:id/system", monitorHandler.GetAgentSystemInfo)
 				protected.GET("/monitor/agents/:id/hardware", monitorHandler.GetAgentHardwareStats)
+				protected.GET("/monitor/agents/:id/processes", monitorHandler.GetAgentProcesses)
+				protected.GET("/monitor/agents/:id/smart", monitorHandler.GetAgentSmart)
 				protected.GET("/monitor/agents/:id/peaks", monitorHandler.GetAgentPeakStats)
+				protected.GET("/monitor/agents/:id/usage", monitorHandler.GetAgentBandwidthUsage)
+				protected.GET("/monitor/agents/:id/interfaces/history", monitorHandler.GetAgentInterfaceHistory)
+				protected.GET("/monitor/agents/:id/probes", monitorHandler.GetAgentProbes)
+				protected.PUT("/monitor/agents/:id/probes", monitorHandler.UpdateAgentProbes)
+				protected.GET("/monitor/agents/:id/probes/results", monitorHandler.GetAgentProbeResults)
+				protected.GET("/monitor/fleet/resources", monitorHandler.GetFleetResourceStats)
+				protected.GET("/monitor/snapshot", monitorHandler.GetSnapshot)
 				protected.GET("/monitor/tailscale/status", monitorHandler.GetTailscaleStatus)
 			}
 
@@ -282,6 +412,7 @@ func (s *Server) RegisterRoutes() {
 			protected.POST("/notifications/channels", s.handleCreateNotificationChannel)
 			protected.PUT("/notifications/channels/:id", s.handleUpdateNotificationChannel)
 			protected.DELETE("/notifications/channels/:id", s.handleDeleteNotificationChannel)
+			protected.POST("/notifications/channels/:id/test", s.handleTestNotificationChannel)
 
 			protected.GET("/notifications/events", s.handleGetNotificationEvents)
 
@@ -295,6 +426,10 @@ func (s *Server) RegisterRoutes() {
 
 			protected.GET("/settings/dashboard", s.handleGetDashboardSettings)
 			protected.PUT("/settings/dashboard", s.handleUpdateDashboardSettings)
+
+			protected.GET("/keys", s.handleGetAPIKeys)
+			protected.POST("/keys", s.handleCreateAPIKey)
+			protected.DELETE("/keys/:id", s.handleDeleteAPIKey)
 		}
 	}
 
@@ -319,7 +454,7 @@ func LoggerMiddleware() gin.HandlerFunc {
 		c.Next()
 
 		// skip certain endpoints to reduce noise
-		if path == "/health" || path == "/api/speedtest/status" && c.Writer.Status() == 200 {
+		if isHealthPath(path) || path == "/api/speedtest/status" && c.Writer.Status() == 200 {
 			return
 		}
 
@@ -27,6 +27,7 @@ type SessionClaims struct {
 	Type         string `json:"type"`
 	Username     string `json:"username,omitempty"`
 	Subject      string `json:"sub,omitempty"`
+	Role         string `json:"role,omitempty"`
 	IDTokenExp   int64  `json:"id_exp,omitempty"`
 	RefreshToken string `json:"rt,omitempty"`
 	LastRefresh  int64  `json:"lrt,omitempty"`
@@ -0,0 +1,267 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/speedtest"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// maxBatchRuns caps the number of cells a single matrix may expand to
+const maxBatchRuns = 100
+
+// buildBatchRuns expands a matrix into its runs, validating every cell up front so a
+// bad entry is rejected before anything is started
+func buildBatchRuns(matrix types.SpeedTestMatrix) ([]types.SpeedTestBatchRun, error) {
+	if len(matrix.Targets) == 0 {
+		return nil, fmt.Errorf("matrix needs at least one target")
+	}
+
+	directions := matrix.Directions
+	if len(directions) == 0 {
+		directions = []string{types.BatchDirectionBoth}
+	}
+	seen := make(map[string]bool, len(directions))
+	unique := make([]string, 0, len(directions))
+	for _, direction := range directions {
+		direction = strings.ToLower(strings.TrimSpace(direction))
+		switch direction {
+		case types.BatchDirectionDownload, types.BatchDirectionUpload, types.BatchDirectionBoth:
+		default:
+			return nil, fmt.Errorf("invalid direction %q, expected download, upload or both", direction)
+		}
+		if !seen[direction] {
+			seen[direction] = true
+			unique = append(unique, direction)
+		}
+	}
+
+	var runs []types.SpeedTestBatchRun
+	for _, target := range matrix.Targets {
+		servers := target.Servers
+		if len(servers) == 0 {
+			servers = []string{""}
+		}
+		for _, server := range servers {
+			for _, direction := range unique {
+				run := types.SpeedTestBatchRun{
+					TestType:  target.TestType,
+					Server:    strings.TrimSpace(server),
+					Direction: direction,
+				}
+				provider, _, err := batchRunOptions(run)
+				if err != nil {
+					return nil, err
+				}
+				// The LibreSpeed and Ookla CLIs always measure both directions
+				if direction != types.BatchDirectionBoth && (provider == "librespeed" || provider == "ookla") {
+					return nil, fmt.Errorf("%s can't measure a single direction, use both", provider)
+				}
+				run.TestType = provider
+				runs = append(runs, run)
+			}
+		}
+	}
+
+	if len(runs) > maxBatchRuns {
+		return nil, fmt.Errorf("matrix expands to %d runs, at most %d are allowed", len(runs), maxBatchRuns)
+	}
+
+	return runs, nil
+}
+
+// batchRunOptions maps a batch run onto test options, measuring only its direction
+func batchRunOptions(run types.SpeedTestBatchRun) (string, *types.TestOptions, error) {
+	req := speedTestRunRequest{Provider: run.TestType}
+	if p := strings.ToLower(strings.TrimSpace(run.TestType)); p == "iperf" || p == "iperf3" {
+		req.Host = run.Server
+	} else {
+		req.ServerID = run.Server
+	}
+
+	provider, opts, err := runTestOptions(req)
+	if err != nil {
+		return "", nil, err
+	}
+	opts.EnableDownload = run.Direction != types.BatchDirectionUpload
+	opts.EnableUpload = run.Direction != types.BatchDirectionDownload
+
+	return provider, opts, nil
+}
+
+// handleStartSpeedTestBatch stores a test matrix as a batch and runs it in the background.
+// Only one batch runs at a time.
+func (s *Server) handleStartSpeedTestBatch(c *gin.Context) {
+	var matrix types.SpeedTestMatrix
+	if err := c.ShouldBindJSON(&matrix); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	runs, err := buildBatchRuns(matrix)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	if s.activeBatchID != 0 {
+		activeID := s.activeBatchID
+		s.mu.Unlock()
+		c.JSON(http.StatusConflict, gin.H{"error": "A speed test batch is already in progress", "id": activeID})
+		return
+	}
+	// Reserve the slot while the batch is stored
+	s.activeBatchID = -1
+	s.mu.Unlock()
+
+	batch, err := s.db.CreateSpeedTestBatch(c.Request.Context(), &types.SpeedTestBatch{
+		Name: strings.TrimSpace(matrix.Name),
+		Runs: runs,
+	})
+	if err != nil {
+		s.mu.Lock()
+		s.activeBatchID = 0
+		s.mu.Unlock()
+		c.Status(http.StatusInternalServerError)
+		_ = c.Error(fmt.Errorf("failed to create speed test batch: %w", err))
+		return
+	}
+
+	s.mu.Lock()
+	s.activeBatchID = batch.ID
+	s.mu.Unlock()
+
+	log.Info().Int64("batchID", batch.ID).Int("runs", len(batch.Runs)).Msg("Starting speed test batch")
+
+	// The batch's runs are updated while it executes, so respond with a copy
+	response := *batch
+	response.Runs = slices.Clone(batch.Runs)
+
+	go s.executeSpeedTestBatch(batch)
+
+	c.JSON(http.StatusAccepted, response)
+}
+
+// executeSpeedTestBatch runs every cell of a batch, at most speedtest.max_concurrent at
+// once. The batch fails only when none of its runs succeeded.
+func (s *Server) executeSpeedTestBatch(batch *types.SpeedTestBatch) {
+	defer func() {
+		s.mu.Lock()
+		if s.activeBatchID == batch.ID {
+			s.activeBatchID = 0
+		}
+		s.mu.Unlock()
+	}()
+
+	ctx := context.Background()
+	if err := s.db.UpdateSpeedTestBatchStatus(ctx, batch.ID, types.BatchStatusRunning, nil); err != nil {
+		log.Error().Err(err).Int64("batchID", batch.ID).Msg("Failed to mark speed test batch running")
+	}
+
+	slots := make(chan struct{}, max(s.config.SpeedTest.MaxConcurrent, 1))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+
+	for i := range batch.Runs {
+		run := &batch.Runs[i]
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			if s.executeSpeedTestBatchRun(ctx, run) {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	status := types.BatchStatusCompleted
+	if succeeded == 0 {
+		status = types.BatchStatusFailed
+	}
+	completedAt := time.Now().UTC()
+	if err := s.db.UpdateSpeedTestBatchStatus(ctx, batch.ID, status, &completedAt); err != nil {
+		log.Error().Err(err).Int64("batchID", batch.ID).Msg("Failed to finish speed test batch")
+	}
+
+	log.Info().Int64("batchID", batch.ID).Int("succeeded", succeeded).Int("runs", len(batch.Runs)).Msg("Speed test batch finished")
+}
+
+// executeSpeedTestBatchRun runs one cell and records its outcome, reporting whether it succeeded
+func (s *Server) executeSpeedTestBatchRun(ctx context.Context, run *types.SpeedTestBatchRun) bool {
+	run.Status = types.BatchStatusRunning
+	if err := s.db.UpdateSpeedTestBatchRun(ctx, run); err != nil {
+		log.Error().Err(err).Int64("runID", run.ID).Msg("Failed to mark batch run running")
+	}
+
+	_, opts, err := batchRunOptions(*run)
+	if err == nil {
+		testCtx, cancel := context.WithTimeout(ctx, s.speedTestTimeout(opts))
+		var result *speedtest.Result
+		result, err = s.speedtest.RunTest(testCtx, opts)
+		cancel()
+		if err == nil && result.ID != 0 {
+			id := result.ID
+			run.SpeedTestID = &id
+		}
+	}
+
+	completedAt := time.Now().UTC()
+	run.CompletedAt = &completedAt
+	if err != nil {
+		run.Status = types.BatchStatusFailed
+		run.Error = err.Error()
+		log.Error().Err(err).Int64("batchID", run.BatchID).Str("testType", run.TestType).Str("server", run.Server).Msg("Batch speed test run failed")
+	} else {
+		run.Status = types.BatchStatusCompleted
+	}
+
+	if err := s.db.UpdateSpeedTestBatchRun(ctx, run); err != nil {
+		log.Error().Err(err).Int64("runID", run.ID).Msg("Failed to record batch run result")
+	}
+
+	return run.Status == types.BatchStatusCompleted
+}
+
+// handleGetSpeedTestBatch returns a batch with its runs and their stored results
+func (s *Server) handleGetSpeedTestBatch(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid batch ID"})
+		return
+	}
+
+	batch, err := s.db.GetSpeedTestBatch(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Batch not found"})
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		_ = c.Error(fmt.Errorf("failed to get speed test batch: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
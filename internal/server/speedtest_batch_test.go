@@ -0,0 +1,79 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestBuildBatchRuns(t *testing.T) {
+	runs, err := buildBatchRuns(types.SpeedTestMatrix{
+		Targets: []types.SpeedTestMatrixTarget{
+			{TestType: "speedtest", Servers: []string{"1234", "5678"}},
+			{TestType: "iperf", Servers: []string{"iperf.example.com:5201"}},
+		},
+		Directions: []string{"Download", "upload", "download"},
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 6, "3 servers x 2 unique directions")
+	assert.Equal(t, types.SpeedTestBatchRun{TestType: "speedtest", Server: "1234", Direction: "download"}, runs[0])
+	assert.Equal(t, types.SpeedTestBatchRun{TestType: "iperf3", Server: "iperf.example.com:5201", Direction: "upload"}, runs[5])
+
+	runs, err = buildBatchRuns(types.SpeedTestMatrix{
+		Targets: []types.SpeedTestMatrixTarget{{TestType: "librespeed"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1, "no servers runs once against the automatic pick")
+	assert.Equal(t, types.BatchDirectionBoth, runs[0].Direction)
+
+	_, err = buildBatchRuns(types.SpeedTestMatrix{})
+	assert.Error(t, err)
+
+	_, err = buildBatchRuns(types.SpeedTestMatrix{
+		Targets:    []types.SpeedTestMatrixTarget{{TestType: "speedtest"}},
+		Directions: []string{"sideways"},
+	})
+	assert.Error(t, err)
+
+	_, err = buildBatchRuns(types.SpeedTestMatrix{
+		Targets: []types.SpeedTestMatrixTarget{{TestType: "iperf3"}},
+	})
+	assert.Error(t, err, "iperf3 needs a host")
+
+	for _, provider := range []string{"librespeed", "ookla"} {
+		_, err = buildBatchRuns(types.SpeedTestMatrix{
+			Targets:    []types.SpeedTestMatrixTarget{{TestType: provider}},
+			Directions: []string{"download"},
+		})
+		assert.Error(t, err, "%s measures both directions only", provider)
+	}
+
+	servers := make([]string, maxBatchRuns+1)
+	for i := range servers {
+		servers[i] = "host:5201"
+	}
+	_, err = buildBatchRuns(types.SpeedTestMatrix{
+		Targets: []types.SpeedTestMatrixTarget{{TestType: "iperf3", Servers: servers}},
+	})
+	assert.Error(t, err)
+}
+
+func TestBatchRunOptions(t *testing.T) {
+	_, opts, err := batchRunOptions(types.SpeedTestBatchRun{TestType: "speedtest", Server: "1234", Direction: types.BatchDirectionUpload})
+	require.NoError(t, err)
+	assert.False(t, opts.EnableDownload)
+	assert.True(t, opts.EnableUpload)
+	assert.Equal(t, []string{"1234"}, opts.ServerIDs)
+
+	_, opts, err = batchRunOptions(types.SpeedTestBatchRun{TestType: "iperf3", Server: "host:5201", Direction: types.BatchDirectionBoth})
+	require.NoError(t, err)
+	assert.True(t, opts.EnableDownload)
+	assert.True(t, opts.EnableUpload)
+	assert.Equal(t, "host:5201", opts.ServerHost)
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/speedtest"
+	"github.com/autobrr/netronome/internal/types"
+	"github.com/autobrr/netronome/internal/utils"
+)
+
+// speedTestRunRetention is how long finished on-demand runs can still be polled
+const speedTestRunRetention = time.Hour
+
+// Status values of an on-demand speed test run
+const (
+	speedTestRunRunning   = "running"
+	speedTestRunCompleted = "completed"
+	speedTestRunFailed    = "failed"
+)
+
+// speedTestRunRequest starts an on-demand test. Provider is speedtest, iperf3,
+// librespeed or ookla, defaulting to speedtest.
+type speedTestRunRequest struct {
+	Provider string `json:"provider"`
+	ServerID string `json:"serverId"`
+	Host     string `json:"host"`
+}
+
+// speedTestRun tracks one on-demand test started through the API
+type speedTestRun struct {
+	ID          string             `json:"id"`
+	Provider    string             `json:"provider"`
+	Status      string             `json:"status"`
+	Progress    *types.SpeedUpdate `json:"progress,omitempty"`
+	Result      *speedtest.Result  `json:"result,omitempty"`
+	Error       string             `json:"error,omitempty"`
+	StartedAt   time.Time          `json:"startedAt"`
+	CompletedAt *time.Time         `json:"completedAt,omitempty"`
+}
+
+// runTestOptions maps a run request onto test options for the chosen provider
+func runTestOptions(req speedTestRunRequest) (string, *types.TestOptions, error) {
+	opts := &types.TestOptions{
+		EnableDownload: true,
+		EnableUpload:   true,
+		EnablePing:     true,
+		EnableJitter:   true,
+		ServerHost:     strings.TrimSpace(req.Host),
+	}
+	if req.ServerID != "" {
+		opts.ServerIDs = []string{req.ServerID}
+	}
+
+	provider := strings.ToLower(strings.TrimSpace(req.Provider))
+	switch provider {
+	case "", "speedtest":
+		provider = "speedtest"
+	case "iperf", "iperf3":
+		provider = "iperf3"
+		if opts.ServerHost == "" {
+			return "", nil, fmt.Errorf("host is required for iperf3")
+		}
+		opts.UseIperf = true
+	case "librespeed":
+		opts.UseLibrespeed = true
+	case "ookla":
+		opts.UseOokla = true
+	default:
+		return "", nil, fmt.Errorf("invalid provider %q, expected speedtest, iperf3, librespeed or ookla", req.Provider)
+	}
+
+	return provider, opts, nil
+}
+
+// speedTestTimeout returns the configured timeout for the provider selected in opts
+func (s *Server) speedTestTimeout(opts *types.TestOptions) time.Duration {
+	switch {
+	case opts.UseLibrespeed:
+		return time.Duration(s.config.SpeedTest.Librespeed.Timeout) * time.Second
+	case opts.UseOokla:
+		return time.Duration(s.config.SpeedTest.Ookla.Timeout) * time.Second
+	default:
+		return time.Duration(s.config.SpeedTest.Timeout) * time.Second
+	}
+}
+
+// handleStartSpeedTestRun starts a speed test in the background and returns its run ID.
+// Progress is broadcast as usual with the run ID attached, so it shows up on the
+// status endpoint.
+func (s *Server) handleStartSpeedTestRun(c *gin.Context) {
+	var req speedTestRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	provider, opts, err := runTestOptions(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := utils.GenerateSecureToken(8)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		_ = c.Error(fmt.Errorf("failed to generate run ID: %w", err))
+		return
+	}
+
+	run := &speedTestRun{
+		ID:        id,
+		Provider:  provider,
+		Status:    speedTestRunRunning,
+		StartedAt: time.Now().UTC(),
+	}
+
+	s.mu.Lock()
+	if s.activeRunID != "" {
+		activeID := s.activeRunID
+		s.mu.Unlock()
+		c.JSON(http.StatusConflict, gin.H{"error": "A speed test run is already in progress", "id": activeID})
+		return
+	}
+	opts.RunID = id
+	s.pruneSpeedTestRunsLocked(run.StartedAt)
+	s.runs[id] = run
+	s.activeRunID = id
+	s.lastUpdate = &types.SpeedUpdate{RunID: id}
+	s.mu.Unlock()
+
+	log.Info().Str("runID", id).Str("provider", provider).Msg("Starting on-demand speed test run")
+
+	go s.executeSpeedTestRun(run, opts)
+
+	c.JSON(http.StatusAccepted, gin.H{"id": id, "status": run.Status})
+}
+
+// executeSpeedTestRun runs the test and records its outcome on run
+func (s *Server) executeSpeedTestRun(run *speedTestRun, opts *types.TestOptions) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.speedTestTimeout(opts))
+	defer cancel()
+
+	result, err := s.speedtest.RunTest(ctx, opts)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	completedAt := time.Now().UTC()
+	run.CompletedAt = &completedAt
+	if s.activeRunID == run.ID {
+		s.activeRunID = ""
+	}
+	if err != nil {
+		run.Status = speedTestRunFailed
+		run.Error = err.Error()
+		log.Error().Err(err).Str("runID", run.ID).Msg("On-demand speed test run failed")
+		return
+	}
+
+	run.Status = speedTestRunCompleted
+	run.Result = result
+	if run.Progress != nil {
+		run.Progress.IsComplete = true
+	}
+	if s.lastUpdate != nil && s.lastUpdate.RunID == run.ID {
+		s.lastUpdate.IsComplete = true
+	}
+}
+
+// handleGetSpeedTestRun returns the progress or final result of an on-demand run
+func (s *Server) handleGetSpeedTestRun(c *gin.Context) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	run, ok := s.runs[c.Param("id")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Run not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// pruneSpeedTestRunsLocked drops finished runs past their retention. Callers hold s.mu.
+func (s *Server) pruneSpeedTestRunsLocked(now time.Time) {
+	for id, run := range s.runs {
+		if run.CompletedAt != nil && now.Sub(*run.CompletedAt) > speedTestRunRetention {
+			delete(s.runs, id)
+		}
+	}
+}
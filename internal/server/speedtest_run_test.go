@@ -0,0 +1,53 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestRunTestOptions(t *testing.T) {
+	provider, opts, err := runTestOptions(speedTestRunRequest{ServerID: "1234"})
+	require.NoError(t, err)
+	assert.Equal(t, "speedtest", provider)
+	assert.Equal(t, []string{"1234"}, opts.ServerIDs)
+	assert.True(t, opts.EnableDownload)
+	assert.True(t, opts.EnableUpload)
+
+	provider, opts, err = runTestOptions(speedTestRunRequest{Provider: "iperf", Host: " iperf.example.com:5201 "})
+	require.NoError(t, err)
+	assert.Equal(t, "iperf3", provider)
+	assert.True(t, opts.UseIperf)
+	assert.Equal(t, "iperf.example.com:5201", opts.ServerHost)
+
+	_, opts, err = runTestOptions(speedTestRunRequest{Provider: "Ookla"})
+	require.NoError(t, err)
+	assert.True(t, opts.UseOokla)
+
+	_, _, err = runTestOptions(speedTestRunRequest{Provider: "iperf3"})
+	assert.Error(t, err, "iperf3 needs a host")
+
+	_, _, err = runTestOptions(speedTestRunRequest{Provider: "fast"})
+	assert.Error(t, err)
+}
+
+func TestBroadcastUpdateRunProgress(t *testing.T) {
+	run := &speedTestRun{ID: "run1", Status: speedTestRunRunning}
+	s := &Server{runs: map[string]*speedTestRun{run.ID: run}, activeRunID: run.ID}
+
+	// Another test running next to the run keeps its updates to itself
+	s.BroadcastUpdate(types.SpeedUpdate{Type: "download", TestType: "iperf3", Speed: 50})
+	assert.Nil(t, run.Progress)
+	assert.Empty(t, s.lastUpdate.RunID)
+
+	s.BroadcastUpdate(types.SpeedUpdate{Type: "download", TestType: "speedtest", Speed: 100, RunID: run.ID})
+	require.NotNil(t, run.Progress)
+	assert.Equal(t, 100.0, run.Progress.Speed)
+	assert.Equal(t, run.ID, s.lastUpdate.RunID)
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/database"
+)
+
+const monitoringPausedSettingKey = "monitoring_paused"
+
+type systemStatusResponse struct {
+	Paused bool `json:"paused"`
+}
+
+// RestoreMonitoringPause applies the pause state persisted before a restart, so a
+// restart during maintenance stays paused. Call it before the scheduler and the monitor
+// service start.
+func (s *Server) RestoreMonitoringPause(ctx context.Context) {
+	value, err := s.db.GetAppSetting(ctx, monitoringPausedSettingKey)
+	if err != nil {
+		if !errors.Is(err, database.ErrNotFound) {
+			log.Error().Err(err).Msg("Failed to load monitoring pause state")
+		}
+		return
+	}
+
+	paused, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Warn().Err(err).Str("value", value).Msg("Invalid persisted monitoring pause state, ignoring")
+		return
+	}
+
+	if paused {
+		log.Warn().Msg("Monitoring is paused, resume it with POST /api/system/resume")
+	}
+	s.applyMonitoringPause(paused)
+}
+
+// applyMonitoringPause pauses or resumes the scheduled monitors and the agent clients
+func (s *Server) applyMonitoringPause(paused bool) {
+	s.mu.Lock()
+	s.monitoringPaused = paused
+	monitorService := s.monitorService
+	s.mu.Unlock()
+
+	if s.scheduler != nil {
+		s.scheduler.SetPaused(paused)
+	}
+	if monitorService != nil {
+		monitorService.SetPaused(paused)
+	}
+}
+
+func (s *Server) handleSystemStatus(c *gin.Context) {
+	s.mu.RLock()
+	paused := s.monitoringPaused
+	s.mu.RUnlock()
+
+	c.JSON(http.StatusOK, systemStatusResponse{Paused: paused})
+}
+
+func (s *Server) handlePauseMonitoring(c *gin.Context) {
+	s.setMonitoringPaused(c, true)
+}
+
+func (s *Server) handleResumeMonitoring(c *gin.Context) {
+	s.setMonitoringPaused(c, false)
+}
+
+// setMonitoringPaused persists the pause state before applying it, so a failed write
+// doesn't leave a pause that a restart would silently undo
+func (s *Server) setMonitoringPaused(c *gin.Context, paused bool) {
+	if err := s.db.SetAppSetting(c.Request.Context(), monitoringPausedSettingKey, strconv.FormatBool(paused)); err != nil {
+		log.Error().Err(err).Bool("paused", paused).Msg("Failed to save monitoring pause state")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save monitoring pause state"})
+		return
+	}
+
+	s.applyMonitoringPause(paused)
+	c.JSON(http.StatusOK, systemStatusResponse{Paused: paused})
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/scheduler"
+)
+
+type settingsTestDB struct {
+	database.Service
+	settings map[string]string
+}
+
+func (d *settingsTestDB) GetAppSetting(ctx context.Context, key string) (string, error) {
+	value, ok := d.settings[key]
+	if !ok {
+		return "", database.ErrNotFound
+	}
+	return value, nil
+}
+
+func (d *settingsTestDB) SetAppSetting(ctx context.Context, key, value string) error {
+	d.settings[key] = value
+	return nil
+}
+
+type pauseTestScheduler struct {
+	scheduler.Service
+	paused bool
+}
+
+func (s *pauseTestScheduler) SetPaused(paused bool) {
+	s.paused = paused
+}
+
+func TestMonitoringPauseHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := &settingsTestDB{settings: map[string]string{}}
+	sched := &pauseTestScheduler{}
+	s := &Server{Router: gin.New(), db: db, scheduler: sched}
+	s.Router.GET("/api/system/status", s.handleSystemStatus)
+	s.Router.POST("/api/system/pause", s.handlePauseMonitoring)
+	s.Router.POST("/api/system/resume", s.handleResumeMonitoring)
+
+	status := func() systemStatusResponse {
+		w := httptest.NewRecorder()
+		s.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/system/status", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp systemStatusResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	assert.False(t, status().Paused)
+
+	w := httptest.NewRecorder()
+	s.Router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/system/pause", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, status().Paused)
+	assert.True(t, sched.paused)
+	assert.Equal(t, "true", db.settings[monitoringPausedSettingKey])
+
+	w = httptest.NewRecorder()
+	s.Router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/system/resume", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, status().Paused)
+	assert.False(t, sched.paused)
+	assert.Equal(t, "false", db.settings[monitoringPausedSettingKey])
+}
+
+func TestRestoreMonitoringPause(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]string
+		want     bool
+	}{
+		{name: "never paused", settings: map[string]string{}, want: false},
+		{name: "paused before restart", settings: map[string]string{monitoringPausedSettingKey: "true"}, want: true},
+		{name: "resumed before restart", settings: map[string]string{monitoringPausedSettingKey: "false"}, want: false},
+		{name: "invalid value", settings: map[string]string{monitoringPausedSettingKey: "maybe"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched := &pauseTestScheduler{}
+			s := &Server{db: &settingsTestDB{settings: tt.settings}, scheduler: sched}
+
+			s.RestoreMonitoringPause(context.Background())
+			assert.Equal(t, tt.want, s.monitoringPaused)
+			assert.Equal(t, tt.want, sched.paused)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// ErrGeoIPUnavailable is returned when no GeoIP database is loaded to enrich with
+var ErrGeoIPUnavailable = errors.New("no GeoIP databases loaded")
+
+// GeoIPBackfillResult summarizes a re-enrichment run over stored MTR data and
+// traceroute monitor hops
+type GeoIPBackfillResult struct {
+	Scanned      int `json:"scanned"`
+	Updated      int `json:"updated"`
+	HopsEnriched int `json:"hopsEnriched"`
+	Failed       int `json:"failed"`
+}
+
+// ReenrichGeoIP re-applies GeoIP country and ASN data to MTR results and traceroute
+// monitor results stored within [from, to]. Zero times leave the range open. Only
+// results whose data changed are rewritten.
+func (s *service) ReenrichGeoIP(ctx context.Context, from, to time.Time) (*GeoIPBackfillResult, error) {
+	s.initGeoIP()
+	if !geoIPLoaded() {
+		return nil, ErrGeoIPUnavailable
+	}
+
+	records, err := s.db.GetPacketLossMTRData(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GeoIPBackfillResult{}
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		result.Scanned++
+
+		var data types.MTRData
+		if err := json.Unmarshal([]byte(record.MTRData), &data); err != nil {
+			log.Warn().Err(err).Int64("resultID", record.ResultID).Msg("Skipping unreadable MTR data during GeoIP backfill")
+			result.Failed++
+			continue
+		}
+
+		enriched := enrichMTRHops(data.Hops)
+		if enriched == 0 {
+			continue
+		}
+
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return result, fmt.Errorf("failed to encode MTR data for result %d: %w", record.ResultID, err)
+		}
+		if err := s.db.UpdatePacketLossMTRData(ctx, record.ResultID, string(encoded)); err != nil {
+			return result, err
+		}
+		result.Updated++
+		result.HopsEnriched += enriched
+	}
+
+	if err := s.reenrichTracerouteHops(ctx, from, to, result); err != nil {
+		return result, err
+	}
+
+	log.Info().
+		Int("scanned", result.Scanned).
+		Int("updated", result.Updated).
+		Int("hopsEnriched", result.HopsEnriched).
+		Msg("GeoIP backfill completed")
+
+	return result, nil
+}
+
+// reenrichTracerouteHops re-applies GeoIP data to stored traceroute monitor hops,
+// adding to the counts in result
+func (s *service) reenrichTracerouteHops(ctx context.Context, from, to time.Time, result *GeoIPBackfillResult) error {
+	records, err := s.db.GetTracerouteMonitorHops(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result.Scanned++
+
+		var hops []TracerouteHop
+		if err := json.Unmarshal([]byte(record.Hops), &hops); err != nil {
+			log.Warn().Err(err).Int64("resultID", record.ResultID).Msg("Skipping unreadable traceroute hops during GeoIP backfill")
+			result.Failed++
+			continue
+		}
+
+		enriched := enrichTracerouteHops(hops)
+		if enriched == 0 {
+			continue
+		}
+
+		encoded, err := json.Marshal(hops)
+		if err != nil {
+			return fmt.Errorf("failed to encode traceroute hops for result %d: %w", record.ResultID, err)
+		}
+		if err := s.db.UpdateTracerouteMonitorResultHops(ctx, record.ResultID, string(encoded)); err != nil {
+			return err
+		}
+		result.Updated++
+		result.HopsEnriched += enriched
+	}
+	return nil
+}
+
+// enrichMTRHops fills in country and ASN data from the loaded GeoIP databases and
+// returns how many hops changed. Existing values are only replaced by non-empty lookups.
+func enrichMTRHops(hops []types.MTRHop) int {
+	changed := 0
+	for i := range hops {
+		hop := &hops[i]
+		ip := hop.IP
+		if ip == "" && net.ParseIP(hop.Host) != nil {
+			ip = hop.Host
+		}
+		if ip == "" {
+			continue
+		}
+
+		updated := false
+		if country := getCountryFromIP(ip); country != "" && country != hop.CountryCode {
+			hop.CountryCode = country
+			updated = true
+		}
+		if as := getASNFromIP(ip); as != "" && as != hop.AS {
+			hop.AS = as
+			updated = true
+		}
+		if updated {
+			changed++
+		}
+	}
+	return changed
+}
+
+// enrichTracerouteHops is enrichMTRHops for traceroute hops, which time out with an
+// IP of "*" rather than an empty one
+func enrichTracerouteHops(hops []TracerouteHop) int {
+	changed := 0
+	for i := range hops {
+		hop := &hops[i]
+		if net.ParseIP(hop.IP) == nil {
+			continue
+		}
+
+		updated := false
+		if country := getCountryFromIP(hop.IP); country != "" && country != hop.CountryCode {
+			hop.CountryCode = country
+			updated = true
+		}
+		if as := getASNFromIP(hop.IP); as != "" && as != hop.AS {
+			hop.AS = as
+			updated = true
+		}
+		if updated {
+			changed++
+		}
+	}
+	return changed
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestReenrichGeoIP_NoDatabases(t *testing.T) {
+	if geoIPLoaded() {
+		t.Skip("GeoIP databases already loaded")
+	}
+
+	s := &service{}
+	_, err := s.ReenrichGeoIP(context.Background(), time.Time{}, time.Time{})
+	assert.ErrorIs(t, err, ErrGeoIPUnavailable)
+}
+
+func TestReenrichGeoIP_ConcurrentInit(t *testing.T) {
+	if geoIPLoaded() {
+		t.Skip("GeoIP databases already loaded")
+	}
+
+	// Lazy initialization from concurrent backfills must not race (go test -race)
+	s := &service{}
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.ReenrichGeoIP(context.Background(), time.Time{}, time.Time{})
+			assert.ErrorIs(t, err, ErrGeoIPUnavailable)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestEnrichMTRHops_NoDatabasesKeepsData(t *testing.T) {
+	if geoIPLoaded() {
+		t.Skip("GeoIP databases already loaded")
+	}
+
+	hops := []types.MTRHop{
+		{Number: 1, Host: "192.168.1.1", CountryCode: "SE", AS: "AS1 Example"},
+		{Number: 2, Host: "???"},
+	}
+	assert.Equal(t, 0, enrichMTRHops(hops))
+	assert.Equal(t, "SE", hops[0].CountryCode, "existing data should not be cleared by empty lookups")
+	assert.Equal(t, "AS1 Example", hops[0].AS)
+}
+
+func TestEnrichTracerouteHops_NoDatabasesKeepsData(t *testing.T) {
+	if geoIPLoaded() {
+		t.Skip("GeoIP databases already loaded")
+	}
+
+	hops := []TracerouteHop{
+		{Number: 1, IP: "203.0.113.1", CountryCode: "NL", AS: "AS2 Example"},
+		{Number: 2, IP: "*", Timeout: true},
+	}
+	assert.Equal(t, 0, enrichTracerouteHops(hops))
+	assert.Equal(t, "NL", hops[0].CountryCode, "existing data should not be cleared by empty lookups")
+	assert.Equal(t, "AS2 Example", hops[0].AS)
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"container/list"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultGeoIPCacheSize = 4096
+	defaultGeoIPCacheTTL  = 24 * time.Hour
+)
+
+// geoIPLookups caches country and ASN lookups for hosts and hop addresses, from
+// geoip.cache_size and geoip.cache_ttl. Swapped atomically since lookups run
+// concurrently with initGeoIP.
+var geoIPLookups atomic.Pointer[geoIPCache]
+
+func init() {
+	geoIPLookups.Store(newGeoIPCache(defaultGeoIPCacheSize, defaultGeoIPCacheTTL))
+}
+
+// geoIPCache is a concurrency-safe LRU cache of lookup results with a fixed TTL.
+// Empty results are cached too, so unresolvable hosts don't hit DNS on every run.
+type geoIPCache struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type geoIPCacheEntry struct {
+	key     string
+	value   string
+	expires time.Time
+}
+
+// newGeoIPCache returns a cache holding up to size entries. A size of zero disables caching.
+func newGeoIPCache(size int, ttl time.Duration) *geoIPCache {
+	return &geoIPCache{
+		size:  size,
+		ttl:   ttl,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// lookup returns the cached value for key, calling fn and caching its result on a miss
+func (c *geoIPCache) lookup(key string, fn func() string) string {
+	if c == nil || c.size <= 0 {
+		return fn()
+	}
+
+	if value, ok := c.get(key); ok {
+		return value
+	}
+
+	// Computed outside the lock since fn may resolve DNS; concurrent misses for the
+	// same key just do the lookup twice
+	value := fn()
+	c.add(key, value)
+	return value
+}
+
+func (c *geoIPCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*geoIPCacheEntry)
+	if time.Now().After(entry.expires) {
+		c.ll.Remove(elem)
+		delete(c.items, key)
+		return "", false
+	}
+	c.ll.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (c *geoIPCache) add(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(c.ttl)
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*geoIPCacheEntry)
+		entry.value = value
+		entry.expires = expires
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&geoIPCacheEntry{key: key, value: value, expires: expires})
+	for c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*geoIPCacheEntry).key)
+	}
+}
+
+// lookupHost is lookup for a hostname that has to be resolved first. A resolver error
+// returns an empty result without caching it, unless the host doesn't exist, so a
+// transient DNS failure is retried on the next run instead of sticking for the TTL.
+func (c *geoIPCache) lookupHost(key string, fn func() (string, error)) string {
+	if c == nil || c.size <= 0 {
+		value, _ := fn()
+		return value
+	}
+
+	if value, ok := c.get(key); ok {
+		return value
+	}
+
+	value, err := fn()
+	var dnsErr *net.DNSError
+	if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		return ""
+	}
+	c.add(key, value)
+	return value
+}
+
+// purge drops every entry, e.g. after a database is replaced
+func (c *geoIPCache) purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.mu.Unlock()
+}
+
+// count returns the number of cached entries, including expired ones not yet evicted
+func (c *geoIPCache) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeoIPCacheLookup(t *testing.T) {
+	c := newGeoIPCache(2, time.Hour)
+
+	calls := 0
+	lookup := func(value string) func() string {
+		return func() string {
+			calls++
+			return value
+		}
+	}
+
+	assert.Equal(t, "SE", c.lookup("country:1.1.1.1", lookup("SE")))
+	assert.Equal(t, "SE", c.lookup("country:1.1.1.1", lookup("NL")), "hits are served from the cache")
+	assert.Equal(t, 1, calls)
+
+	assert.Equal(t, "", c.lookup("country:unresolvable.invalid", lookup("")))
+	assert.Equal(t, "", c.lookup("country:unresolvable.invalid", lookup("US")), "empty results are cached too")
+	assert.Equal(t, 2, calls)
+
+	// Adding a third entry evicts the least recently used one
+	c.lookup("country:1.1.1.1", lookup("SE"))
+	c.lookup("country:8.8.8.8", lookup("US"))
+	assert.Equal(t, 2, c.count())
+	assert.Equal(t, "SE", c.lookup("country:1.1.1.1", lookup("XX")))
+	assert.Equal(t, "DE", c.lookup("country:unresolvable.invalid", lookup("DE")), "evicted entry is looked up again")
+
+	c.purge()
+	assert.Equal(t, 0, c.count())
+}
+
+func TestGeoIPCacheLookupHost(t *testing.T) {
+	c := newGeoIPCache(10, time.Hour)
+
+	timeout := &net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}
+	assert.Equal(t, "", c.lookupHost("country:example.com", func() (string, error) { return "", timeout }))
+	assert.Equal(t, 0, c.count(), "transient DNS failures aren't cached")
+	assert.Equal(t, "SE", c.lookupHost("country:example.com", func() (string, error) { return "SE", nil }))
+	assert.Equal(t, "SE", c.lookupHost("country:example.com", func() (string, error) { return "NL", nil }))
+
+	notFound := &net.DNSError{Err: "no such host", Name: "unresolvable.invalid", IsNotFound: true}
+	assert.Equal(t, "", c.lookupHost("country:unresolvable.invalid", func() (string, error) { return "", notFound }))
+	assert.Equal(t, "", c.lookupHost("country:unresolvable.invalid", func() (string, error) { return "US", nil }), "missing hosts are cached")
+}
+
+func TestGeoIPCacheExpiry(t *testing.T) {
+	c := newGeoIPCache(10, time.Millisecond)
+	c.lookup("asn:1.1.1.1", func() string { return "AS13335" })
+
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, "AS1", c.lookup("asn:1.1.1.1", func() string { return "AS1" }), "expired entries are refreshed")
+}
+
+func TestGeoIPCacheDisabled(t *testing.T) {
+	c := newGeoIPCache(0, time.Hour)
+
+	calls := 0
+	for range 3 {
+		c.lookup("country:1.1.1.1", func() string {
+			calls++
+			return "SE"
+		})
+	}
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 0, c.count())
+}
+
+func TestGeoIPCacheConcurrent(t *testing.T) {
+	c := newGeoIPCache(64, time.Hour)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 200 {
+				key := fmt.Sprintf("country:10.0.%d.%d", i, j%100)
+				c.lookup(key, func() string { return "SE" })
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, c.count(), 64)
+}
@@ -0,0 +1,255 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+const (
+	defaultGeoIPUpdateInterval = 7 * 24 * time.Hour
+	// geoIPCheckInterval is how often the updater looks for stale databases
+	geoIPCheckInterval = time.Hour
+	// geoIPRetryInterval spaces out retries after a failed download, since MaxMind
+	// limits daily downloads per account
+	geoIPRetryInterval = 6 * time.Hour
+	// geoIPCloseDelay keeps a replaced reader open for lookups that already hold it
+	geoIPCloseDelay = time.Minute
+	// maxGeoIPDatabaseSize bounds the extracted mmdb file
+	maxGeoIPDatabaseSize = 512 << 20
+)
+
+// geoIPDownloadURL is the MaxMind download endpoint, replaced in tests
+var geoIPDownloadURL = "https://download.maxmind.com/app/geoip_download"
+
+var geoIPUpdaterOnce sync.Once
+
+// loadGeoIPDatabase opens the mmdb file at path and swaps it into target, closing the
+// previous reader once in-flight lookups are done with it
+func loadGeoIPDatabase(target *atomic.Pointer[geoip2.Reader], path string) error {
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return err
+	}
+	if old := target.Swap(db); old != nil {
+		time.AfterFunc(geoIPCloseDelay, func() { _ = old.Close() })
+	}
+	geoIPLookups.Load().purge()
+	return nil
+}
+
+// geoIPUpdater downloads MaxMind database editions into a directory and keeps them fresh
+type geoIPUpdater struct {
+	licenseKey string
+	editions   []string
+	dir        string
+	interval   time.Duration
+	httpClient *http.Client
+
+	failedAt map[string]time.Time
+}
+
+// startGeoIPUpdater loads previously downloaded databases and starts the background
+// updater. It only runs once per process.
+func startGeoIPUpdater(cfg config.GeoIPConfig) {
+	geoIPUpdaterOnce.Do(func() {
+		u := newGeoIPUpdater(cfg)
+		u.loadExisting()
+		go u.run()
+	})
+}
+
+func newGeoIPUpdater(cfg config.GeoIPConfig) *geoIPUpdater {
+	interval := defaultGeoIPUpdateInterval
+	if cfg.UpdateInterval != "" {
+		if d, err := time.ParseDuration(cfg.UpdateInterval); err == nil && d > 0 {
+			interval = d
+		} else {
+			log.Warn().Str("interval", cfg.UpdateInterval).Msg("Invalid geoip.update_interval, using default")
+		}
+	}
+
+	return &geoIPUpdater{
+		licenseKey: cfg.LicenseKey,
+		editions:   cfg.EditionIDs,
+		dir:        cfg.DatabaseDir,
+		interval:   interval,
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		failedAt:   make(map[string]time.Time),
+	}
+}
+
+// geoIPTarget returns the reader an edition is served from
+func geoIPTarget(edition string) *atomic.Pointer[geoip2.Reader] {
+	if strings.Contains(edition, "ASN") {
+		return &asnDB
+	}
+	return &countryDB
+}
+
+func (u *geoIPUpdater) path(edition string) string {
+	return filepath.Join(u.dir, edition+".mmdb")
+}
+
+// loadExisting serves databases left by an earlier download until they are refreshed
+func (u *geoIPUpdater) loadExisting() {
+	for _, edition := range u.editions {
+		path := u.path(edition)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := loadGeoIPDatabase(geoIPTarget(edition), path); err != nil {
+			log.Warn().Str("edition", edition).Str("path", path).Err(err).Msg("Failed to load downloaded GeoIP database")
+			continue
+		}
+		log.Info().Str("edition", edition).Str("path", path).Msg("GeoIP database loaded successfully")
+	}
+}
+
+func (u *geoIPUpdater) run() {
+	u.updateStale(context.Background())
+
+	ticker := time.NewTicker(geoIPCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		u.updateStale(context.Background())
+	}
+}
+
+// updateStale downloads every edition that is missing or older than the update interval
+func (u *geoIPUpdater) updateStale(ctx context.Context) {
+	now := time.Now()
+	for _, edition := range u.editions {
+		if info, err := os.Stat(u.path(edition)); err == nil && now.Sub(info.ModTime()) < u.interval {
+			continue
+		}
+		if failed, ok := u.failedAt[edition]; ok && now.Sub(failed) < geoIPRetryInterval {
+			continue
+		}
+
+		if err := u.update(ctx, edition); err != nil {
+			u.failedAt[edition] = now
+			log.Error().Str("edition", edition).Err(err).Msg("Failed to update GeoIP database")
+			continue
+		}
+		delete(u.failedAt, edition)
+		log.Info().Str("edition", edition).Str("path", u.path(edition)).Msg("GeoIP database updated")
+	}
+}
+
+// update downloads an edition, validates it and atomically replaces the served reader
+func (u *geoIPUpdater) update(ctx context.Context, edition string) error {
+	if err := os.MkdirAll(u.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create GeoIP directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(u.dir, edition+"-*.mmdb.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if err := u.download(ctx, edition, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write database: %w", err)
+	}
+
+	// Make sure the download is a readable database before it replaces the current one
+	db, err := geoip2.Open(tmpPath)
+	if err != nil {
+		return fmt.Errorf("downloaded database is invalid: %w", err)
+	}
+	db.Close()
+
+	path := u.path(edition)
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace database: %w", err)
+	}
+	return loadGeoIPDatabase(geoIPTarget(edition), path)
+}
+
+// download fetches the edition's tar.gz archive and writes the mmdb file inside it to w
+func (u *geoIPUpdater) download(ctx context.Context, edition string, w io.Writer) error {
+	query := url.Values{}
+	query.Set("edition_id", edition)
+	query.Set("license_key", u.licenseKey)
+	query.Set("suffix", "tar.gz")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", geoIPDownloadURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create download request: %w", err)
+	}
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		// The URL carries the license key, so don't log it with the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to download %s: %w", edition, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("download of %s returned status %d: %s", edition, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return extractMMDB(resp.Body, w)
+}
+
+// extractMMDB copies the first .mmdb file from a tar.gz archive to w
+func extractMMDB(r io.Reader, w io.Writer) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return errors.New("archive does not contain an mmdb file")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || !strings.HasSuffix(header.Name, ".mmdb") {
+			continue
+		}
+
+		n, err := io.Copy(w, io.LimitReader(tr, maxGeoIPDatabaseSize+1))
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %w", header.Name, err)
+		}
+		if n > maxGeoIPDatabaseSize {
+			return fmt.Errorf("%s exceeds %d bytes", header.Name, maxGeoIPDatabaseSize)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+func buildArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestExtractMMDB(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "mmdb in dated directory",
+			files: map[string]string{"GeoLite2-ASN_20260101/COPYRIGHT.txt": "c", "GeoLite2-ASN_20260101/GeoLite2-ASN.mmdb": "db"},
+			want:  "db",
+		},
+		{
+			name:    "no mmdb",
+			files:   map[string]string{"README.txt": "nothing here"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := extractMMDB(bytes.NewReader(buildArchive(t, tt.files)), &out)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+
+	assert.Error(t, extractMMDB(bytes.NewReader([]byte("not gzip")), &bytes.Buffer{}))
+}
+
+func TestGeoIPTarget(t *testing.T) {
+	assert.Same(t, &asnDB, geoIPTarget("GeoLite2-ASN"))
+	assert.Same(t, &countryDB, geoIPTarget("GeoLite2-Country"))
+	assert.Same(t, &countryDB, geoIPTarget("GeoIP2-City"))
+}
+
+func TestGeoIPUpdaterDownload(t *testing.T) {
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		if r.URL.Query().Get("license_key") != "good" {
+			http.Error(w, "Invalid license key", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write(buildArchive(t, map[string]string{"GeoLite2-ASN_20260101/GeoLite2-ASN.mmdb": "db"}))
+	}))
+	defer srv.Close()
+
+	prev := geoIPDownloadURL
+	geoIPDownloadURL = srv.URL
+	t.Cleanup(func() { geoIPDownloadURL = prev })
+
+	u := newGeoIPUpdater(config.GeoIPConfig{LicenseKey: "good", EditionIDs: []string{"GeoLite2-ASN"}, DatabaseDir: t.TempDir()})
+	assert.Equal(t, defaultGeoIPUpdateInterval, u.interval)
+
+	var out bytes.Buffer
+	require.NoError(t, u.download(context.Background(), "GeoLite2-ASN", &out))
+	assert.Equal(t, "db", out.String())
+	assert.Contains(t, gotQuery, "edition_id=GeoLite2-ASN")
+	assert.Contains(t, gotQuery, "suffix=tar.gz")
+
+	u.licenseKey = "bad"
+	err := u.download(context.Background(), "GeoLite2-ASN", &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
+
+func TestGeoIPUpdaterKeepsDatabaseOnInvalidDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(buildArchive(t, map[string]string{"GeoLite2-Country.mmdb": "not a database"}))
+	}))
+	defer srv.Close()
+
+	prev := geoIPDownloadURL
+	geoIPDownloadURL = srv.URL
+	t.Cleanup(func() { geoIPDownloadURL = prev })
+
+	u := newGeoIPUpdater(config.GeoIPConfig{LicenseKey: "key", EditionIDs: []string{"GeoLite2-Country"}, DatabaseDir: t.TempDir(), UpdateInterval: "24h"})
+	assert.Equal(t, 24*time.Hour, u.interval)
+
+	existing := []byte("previous download")
+	require.NoError(t, os.WriteFile(u.path("GeoLite2-Country"), existing, 0o644))
+	stale := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(u.path("GeoLite2-Country"), stale, stale))
+
+	u.updateStale(context.Background())
+
+	got, err := os.ReadFile(u.path("GeoLite2-Country"))
+	require.NoError(t, err)
+	assert.Equal(t, existing, got, "an invalid download must not replace the current file")
+	assert.Contains(t, u.failedAt, "GeoLite2-Country", "failures are remembered to space out retries")
+
+	entries, err := os.ReadDir(u.dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files are cleaned up")
+}
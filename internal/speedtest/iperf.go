@@ -50,15 +50,58 @@ type iperfEndData struct {
 		JitterMs      float64 `json:"jitter_ms"`
 	} `json:"sum_sent"`
 	SumReceived struct {
+		BitsPerSecond float64  `json:"bits_per_second"`
+		JitterMs      float64  `json:"jitter_ms"`
+		LostPercent   *float64 `json:"lost_percent"`
+	} `json:"sum_received"`
+	// Sum carries the receiver's UDP statistics on iperf3 versions without sum_received
+	Sum *struct {
 		BitsPerSecond float64 `json:"bits_per_second"`
 		JitterMs      float64 `json:"jitter_ms"`
-	} `json:"sum_received"`
+		LostPercent   float64 `json:"lost_percent"`
+	} `json:"sum"`
+	// SumReceivedBidirReverse is the server to client direction of a --bidir test, as
+	// received by the client
+	SumReceivedBidirReverse struct {
+		BitsPerSecond float64  `json:"bits_per_second"`
+		JitterMs      float64  `json:"jitter_ms"`
+		LostPercent   *float64 `json:"lost_percent"`
+	} `json:"sum_received_bidir_reverse"`
+}
+
+// iperfIntervalData is the data of a --json-stream interval event. SumBidirReverse is only
+// set for --bidir tests and holds the server to client direction.
+type iperfIntervalData struct {
+	Sum struct {
+		BitsPerSecond float64 `json:"bits_per_second"`
+	} `json:"sum"`
+	SumBidirReverse struct {
+		BitsPerSecond float64 `json:"bits_per_second"`
+	} `json:"sum_bidir_reverse"`
+}
+
+// iperfDirection is what an iperf3 run measures
+type iperfDirection int
+
+const (
+	iperfUpload iperfDirection = iota
+	iperfDownload
+	iperfBidirectional
+)
+
+// iperfBidirMetrics is the outcome of a --bidir test, with speeds in Mbps
+type iperfBidirMetrics struct {
+	download     float64
+	upload       float64
+	jitter       *float64
+	datagramLoss *float64
 }
 
 type IperfRunner struct {
 	config           config.IperfConfig
 	progressCallback func(types.SpeedUpdate)
 	pingResult       *PingResult
+	source           SourceAddress
 }
 
 func NewIperfRunner(cfg config.IperfConfig) *IperfRunner {
@@ -96,7 +139,7 @@ func (r *IperfRunner) RunTest(ctx context.Context, opts *types.TestOptions) (*Re
 		Msg("Starting iperf3 test")
 
 	var downloadSpeed, uploadSpeed float64
-	var jitterMs *float64
+	var jitterMs, datagramLoss *float64
 	var latency string = "0ms"
 
 	// Use ping results if available
@@ -104,7 +147,21 @@ func (r *IperfRunner) RunTest(ctx context.Context, opts *types.TestOptions) (*Re
 		latency = r.pingResult.FormatLatency()
 	}
 
-	if opts.EnableDownload {
+	download, upload := iperfTestDirections(r.config, opts)
+
+	// Bidirectional tests measure both directions in a single iperf3 run
+	bidirectional := r.config.Bidirectional && download && upload
+
+	if bidirectional {
+		bidirResult, err := r.runBidirIperfTest(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("bidirectional test failed: %w", err)
+		}
+		downloadSpeed = bidirResult.DownloadSpeed
+		uploadSpeed = bidirResult.UploadSpeed
+		jitterMs = bidirResult.Jitter
+		datagramLoss = bidirResult.DatagramLoss
+	} else if download {
 		downloadOpts := *opts
 		downloadOpts.EnableDownload = true
 		downloadOpts.EnableUpload = false
@@ -117,11 +174,12 @@ func (r *IperfRunner) RunTest(ctx context.Context, opts *types.TestOptions) (*Re
 		if downloadResult.Jitter != nil {
 			jitterMs = downloadResult.Jitter
 		}
+		datagramLoss = downloadResult.DatagramLoss
 	}
 
-	time.Sleep(2 * time.Second)
+	if upload && !bidirectional {
+		time.Sleep(2 * time.Second)
 
-	if opts.EnableUpload {
 		uploadOpts := *opts
 		uploadOpts.EnableDownload = false
 		uploadOpts.EnableUpload = true
@@ -131,6 +189,13 @@ func (r *IperfRunner) RunTest(ctx context.Context, opts *types.TestOptions) (*Re
 			return nil, fmt.Errorf("upload test failed: %w", err)
 		}
 		uploadSpeed = uploadResult.UploadSpeed
+		// UDP upload-only tests still report the receiver's jitter and loss
+		if jitterMs == nil {
+			jitterMs = uploadResult.Jitter
+		}
+		if datagramLoss == nil {
+			datagramLoss = uploadResult.DatagramLoss
+		}
 	}
 
 	// Skip jitter test for iperf3 - it's unreliable and causes timeouts
@@ -154,6 +219,7 @@ func (r *IperfRunner) RunTest(ctx context.Context, opts *types.TestOptions) (*Re
 		UploadSpeed:   uploadSpeed,
 		Latency:       latency,
 		Jitter:        jitterFloat,
+		DatagramLoss:  datagramLoss,
 		Download:      downloadSpeed,
 		Upload:        uploadSpeed,
 	}
@@ -167,16 +233,7 @@ func (r *IperfRunner) runSingleIperfTest(ctx context.Context, opts *types.TestOp
 		return nil, fmt.Errorf("server host is required for iperf3 test")
 	}
 
-	// Split host and port if port is included
-	host := opts.ServerHost
-	port := "5201" // Default to 5201 since we know it works
-	if strings.Contains(host, ":") {
-		parts := strings.Split(host, ":")
-		host = parts[0]
-		if len(parts) > 1 {
-			port = parts[1]
-		}
-	}
+	host, port := splitIperfHost(opts.ServerHost)
 
 	log.Debug().
 		Str("host", host).
@@ -204,12 +261,174 @@ func (r *IperfRunner) runSingleIperfTest(ctx context.Context, opts *types.TestOp
 			Progress:   0,
 			IsComplete: false,
 			TestType:   "iperf3",
+			RunID:      opts.RunID,
+		})
+	}
+
+	direction := iperfUpload
+	if opts.EnableDownload {
+		direction = iperfDownload
+	}
+
+	output, err := r.runIperfCommand(ctx, host, port, direction, func(interval iperfIntervalData, progress float64) {
+		// Get speed from current interval
+		currentSpeed := interval.Sum.BitsPerSecond / 1_000_000 // Convert to Mbps
+
+		log.Debug().
+			Float64("progress", progress).
+			Float64("speed", currentSpeed).
+			Str("type", testType).
+			Msg("iperf3 streaming progress update")
+
+		if r.progressCallback != nil {
+			r.progressCallback(types.SpeedUpdate{
+				Type:       testType,
+				ServerName: serverName,
+				Speed:      currentSpeed,
+				Progress:   progress,
+				IsComplete: false,
+				TestType:   "iperf3",
+				RunID:      opts.RunID,
+			})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := parseIperfEnd(output)
+	if err != nil {
+		return nil, err
+	}
+
+	speedMbps := selectIperfDirectionMetrics(end, opts.EnableDownload)
+	jitterMs := selectIperfDirectionJitter(end, opts.EnableDownload)
+	var datagramLoss *float64
+	if r.config.Protocol == config.IperfProtocolUDP {
+		speedMbps, jitterMs, datagramLoss = selectIperfUDPMetrics(end)
+	}
+
+	// Send final update
+	if r.progressCallback != nil {
+		r.progressCallback(types.SpeedUpdate{
+			Type:       testType,
+			ServerName: serverName,
+			Speed:      speedMbps,
+			Progress:   100.0,
+			IsComplete: true,
+			TestType:   "iperf3",
+			RunID:      opts.RunID,
+		})
+	}
+
+	return &types.SpeedTestResult{
+		ServerName: serverName,
+		TestType:   "iperf3",
+		DownloadSpeed: func() float64 {
+			if opts.EnableDownload {
+				return speedMbps
+			}
+			return 0
+		}(),
+		UploadSpeed: func() float64 {
+			if !opts.EnableDownload {
+				return speedMbps
+			}
+			return 0
+		}(),
+		Jitter:       jitterMs,
+		DatagramLoss: datagramLoss,
+		IsScheduled:  opts.IsScheduled,
+	}, nil
+}
+
+// runBidirIperfTest measures download and upload at the same time with iperf3 --bidir.
+// Progress updates carry both directions: Speed is the download and UploadSpeed the upload.
+func (r *IperfRunner) runBidirIperfTest(ctx context.Context, opts *types.TestOptions) (*types.SpeedTestResult, error) {
+	host, port := splitIperfHost(opts.ServerHost)
+
+	log.Debug().
+		Str("host", host).
+		Str("port", port).
+		Msg("Starting bidirectional iperf3 test")
+
+	serverName := opts.ServerName
+	if serverName == "" {
+		serverName = fmt.Sprintf("%s:%s", host, port)
+	}
+
+	if r.progressCallback != nil {
+		r.progressCallback(types.SpeedUpdate{
+			Type:       types.SpeedUpdateBidirectional,
+			ServerName: serverName,
+			TestType:   "iperf3",
+			RunID:      opts.RunID,
+		})
+	}
+
+	output, err := r.runIperfCommand(ctx, host, port, iperfBidirectional, func(interval iperfIntervalData, progress float64) {
+		download, upload := selectIperfBidirIntervalSpeeds(interval)
+
+		log.Debug().
+			Float64("progress", progress).
+			Float64("download", download).
+			Float64("upload", upload).
+			Msg("iperf3 bidirectional streaming progress update")
+
+		if r.progressCallback != nil {
+			r.progressCallback(types.SpeedUpdate{
+				Type:        types.SpeedUpdateBidirectional,
+				ServerName:  serverName,
+				Speed:       download,
+				UploadSpeed: upload,
+				Progress:    progress,
+				TestType:    "iperf3",
+				RunID:       opts.RunID,
+			})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := parseIperfEnd(output)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := selectIperfBidirMetrics(end, r.config.Protocol == config.IperfProtocolUDP)
+
+	if r.progressCallback != nil {
+		r.progressCallback(types.SpeedUpdate{
+			Type:        types.SpeedUpdateBidirectional,
+			ServerName:  serverName,
+			Speed:       metrics.download,
+			UploadSpeed: metrics.upload,
+			Progress:    100.0,
+			IsComplete:  true,
+			TestType:    "iperf3",
+			RunID:       opts.RunID,
 		})
 	}
 
+	return &types.SpeedTestResult{
+		ServerName:    serverName,
+		TestType:      "iperf3",
+		DownloadSpeed: metrics.download,
+		UploadSpeed:   metrics.upload,
+		Jitter:        metrics.jitter,
+		DatagramLoss:  metrics.datagramLoss,
+		IsScheduled:   opts.IsScheduled,
+	}, nil
+}
+
+// runIperfCommand runs iperf3 against host:port and returns its JSON output. With
+// --json-stream output, onInterval is called for interval events at most once a second
+// together with the test's progress in percent.
+func (r *IperfRunner) runIperfCommand(ctx context.Context, host, port string, direction iperfDirection, onInterval func(iperfIntervalData, float64)) (string, error) {
 	// Check if iperf3 is installed
 	if _, err := exec.LookPath("iperf3"); err != nil {
-		return nil, fmt.Errorf("iperf3 not found: please install iperf3 to use this feature")
+		return "", fmt.Errorf("iperf3 not found: please install iperf3 to use this feature")
 	}
 
 	jsonOutputArg := "-J"
@@ -217,24 +436,18 @@ func (r *IperfRunner) runSingleIperfTest(ctx context.Context, opts *types.TestOp
 		jsonOutputArg = "--json-stream"
 	}
 
-	args := []string{
-		"-c", host,
-		"-p", port,
-		jsonOutputArg,
-		"-i", "1", // 1-second interval to match speedtest.net consistency
-		"-t", strconv.Itoa(r.config.TestDuration), // Test duration in seconds
-		"-P", strconv.Itoa(r.config.ParallelConns), // Number of parallel connections
-		"--format", "m", // Force Mbps output
+	args := buildIperfArgs(r.config, host, port, jsonOutputArg, direction)
+	source, err := r.source.Resolve()
+	if err != nil {
+		return "", err
 	}
-
-	// Always use TCP mode for iperf3 speed tests
-	// UDP mode with bandwidth limits gives misleading results
-	if opts.EnableDownload {
-		args = append(args, "-R")
+	if source != nil {
+		args = append(args, "-B", source.String())
 	}
 
 	log.Debug().
 		Str("json_output_mode", jsonOutputArg).
+		Strs("args", args).
 		Msg("Selected iperf3 JSON mode")
 
 	// Create a timeout context for the iperf3 command
@@ -246,12 +459,12 @@ func (r *IperfRunner) runSingleIperfTest(ctx context.Context, opts *types.TestOp
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+		return "", fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
 
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+		return "", fmt.Errorf("failed to create stderr pipe: %w", err)
 	}
 
 	var output strings.Builder
@@ -262,7 +475,7 @@ func (r *IperfRunner) runSingleIperfTest(ctx context.Context, opts *types.TestOp
 	var lastUpdate atomic.Int64
 
 	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start iperf3: %w", err)
+		return "", fmt.Errorf("failed to start iperf3: %w", err)
 	}
 
 	go func() {
@@ -278,49 +491,27 @@ func (r *IperfRunner) runSingleIperfTest(ctx context.Context, opts *types.TestOp
 
 		// Try to parse JSON streaming format for progress updates
 		var streamData struct {
-			Event string `json:"event"`
-			Data  struct {
-				Sum struct {
-					Bits_per_second float64 `json:"bits_per_second"`
-				} `json:"sum"`
-			} `json:"data"`
+			Event string            `json:"event"`
+			Data  iperfIntervalData `json:"data"`
 		}
 
-		if err := json.Unmarshal([]byte(line), &streamData); err == nil {
-			// Only process interval events for real-time progress
-			if streamData.Event == "interval" {
-				// Apply the same 1-second throttling as speedtest.net
-				now := time.Now().Unix()
-				lastUpdateTime := lastUpdate.Load()
-
-				if now-lastUpdateTime >= 1 {
-					// Calculate progress based on elapsed time
-					elapsed := time.Since(startTime)
-					progress := math.Min(100, (elapsed.Seconds()/totalDuration.Seconds())*100)
-
-					// Get speed from current interval
-					currentSpeed := streamData.Data.Sum.Bits_per_second / 1_000_000 // Convert to Mbps
-
-					log.Debug().
-						Float64("progress", progress).
-						Float64("speed", currentSpeed).
-						Str("type", testType).
-						Str("event", streamData.Event).
-						Msg("iperf3 streaming progress update")
-
-					if progress > 0 && r.progressCallback != nil {
-						r.progressCallback(types.SpeedUpdate{
-							Type:       testType,
-							ServerName: serverName,
-							Speed:      currentSpeed,
-							Progress:   progress,
-							IsComplete: false,
-							TestType:   "iperf3",
-						})
-						lastUpdate.Store(now)
-					}
-				}
-			}
+		// Only process interval events for real-time progress
+		if err := json.Unmarshal([]byte(line), &streamData); err != nil || streamData.Event != "interval" {
+			continue
+		}
+
+		// Apply the same 1-second throttling as speedtest.net
+		now := time.Now().Unix()
+		if now-lastUpdate.Load() < 1 {
+			continue
+		}
+
+		// Calculate progress based on elapsed time
+		elapsed := time.Since(startTime)
+		progress := math.Min(100, (elapsed.Seconds()/totalDuration.Seconds())*100)
+		if progress > 0 {
+			onInterval(streamData.Data, progress)
+			lastUpdate.Store(now)
 		}
 	}
 
@@ -329,7 +520,7 @@ func (r *IperfRunner) runSingleIperfTest(ctx context.Context, opts *types.TestOp
 
 		// Check if the error was due to context timeout
 		if timeoutCtx.Err() == context.DeadlineExceeded {
-			return nil, fmt.Errorf("iperf3 test timed out after %d seconds: %s", r.config.Timeout, formatIperfFailureOutput("", stderrOutput.String()))
+			return "", fmt.Errorf("iperf3 test timed out after %d seconds: %s", r.config.Timeout, formatIperfFailureOutput("", stderrOutput.String()))
 		}
 
 		outputStr := strings.TrimSpace(output.String())
@@ -340,51 +531,79 @@ func (r *IperfRunner) runSingleIperfTest(ctx context.Context, opts *types.TestOp
 				outputStr = string(formattedJSON)
 			}
 		}
-		return nil, fmt.Errorf("iperf3 failed: %s - %w", formatIperfFailureOutput(outputStr, stderrOutput.String()), err)
+		return "", fmt.Errorf("iperf3 failed: %s - %w", formatIperfFailureOutput(outputStr, stderrOutput.String()), err)
 	}
 	<-stderrDone
 
-	speedMbps, jitterMs, err := parseIperfFinalMetrics(output.String(), opts.EnableDownload)
-	if err != nil {
-		return nil, err
+	return output.String(), nil
+}
+
+// splitIperfHost splits host:port, defaulting to port 5201
+func splitIperfHost(serverHost string) (string, string) {
+	host := serverHost
+	port := "5201" // Default to 5201 since we know it works
+	if strings.Contains(host, ":") {
+		parts := strings.Split(host, ":")
+		host = parts[0]
+		if len(parts) > 1 {
+			port = parts[1]
+		}
 	}
+	return host, port
+}
 
-	// Send final update
-	if r.progressCallback != nil {
-		r.progressCallback(types.SpeedUpdate{
-			Type:       testType,
-			ServerName: serverName,
-			Speed:      speedMbps,
-			Progress:   100.0,
-			IsComplete: true,
-			TestType:   "iperf3",
-		})
+// iperfTestDirections returns the directions a test measures. Reverse mode only runs
+// server to client tests, so it measures the download whichever directions were asked for.
+func iperfTestDirections(cfg config.IperfConfig, opts *types.TestOptions) (download, upload bool) {
+	if cfg.Reverse {
+		return opts.EnableDownload || opts.EnableUpload, false
 	}
+	return opts.EnableDownload, opts.EnableUpload
+}
 
-	return &types.SpeedTestResult{
-		ServerName: serverName,
-		TestType:   "iperf3",
-		DownloadSpeed: func() float64 {
-			if opts.EnableDownload {
-				return speedMbps
-			}
-			return 0
-		}(),
-		UploadSpeed: func() float64 {
-			if !opts.EnableDownload {
-				return speedMbps
-			}
-			return 0
-		}(),
-		Jitter:      jitterMs,
-		IsScheduled: opts.IsScheduled,
-	}, nil
+// buildIperfArgs returns the iperf3 client arguments for a test in the given direction.
+// Download tests run in reverse mode (-R), server to client, and bidirectional tests
+// use --bidir.
+func buildIperfArgs(cfg config.IperfConfig, host, port, jsonOutputArg string, direction iperfDirection) []string {
+	args := []string{
+		"-c", host,
+		"-p", port,
+		jsonOutputArg,
+		"-i", "1", // 1-second interval to match speedtest.net consistency
+		"-t", strconv.Itoa(cfg.TestDuration), // Test duration in seconds
+		"-P", strconv.Itoa(cfg.ParallelConns), // Number of parallel connections
+		"--format", "m", // Force Mbps output
+	}
+
+	if cfg.Protocol == config.IperfProtocolUDP {
+		args = append(args, "-u")
+	}
+	if cfg.Bitrate != "" {
+		args = append(args, "-b", cfg.Bitrate)
+	}
+	switch direction {
+	case iperfBidirectional:
+		args = append(args, "--bidir")
+	case iperfDownload:
+		args = append(args, "-R")
+	}
+
+	return args
 }
 
 func parseIperfFinalMetrics(rawOutput string, isDownload bool) (float64, *float64, error) {
+	end, err := parseIperfEnd(rawOutput)
+	if err != nil {
+		return 0, nil, err
+	}
+	return selectIperfDirectionMetrics(end, isDownload), selectIperfDirectionJitter(end, isDownload), nil
+}
+
+// parseIperfEnd extracts the end summary from --json-stream or -J output
+func parseIperfEnd(rawOutput string) (iperfEndData, error) {
 	trimmed := strings.TrimSpace(rawOutput)
 	if trimmed == "" {
-		return 0, nil, fmt.Errorf("empty iperf3 output")
+		return iperfEndData{}, fmt.Errorf("empty iperf3 output")
 	}
 
 	// First try streaming format: line-delimited events with an "end" entry.
@@ -409,12 +628,12 @@ func parseIperfFinalMetrics(rawOutput string, isDownload bool) (float64, *float6
 
 		var parsed iperfEndData
 		if err := json.Unmarshal(event.Data, &parsed); err != nil {
-			return 0, nil, fmt.Errorf("failed to decode iperf3 end event: %w", err)
+			return iperfEndData{}, fmt.Errorf("failed to decode iperf3 end event: %w", err)
 		}
-		return selectIperfDirectionMetrics(parsed, isDownload), selectIperfDirectionJitter(parsed, isDownload), nil
+		return parsed, nil
 	}
 	if err := scanner.Err(); err != nil && !errors.Is(err, bufio.ErrTooLong) {
-		return 0, nil, fmt.Errorf("failed to scan iperf3 output: %w", err)
+		return iperfEndData{}, fmt.Errorf("failed to scan iperf3 output: %w", err)
 	}
 
 	// Fallback: classic monolithic -J output.
@@ -422,10 +641,65 @@ func parseIperfFinalMetrics(rawOutput string, isDownload bool) (float64, *float6
 		End iperfEndData `json:"end"`
 	}
 	if err := json.Unmarshal([]byte(trimmed), &parsed); err != nil {
-		return 0, nil, fmt.Errorf("failed to parse iperf3 output: %w", err)
+		return iperfEndData{}, fmt.Errorf("failed to parse iperf3 output: %w", err)
+	}
+
+	return parsed.End, nil
+}
+
+// selectIperfUDPMetrics returns the receiver's throughput, jitter and datagram loss for
+// a UDP test. The sender side only reports the target bitrate, so it is never used.
+func selectIperfUDPMetrics(end iperfEndData) (float64, *float64, *float64) {
+	speed := end.SumReceived.BitsPerSecond
+	jitter := end.SumReceived.JitterMs
+	loss := end.SumReceived.LostPercent
+	if end.Sum != nil {
+		if speed <= 0 {
+			speed = end.Sum.BitsPerSecond
+		}
+		if jitter <= 0 {
+			jitter = end.Sum.JitterMs
+		}
+		if loss == nil {
+			loss = &end.Sum.LostPercent
+		}
+	}
+
+	var jitterMs *float64
+	if jitter > 0 {
+		jitterMs = &jitter
+	}
+	return speed / 1_000_000, jitterMs, loss
+}
+
+// selectIperfBidirIntervalSpeeds returns the download and upload speeds in Mbps of a
+// --bidir interval. The client to server direction is the upload.
+func selectIperfBidirIntervalSpeeds(interval iperfIntervalData) (float64, float64) {
+	upload := interval.Sum.BitsPerSecond / 1_000_000
+	download := interval.SumBidirReverse.BitsPerSecond / 1_000_000
+	return download, upload
+}
+
+// selectIperfBidirMetrics returns both directions of a --bidir test. UDP speeds, jitter
+// and datagram loss come from the receiving side; jitter and loss are those of the
+// download direction, like in single direction tests.
+func selectIperfBidirMetrics(end iperfEndData, udp bool) iperfBidirMetrics {
+	upload := end.SumSent.BitsPerSecond / 1_000_000
+	if udp {
+		upload = end.SumReceived.BitsPerSecond / 1_000_000
 	}
+	download := end.SumReceivedBidirReverse.BitsPerSecond / 1_000_000
 
-	return selectIperfDirectionMetrics(parsed.End, isDownload), selectIperfDirectionJitter(parsed.End, isDownload), nil
+	metrics := iperfBidirMetrics{download: download, upload: upload}
+	jitter, loss := end.SumReceivedBidirReverse.JitterMs, end.SumReceivedBidirReverse.LostPercent
+
+	if jitter > 0 {
+		metrics.jitter = &jitter
+	}
+	if udp {
+		metrics.datagramLoss = loss
+	}
+	return metrics
 }
 
 func selectIperfDirectionMetrics(end iperfEndData, isDownload bool) float64 {
@@ -4,12 +4,16 @@
 package speedtest
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/types"
 )
 
 func TestParseIperfFinalMetrics_StreamOutput(t *testing.T) {
@@ -82,3 +86,167 @@ func TestFormatIperfFailureOutput(t *testing.T) {
 	assert.Equal(t, "stderr=unknown option --json-stream", formatIperfFailureOutput("", "unknown option --json-stream"))
 	assert.Equal(t, "no output", formatIperfFailureOutput("", ""))
 }
+
+func TestBuildIperfArgs(t *testing.T) {
+	base := config.IperfConfig{TestDuration: 10, ParallelConns: 4, Protocol: config.IperfProtocolTCP}
+
+	tests := []struct {
+		name      string
+		modify    func(cfg *config.IperfConfig)
+		direction iperfDirection
+		want      []string
+		notWant   []string
+	}{
+		{name: "tcp download", direction: iperfDownload, want: []string{"-R"}, notWant: []string{"-u", "-b", "--bidir"}},
+		{name: "tcp upload", direction: iperfUpload, notWant: []string{"-R", "-u", "-b", "--bidir"}},
+		{
+			name:      "udp with bitrate",
+			modify:    func(cfg *config.IperfConfig) { cfg.Protocol = config.IperfProtocolUDP; cfg.Bitrate = "100M" },
+			direction: iperfDownload,
+			want:      []string{"-u", "-b", "100M", "-R"},
+		},
+		{
+			name:      "reverse download",
+			modify:    func(cfg *config.IperfConfig) { cfg.Reverse = true },
+			direction: iperfDownload,
+			want:      []string{"-R"},
+		},
+		{
+			name:      "bidirectional",
+			modify:    func(cfg *config.IperfConfig) { cfg.Bidirectional = true; cfg.Reverse = true },
+			direction: iperfBidirectional,
+			want:      []string{"--bidir"},
+			notWant:   []string{"-R"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			if tt.modify != nil {
+				tt.modify(&cfg)
+			}
+			args := buildIperfArgs(cfg, "iperf.example.com", "5201", "-J", tt.direction)
+
+			assert.Equal(t, []string{"-c", "iperf.example.com", "-p", "5201", "-J"}, args[:5])
+			joined := strings.Join(args, " ")
+			for _, want := range tt.want {
+				assert.Contains(t, args, want)
+			}
+			if len(tt.want) > 1 {
+				assert.Contains(t, joined, strings.Join(tt.want, " "))
+			}
+			for _, notWant := range tt.notWant {
+				assert.NotContains(t, args, notWant)
+			}
+		})
+	}
+}
+
+func TestSelectIperfUDPMetrics(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		wantSpeed  float64
+		wantJitter float64
+		wantLoss   float64
+	}{
+		{
+			name:       "sum_received with loss",
+			output:     `{"event":"end","data":{"sum_sent":{"bits_per_second":100000000,"jitter_ms":0},"sum_received":{"bits_per_second":95000000,"jitter_ms":0.42,"lost_percent":5}}}`,
+			wantSpeed:  95,
+			wantJitter: 0.42,
+			wantLoss:   5,
+		},
+		{
+			name:       "older iperf3 sum only",
+			output:     `{"end":{"sum":{"bits_per_second":50000000,"jitter_ms":1.1,"lost_percent":0.25}}}`,
+			wantSpeed:  50,
+			wantJitter: 1.1,
+			wantLoss:   0.25,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end, err := parseIperfEnd(tt.output)
+			require.NoError(t, err)
+
+			speed, jitter, loss := selectIperfUDPMetrics(end)
+			assert.InDelta(t, tt.wantSpeed, speed, 0.0001)
+			require.NotNil(t, jitter)
+			assert.InDelta(t, tt.wantJitter, *jitter, 0.0001)
+			require.NotNil(t, loss)
+			assert.InDelta(t, tt.wantLoss, *loss, 0.0001)
+		})
+	}
+}
+
+func TestSelectIperfBidirMetrics(t *testing.T) {
+	const tcpEnd = `{"event":"end","data":{"sum_sent":{"bits_per_second":40000000},"sum_received":{"bits_per_second":39000000},"sum_sent_bidir_reverse":{"bits_per_second":91000000},"sum_received_bidir_reverse":{"bits_per_second":90000000}}}`
+	const udpEnd = `{"end":{"sum_sent":{"bits_per_second":50000000},"sum_received":{"bits_per_second":48000000,"jitter_ms":0.8,"lost_percent":4},"sum_sent_bidir_reverse":{"bits_per_second":100000000},"sum_received_bidir_reverse":{"bits_per_second":99000000,"jitter_ms":0.3,"lost_percent":1}}}`
+
+	tests := []struct {
+		name         string
+		output       string
+		udp          bool
+		wantDownload float64
+		wantUpload   float64
+		wantJitter   *float64
+		wantLoss     *float64
+	}{
+		{name: "tcp", output: tcpEnd, wantDownload: 90, wantUpload: 40},
+		{name: "udp", output: udpEnd, udp: true, wantDownload: 99, wantUpload: 48, wantJitter: floatPtr(0.3), wantLoss: floatPtr(1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end, err := parseIperfEnd(tt.output)
+			require.NoError(t, err)
+
+			metrics := selectIperfBidirMetrics(end, tt.udp)
+			assert.InDelta(t, tt.wantDownload, metrics.download, 0.0001)
+			assert.InDelta(t, tt.wantUpload, metrics.upload, 0.0001)
+			assert.Equal(t, tt.wantJitter, metrics.jitter)
+			assert.Equal(t, tt.wantLoss, metrics.datagramLoss)
+		})
+	}
+}
+
+func TestSelectIperfBidirIntervalSpeeds(t *testing.T) {
+	var interval struct {
+		Data iperfIntervalData `json:"data"`
+	}
+	line := `{"event":"interval","data":{"sum":{"bits_per_second":20000000},"sum_bidir_reverse":{"bits_per_second":80000000}}}`
+	require.NoError(t, json.Unmarshal([]byte(line), &interval))
+
+	download, upload := selectIperfBidirIntervalSpeeds(interval.Data)
+	assert.InDelta(t, 80, download, 0.0001)
+	assert.InDelta(t, 20, upload, 0.0001)
+}
+
+func TestIperfTestDirections(t *testing.T) {
+	both := &types.TestOptions{EnableDownload: true, EnableUpload: true}
+	uploadOnly := &types.TestOptions{EnableUpload: true}
+
+	download, upload := iperfTestDirections(config.IperfConfig{}, both)
+	assert.True(t, download)
+	assert.True(t, upload)
+
+	download, upload = iperfTestDirections(config.IperfConfig{}, uploadOnly)
+	assert.False(t, download)
+	assert.True(t, upload)
+
+	// Reverse mode only measures server to client
+	download, upload = iperfTestDirections(config.IperfConfig{Reverse: true}, both)
+	assert.True(t, download)
+	assert.False(t, upload)
+
+	download, upload = iperfTestDirections(config.IperfConfig{Reverse: true}, uploadOnly)
+	assert.True(t, download)
+	assert.False(t, upload)
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
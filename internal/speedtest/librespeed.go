@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
 	"os"
 	"os/exec"
 	"strconv"
@@ -25,6 +26,8 @@ import (
 const (
 	librespeedPublicServersURL = "https://librespeed.org/backend-servers/servers.json"
 	publicServerCacheDuration  = 30 * time.Minute
+	customServerCacheDuration  = 30 * time.Minute
+	ttfbProbeTimeout           = 10 * time.Second
 )
 
 type LibrespeedResult struct {
@@ -58,15 +61,14 @@ type Client struct {
 	Timezone string `json:"timezone"`
 }
 
-type LibrespeedServer struct {
-	ID       int    `json:"id"`
-	Name     string `json:"name"`
-	Server   string `json:"server"`
-	DlURL    string `json:"dlURL"`
-	UlURL    string `json:"ulURL"`
-	PingURL  string `json:"pingURL"`
-	GetIpURL string `json:"getIpURL"`
-}
+type LibrespeedServer = config.LibrespeedServer
+
+// Sources of the custom librespeed server list, in order of preference
+const (
+	librespeedSourceInline = "inline"
+	librespeedSourceURL    = "url"
+	librespeedSourceFile   = "file"
+)
 
 // LibrespeedPublicServer represents a server from librespeed.org public list
 type LibrespeedPublicServer struct {
@@ -84,17 +86,50 @@ type LibrespeedPublicServer struct {
 type LibrespeedRunner struct {
 	config           config.LibrespeedConfig
 	progressCallback func(types.SpeedUpdate)
+	source           SourceAddress
+	userAgent        string
 
 	// Public server cache
 	publicServerCache []LibrespeedPublicServer
 	cacheExpiry       time.Time
 	cacheMu           sync.RWMutex
+
+	// Custom server list cache, only used for servers_url
+	customServerCache []LibrespeedServer
+	customCacheExpiry time.Time
+	customServerMu    sync.Mutex
 }
 
 func NewLibrespeedRunner(cfg config.LibrespeedConfig) *LibrespeedRunner {
-	return &LibrespeedRunner{
+	r := &LibrespeedRunner{
 		config: cfg,
 	}
+
+	event := log.Info().Str("source", r.serverSource())
+	switch r.serverSource() {
+	case librespeedSourceInline:
+		event = event.Int("count", len(cfg.Servers))
+	case librespeedSourceURL:
+		event = event.Str("url", cfg.ServersURL)
+	default:
+		event = event.Str("path", cfg.ServersPath)
+	}
+	event.Msg("Using custom librespeed servers")
+
+	return r
+}
+
+// serverSource returns where the custom server list comes from: inline servers from the
+// config, then servers_url, then librespeed-servers.json
+func (r *LibrespeedRunner) serverSource() string {
+	switch {
+	case len(r.config.Servers) > 0:
+		return librespeedSourceInline
+	case r.config.ServersURL != "":
+		return librespeedSourceURL
+	default:
+		return librespeedSourceFile
+	}
 }
 
 func (r *LibrespeedRunner) GetTestType() string {
@@ -113,7 +148,25 @@ func (r *LibrespeedRunner) RunTest(ctx context.Context, opts *types.TestOptions)
 		return nil, fmt.Errorf("librespeed-cli not found: please install librespeed-cli to use this feature")
 	}
 
-	args := r.buildArgs(opts)
+	localJSON := r.config.ServersPath
+	if !opts.IsPublicServer && r.serverSource() != librespeedSourceFile {
+		// librespeed-cli only reads server lists from files, so hand it the resolved list
+		path, err := r.writeCustomServers()
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(path)
+		localJSON = path
+	}
+
+	args := r.buildArgs(opts, localJSON)
+	source, err := r.source.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	if source != nil {
+		args = append(args, "--source", source.String())
+	}
 
 	log.Debug().Strs("args", args).Msg("librespeed-cli arguments")
 
@@ -153,6 +206,16 @@ func (r *LibrespeedRunner) RunTest(ctx context.Context, opts *types.TestOptions)
 		Jitter:        librespeedResult.Jitter,
 	}
 
+	if librespeedResult.Server.URL != "" {
+		ttfb, err := measureTTFB(ctx, probeHTTPClient(source, r.userAgent, 0, true), librespeedResult.Server.URL)
+		if err != nil {
+			log.Warn().Err(err).Str("url", librespeedResult.Server.URL).Msg("failed to measure librespeed TTFB")
+		} else {
+			result.TTFB = float64(ttfb.Microseconds()) / 1000
+			log.Debug().Float64("ttfb_ms", result.TTFB).Msg("measured librespeed TTFB")
+		}
+	}
+
 	// Final completion update
 	if r.progressCallback != nil {
 		r.progressCallback(types.SpeedUpdate{
@@ -163,20 +226,56 @@ func (r *LibrespeedRunner) RunTest(ctx context.Context, opts *types.TestOptions)
 			IsComplete:  true,
 			IsScheduled: opts.IsScheduled,
 			TestType:    "librespeed",
+			RunID:       opts.RunID,
 		})
 	}
 
 	return result, nil
 }
 
-func (r *LibrespeedRunner) buildArgs(opts *types.TestOptions) []string {
+// measureTTFB issues a single GET against the test server on a fresh connection
+// and returns the time from request start until the first response byte, so DNS,
+// connect, TLS and server think time are all included. client must not reuse connections.
+func measureTTFB(ctx context.Context, client *http.Client, serverURL string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, ttfbProbeTimeout)
+	defer cancel()
+
+	var start time.Time
+	var ttfb time.Duration
+	trace := &httptrace.ClientTrace{
+		GotFirstResponseByte: func() {
+			ttfb = time.Since(start)
+		},
+	}
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, serverURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create TTFB request: %w", err)
+	}
+
+	start = time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("TTFB request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if ttfb == 0 {
+		return 0, fmt.Errorf("no response byte received from %s", serverURL)
+	}
+
+	return ttfb, nil
+}
+
+func (r *LibrespeedRunner) buildArgs(opts *types.TestOptions, localJSON string) []string {
 	args := []string{"--json"}
 
 	if opts.IsPublicServer {
 		// Keep CLI server IDs in sync with our fetched public list.
 		args = append(args, "--server-json", librespeedPublicServersURL)
 	} else {
-		args = append(args, "--local-json", r.config.ServersPath)
+		args = append(args, "--local-json", localJSON)
 	}
 
 	if len(opts.ServerIDs) > 0 {
@@ -236,21 +335,11 @@ func (r *LibrespeedRunner) GetServers() ([]ServerResponse, error) {
 	return allServers, nil
 }
 
-// getCustomServers loads servers from the local librespeed-servers.json file
+// getCustomServers returns the custom servers for the server list
 func (r *LibrespeedRunner) getCustomServers() ([]ServerResponse, error) {
-	jsonFile, err := os.Open(r.config.ServersPath)
+	servers, err := r.loadCustomServers()
 	if err != nil {
-		if os.IsNotExist(err) {
-			log.Debug().Str("path", r.config.ServersPath).Msg("librespeed-servers.json not found, skipping custom servers")
-			return []ServerResponse{}, nil
-		}
-		return nil, fmt.Errorf("failed to open librespeed-servers.json at %s: %w", r.config.ServersPath, err)
-	}
-	defer jsonFile.Close()
-
-	var servers []LibrespeedServer
-	if err := json.NewDecoder(jsonFile).Decode(&servers); err != nil {
-		return nil, fmt.Errorf("failed to decode librespeed-servers.json: %w", err)
+		return nil, err
 	}
 
 	response := make([]ServerResponse, len(servers))
@@ -269,6 +358,119 @@ func (r *LibrespeedRunner) getCustomServers() ([]ServerResponse, error) {
 	return response, nil
 }
 
+// loadCustomServers returns the custom servers from the configured source
+func (r *LibrespeedRunner) loadCustomServers() ([]LibrespeedServer, error) {
+	switch r.serverSource() {
+	case librespeedSourceInline:
+		return r.config.Servers, nil
+	case librespeedSourceURL:
+		return r.fetchCustomServers()
+	default:
+		return r.readCustomServers()
+	}
+}
+
+// readCustomServers loads servers from the local librespeed-servers.json file
+func (r *LibrespeedRunner) readCustomServers() ([]LibrespeedServer, error) {
+	jsonFile, err := os.Open(r.config.ServersPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Debug().Str("path", r.config.ServersPath).Msg("librespeed-servers.json not found, skipping custom servers")
+			return []LibrespeedServer{}, nil
+		}
+		return nil, fmt.Errorf("failed to open librespeed-servers.json at %s: %w", r.config.ServersPath, err)
+	}
+	defer jsonFile.Close()
+
+	var servers []LibrespeedServer
+	if err := json.NewDecoder(jsonFile).Decode(&servers); err != nil {
+		return nil, fmt.Errorf("failed to decode librespeed-servers.json: %w", err)
+	}
+	if err := config.ValidateLibrespeedServers(servers); err != nil {
+		return nil, fmt.Errorf("invalid librespeed-servers.json: %w", err)
+	}
+
+	return servers, nil
+}
+
+// fetchCustomServers fetches the server list from servers_url with caching. When a
+// refresh fails the last fetched list is used until the server list is reachable again.
+func (r *LibrespeedRunner) fetchCustomServers() ([]LibrespeedServer, error) {
+	r.customServerMu.Lock()
+	defer r.customServerMu.Unlock()
+
+	if r.customServerCache != nil && time.Now().Before(r.customCacheExpiry) {
+		return r.customServerCache, nil
+	}
+
+	client, err := r.httpClient(15 * time.Second)
+	if err != nil {
+		return nil, err
+	}
+	servers, err := fetchLibrespeedServers(client, r.config.ServersURL)
+	if err != nil {
+		if r.customServerCache != nil {
+			log.Warn().Err(err).Str("url", r.config.ServersURL).Msg("failed to refresh librespeed servers, using cached list")
+			return r.customServerCache, nil
+		}
+		return nil, err
+	}
+
+	r.customServerCache = servers
+	r.customCacheExpiry = time.Now().Add(customServerCacheDuration)
+
+	log.Debug().Int("count", len(servers)).Str("url", r.config.ServersURL).Msg("fetched custom librespeed servers")
+	return servers, nil
+}
+
+// fetchLibrespeedServers downloads and validates a librespeed server list
+func fetchLibrespeedServers(client *http.Client, serversURL string) ([]LibrespeedServer, error) {
+	resp, err := client.Get(serversURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch librespeed servers: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch librespeed servers: status %d", resp.StatusCode)
+	}
+
+	var servers []LibrespeedServer
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10*1024*1024)).Decode(&servers); err != nil {
+		return nil, fmt.Errorf("failed to decode librespeed servers from %s: %w", serversURL, err)
+	}
+	if err := config.ValidateLibrespeedServers(servers); err != nil {
+		return nil, fmt.Errorf("invalid librespeed servers from %s: %w", serversURL, err)
+	}
+
+	return servers, nil
+}
+
+// writeCustomServers writes the custom servers to a temporary file for librespeed-cli.
+// The caller removes the file.
+func (r *LibrespeedRunner) writeCustomServers() (string, error) {
+	servers, err := r.loadCustomServers()
+	if err != nil {
+		return "", fmt.Errorf("failed to load custom librespeed servers: %w", err)
+	}
+
+	file, err := os.CreateTemp("", "netronome-librespeed-*.json")
+	if err != nil {
+		return "", fmt.Errorf("failed to create librespeed server file: %w", err)
+	}
+	if err := json.NewEncoder(file).Encode(servers); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write librespeed server file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write librespeed server file: %w", err)
+	}
+
+	return file.Name(), nil
+}
+
 // fetchPublicServers fetches servers from librespeed.org with caching
 func (r *LibrespeedRunner) fetchPublicServers() ([]LibrespeedPublicServer, error) {
 	// Check cache first (with read lock)
@@ -284,7 +486,10 @@ func (r *LibrespeedRunner) fetchPublicServers() ([]LibrespeedPublicServer, error
 	// Fetch from librespeed.org
 	log.Debug().Str("url", librespeedPublicServersURL).Msg("fetching public librespeed servers")
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client, err := r.httpClient(15 * time.Second)
+	if err != nil {
+		return nil, err
+	}
 	resp, err := client.Get(librespeedPublicServersURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch public servers: %w", err)
@@ -313,6 +518,16 @@ func (r *LibrespeedRunner) fetchPublicServers() ([]LibrespeedPublicServer, error
 	return servers, nil
 }
 
+// httpClient returns a client for librespeed server lists that connects from the source
+// address and sends the configured User-Agent
+func (r *LibrespeedRunner) httpClient(timeout time.Duration) (*http.Client, error) {
+	source, err := r.source.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	return probeHTTPClient(source, r.userAgent, timeout, false), nil
+}
+
 // parseCountryFromName extracts country from server names like "Amsterdam, Netherlands (Clouvider)"
 func parseCountryFromName(name string) string {
 	parts := strings.Split(name, ",")
@@ -4,9 +4,18 @@
 package speedtest
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/autobrr/netronome/internal/config"
 	"github.com/autobrr/netronome/internal/types"
@@ -20,7 +29,7 @@ func TestBuildArgsUsesServerJSONForPublicServers(t *testing.T) {
 	args := runner.buildArgs(&types.TestOptions{
 		IsPublicServer: true,
 		ServerIDs:      []string{"123"},
-	})
+	}, "/tmp/local-servers.json")
 
 	assert.Equal(t, []string{
 		"--json",
@@ -37,7 +46,7 @@ func TestBuildArgsUsesLocalJSONForCustomServers(t *testing.T) {
 	args := runner.buildArgs(&types.TestOptions{
 		IsPublicServer: false,
 		ServerIDs:      []string{"42"},
-	})
+	}, "/tmp/local-servers.json")
 
 	assert.Equal(t, []string{
 		"--json",
@@ -45,3 +54,149 @@ func TestBuildArgsUsesLocalJSONForCustomServers(t *testing.T) {
 		"--server", "42",
 	}, args)
 }
+
+func TestLoadCustomServersPrefersInlineThenURLThenFile(t *testing.T) {
+	fileServers := []LibrespeedServer{{ID: 1, Name: "file", Server: "http://file.lan/"}}
+	path := filepath.Join(t.TempDir(), "librespeed-servers.json")
+	data, err := json.Marshal(fileServers)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	urlServers := []LibrespeedServer{{ID: 2, Name: "url", Server: "http://url.lan/"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(urlServers)
+	}))
+	defer server.Close()
+
+	inlineServers := []LibrespeedServer{{ID: 3, Name: "inline", Server: "http://inline.lan/"}}
+
+	tests := []struct {
+		name       string
+		cfg        config.LibrespeedConfig
+		wantSource string
+		want       []LibrespeedServer
+	}{
+		{
+			name:       "inline",
+			cfg:        config.LibrespeedConfig{ServersPath: path, ServersURL: server.URL, Servers: inlineServers},
+			wantSource: librespeedSourceInline,
+			want:       inlineServers,
+		},
+		{
+			name:       "url",
+			cfg:        config.LibrespeedConfig{ServersPath: path, ServersURL: server.URL},
+			wantSource: librespeedSourceURL,
+			want:       urlServers,
+		},
+		{
+			name:       "file",
+			cfg:        config.LibrespeedConfig{ServersPath: path},
+			wantSource: librespeedSourceFile,
+			want:       fileServers,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewLibrespeedRunner(tt.cfg)
+			assert.Equal(t, tt.wantSource, runner.serverSource())
+
+			servers, err := runner.loadCustomServers()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, servers)
+		})
+	}
+}
+
+func TestFetchCustomServersCaches(t *testing.T) {
+	var requests atomic.Int32
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"id":7,"name":"Office","server":"https://speed.example.com/","dlURL":"garbage.php"}]`))
+	}))
+	defer server.Close()
+
+	runner := NewLibrespeedRunner(config.LibrespeedConfig{ServersURL: server.URL})
+	want := []LibrespeedServer{{ID: 7, Name: "Office", Server: "https://speed.example.com/", DlURL: "garbage.php"}}
+
+	servers, err := runner.loadCustomServers()
+	require.NoError(t, err)
+	assert.Equal(t, want, servers)
+
+	servers, err = runner.loadCustomServers()
+	require.NoError(t, err)
+	assert.Equal(t, want, servers)
+	assert.EqualValues(t, 1, requests.Load(), "the list is cached")
+
+	// An expired list is still used while the server list is unreachable
+	fail.Store(true)
+	runner.customCacheExpiry = time.Now().Add(-time.Second)
+	servers, err = runner.loadCustomServers()
+	require.NoError(t, err)
+	assert.Equal(t, want, servers)
+	assert.EqualValues(t, 2, requests.Load())
+}
+
+func TestFetchCustomServersValidates(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{name: "not a list", body: `{"servers":[]}`, wantErr: "failed to decode"},
+		{name: "missing server", body: `[{"id":1,"name":"Office"}]`, wantErr: "invalid librespeed servers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			runner := NewLibrespeedRunner(config.LibrespeedConfig{ServersURL: server.URL})
+			_, err := runner.loadCustomServers()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestWriteCustomServers(t *testing.T) {
+	inline := []LibrespeedServer{{ID: 3, Name: "inline", Server: "http://inline.lan/", DlURL: "garbage.php"}}
+	runner := NewLibrespeedRunner(config.LibrespeedConfig{Servers: inline})
+
+	path, err := runner.writeCustomServers()
+	require.NoError(t, err)
+	defer os.Remove(path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"id":3,"name":"inline","server":"http://inline.lan/","dlURL":"garbage.php","ulURL":"","pingURL":"","getIpURL":""}]`, string(data))
+}
+
+func TestMeasureTTFB(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ttfb, err := measureTTFB(context.Background(), probeHTTPClient(nil, "", 0, true), server.URL)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, ttfb, 20*time.Millisecond)
+}
+
+func TestMeasureTTFBUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	_, err := measureTTFB(context.Background(), probeHTTPClient(nil, "", 0, true), url)
+	assert.Error(t, err)
+}
@@ -7,8 +7,10 @@ package speedtest
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"syscall"
+	"time"
 )
 
 // configureMTRCommand sets Unix-specific attributes for the MTR command
@@ -44,13 +46,13 @@ func killMTRProcessGroup(pid int) error {
 
 // buildMTRArgs builds Unix-specific MTR arguments
 // On Unix, we can use the -j flag for JSON output directly
-func buildMTRArgs(host string, packetCount int, privilegedMode bool, enableDNS bool) ([]string, string, error) {
+func buildMTRArgs(host string, family AddressFamily, packetCount int, interval time.Duration, packetSize int, privilegedMode bool, enableDNS bool) ([]string, string, error) {
 	args := []string{
-		"-4",                                 // Force IPv4
+		mtrFamilyFlag(family),                // Force the address family
 		"-j",                                 // JSON output
 		"-c", fmt.Sprintf("%d", packetCount), // Number of cycles
-		"-i", "1",                            // 1 second interval
 	}
+	args = append(args, mtrProbeArgs(interval, packetSize, os.Getuid() == 0)...)
 
 	if !enableDNS {
 		args = append(args, "--no-dns")
@@ -15,6 +15,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 )
 
 // configureMTRCommand sets Windows-specific attributes for the MTR command
@@ -67,9 +68,9 @@ func killMTRProcessGroup(pid int) error {
 // buildMTRArgs builds Windows-specific MTR arguments
 // Windows MTR doesn't support -j for JSON output, so we use -r for report mode
 // and -w for wide format, then capture stdout and parse it
-func buildMTRArgs(host string, packetCount int, privilegedMode bool, enableDNS bool) ([]string, string, error) {
+func buildMTRArgs(host string, family AddressFamily, packetCount int, interval time.Duration, packetSize int, privilegedMode bool, enableDNS bool) ([]string, string, error) {
 	args := []string{
-		"-4",                                 // Force IPv4
+		mtrFamilyFlag(family),                // Force the address family
 		"-r",                                 // Report mode
 		"-w",                                 // Wide report, don't truncate hostnames
 	}
@@ -80,9 +81,9 @@ func buildMTRArgs(host string, packetCount int, privilegedMode bool, enableDNS b
 
 	args = append(args,
 		"-c", fmt.Sprintf("%d", packetCount), // Number of cycles
-		"-i", "1", // 1 second interval
 		"-t", "2", // 2 second timeout per hop to prevent hanging on unresponsive hops
 	)
+	args = append(args, mtrProbeArgs(interval, packetSize, os.Getuid() == 0)...)
 
 	// Add UDP mode if not privileged (Windows MTR defaults to ICMP in privileged mode)
 	if !privilegedMode {
@@ -0,0 +1,168 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+const (
+	// StandardMTU is the Ethernet MTU; a smaller path MTU is flagged on the result
+	StandardMTU = 1500
+
+	// minProbeMTU is the smallest path MTU searched for (the IPv4 minimum reassembly size)
+	minProbeMTU = 576
+
+	mtuProbeTimeout = 2 * time.Second
+)
+
+var errMTUProbeNoReply = errors.New("no reply to the smallest MTU probe")
+
+// mtuProbeFunc sends one don't-fragment packet of the given total size and reports whether it got through
+type mtuProbeFunc func(ctx context.Context, size int) (bool, error)
+
+// discoverPathMTU binary searches for the largest packet size that passes without fragmentation
+func discoverPathMTU(ctx context.Context, probe mtuProbeFunc) (int, error) {
+	ok, err := probe(ctx, StandardMTU)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return StandardMTU, nil
+	}
+
+	ok, err = probe(ctx, minProbeMTU)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, errMTUProbeNoReply
+	}
+
+	// lo always passes, hi always fails
+	lo, hi := minProbeMTU, StandardMTU
+	for hi-lo > 1 {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		mid := (lo + hi) / 2
+		ok, err := probe(ctx, mid)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
+
+// mtuWarning explains a path MTU below the standard size, or returns "" when there is nothing to flag
+func mtuWarning(mtu int) string {
+	if mtu <= 0 || mtu >= StandardMTU {
+		return ""
+	}
+	return fmt.Sprintf("Path MTU is %d, below the standard %d; fragmentation on a PPPoE or VPN link may be limiting throughput", mtu, StandardMTU)
+}
+
+// mtuProbeHost picks the probe target: the test's server host without a port, or the configured fallback
+func (s *service) mtuProbeHost(opts *types.TestOptions) string {
+	host := opts.ServerHost
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "" {
+		s.configMu.RLock()
+		host = s.config.MTUProbeHost
+		s.configMu.RUnlock()
+	}
+	return host
+}
+
+// runMTUProbe measures the path MTU before a test when the options ask for it.
+// It returns 0 when the probe is disabled or fails, which never fails the test.
+func (s *service) runMTUProbe(ctx context.Context, opts *types.TestOptions) int {
+	if !opts.EnableMTUProbe {
+		return 0
+	}
+
+	host := s.mtuProbeHost(opts)
+	if host == "" {
+		log.Warn().Msg("MTU probe enabled but no probe host is available, skipping")
+		return 0
+	}
+	if _, err := exec.LookPath("ping"); err != nil {
+		log.Warn().Err(err).Msg("MTU probe skipped, ping command not found")
+		return 0
+	}
+
+	mtu, err := discoverPathMTU(ctx, pingMTUProbe(host))
+	if err != nil {
+		log.Warn().Err(err).Str("host", host).Msg("Path MTU probe failed, continuing with speed test")
+		return 0
+	}
+
+	log.Info().Str("host", host).Int("path_mtu", mtu).Msg("Path MTU probe completed")
+	return mtu
+}
+
+// applyPathMTU annotates a result with the probed path MTU and its warning
+func applyPathMTU(result *Result, mtu int) {
+	if mtu <= 0 {
+		return
+	}
+	result.PathMTU = mtu
+	result.MTUWarning = mtuWarning(mtu)
+}
+
+// pingMTUProbe probes with the system ping and the don't-fragment flag
+func pingMTUProbe(host string) mtuProbeFunc {
+	// ICMP header plus the IPv4 or IPv6 header
+	overhead := 28
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		overhead = 48
+	}
+
+	return func(ctx context.Context, size int) (bool, error) {
+		probeCtx, cancel := context.WithTimeout(ctx, mtuProbeTimeout+time.Second)
+		defer cancel()
+
+		cmd := exec.CommandContext(probeCtx, "ping", buildMTUProbeArgs(host, size-overhead)...)
+		if err := cmd.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) || probeCtx.Err() != nil {
+				// Too big, dropped or timed out; only a cancelled parent is an error
+				return false, ctx.Err()
+			}
+			return false, fmt.Errorf("ping failed: %w", err)
+		}
+		return true, nil
+	}
+}
+
+// buildMTUProbeArgs builds a single don't-fragment ping with the given payload size
+func buildMTUProbeArgs(host string, payload int) []string {
+	size := strconv.Itoa(payload)
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"-c", "1", "-D", "-s", size, "-W", strconv.Itoa(int(mtuProbeTimeout.Milliseconds())), host}
+	case "windows":
+		return []string{"-n", "1", "-f", "-l", size, "-w", strconv.Itoa(int(mtuProbeTimeout.Milliseconds())), host}
+	default:
+		return []string{"-c", "1", "-M", "do", "-s", size, "-W", strconv.Itoa(int(mtuProbeTimeout.Seconds())), host}
+	}
+}
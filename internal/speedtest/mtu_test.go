@@ -0,0 +1,85 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// pathWithMTU simulates a path that drops don't-fragment packets larger than mtu
+func pathWithMTU(mtu int, probes *int) mtuProbeFunc {
+	return func(ctx context.Context, size int) (bool, error) {
+		*probes++
+		return size <= mtu, nil
+	}
+}
+
+func TestDiscoverPathMTU(t *testing.T) {
+	tests := []struct {
+		name string
+		mtu  int
+	}{
+		{name: "standard ethernet", mtu: 1500},
+		{name: "pppoe", mtu: 1492},
+		{name: "wireguard", mtu: 1420},
+		{name: "ipv6 minimum", mtu: 1280},
+		{name: "smallest searched", mtu: minProbeMTU},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var probes int
+			mtu, err := discoverPathMTU(context.Background(), pathWithMTU(tt.mtu, &probes))
+			require.NoError(t, err)
+			assert.Equal(t, tt.mtu, mtu)
+			assert.LessOrEqual(t, probes, 12, "search should stay logarithmic")
+		})
+	}
+}
+
+func TestDiscoverPathMTU_NoReply(t *testing.T) {
+	var probes int
+	_, err := discoverPathMTU(context.Background(), pathWithMTU(0, &probes))
+	assert.ErrorIs(t, err, errMTUProbeNoReply)
+	assert.Equal(t, 2, probes)
+}
+
+func TestDiscoverPathMTU_ProbeError(t *testing.T) {
+	probeErr := errors.New("cancelled")
+	_, err := discoverPathMTU(context.Background(), func(ctx context.Context, size int) (bool, error) {
+		return false, probeErr
+	})
+	assert.ErrorIs(t, err, probeErr)
+}
+
+func TestApplyPathMTU(t *testing.T) {
+	result := &Result{}
+	applyPathMTU(result, 0)
+	assert.Zero(t, result.PathMTU)
+	assert.Empty(t, result.MTUWarning)
+
+	applyPathMTU(result, StandardMTU)
+	assert.Equal(t, StandardMTU, result.PathMTU)
+	assert.Empty(t, result.MTUWarning)
+
+	applyPathMTU(result, 1492)
+	assert.Equal(t, 1492, result.PathMTU)
+	assert.Contains(t, result.MTUWarning, "1492")
+}
+
+func TestMTUProbeHost(t *testing.T) {
+	s := &service{config: config.SpeedTestConfig{MTUProbeHost: "1.1.1.1"}}
+
+	assert.Equal(t, "iperf.example.com", s.mtuProbeHost(&types.TestOptions{ServerHost: "iperf.example.com:5201"}))
+	assert.Equal(t, "10.0.0.5", s.mtuProbeHost(&types.TestOptions{ServerHost: "10.0.0.5"}))
+	assert.Equal(t, "1.1.1.1", s.mtuProbeHost(&types.TestOptions{}))
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// ooklaBinary is the official Ookla CLI. The unrelated Python speedtest-cli installs under the
+// same name but lacks --format, so it fails with a CLI error rather than bad results.
+const ooklaBinary = "speedtest"
+
+// ErrOoklaLicenseNotAccepted is returned when tests run before speedtest.ookla.accept_license is set
+var ErrOoklaLicenseNotAccepted = errors.New("Ookla license not accepted: set speedtest.ookla.accept_license = true to run Ookla tests")
+
+// OoklaResult is the JSON written by `speedtest --format=json`
+type OoklaResult struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Ping      struct {
+		Jitter  float64 `json:"jitter"`
+		Latency float64 `json:"latency"`
+	} `json:"ping"`
+	Download   OoklaTransfer `json:"download"`
+	Upload     OoklaTransfer `json:"upload"`
+	PacketLoss float64       `json:"packetLoss"`
+	ISP        string        `json:"isp"`
+	Server     OoklaServer   `json:"server"`
+	Result     struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	} `json:"result"`
+}
+
+// OoklaTransfer is one direction of an Ookla test, bandwidth is in bytes per second
+type OoklaTransfer struct {
+	Bandwidth int64 `json:"bandwidth"`
+	Bytes     int64 `json:"bytes"`
+	Elapsed   int64 `json:"elapsed"`
+}
+
+// OoklaServer is a speedtest.net server as reported by the Ookla CLI
+type OoklaServer struct {
+	ID       int    `json:"id"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Country  string `json:"country"`
+}
+
+type OoklaRunner struct {
+	config           config.OoklaConfig
+	progressCallback func(types.SpeedUpdate)
+	source           SourceAddress
+}
+
+func NewOoklaRunner(cfg config.OoklaConfig) *OoklaRunner {
+	return &OoklaRunner{
+		config: cfg,
+	}
+}
+
+func (r *OoklaRunner) GetTestType() string {
+	return "ookla"
+}
+
+func (r *OoklaRunner) SetProgressCallback(callback func(types.SpeedUpdate)) {
+	r.progressCallback = callback
+}
+
+func (r *OoklaRunner) RunTest(ctx context.Context, opts *types.TestOptions) (*Result, error) {
+	log.Debug().Str("server_ids", fmt.Sprintf("%v", opts.ServerIDs)).Msg("starting Ookla test")
+
+	if _, err := exec.LookPath(ooklaBinary); err != nil {
+		return nil, fmt.Errorf("speedtest CLI not found: please install the official Ookla speedtest CLI to use this feature")
+	}
+	if !r.config.AcceptLicense {
+		return nil, ErrOoklaLicenseNotAccepted
+	}
+
+	args := r.buildArgs(opts)
+	source, err := r.source.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	if source != nil {
+		args = append(args, "--ip="+source.String())
+	}
+
+	log.Debug().Strs("args", args).Msg("Ookla speedtest arguments")
+
+	output, err := exec.CommandContext(ctx, ooklaBinary, args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			log.Error().Err(err).Str("stderr", string(exitErr.Stderr)).Msg("Ookla speedtest failed")
+			return nil, fmt.Errorf("speedtest CLI failed: %v: %s", err, string(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("speedtest CLI failed: %w", err)
+	}
+
+	log.Debug().Str("output", string(output)).Msg("Ookla speedtest output")
+
+	ooklaResult, err := parseOoklaResult(output)
+	if err != nil {
+		log.Error().Err(err).Str("output", string(output)).Msg("failed to parse Ookla speedtest output")
+		return nil, err
+	}
+
+	log.Info().Interface("result", ooklaResult).Msg("Ookla test complete")
+
+	result := ooklaResult.toResult()
+
+	// Final completion update
+	if r.progressCallback != nil {
+		r.progressCallback(types.SpeedUpdate{
+			Type:        "complete",
+			ServerName:  result.Server,
+			Speed:       result.DownloadSpeed,
+			Progress:    100,
+			IsComplete:  true,
+			IsScheduled: opts.IsScheduled,
+			TestType:    "ookla",
+			RunID:       opts.RunID,
+		})
+	}
+
+	return result, nil
+}
+
+func (r *OoklaRunner) buildArgs(opts *types.TestOptions) []string {
+	args := []string{"--format=json", "--progress=no", "--accept-license", "--accept-gdpr"}
+
+	serverID := r.config.ServerID
+	if len(opts.ServerIDs) > 0 {
+		serverID = opts.ServerIDs[0]
+	}
+	if serverID != "" {
+		args = append(args, "--server-id="+serverID)
+	}
+
+	return args
+}
+
+// parseOoklaResult decodes the CLI's JSON result, rejecting log or error lines
+func parseOoklaResult(output []byte) (*OoklaResult, error) {
+	var result OoklaResult
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse speedtest CLI output: %w", err)
+	}
+	if result.Type != "result" {
+		return nil, fmt.Errorf("speedtest CLI returned %q instead of a result", result.Type)
+	}
+	return &result, nil
+}
+
+// toResult converts an Ookla result to the shared result, bandwidth from bytes/s to Mbps
+func (o *OoklaResult) toResult() *Result {
+	server := o.Server.Name
+	if server == "" {
+		server = o.Server.Host
+	}
+
+	return &Result{
+		Timestamp:     o.Timestamp,
+		Server:        server,
+		DownloadSpeed: float64(o.Download.Bandwidth) * 8 / 1e6,
+		UploadSpeed:   float64(o.Upload.Bandwidth) * 8 / 1e6,
+		Latency:       time.Duration(o.Ping.Latency * float64(time.Millisecond)).String(),
+		Jitter:        o.Ping.Jitter,
+	}
+}
+
+// GetServers lists the speedtest.net servers the Ookla CLI would test against
+func (r *OoklaRunner) GetServers() ([]ServerResponse, error) {
+	if _, err := exec.LookPath(ooklaBinary); err != nil {
+		return nil, fmt.Errorf("speedtest CLI not found: please install the official Ookla speedtest CLI to use this feature")
+	}
+	if !r.config.AcceptLicense {
+		return nil, ErrOoklaLicenseNotAccepted
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, ooklaBinary, "--servers", "--format=json", "--accept-license", "--accept-gdpr").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Ookla servers: %w", err)
+	}
+
+	var list struct {
+		Servers []OoklaServer `json:"servers"`
+	}
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse Ookla server list: %w", err)
+	}
+
+	response := make([]ServerResponse, len(list.Servers))
+	for i, server := range list.Servers {
+		response[i] = ServerResponse{
+			ID:       strconv.Itoa(server.ID),
+			Name:     server.Location,
+			Host:     fmt.Sprintf("%s:%d", server.Host, server.Port),
+			Country:  server.Country,
+			Sponsor:  server.Name,
+			IsPublic: true,
+		}
+	}
+
+	return response, nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+const ooklaResultJSON = `{"type":"result","timestamp":"2026-10-16T10:00:00Z",
+"ping":{"jitter":1.25,"latency":8.5},
+"download":{"bandwidth":117000000,"bytes":1200000000,"elapsed":10000},
+"upload":{"bandwidth":5000000,"bytes":50000000,"elapsed":10000},
+"packetLoss":0,"isp":"Example ISP",
+"server":{"id":12345,"host":"speedtest.example.net","port":8080,"name":"Example","location":"Oslo","country":"Norway"},
+"result":{"id":"abc","url":"https://www.speedtest.net/result/c/abc"}}`
+
+func TestParseOoklaResult(t *testing.T) {
+	parsed, err := parseOoklaResult([]byte(ooklaResultJSON))
+	require.NoError(t, err)
+
+	result := parsed.toResult()
+	assert.Equal(t, "Example", result.Server)
+	assert.InDelta(t, 936.0, result.DownloadSpeed, 0.001)
+	assert.InDelta(t, 40.0, result.UploadSpeed, 0.001)
+	assert.Equal(t, "8.5ms", result.Latency)
+	assert.Equal(t, 1.25, result.Jitter)
+}
+
+func TestParseOoklaResultRejectsLogLines(t *testing.T) {
+	_, err := parseOoklaResult([]byte(`{"type":"log","level":"error","message":"Configuration - Could not retrieve or read configuration"}`))
+	assert.Error(t, err)
+
+	_, err = parseOoklaResult([]byte("Retrieving speedtest.net configuration..."))
+	assert.Error(t, err)
+}
+
+func TestOoklaBuildArgs(t *testing.T) {
+	runner := NewOoklaRunner(config.OoklaConfig{ServerID: "111", AcceptLicense: true})
+
+	assert.Equal(t, []string{
+		"--format=json", "--progress=no", "--accept-license", "--accept-gdpr",
+		"--server-id=111",
+	}, runner.buildArgs(&types.TestOptions{}))
+
+	assert.Equal(t, []string{
+		"--format=json", "--progress=no", "--accept-license", "--accept-gdpr",
+		"--server-id=222",
+	}, runner.buildArgs(&types.TestOptions{ServerIDs: []string{"222"}}))
+
+	assert.Equal(t, []string{
+		"--format=json", "--progress=no", "--accept-license", "--accept-gdpr",
+	}, NewOoklaRunner(config.OoklaConfig{}).buildArgs(&types.TestOptions{}))
+}
@@ -6,20 +6,26 @@ package speedtest
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	probing "github.com/prometheus-community/pro-bing"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/logger"
 	"github.com/autobrr/netronome/internal/notifications"
+	"github.com/autobrr/netronome/internal/telemetry"
 	"github.com/autobrr/netronome/internal/types"
 )
 
@@ -29,10 +35,17 @@ type PacketLossMonitor struct {
 	Host        string
 	Name        string
 	PacketCount int
-	Threshold   float64
+	Threshold   float64 // critical loss percentage
+	Warning     float64 // warning loss percentage, 0 disables the warning level
 	Enabled     bool
+	ProbeMode   string // types.PacketLossProbe*, empty means icmp
+	ProbePort   int    // TCP port for the tcp probe mode
+	IntervalMs  int    // Delay between ping and MTR packets, 0 means pingSendInterval
+	PacketSize  int    // ICMP payload size in bytes, 0 uses the pinger default
+	Family      AddressFamily
 	Cancel      context.CancelFunc
 	ctx         context.Context
+	source      net.IP // Local address this run's probes are sent from, nil lets the system pick
 }
 
 // PacketLossService manages packet loss monitoring
@@ -41,7 +54,8 @@ type PacketLossService struct {
 	progress      map[int64]float64   // Track current progress for each monitor
 	completed     map[int64]time.Time // Track recently completed tests
 	mtrData       map[int64]string    // Store MTR JSON data temporarily
-	mtrPrivileged map[int64]bool      // Track if MTR ran in privileged mode
+	runPrivileged map[int64]bool      // Track if the last MTR or ping run used privileged mode
+	probedIP      map[int64]string    // Address the last MTR, ping or TCP run actually probed
 	mu            sync.RWMutex
 	db            database.Service
 	notifier      *notifications.Notifier
@@ -49,13 +63,160 @@ type PacketLossService struct {
 	scheduler     interface {
 		UpdateMonitorSchedule(monitorID int64, interval string) error
 	}
-	maxConcurrent  int
+	// slots bounds how many tests run at once (packetloss.max_concurrent_monitors),
+	// whether started manually or by the scheduler
+	slots          *slotQueue
 	privilegedMode bool
 	enableDNS      bool
+	timeoutMargin  time.Duration
+	sourceAddress  SourceAddress
+
+	// completedWindow is how long a finished test reports as completed, completedRetention
+	// how long completion times are kept before being pruned
+	completedWindow    time.Duration
+	completedRetention time.Duration
+}
+
+const (
+	// pingSendInterval is the delay between ICMP echo requests
+	pingSendInterval = 1 * time.Second
+	// pingRTTBudget is how long to wait for the reply to the final packet
+	pingRTTBudget = 2 * time.Second
+	// pingFinishGrace lets the pinger report its own statistics before the run is abandoned
+	pingFinishGrace = 2 * time.Second
+
+	defaultCompletedWindow    = 5 * time.Second
+	defaultCompletedRetention = time.Minute
+
+	// tcpProbeInterval is the delay between TCP connection attempts
+	tcpProbeInterval = 1 * time.Second
+	// tcpProbeTimeout bounds each TCP connection attempt
+	tcpProbeTimeout = 2 * time.Second
+)
+
+// sendInterval returns the delay between ping and MTR packets
+func (m *PacketLossMonitor) sendInterval() time.Duration {
+	if m.IntervalMs > 0 {
+		return time.Duration(m.IntervalMs) * time.Millisecond
+	}
+	return pingSendInterval
+}
+
+// icmpHeaderOverhead is the IPv4 and ICMP header size, which MTR counts in its packet size
+const icmpHeaderOverhead = 28
+
+// mtrMinUserInterval is the shortest interval MTR accepts when not run as root
+const mtrMinUserInterval = time.Second
+
+// mtrProbeArgs returns the MTR interval and packet size arguments. MTR's size includes
+// the headers, so the ICMP payload size is converted. MTR rejects sub-second intervals
+// from non-root users, so those are raised to a second unless root is set.
+func mtrProbeArgs(interval time.Duration, packetSize int, root bool) []string {
+	if !root && interval < mtrMinUserInterval {
+		interval = mtrMinUserInterval
+	}
+	args := []string{"-i", strconv.FormatFloat(interval.Seconds(), 'f', -1, 64)}
+	if packetSize > 0 {
+		args = append(args, "-s", strconv.Itoa(packetSize+icmpHeaderOverhead))
+	}
+	return args
+}
+
+// packetLossFamily parses a stored address family, falling back to auto for values
+// written before the option existed
+func packetLossFamily(value string) AddressFamily {
+	family, err := ParseAddressFamily(value)
+	if err != nil {
+		return AddressFamilyAuto
+	}
+	return family
+}
+
+// pingNetwork returns the pro-bing network for an address family
+func pingNetwork(family AddressFamily) string {
+	switch family {
+	case AddressFamilyIPv4:
+		return "ip4"
+	case AddressFamilyIPv6:
+		return "ip6"
+	default:
+		return "ip"
+	}
+}
+
+// tcpNetwork returns the dial network for an address family
+func tcpNetwork(family AddressFamily) string {
+	switch family {
+	case AddressFamilyIPv4:
+		return "tcp4"
+	case AddressFamilyIPv6:
+		return "tcp6"
+	default:
+		return "tcp"
+	}
+}
+
+// mtrFamily returns the family MTR probes for host. MTR has always been forced to IPv4,
+// so auto keeps doing that unless the host is an IPv6 literal.
+func mtrFamily(family AddressFamily, host string) AddressFamily {
+	if family == AddressFamilyAuto {
+		if ip := net.ParseIP(host); ip != nil {
+			return ipFamily(ip)
+		}
+		return AddressFamilyIPv4
+	}
+	return family
+}
+
+// withMTRSource makes MTR send its probes from source, when one is set
+func withMTRSource(args []string, source net.IP) []string {
+	if source == nil {
+		return args
+	}
+	return append([]string{"--address", source.String()}, args...)
+}
+
+// mtrFamilyFlag returns the MTR flag that forces an address family
+func mtrFamilyFlag(family AddressFamily) string {
+	if family == AddressFamilyIPv6 {
+		return "-6"
+	}
+	return "-4"
+}
+
+// resolveProbeIP resolves host to the first address of the given family
+func resolveProbeIP(ctx context.Context, host string, family AddressFamily) (net.IP, error) {
+	network := pingNetwork(family)
+	if ip := net.ParseIP(host); ip != nil {
+		if ipFamily(ip) != family && family != AddressFamilyAuto {
+			return nil, fmt.Errorf("%s is not an %s address", host, family)
+		}
+		return ip, nil
+	}
+	ips, err := net.DefaultResolver.LookupIP(ctx, network, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no %s address found for %s", family, host)
+	}
+	return ips[0], nil
+}
+
+// pingTimeout returns how long a ping test may run: the time to send every packet,
+// the reply budget for the last one, and the configured margin
+func pingTimeout(packetCount int, interval, margin time.Duration) time.Duration {
+	if packetCount < 1 {
+		packetCount = 1
+	}
+	if margin < 0 {
+		margin = 0
+	}
+	return time.Duration(packetCount)*interval + pingRTTBudget + margin
 }
 
 // NewPacketLossService creates a new packet loss monitoring service
-func NewPacketLossService(db database.Service, notifier *notifications.Notifier, broadcast func(types.PacketLossUpdate), maxConcurrent int, privilegedMode bool, enableDNS bool) *PacketLossService {
+func NewPacketLossService(db database.Service, notifier *notifications.Notifier, broadcast func(types.PacketLossUpdate), maxConcurrent int, privilegedMode bool, enableDNS bool, timeoutMargin time.Duration) *PacketLossService {
 	if maxConcurrent <= 0 {
 		maxConcurrent = 10
 	}
@@ -64,14 +225,36 @@ func NewPacketLossService(db database.Service, notifier *notifications.Notifier,
 		progress:       make(map[int64]float64),
 		completed:      make(map[int64]time.Time),
 		mtrData:        make(map[int64]string),
-		mtrPrivileged:  make(map[int64]bool),
+		runPrivileged:  make(map[int64]bool),
+		probedIP:       make(map[int64]string),
 		db:             db,
 		notifier:       notifier,
 		broadcast:      broadcast,
-		maxConcurrent:  maxConcurrent,
+		slots:          newSlotQueue(maxConcurrent),
 		privilegedMode: privilegedMode,
 		enableDNS:      enableDNS,
+		timeoutMargin:  timeoutMargin,
+
+		completedWindow:    defaultCompletedWindow,
+		completedRetention: defaultCompletedRetention,
+	}
+}
+
+// SetCompletionWindows sets how long a finished test is reported as completed and how
+// long completion times are kept. Retention is raised to the window if shorter, so a
+// completion is never pruned while it should still be reported.
+func (s *PacketLossService) SetCompletionWindows(window, retention time.Duration) {
+	if window <= 0 {
+		window = defaultCompletedWindow
 	}
+	if retention < window {
+		retention = window
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.completedWindow = window
+	s.completedRetention = retention
 }
 
 // SetBroadcast sets the broadcast function for the service
@@ -81,6 +264,13 @@ func (s *PacketLossService) SetBroadcast(broadcast func(types.PacketLossUpdate))
 	s.broadcast = broadcast
 }
 
+// SetSourceAddress sets the interface or local address probes are sent from
+func (s *PacketLossService) SetSourceAddress(source SourceAddress) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sourceAddress = source
+}
+
 // SetScheduler sets the scheduler for the service
 func (s *PacketLossService) SetScheduler(scheduler interface {
 	UpdateMonitorSchedule(monitorID int64, interval string) error
@@ -100,14 +290,15 @@ func (s *PacketLossService) StartMonitor(monitorID int64) error {
 		return fmt.Errorf("monitor %d is already running", monitorID)
 	}
 
-	// Check concurrent limit
-	if len(s.monitors) >= s.maxConcurrent {
-		return fmt.Errorf("maximum concurrent monitors (%d) reached", s.maxConcurrent)
+	// Check concurrent limit, shared with scheduled tests
+	if !s.slots.tryAcquire(time.Time{}) {
+		return fmt.Errorf("maximum concurrent monitors (%d) reached", cap(s.slots.slots))
 	}
 
 	// Get monitor config from database
 	monitorConfig, err := s.db.GetPacketLossMonitor(monitorID)
 	if err != nil {
+		s.slots.release()
 		return fmt.Errorf("failed to get monitor config: %w", err)
 	}
 
@@ -118,8 +309,14 @@ func (s *PacketLossService) StartMonitor(monitorID int64) error {
 		Host:        monitorConfig.Host,
 		Name:        monitorConfig.Name,
 		PacketCount: monitorConfig.PacketCount,
-		Threshold:   monitorConfig.Threshold,
+		Threshold:   criticalThreshold(monitorConfig),
+		Warning:     monitorConfig.WarningThreshold,
 		Enabled:     true,
+		ProbeMode:   monitorConfig.ProbeMode,
+		ProbePort:   monitorConfig.ProbePort,
+		IntervalMs:  monitorConfig.IntervalMs,
+		PacketSize:  monitorConfig.PacketSize,
+		Family:      packetLossFamily(monitorConfig.AddressFamily),
 		Cancel:      cancel,
 		ctx:         ctx,
 	}
@@ -164,8 +361,10 @@ func (s *PacketLossService) StopMonitor(monitorID int64) error {
 	return nil
 }
 
-// runMonitor runs a single test for manual start
+// runMonitor runs a single test for manual start, freeing the slot StartMonitor took
 func (s *PacketLossService) runMonitor(monitor *PacketLossMonitor) {
+	defer s.slots.release()
+
 	log.Info().
 		Int64("monitorID", monitor.ID).
 		Str("host", monitor.Host).
@@ -211,29 +410,229 @@ func (s *PacketLossService) runSingleTest(monitor *PacketLossMonitor) {
 		})
 	}
 
-	// Try MTR first if available
+	s.mu.RLock()
+	sourceAddress := s.sourceAddress
+	s.mu.RUnlock()
+	source, err := sourceAddress.Resolve()
+	if err != nil {
+		log.Error().
+			Err(err).
+			Int64("monitorID", monitor.ID).
+			Str("host", monitor.Host).
+			Msg("Failed to resolve packet loss source address")
+		s.broadcastTestError(monitor, fmt.Sprintf("Failed to resolve source address: %v", err))
+		return
+	}
+	monitor.source = source
+
+	if monitor.ProbeMode == types.PacketLossProbeTCP {
+		s.processResults(monitor, s.runTCPTest(monitor))
+		return
+	}
+
+	// Try MTR first if available; the udp mode forces MTR's UDP probes. Only icmp
+	// falls back to ping, since an ICMP ping reports false 100% loss on the hosts
+	// that udp mode exists for
+	udpMode := monitor.ProbeMode == types.PacketLossProbeUDP
 	if s.checkMTRAvailable() {
 		log.Info().
 			Int64("monitorID", monitor.ID).
 			Str("host", monitor.Host).
 			Msg("MTR is available, attempting MTR test")
 
-		if result, err := s.runMTRTest(monitor); err == nil {
+		result, err := s.runMTRTest(monitor)
+		if err == nil {
 			s.processResults(monitor, result)
 			return
-		} else {
-			log.Warn().
+		}
+		if udpMode {
+			log.Error().
 				Err(err).
 				Int64("monitorID", monitor.ID).
 				Str("host", monitor.Host).
-				Msg("MTR test failed, falling back to ping")
+				Msg("MTR UDP test failed")
+			s.broadcastTestError(monitor, fmt.Sprintf("MTR UDP test failed: %v", err))
+			return
 		}
+		log.Warn().
+			Err(err).
+			Int64("monitorID", monitor.ID).
+			Str("host", monitor.Host).
+			Msg("MTR test failed, falling back to ping")
+	} else if udpMode {
+		log.Error().
+			Int64("monitorID", monitor.ID).
+			Str("host", monitor.Host).
+			Msg("UDP probe mode requires MTR, which is not available")
+		s.broadcastTestError(monitor, "UDP probe mode requires MTR to be installed")
+		return
 	}
 
 	// Fall back to regular ping test
 	s.runPingTest(monitor)
 }
 
+// broadcastTestError ends a test run without a result and reports why
+func (s *PacketLossService) broadcastTestError(monitor *PacketLossMonitor, message string) {
+	s.mu.Lock()
+	delete(s.progress, monitor.ID)
+	s.mu.Unlock()
+
+	if s.broadcast != nil {
+		s.broadcast(types.PacketLossUpdate{
+			Type:       "packetloss",
+			MonitorID:  monitor.ID,
+			Host:       monitor.Host,
+			IsRunning:  false,
+			IsComplete: true,
+			Error:      message,
+		})
+	}
+}
+
+// runTCPTest probes hosts that block ICMP by opening and closing a TCP connection per
+// packet. Completed handshakes count as received, anything else as lost.
+func (s *PacketLossService) runTCPTest(monitor *PacketLossMonitor) *probing.Statistics {
+	port := monitor.ProbePort
+	if port == 0 {
+		port = types.DefaultPacketLossProbePort
+	}
+	address := net.JoinHostPort(monitor.Host, strconv.Itoa(port))
+
+	ctx := monitor.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	log.Info().
+		Int64("monitorID", monitor.ID).
+		Str("address", address).
+		Str("family", string(monitor.Family)).
+		Int("packetCount", monitor.PacketCount).
+		Msg("Running TCP packet loss test")
+
+	// TCP connects need no raw sockets
+	s.mu.Lock()
+	s.runPrivileged[monitor.ID] = false
+	s.mu.Unlock()
+
+	// A source of the other family than the monitor asks for is left to the system. With
+	// auto, the dialer only tries the host's addresses of the source's family.
+	source := monitor.source
+	if source != nil && monitor.Family != AddressFamilyAuto && ipFamily(source) != monitor.Family {
+		source = nil
+	}
+	dialer := sourceDialer(source, tcpProbeTimeout)
+	hotLog := logger.Sampled()
+	rtts := make([]time.Duration, 0, monitor.PacketCount)
+	sent := 0
+
+	for sent < monitor.PacketCount {
+		if sent > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(tcpProbeInterval):
+			}
+		}
+		if ctx.Err() != nil {
+			log.Warn().Int64("monitorID", monitor.ID).Msg("TCP packet loss test cancelled")
+			break
+		}
+
+		sent++
+		start := time.Now()
+		conn, err := dialer.DialContext(ctx, tcpNetwork(monitor.Family), address)
+		if err == nil {
+			rtts = append(rtts, time.Since(start))
+			if len(rtts) == 1 {
+				if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+					s.mu.Lock()
+					s.probedIP[monitor.ID] = addr.IP.String()
+					s.mu.Unlock()
+				}
+			}
+			conn.Close()
+		}
+
+		hotLog.Debug().
+			Int64("monitorID", monitor.ID).
+			Int("seq", sent).
+			Bool("connected", err == nil).
+			Msg("TCP probe")
+
+		progress := float64(sent) / float64(monitor.PacketCount) * 100
+		s.mu.Lock()
+		s.progress[monitor.ID] = progress
+		s.mu.Unlock()
+
+		if s.broadcast != nil {
+			s.broadcast(types.PacketLossUpdate{
+				Type:        "packetloss",
+				MonitorID:   monitor.ID,
+				Host:        monitor.Host,
+				IsRunning:   true,
+				IsComplete:  false,
+				Progress:    progress,
+				PacketsSent: sent,
+				PacketsRecv: len(rtts),
+			})
+		}
+	}
+
+	return tcpStatistics(sent, rtts)
+}
+
+// tcpStatistics summarizes TCP connect times the way pro-bing summarizes ping replies
+func tcpStatistics(sent int, rtts []time.Duration) *probing.Statistics {
+	stats := &probing.Statistics{
+		PacketsSent: sent,
+		PacketsRecv: len(rtts),
+		PacketLoss:  100,
+	}
+	if sent > 0 {
+		stats.PacketLoss = float64(sent-len(rtts)) / float64(sent) * 100
+	}
+	if len(rtts) == 0 {
+		return stats
+	}
+
+	var total time.Duration
+	stats.MinRtt = rtts[0]
+	for _, rtt := range rtts {
+		total += rtt
+		stats.MinRtt = min(stats.MinRtt, rtt)
+		stats.MaxRtt = max(stats.MaxRtt, rtt)
+	}
+	stats.AvgRtt = total / time.Duration(len(rtts))
+
+	var variance float64
+	for _, rtt := range rtts {
+		diff := float64(rtt - stats.AvgRtt)
+		variance += diff * diff
+	}
+	stats.StdDevRtt = time.Duration(math.Sqrt(variance / float64(len(rtts))))
+	stats.Rtts = rtts
+
+	return stats
+}
+
+// meanJitter returns the mean absolute difference between consecutive RTTs in ms
+func meanJitter(rtts []time.Duration) float64 {
+	if len(rtts) < 2 {
+		return 0
+	}
+
+	var total time.Duration
+	for i := 1; i < len(rtts); i++ {
+		diff := rtts[i] - rtts[i-1]
+		if diff < 0 {
+			diff = -diff
+		}
+		total += diff
+	}
+	return float64(total) / float64(len(rtts)-1) / float64(time.Millisecond)
+}
+
 // checkMTRAvailable checks if MTR is available on the system
 func (s *PacketLossService) checkMTRAvailable() bool {
 	_, err := exec.LookPath("mtr")
@@ -242,9 +641,13 @@ func (s *PacketLossService) checkMTRAvailable() bool {
 
 // runPingTest runs a traditional ping-based packet loss test
 func (s *PacketLossService) runPingTest(monitor *PacketLossMonitor) {
+	// One guard covers both attempts so a privileged attempt that completed can't be
+	// completed again by the unprivileged fallback
+	guard := &completionGuard{}
+
 	// Try privileged mode first if configured
 	if s.privilegedMode {
-		if err := s.runPingWithPrivilege(monitor, true); err == nil {
+		if err := s.runPingWithPrivilege(monitor, true, guard); err == nil {
 			return // Success
 		} else if strings.Contains(err.Error(), "operation not permitted") {
 			log.Warn().
@@ -254,26 +657,52 @@ func (s *PacketLossService) runPingTest(monitor *PacketLossMonitor) {
 				Msg("Privileged ping failed, trying unprivileged mode")
 
 			// Try unprivileged mode
-			if err := s.runPingWithPrivilege(monitor, false); err == nil {
+			if err := s.runPingWithPrivilege(monitor, false, guard); err == nil {
 				return // Success with unprivileged
 			}
 			// If unprivileged also failed, let it fail naturally
 			// The error has already been handled in runPingWithPrivilege
 		} else {
 			// Other error in privileged mode, try unprivileged as fallback
-			s.runPingWithPrivilege(monitor, false)
+			s.runPingWithPrivilege(monitor, false, guard)
 		}
 	} else {
 		// Not using privileged mode, run unprivileged directly
-		s.runPingWithPrivilege(monitor, false)
+		s.runPingWithPrivilege(monitor, false, guard)
+	}
+}
+
+// completionGuard makes a test complete once: its results are processed and the
+// completion broadcast sent by whichever completion path gets there first
+type completionGuard struct {
+	once sync.Once
+}
+
+// complete runs fn unless the test already completed and reports whether it ran
+func (g *completionGuard) complete(fn func()) bool {
+	ran := false
+	g.once.Do(func() {
+		fn()
+		ran = true
+	})
+	return ran
+}
+
+// finishTest processes the results of a test unless another completion path already did
+func (s *PacketLossService) finishTest(guard *completionGuard, monitor *PacketLossMonitor, stats *probing.Statistics) {
+	if !guard.complete(func() { s.processResults(monitor, stats) }) {
+		log.Debug().
+			Int64("monitorID", monitor.ID).
+			Msg("Packet loss test already completed, ignoring duplicate completion")
 	}
 }
 
 // runPingWithPrivilege runs the ping test with specified privilege mode
-func (s *PacketLossService) runPingWithPrivilege(monitor *PacketLossMonitor, usePrivileged bool) error {
+func (s *PacketLossService) runPingWithPrivilege(monitor *PacketLossMonitor, usePrivileged bool, guard *completionGuard) error {
 	// Create a new pinger for this test
-	pinger, err := probing.NewPinger(monitor.Host)
-	if err != nil {
+	pinger := probing.New(monitor.Host)
+	pinger.SetNetwork(pingNetwork(monitor.Family))
+	if err := pinger.Resolve(); err != nil {
 		log.Error().
 			Err(err).
 			Int64("monitorID", monitor.ID).
@@ -282,36 +711,54 @@ func (s *PacketLossService) runPingWithPrivilege(monitor *PacketLossMonitor, use
 
 		// Broadcast error
 		if s.broadcast != nil {
-			s.broadcast(types.PacketLossUpdate{
-				Type:       "packetloss",
-				MonitorID:  monitor.ID,
-				Host:       monitor.Host,
-				IsRunning:  false,
-				IsComplete: true,
-				Error:      fmt.Sprintf("Failed to create pinger: %v", err),
+			guard.complete(func() {
+				s.broadcast(types.PacketLossUpdate{
+					Type:       "packetloss",
+					MonitorID:  monitor.ID,
+					Host:       monitor.Host,
+					IsRunning:  false,
+					IsComplete: true,
+					Error:      fmt.Sprintf("Failed to create pinger: %v", err),
+				})
 			})
 		}
 		return err
 	}
 
 	// Configure pinger
-	pinger.Interval = 1 * time.Second // Send one packet per second
+	pinger.Interval = monitor.sendInterval()
+	if monitor.PacketSize > 0 {
+		pinger.Size = monitor.PacketSize
+	}
 	pinger.Count = monitor.PacketCount
-	pinger.Timeout = time.Duration(monitor.PacketCount*2) * time.Second // 2 seconds per packet timeout
+	pinger.Timeout = pingTimeout(monitor.PacketCount, pinger.Interval, s.timeoutMargin)
 	pinger.SetPrivileged(usePrivileged)
+	if source := sourceFor(monitor.source, pinger.IPAddr().IP); source != nil {
+		pinger.Source = source.String()
+	}
 
 	log.Info().
 		Int64("monitorID", monitor.ID).
 		Str("host", monitor.Host).
+		Str("ip", pinger.IPAddr().String()).
 		Bool("privilegedMode", usePrivileged).
+		Dur("interval", pinger.Interval).
+		Int("size", pinger.Size).
 		Msg("Configured pinger")
 
+	// Store the mode this attempt runs in so the result records it
+	s.mu.Lock()
+	s.runPrivileged[monitor.ID] = usePrivileged
+	s.probedIP[monitor.ID] = pinger.IPAddr().IP.String()
+	s.mu.Unlock()
+
 	// Create results channel for this test
 	results := make(chan *probing.Statistics, 1)
 
 	// Set up callbacks
 	packetsReceived := 0
 	packetsSent := 0
+	hotLog := logger.Sampled()
 
 	// Track when packets are sent
 	pinger.OnSend = func(pkt *probing.Packet) {
@@ -323,7 +770,7 @@ func (s *PacketLossService) runPingWithPrivilege(monitor *PacketLossMonitor, use
 		s.progress[monitor.ID] = progress
 		s.mu.Unlock()
 
-		log.Debug().
+		hotLog.Debug().
 			Int64("monitorID", monitor.ID).
 			Int("seq", pkt.Seq).
 			Int("sent", packetsSent).
@@ -348,7 +795,7 @@ func (s *PacketLossService) runPingWithPrivilege(monitor *PacketLossMonitor, use
 	pinger.OnRecv = func(pkt *probing.Packet) {
 		packetsReceived++
 
-		log.Debug().
+		hotLog.Debug().
 			Int64("monitorID", monitor.ID).
 			Int("seq", pkt.Seq).
 			Dur("rtt", pkt.Rtt).
@@ -376,7 +823,7 @@ func (s *PacketLossService) runPingWithPrivilege(monitor *PacketLossMonitor, use
 	}
 
 	// Create context with timeout for goroutine management
-	timeoutDuration := time.Duration(monitor.PacketCount*2) * time.Second
+	timeoutDuration := pinger.Timeout + pingFinishGrace
 	pingerCtx, pingerCancel := context.WithTimeout(context.Background(), timeoutDuration)
 	defer pingerCancel()
 
@@ -484,7 +931,7 @@ func (s *PacketLossService) runPingWithPrivilege(monitor *PacketLossMonitor, use
 			Msg("Test completed via OnFinish callback")
 		completed = true
 		// Process results
-		s.processResults(monitor, stats)
+		s.finishTest(guard, monitor, stats)
 
 	case <-pingerCtx.Done():
 		log.Warn().
@@ -502,7 +949,7 @@ func (s *PacketLossService) runPingWithPrivilege(monitor *PacketLossMonitor, use
 			log.Info().
 				Int64("monitorID", monitor.ID).
 				Msg("Using stats from OnFinish despite timeout")
-			s.processResults(monitor, stats)
+			s.finishTest(guard, monitor, stats)
 		} else {
 			log.Warn().
 				Int64("monitorID", monitor.ID).
@@ -517,25 +964,7 @@ func (s *PacketLossService) runPingWithPrivilege(monitor *PacketLossMonitor, use
 				AvgRtt:      0,
 				StdDevRtt:   0,
 			}
-			s.processResults(monitor, timeoutStats)
-		}
-
-		// Broadcast timeout completion
-		if s.broadcast != nil {
-			packetLoss := 100.0
-			if stats != nil {
-				packetLoss = stats.PacketLoss
-			}
-			s.broadcast(types.PacketLossUpdate{
-				Type:        "packetloss",
-				MonitorID:   monitor.ID,
-				Host:        monitor.Host,
-				IsRunning:   false,
-				IsComplete:  true,
-				PacketLoss:  packetLoss,
-				PacketsSent: monitor.PacketCount,
-				PacketsRecv: 0,
-			})
+			s.finishTest(guard, monitor, timeoutStats)
 		}
 
 	case <-pingerDone:
@@ -565,7 +994,7 @@ func (s *PacketLossService) runPingWithPrivilege(monitor *PacketLossMonitor, use
 				Int64("monitorID", monitor.ID).
 				Msg("Test completed via delayed OnFinish")
 			completed = true
-			s.processResults(monitor, stats)
+			s.finishTest(guard, monitor, stats)
 		case <-time.After(1 * time.Second):
 			log.Warn().
 				Int64("monitorID", monitor.ID).
@@ -581,7 +1010,7 @@ func (s *PacketLossService) runPingWithPrivilege(monitor *PacketLossMonitor, use
 				log.Info().
 					Int64("monitorID", monitor.ID).
 					Msg("Using cached stats from OnFinish")
-				s.processResults(monitor, stats)
+				s.finishTest(guard, monitor, stats)
 			} else {
 				log.Warn().
 					Int64("monitorID", monitor.ID).
@@ -595,7 +1024,7 @@ func (s *PacketLossService) runPingWithPrivilege(monitor *PacketLossMonitor, use
 					AvgRtt:      0,
 					StdDevRtt:   0,
 				}
-				s.processResults(monitor, timeoutStats)
+				s.finishTest(guard, monitor, timeoutStats)
 			}
 		}
 	}
@@ -628,12 +1057,13 @@ func (s *PacketLossService) processResults(monitor *PacketLossMonitor, stats *pr
 		Msg("Packet loss test completed")
 
 	// Mark test as completed
+	completedAt := time.Now()
 	s.mu.Lock()
-	s.completed[monitor.ID] = time.Now()
+	s.completed[monitor.ID] = completedAt
 
-	// Clean up old completed entries (older than 1 minute)
+	// Clean up completed entries older than the retention window
 	for id, completedTime := range s.completed {
-		if time.Since(completedTime) > time.Minute {
+		if time.Since(completedTime) > s.completedRetention {
 			delete(s.completed, id)
 		}
 	}
@@ -644,6 +1074,7 @@ func (s *PacketLossService) processResults(monitor *PacketLossMonitor, stats *pr
 	hopCount := 0
 	var mtrDataStr *string
 	privilegedMode := false
+	jitter := 0.0
 
 	s.mu.RLock()
 	if mtrJSON, exists := s.mtrData[monitor.ID]; exists {
@@ -653,11 +1084,16 @@ func (s *PacketLossService) processResults(monitor *PacketLossMonitor, stats *pr
 		if len(stats.Rtts) > 0 {
 			hopCount = int(stats.Rtts[0])
 		}
-		// Get the privileged mode status
-		if priv, exists := s.mtrPrivileged[monitor.ID]; exists {
-			privilegedMode = priv
-		}
+		// MTR only reports per-hop aggregates, so the last hop's StDev stands in for jitter
+		jitter = float64(stats.StdDevRtt) / float64(time.Millisecond)
+	} else {
+		jitter = meanJitter(stats.Rtts)
 	}
+	// Get the privileged mode the MTR or ping run actually used
+	if priv, exists := s.runPrivileged[monitor.ID]; exists {
+		privilegedMode = priv
+	}
+	probedIP := s.probedIP[monitor.ID]
 	s.mu.RUnlock()
 
 	// Save results to database
@@ -668,6 +1104,7 @@ func (s *PacketLossService) processResults(monitor *PacketLossMonitor, stats *pr
 		MaxRTT:         float64(stats.MaxRtt.Milliseconds()),
 		AvgRTT:         float64(stats.AvgRtt.Milliseconds()),
 		StdDevRTT:      float64(stats.StdDevRtt.Milliseconds()),
+		Jitter:         jitter,
 		PacketsSent:    stats.PacketsSent,
 		PacketsRecv:    stats.PacketsRecv,
 		UsedMTR:        usedMTR,
@@ -676,13 +1113,28 @@ func (s *PacketLossService) processResults(monitor *PacketLossMonitor, stats *pr
 		PrivilegedMode: privilegedMode,
 		CreatedAt:      time.Now(),
 	}
+	if probedIP != "" {
+		result.ProbedIP = &probedIP
+	}
 
-	// Clean up MTR data after use
-	if usedMTR {
-		s.mu.Lock()
-		delete(s.mtrData, monitor.ID)
-		delete(s.mtrPrivileged, monitor.ID)
-		s.mu.Unlock()
+	// Clean up per-run data after use
+	s.mu.Lock()
+	delete(s.mtrData, monitor.ID)
+	delete(s.runPrivileged, monitor.ID)
+	delete(s.probedIP, monitor.ID)
+	s.mu.Unlock()
+
+	// Read the previous route length before this result becomes the latest one
+	previousHops := 0
+	if s.notifier != nil && s.db != nil && hopCount > 0 {
+		hops, err := s.db.GetLatestPacketLossHopCount(monitor.ID)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Int64("monitorID", monitor.ID).
+				Msg("Failed to get previous hop count")
+		}
+		previousHops = hops
 	}
 
 	if s.db != nil {
@@ -697,23 +1149,32 @@ func (s *PacketLossService) processResults(monitor *PacketLossMonitor, stats *pr
 	// Broadcast complete update
 	if s.broadcast != nil {
 		s.broadcast(types.PacketLossUpdate{
-			Type:        "packetloss",
-			MonitorID:   monitor.ID,
-			Host:        monitor.Host,
-			IsRunning:   false,
-			IsComplete:  true,
-			PacketLoss:  stats.PacketLoss,
-			MinRTT:      result.MinRTT,
-			MaxRTT:      result.MaxRTT,
-			AvgRTT:      result.AvgRTT,
-			StdDevRTT:   result.StdDevRTT,
-			PacketsSent: stats.PacketsSent,
-			PacketsRecv: stats.PacketsRecv,
-			UsedMTR:     usedMTR,
-			HopCount:    hopCount,
+			Type:           "packetloss",
+			MonitorID:      monitor.ID,
+			Host:           monitor.Host,
+			IsRunning:      false,
+			IsComplete:     true,
+			PacketLoss:     stats.PacketLoss,
+			MinRTT:         result.MinRTT,
+			MaxRTT:         result.MaxRTT,
+			AvgRTT:         result.AvgRTT,
+			StdDevRTT:      result.StdDevRTT,
+			Jitter:         result.Jitter,
+			PacketsSent:    stats.PacketsSent,
+			PacketsRecv:    stats.PacketsRecv,
+			UsedMTR:        usedMTR,
+			HopCount:       hopCount,
+			PrivilegedMode: privilegedMode,
+			ProbedIP:       probedIP,
+			CompletedAt:    &completedAt,
 		})
 	}
 
+	// A changed route length often means a reroute even when latency looks the same
+	if s.notifier != nil && previousHops > 0 && previousHops != hopCount {
+		s.sendHopCountChangeNotification(monitor, previousHops, hopCount)
+	}
+
 	// Check notification conditions and track state
 	if s.notifier != nil && s.db != nil {
 		// Get full monitor from database to check state
@@ -726,15 +1187,7 @@ func (s *PacketLossService) processResults(monitor *PacketLossMonitor, stats *pr
 			return
 		}
 
-		// Determine current state
-		var currentState string
-		if stats.PacketLoss >= 100.0 {
-			currentState = "down"
-		} else if stats.PacketLoss > monitor.Threshold {
-			currentState = "threshold_exceeded"
-		} else {
-			currentState = "ok"
-		}
+		currentState := packetLossState(stats.PacketLoss, monitor)
 
 		// Get previous state from database
 		previousState := dbMonitor.LastState
@@ -744,16 +1197,8 @@ func (s *PacketLossService) processResults(monitor *PacketLossMonitor, stats *pr
 
 		// Determine state transitions and send appropriate notifications
 		if previousState != currentState {
-			// State has changed
-			if currentState == "down" {
-				// Monitor went down
-				s.sendPacketLossNotification(monitor, stats, database.NotificationEventPacketLossDown)
-			} else if currentState == "threshold_exceeded" {
-				// Threshold exceeded (but not down)
-				s.sendPacketLossNotification(monitor, stats, database.NotificationEventPacketLossHigh)
-			} else if currentState == "ok" && (previousState == "down" || previousState == "threshold_exceeded") {
-				// Monitor recovered
-				s.sendPacketLossNotification(monitor, stats, database.NotificationEventPacketLossRecovered)
+			if eventType := packetLossTransitionEvent(previousState, currentState); eventType != "" {
+				s.sendPacketLossNotification(monitor, stats, eventType)
 			}
 
 			// Update state in database
@@ -768,6 +1213,70 @@ func (s *PacketLossService) processResults(monitor *PacketLossMonitor, stats *pr
 	}
 }
 
+// Packet loss monitor states, from best to worst. The state is persisted as
+// last_state so transitions survive restarts.
+const (
+	packetLossStateOK       = "ok"
+	packetLossStateWarning  = "warning"
+	packetLossStateCritical = "critical"
+	packetLossStateDown     = "down"
+)
+
+// packetLossSeverity ranks states so transitions can tell escalation from recovery.
+// Unknown states, such as "unknown" before the first result, rank as ok.
+var packetLossSeverity = map[string]int{
+	packetLossStateOK:       0,
+	packetLossStateWarning:  1,
+	packetLossStateCritical: 2,
+	packetLossStateDown:     3,
+}
+
+// criticalThreshold returns the monitor's critical threshold, falling back to the
+// legacy single threshold for monitors saved without one
+func criticalThreshold(monitor *types.PacketLossMonitor) float64 {
+	if monitor.CriticalThreshold > 0 {
+		return monitor.CriticalThreshold
+	}
+	return monitor.Threshold
+}
+
+// packetLossState classifies a loss percentage against the monitor's thresholds
+func packetLossState(packetLoss float64, monitor *PacketLossMonitor) string {
+	switch {
+	case packetLoss >= 100.0:
+		return packetLossStateDown
+	case packetLoss > monitor.Threshold:
+		return packetLossStateCritical
+	case monitor.Warning > 0 && packetLoss > monitor.Warning:
+		return packetLossStateWarning
+	default:
+		return packetLossStateOK
+	}
+}
+
+// packetLossTransitionEvent returns the notification event for a state change, or ""
+// when none is due. Escalations notify with the new level; stepping down to a lower
+// problem level stays quiet until the monitor is fully ok again.
+func packetLossTransitionEvent(previousState, currentState string) string {
+	previous := packetLossSeverity[previousState]
+	current := packetLossSeverity[currentState]
+
+	if current > previous {
+		switch currentState {
+		case packetLossStateWarning:
+			return database.NotificationEventPacketLossWarning
+		case packetLossStateCritical:
+			return database.NotificationEventPacketLossHigh
+		case packetLossStateDown:
+			return database.NotificationEventPacketLossDown
+		}
+	}
+	if currentState == packetLossStateOK && previous > 0 {
+		return database.NotificationEventPacketLossRecovered
+	}
+	return ""
+}
+
 // sendPacketLossNotification sends a notification for packet loss events
 func (s *PacketLossService) sendPacketLossNotification(monitor *PacketLossMonitor, stats *probing.Statistics, eventType string) {
 	// Create packet loss notification data
@@ -781,7 +1290,18 @@ func (s *PacketLossService) sendPacketLossNotification(monitor *PacketLossMonito
 	isRecovered := eventType == database.NotificationEventPacketLossRecovered
 
 	// Send notification with appropriate parameters
-	if err := s.notifier.SendPacketLossNotification(monitorName, monitor.Host, stats.PacketLoss, isDown, isRecovered); err != nil {
+	var err error
+	if eventType == database.NotificationEventPacketLossWarning {
+		err = s.notifier.SendPacketLossWarningNotification(monitorName, monitor.Host, stats.PacketLoss, monitor.Warning)
+	} else {
+		err = s.notifier.SendPacketLossNotification(monitorName, monitor.Host, stats.PacketLoss, isDown, isRecovered)
+	}
+	if errors.Is(err, notifications.ErrSuppressed) {
+		log.Info().
+			Int64("monitorID", monitor.ID).
+			Str("eventType", eventType).
+			Msg("Packet loss notification suppressed during quiet hours")
+	} else if err != nil {
 		log.Error().
 			Err(err).
 			Int64("monitorID", monitor.ID).
@@ -797,6 +1317,32 @@ func (s *PacketLossService) sendPacketLossNotification(monitor *PacketLossMonito
 	}
 }
 
+// sendHopCountChangeNotification sends a notification when the MTR route length changed
+func (s *PacketLossService) sendHopCountChangeNotification(monitor *PacketLossMonitor, previousHops, currentHops int) {
+	monitorName := monitor.Name
+	if monitorName == "" {
+		monitorName = monitor.Host
+	}
+
+	if err := s.notifier.SendHopCountChangeNotification(monitorName, monitor.Host, previousHops, currentHops); errors.Is(err, notifications.ErrSuppressed) {
+		log.Info().
+			Int64("monitorID", monitor.ID).
+			Msg("Hop count change notification suppressed during quiet hours")
+	} else if err != nil {
+		log.Error().
+			Err(err).
+			Int64("monitorID", monitor.ID).
+			Msg("Failed to send hop count change notification")
+	} else {
+		log.Info().
+			Int64("monitorID", monitor.ID).
+			Str("host", monitor.Host).
+			Int("previousHops", previousHops).
+			Int("currentHops", currentHops).
+			Msg("Hop count change notification sent")
+	}
+}
+
 // GetMonitorStatus returns the current status of a monitor
 func (s *PacketLossService) GetMonitorStatus(monitorID int64) (*types.PacketLossUpdate, error) {
 	s.mu.RLock()
@@ -812,13 +1358,13 @@ func (s *PacketLossService) GetMonitorStatus(monitorID int64) (*types.PacketLoss
 	activeMonitor, isInMemory := s.monitors[monitorID]
 	progress := s.progress[monitorID]
 
-	// Check if test was recently completed (within last 5 seconds)
+	// Check if test was recently completed (within the completed window)
 	completedTime, wasCompleted := s.completed[monitorID]
-	isRecentlyCompleted := wasCompleted && time.Since(completedTime) < 5*time.Second
+	isRecentlyCompleted := wasCompleted && time.Since(completedTime) < s.completedWindow
 
 	// Quad-state logic:
 	// 1. Actively testing: in memory + has progress > 0
-	// 2. Recently completed: marked as completed within last 5 seconds
+	// 2. Recently completed: marked as completed within the completed window
 	// 3. Scheduled monitoring: enabled in DB but not actively testing
 	// 4. Disabled: not enabled in DB
 
@@ -839,11 +1385,12 @@ func (s *PacketLossService) GetMonitorStatus(monitorID int64) (*types.PacketLoss
 		if err != nil {
 			// Return completion status without results
 			return &types.PacketLossUpdate{
-				Type:       "packetloss",
-				MonitorID:  monitorID,
-				Host:       monitorConfig.Host,
-				IsRunning:  false,
-				IsComplete: true,
+				Type:        "packetloss",
+				MonitorID:   monitorID,
+				Host:        monitorConfig.Host,
+				IsRunning:   false,
+				IsComplete:  true,
+				CompletedAt: &completedTime,
 			}, nil
 		}
 
@@ -858,8 +1405,10 @@ func (s *PacketLossService) GetMonitorStatus(monitorID int64) (*types.PacketLoss
 			MinRTT:      result.MinRTT,
 			MaxRTT:      result.MaxRTT,
 			AvgRTT:      result.AvgRTT,
+			Jitter:      result.Jitter,
 			PacketsSent: result.PacketsSent,
 			PacketsRecv: result.PacketsRecv,
+			CompletedAt: &completedTime,
 		}, nil
 	} else if monitorConfig.Enabled {
 		// State 3: Scheduled monitoring - enabled but waiting for next test
@@ -887,6 +1436,7 @@ func (s *PacketLossService) GetMonitorStatus(monitorID int64) (*types.PacketLoss
 			MinRTT:      result.MinRTT,
 			MaxRTT:      result.MaxRTT,
 			AvgRTT:      result.AvgRTT,
+			Jitter:      result.Jitter,
 			PacketsSent: result.PacketsSent,
 			PacketsRecv: result.PacketsRecv,
 		}, nil
@@ -977,6 +1527,26 @@ type mtrReport struct {
 
 // runMTRTest runs an MTR test and returns statistics
 func (s *PacketLossService) runMTRTest(monitor *PacketLossMonitor) (*probing.Statistics, error) {
+	_, span := telemetry.Start(context.Background(), "packetloss.mtr",
+		attribute.Int64("packetloss.monitor_id", monitor.ID),
+		attribute.String("packetloss.host", monitor.Host),
+		attribute.String("packetloss.probe_mode", monitor.ProbeMode),
+		attribute.Int("packetloss.packet_count", monitor.PacketCount),
+	)
+	stats, err := s.runMTR(monitor)
+	if stats != nil {
+		span.SetAttributes(
+			attribute.Float64("packetloss.loss_percent", stats.PacketLoss),
+			attribute.Int("packetloss.packets_sent", stats.PacketsSent),
+			attribute.Float64("packetloss.avg_rtt_ms", float64(stats.AvgRtt)/float64(time.Millisecond)),
+		)
+	}
+	telemetry.End(span, err)
+	return stats, err
+}
+
+// runMTR runs mtr against a monitor's host and converts its report into statistics
+func (s *PacketLossService) runMTR(monitor *PacketLossMonitor) (*probing.Statistics, error) {
 	if _, err := exec.LookPath("mtr"); err != nil {
 		if runtime.GOOS == "windows" {
 			return nil, fmt.Errorf("WinMTRCmd not found: please install from https://github.com/dqos/WinMTRCmd/releases and add to PATH")
@@ -986,7 +1556,7 @@ func (s *PacketLossService) runMTRTest(monitor *PacketLossMonitor) (*probing.Sta
 
 	// Initialize GeoIP databases if not already done
 	// This is a workaround since PacketLossService doesn't have access to the main service
-	if countryDB == nil && asnDB == nil {
+	if !geoIPLoaded() {
 		log.Info().Msg("GeoIP databases not initialized for MTR. GeoIP enrichment will be unavailable.")
 		log.Info().Msg("To enable GeoIP for MTR, ensure GeoIP is configured in the [geoip] section of your config file.")
 	}
@@ -1005,20 +1575,34 @@ func (s *PacketLossService) runMTRTest(monitor *PacketLossMonitor) (*probing.Sta
 	}
 
 	// Create timeout context
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(monitor.PacketCount*6)*time.Second)
+	// Every cycle gets the packet interval plus 5s for slow hops
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(monitor.PacketCount)*(monitor.sendInterval()+5*time.Second))
 	defer cancel()
 
 	// Build platform-specific MTR command arguments
-	args, platformFlag, err := buildMTRArgs(monitor.Host, monitor.PacketCount, s.privilegedMode, s.enableDNS)
+	// The udp probe mode skips ICMP even when privileged mode is configured
+	privileged := s.privilegedMode && monitor.ProbeMode != types.PacketLossProbeUDP
+
+	// Resolve the target here so MTR probes the family the monitor asks for and the result
+	// records which address was tested
+	family := mtrFamily(monitor.Family, monitor.Host)
+	target, err := resolveProbeIP(ctx, monitor.Host, family)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s address for %s: %w", family, monitor.Host, err)
+	}
+
+	args, platformFlag, err := buildMTRArgs(target.String(), family, monitor.PacketCount, monitor.sendInterval(), monitor.PacketSize, privileged, s.enableDNS)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build MTR arguments: %w", err)
 	}
+	source := sourceFor(monitor.source, target)
+	args = withMTRSource(args, source)
 
 	// Track if we're using privileged mode
-	actuallyPrivileged := s.privilegedMode
+	actuallyPrivileged := privileged
 
 	// Log MTR mode
-	if s.privilegedMode {
+	if privileged {
 		log.Info().
 			Int64("monitorID", monitor.ID).
 			Str("host", monitor.Host).
@@ -1169,17 +1753,18 @@ func (s *PacketLossService) runMTRTest(monitor *PacketLossMonitor) (*probing.Sta
 	output, err := runMTRCommand(args, platformFlag)
 	if err != nil {
 		// If privileged mode failed, try UDP mode
-		if s.privilegedMode {
+		if privileged {
 			log.Warn().
 				Err(err).
 				Int64("monitorID", monitor.ID).
 				Msg("MTR privileged mode failed, trying UDP mode")
 
 			// Rebuild args with UDP mode for retry
-			retryArgs, retryPlatformFlag, buildErr := buildMTRArgs(monitor.Host, monitor.PacketCount, false, s.enableDNS)
+			retryArgs, retryPlatformFlag, buildErr := buildMTRArgs(target.String(), family, monitor.PacketCount, monitor.sendInterval(), monitor.PacketSize, false, s.enableDNS)
 			if buildErr != nil {
 				return nil, fmt.Errorf("failed to build retry MTR arguments: %w", buildErr)
 			}
+			retryArgs = withMTRSource(retryArgs, source)
 
 			output, err = runMTRCommand(retryArgs, retryPlatformFlag)
 			if err != nil {
@@ -1194,7 +1779,8 @@ func (s *PacketLossService) runMTRTest(monitor *PacketLossMonitor) (*probing.Sta
 
 	// Store whether this MTR test ran in privileged mode
 	s.mu.Lock()
-	s.mtrPrivileged[monitor.ID] = actuallyPrivileged
+	s.runPrivileged[monitor.ID] = actuallyPrivileged
+	s.probedIP[monitor.ID] = target.String()
 	s.mu.Unlock()
 
 	// Parse MTR JSON output
@@ -1221,6 +1807,7 @@ func (s *PacketLossService) runMTRTest(monitor *PacketLossMonitor) (*probing.Sta
 	}
 
 	// Convert hops to our format
+	hotLog := logger.Sampled()
 	for i, hop := range report.Report.Hubs {
 		mtrHop := types.MTRHop{
 			Number:     i + 1,
@@ -1256,7 +1843,7 @@ func (s *PacketLossService) runMTRTest(monitor *PacketLossMonitor) (*probing.Sta
 
 			// Debug logging
 			if mtrHop.CountryCode != "" || mtrHop.AS != "" {
-				log.Debug().
+				hotLog.Debug().
 					Str("ip", mtrHop.IP).
 					Str("countryCode", mtrHop.CountryCode).
 					Str("as", mtrHop.AS).
@@ -1304,8 +1891,24 @@ func (s *PacketLossService) runMTRTest(monitor *PacketLossMonitor) (*probing.Sta
 	return stats, nil
 }
 
-// RunScheduledTest runs a single packet loss test for a monitor called by the scheduler
-func (s *PacketLossService) RunScheduledTest(monitor *types.PacketLossMonitor) {
+// QueueStats reports how many tests wait for or hold a packetloss.max_concurrent_monitors slot
+func (s *PacketLossService) QueueStats() types.QueueStats {
+	return s.slots.stats()
+}
+
+// RunScheduledTest waits for a free slot and runs a single packet loss test for a
+// monitor. It gives up when ctx ends before a slot is free.
+func (s *PacketLossService) RunScheduledTest(ctx context.Context, monitor *types.PacketLossMonitor) {
+	if err := s.slots.wait(ctx, scheduledStartFromContext(ctx)); err != nil {
+		log.Warn().
+			Err(err).
+			Int64("monitorID", monitor.ID).
+			Str("host", monitor.Host).
+			Msg("Packet loss test gave up waiting in queue")
+		return
+	}
+	defer s.slots.release()
+
 	log.Info().
 		Int64("monitorID", monitor.ID).
 		Str("host", monitor.Host).
@@ -1321,8 +1924,14 @@ func (s *PacketLossService) RunScheduledTest(monitor *types.PacketLossMonitor) {
 		Host:        strings.TrimSpace(monitor.Host),
 		Name:        monitor.Name,
 		PacketCount: monitor.PacketCount,
-		Threshold:   monitor.Threshold,
+		Threshold:   criticalThreshold(monitor),
+		Warning:     monitor.WarningThreshold,
 		Enabled:     monitor.Enabled,
+		ProbeMode:   monitor.ProbeMode,
+		ProbePort:   monitor.ProbePort,
+		IntervalMs:  monitor.IntervalMs,
+		PacketSize:  monitor.PacketSize,
+		Family:      packetLossFamily(monitor.AddressFamily),
 		ctx:         ctx,
 		Cancel:      cancel,
 	}
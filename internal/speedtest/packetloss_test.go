@@ -0,0 +1,413 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"net"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	probing "github.com/prometheus-community/pro-bing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestPingTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		packetCount int
+		interval    time.Duration
+		margin      time.Duration
+		want        time.Duration
+	}{
+		{name: "short monitor", packetCount: 10, interval: time.Second, margin: 5 * time.Second, want: 17 * time.Second},
+		{name: "long monitor", packetCount: 300, interval: time.Second, margin: 5 * time.Second, want: 307 * time.Second},
+		{name: "slow interval", packetCount: 20, interval: 5 * time.Second, margin: 5 * time.Second, want: 107 * time.Second},
+		{name: "no margin", packetCount: 10, interval: time.Second, margin: 0, want: 12 * time.Second},
+		{name: "negative margin ignored", packetCount: 10, interval: time.Second, margin: -time.Minute, want: 12 * time.Second},
+		{name: "zero packets treated as one", packetCount: 0, interval: time.Second, margin: 5 * time.Second, want: 8 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pingTimeout(tt.packetCount, tt.interval, tt.margin)
+			assert.Equal(t, tt.want, got)
+			// The send window alone must always fit, otherwise the last packets are cut off
+			assert.Greater(t, got, time.Duration(max(tt.packetCount, 1))*tt.interval)
+		})
+	}
+}
+
+func TestMTRProbeArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		monitor    PacketLossMonitor
+		root       bool
+		want       []string
+		wantWindow time.Duration
+	}{
+		{name: "defaults", monitor: PacketLossMonitor{}, want: []string{"-i", "1"}, wantWindow: time.Second},
+		{name: "sub-second interval as root", monitor: PacketLossMonitor{IntervalMs: 200}, root: true, want: []string{"-i", "0.2"}, wantWindow: 200 * time.Millisecond},
+		{name: "sub-second interval raised for users", monitor: PacketLossMonitor{IntervalMs: 100}, want: []string{"-i", "1"}, wantWindow: 100 * time.Millisecond},
+		{name: "payload converted to packet size", monitor: PacketLossMonitor{IntervalMs: 1500, PacketSize: 1472}, want: []string{"-i", "1.5", "-s", "1500"}, wantWindow: 1500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantWindow, tt.monitor.sendInterval())
+			assert.Equal(t, tt.want, mtrProbeArgs(tt.monitor.sendInterval(), tt.monitor.PacketSize, tt.root))
+		})
+	}
+}
+
+func TestPacketLossAddressFamily(t *testing.T) {
+	tests := []struct {
+		name        string
+		stored      string
+		host        string
+		wantPing    string
+		wantTCP     string
+		wantMTRFlag string
+	}{
+		{name: "unset keeps auto", stored: "", host: "example.com", wantPing: "ip", wantTCP: "tcp", wantMTRFlag: "-4"},
+		{name: "unknown value falls back to auto", stored: "ipx", host: "example.com", wantPing: "ip", wantTCP: "tcp", wantMTRFlag: "-4"},
+		{name: "auto with IPv6 literal", stored: "auto", host: "2606:4700:4700::1111", wantPing: "ip", wantTCP: "tcp", wantMTRFlag: "-6"},
+		{name: "forced IPv4", stored: "ipv4", host: "example.com", wantPing: "ip4", wantTCP: "tcp4", wantMTRFlag: "-4"},
+		{name: "forced IPv6", stored: "IPv6", host: "example.com", wantPing: "ip6", wantTCP: "tcp6", wantMTRFlag: "-6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			family := packetLossFamily(tt.stored)
+			assert.Equal(t, tt.wantPing, pingNetwork(family))
+			assert.Equal(t, tt.wantTCP, tcpNetwork(family))
+			assert.Equal(t, tt.wantMTRFlag, mtrFamilyFlag(mtrFamily(family, tt.host)))
+
+			args, _, err := buildMTRArgs(tt.host, mtrFamily(family, tt.host), 10, time.Second, 0, true, false)
+			require.NoError(t, err)
+			assert.Contains(t, args, tt.wantMTRFlag)
+		})
+	}
+}
+
+func TestResolveProbeIPLiteral(t *testing.T) {
+	ip, err := resolveProbeIP(t.Context(), "2606:4700:4700::1111", AddressFamilyIPv6)
+	require.NoError(t, err)
+	assert.Equal(t, "2606:4700:4700::1111", ip.String())
+
+	_, err = resolveProbeIP(t.Context(), "1.1.1.1", AddressFamilyIPv6)
+	assert.Error(t, err, "an IPv4 literal can't be probed over IPv6")
+}
+
+func TestProcessResultsPrivilegedMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		privileged *bool
+		mtrData    string
+		probedIP   string
+		want       bool
+	}{
+		{name: "privileged ping", privileged: boolPtr(true), probedIP: "1.1.1.1", want: true},
+		{name: "unprivileged ping", privileged: boolPtr(false), want: false},
+		{name: "privileged mtr", privileged: boolPtr(true), mtrData: "{}", want: true},
+		{name: "unknown mode", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updates []types.PacketLossUpdate
+			s := NewPacketLossService(nil, nil, func(u types.PacketLossUpdate) {
+				updates = append(updates, u)
+			}, 1, false, false, 0)
+
+			monitor := &PacketLossMonitor{ID: 1, Host: "1.1.1.1", PacketCount: 10}
+			if tt.privileged != nil {
+				s.runPrivileged[monitor.ID] = *tt.privileged
+			}
+			if tt.mtrData != "" {
+				s.mtrData[monitor.ID] = tt.mtrData
+			}
+			if tt.probedIP != "" {
+				s.probedIP[monitor.ID] = tt.probedIP
+			}
+
+			s.processResults(monitor, &probing.Statistics{PacketsSent: 10, PacketsRecv: 10})
+
+			require.Len(t, updates, 1)
+			assert.Equal(t, tt.want, updates[0].PrivilegedMode)
+			assert.Equal(t, tt.mtrData != "", updates[0].UsedMTR)
+			assert.Equal(t, tt.probedIP, updates[0].ProbedIP)
+			assert.NotContains(t, s.runPrivileged, monitor.ID, "per-run mode should be cleared after use")
+			assert.NotContains(t, s.probedIP, monitor.ID, "probed address should be cleared after use")
+		})
+	}
+}
+
+func TestPacketLossState(t *testing.T) {
+	withWarning := &PacketLossMonitor{Threshold: 10, Warning: 2}
+	criticalOnly := &PacketLossMonitor{Threshold: 10}
+
+	tests := []struct {
+		name     string
+		loss     float64
+		monitor  *PacketLossMonitor
+		expected string
+	}{
+		{name: "no loss", loss: 0, monitor: withWarning, expected: packetLossStateOK},
+		{name: "at warning threshold", loss: 2, monitor: withWarning, expected: packetLossStateOK},
+		{name: "above warning threshold", loss: 5, monitor: withWarning, expected: packetLossStateWarning},
+		{name: "above critical threshold", loss: 20, monitor: withWarning, expected: packetLossStateCritical},
+		{name: "total loss", loss: 100, monitor: withWarning, expected: packetLossStateDown},
+		{name: "warning level disabled", loss: 5, monitor: criticalOnly, expected: packetLossStateOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, packetLossState(tt.loss, tt.monitor))
+		})
+	}
+}
+
+func TestPacketLossTransitionEvent(t *testing.T) {
+	tests := []struct {
+		previous string
+		current  string
+		expected string
+	}{
+		{previous: "unknown", current: packetLossStateOK, expected: ""},
+		{previous: packetLossStateOK, current: packetLossStateWarning, expected: database.NotificationEventPacketLossWarning},
+		{previous: packetLossStateWarning, current: packetLossStateCritical, expected: database.NotificationEventPacketLossHigh},
+		{previous: packetLossStateOK, current: packetLossStateCritical, expected: database.NotificationEventPacketLossHigh},
+		{previous: packetLossStateCritical, current: packetLossStateDown, expected: database.NotificationEventPacketLossDown},
+		{previous: "unknown", current: packetLossStateDown, expected: database.NotificationEventPacketLossDown},
+		{previous: packetLossStateDown, current: packetLossStateCritical, expected: ""},
+		{previous: packetLossStateCritical, current: packetLossStateWarning, expected: ""},
+		{previous: packetLossStateWarning, current: packetLossStateOK, expected: database.NotificationEventPacketLossRecovered},
+		{previous: packetLossStateDown, current: packetLossStateOK, expected: database.NotificationEventPacketLossRecovered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.previous+"->"+tt.current, func(t *testing.T) {
+			assert.Equal(t, tt.expected, packetLossTransitionEvent(tt.previous, tt.current))
+		})
+	}
+}
+
+func TestCriticalThresholdFallback(t *testing.T) {
+	assert.Equal(t, 7.5, criticalThreshold(&types.PacketLossMonitor{Threshold: 5, CriticalThreshold: 7.5}))
+	assert.Equal(t, 5.0, criticalThreshold(&types.PacketLossMonitor{Threshold: 5}))
+}
+
+func TestMeanJitter(t *testing.T) {
+	ms := time.Millisecond
+	assert.Zero(t, meanJitter(nil))
+	assert.Zero(t, meanJitter([]time.Duration{20 * ms}))
+	assert.Zero(t, meanJitter([]time.Duration{20 * ms, 20 * ms, 20 * ms}))
+	// |30-10| + |20-30| + |25-20| = 35 over 3 gaps
+	assert.InDelta(t, 35.0/3, meanJitter([]time.Duration{10 * ms, 30 * ms, 20 * ms, 25 * ms}), 0.0001)
+	assert.InDelta(t, 0.5, meanJitter([]time.Duration{1000 * time.Microsecond, 1500 * time.Microsecond}), 0.0001)
+}
+
+func TestProcessResultsJitter(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name    string
+		mtrData string
+		stats   *probing.Statistics
+		want    float64
+	}{
+		{
+			name:  "ping uses consecutive rtt variation",
+			stats: &probing.Statistics{PacketsSent: 3, PacketsRecv: 3, Rtts: []time.Duration{10 * ms, 14 * ms, 12 * ms}},
+			want:  3,
+		},
+		{
+			name:    "mtr uses last hop stdev",
+			mtrData: "{}",
+			stats:   &probing.Statistics{PacketsSent: 10, PacketsRecv: 10, StdDevRtt: 2500 * time.Microsecond, Rtts: []time.Duration{8}},
+			want:    2.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updates []types.PacketLossUpdate
+			s := NewPacketLossService(nil, nil, func(u types.PacketLossUpdate) {
+				updates = append(updates, u)
+			}, 1, false, false, 0)
+
+			monitor := &PacketLossMonitor{ID: 1, Host: "1.1.1.1", PacketCount: 10}
+			if tt.mtrData != "" {
+				s.mtrData[monitor.ID] = tt.mtrData
+			}
+
+			s.processResults(monitor, tt.stats)
+
+			require.Len(t, updates, 1)
+			assert.InDelta(t, tt.want, updates[0].Jitter, 0.0001)
+		})
+	}
+}
+
+func TestFinishTestRacingCompletions(t *testing.T) {
+	var mu sync.Mutex
+	var updates []types.PacketLossUpdate
+	s := NewPacketLossService(nil, nil, func(u types.PacketLossUpdate) {
+		mu.Lock()
+		updates = append(updates, u)
+		mu.Unlock()
+	}, 1, false, false, 0)
+
+	monitor := &PacketLossMonitor{ID: 1, Host: "1.1.1.1", PacketCount: 10}
+	guard := &completionGuard{}
+
+	// OnFinish, the timeout and the delayed OnFinish fallback all race to complete
+	paths := []*probing.Statistics{
+		{PacketsSent: 10, PacketsRecv: 10},
+		{PacketsSent: 10, PacketsRecv: 0, PacketLoss: 100},
+		{PacketsSent: 10, PacketsRecv: 9, PacketLoss: 10},
+	}
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		for _, stats := range paths {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				s.finishTest(guard, monitor, stats)
+			}()
+		}
+	}
+	close(start)
+	wg.Wait()
+
+	require.Len(t, updates, 1, "exactly one completion broadcast")
+	assert.True(t, updates[0].IsComplete)
+	assert.Contains(t, s.completed, monitor.ID)
+}
+
+func TestCompletionGuard(t *testing.T) {
+	guard := &completionGuard{}
+	calls := 0
+
+	assert.True(t, guard.complete(func() { calls++ }), "first completion runs")
+	assert.False(t, guard.complete(func() { calls++ }), "error broadcast after results is dropped")
+	assert.Equal(t, 1, calls)
+}
+
+// statusDB serves a single enabled monitor and its latest result for GetMonitorStatus
+type statusDB struct {
+	database.Service
+}
+
+func (statusDB) GetPacketLossMonitor(monitorID int64) (*types.PacketLossMonitor, error) {
+	return &types.PacketLossMonitor{ID: monitorID, Host: "1.1.1.1", Enabled: true}, nil
+}
+
+func (statusDB) GetLatestPacketLossResult(monitorID int64) (*types.PacketLossResult, error) {
+	return &types.PacketLossResult{MonitorID: monitorID, PacketLoss: 10}, nil
+}
+
+func TestGetMonitorStatusCompletedWindow(t *testing.T) {
+	s := NewPacketLossService(statusDB{}, nil, nil, 1, false, false, 0)
+	s.SetCompletionWindows(30*time.Second, 0)
+	assert.Equal(t, 30*time.Second, s.completedRetention, "retention is never shorter than the window")
+
+	completedAt := time.Now().Add(-10 * time.Second)
+	s.completed[1] = completedAt
+
+	status, err := s.GetMonitorStatus(1)
+	require.NoError(t, err)
+	assert.True(t, status.IsComplete, "10s old completion is inside a 30s window")
+	require.NotNil(t, status.CompletedAt)
+	assert.True(t, completedAt.Equal(*status.CompletedAt))
+
+	s.SetCompletionWindows(5*time.Second, time.Minute)
+	status, err = s.GetMonitorStatus(1)
+	require.NoError(t, err)
+	assert.False(t, status.IsComplete, "10s old completion is outside a 5s window")
+	assert.Nil(t, status.CompletedAt)
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestTCPStatistics(t *testing.T) {
+	stats := tcpStatistics(4, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond})
+	assert.Equal(t, 4, stats.PacketsSent)
+	assert.Equal(t, 3, stats.PacketsRecv)
+	assert.InDelta(t, 25.0, stats.PacketLoss, 0.001)
+	assert.Equal(t, 10*time.Millisecond, stats.MinRtt)
+	assert.Equal(t, 30*time.Millisecond, stats.MaxRtt)
+	assert.Equal(t, 20*time.Millisecond, stats.AvgRtt)
+	assert.InDelta(t, float64(8165*time.Microsecond), float64(stats.StdDevRtt), float64(time.Microsecond))
+
+	lost := tcpStatistics(5, nil)
+	assert.Equal(t, 100.0, lost.PacketLoss)
+	assert.Zero(t, lost.AvgRtt)
+}
+
+func TestRunTCPTest(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	var updates []types.PacketLossUpdate
+	s := NewPacketLossService(nil, nil, func(u types.PacketLossUpdate) {
+		updates = append(updates, u)
+	}, 1, true, false, 0)
+
+	monitor := &PacketLossMonitor{ID: 1, Host: "127.0.0.1", PacketCount: 2, ProbeMode: types.PacketLossProbeTCP, ProbePort: port}
+	stats := s.runTCPTest(monitor)
+	assert.Equal(t, 2, stats.PacketsSent)
+	assert.Equal(t, 2, stats.PacketsRecv)
+	assert.Zero(t, stats.PacketLoss)
+	require.Len(t, updates, 2)
+	assert.Equal(t, 100.0, updates[1].Progress)
+	assert.False(t, s.runPrivileged[monitor.ID], "tcp probes never run privileged")
+
+	// A closed port loses every probe
+	listener.Close()
+	monitor.PacketCount = 1
+	stats = s.runTCPTest(monitor)
+	assert.Equal(t, 1, stats.PacketsSent)
+	assert.Zero(t, stats.PacketsRecv)
+	assert.Equal(t, 100.0, stats.PacketLoss)
+}
+
+func TestRunSingleTestUDPWithoutMTR(t *testing.T) {
+	if _, err := exec.LookPath("mtr"); err == nil {
+		t.Skip("mtr is installed")
+	}
+
+	var updates []types.PacketLossUpdate
+	s := NewPacketLossService(nil, nil, func(u types.PacketLossUpdate) {
+		updates = append(updates, u)
+	}, 1, false, false, 0)
+
+	monitor := &PacketLossMonitor{ID: 1, Host: "127.0.0.1", PacketCount: 1, ProbeMode: types.PacketLossProbeUDP}
+	s.runSingleTest(monitor)
+
+	require.Len(t, updates, 2, "start and error broadcasts, no ping fallback")
+	last := updates[1]
+	assert.True(t, last.IsComplete)
+	assert.Contains(t, last.Error, "requires MTR")
+	assert.Zero(t, last.PacketsSent)
+}
@@ -6,6 +6,7 @@ package speedtest
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"regexp"
@@ -15,6 +16,8 @@ import (
 	"time"
 
 	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/config"
 )
 
 // PingResult represents the parsed output from ping command
@@ -42,11 +45,12 @@ func (s *service) RunPingTest(ctx context.Context, host string) (*PingResult, er
 
 	// Check for Docker environment indicators
 	inDocker := s.isRunningInDocker()
+	pingCfg := s.pingConfig()
 
 	log.Info().
 		Str("host", host).
-		Int("count", s.config.IPerf.Ping.Count).
-		Int("interval", s.config.IPerf.Ping.Interval).
+		Int("count", pingCfg.Count).
+		Int("interval", pingCfg.Interval).
 		Bool("in_docker", inDocker).
 		Str("os", runtime.GOOS).
 		Msg("Starting ping test")
@@ -56,18 +60,23 @@ func (s *service) RunPingTest(ctx context.Context, host string) (*PingResult, er
 		return nil, fmt.Errorf("ping command not found: %w", err)
 	}
 
+	source, err := s.source.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
 	// Build ping command based on OS
-	args := s.buildPingArgs(host)
+	args := s.buildPingArgs(host, pingCfg, source)
 
 	log.Info().
 		Str("host", host).
 		Strs("args", args).
 		Str("os", runtime.GOOS).
-		Int("timeout_seconds", s.config.IPerf.Ping.Timeout).
+		Int("timeout_seconds", pingCfg.Timeout).
 		Msg("Executing ping command")
 
 	// Create a timeout context for the ping command
-	timeout := time.Duration(s.config.IPerf.Ping.Timeout) * time.Second
+	timeout := time.Duration(pingCfg.Timeout) * time.Second
 	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
@@ -79,9 +88,9 @@ func (s *service) RunPingTest(ctx context.Context, host string) (*PingResult, er
 		if timeoutCtx.Err() == context.DeadlineExceeded {
 			log.Error().
 				Str("host", host).
-				Int("timeout_seconds", s.config.IPerf.Ping.Timeout).
+				Int("timeout_seconds", pingCfg.Timeout).
 				Msg("Ping test timed out")
-			return nil, fmt.Errorf("ping test timed out after %d seconds", s.config.IPerf.Ping.Timeout)
+			return nil, fmt.Errorf("ping test timed out after %d seconds", pingCfg.Timeout)
 		}
 		log.Error().Err(err).
 			Str("host", host).
@@ -130,16 +139,17 @@ func (s *service) RunPingTest(ctx context.Context, host string) (*PingResult, er
 	return result, nil
 }
 
-// buildPingArgs builds ping command arguments based on the operating system
-func (s *service) buildPingArgs(host string) []string {
+// buildPingArgs builds ping command arguments based on the operating system. A non-nil
+// source is the local address pings are sent from.
+func (s *service) buildPingArgs(host string, pingCfg config.PingConfig, source net.IP) []string {
 	var args []string
 
 	switch runtime.GOOS {
 	case "darwin", "linux":
 		args = []string{
-			"-c", strconv.Itoa(s.config.IPerf.Ping.Count), // packet count
-			"-i", fmt.Sprintf("%.1f", float64(s.config.IPerf.Ping.Interval)/1000), // interval in seconds
-			"-W", strconv.Itoa(s.config.IPerf.Ping.Timeout * 1000), // timeout in milliseconds
+			"-c", strconv.Itoa(pingCfg.Count), // packet count
+			"-i", fmt.Sprintf("%.1f", float64(pingCfg.Interval)/1000), // interval in seconds
+			"-W", strconv.Itoa(pingCfg.Timeout * 1000), // timeout in milliseconds
 		}
 
 		// Add additional flags for Docker environments if needed
@@ -151,32 +161,47 @@ func (s *service) buildPingArgs(host string) []string {
 				Msg("Detected Docker environment, using standard Linux ping args")
 		}
 
+		if source != nil {
+			// Linux ping binds with -I, macOS ping with -S
+			flag := "-I"
+			if runtime.GOOS == "darwin" {
+				flag = "-S"
+			}
+			args = append(args, flag, source.String())
+		}
+
 		args = append(args, host)
 
 		log.Debug().
 			Str("os", runtime.GOOS).
 			Str("host", host).
-			Int("count", s.config.IPerf.Ping.Count).
-			Int("interval_ms", s.config.IPerf.Ping.Interval).
-			Int("timeout_ms", s.config.IPerf.Ping.Timeout*1000).
+			Int("count", pingCfg.Count).
+			Int("interval_ms", pingCfg.Interval).
+			Int("timeout_ms", pingCfg.Timeout*1000).
 			Strs("final_args", args).
 			Bool("docker_detected", s.isRunningInDocker()).
 			Msg("Built ping arguments for Unix/Linux/macOS")
 	case "windows":
 		args = []string{
-			"-n", strconv.Itoa(s.config.IPerf.Ping.Count), // packet count
+			"-n", strconv.Itoa(pingCfg.Count), // packet count
 			"-l", "32", // packet size
-			"-w", strconv.Itoa(s.config.IPerf.Ping.Timeout * 1000), // timeout in milliseconds
-			host,
+			"-w", strconv.Itoa(pingCfg.Timeout * 1000), // timeout in milliseconds
+		}
+		if source != nil {
+			args = append(args, "-S", source.String())
 		}
+		args = append(args, host)
 	default:
 		// Default to Linux/Unix style
 		args = []string{
-			"-c", strconv.Itoa(s.config.IPerf.Ping.Count),
-			"-i", fmt.Sprintf("%.1f", float64(s.config.IPerf.Ping.Interval)/1000),
-			"-W", strconv.Itoa(s.config.IPerf.Ping.Timeout * 1000),
-			host,
+			"-c", strconv.Itoa(pingCfg.Count),
+			"-i", fmt.Sprintf("%.1f", float64(pingCfg.Interval)/1000),
+			"-W", strconv.Itoa(pingCfg.Timeout * 1000),
 		}
+		if source != nil {
+			args = append(args, "-I", source.String())
+		}
+		args = append(args, host)
 	}
 
 	return args
@@ -0,0 +1,120 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+// scheduledStartKey carries when a scheduled job was due, so its queue can report how
+// late it started
+type scheduledStartKey struct{}
+
+// WithScheduledStart marks ctx as belonging to a scheduled run that was due at start
+func WithScheduledStart(ctx context.Context, start time.Time) context.Context {
+	return context.WithValue(ctx, scheduledStartKey{}, start)
+}
+
+// scheduledStartFromContext returns when the run in ctx was due, zero for runs that
+// weren't scheduled
+func scheduledStartFromContext(ctx context.Context) time.Time {
+	start, _ := ctx.Value(scheduledStartKey{}).(time.Time)
+	return start
+}
+
+// slotQueue bounds how many jobs of one kind run at once and records how long jobs
+// wait for a slot. It is the only concurrency limit for speed tests and packet loss
+// tests, wherever they were started from.
+type slotQueue struct {
+	slots chan struct{}
+
+	mu        sync.Mutex
+	queued    int
+	running   int
+	started   int64
+	totalWait time.Duration
+	maxWait   time.Duration
+	lastWait  time.Duration
+	lastDelay time.Duration
+}
+
+func newSlotQueue(limit int) *slotQueue {
+	return &slotQueue{slots: make(chan struct{}, max(limit, 1))}
+}
+
+// tryAcquire takes a slot if one is free. scheduledAt is when the job was due, zero
+// for jobs that weren't scheduled. On success the caller must call release.
+func (q *slotQueue) tryAcquire(scheduledAt time.Time) bool {
+	select {
+	case q.slots <- struct{}{}:
+		q.start(0, scheduledAt)
+		return true
+	default:
+		return false
+	}
+}
+
+// wait blocks until a slot is free or ctx ends. On success the caller must call release.
+func (q *slotQueue) wait(ctx context.Context, scheduledAt time.Time) error {
+	q.mu.Lock()
+	q.queued++
+	q.mu.Unlock()
+
+	enqueuedAt := time.Now()
+	select {
+	case q.slots <- struct{}{}:
+	case <-ctx.Done():
+		q.mu.Lock()
+		q.queued--
+		q.mu.Unlock()
+		return ctx.Err()
+	}
+
+	q.mu.Lock()
+	q.queued--
+	q.mu.Unlock()
+	q.start(time.Since(enqueuedAt), scheduledAt)
+	return nil
+}
+
+func (q *slotQueue) start(waited time.Duration, scheduledAt time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running++
+	q.started++
+	q.totalWait += waited
+	q.maxWait = max(q.maxWait, waited)
+	q.lastWait = waited
+	if !scheduledAt.IsZero() {
+		q.lastDelay = max(time.Since(scheduledAt), 0)
+	}
+}
+
+// release frees the slot taken by tryAcquire or wait
+func (q *slotQueue) release() {
+	<-q.slots
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running--
+}
+
+func (q *slotQueue) stats() types.QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return types.QueueStats{
+		Limit:            cap(q.slots),
+		Queued:           q.queued,
+		Running:          q.running,
+		Started:          q.started,
+		TotalWaitSeconds: q.totalWait.Seconds(),
+		MaxWaitSeconds:   q.maxWait.Seconds(),
+		LastWaitSeconds:  q.lastWait.Seconds(),
+		LastDelaySeconds: q.lastDelay.Seconds(),
+	}
+}
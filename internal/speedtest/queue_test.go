@@ -0,0 +1,79 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlotQueueLimitsRunningJobs(t *testing.T) {
+	q := newSlotQueue(1)
+	require.True(t, q.tryAcquire(time.Time{}))
+	assert.False(t, q.tryAcquire(time.Time{}), "no slot left")
+
+	started := make(chan struct{})
+	go func() {
+		if q.wait(context.Background(), time.Time{}) == nil {
+			close(started)
+		}
+	}()
+
+	select {
+	case <-started:
+		t.Fatal("second job started while the only slot was taken")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	stats := q.stats()
+	assert.Equal(t, 1, stats.Limit)
+	assert.Equal(t, 1, stats.Queued)
+	assert.Equal(t, 1, stats.Running)
+
+	q.release()
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("second job did not start after the slot was released")
+	}
+
+	stats = q.stats()
+	assert.Equal(t, 0, stats.Queued)
+	assert.Equal(t, 1, stats.Running)
+	assert.EqualValues(t, 2, stats.Started)
+	assert.GreaterOrEqual(t, stats.LastWaitSeconds, 0.05)
+	assert.GreaterOrEqual(t, stats.TotalWaitSeconds, stats.LastWaitSeconds)
+	assert.GreaterOrEqual(t, stats.MaxWaitSeconds, stats.LastWaitSeconds)
+}
+
+func TestSlotQueueCancelledWait(t *testing.T) {
+	q := newSlotQueue(1)
+	require.True(t, q.tryAcquire(time.Time{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, q.wait(ctx, time.Time{}), context.Canceled)
+
+	stats := q.stats()
+	assert.Equal(t, 0, stats.Queued, "a cancelled job leaves the queue")
+	assert.Equal(t, 1, stats.Running)
+}
+
+func TestSlotQueueRecordsStartDelay(t *testing.T) {
+	q := newSlotQueue(0)
+	ctx := WithScheduledStart(context.Background(), time.Now().Add(-time.Minute))
+	require.NoError(t, q.wait(ctx, scheduledStartFromContext(ctx)))
+
+	stats := q.stats()
+	assert.Equal(t, 1, stats.Limit, "non-positive limits default to 1")
+	assert.GreaterOrEqual(t, stats.LastDelaySeconds, 60.0)
+
+	q.release()
+	require.True(t, q.tryAcquire(time.Time{}))
+	assert.GreaterOrEqual(t, q.stats().LastDelaySeconds, 60.0, "unscheduled jobs keep the last delay")
+}
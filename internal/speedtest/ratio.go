@@ -0,0 +1,70 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"math"
+	"sort"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+const (
+	// RatioBaselineWindow is how many earlier results of the same test type form the ratio baseline
+	RatioBaselineWindow = 5
+
+	// DefaultRatioShiftPercent is the change from baseline flagged as a shift in the ratio history
+	DefaultRatioShiftPercent = 25.0
+)
+
+// downUpRatio returns download divided by upload, or nil when either speed is missing
+func downUpRatio(download, upload float64) *float64 {
+	if download <= 0 || upload <= 0 {
+		return nil
+	}
+	ratio := download / upload
+	return &ratio
+}
+
+// ratioBaseline returns the median of ratios so a single outlier doesn't move the baseline
+func ratioBaseline(ratios []float64) (float64, bool) {
+	if len(ratios) == 0 {
+		return 0, false
+	}
+	sorted := append([]float64(nil), ratios...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2, true
+	}
+	return sorted[mid], true
+}
+
+// ratioChangePercent returns how far ratio is from baseline, in percent of the baseline
+func ratioChangePercent(ratio, baseline float64) float64 {
+	return math.Abs(ratio-baseline) / baseline * 100
+}
+
+// AnnotateRatioShifts fills in the baseline and change for each point, in place, and
+// flags points whose change exceeds thresholdPercent. Points must be oldest first;
+// the first points of each test type have no baseline to compare against.
+func AnnotateRatioShifts(points []types.SpeedTestRatio, thresholdPercent float64) {
+	history := make(map[string][]float64)
+	for i := range points {
+		p := &points[i]
+		previous := history[p.TestType]
+		if baseline, ok := ratioBaseline(previous); ok && baseline > 0 {
+			change := ratioChangePercent(p.DownUpRatio, baseline)
+			p.BaselineRatio = &baseline
+			p.ChangePercent = &change
+			p.Shift = change > thresholdPercent
+		}
+
+		previous = append(previous, p.DownUpRatio)
+		if len(previous) > RatioBaselineWindow {
+			previous = previous[1:]
+		}
+		history[p.TestType] = previous
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestDownUpRatio(t *testing.T) {
+	ratio := downUpRatio(500, 50)
+	require.NotNil(t, ratio)
+	assert.InDelta(t, 10.0, *ratio, 0.0001)
+
+	assert.Nil(t, downUpRatio(500, 0), "no upload means no ratio")
+	assert.Nil(t, downUpRatio(0, 50), "no download means no ratio")
+}
+
+func TestRatioBaseline(t *testing.T) {
+	_, ok := ratioBaseline(nil)
+	assert.False(t, ok)
+
+	baseline, ok := ratioBaseline([]float64{10, 30, 11})
+	require.True(t, ok)
+	assert.Equal(t, 11.0, baseline, "median ignores the outlier")
+
+	baseline, _ = ratioBaseline([]float64{10, 12})
+	assert.Equal(t, 11.0, baseline)
+}
+
+func TestAnnotateRatioShifts(t *testing.T) {
+	points := []types.SpeedTestRatio{
+		{ID: 1, TestType: "speedtest", DownUpRatio: 10},
+		{ID: 2, TestType: "iperf3", DownUpRatio: 1},
+		{ID: 3, TestType: "speedtest", DownUpRatio: 10.5},
+		{ID: 4, TestType: "speedtest", DownUpRatio: 10},
+		{ID: 5, TestType: "speedtest", DownUpRatio: 5}, // provisioning change
+		{ID: 6, TestType: "iperf3", DownUpRatio: 1.1},
+	}
+
+	AnnotateRatioShifts(points, DefaultRatioShiftPercent)
+
+	assert.Nil(t, points[0].BaselineRatio, "first speedtest result has no baseline")
+	assert.Nil(t, points[1].BaselineRatio, "test types have separate baselines")
+
+	require.NotNil(t, points[2].ChangePercent)
+	assert.InDelta(t, 5.0, *points[2].ChangePercent, 0.0001)
+	assert.False(t, points[2].Shift)
+
+	require.NotNil(t, points[4].BaselineRatio)
+	assert.Equal(t, 10.0, *points[4].BaselineRatio)
+	assert.InDelta(t, 50.0, *points[4].ChangePercent, 0.0001)
+	assert.True(t, points[4].Shift)
+
+	require.NotNil(t, points[5].ChangePercent)
+	assert.InDelta(t, 10.0, *points[5].ChangePercent, 0.0001)
+	assert.False(t, points[5].Shift)
+}
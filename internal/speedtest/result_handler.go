@@ -6,6 +6,7 @@ package speedtest
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -18,6 +19,9 @@ import (
 type DefaultResultHandler struct {
 	db       database.Service
 	notifier *notifications.Notifier
+
+	// thresholdMu serializes threshold state transitions of concurrent results
+	thresholdMu sync.Mutex
 }
 
 func NewResultHandler(db database.Service, notifier *notifications.Notifier) *DefaultResultHandler {
@@ -47,6 +51,8 @@ func (h *DefaultResultHandler) SaveResult(ctx context.Context, result *Result, t
 	case "librespeed":
 		serverHost = &result.Server
 		serverID = fmt.Sprintf("librespeed-%s", result.Server)
+	case "ookla":
+		serverID = fmt.Sprintf("ookla-%s", result.Server)
 	case "speedtest":
 		// For speedtest.net, we'll need to extract host info from the result
 		serverID = result.Server
@@ -57,11 +63,34 @@ func (h *DefaultResultHandler) SaveResult(ctx context.Context, result *Result, t
 		jitterPtr = &result.Jitter
 	}
 
+	var ttfbPtr *float64
+	if result.TTFB > 0 {
+		ttfbPtr = &result.TTFB
+	}
+
+	var pathMTU *int
+	var mtuWarning *string
+	if result.PathMTU > 0 {
+		pathMTU = &result.PathMTU
+		if result.MTUWarning != "" {
+			mtuWarning = &result.MTUWarning
+		}
+	}
+
+	var loadRx, loadTx *int64
+	var loadCPU *float64
+	if result.Conditions != nil {
+		loadRx = &result.Conditions.RxBytesPerSecond
+		loadTx = &result.Conditions.TxBytesPerSecond
+		loadCPU = result.Conditions.CPUUsagePercent
+	}
+
 	// Give the DB write up to 10s while still respecting upstream cancellations and values.
 	saveCtx, saveCancel := context.WithTimeout(ctx, 10*time.Second)
 	defer saveCancel()
 
 	createdAt := time.Now().UTC()
+	result.Attempts = attemptFromContext(ctx)
 
 	dbResult, err := h.db.SaveSpeedTest(saveCtx, types.SpeedTestResult{
 		ServerName:    result.Server,
@@ -72,8 +101,18 @@ func (h *DefaultResultHandler) SaveResult(ctx context.Context, result *Result, t
 		UploadSpeed:   result.UploadSpeed,
 		Latency:       result.Latency,
 		Jitter:        jitterPtr,
+		TTFB:          ttfbPtr,
+		DownUpRatio:   downUpRatio(result.DownloadSpeed, result.UploadSpeed),
+		PathMTU:       pathMTU,
+		MTUWarning:    mtuWarning,
+		DatagramLoss:  result.DatagramLoss,
 		IsScheduled:   opts.IsScheduled,
+		Attempts:      result.Attempts,
 		CreatedAt:     createdAt,
+
+		LoadRxBytesPerSecond: loadRx,
+		LoadTxBytesPerSecond: loadTx,
+		LoadCPUPercent:       loadCPU,
 	})
 	if err != nil {
 		log.Error().Err(err).
@@ -109,8 +148,36 @@ func (h *DefaultResultHandler) SendNotification(result *types.SpeedTestResult) {
 			ISP:        "",    // ISP not available in types.SpeedTestResult
 			Failed:     false, // Assuming successful test if we got here
 		}
+		if result.DownUpRatio != nil {
+			notifResult.DownUpRatio = *result.DownUpRatio
+			notifResult.BaselineRatio = h.ratioBaseline(result)
+		}
 		h.notifier.SendSpeedTestNotification(notifResult)
+		h.trackThresholds(result.TestType, notifResult)
+	}
+}
+
+// ratioBaseline returns the median ratio of the results preceding result with the same
+// test type, or 0 when there is no history to compare against
+func (h *DefaultResultHandler) ratioBaseline(result *types.SpeedTestResult) float64 {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Fetch one extra row since the result itself is already stored
+	recent, err := h.db.GetSpeedTestRatios(ctx, result.TestType, time.Time{}, result.CreatedAt, RatioBaselineWindow+1)
+	if err != nil {
+		log.Warn().Err(err).Int64("result_id", result.ID).Msg("Failed to load ratio history for notification")
+		return 0
+	}
+
+	ratios := make([]float64, 0, RatioBaselineWindow)
+	for _, r := range recent {
+		if r.ID != result.ID && len(ratios) < RatioBaselineWindow {
+			ratios = append(ratios, r.DownUpRatio)
+		}
 	}
+	baseline, _ := ratioBaseline(ratios)
+	return baseline
 }
 
 // parsePingValue extracts the numeric ping value from a latency string like "10.5ms"
@@ -0,0 +1,67 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+const (
+	// scheduledAttemptTimeout bounds a single run of a scheduled test
+	scheduledAttemptTimeout = 5 * time.Minute
+	// defaultRetryBackoff replaces a missing or invalid speedtest.retry_backoff
+	defaultRetryBackoff = 30 * time.Second
+)
+
+// attemptKey carries the attempt number of a run, so the stored result can note it
+type attemptKey struct{}
+
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// attemptFromContext returns the attempt number of the run in ctx, 1 outside RunTest
+func attemptFromContext(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+	return 1
+}
+
+// retryPolicy returns how often a failed test is retried and the wait before the first
+// retry. Only scheduled tests are retried; a failed manual test is reported right away.
+func (s *service) retryPolicy(opts *types.TestOptions) (int, time.Duration) {
+	if !opts.IsScheduled {
+		return 0, 0
+	}
+
+	s.configMu.RLock()
+	retries, backoff := s.config.Retries, s.config.RetryBackoff
+	s.configMu.RUnlock()
+	return min(max(retries, 0), config.MaxSpeedTestRetries), parseRetryBackoff(backoff)
+}
+
+// parseRetryBackoff parses speedtest.retry_backoff, falling back to the default
+func parseRetryBackoff(value string) time.Duration {
+	if value == "" {
+		return defaultRetryBackoff
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Warn().Str("key", "speedtest.retry_backoff").Str("value", value).Dur("default", defaultRetryBackoff).Msg("Invalid retry backoff, using default")
+		return defaultRetryBackoff
+	}
+	return d
+}
+
+// retryDelay is the wait after the given failed attempt, doubling from backoff
+func retryDelay(backoff time.Duration, attempt int) time.Duration {
+	return backoff << (attempt - 1)
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+var errBlip = errors.New("network blip")
+
+// failingRun fails the first failures attempts and records the attempt of each call
+func failingRun(failures int, attempts *[]int) func(context.Context, *types.TestOptions) (*Result, error) {
+	return func(ctx context.Context, opts *types.TestOptions) (*Result, error) {
+		attempt := attemptFromContext(ctx)
+		*attempts = append(*attempts, attempt)
+		if attempt <= failures {
+			return nil, errBlip
+		}
+		return &Result{Attempts: attempt}, nil
+	}
+}
+
+func TestRunWithRetries(t *testing.T) {
+	svc := &service{config: config.SpeedTestConfig{Retries: 3, RetryBackoff: "1ms"}}
+	scheduled := &types.TestOptions{IsScheduled: true}
+
+	var calls []int
+	result, attempts, err := svc.runWithRetries(context.Background(), scheduled, failingRun(2, &calls))
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 3, result.Attempts)
+	assert.Equal(t, []int{1, 2, 3}, calls)
+
+	calls = nil
+	_, attempts, err = svc.runWithRetries(context.Background(), scheduled, failingRun(10, &calls))
+	require.ErrorIs(t, err, errBlip)
+	assert.ErrorContains(t, err, "after 4 attempts")
+	assert.Equal(t, 4, attempts)
+
+	// Manual tests fail right away
+	calls = nil
+	_, attempts, err = svc.runWithRetries(context.Background(), &types.TestOptions{}, failingRun(1, &calls))
+	require.ErrorIs(t, err, errBlip)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, []int{1}, calls)
+}
+
+func TestRunWithRetriesStopsOnContext(t *testing.T) {
+	svc := &service{config: config.SpeedTestConfig{Retries: 3, RetryBackoff: "1h"}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var calls []int
+	_, attempts, err := svc.runWithRetries(ctx, &types.TestOptions{IsScheduled: true}, failingRun(10, &calls))
+	require.ErrorIs(t, err, errBlip)
+	assert.Equal(t, 1, attempts, "the backoff outlasts the context")
+}
+
+func TestRunWithRetriesFreesSlotDuringBackoff(t *testing.T) {
+	svc := &service{config: config.SpeedTestConfig{Retries: 1, RetryBackoff: "200ms"}, queue: newSlotQueue(1)}
+
+	// Holds a slot per attempt like runAttempt, failing the first one
+	failed := make(chan struct{})
+	run := func(ctx context.Context, opts *types.TestOptions) (*Result, error) {
+		if err := svc.acquireSlot(ctx, opts); err != nil {
+			return nil, err
+		}
+		defer svc.queue.release()
+		if attemptFromContext(ctx) == 1 {
+			close(failed)
+			return nil, errBlip
+		}
+		return &Result{Attempts: attemptFromContext(ctx)}, nil
+	}
+
+	done := make(chan *Result)
+	go func() {
+		result, _, err := svc.runWithRetries(context.Background(), &types.TestOptions{IsScheduled: true}, run)
+		assert.NoError(t, err)
+		done <- result
+	}()
+
+	// Another test gets the slot while the failed one waits to retry
+	<-failed
+	require.Eventually(t, func() bool { return svc.queue.tryAcquire(time.Time{}) }, 150*time.Millisecond, time.Millisecond)
+	svc.queue.release()
+
+	select {
+	case result := <-done:
+		assert.Equal(t, 2, result.Attempts)
+	case <-time.After(time.Second):
+		t.Fatal("retry did not run")
+	}
+}
+
+func TestAttemptFromContext(t *testing.T) {
+	assert.Equal(t, 1, attemptFromContext(context.Background()))
+	assert.Equal(t, 3, attemptFromContext(withAttempt(context.Background(), 3)))
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+// SourceAddress binds outbound test traffic to a network interface or local address, for
+// hosts with more than one uplink (speedtest.source_interface and speedtest.source_ip)
+type SourceAddress struct {
+	Interface string
+	IP        string
+}
+
+// NewSourceAddress returns the source address configured for speed tests and probes
+func NewSourceAddress(cfg config.SpeedTestConfig) SourceAddress {
+	return SourceAddress{
+		Interface: strings.TrimSpace(cfg.SourceInterface),
+		IP:        strings.TrimSpace(cfg.SourceIP),
+	}
+}
+
+// Resolve returns the local address to bind to, or nil when none is configured. The
+// address has to be assigned to this host, and to the interface when both are set. An
+// interface alone resolves to its first IPv4 address, or to its first global IPv6
+// address when it has none. It's resolved per test, so an address renewed by DHCP is used.
+func (s SourceAddress) Resolve() (net.IP, error) {
+	if s.Interface == "" && s.IP == "" {
+		return nil, nil
+	}
+
+	var want net.IP
+	if s.IP != "" {
+		if want = net.ParseIP(s.IP); want == nil {
+			return nil, fmt.Errorf("invalid source IP %q", s.IP)
+		}
+	}
+
+	var addrs []net.Addr
+	if s.Interface != "" {
+		iface, err := net.InterfaceByName(s.Interface)
+		if err != nil {
+			return nil, fmt.Errorf("source interface %q: %w", s.Interface, err)
+		}
+		if addrs, err = iface.Addrs(); err != nil {
+			return nil, fmt.Errorf("failed to list addresses of %s: %w", s.Interface, err)
+		}
+	} else {
+		var err error
+		if addrs, err = net.InterfaceAddrs(); err != nil {
+			return nil, fmt.Errorf("failed to list local addresses: %w", err)
+		}
+	}
+
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		if prefix, ok := addr.(*net.IPNet); ok {
+			ips = append(ips, prefix.IP)
+		}
+	}
+
+	if ip := pickSourceIP(ips, want); ip != nil {
+		return ip, nil
+	}
+	switch {
+	case want != nil && s.Interface != "":
+		return nil, fmt.Errorf("source IP %s is not assigned to interface %s", want, s.Interface)
+	case want != nil:
+		return nil, fmt.Errorf("source IP %s is not assigned to this host", want)
+	default:
+		return nil, fmt.Errorf("source interface %s has no usable address", s.Interface)
+	}
+}
+
+// pickSourceIP returns want when it's one of ips. Without want it returns the first IPv4
+// address that isn't link-local, falling back to the first global unicast IPv6 address.
+func pickSourceIP(ips []net.IP, want net.IP) net.IP {
+	if want != nil {
+		for _, ip := range ips {
+			if ip.Equal(want) {
+				return want
+			}
+		}
+		return nil
+	}
+
+	var v6 net.IP
+	for _, ip := range ips {
+		if ip.To4() != nil && !ip.IsLinkLocalUnicast() {
+			return ip
+		}
+		if v6 == nil && ip.To4() == nil && ip.IsGlobalUnicast() {
+			v6 = ip
+		}
+	}
+	return v6
+}
+
+// sourceFor returns source when it can send to target, or nil when no source is set or
+// the address families differ, in which case the system picks the source
+func sourceFor(source, target net.IP) net.IP {
+	if source == nil || target == nil || (source.To4() != nil) != (target.To4() != nil) {
+		return nil
+	}
+	return source
+}
+
+// sourceDialer returns a dialer that connects from source, or from an address the
+// system picks when source is nil. Dialing a host with addresses of both families
+// only tries those of source's family.
+func sourceDialer(source net.IP, timeout time.Duration) *net.Dialer {
+	dialer := &net.Dialer{Timeout: timeout}
+	if source != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: source}
+	}
+	return dialer
+}
+
+// probeHTTPClient returns an HTTP client for requests to test servers and their server
+// lists that connects from source and sends userAgent
+func probeHTTPClient(source net.IP, userAgent string, timeout time.Duration, disableKeepAlives bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = sourceDialer(source, 30*time.Second).DialContext
+	transport.DisableKeepAlives = disableKeepAlives
+
+	var rt http.RoundTripper = transport
+	if userAgent != "" {
+		rt = userAgentTransport{base: transport, userAgent: userAgent}
+	}
+	return &http.Client{Timeout: timeout, Transport: rt}
+}
+
+// userAgentTransport sets the User-Agent of every request sent through it
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+func (t userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.base.RoundTrip(req)
+}
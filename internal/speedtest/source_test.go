@@ -0,0 +1,89 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceAddressResolve(t *testing.T) {
+	ip, err := SourceAddress{}.Resolve()
+	require.NoError(t, err)
+	assert.Nil(t, ip, "no source configured")
+
+	ip, err = SourceAddress{IP: "127.0.0.1"}.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", ip.String())
+
+	_, err = SourceAddress{IP: "192.0.2.123"}.Resolve()
+	assert.ErrorContains(t, err, "not assigned to this host")
+
+	_, err = SourceAddress{IP: "not-an-ip"}.Resolve()
+	assert.ErrorContains(t, err, "invalid source IP")
+
+	_, err = SourceAddress{Interface: "netronome-missing0"}.Resolve()
+	assert.ErrorContains(t, err, "netronome-missing0")
+}
+
+func TestPickSourceIP(t *testing.T) {
+	linkLocal := net.ParseIP("169.254.1.1")
+	v4 := net.ParseIP("192.168.1.10")
+	v6LinkLocal := net.ParseIP("fe80::1")
+	v6 := net.ParseIP("2001:db8::10")
+
+	assert.Equal(t, v4, pickSourceIP([]net.IP{v6LinkLocal, v6, linkLocal, v4}, nil), "IPv4 is preferred")
+	assert.Equal(t, v6, pickSourceIP([]net.IP{v6LinkLocal, linkLocal, v6}, nil), "global IPv6 without IPv4")
+	assert.Nil(t, pickSourceIP([]net.IP{v6LinkLocal, linkLocal}, nil), "only link-local addresses")
+
+	assert.Equal(t, v6, pickSourceIP([]net.IP{v4, v6}, net.ParseIP("2001:db8::10")))
+	assert.Nil(t, pickSourceIP([]net.IP{v4, v6}, net.ParseIP("192.168.1.11")))
+}
+
+func TestSourceFor(t *testing.T) {
+	v4 := net.ParseIP("192.168.1.10")
+	v6 := net.ParseIP("2001:db8::10")
+
+	assert.Equal(t, v4, sourceFor(v4, net.ParseIP("1.1.1.1")))
+	assert.Equal(t, v6, sourceFor(v6, net.ParseIP("2606:4700::1111")))
+	assert.Nil(t, sourceFor(v4, net.ParseIP("2606:4700::1111")), "family mismatch")
+	assert.Nil(t, sourceFor(v6, net.ParseIP("1.1.1.1")), "family mismatch")
+	assert.Nil(t, sourceFor(nil, net.ParseIP("1.1.1.1")))
+}
+
+func TestWithMTRSource(t *testing.T) {
+	args := []string{"-4", "-j", "example.com"}
+	assert.Equal(t, args, withMTRSource(args, nil))
+	assert.Equal(t, []string{"--address", "192.168.1.10", "-4", "-j", "example.com"},
+		withMTRSource(args, net.ParseIP("192.168.1.10")))
+}
+
+func TestProbeHTTPClient(t *testing.T) {
+	var gotUserAgent string
+	var gotRemote string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserAgent = r.UserAgent()
+		gotRemote, _, _ = net.SplitHostPort(r.RemoteAddr)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := probeHTTPClient(net.ParseIP("127.0.0.1"), "netronome-test/1.0", 0, true)
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", "overridden")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "netronome-test/1.0", gotUserAgent)
+	assert.Equal(t, "127.0.0.1", gotRemote)
+	assert.Equal(t, "overridden", req.Header.Get("User-Agent"), "the caller's request is not modified")
+}
@@ -6,12 +6,16 @@ package speedtest
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/autobrr/netronome/internal/config"
 	"github.com/autobrr/netronome/internal/database"
 	"github.com/autobrr/netronome/internal/notifications"
+	"github.com/autobrr/netronome/internal/telemetry"
 	"github.com/autobrr/netronome/internal/types"
 )
 
@@ -20,24 +24,38 @@ type Service interface {
 	GetServers(testType string) ([]ServerResponse, error)
 	GetLibrespeedServers() ([]ServerResponse, error)
 	RunLibrespeedTest(ctx context.Context, opts *types.TestOptions) (*Result, error)
-	RunTraceroute(ctx context.Context, host string) (*TracerouteResult, error)
+	RunTraceroute(ctx context.Context, host string, opts TracerouteOptions) (*TracerouteResult, error)
+	ReenrichGeoIP(ctx context.Context, from, to time.Time) (*GeoIPBackfillResult, error)
 	SetBroadcastUpdate(broadcastUpdate func(types.SpeedUpdate))
 	SetBroadcastTracerouteUpdate(broadcastUpdate func(types.TracerouteUpdate))
+	SetConditionsProvider(provider ConditionsProvider)
+	SetSamplingBooster(booster SamplingBooster)
 	GetNotifier() *notifications.Notifier
+	// QueueStats reports how many tests wait for or hold a speedtest.max_concurrent slot
+	QueueStats() types.QueueStats
+	Reload(cfg *config.Config)
 }
 
 type service struct {
 	db                        database.Service
+	configMu                  sync.RWMutex
 	config                    config.SpeedTestConfig
 	fullConfig                *config.Config
 	notifier                  *notifications.Notifier
 	broadcastUpdate           func(types.SpeedUpdate)
 	broadcastTracerouteUpdate func(types.TracerouteUpdate)
+	conditionsProvider        ConditionsProvider
+	samplingBooster           SamplingBooster
+	// queue bounds how many tests run at once (speedtest.max_concurrent)
+	queue *slotQueue
+	// source binds test traffic to speedtest.source_interface or source_ip
+	source SourceAddress
 
 	// New architecture components
 	speedtestNetRunner *SpeedtestNetRunner
 	iperfRunner        *IperfRunner
 	librespeedRunner   *LibrespeedRunner
+	ooklaRunner        *OoklaRunner
 	resultHandler      ResultHandler
 }
 
@@ -47,13 +65,20 @@ func New(db database.Service, cfg config.SpeedTestConfig, notifier *notification
 		config:     cfg,
 		fullConfig: fullConfig,
 		notifier:   notifier,
+		queue:      newSlotQueue(cfg.MaxConcurrent),
+		source:     NewSourceAddress(cfg),
 	}
 
 	// Initialize new architecture components
 	svc.resultHandler = NewResultHandler(db, notifier)
 	svc.speedtestNetRunner = NewSpeedtestNetRunner(cfg)
 	svc.iperfRunner = NewIperfRunner(cfg.IPerf)
+	svc.iperfRunner.source = svc.source
 	svc.librespeedRunner = NewLibrespeedRunner(cfg.Librespeed)
+	svc.librespeedRunner.source = svc.source
+	svc.librespeedRunner.userAgent = cfg.UserAgent
+	svc.ooklaRunner = NewOoklaRunner(cfg.Ookla)
+	svc.ooklaRunner.source = svc.source
 
 	// Initialize GeoIP databases for all speedtest features (traceroute, MTR, etc.)
 	svc.initGeoIP()
@@ -70,20 +95,76 @@ func (s *service) SetBroadcastTracerouteUpdate(broadcastUpdate func(types.Tracer
 	s.broadcastTracerouteUpdate = broadcastUpdate
 }
 
+func (s *service) SetConditionsProvider(provider ConditionsProvider) {
+	s.conditionsProvider = provider
+}
+
+func (s *service) SetSamplingBooster(booster SamplingBooster) {
+	s.samplingBooster = booster
+}
+
+// boostSampling raises agent sampling for the test and returns the func that restores it
+func (s *service) boostSampling() func() {
+	if s.samplingBooster == nil {
+		return func() {}
+	}
+	return s.samplingBooster.BoostLocalSampling()
+}
+
+// captureConditions snapshots host load before the test traffic starts skewing it
+func (s *service) captureConditions() *types.NetworkConditions {
+	if s.conditionsProvider == nil {
+		return nil
+	}
+	return s.conditionsProvider.LocalNetworkConditions()
+}
+
 func (s *service) GetNotifier() *notifications.Notifier {
 	return s.notifier
 }
 
+// Reload applies a reloaded configuration. Only the iperf ping settings
+// (speedtest.iperf.ping count, interval and timeout) and the retry settings are
+// hot-reloadable; runner timeouts, max_concurrent, traceroute and GeoIP settings keep
+// their startup values until restart.
+func (s *service) Reload(cfg *config.Config) {
+	s.configMu.Lock()
+	s.config.IPerf.Ping = cfg.SpeedTest.IPerf.Ping
+	s.config.Retries = cfg.SpeedTest.Retries
+	s.config.RetryBackoff = cfg.SpeedTest.RetryBackoff
+	s.configMu.Unlock()
+
+	log.Info().
+		Int("count", cfg.SpeedTest.IPerf.Ping.Count).
+		Int("interval", cfg.SpeedTest.IPerf.Ping.Interval).
+		Int("timeout", cfg.SpeedTest.IPerf.Ping.Timeout).
+		Int("retries", cfg.SpeedTest.Retries).
+		Str("retry_backoff", cfg.SpeedTest.RetryBackoff).
+		Msg("Reloaded speedtest ping and retry settings")
+}
+
+// pingConfig returns the current ping settings, which may change on Reload
+func (s *service) pingConfig() config.PingConfig {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config.IPerf.Ping
+}
+
 func (s *service) GetLibrespeedServers() ([]ServerResponse, error) {
 	return s.librespeedRunner.GetServers()
 }
 
 func (s *service) RunLibrespeedTest(ctx context.Context, opts *types.TestOptions) (*Result, error) {
+	conditions := s.captureConditions()
+	pathMTU := s.runMTUProbe(ctx, opts)
+	defer s.boostSampling()()
 	s.librespeedRunner.SetProgressCallback(s.broadcastUpdate)
 	result, err := s.librespeedRunner.RunTest(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
+	result.Conditions = conditions
+	applyPathMTU(result, pathMTU)
 
 	// Save result using the result handler
 	if err := s.resultHandler.SaveResult(ctx, result, "librespeed", opts); err != nil {
@@ -93,16 +174,178 @@ func (s *service) RunLibrespeedTest(ctx context.Context, opts *types.TestOptions
 	return result, nil
 }
 
+func (s *service) RunOoklaTest(ctx context.Context, opts *types.TestOptions) (*Result, error) {
+	conditions := s.captureConditions()
+	pathMTU := s.runMTUProbe(ctx, opts)
+	defer s.boostSampling()()
+	s.ooklaRunner.SetProgressCallback(s.broadcastUpdate)
+	result, err := s.ooklaRunner.RunTest(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Ookla test failed: %w", err)
+	}
+	result.Conditions = conditions
+	applyPathMTU(result, pathMTU)
+
+	// Save result using the result handler
+	if err := s.resultHandler.SaveResult(ctx, result, "ookla", opts); err != nil {
+		log.Error().Err(err).Msg("Failed to save Ookla result")
+	}
+
+	return result, nil
+}
+
 func (s *service) RunIperfTest(ctx context.Context, opts *types.TestOptions) (*types.SpeedTestResult, error) {
 	s.iperfRunner.SetProgressCallback(s.broadcastUpdate)
 	return s.iperfRunner.runSingleIperfTest(ctx, opts)
 }
 
+// RunTest runs a test once one of the max_concurrent slots is free. Tests that
+// have to wait are broadcast as "queued"; the wait counts against ctx, but not against
+// the time a scheduled attempt gets once it runs. A failed
+// scheduled test is retried up to speedtest.retries times, freeing its slot while it
+// waits out the backoff.
 func (s *service) RunTest(ctx context.Context, opts *types.TestOptions) (*Result, error) {
+	ctx, span := telemetry.Start(ctx, "speedtest",
+		attribute.String("speedtest.provider", speedTestType(opts)),
+		attribute.String("speedtest.host", opts.ServerHost),
+		attribute.StringSlice("speedtest.server_ids", opts.ServerIDs),
+		attribute.Bool("speedtest.scheduled", opts.IsScheduled),
+	)
+
+	result, attempts, err := s.runWithRetries(ctx, opts, s.runAttempt)
+	span.SetAttributes(attribute.Int("speedtest.attempts", attempts))
+	if result != nil {
+		span.SetAttributes(
+			attribute.String("speedtest.server", result.Server),
+			attribute.Float64("speedtest.download_mbps", result.DownloadSpeed),
+			attribute.Float64("speedtest.upload_mbps", result.UploadSpeed),
+		)
+	}
+	telemetry.End(span, err)
+	return result, err
+}
+
+// runWithRetries calls run until it succeeds, the retries allowed by retryPolicy are
+// used up or ctx ends, and returns the number of attempts made
+func (s *service) runWithRetries(ctx context.Context, opts *types.TestOptions, run func(context.Context, *types.TestOptions) (*Result, error)) (*Result, int, error) {
+	retries, backoff := s.retryPolicy(opts)
+	testType := speedTestType(opts)
+
+	for attempt := 1; ; attempt++ {
+		result, err := run(withAttempt(ctx, attempt), opts)
+		if err == nil {
+			if attempt > 1 {
+				log.Info().
+					Str("test_type", testType).
+					Int("attempt", attempt).
+					Msg("Speed test succeeded after retrying")
+			}
+			return result, attempt, nil
+		}
+		if attempt > retries || ctx.Err() != nil {
+			if attempt > 1 {
+				err = fmt.Errorf("speed test failed after %d attempts: %w", attempt, err)
+			}
+			return nil, attempt, err
+		}
+
+		delay := retryDelay(backoff, attempt)
+		log.Warn().
+			Err(err).
+			Str("test_type", testType).
+			Int("attempt", attempt).
+			Int("max_attempts", retries+1).
+			Dur("retry_in", delay).
+			Msg("Speed test attempt failed, retrying")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, attempt, fmt.Errorf("speed test gave up retrying after %d attempts: %w", attempt, err)
+		}
+	}
+}
+
+// runAttempt runs the test once, holding a test slot for the duration of the run. A
+// scheduled attempt gets scheduledAttemptTimeout from when it has its slot, so time
+// spent queued behind other tests doesn't cut it short.
+func (s *service) runAttempt(ctx context.Context, opts *types.TestOptions) (*Result, error) {
+	if err := s.acquireSlot(ctx, opts); err != nil {
+		return nil, err
+	}
+	defer s.queue.release()
+
+	if opts.IsScheduled {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, scheduledAttemptTimeout)
+		defer cancel()
+	}
+	return s.runTest(ctx, opts)
+}
+
+// acquireSlot blocks until a test slot is free. The caller must release it.
+func (s *service) acquireSlot(ctx context.Context, opts *types.TestOptions) error {
+	// Only the first attempt of a scheduled test counts towards its start delay, a
+	// retry is late by its backoff
+	var scheduledAt time.Time
+	if attemptFromContext(ctx) == 1 {
+		scheduledAt = scheduledStartFromContext(ctx)
+	}
+
+	if s.queue.tryAcquire(scheduledAt) {
+		return nil
+	}
+
+	testType := speedTestType(opts)
+	log.Info().
+		Str("test_type", testType).
+		Bool("isScheduled", opts.IsScheduled).
+		Int("max_concurrent", cap(s.queue.slots)).
+		Msg("Speed test queued until a running test finishes")
+
+	if s.broadcastUpdate != nil {
+		s.broadcastUpdate(types.SpeedUpdate{
+			Type:        "queued",
+			IsScheduled: opts.IsScheduled,
+			TestType:    testType,
+			RunID:       opts.RunID,
+		})
+	}
+
+	if err := s.queue.wait(ctx, scheduledAt); err != nil {
+		return fmt.Errorf("speed test gave up waiting in queue: %w", err)
+	}
+	log.Debug().Str("test_type", testType).Msg("Queued speed test starting")
+	return nil
+}
+
+// QueueStats reports how many tests wait for or hold a speedtest.max_concurrent slot
+func (s *service) QueueStats() types.QueueStats {
+	return s.queue.stats()
+}
+
+// speedTestType names the runner RunTest picks for opts
+func speedTestType(opts *types.TestOptions) string {
+	switch {
+	case opts.UseLibrespeed:
+		return "librespeed"
+	case opts.UseOokla:
+		return "ookla"
+	case opts.UseIperf && opts.ServerHost != "":
+		return "iperf3"
+	default:
+		return "speedtest"
+	}
+}
+
+func (s *service) runTest(ctx context.Context, opts *types.TestOptions) (*Result, error) {
 	log.Debug().
 		Bool("isScheduled", opts.IsScheduled).
 		Bool("useIperf", opts.UseIperf).
 		Bool("useLibrespeed", opts.UseLibrespeed).
+		Bool("useOokla", opts.UseOokla).
 		Str("server_ids", fmt.Sprintf("%v", opts.ServerIDs)).
 		Str("server_host", opts.ServerHost).
 		Msg("Starting speed test coordination")
@@ -111,6 +354,14 @@ func (s *service) RunTest(ctx context.Context, opts *types.TestOptions) (*Result
 		log.Info().Msg("Using librespeed runner (handles ping natively)")
 		return s.RunLibrespeedTest(ctx, opts)
 	}
+	if opts.UseOokla {
+		log.Info().Msg("Using Ookla runner (handles ping natively)")
+		return s.RunOoklaTest(ctx, opts)
+	}
+
+	conditions := s.captureConditions()
+	pathMTU := s.runMTUProbe(ctx, opts)
+	defer s.boostSampling()()
 
 	if opts.UseIperf && opts.ServerHost != "" {
 		log.Info().Str("server_host", opts.ServerHost).Msg("Using iperf3 runner")
@@ -138,6 +389,8 @@ func (s *service) RunTest(ctx context.Context, opts *types.TestOptions) (*Result
 		if err != nil {
 			return nil, fmt.Errorf("iperf3 test failed: %w", err)
 		}
+		result.Conditions = conditions
+		applyPathMTU(result, pathMTU)
 
 		// Save the result
 		if err := s.resultHandler.SaveResult(ctx, result, "iperf3", opts); err != nil {
@@ -154,6 +407,8 @@ func (s *service) RunTest(ctx context.Context, opts *types.TestOptions) (*Result
 	if err != nil {
 		return nil, fmt.Errorf("speedtest.net test failed: %w", err)
 	}
+	result.Conditions = conditions
+	applyPathMTU(result, pathMTU)
 
 	// Save the result
 	if err := s.resultHandler.SaveResult(ctx, result, "speedtest", opts); err != nil {
@@ -169,6 +424,8 @@ func (s *service) GetServers(testType string) ([]ServerResponse, error) {
 		return s.GetLibrespeedServers()
 	case "iperf3":
 		return s.iperfRunner.GetServers()
+	case "ookla":
+		return s.ooklaRunner.GetServers()
 	case "speedtest":
 		return s.speedtestNetRunner.GetServers()
 	default:
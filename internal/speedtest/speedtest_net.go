@@ -29,8 +29,17 @@ type SpeedtestNetRunner struct {
 }
 
 func NewSpeedtestNetRunner(cfg config.SpeedTestConfig) *SpeedtestNetRunner {
+	// The client binds its dialer once, so the source address is resolved here
+	userConfig := &st.UserConfig{UserAgent: cfg.UserAgent}
+	source, err := NewSourceAddress(cfg).Resolve()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to resolve speedtest.net source address, using the default route")
+	} else if source != nil {
+		userConfig.Source = source.String()
+	}
+
 	return &SpeedtestNetRunner{
-		client:        st.New(),
+		client:        st.New(st.WithUserConfig(userConfig)),
 		config:        cfg,
 		cacheDuration: 30 * time.Minute,
 		cacheExpiry:   time.Now(),
@@ -123,6 +132,7 @@ func (r *SpeedtestNetRunner) RunTest(ctx context.Context, opts *types.TestOption
 				IsComplete:  false,
 				IsScheduled: opts.IsScheduled,
 				TestType:    "speedtest",
+				RunID:       opts.RunID,
 			})
 		}
 	}); err != nil {
@@ -140,6 +150,7 @@ func (r *SpeedtestNetRunner) RunTest(ctx context.Context, opts *types.TestOption
 			IsComplete:  false,
 			IsScheduled: opts.IsScheduled,
 			TestType:    "speedtest",
+			RunID:       opts.RunID,
 		})
 	}
 
@@ -169,6 +180,7 @@ func (r *SpeedtestNetRunner) RunTest(ctx context.Context, opts *types.TestOption
 						IsComplete:  progress >= 100,
 						IsScheduled: opts.IsScheduled,
 						TestType:    "speedtest",
+						RunID:       opts.RunID,
 					})
 					lastUpdate.Store(now)
 				}
@@ -197,6 +209,7 @@ func (r *SpeedtestNetRunner) RunTest(ctx context.Context, opts *types.TestOption
 				IsComplete:  true,
 				IsScheduled: opts.IsScheduled,
 				TestType:    "speedtest",
+				RunID:       opts.RunID,
 			})
 		}
 	}
@@ -227,6 +240,7 @@ func (r *SpeedtestNetRunner) RunTest(ctx context.Context, opts *types.TestOption
 						IsComplete:  progress >= 100,
 						IsScheduled: opts.IsScheduled,
 						TestType:    "speedtest",
+						RunID:       opts.RunID,
 					})
 					lastUpdate.Store(now)
 				}
@@ -255,6 +269,7 @@ func (r *SpeedtestNetRunner) RunTest(ctx context.Context, opts *types.TestOption
 				IsComplete:  true,
 				IsScheduled: opts.IsScheduled,
 				TestType:    "speedtest",
+				RunID:       opts.RunID,
 			})
 		}
 
@@ -267,6 +282,7 @@ func (r *SpeedtestNetRunner) RunTest(ctx context.Context, opts *types.TestOption
 				IsComplete:  true,
 				IsScheduled: opts.IsScheduled,
 				TestType:    "speedtest",
+				RunID:       opts.RunID,
 			})
 		}
 	}
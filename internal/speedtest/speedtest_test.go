@@ -0,0 +1,99 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/types"
+)
+
+func TestAcquireSlotQueuesBeyondLimit(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		updates []types.SpeedUpdate
+	)
+	svc := &service{
+		queue: newSlotQueue(1),
+		broadcastUpdate: func(update types.SpeedUpdate) {
+			mu.Lock()
+			defer mu.Unlock()
+			updates = append(updates, update)
+		},
+	}
+
+	require.NoError(t, svc.acquireSlot(context.Background(), &types.TestOptions{}))
+
+	acquired := make(chan struct{})
+	go func() {
+		assert.NoError(t, svc.acquireSlot(context.Background(), &types.TestOptions{UseOokla: true, IsScheduled: true}))
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second test started while the only slot was taken")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	stats := svc.QueueStats()
+	assert.Equal(t, 1, stats.Limit)
+	assert.Equal(t, 1, stats.Queued)
+	assert.Equal(t, 1, stats.Running)
+
+	mu.Lock()
+	require.Len(t, updates, 1)
+	assert.Equal(t, "queued", updates[0].Type)
+	assert.Equal(t, "ookla", updates[0].TestType)
+	assert.True(t, updates[0].IsScheduled)
+	mu.Unlock()
+
+	svc.queue.release()
+	select {
+	case <-acquired:
+		svc.queue.release()
+	case <-time.After(time.Second):
+		t.Fatal("queued test did not start after the slot was released")
+	}
+}
+
+func TestAcquireSlotGivesUpOnContext(t *testing.T) {
+	svc := &service{queue: newSlotQueue(1)}
+
+	require.NoError(t, svc.acquireSlot(context.Background(), &types.TestOptions{}))
+	defer svc.queue.release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := svc.acquireSlot(ctx, &types.TestOptions{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, svc.QueueStats().Queued, "a test that gave up no longer counts as queued")
+}
+
+func TestSpeedTestType(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     types.TestOptions
+		expected string
+	}{
+		{name: "speedtest.net by default", opts: types.TestOptions{}, expected: "speedtest"},
+		{name: "iperf3 with host", opts: types.TestOptions{UseIperf: true, ServerHost: "iperf.example.com:5201"}, expected: "iperf3"},
+		{name: "iperf3 without host falls back", opts: types.TestOptions{UseIperf: true}, expected: "speedtest"},
+		{name: "librespeed", opts: types.TestOptions{UseLibrespeed: true}, expected: "librespeed"},
+		{name: "ookla", opts: types.TestOptions{UseOokla: true}, expected: "ookla"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, speedTestType(&tt.opts))
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/notifications"
+)
+
+// thresholdStateSettingPrefix prefixes the app setting, one per test type, that holds
+// the metrics of the last result past a rule threshold, so transitions survive restarts
+const thresholdStateSettingPrefix = "speedtest_threshold_state_"
+
+// thresholdStateOK is the stored state of a test type whose last result was within
+// every threshold
+const thresholdStateOK = "ok"
+
+// trackThresholds compares a result against the ping, download and upload rule
+// thresholds and the state left by the previous result of the same test type. A metric
+// crossing a threshold sends its threshold event, and a result back within every
+// threshold after one that wasn't sends the recovered event. Results that stay past a
+// threshold or stay within them are quiet.
+func (h *DefaultResultHandler) trackThresholds(testType string, result *notifications.SpeedTestResult) {
+	if h.db == nil || h.notifier == nil {
+		return
+	}
+
+	// Held across the read and write so concurrent tests can't both see the old state
+	h.thresholdMu.Lock()
+	defer h.thresholdMu.Unlock()
+
+	breaches := h.notifier.SpeedTestBreaches(result)
+	if len(breaches) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key := thresholdStateSettingPrefix + testType
+	stored, err := h.db.GetAppSetting(ctx, key)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		log.Error().Err(err).Str("test_type", testType).Msg("Failed to load speed test threshold state")
+		return
+	}
+
+	previous := parseThresholdState(stored)
+	current := nextThresholdState(previous, breaches)
+	state := formatThresholdState(current)
+	if state == stored {
+		return
+	}
+
+	for _, metric := range current {
+		if slices.Contains(previous, metric) {
+			continue
+		}
+		if err := h.notifier.SendSpeedTestThresholdNotification(result, metric); err != nil {
+			log.Error().Err(err).Str("test_type", testType).Str("metric", metric).Msg("Failed to send speed test threshold notification")
+		}
+	}
+	if len(current) == 0 && len(previous) > 0 {
+		if err := h.notifier.SendSpeedTestRecoveredNotification(result); err != nil {
+			log.Error().Err(err).Str("test_type", testType).Msg("Failed to send speed test recovered notification")
+		}
+	}
+
+	if err := h.db.SetAppSetting(ctx, key, state); err != nil {
+		log.Error().Err(err).Str("test_type", testType).Str("state", state).Msg("Failed to update speed test threshold state")
+	}
+}
+
+// nextThresholdState applies the breaches of a result to the previous state. A metric
+// the result didn't measure, or without a rule threshold, keeps its previous state.
+func nextThresholdState(previous []string, breaches map[string]bool) []string {
+	var current []string
+	for _, metric := range previous {
+		if breached, ok := breaches[metric]; !ok || breached {
+			current = append(current, metric)
+		}
+	}
+	for metric, breached := range breaches {
+		if breached && !slices.Contains(current, metric) {
+			current = append(current, metric)
+		}
+	}
+	slices.Sort(current)
+	return current
+}
+
+// parseThresholdState returns the metrics past a threshold of a stored state. A missing
+// state, before the first result, counts as ok.
+func parseThresholdState(state string) []string {
+	if state == "" || state == thresholdStateOK {
+		return nil
+	}
+	return strings.Split(state, ",")
+}
+
+// formatThresholdState is the inverse of parseThresholdState
+func formatThresholdState(metrics []string) string {
+	if len(metrics) == 0 {
+		return thresholdStateOK
+	}
+	return strings.Join(metrics, ",")
+}
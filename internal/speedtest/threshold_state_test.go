@@ -0,0 +1,137 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/notifications"
+)
+
+// thresholdDB keeps app settings in memory, serves speed test rules per event and
+// records the events notifications were sent for
+type thresholdDB struct {
+	database.Service
+	settings map[string]string
+	rules    map[string][]database.NotificationRule
+	eventIDs map[int64]string
+	sent     []string
+}
+
+func (d *thresholdDB) GetAppSetting(ctx context.Context, key string) (string, error) {
+	value, ok := d.settings[key]
+	if !ok {
+		return "", database.ErrNotFound
+	}
+	return value, nil
+}
+
+func (d *thresholdDB) SetAppSetting(ctx context.Context, key, value string) error {
+	d.settings[key] = value
+	return nil
+}
+
+func (d *thresholdDB) GetEnabledRulesForEvent(category, eventType string) ([]database.NotificationRule, error) {
+	return d.rules[eventType], nil
+}
+
+func (d *thresholdDB) CheckThreshold(rule *database.NotificationRule, value float64) bool {
+	if *rule.ThresholdOperator == "gt" {
+		return value > *rule.ThresholdValue
+	}
+	return value < *rule.ThresholdValue
+}
+
+func (d *thresholdDB) LogNotification(channelID, eventID int64, success bool, errorMessage, payload *string) error {
+	d.sent = append(d.sent, d.eventIDs[eventID])
+	return nil
+}
+
+func newThresholdDB(t *testing.T) *thresholdDB {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+
+	channel := &database.NotificationChannel{ID: 1, URL: srv.URL, Enabled: true}
+	rule := func(eventID int64, operator string, threshold float64) database.NotificationRule {
+		return database.NotificationRule{ID: eventID, ChannelID: 1, EventID: eventID, Enabled: true, ThresholdValue: &threshold, ThresholdOperator: &operator, Channel: channel}
+	}
+
+	return &thresholdDB{
+		settings: map[string]string{},
+		rules: map[string][]database.NotificationRule{
+			database.NotificationEventSpeedtestPingHigh:    {rule(1, "gt", 50)},
+			database.NotificationEventSpeedtestDownloadLow: {rule(2, "lt", 100)},
+			database.NotificationEventSpeedtestRecovered:   {{ID: 3, ChannelID: 1, EventID: 3, Enabled: true, Channel: channel}},
+			database.NotificationEventSpeedtestUploadLow:   {{ID: 4, ChannelID: 1, EventID: 4, Enabled: true, Channel: channel}},
+		},
+		eventIDs: map[int64]string{
+			1: database.NotificationEventSpeedtestPingHigh,
+			2: database.NotificationEventSpeedtestDownloadLow,
+			3: database.NotificationEventSpeedtestRecovered,
+			4: database.NotificationEventSpeedtestUploadLow,
+		},
+	}
+}
+
+func TestTrackThresholds(t *testing.T) {
+	db := newThresholdDB(t)
+	notifier, err := notifications.NewNotifier(db, config.New())
+	require.NoError(t, err)
+	h := NewResultHandler(db, notifier)
+
+	steps := []struct {
+		name      string
+		result    notifications.SpeedTestResult
+		wantSent  []string
+		wantState string
+	}{
+		{name: "first result ok", result: notifications.SpeedTestResult{Download: 150, Ping: 10}, wantState: "ok"},
+		{name: "download drops", result: notifications.SpeedTestResult{Download: 50, Ping: 10}, wantSent: []string{database.NotificationEventSpeedtestDownloadLow}, wantState: "download"},
+		{name: "download stays low", result: notifications.SpeedTestResult{Download: 40, Ping: 10}, wantState: "download"},
+		{name: "ping rises too", result: notifications.SpeedTestResult{Download: 60, Ping: 80}, wantSent: []string{database.NotificationEventSpeedtestPingHigh}, wantState: "download,ping"},
+		{name: "download recovers alone", result: notifications.SpeedTestResult{Download: 150, Ping: 80}, wantState: "ping"},
+		{name: "ping unmeasured", result: notifications.SpeedTestResult{Download: 150}, wantState: "ping"},
+		{name: "all recovered", result: notifications.SpeedTestResult{Download: 150, Ping: 10}, wantSent: []string{database.NotificationEventSpeedtestRecovered}, wantState: "ok"},
+		{name: "stays ok", result: notifications.SpeedTestResult{Download: 150, Ping: 10}, wantState: "ok"},
+	}
+
+	for _, step := range steps {
+		db.sent = nil
+		h.trackThresholds("speedtest", &step.result)
+
+		assert.Equal(t, step.wantSent, db.sent, step.name)
+		assert.Equal(t, step.wantState, db.settings[thresholdStateSettingPrefix+"speedtest"], step.name)
+	}
+
+	// Each test type has its own state
+	db.sent = nil
+	h.trackThresholds("iperf3", &notifications.SpeedTestResult{Download: 50})
+	assert.Equal(t, []string{database.NotificationEventSpeedtestDownloadLow}, db.sent)
+	assert.Equal(t, "ok", db.settings[thresholdStateSettingPrefix+"speedtest"])
+}
+
+func TestSendSpeedTestNotificationSkipsThresholdRules(t *testing.T) {
+	db := newThresholdDB(t)
+	notifier, err := notifications.NewNotifier(db, config.New())
+	require.NoError(t, err)
+
+	// Ping and download have rule thresholds and only notify on crossings, the upload
+	// rule has none and still fires for every result
+	require.NoError(t, notifier.SendSpeedTestNotification(&notifications.SpeedTestResult{Download: 50, Upload: 10, Ping: 80}))
+	assert.Equal(t, []string{database.NotificationEventSpeedtestUploadLow}, db.sent)
+}
+
+func TestNextThresholdState(t *testing.T) {
+	assert.Equal(t, []string{"download", "ping"}, nextThresholdState([]string{"ping"}, map[string]bool{"download": true}))
+	assert.Nil(t, nextThresholdState([]string{"ping"}, map[string]bool{"ping": false}))
+	assert.Equal(t, []string{"upload"}, nextThresholdState([]string{"upload"}, map[string]bool{"ping": false}))
+}
@@ -6,6 +6,7 @@ package speedtest
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -15,32 +16,65 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/oschwald/geoip2-golang"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/autobrr/netronome/internal/config"
+	"github.com/autobrr/netronome/internal/logger"
+	"github.com/autobrr/netronome/internal/telemetry"
 	"github.com/autobrr/netronome/internal/types"
 )
 
-// Global GeoIP database instances
+// Global GeoIP database instances, swapped atomically when the updater downloads new ones
 var (
-	countryDB *geoip2.Reader
-	asnDB     *geoip2.Reader
+	countryDB atomic.Pointer[geoip2.Reader]
+	asnDB     atomic.Pointer[geoip2.Reader]
+
+	// skipPrivateGeoIP avoids mmdb reads for hops that can never match a public record
+	skipPrivateGeoIP bool
+
+	// privateHopNets are the ranges skipped by skipPrivateGeoIP, from geoip.private_hop_ranges
+	privateHopNets = parsePrivateHopRanges(config.DefaultPrivateHopRanges())
+
+	// geoIPInitOnce runs loadGeoIP once, since the settings above and the lookup cache
+	// are read by traceroutes and backfills running concurrently
+	geoIPInitOnce sync.Once
 )
 
-// Initialize GeoIP database
+// initGeoIP loads the GeoIP settings and databases on first use
 func (s *service) initGeoIP() {
+	geoIPInitOnce.Do(s.loadGeoIP)
+}
+
+// Initialize GeoIP database
+func (s *service) loadGeoIP() {
 	// Check if GeoIP is configured and enabled
-	if s.fullConfig == nil || (s.fullConfig.GeoIP.CountryDatabasePath == "" && s.fullConfig.GeoIP.ASNDatabasePath == "") {
+	if s.fullConfig == nil || (s.fullConfig.GeoIP.LicenseKey == "" && s.fullConfig.GeoIP.CountryDatabasePath == "" && s.fullConfig.GeoIP.ASNDatabasePath == "") {
 		log.Info().Msg("GeoIP not configured. Country and ASN detection disabled. Configure [geoip] section in config to enable.")
 		return
 	}
 
+	skipPrivateGeoIP = s.fullConfig.GeoIP.SkipPrivateHops
+	if s.fullConfig.GeoIP.PrivateHopRanges != nil {
+		privateHopNets = parsePrivateHopRanges(s.fullConfig.GeoIP.PrivateHopRanges)
+	}
+	geoIPLookups.Store(newGeoIPCache(s.fullConfig.GeoIP.CacheSize, parseGeoIPCacheTTL(s.fullConfig.GeoIP.CacheTTL)))
+
+	// With a license key the databases are downloaded and kept fresh in the background
+	if s.fullConfig.GeoIP.LicenseKey != "" {
+		startGeoIPUpdater(s.fullConfig.GeoIP)
+		return
+	}
+
 	// Load Country database if configured
 	if s.fullConfig.GeoIP.CountryDatabasePath != "" {
-		if db, err := geoip2.Open(s.fullConfig.GeoIP.CountryDatabasePath); err == nil {
-			countryDB = db
+		if err := loadGeoIPDatabase(&countryDB, s.fullConfig.GeoIP.CountryDatabasePath); err == nil {
 			log.Info().Str("path", s.fullConfig.GeoIP.CountryDatabasePath).Msg("GeoIP Country database loaded successfully")
 		} else {
 			log.Warn().Str("path", s.fullConfig.GeoIP.CountryDatabasePath).Err(err).Msg("Failed to load GeoIP Country database")
@@ -49,63 +83,119 @@ func (s *service) initGeoIP() {
 
 	// Load ASN database if configured
 	if s.fullConfig.GeoIP.ASNDatabasePath != "" {
-		if db, err := geoip2.Open(s.fullConfig.GeoIP.ASNDatabasePath); err == nil {
-			asnDB = db
+		if err := loadGeoIPDatabase(&asnDB, s.fullConfig.GeoIP.ASNDatabasePath); err == nil {
 			log.Info().Str("path", s.fullConfig.GeoIP.ASNDatabasePath).Msg("GeoIP ASN database loaded successfully")
 		} else {
 			log.Warn().Str("path", s.fullConfig.GeoIP.ASNDatabasePath).Err(err).Msg("Failed to load GeoIP ASN database")
 		}
 	}
 
-	if countryDB == nil && asnDB == nil {
+	if !geoIPLoaded() {
 		log.Warn().Msg("No GeoIP databases loaded. See README for setup instructions.")
 	}
 }
 
-// Get country code from IP address
-func getCountryFromIP(ip string) string {
-	if countryDB == nil {
-		return ""
+// geoIPLoaded reports whether a Country or ASN database is available for lookups
+func geoIPLoaded() bool {
+	return countryDB.Load() != nil || asnDB.Load() != nil
+}
+
+// parsePrivateHopRanges parses the configured CIDRs once, skipping invalid entries
+func parsePrivateHopRanges(cidrs []string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			log.Warn().Str("cidr", cidr).Err(err).Msg("Ignoring invalid geoip.private_hop_ranges entry")
+			continue
+		}
+		nets = append(nets, ipNet)
 	}
+	return nets
+}
 
-	netIP := net.ParseIP(ip)
-	if netIP == nil {
-		return ""
+// isPrivateHopIP reports whether an address falls in one of the private hop ranges,
+// by default those non-routable on the public internet
+func isPrivateHopIP(ip net.IP) bool {
+	for _, ipNet := range privateHopNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
 	}
+	return false
+}
 
-	record, err := countryDB.Country(netIP)
-	if err != nil {
+// parseGeoIPCacheTTL falls back to the default for an empty or invalid geoip.cache_ttl
+func parseGeoIPCacheTTL(value string) time.Duration {
+	if value == "" {
+		return defaultGeoIPCacheTTL
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		log.Warn().Str("ttl", value).Msg("Invalid geoip.cache_ttl, using default")
+		return defaultGeoIPCacheTTL
+	}
+	return ttl
+}
+
+// Get country code from IP address
+func getCountryFromIP(ip string) string {
+	db := countryDB.Load()
+	if db == nil {
 		return ""
 	}
 
-	return record.Country.IsoCode
+	return geoIPLookups.Load().lookup("country:"+ip, func() string {
+		netIP := net.ParseIP(ip)
+		if netIP == nil {
+			return ""
+		}
+
+		if skipPrivateGeoIP && isPrivateHopIP(netIP) {
+			return ""
+		}
+
+		record, err := db.Country(netIP)
+		if err != nil {
+			return ""
+		}
+
+		return record.Country.IsoCode
+	})
 }
 
 // Get ASN information from IP address
 func getASNFromIP(ip string) string {
-	if asnDB == nil {
+	db := asnDB.Load()
+	if db == nil {
 		return ""
 	}
 
-	netIP := net.ParseIP(ip)
-	if netIP == nil {
-		return ""
-	}
+	return geoIPLookups.Load().lookup("asn:"+ip, func() string {
+		netIP := net.ParseIP(ip)
+		if netIP == nil {
+			return ""
+		}
 
-	record, err := asnDB.ASN(netIP)
-	if err != nil {
-		return ""
-	}
+		if skipPrivateGeoIP && isPrivateHopIP(netIP) {
+			return ""
+		}
 
-	if record.AutonomousSystemOrganization != "" {
-		return fmt.Sprintf("AS%d %s", record.AutonomousSystemNumber, record.AutonomousSystemOrganization)
-	}
-	return fmt.Sprintf("AS%d", record.AutonomousSystemNumber)
+		record, err := db.ASN(netIP)
+		if err != nil {
+			return ""
+		}
+
+		if record.AutonomousSystemOrganization != "" {
+			return fmt.Sprintf("AS%d %s", record.AutonomousSystemNumber, record.AutonomousSystemOrganization)
+		}
+		return fmt.Sprintf("AS%d", record.AutonomousSystemNumber)
+	})
 }
 
 // Resolve hostname to IP and get country
 func getCountryFromHost(host string) string {
-	if countryDB == nil {
+	if countryDB.Load() == nil {
 		return ""
 	}
 
@@ -114,19 +204,24 @@ func getCountryFromHost(host string) string {
 		return getCountryFromIP(host)
 	}
 
-	// Resolve hostname to IP
-	ips, err := net.LookupIP(host)
-	if err != nil || len(ips) == 0 {
-		return ""
-	}
+	// Cache by hostname too so repeated runs skip the DNS lookup
+	return geoIPLookups.Load().lookupHost("country:"+host, func() (string, error) {
+		ips, err := net.LookupIP(host)
+		if err != nil {
+			return "", err
+		}
+		if len(ips) == 0 {
+			return "", nil
+		}
 
-	// Use the first IP address
-	return getCountryFromIP(ips[0].String())
+		// Use the first IP address
+		return getCountryFromIP(ips[0].String()), nil
+	})
 }
 
 // Resolve hostname to IP and get ASN
 func getASNFromHost(host string) string {
-	if asnDB == nil {
+	if asnDB.Load() == nil {
 		return ""
 	}
 
@@ -135,14 +230,19 @@ func getASNFromHost(host string) string {
 		return getASNFromIP(host)
 	}
 
-	// Resolve hostname to IP
-	ips, err := net.LookupIP(host)
-	if err != nil || len(ips) == 0 {
-		return ""
-	}
+	// Cache by hostname too so repeated runs skip the DNS lookup
+	return geoIPLookups.Load().lookupHost("asn:"+host, func() (string, error) {
+		ips, err := net.LookupIP(host)
+		if err != nil {
+			return "", err
+		}
+		if len(ips) == 0 {
+			return "", nil
+		}
 
-	// Use the first IP address
-	return getASNFromIP(ips[0].String())
+		// Use the first IP address
+		return getASNFromIP(ips[0].String()), nil
+	})
 }
 
 // TracerouteHop represents a single hop in the traceroute path
@@ -166,18 +266,154 @@ type TracerouteResult struct {
 	Hops        []TracerouteHop `json:"hops"`
 	TotalHops   int             `json:"totalHops"`
 	Complete    bool            `json:"complete"`
+	// ResolvedIPs lists every address the destination resolved to
+	ResolvedIPs []string `json:"resolvedIps,omitempty"`
+	// Paths holds one result per traced IP when several resolved IPs were traced
+	Paths []TracerouteResult `json:"paths,omitempty"`
+}
+
+// TracerouteOptions controls how a traceroute runs
+type TracerouteOptions struct {
+	Family AddressFamily
+	// ResolveNames looks up the reverse DNS name of hops reported by address only
+	ResolveNames bool
+}
+
+// MaxTracerouteIPs caps how many resolved IPs are traced for one request
+const MaxTracerouteIPs = 8
+
+// AddressFamily selects the IP version a traceroute runs over
+type AddressFamily string
+
+const (
+	// AddressFamilyAuto traces over the family of the first resolved address
+	AddressFamilyAuto AddressFamily = "auto"
+	AddressFamilyIPv4 AddressFamily = "ipv4"
+	AddressFamilyIPv6 AddressFamily = "ipv6"
+)
+
+var (
+	// tracerouteHeaderRegex matches the first line of traceroute and traceroute6 output
+	tracerouteHeaderRegex = regexp.MustCompile(`^traceroute6? to `)
+	// bracketedHopRegex matches a hop printed as "host [address]", as tracert does
+	bracketedHopRegex = regexp.MustCompile(`^(\S+)\s+\[([^\]]+)\]$`)
+)
+
+// ParseAddressFamily validates an address family name, an empty name means auto
+func ParseAddressFamily(v string) (AddressFamily, error) {
+	switch family := AddressFamily(strings.ToLower(strings.TrimSpace(v))); family {
+	case "":
+		return AddressFamilyAuto, nil
+	case AddressFamilyAuto, AddressFamilyIPv4, AddressFamilyIPv6:
+		return family, nil
+	default:
+		return "", fmt.Errorf("invalid address family %q, must be auto, ipv4 or ipv6", v)
+	}
+}
+
+func ipFamily(ip net.IP) AddressFamily {
+	if ip.To4() != nil {
+		return AddressFamilyIPv4
+	}
+	return AddressFamilyIPv6
+}
+
+// selectFamilyIPs resolves auto to the family of the first address and returns the
+// addresses of the chosen family in resolver order
+func selectFamilyIPs(ips []net.IP, family AddressFamily) (AddressFamily, []string, error) {
+	if len(ips) == 0 {
+		return "", nil, fmt.Errorf("no IP addresses to trace")
+	}
+	if family == "" || family == AddressFamilyAuto {
+		family = ipFamily(ips[0])
+	}
+
+	var selected []string
+	for _, ip := range ips {
+		if ipFamily(ip) == family {
+			selected = append(selected, ip.String())
+		}
+	}
+	if len(selected) == 0 {
+		return "", nil, fmt.Errorf("host has no %s address", family)
+	}
+	return family, selected, nil
+}
+
+// tracerouteCommand picks the binary and family flags for goos. macOS ships a separate
+// IPv4-only traceroute and traceroute6, the others take -4 or -6.
+func tracerouteCommand(goos string, family AddressFamily) (string, []string) {
+	switch goos {
+	case "windows":
+		switch family {
+		case AddressFamilyIPv4:
+			return "tracert", []string{"-4"}
+		case AddressFamilyIPv6:
+			return "tracert", []string{"-6"}
+		}
+		return "tracert", nil
+	case "darwin":
+		if family == AddressFamilyIPv6 {
+			return "traceroute6", nil
+		}
+		return "traceroute", nil
+	default:
+		switch family {
+		case AddressFamilyIPv4:
+			return "traceroute", []string{"-4"}
+		case AddressFamilyIPv6:
+			return "traceroute", []string{"-6"}
+		}
+		return "traceroute", nil
+	}
+}
+
+// splitHopTarget splits a hop's host column into hostname and address, accepting
+// "host [address]", "[address]" and bare addresses
+func splitHopTarget(target string) (string, string) {
+	target = strings.TrimSpace(target)
+	if match := bracketedHopRegex.FindStringSubmatch(target); match != nil {
+		return match[1], match[2]
+	}
+	ip := strings.TrimSuffix(strings.TrimPrefix(target, "["), "]")
+	return ip, ip
+}
+
+// sameIP compares addresses by value so differently written IPv6 forms match
+func sameIP(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	return ipA != nil && ipB != nil && ipA.Equal(ipB)
 }
 
 // RunTraceroute executes a traceroute test against the specified host
-func (s *service) RunTraceroute(ctx context.Context, host string) (*TracerouteResult, error) {
+func (s *service) RunTraceroute(ctx context.Context, host string, opts TracerouteOptions) (*TracerouteResult, error) {
+	ctx, span := telemetry.Start(ctx, "traceroute",
+		attribute.String("traceroute.host", host),
+		attribute.String("traceroute.family", string(opts.Family)),
+	)
+	result, err := s.runTraceroute(ctx, host, opts)
+	if result != nil {
+		span.SetAttributes(
+			attribute.String("traceroute.ip", result.IP),
+			attribute.Int("traceroute.hops", result.TotalHops),
+			attribute.Bool("traceroute.complete", result.Complete),
+		)
+	}
+	telemetry.End(span, err)
+	return result, err
+}
+
+// runTraceroute resolves host and traces the route to its addresses
+func (s *service) runTraceroute(ctx context.Context, host string, opts TracerouteOptions) (*TracerouteResult, error) {
 	if host == "" {
 		return nil, fmt.Errorf("host is required for traceroute test")
 	}
 
 	// Initialize GeoIP databases if not already done
-	if countryDB == nil && asnDB == nil {
-		s.initGeoIP()
-	}
+	s.initGeoIP()
 
 	// Extract hostname from URL if it's a full URL
 	originalHost := host
@@ -198,8 +434,7 @@ func (s *service) RunTraceroute(ctx context.Context, host string) (*TracerouteRe
 		host = normalizeTracerouteHost(host)
 	}
 
-	// Resolve the destination hostname to IP address
-	var destinationIP string
+	// Resolve the destination hostname to IP addresses
 	ips, err := net.LookupIP(host)
 	if err != nil {
 		log.Error().Err(err).
@@ -207,19 +442,82 @@ func (s *service) RunTraceroute(ctx context.Context, host string) (*TracerouteRe
 			Msg("Failed to resolve hostname")
 		return nil, fmt.Errorf("failed to resolve hostname '%s': %w", host, err)
 	}
-	if len(ips) > 0 {
-		destinationIP = ips[0].String()
-		log.Info().
-			Str("host", host).
-			Str("resolved_ip", destinationIP).
-			Msg("Resolved destination hostname to IP")
-	} else {
+	if len(ips) == 0 {
 		log.Error().
 			Str("host", host).
 			Msg("No IP addresses found for hostname")
 		return nil, fmt.Errorf("no IP addresses found for hostname '%s'", host)
 	}
 
+	resolvedIPs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		resolvedIPs = append(resolvedIPs, ip.String())
+	}
+	family, familyIPs, err := selectFamilyIPs(ips, opts.Family)
+	if err != nil {
+		return nil, fmt.Errorf("cannot trace '%s': %w", host, err)
+	}
+	log.Info().
+		Str("host", host).
+		Strs("resolved_ips", resolvedIPs).
+		Str("family", string(family)).
+		Msg("Resolved destination hostname to IP")
+
+	targets := tracerouteTargets(familyIPs, s.config.TracerouteMaxIPs)
+	opts.Family = family
+	if len(targets) == 1 {
+		// Trace the hostname itself so the output matches what the user asked for
+		result, err := s.runTracerouteTo(ctx, originalHost, host, targets[0], opts)
+		if err != nil {
+			return nil, err
+		}
+		result.ResolvedIPs = resolvedIPs
+		return result, nil
+	}
+
+	// Different backends of a load-balanced host may route differently, so trace each one
+	var paths []TracerouteResult
+	for _, ip := range targets {
+		path, err := s.runTracerouteTo(ctx, originalHost, ip, ip, opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			log.Warn().Err(err).
+				Str("host", host).
+				Str("ip", ip).
+				Msg("Traceroute to resolved IP failed")
+			continue
+		}
+		paths = append(paths, *path)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("traceroute failed for all resolved IPs of '%s'", host)
+	}
+
+	result := paths[0]
+	result.ResolvedIPs = resolvedIPs
+	result.Paths = paths
+	return &result, nil
+}
+
+// tracerouteTargets picks which resolved IPs to trace, keeping resolver order.
+// A max below 2 traces only the first address.
+func tracerouteTargets(resolvedIPs []string, maxIPs int) []string {
+	if maxIPs < 1 {
+		maxIPs = 1
+	}
+	if maxIPs > MaxTracerouteIPs {
+		maxIPs = MaxTracerouteIPs
+	}
+	if len(resolvedIPs) > maxIPs {
+		return resolvedIPs[:maxIPs]
+	}
+	return resolvedIPs
+}
+
+// runTracerouteTo runs a single traceroute to target, expecting it to end at destinationIP
+func (s *service) runTracerouteTo(ctx context.Context, originalHost, host, destinationIP string, opts TracerouteOptions) (*TracerouteResult, error) {
 	// Check for Docker environment indicators
 	inDocker := s.isRunningInDocker()
 
@@ -230,18 +528,25 @@ func (s *service) RunTraceroute(ctx context.Context, host string) (*TracerouteRe
 		Str("destination_ip", destinationIP).
 		Msg("Starting traceroute test")
 
-	// Check if traceroute command is available
-	cmdName := "traceroute"
-	if runtime.GOOS == "windows" {
-		cmdName = "tracert"
+	if s.tracerouteConfig().Native {
+		result, err := s.runNativeTraceroute(ctx, originalHost, host, destinationIP, opts)
+		if !errors.Is(err, errRawSocketUnavailable) {
+			return result, err
+		}
+		log.Warn().Err(err).
+			Str("host", host).
+			Msg("Native traceroute needs raw sockets, falling back to the system traceroute")
 	}
 
+	// Check if traceroute command is available
+	cmdName, familyArgs := tracerouteCommand(runtime.GOOS, opts.Family)
+
 	if _, err := exec.LookPath(cmdName); err != nil {
 		return nil, fmt.Errorf("%s command not found: %w", cmdName, err)
 	}
 
 	// Build traceroute command based on OS
-	args := s.buildTracerouteArgs(host)
+	args := append(familyArgs, s.buildTracerouteArgs(host)...)
 
 	log.Info().
 		Str("host", host).
@@ -268,8 +573,13 @@ func (s *service) RunTraceroute(ctx context.Context, host string) (*TracerouteRe
 		return nil, fmt.Errorf("failed to start traceroute command: %w", err)
 	}
 
+	var names *hopNameResolver
+	if opts.ResolveNames {
+		names = newHopNameResolver(timeoutCtx)
+	}
+
 	// Read output line by line and parse for streaming updates
-	result, err := s.parseTracerouteOutputStreaming(stdout, originalHost, host, destinationIP, cmd)
+	result, err := s.parseTracerouteOutputStreaming(stdout, originalHost, host, destinationIP, cmd, names)
 	if err != nil {
 		_ = cmd.Process.Kill() // Kill the process if parsing fails
 		return nil, fmt.Errorf("failed to parse traceroute output: %w", err)
@@ -335,34 +645,60 @@ func normalizeTracerouteHost(host string) string {
 	return host
 }
 
+// tracerouteConfig returns the traceroute settings, using the defaults for unset values
+func (s *service) tracerouteConfig() config.TracerouteConfig {
+	cfg := s.config.Traceroute
+	if cfg.MaxHops <= 0 {
+		cfg.MaxHops = config.DefaultTracerouteMaxHops
+	}
+	if cfg.Queries <= 0 {
+		cfg.Queries = config.DefaultTracerouteQueries
+	}
+	return cfg
+}
+
+// tracerouteMaxConsecutiveTimeouts is how many timed out hops in a row end a traceroute.
+// With fewer queries per hop a single lost probe times out the hop, so more are allowed.
+func tracerouteMaxConsecutiveTimeouts(cfg config.TracerouteConfig) int {
+	return min(max(3, 6-cfg.Queries), cfg.MaxHops)
+}
+
 // buildTracerouteArgs builds traceroute command arguments based on the operating system
 func (s *service) buildTracerouteArgs(host string) []string {
+	return buildTracerouteArgs(runtime.GOOS, host, s.tracerouteConfig(), s.isRunningInDocker())
+}
+
+func buildTracerouteArgs(goos, host string, cfg config.TracerouteConfig, inDocker bool) []string {
+	maxHops := strconv.Itoa(cfg.MaxHops)
+	queries := strconv.Itoa(cfg.Queries)
+
 	var args []string
 
-	switch runtime.GOOS {
+	switch goos {
 	case "darwin", "linux":
 		args = []string{
 			"-w", "2", // Wait 2 seconds for response (faster than default)
-			"-m", "30", // Max 30 hops
-			"-q", "3", // 3 queries per hop for RTT1, RTT2, RTT3
+			"-m", maxHops, // Max hops
+			"-q", queries, // Queries per hop, the first three fill RTT1, RTT2, RTT3
 			host,
 		}
 
 		log.Debug().
-			Str("os", runtime.GOOS).
+			Str("os", goos).
 			Str("host", host).
 			Strs("final_args", args).
-			Bool("docker_detected", s.isRunningInDocker()).
+			Bool("docker_detected", inDocker).
 			Msg("Built traceroute arguments for Unix/Linux/macOS")
 	case "windows":
+		// tracert has no option for the number of queries per hop, it always sends 3
 		args = []string{
 			"-w", "2000", // Wait 2000 milliseconds for response (faster streaming)
-			"-h", "30", // Max 30 hops
+			"-h", maxHops, // Max hops
 			host,
 		}
 
 		log.Debug().
-			Str("os", runtime.GOOS).
+			Str("os", goos).
 			Str("host", host).
 			Strs("final_args", args).
 			Msg("Built traceroute arguments for Windows")
@@ -370,8 +706,8 @@ func (s *service) buildTracerouteArgs(host string) []string {
 		// Default to Linux/Unix style
 		args = []string{
 			"-w", "2",
-			"-m", "30",
-			"-q", "3",
+			"-m", maxHops,
+			"-q", queries,
 			host,
 		}
 	}
@@ -410,7 +746,7 @@ func (s *service) parseUnixTracerouteOutput(lines []string, result *TracerouteRe
 	// Extract destination IP from first line
 	if len(lines) > 0 {
 		firstLine := lines[0]
-		if strings.Contains(firstLine, "traceroute to") {
+		if tracerouteHeaderRegex.MatchString(strings.TrimSpace(firstLine)) {
 			// Extract IP from parentheses
 			ipRegex := regexp.MustCompile(`\(([^)]+)\)`)
 			if match := ipRegex.FindStringSubmatch(firstLine); match != nil {
@@ -422,16 +758,16 @@ func (s *service) parseUnixTracerouteOutput(lines []string, result *TracerouteRe
 	// Regex patterns for parsing hop lines
 	// Updated to handle both hostname and IP, or just IP
 	// Updated regex patterns for single query per hop
-	hopRegex := regexp.MustCompile(`^\s*(\d+)\s+([^\s]+)\s+\(([^)]+)\)\s+([\d.]+)\s+ms`)
+	hopRegex := regexp.MustCompile(`^\s*(\d+)\s+([^\s]+)\s+[(\[]([^)\]]+)[)\]]\s+([\d.]+)\s+ms`)
 	hopRegexIPOnly := regexp.MustCompile(`^\s*(\d+)\s+([^\s]+)\s+([0-9A-Fa-f:.]+)\s+ms`)
 	// Also support the old 3-query format for backward compatibility
-	hopRegex3 := regexp.MustCompile(`^\s*(\d+)\s+([^\s]+)\s+\(([^)]+)\)\s+([\d.]+)\s+ms\s+([\d.]+)\s+ms\s+([\d.]+)\s+ms`)
+	hopRegex3 := regexp.MustCompile(`^\s*(\d+)\s+([^\s]+)\s+[(\[]([^)\]]+)[)\]]\s+([\d.]+)\s+ms\s+([\d.]+)\s+ms\s+([\d.]+)\s+ms`)
 	hopRegexIPOnly3 := regexp.MustCompile(`^\s*(\d+)\s+([^\s]+)\s+([0-9A-Fa-f:.]+)\s+ms\s+([\d.]+)\s+ms\s+([\d.]+)\s+ms`)
-	timeoutRegex := regexp.MustCompile(`^\s*(\d+)\s+\*\s+\*\s+\*`)
+	timeoutRegex := regexp.MustCompile(`^\s*(\d+)(?:\s+\*)+\s*$`)
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "traceroute to") {
+		if line == "" || tracerouteHeaderRegex.MatchString(line) {
 			continue
 		}
 
@@ -474,7 +810,7 @@ func (s *service) parseUnixTracerouteOutput(lines []string, result *TracerouteRe
 		} else if match := hopRegexIPOnly3.FindStringSubmatch(line); match != nil {
 			// Try to match hop line with IP only (3-query format)
 			hopNum, _ := strconv.Atoi(match[1])
-			ip := match[2]
+			ip := strings.Trim(match[2], "[]")
 			rtt1, _ := strconv.ParseFloat(match[3], 64)
 			rtt2, _ := strconv.ParseFloat(match[4], 64)
 			rtt3, _ := strconv.ParseFloat(match[5], 64)
@@ -513,7 +849,7 @@ func (s *service) parseUnixTracerouteOutput(lines []string, result *TracerouteRe
 		} else if match := hopRegexIPOnly.FindStringSubmatch(line); match != nil {
 			// Try to match hop line with IP only (single query format)
 			hopNum, _ := strconv.Atoi(match[1])
-			ip := match[2]
+			ip := strings.Trim(match[2], "[]")
 			rtt1, _ := strconv.ParseFloat(match[3], 64)
 
 			hop := TracerouteHop{
@@ -591,7 +927,7 @@ func (s *service) parseWindowsTracerouteOutput(lines []string, result *Tracerout
 			rtt1Str := strings.TrimPrefix(match[2], "<")
 			rtt2Str := strings.TrimPrefix(match[3], "<")
 			rtt3Str := strings.TrimPrefix(match[4], "<")
-			ip := strings.TrimSpace(match[5])
+			hostname, ip := splitHopTarget(match[5])
 
 			rtt1, _ := strconv.ParseFloat(rtt1Str, 64)
 			rtt2, _ := strconv.ParseFloat(rtt2Str, 64)
@@ -599,7 +935,7 @@ func (s *service) parseWindowsTracerouteOutput(lines []string, result *Tracerout
 
 			hop := TracerouteHop{
 				Number:      hopNum,
-				Host:        ip,
+				Host:        hostname,
 				IP:          ip,
 				RTT1:        rtt1,
 				RTT2:        rtt2,
@@ -618,33 +954,12 @@ func (s *service) parseWindowsTracerouteOutput(lines []string, result *Tracerout
 	return result, nil
 }
 
-// parseTracerouteOutputStreaming parses traceroute output line by line and broadcasts updates
-func (s *service) parseTracerouteOutputStreaming(stdout io.ReadCloser, originalHost, host, destinationIP string, cmd *exec.Cmd) (*TracerouteResult, error) {
-	result := &TracerouteResult{
-		Destination: originalHost,
-		Hops:        []TracerouteHop{},
-	}
-
+// parseTracerouteOutputStreaming parses traceroute output line by line and broadcasts
+// updates. Hops are named by names when it is set.
+func (s *service) parseTracerouteOutputStreaming(stdout io.ReadCloser, originalHost, host, destinationIP string, cmd *exec.Cmd, names *hopNameResolver) (*TracerouteResult, error) {
+	stream := s.newTracerouteStream(originalHost, host, destinationIP)
+	stream.names = names
 	scanner := bufio.NewScanner(stdout)
-	maxHops := 30               // Default max hops
-	maxConsecutiveTimeouts := 3 // Stop after 3 consecutive timeouts (reduced from 5)
-	consecutiveTimeouts := 0
-	reachedDestination := false
-
-	// Send initial update
-	if s.broadcastTracerouteUpdate != nil {
-		s.broadcastTracerouteUpdate(types.TracerouteUpdate{
-			Type:        "traceroute",
-			Host:        host,
-			Progress:    0.0,
-			IsComplete:  false,
-			CurrentHop:  0,
-			TotalHops:   maxHops,
-			Hops:        []TracerouteHop{},
-			Destination: originalHost,
-			IP:          "",
-		})
-	}
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -653,10 +968,10 @@ func (s *service) parseTracerouteOutputStreaming(stdout io.ReadCloser, originalH
 		}
 
 		// Extract destination IP from first line
-		if len(result.Hops) == 0 && strings.Contains(line, "traceroute to") {
+		if len(stream.result.Hops) == 0 && tracerouteHeaderRegex.MatchString(line) {
 			ipRegex := regexp.MustCompile(`\(([^)]+)\)`)
 			if match := ipRegex.FindStringSubmatch(line); match != nil {
-				result.IP = match[1]
+				stream.result.IP = match[1]
 			}
 			continue
 		}
@@ -668,113 +983,186 @@ func (s *service) parseTracerouteOutputStreaming(stdout io.ReadCloser, originalH
 
 		// Parse hop line
 		hop := s.parseHopLine(line)
-		if hop != nil {
-			result.Hops = append(result.Hops, *hop)
-
-			// Check if we've reached the destination IP
-			if !hop.Timeout && destinationIP != "" && hop.IP == destinationIP {
-				reachedDestination = true
-				log.Info().
-					Str("destination_ip", destinationIP).
-					Int("hop", hop.Number).
-					Msg("Reached destination IP, traceroute will complete")
+		if hop != nil && stream.addHop(*hop) {
+			// Kill the traceroute process to stop further output
+			if cmd.Process != nil {
+				_ = cmd.Process.Kill()
 			}
+			break
+		}
+	}
 
-			// Track consecutive timeouts
-			if hop.Timeout {
-				consecutiveTimeouts++
-				log.Debug().
-					Int("hop", hop.Number).
-					Int("consecutive_timeouts", consecutiveTimeouts).
-					Msg("Timeout detected")
-			} else {
-				consecutiveTimeouts = 0 // Reset counter on successful hop
-			}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading traceroute output: %w", err)
+	}
 
-			// Calculate progress and broadcast update
-			progress := float64(hop.Number) / float64(maxHops) * 100.0
-			if progress > 100.0 {
-				progress = 100.0
-			}
+	return stream.finish(), nil
+}
 
-			if s.broadcastTracerouteUpdate != nil {
-				s.broadcastTracerouteUpdate(types.TracerouteUpdate{
-					Type:        "traceroute",
-					Host:        host,
-					Progress:    progress,
-					IsComplete:  false,
-					CurrentHop:  hop.Number,
-					TotalHops:   maxHops,
-					Hops:        result.Hops, // Include all hops found so far
-					Destination: result.Destination,
-					IP:          result.IP,
-				})
-			}
+// tracerouteStream collects the hops of a running traceroute, broadcasts progress and
+// decides when to stop early. Both the system binary and the native traceroute feed it.
+type tracerouteStream struct {
+	s             *service
+	host          string
+	destinationIP string
+	result        *TracerouteResult
+
+	maxHops                int
+	maxConsecutiveTimeouts int
+	consecutiveTimeouts    int
+	reachedDestination     bool
+	hotLog                 zerolog.Logger
+
+	// names resolves hops reported by address only, nil to leave them unnamed
+	names *hopNameResolver
+}
 
-			log.Debug().
-				Int("hop", hop.Number).
-				Str("host", hop.Host).
-				Str("ip", hop.IP).
-				Bool("timeout", hop.Timeout).
-				Float64("progress", progress).
-				Msg("Parsed traceroute hop")
-
-			// Check if we should terminate early
-			shouldTerminate := false
-			terminationReason := ""
-
-			// Terminate due to consecutive timeouts
-			if consecutiveTimeouts >= maxConsecutiveTimeouts {
-				shouldTerminate = true
-				terminationReason = "consecutive timeouts"
-			}
+// newTracerouteStream starts a traceroute stream and sends the initial update
+func (s *service) newTracerouteStream(originalHost, host, destinationIP string) *tracerouteStream {
+	tracerouteCfg := s.tracerouteConfig()
+	stream := &tracerouteStream{
+		s:             s,
+		host:          host,
+		destinationIP: destinationIP,
+		result: &TracerouteResult{
+			Destination: originalHost,
+			Hops:        []TracerouteHop{},
+		},
+		maxHops:                tracerouteCfg.MaxHops,
+		maxConsecutiveTimeouts: tracerouteMaxConsecutiveTimeouts(tracerouteCfg),
+		hotLog:                 logger.Sampled(),
+	}
 
-			// If we reached destination and have timeouts after, terminate sooner
-			if reachedDestination && consecutiveTimeouts >= 2 {
-				shouldTerminate = true
-				terminationReason = "reached destination with subsequent timeouts"
-			}
+	// Send initial update
+	if s.broadcastTracerouteUpdate != nil {
+		s.broadcastTracerouteUpdate(types.TracerouteUpdate{
+			Type:        "traceroute",
+			Host:        host,
+			Progress:    0.0,
+			IsComplete:  false,
+			CurrentHop:  0,
+			TotalHops:   stream.maxHops,
+			Hops:        []TracerouteHop{},
+			Destination: originalHost,
+			IP:          "",
+		})
+	}
 
-			if shouldTerminate {
-				log.Info().
-					Int("consecutive_timeouts", consecutiveTimeouts).
-					Int("max_allowed", maxConsecutiveTimeouts).
-					Int("last_hop", hop.Number).
-					Str("reason", terminationReason).
-					Bool("reached_destination", reachedDestination).
-					Msg("Terminating traceroute early")
-
-				// Kill the traceroute process to stop further output
-				if cmd.Process != nil {
-					_ = cmd.Process.Kill()
-				}
-				break
-			}
-		}
+	return stream
+}
+
+// addHop records a hop and broadcasts the progress. It returns true when the
+// traceroute should stop early.
+func (t *tracerouteStream) addHop(hop TracerouteHop) bool {
+	t.result.Hops = append(t.result.Hops, hop)
+	if t.names != nil {
+		// Lookups finish in the background; each update names the hops resolved so far
+		t.names.resolve(hop)
+		t.names.fill(t.result.Hops)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading traceroute output: %w", err)
+	// Check if we've reached the destination IP
+	if !hop.Timeout && t.destinationIP != "" && sameIP(hop.IP, t.destinationIP) {
+		t.reachedDestination = true
+		log.Info().
+			Str("destination_ip", t.destinationIP).
+			Int("hop", hop.Number).
+			Msg("Reached destination IP, traceroute will complete")
+	}
+
+	// Track consecutive timeouts
+	if hop.Timeout {
+		t.consecutiveTimeouts++
+		t.hotLog.Debug().
+			Int("hop", hop.Number).
+			Int("consecutive_timeouts", t.consecutiveTimeouts).
+			Msg("Timeout detected")
+	} else {
+		t.consecutiveTimeouts = 0 // Reset counter on successful hop
+	}
+
+	// Calculate progress and broadcast update
+	progress := float64(hop.Number) / float64(t.maxHops) * 100.0
+	if progress > 100.0 {
+		progress = 100.0
+	}
+
+	if t.s.broadcastTracerouteUpdate != nil {
+		t.s.broadcastTracerouteUpdate(types.TracerouteUpdate{
+			Type:        "traceroute",
+			Host:        t.host,
+			Progress:    progress,
+			IsComplete:  false,
+			CurrentHop:  hop.Number,
+			TotalHops:   t.maxHops,
+			Hops:        t.result.Hops, // Include all hops found so far
+			Destination: t.result.Destination,
+			IP:          t.result.IP,
+		})
+	}
+
+	t.hotLog.Debug().
+		Int("hop", hop.Number).
+		Str("host", hop.Host).
+		Str("ip", hop.IP).
+		Bool("timeout", hop.Timeout).
+		Float64("progress", progress).
+		Msg("Parsed traceroute hop")
+
+	// Check if we should terminate early
+	shouldTerminate := false
+	terminationReason := ""
+
+	// Terminate due to consecutive timeouts
+	if t.consecutiveTimeouts >= t.maxConsecutiveTimeouts {
+		shouldTerminate = true
+		terminationReason = "consecutive timeouts"
+	}
+
+	// If we reached destination and have timeouts after, terminate sooner
+	if t.reachedDestination && t.consecutiveTimeouts >= 2 {
+		shouldTerminate = true
+		terminationReason = "reached destination with subsequent timeouts"
 	}
 
+	if shouldTerminate {
+		log.Info().
+			Int("consecutive_timeouts", t.consecutiveTimeouts).
+			Int("max_allowed", t.maxConsecutiveTimeouts).
+			Int("last_hop", hop.Number).
+			Str("reason", terminationReason).
+			Bool("reached_destination", t.reachedDestination).
+			Msg("Terminating traceroute early")
+	}
+
+	return shouldTerminate
+}
+
+// finish completes the result and sends the final update
+func (t *tracerouteStream) finish() *TracerouteResult {
+	result := t.result
+	if t.names != nil {
+		t.names.wait()
+		t.names.fill(result.Hops)
+	}
 	result.TotalHops = len(result.Hops)
 	result.Complete = result.TotalHops > 0
 
 	// Determine if we terminated early (either due to consecutive timeouts or reaching destination)
-	terminatedEarly := consecutiveTimeouts >= maxConsecutiveTimeouts || (reachedDestination && consecutiveTimeouts >= 2)
+	terminatedEarly := t.consecutiveTimeouts >= t.maxConsecutiveTimeouts || (t.reachedDestination && t.consecutiveTimeouts >= 2)
 
 	// Send final update
-	if s.broadcastTracerouteUpdate != nil {
+	if t.s.broadcastTracerouteUpdate != nil {
 		finalProgress := 100.0
 		if terminatedEarly && result.TotalHops > 0 {
 			// For early termination, calculate progress based on where we stopped
 			lastHop := result.Hops[result.TotalHops-1].Number
-			finalProgress = float64(lastHop) / float64(maxHops) * 100.0
+			finalProgress = float64(lastHop) / float64(t.maxHops) * 100.0
 		}
 
-		s.broadcastTracerouteUpdate(types.TracerouteUpdate{
+		t.s.broadcastTracerouteUpdate(types.TracerouteUpdate{
 			Type:            "traceroute",
-			Host:            host,
+			Host:            t.host,
 			Progress:        finalProgress,
 			IsComplete:      true,
 			CurrentHop:      result.TotalHops,
@@ -789,13 +1177,13 @@ func (s *service) parseTracerouteOutputStreaming(stdout io.ReadCloser, originalH
 	if terminatedEarly {
 		log.Info().
 			Int("total_hops_found", result.TotalHops).
-			Int("consecutive_timeouts", consecutiveTimeouts).
-			Bool("reached_destination", reachedDestination).
-			Str("destination_ip", destinationIP).
+			Int("consecutive_timeouts", t.consecutiveTimeouts).
+			Bool("reached_destination", t.reachedDestination).
+			Str("destination_ip", t.destinationIP).
 			Msg("Traceroute completed with early termination")
 	}
 
-	return result, nil
+	return result
 }
 
 // parseHopLine parses a single hop line from traceroute output
@@ -805,19 +1193,19 @@ func (s *service) parseHopLine(line string) *TracerouteHop {
 
 	switch runtime.GOOS {
 	case "darwin", "linux":
-		// Unix traceroute patterns (3 query format)
-		hopRegex = regexp.MustCompile(`^\s*(\d+)\s+([^\s]+)\s+\(([^)]+)\)\s+([\d.]+)\s+ms\s+([\d.]+)\s+ms\s+([\d.]+)\s+ms`)
-		hopRegexIPOnly = regexp.MustCompile(`^\s*(\d+)\s+([^\s]+)\s+([0-9A-Fa-f:.]+)\s+ms\s+([\d.]+)\s+ms\s+([\d.]+)\s+ms`)
-		timeoutRegex = regexp.MustCompile(`^\s*(\d+)\s+\*\s+\*\s+\*`)
+		// Unix traceroute patterns, with one to three queries per hop
+		hopRegex = regexp.MustCompile(`^\s*(\d+)\s+([^\s]+)\s+[(\[]([^)\]]+)[)\]]\s+([\d.]+)\s+ms(?:\s+([\d.]+)\s+ms)?(?:\s+([\d.]+)\s+ms)?`)
+		hopRegexIPOnly = regexp.MustCompile(`^\s*(\d+)\s+([^\s]+)\s+([0-9A-Fa-f:.]+)\s+ms(?:\s+([\d.]+)\s+ms)?(?:\s+([\d.]+)\s+ms)?`)
+		timeoutRegex = regexp.MustCompile(`^\s*(\d+)(?:\s+\*)+\s*$`)
 	case "windows":
 		// Windows tracert patterns
 		hopRegex = regexp.MustCompile(`^\s*(\d+)\s+(<?[\d.]+)\s+ms\s+(<?[\d.]+)\s+ms\s+(<?[\d.]+)\s+ms\s+(.+)`)
 		timeoutRegex = regexp.MustCompile(`^\s*(\d+)\s+\*\s+\*\s+\*\s+Request timed out\.`)
 	default:
-		// Default to Unix style (3 query format)
-		hopRegex = regexp.MustCompile(`^\s*(\d+)\s+([^\s]+)\s+\(([^)]+)\)\s+([\d.]+)\s+ms\s+([\d.]+)\s+ms\s+([\d.]+)\s+ms`)
-		hopRegexIPOnly = regexp.MustCompile(`^\s*(\d+)\s+([^\s]+)\s+([0-9A-Fa-f:.]+)\s+ms\s+([\d.]+)\s+ms\s+([\d.]+)\s+ms`)
-		timeoutRegex = regexp.MustCompile(`^\s*(\d+)\s+\*\s+\*\s+\*`)
+		// Default to Unix style
+		hopRegex = regexp.MustCompile(`^\s*(\d+)\s+([^\s]+)\s+[(\[]([^)\]]+)[)\]]\s+([\d.]+)\s+ms(?:\s+([\d.]+)\s+ms)?(?:\s+([\d.]+)\s+ms)?`)
+		hopRegexIPOnly = regexp.MustCompile(`^\s*(\d+)\s+([^\s]+)\s+([0-9A-Fa-f:.]+)\s+ms(?:\s+([\d.]+)\s+ms)?(?:\s+([\d.]+)\s+ms)?`)
+		timeoutRegex = regexp.MustCompile(`^\s*(\d+)(?:\s+\*)+\s*$`)
 	}
 
 	// Try to match timeout line first
@@ -840,7 +1228,7 @@ func (s *service) parseHopLine(line string) *TracerouteHop {
 			rtt1Str := strings.TrimPrefix(match[2], "<")
 			rtt2Str := strings.TrimPrefix(match[3], "<")
 			rtt3Str := strings.TrimPrefix(match[4], "<")
-			ip := strings.TrimSpace(match[5])
+			hostname, ip := splitHopTarget(match[5])
 
 			rtt1, _ := strconv.ParseFloat(rtt1Str, 64)
 			rtt2, _ := strconv.ParseFloat(rtt2Str, 64)
@@ -848,7 +1236,7 @@ func (s *service) parseHopLine(line string) *TracerouteHop {
 
 			return &TracerouteHop{
 				Number:      hopNum,
-				Host:        ip,
+				Host:        hostname,
 				IP:          ip,
 				RTT1:        rtt1,
 				RTT2:        rtt2,
@@ -859,7 +1247,7 @@ func (s *service) parseHopLine(line string) *TracerouteHop {
 			}
 		}
 	} else {
-		// 3 query format
+		// Missing RTTs of hops probed fewer than 3 times stay 0
 		if match := hopRegex.FindStringSubmatch(line); match != nil {
 			hopNum, _ := strconv.Atoi(match[1])
 			hostname := match[2]
@@ -881,7 +1269,7 @@ func (s *service) parseHopLine(line string) *TracerouteHop {
 			}
 		} else if match := hopRegexIPOnly.FindStringSubmatch(line); match != nil {
 			hopNum, _ := strconv.Atoi(match[1])
-			ip := match[2]
+			ip := strings.Trim(match[2], "[]")
 			rtt1, _ := strconv.ParseFloat(match[3], 64)
 			rtt2, _ := strconv.ParseFloat(match[4], 64)
 			rtt3, _ := strconv.ParseFloat(match[5], 64)
@@ -0,0 +1,137 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// hopNameWorkers bounds the reverse DNS lookups running at once for one traceroute
+	hopNameWorkers = 4
+	// hopNameLookupTimeout bounds a single reverse DNS lookup
+	hopNameLookupTimeout = 2 * time.Second
+
+	hopNameCacheSize = 1024
+	hopNameCacheTTL  = time.Hour
+)
+
+// hopNameLookups caches reverse DNS names of hop addresses across traceroutes. Addresses
+// without a name are cached as "", failed lookups aren't cached.
+var hopNameLookups = newGeoIPCache(hopNameCacheSize, hopNameCacheTTL)
+
+// lookupAddr resolves the names of an address, replaced in tests
+var lookupAddr = net.DefaultResolver.LookupAddr
+
+// hopNameResolver looks up the reverse DNS names of traceroute hops in the background,
+// so a slow resolver doesn't hold up the hop stream
+type hopNameResolver struct {
+	ctx   context.Context
+	slots chan struct{}
+	wg    sync.WaitGroup
+
+	mu    sync.Mutex
+	names map[string]string // by IP, "" until resolved or when there is no name
+	seen  map[string]bool
+}
+
+// newHopNameResolver returns a resolver whose lookups stop when ctx is done
+func newHopNameResolver(ctx context.Context) *hopNameResolver {
+	return &hopNameResolver{
+		ctx:   ctx,
+		slots: make(chan struct{}, hopNameWorkers),
+		names: make(map[string]string),
+		seen:  make(map[string]bool),
+	}
+}
+
+// needsName reports whether a hop was reported by address only
+func needsName(hop TracerouteHop) bool {
+	return !hop.Timeout && hop.IP != "" && hop.IP != "*" && (hop.Host == "" || hop.Host == hop.IP)
+}
+
+// resolve starts looking up the name of a hop, unless it has one or is already queued
+func (r *hopNameResolver) resolve(hop TracerouteHop) {
+	if !needsName(hop) {
+		return
+	}
+
+	r.mu.Lock()
+	if r.seen[hop.IP] {
+		r.mu.Unlock()
+		return
+	}
+	r.seen[hop.IP] = true
+	r.mu.Unlock()
+
+	r.wg.Add(1)
+	go func(ip string) {
+		defer r.wg.Done()
+
+		select {
+		case r.slots <- struct{}{}:
+		case <-r.ctx.Done():
+			return
+		}
+		defer func() { <-r.slots }()
+
+		name, ok := hopNameLookups.get(ip)
+		if !ok {
+			var err error
+			if name, err = lookupHopAddr(r.ctx, ip); err != nil {
+				return
+			}
+			hopNameLookups.add(ip, name)
+		}
+
+		r.mu.Lock()
+		r.names[ip] = name
+		r.mu.Unlock()
+	}(hop.IP)
+}
+
+// fill names the hops whose lookup has finished
+func (r *hopNameResolver) fill(hops []TracerouteHop) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range hops {
+		if !needsName(hops[i]) {
+			continue
+		}
+		if name := r.names[hops[i].IP]; name != "" {
+			hops[i].Host = name
+		}
+	}
+}
+
+// wait blocks until every started lookup has finished or given up
+func (r *hopNameResolver) wait() {
+	r.wg.Wait()
+}
+
+// lookupHopAddr returns the first reverse DNS name of an address, "" when it has none.
+// Failed lookups return an error so they aren't cached as having no name.
+func lookupHopAddr(ctx context.Context, ip string) (string, error) {
+	lookupCtx, cancel := context.WithTimeout(ctx, hopNameLookupTimeout)
+	defer cancel()
+
+	names, err := lookupAddr(lookupCtx, ip)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+	return strings.TrimSuffix(names[0], "."), nil
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubLookupAddr replaces the reverse DNS resolver and the name cache for a test
+func stubLookupAddr(t *testing.T, fn func(ctx context.Context, addr string) ([]string, error)) {
+	t.Helper()
+	origLookup, origCache := lookupAddr, hopNameLookups
+	lookupAddr = fn
+	hopNameLookups = newGeoIPCache(hopNameCacheSize, hopNameCacheTTL)
+	t.Cleanup(func() {
+		lookupAddr, hopNameLookups = origLookup, origCache
+	})
+}
+
+func TestHopNameResolver(t *testing.T) {
+	var calls atomic.Int32
+	stubLookupAddr(t, func(ctx context.Context, addr string) ([]string, error) {
+		calls.Add(1)
+		switch addr {
+		case "192.0.2.1":
+			return []string{"router.example.net."}, nil
+		case "192.0.2.2":
+			return nil, &net.DNSError{Err: "no such host", Name: addr, IsNotFound: true}
+		default:
+			return nil, errors.New("resolver unreachable")
+		}
+	})
+
+	hops := []TracerouteHop{
+		{Number: 1, Host: "192.0.2.1", IP: "192.0.2.1"},
+		{Number: 2, Host: "192.0.2.2", IP: "192.0.2.2"},
+		{Number: 3, Host: "192.0.2.3", IP: "192.0.2.3"},
+		{Number: 4, Host: "*", IP: "*", Timeout: true},
+		{Number: 5, Host: "named.example.net", IP: "192.0.2.5"},
+	}
+
+	r := newHopNameResolver(context.Background())
+	for _, hop := range hops {
+		r.resolve(hop)
+	}
+	r.wait()
+	r.fill(hops)
+
+	assert.Equal(t, "router.example.net", hops[0].Host)
+	assert.Equal(t, "192.0.2.2", hops[1].Host, "addresses without a name keep the IP")
+	assert.Equal(t, "192.0.2.3", hops[2].Host, "failed lookups keep the IP")
+	assert.Equal(t, "*", hops[3].Host)
+	assert.Equal(t, "named.example.net", hops[4].Host)
+	assert.EqualValues(t, 3, calls.Load(), "timeouts and named hops aren't looked up")
+
+	// Names and missing names are cached across traceroutes, failures are retried
+	r = newHopNameResolver(context.Background())
+	for _, hop := range hops[:3] {
+		r.resolve(TracerouteHop{Host: hop.IP, IP: hop.IP})
+	}
+	r.wait()
+	assert.EqualValues(t, 4, calls.Load())
+}
+
+func TestHopNameResolverBoundsWorkers(t *testing.T) {
+	var running, peak atomic.Int32
+	stubLookupAddr(t, func(ctx context.Context, addr string) ([]string, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return []string{"hop.example.net"}, nil
+	})
+
+	r := newHopNameResolver(context.Background())
+	for i := range 20 {
+		ip := net.IPv4(198, 51, 100, byte(i+1)).String()
+		r.resolve(TracerouteHop{Number: i + 1, Host: ip, IP: ip})
+	}
+	r.wait()
+
+	assert.LessOrEqual(t, peak.Load(), int32(hopNameWorkers))
+}
+
+func TestParseTracerouteOutputStreamingResolvesNames(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("parses Unix traceroute output")
+	}
+
+	stubLookupAddr(t, func(ctx context.Context, addr string) ([]string, error) {
+		return []string{"hop-" + strings.ReplaceAll(addr, ".", "-") + ".example.net."}, nil
+	})
+
+	output := strings.Join([]string{
+		"traceroute to 192.0.2.3 (192.0.2.3), 30 hops max, 60 byte packets",
+		" 1  192.0.2.1  1.100 ms  1.200 ms  1.300 ms",
+		" 2  192.0.2.2  2.100 ms  2.200 ms  2.300 ms",
+		" 3  192.0.2.3  3.100 ms  3.200 ms  3.300 ms",
+	}, "\n")
+
+	s := &service{}
+	names := newHopNameResolver(context.Background())
+	result, err := s.parseTracerouteOutputStreaming(io.NopCloser(strings.NewReader(output)), "192.0.2.3", "192.0.2.3", "192.0.2.3", &exec.Cmd{}, names)
+	require.NoError(t, err)
+	require.Len(t, result.Hops, 3)
+	assert.Equal(t, "hop-192-0-2-1.example.net", result.Hops[0].Host)
+	assert.Equal(t, "hop-192-0-2-3.example.net", result.Hops[2].Host)
+	assert.Equal(t, "192.0.2.3", result.Hops[2].IP)
+
+	// Without a resolver hops keep their addresses
+	result, err = s.parseTracerouteOutputStreaming(io.NopCloser(strings.NewReader(output)), "192.0.2.3", "192.0.2.3", "192.0.2.3", &exec.Cmd{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "192.0.2.1", result.Hops[0].Host)
+}
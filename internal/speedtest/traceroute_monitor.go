@@ -0,0 +1,134 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/netronome/internal/database"
+	"github.com/autobrr/netronome/internal/types"
+)
+
+const (
+	// scheduledTracerouteTimeout bounds a single scheduled traceroute run
+	scheduledTracerouteTimeout = 5 * time.Minute
+	// maxConcurrentTraceroutes limits how many scheduled traceroutes run at once
+	maxConcurrentTraceroutes = 2
+)
+
+// TracerouteService runs scheduled traceroute monitors and stores their hop lists
+type TracerouteService struct {
+	db     database.Service
+	tracer interface {
+		RunTraceroute(ctx context.Context, host string, opts TracerouteOptions) (*TracerouteResult, error)
+	}
+	slots *slotQueue
+}
+
+// NewTracerouteService creates a new traceroute monitoring service
+func NewTracerouteService(db database.Service, tracer Service) *TracerouteService {
+	return &TracerouteService{
+		db:     db,
+		tracer: tracer,
+		slots:  newSlotQueue(maxConcurrentTraceroutes),
+	}
+}
+
+// QueueStats reports how many scheduled traceroutes wait for or hold a slot
+func (s *TracerouteService) QueueStats() types.QueueStats {
+	return s.slots.stats()
+}
+
+// RunScheduledTraceroute waits for a free slot and runs a single traceroute for a
+// monitor called by the scheduler. It gives up when ctx ends before a slot is free.
+func (s *TracerouteService) RunScheduledTraceroute(ctx context.Context, monitor *types.TracerouteMonitor) {
+	if err := s.slots.wait(ctx, scheduledStartFromContext(ctx)); err != nil {
+		log.Warn().Err(err).Int64("monitorID", monitor.ID).Str("host", monitor.Host).Msg("Scheduled traceroute gave up waiting in queue")
+		return
+	}
+	defer s.slots.release()
+
+	log.Info().
+		Int64("monitorID", monitor.ID).
+		Str("host", monitor.Host).
+		Msg("Running scheduled traceroute")
+
+	ctx, cancel := context.WithTimeout(context.Background(), scheduledTracerouteTimeout)
+	defer cancel()
+
+	family, err := ParseAddressFamily(monitor.Family)
+	if err != nil {
+		log.Error().Err(err).Int64("monitorID", monitor.ID).Msg("Invalid traceroute monitor address family")
+		return
+	}
+
+	result, err := s.tracer.RunTraceroute(ctx, strings.TrimSpace(monitor.Host), TracerouteOptions{Family: family})
+	if err != nil {
+		log.Error().Err(err).Int64("monitorID", monitor.ID).Str("host", monitor.Host).Msg("Scheduled traceroute failed")
+		return
+	}
+
+	hops, err := json.Marshal(result.Hops)
+	if err != nil {
+		log.Error().Err(err).Int64("monitorID", monitor.ID).Msg("Failed to marshal traceroute hops")
+		return
+	}
+
+	record := &types.TracerouteMonitorResult{
+		MonitorID:   monitor.ID,
+		Destination: result.Destination,
+		IP:          result.IP,
+		TotalHops:   result.TotalHops,
+		Complete:    result.Complete,
+		Hops:        hops,
+	}
+
+	previous, err := s.db.GetLatestTracerouteMonitorResult(ctx, monitor.ID)
+	switch {
+	case err == nil:
+		var previousHops []TracerouteHop
+		if err := json.Unmarshal(previous.Hops, &previousHops); err != nil {
+			log.Warn().Err(err).Int64("monitorID", monitor.ID).Msg("Failed to parse previous traceroute hops")
+		} else {
+			record.PathChanged = hopPathChanged(previousHops, result.Hops)
+		}
+	case !errors.Is(err, database.ErrNotFound):
+		log.Warn().Err(err).Int64("monitorID", monitor.ID).Msg("Failed to load previous traceroute result")
+	}
+
+	if err := s.db.SaveTracerouteMonitorResult(ctx, record); err != nil {
+		log.Error().Err(err).Int64("monitorID", monitor.ID).Msg("Failed to save traceroute result")
+		return
+	}
+
+	log.Info().
+		Int64("monitorID", monitor.ID).
+		Str("host", monitor.Host).
+		Int("totalHops", record.TotalHops).
+		Bool("pathChanged", record.PathChanged).
+		Msg("Scheduled traceroute completed")
+}
+
+// hopPathChanged reports whether two runs took different routes. Hops are compared by
+// address, and a timed out hop on either side matches anything since it hides the router.
+func hopPathChanged(previous, current []TracerouteHop) bool {
+	if len(previous) != len(current) {
+		return true
+	}
+	for i := range current {
+		if previous[i].Timeout || current[i].Timeout {
+			continue
+		}
+		if !sameIP(previous[i].IP, current[i].IP) {
+			return true
+		}
+	}
+	return false
+}
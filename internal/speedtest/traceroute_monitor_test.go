@@ -0,0 +1,59 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHopPathChanged(t *testing.T) {
+	base := []TracerouteHop{
+		{Number: 1, IP: "192.168.1.1"},
+		{Number: 2, IP: "10.0.0.1"},
+		{Number: 3, IP: "2001:db8::1"},
+	}
+
+	tests := []struct {
+		name     string
+		current  []TracerouteHop
+		expected bool
+	}{
+		{
+			name:     "same path",
+			current:  base,
+			expected: false,
+		},
+		{
+			name: "different hop address",
+			current: []TracerouteHop{
+				{Number: 1, IP: "192.168.1.1"},
+				{Number: 2, IP: "10.0.0.2"},
+				{Number: 3, IP: "2001:db8::1"},
+			},
+			expected: true,
+		},
+		{
+			name: "timed out hop matches",
+			current: []TracerouteHop{
+				{Number: 1, IP: "192.168.1.1"},
+				{Number: 2, Timeout: true},
+				{Number: 3, IP: "2001:db8:0::1"},
+			},
+			expected: false,
+		},
+		{
+			name:     "different hop count",
+			current:  base[:2],
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, hopPathChanged(base, tt.current))
+		})
+	}
+}
@@ -0,0 +1,282 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// errRawSocketUnavailable is returned by the native traceroute when it may not open a raw
+// ICMP socket, in which case the system traceroute binary is used instead
+var errRawSocketUnavailable = errors.New("raw ICMP sockets are not permitted")
+
+const (
+	// nativeTracerouteProbeTimeout matches the -w 2 passed to the system traceroute
+	nativeTracerouteProbeTimeout = 2 * time.Second
+	// nativeTracerouteTimeout matches the timeout of the system traceroute command
+	nativeTracerouteTimeout = 60 * time.Second
+)
+
+// Protocol numbers for icmp.ParseMessage
+const (
+	protocolICMP     = 1
+	protocolIPv6ICMP = 58
+)
+
+// icmpTracer sends ICMP echo requests with a limited TTL and matches the replies. The
+// pro-bing pinger discards the Time Exceeded messages intermediate hops answer with, so
+// the tracer reads the raw socket itself.
+type icmpTracer struct {
+	conn *icmp.PacketConn
+	dst  *net.IPAddr
+	ipv6 bool
+	id   int
+	seq  int
+}
+
+func newICMPTracer(dst net.IP) (*icmpTracer, error) {
+	tracer := &icmpTracer{
+		dst:  &net.IPAddr{IP: dst},
+		ipv6: dst.To4() == nil,
+		// A random ID keeps concurrent traceroutes from matching each other's replies
+		id: rand.IntN(0xffff) + 1,
+	}
+
+	network, address := "ip4:icmp", "0.0.0.0"
+	if tracer.ipv6 {
+		network, address = "ip6:ipv6-icmp", "::"
+	}
+
+	conn, err := icmp.ListenPacket(network, address)
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return nil, fmt.Errorf("%w: %v", errRawSocketUnavailable, err)
+		}
+		return nil, fmt.Errorf("failed to open ICMP socket: %w", err)
+	}
+	tracer.conn = conn
+
+	return tracer, nil
+}
+
+func (t *icmpTracer) Close() error {
+	return t.conn.Close()
+}
+
+// probe sends one echo request with the given TTL and waits for its answer. It returns
+// the answering address, or nil when the probe timed out, and whether the answer came
+// from the end of the path.
+func (t *icmpTracer) probe(ctx context.Context, ttl int) (net.IP, time.Duration, bool, error) {
+	var echoType icmp.Type = ipv4.ICMPTypeEcho
+	if t.ipv6 {
+		echoType = ipv6.ICMPTypeEchoRequest
+		if err := t.conn.IPv6PacketConn().SetHopLimit(ttl); err != nil {
+			return nil, 0, false, fmt.Errorf("failed to set hop limit: %w", err)
+		}
+	} else if err := t.conn.IPv4PacketConn().SetTTL(ttl); err != nil {
+		return nil, 0, false, fmt.Errorf("failed to set TTL: %w", err)
+	}
+
+	t.seq = (t.seq + 1) & 0xffff
+	msg := icmp.Message{
+		Type: echoType,
+		Body: &icmp.Echo{ID: t.id, Seq: t.seq, Data: []byte("netronome")},
+	}
+	packet, err := msg.Marshal(nil)
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("failed to build ICMP echo: %w", err)
+	}
+
+	start := time.Now()
+	if _, err := t.conn.WriteTo(packet, t.dst); err != nil {
+		return nil, 0, false, fmt.Errorf("failed to send ICMP echo: %w", err)
+	}
+
+	deadline := start.Add(nativeTracerouteProbeTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := t.conn.SetReadDeadline(deadline); err != nil {
+		return nil, 0, false, fmt.Errorf("failed to set read deadline: %w", err)
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := t.conn.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return nil, 0, false, ctx.Err()
+			}
+			return nil, 0, false, fmt.Errorf("failed to read ICMP reply: %w", err)
+		}
+
+		var ip net.IP
+		if addr, ok := peer.(*net.IPAddr); ok {
+			ip = addr.IP
+		}
+
+		// The raw socket sees all ICMP traffic of the host, so skip anything that
+		// doesn't answer this probe
+		matched, final := t.matchReply(buf[:n], ip)
+		if !matched {
+			continue
+		}
+		return ip, time.Since(start), final, nil
+	}
+}
+
+// matchReply reports whether an ICMP message sent by from answers the current probe and
+// whether it came from the end of the path, an echo reply or a destination unreachable
+// message from the destination. An unreachable message from a router along the way is
+// just that hop.
+func (t *icmpTracer) matchReply(packet []byte, from net.IP) (bool, bool) {
+	proto := protocolICMP
+	if t.ipv6 {
+		proto = protocolIPv6ICMP
+	}
+
+	msg, err := icmp.ParseMessage(proto, packet)
+	if err != nil {
+		return false, false
+	}
+
+	switch body := msg.Body.(type) {
+	case *icmp.Echo:
+		if msg.Type != ipv4.ICMPTypeEchoReply && msg.Type != ipv6.ICMPTypeEchoReply {
+			return false, false
+		}
+		return body.ID == t.id && body.Seq == t.seq, true
+	case *icmp.TimeExceeded:
+		return quotedEchoMatches(body.Data, t.ipv6, t.id, t.seq), false
+	case *icmp.DstUnreach:
+		return quotedEchoMatches(body.Data, t.ipv6, t.id, t.seq), from.Equal(t.dst.IP)
+	}
+	return false, false
+}
+
+// quotedEchoMatches checks the echo request quoted in an ICMP error message, which
+// starts with the IP header of the original packet
+func quotedEchoMatches(data []byte, isIPv6 bool, id, seq int) bool {
+	headerLen := 40
+	if !isIPv6 {
+		if len(data) < 20 {
+			return false
+		}
+		headerLen = int(data[0]&0x0f) * 4
+	}
+	if len(data) < headerLen+8 {
+		return false
+	}
+
+	echo := data[headerLen:]
+	return int(binary.BigEndian.Uint16(echo[4:6])) == id && int(binary.BigEndian.Uint16(echo[6:8])) == seq
+}
+
+// runNativeTraceroute traces the path to destinationIP with ICMP echo requests of
+// increasing TTL, reporting hops like the system traceroute does. Hops are named after
+// probing finishes when opts.ResolveNames is set. It returns errRawSocketUnavailable
+// when it may not open a raw socket.
+func (s *service) runNativeTraceroute(ctx context.Context, originalHost, host, destinationIP string, opts TracerouteOptions) (*TracerouteResult, error) {
+	dst := net.ParseIP(destinationIP)
+	if dst == nil {
+		return nil, fmt.Errorf("invalid destination IP %q", destinationIP)
+	}
+
+	tracer, err := newICMPTracer(dst)
+	if err != nil {
+		return nil, err
+	}
+	defer tracer.Close()
+
+	tracerouteCfg := s.tracerouteConfig()
+
+	log.Info().
+		Str("host", host).
+		Str("destination_ip", destinationIP).
+		Int("max_hops", tracerouteCfg.MaxHops).
+		Int("queries", tracerouteCfg.Queries).
+		Msg("Starting native traceroute")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, nativeTracerouteTimeout)
+	defer cancel()
+
+	stream := s.newTracerouteStream(originalHost, host, destinationIP)
+	stream.result.IP = destinationIP
+	if opts.ResolveNames {
+		// Looked up in the background with bounded workers, so slow DNS doesn't eat
+		// into the probing budget hop by hop
+		stream.names = newHopNameResolver(timeoutCtx)
+	}
+
+	for ttl := 1; ttl <= tracerouteCfg.MaxHops; ttl++ {
+		hop := TracerouteHop{Number: ttl}
+		var rtts []float64
+		reached := false
+
+		for range tracerouteCfg.Queries {
+			ip, rtt, final, err := tracer.probe(timeoutCtx, ttl)
+			if err != nil {
+				if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+					return nil, fmt.Errorf("traceroute test timed out after %d seconds", int(nativeTracerouteTimeout.Seconds()))
+				}
+				return nil, err
+			}
+			if ip == nil {
+				continue
+			}
+			if hop.IP == "" {
+				hop.IP = ip.String()
+			}
+			rtts = append(rtts, float64(rtt.Microseconds())/1000)
+			reached = reached || final
+		}
+
+		if hop.IP == "" {
+			hop.Host = "*"
+			hop.IP = "*"
+			hop.Timeout = true
+		} else {
+			hop.Host = hop.IP
+			hop.CountryCode = getCountryFromHost(hop.IP)
+			hop.AS = getASNFromHost(hop.IP)
+			for i, rtt := range rtts {
+				switch i {
+				case 0:
+					hop.RTT1 = rtt
+				case 1:
+					hop.RTT2 = rtt
+				case 2:
+					hop.RTT3 = rtt
+				}
+			}
+		}
+
+		if stream.addHop(hop) || reached {
+			break
+		}
+	}
+
+	result := stream.finish()
+
+	log.Info().
+		Str("host", host).
+		Int("total_hops", result.TotalHops).
+		Bool("complete", result.Complete).
+		Msg("Native traceroute completed successfully")
+
+	return result, nil
+}
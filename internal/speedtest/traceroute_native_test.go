@@ -0,0 +1,99 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package speedtest
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+func marshalICMP(t *testing.T, msg icmp.Message) []byte {
+	t.Helper()
+	packet, err := msg.Marshal(nil)
+	require.NoError(t, err)
+	return packet
+}
+
+func TestICMPTracerMatchReply(t *testing.T) {
+	dst := net.ParseIP("192.0.2.1")
+	tracer := &icmpTracer{dst: &net.IPAddr{IP: dst}, id: 4242, seq: 7}
+	router := net.ParseIP("198.51.100.1")
+
+	probe := marshalICMP(t, icmp.Message{Type: ipv4.ICMPTypeEcho, Body: &icmp.Echo{ID: 4242, Seq: 7, Data: []byte("netronome")}})
+	otherProbe := marshalICMP(t, icmp.Message{Type: ipv4.ICMPTypeEcho, Body: &icmp.Echo{ID: 4242, Seq: 6, Data: []byte("netronome")}})
+	ipHeader := make([]byte, 20)
+	ipHeader[0] = 0x45 // IPv4, 20 byte header
+
+	tests := []struct {
+		name        string
+		msg         icmp.Message
+		from        net.IP
+		wantMatched bool
+		wantFinal   bool
+	}{
+		{
+			name:        "time exceeded from a hop",
+			msg:         icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: append(append([]byte{}, ipHeader...), probe...)}},
+			from:        router,
+			wantMatched: true,
+		},
+		{
+			name: "time exceeded for an earlier probe",
+			msg:  icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: append(append([]byte{}, ipHeader...), otherProbe...)}},
+		},
+		{
+			name:        "echo reply from the destination",
+			msg:         icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: 4242, Seq: 7}},
+			from:        dst,
+			wantMatched: true,
+			wantFinal:   true,
+		},
+		{
+			name:        "destination unreachable",
+			msg:         icmp.Message{Type: ipv4.ICMPTypeDestinationUnreachable, Body: &icmp.DstUnreach{Data: append(append([]byte{}, ipHeader...), probe...)}},
+			from:        dst,
+			wantMatched: true,
+			wantFinal:   true,
+		},
+		{
+			name:        "unreachable from a router on the path",
+			msg:         icmp.Message{Type: ipv4.ICMPTypeDestinationUnreachable, Body: &icmp.DstUnreach{Data: append(append([]byte{}, ipHeader...), probe...)}},
+			from:        router,
+			wantMatched: true,
+		},
+		{
+			name: "echo reply to another pinger",
+			msg:  icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: 1, Seq: 7}},
+		},
+		{
+			name: "echo request",
+			msg:  icmp.Message{Type: ipv4.ICMPTypeEcho, Body: &icmp.Echo{ID: 4242, Seq: 7}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, final := tracer.matchReply(marshalICMP(t, tt.msg), tt.from)
+			assert.Equal(t, tt.wantMatched, matched)
+			if tt.wantMatched {
+				assert.Equal(t, tt.wantFinal, final)
+			}
+		})
+	}
+}
+
+func TestQuotedEchoMatchesIPv6(t *testing.T) {
+	probe := marshalICMP(t, icmp.Message{Type: ipv6.ICMPTypeEchoRequest, Body: &icmp.Echo{ID: 10, Seq: 3}})
+	quoted := append(make([]byte, 40), probe...)
+
+	assert.True(t, quotedEchoMatches(quoted, true, 10, 3))
+	assert.False(t, quotedEchoMatches(quoted, true, 10, 4))
+	assert.False(t, quotedEchoMatches(quoted[:44], true, 10, 3), "truncated quotes don't match")
+}
@@ -4,10 +4,13 @@
 package speedtest
 
 import (
+	"net"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/netronome/internal/config"
 )
 
 func TestNormalizeTracerouteHost(t *testing.T) {
@@ -55,6 +58,40 @@ func TestParseHopLineIPv6Address(t *testing.T) {
 	assert.False(t, hop.Timeout)
 }
 
+func TestParseHopLineSingleQuery(t *testing.T) {
+	s := &service{}
+
+	hop := s.parseHopLine(" 3  router.example.net (192.0.2.1)  7.250 ms")
+	require.NotNil(t, hop)
+	assert.Equal(t, 3, hop.Number)
+	assert.Equal(t, "192.0.2.1", hop.IP)
+	assert.Equal(t, 7.25, hop.RTT1)
+	assert.Zero(t, hop.RTT2)
+
+	hop = s.parseHopLine(" 4  *")
+	require.NotNil(t, hop)
+	assert.True(t, hop.Timeout)
+
+	assert.Nil(t, s.parseHopLine(" 5  * router.example.net (192.0.2.1)  7.250 ms"), "partly answered hops aren't timeouts")
+}
+
+func TestBuildTracerouteArgs(t *testing.T) {
+	cfg := config.TracerouteConfig{MaxHops: 48, Queries: 1}
+
+	assert.Equal(t, []string{"-w", "2", "-m", "48", "-q", "1", "example.com"}, buildTracerouteArgs("linux", "example.com", cfg, false))
+	assert.Equal(t, []string{"-w", "2000", "-h", "48", "example.com"}, buildTracerouteArgs("windows", "example.com", cfg, false))
+
+	s := &service{}
+	assert.Equal(t, config.TracerouteConfig{MaxHops: config.DefaultTracerouteMaxHops, Queries: config.DefaultTracerouteQueries}, s.tracerouteConfig(), "unset values use the defaults")
+}
+
+func TestTracerouteMaxConsecutiveTimeouts(t *testing.T) {
+	assert.Equal(t, 3, tracerouteMaxConsecutiveTimeouts(config.TracerouteConfig{MaxHops: 30, Queries: 3}))
+	assert.Equal(t, 3, tracerouteMaxConsecutiveTimeouts(config.TracerouteConfig{MaxHops: 30, Queries: 5}))
+	assert.Equal(t, 5, tracerouteMaxConsecutiveTimeouts(config.TracerouteConfig{MaxHops: 30, Queries: 1}))
+	assert.Equal(t, 2, tracerouteMaxConsecutiveTimeouts(config.TracerouteConfig{MaxHops: 2, Queries: 1}), "capped at the max hops")
+}
+
 func TestParseUnixTracerouteOutputIPv6Address(t *testing.T) {
 	s := &service{}
 	lines := []string{
@@ -70,3 +107,186 @@ func TestParseUnixTracerouteOutputIPv6Address(t *testing.T) {
 	require.Len(t, result.Hops, 1)
 	assert.Equal(t, "2001:db8::1", result.Hops[0].IP)
 }
+
+func TestIsPrivateHopIP(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected bool
+	}{
+		{ip: "10.0.0.1", expected: true},
+		{ip: "172.16.5.4", expected: true},
+		{ip: "192.168.1.1", expected: true},
+		{ip: "127.0.0.1", expected: true},
+		{ip: "169.254.10.1", expected: true},
+		{ip: "100.64.0.1", expected: true},
+		{ip: "100.127.255.254", expected: true},
+		{ip: "fd00::1", expected: true},
+		{ip: "fe80::1", expected: true},
+		{ip: "::1", expected: true},
+		{ip: "224.0.0.251", expected: true},
+		{ip: "239.255.255.250", expected: true},
+		{ip: "ff02::1", expected: true},
+		{ip: "100.128.0.1", expected: false},
+		{ip: "8.8.8.8", expected: false},
+		{ip: "2001:4860:4860::8888", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			ip := net.ParseIP(tt.ip)
+			require.NotNil(t, ip)
+			assert.Equal(t, tt.expected, isPrivateHopIP(ip))
+		})
+	}
+}
+
+func TestIsPrivateHopIP_CustomRanges(t *testing.T) {
+	defaults := privateHopNets
+	t.Cleanup(func() { privateHopNets = defaults })
+
+	privateHopNets = parsePrivateHopRanges([]string{"10.0.0.0/8", " 203.0.113.0/24 ", "not-a-cidr"})
+	require.Len(t, privateHopNets, 2, "invalid entries are skipped")
+
+	assert.True(t, isPrivateHopIP(net.ParseIP("10.1.2.3")))
+	assert.True(t, isPrivateHopIP(net.ParseIP("203.0.113.7")))
+	assert.False(t, isPrivateHopIP(net.ParseIP("192.168.1.1")), "ranges left out of the list are looked up")
+}
+
+func TestTracerouteTargets(t *testing.T) {
+	ips := []string{"192.0.2.1", "192.0.2.2", "2001:db8::1"}
+	many := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6", "10.0.0.7", "10.0.0.8", "10.0.0.9", "10.0.0.10"}
+
+	tests := []struct {
+		name     string
+		ips      []string
+		maxIPs   int
+		expected []string
+	}{
+		{name: "default traces first ip", ips: ips, maxIPs: 1, expected: ips[:1]},
+		{name: "zero treated as one", ips: ips, maxIPs: 0, expected: ips[:1]},
+		{name: "limited to max", ips: ips, maxIPs: 2, expected: ips[:2]},
+		{name: "fewer ips than max", ips: ips, maxIPs: 5, expected: ips},
+		{name: "capped at hard limit", ips: many, maxIPs: 100, expected: many[:MaxTracerouteIPs]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tracerouteTargets(tt.ips, tt.maxIPs))
+		})
+	}
+}
+
+func TestParseAddressFamily(t *testing.T) {
+	for in, want := range map[string]AddressFamily{"": AddressFamilyAuto, "auto": AddressFamilyAuto, "IPv4": AddressFamilyIPv4, " ipv6 ": AddressFamilyIPv6} {
+		family, err := ParseAddressFamily(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, family, in)
+	}
+
+	_, err := ParseAddressFamily("inet6")
+	assert.Error(t, err)
+}
+
+func TestSelectFamilyIPs(t *testing.T) {
+	v4, v4b, v6 := net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2"), net.ParseIP("2001:db8::1")
+
+	tests := []struct {
+		name       string
+		ips        []net.IP
+		family     AddressFamily
+		wantFamily AddressFamily
+		wantIPs    []string
+		wantErr    bool
+	}{
+		{name: "auto follows first v6", ips: []net.IP{v6, v4}, family: AddressFamilyAuto, wantFamily: AddressFamilyIPv6, wantIPs: []string{"2001:db8::1"}},
+		{name: "auto follows first v4", ips: []net.IP{v4, v6, v4b}, family: AddressFamilyAuto, wantFamily: AddressFamilyIPv4, wantIPs: []string{"192.0.2.1", "192.0.2.2"}},
+		{name: "forced v4 skips v6", ips: []net.IP{v6, v4}, family: AddressFamilyIPv4, wantFamily: AddressFamilyIPv4, wantIPs: []string{"192.0.2.1"}},
+		{name: "aaaa only host", ips: []net.IP{v6}, family: AddressFamilyIPv6, wantFamily: AddressFamilyIPv6, wantIPs: []string{"2001:db8::1"}},
+		{name: "missing family", ips: []net.IP{v4}, family: AddressFamilyIPv6, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			family, ips, err := selectFamilyIPs(tt.ips, tt.family)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFamily, family)
+			assert.Equal(t, tt.wantIPs, ips)
+		})
+	}
+}
+
+func TestTracerouteCommand(t *testing.T) {
+	tests := []struct {
+		goos     string
+		family   AddressFamily
+		wantCmd  string
+		wantArgs []string
+	}{
+		{goos: "linux", family: AddressFamilyIPv4, wantCmd: "traceroute", wantArgs: []string{"-4"}},
+		{goos: "linux", family: AddressFamilyIPv6, wantCmd: "traceroute", wantArgs: []string{"-6"}},
+		{goos: "darwin", family: AddressFamilyIPv4, wantCmd: "traceroute"},
+		{goos: "darwin", family: AddressFamilyIPv6, wantCmd: "traceroute6"},
+		{goos: "windows", family: AddressFamilyIPv6, wantCmd: "tracert", wantArgs: []string{"-6"}},
+		{goos: "windows", family: AddressFamilyAuto, wantCmd: "tracert"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos+"/"+string(tt.family), func(t *testing.T) {
+			cmd, args := tracerouteCommand(tt.goos, tt.family)
+			assert.Equal(t, tt.wantCmd, cmd)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func TestSplitHopTarget(t *testing.T) {
+	tests := []struct {
+		target   string
+		wantHost string
+		wantIP   string
+	}{
+		{target: "2001:db8::1", wantHost: "2001:db8::1", wantIP: "2001:db8::1"},
+		{target: "[2001:db8::1]", wantHost: "2001:db8::1", wantIP: "2001:db8::1"},
+		{target: "router.example.net [2001:db8::2]", wantHost: "router.example.net", wantIP: "2001:db8::2"},
+		{target: "edge.example.net [192.0.2.1]", wantHost: "edge.example.net", wantIP: "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			host, ip := splitHopTarget(tt.target)
+			assert.Equal(t, tt.wantHost, host)
+			assert.Equal(t, tt.wantIP, ip)
+		})
+	}
+}
+
+func TestParseUnixTracerouteOutputTraceroute6(t *testing.T) {
+	s := &service{}
+	lines := []string{
+		"traceroute6 to ipv6.google.com (2a00:1450:400f:802::200e) from 2001:db8::10, 64 hops max, 12 byte packets",
+		" 1  gw.example.net (2001:db8::1)  1.100 ms  1.200 ms  1.300 ms",
+		" 2  core.example.net [2001:db8:1::1]  2.100 ms  2.200 ms  2.300 ms",
+		" 3  [2001:db8:2::1]  3.100 ms  3.200 ms  3.300 ms",
+	}
+
+	result, err := s.parseUnixTracerouteOutput(lines, &TracerouteResult{Hops: []TracerouteHop{}})
+	require.NoError(t, err)
+	assert.Equal(t, "2a00:1450:400f:802::200e", result.IP)
+	require.Len(t, result.Hops, 3)
+	assert.Equal(t, "gw.example.net", result.Hops[0].Host)
+	assert.Equal(t, "2001:db8::1", result.Hops[0].IP)
+	assert.Equal(t, "core.example.net", result.Hops[1].Host)
+	assert.Equal(t, "2001:db8:1::1", result.Hops[1].IP)
+	assert.Equal(t, "2001:db8:2::1", result.Hops[2].IP)
+}
+
+func TestSameIP(t *testing.T) {
+	assert.True(t, sameIP("2001:db8::1", "2001:0db8:0:0:0:0:0:1"))
+	assert.True(t, sameIP("192.0.2.1", "192.0.2.1"))
+	assert.False(t, sameIP("2001:db8::1", "2001:db8::2"))
+	assert.False(t, sameIP("*", "2001:db8::1"))
+}
@@ -11,16 +11,22 @@ import (
 )
 
 type Result struct {
-	ID            int64     `json:"id"`
-	Timestamp     time.Time `json:"timestamp"`
-	Server        string    `json:"server"`
-	DownloadSpeed float64   `json:"downloadSpeed"`
-	UploadSpeed   float64   `json:"uploadSpeed"`
-	Latency       string    `json:"latency"`
-	Jitter        float64   `json:"jitter"`
-	Error         string    `json:"error,omitempty"`
-	Download      float64   `json:"-"`
-	Upload        float64   `json:"-"`
+	ID            int64                    `json:"id"`
+	Timestamp     time.Time                `json:"timestamp"`
+	Server        string                   `json:"server"`
+	DownloadSpeed float64                  `json:"downloadSpeed"`
+	UploadSpeed   float64                  `json:"uploadSpeed"`
+	Latency       string                   `json:"latency"`
+	Jitter        float64                  `json:"jitter"`
+	TTFB          float64                  `json:"ttfb,omitempty"`
+	PathMTU       int                      `json:"pathMtu,omitempty"`
+	MTUWarning    string                   `json:"mtuWarning,omitempty"`
+	DatagramLoss  *float64                 `json:"datagramLoss,omitempty"`
+	Attempts      int                      `json:"attempts,omitempty"`
+	Conditions    *types.NetworkConditions `json:"-"`
+	Error         string                   `json:"error,omitempty"`
+	Download      float64                  `json:"-"`
+	Upload        float64                  `json:"-"`
 }
 
 type ServerResponse struct {
@@ -58,17 +64,27 @@ type SpeedUpdate struct {
 type TestRunner interface {
 	// RunTest executes a speed test and returns the result
 	RunTest(ctx context.Context, opts *types.TestOptions) (*Result, error)
-	
+
 	// GetServers returns available servers for this test type
 	GetServers() ([]ServerResponse, error)
-	
+
 	// GetTestType returns the test type identifier
 	GetTestType() string
-	
+
 	// SetProgressCallback sets the callback for progress updates
 	SetProgressCallback(callback func(types.SpeedUpdate))
 }
 
+// ConditionsProvider supplies host network load at test time, typically the monitor service
+type ConditionsProvider interface {
+	LocalNetworkConditions() *types.NetworkConditions
+}
+
+// SamplingBooster raises live bandwidth sampling on local agents while a test runs
+type SamplingBooster interface {
+	BoostLocalSampling() (restore func())
+}
+
 // ResultHandler handles database saves and notifications
 type ResultHandler interface {
 	SaveResult(ctx context.Context, result *Result, testType string, opts *types.TestOptions) error
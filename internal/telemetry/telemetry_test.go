@@ -0,0 +1,63 @@
+// Copyright (c) 2024-2026, s0up and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/autobrr/netronome/internal/config"
+)
+
+func TestInitDisabled(t *testing.T) {
+	shutdown, err := Init(context.Background(), config.New().Telemetry)
+	require.NoError(t, err)
+	assert.False(t, Enabled())
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestStartDisabled(t *testing.T) {
+	ctx := context.Background()
+	spanCtx, span := Start(ctx, "speedtest", attribute.String("speedtest.provider", "iperf3"))
+	assert.Equal(t, ctx, spanCtx, "the context is left alone")
+	assert.False(t, span.IsRecording())
+	End(span, errors.New("ignored"))
+}
+
+func TestStartEnabled(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	original := otel.GetTracerProvider()
+	setProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		enabled.Store(false)
+		otel.SetTracerProvider(original)
+	})
+
+	ctx, parent := Start(context.Background(), "speedtest", attribute.String("speedtest.provider", "iperf3"))
+	_, child := Start(ctx, "db SELECT")
+	End(child, nil)
+	End(parent, errors.New("iperf3 test failed"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "db SELECT", spans[0].Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID(), "spans nest through the context")
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	assert.Equal(t, "speedtest", spans[1].Name())
+	assert.Contains(t, spans[1].Attributes(), attribute.String("speedtest.provider", "iperf3"))
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "iperf3 test failed", spans[1].Status().Description)
+	assert.Equal(t, trace.SpanKindInternal, spans[1].SpanKind())
+}
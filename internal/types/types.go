@@ -4,6 +4,7 @@
 package types
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -17,20 +18,36 @@ type TestOptions struct {
 	IsScheduled      bool     `json:"isScheduled"`
 	UseIperf         bool     `json:"useIperf"`
 	UseLibrespeed    bool     `json:"useLibrespeed"`
+	UseOokla         bool     `json:"useOokla"`
 	ServerHost       string   `json:"serverHost"`
 	ServerName       string   `json:"serverName"`
 	IsPublicServer   bool     `json:"isPublicServer"`
+	ServerRotation   string   `json:"serverRotation,omitempty"` // "", "round_robin" or "random"
+	EnableMTUProbe   bool     `json:"enableMtuProbe,omitempty"` // Probe the path MTU before the test
+	RunID            string   `json:"-"`                        // Run API run the test belongs to, copied to its updates
 }
 
+// Schedule server rotation modes
+const (
+	ServerRotationRoundRobin = "round_robin"
+	ServerRotationRandom     = "random"
+)
+
+// SpeedUpdateBidirectional is the type of updates from tests that measure download and
+// upload at once. Their Speed is the download and UploadSpeed the upload.
+const SpeedUpdateBidirectional = "bidirectional"
+
 type SpeedUpdate struct {
 	Type        string  `json:"type"`
 	ServerName  string  `json:"serverName"`
 	Speed       float64 `json:"speed"`
+	UploadSpeed float64 `json:"uploadSpeed,omitempty"` // Set for bidirectional updates
 	Progress    float64 `json:"progress"`
 	IsComplete  bool    `json:"isComplete"`
 	Latency     string  `json:"latency,omitempty"`
 	IsScheduled bool    `json:"isScheduled"`
-	TestType    string  `json:"testType,omitempty"` // "speedtest", "iperf3", "librespeed"
+	TestType    string  `json:"testType,omitempty"` // "speedtest", "iperf3", "librespeed", "ookla"
+	RunID       string  `json:"runId,omitempty"`    // Set for tests started through the run API
 }
 
 type Schedule struct {
@@ -42,6 +59,9 @@ type Schedule struct {
 	Enabled   bool        `json:"enabled"`
 	Options   TestOptions `json:"options"`
 	CreatedAt time.Time   `json:"createdAt"`
+	// RotationIndex is the position of the next server for round-robin schedules
+	RotationIndex int     `json:"rotationIndex"`
+	LastServerID  *string `json:"lastServerId,omitempty"`
 }
 
 type SpeedTestResult struct {
@@ -55,15 +75,119 @@ type SpeedTestResult struct {
 	Latency       string    `json:"latency,omitempty"`
 	PacketLoss    float64   `json:"packetLoss,omitempty"`
 	Jitter        *float64  `json:"jitter,omitempty"`
+	TTFB          *float64  `json:"ttfb,omitempty"` // Time to first byte in ms (librespeed only)
+	DownUpRatio   *float64  `json:"downUpRatio,omitempty"`
+	PathMTU       *int      `json:"pathMtu,omitempty"`      // From the optional MTU probe
+	MTUWarning    *string   `json:"mtuWarning,omitempty"`   // Set when the path MTU is below 1500
+	DatagramLoss  *float64  `json:"datagramLoss,omitempty"` // Percent of UDP datagrams lost (iperf3 UDP only)
+	Note          *string   `json:"note,omitempty"`         // User annotation added after the test
 	IsScheduled   bool      `json:"isScheduled"`
+	Attempts      int       `json:"attempts"` // Runs it took, more than 1 when failed scheduled runs were retried
+	CreatedAt     time.Time `json:"createdAt"`
+
+	// Local load when the test started, captured from a monitor agent on this host
+	LoadRxBytesPerSecond *int64   `json:"loadRxBytesPerSecond,omitempty"`
+	LoadTxBytesPerSecond *int64   `json:"loadTxBytesPerSecond,omitempty"`
+	LoadCPUPercent       *float64 `json:"loadCpuPercent,omitempty"`
+}
+
+// MaxResultNoteLength caps the size of a note attached to a speed test or packet loss result
+const MaxResultNoteLength = 2000
+
+// ResultNoteRequest sets the note of a result, an empty note clears it
+type ResultNoteRequest struct {
+	Note string `json:"note"`
+}
+
+// SpeedTestRatio is one point of the download/upload asymmetry history.
+// Baseline and change are relative to earlier results of the same test type.
+type SpeedTestRatio struct {
+	ID            int64     `json:"id"`
+	ServerName    string    `json:"serverName"`
+	TestType      string    `json:"testType"`
+	DownloadSpeed float64   `json:"downloadSpeed"`
+	UploadSpeed   float64   `json:"uploadSpeed"`
+	DownUpRatio   float64   `json:"downUpRatio"`
+	BaselineRatio *float64  `json:"baselineRatio,omitempty"`
+	ChangePercent *float64  `json:"changePercent,omitempty"`
+	Shift         bool      `json:"shift"`
 	CreatedAt     time.Time `json:"createdAt"`
 }
 
+// NetworkConditions describes host load at the moment a speed test starts
+type NetworkConditions struct {
+	RxBytesPerSecond int64
+	TxBytesPerSecond int64
+	CPUUsagePercent  *float64
+}
+
 type PaginatedSpeedTests struct {
-	Data  []SpeedTestResult `json:"data"`
-	Total int               `json:"total"`
-	Page  int               `json:"page"`
-	Limit int               `json:"limit"`
+	Data    []SpeedTestResult `json:"data"`
+	Total   int               `json:"total"`
+	Page    int               `json:"page"`
+	Limit   int               `json:"limit"`
+	Filters SpeedTestFilter   `json:"filters"` // Filters applied to the results
+}
+
+// SpeedTestFilter narrows the speed test history, empty fields match everything
+type SpeedTestFilter struct {
+	Provider string     `json:"provider,omitempty"` // Test type, e.g. iperf3 or librespeed
+	Server   string     `json:"server,omitempty"`   // Server name, ID or host
+	Since    *time.Time `json:"since,omitempty"`
+}
+
+// Speed test batch and batch run statuses
+const (
+	BatchStatusPending   = "pending"
+	BatchStatusRunning   = "running"
+	BatchStatusCompleted = "completed"
+	BatchStatusFailed    = "failed"
+)
+
+// Directions a batch run measures
+const (
+	BatchDirectionDownload = "download"
+	BatchDirectionUpload   = "upload"
+	BatchDirectionBoth     = "both"
+)
+
+// SpeedTestMatrix defines a batch: every server of every target is tested once per direction
+type SpeedTestMatrix struct {
+	Name       string                  `json:"name"`
+	Targets    []SpeedTestMatrixTarget `json:"targets"`
+	Directions []string                `json:"directions"` // download, upload or both, defaults to both
+}
+
+// SpeedTestMatrixTarget lists the servers to test with one provider. Servers are server
+// IDs, or host:port for iperf3. An empty list runs once against the automatic pick.
+type SpeedTestMatrixTarget struct {
+	TestType string   `json:"testType"`
+	Servers  []string `json:"servers"`
+}
+
+// SpeedTestBatch groups the runs of one matrix under a single ID
+type SpeedTestBatch struct {
+	ID          int64               `json:"id"`
+	Name        string              `json:"name"`
+	Status      string              `json:"status"`
+	Runs        []SpeedTestBatchRun `json:"runs"`
+	CreatedAt   time.Time           `json:"createdAt"`
+	CompletedAt *time.Time          `json:"completedAt,omitempty"`
+}
+
+// SpeedTestBatchRun is one cell of a batch matrix. Result is set once the test is stored.
+type SpeedTestBatchRun struct {
+	ID          int64            `json:"id"`
+	BatchID     int64            `json:"batchId"`
+	TestType    string           `json:"testType"`
+	Server      string           `json:"server"`
+	Direction   string           `json:"direction"`
+	Status      string           `json:"status"`
+	SpeedTestID *int64           `json:"speedTestId,omitempty"`
+	Result      *SpeedTestResult `json:"result,omitempty"`
+	Error       string           `json:"error,omitempty"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	CompletedAt *time.Time       `json:"completedAt,omitempty"`
 }
 
 type SavedIperfServer struct {
@@ -106,6 +230,22 @@ type MTRHop struct {
 	AS          string  `json:"as,omitempty"`
 }
 
+// MTRDataRecord is the stored MTR JSON of a single packet loss result
+type MTRDataRecord struct {
+	ResultID  int64     `json:"resultId"`
+	MonitorID int64     `json:"monitorId"`
+	MTRData   string    `json:"mtrData"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TracerouteHopsRecord is the stored hop JSON of a single traceroute monitor result
+type TracerouteHopsRecord struct {
+	ResultID  int64     `json:"resultId"`
+	MonitorID int64     `json:"monitorId"`
+	Hops      string    `json:"hops"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
 // MTRData represents the complete MTR test results
 type MTRData struct {
 	Destination string   `json:"destination"`
@@ -116,40 +256,73 @@ type MTRData struct {
 }
 
 type PacketLossUpdate struct {
-	Type        string  `json:"type"`
-	MonitorID   int64   `json:"monitorId"`
-	Host        string  `json:"host"`
-	IsRunning   bool    `json:"isRunning"`
-	IsComplete  bool    `json:"isComplete"`
-	Progress    float64 `json:"progress"`
-	PacketLoss  float64 `json:"packetLoss,omitempty"`
-	MinRTT      float64 `json:"minRtt,omitempty"`
-	MaxRTT      float64 `json:"maxRtt,omitempty"`
-	AvgRTT      float64 `json:"avgRtt,omitempty"`
-	StdDevRTT   float64 `json:"stdDevRtt,omitempty"`
-	PacketsSent int     `json:"packetsSent,omitempty"`
-	PacketsRecv int     `json:"packetsRecv,omitempty"`
-	UsedMTR     bool    `json:"usedMtr,omitempty"`
-	HopCount    int     `json:"hopCount,omitempty"`
-	Error       string  `json:"error,omitempty"`
+	Type           string  `json:"type"`
+	MonitorID      int64   `json:"monitorId"`
+	Host           string  `json:"host"`
+	IsRunning      bool    `json:"isRunning"`
+	IsComplete     bool    `json:"isComplete"`
+	Progress       float64 `json:"progress"`
+	PacketLoss     float64 `json:"packetLoss,omitempty"`
+	MinRTT         float64 `json:"minRtt,omitempty"`
+	MaxRTT         float64 `json:"maxRtt,omitempty"`
+	AvgRTT         float64 `json:"avgRtt,omitempty"`
+	StdDevRTT      float64 `json:"stdDevRtt,omitempty"`
+	Jitter         float64 `json:"jitter,omitempty"`
+	PacketsSent    int     `json:"packetsSent,omitempty"`
+	PacketsRecv    int     `json:"packetsRecv,omitempty"`
+	UsedMTR        bool    `json:"usedMtr,omitempty"`
+	HopCount       int     `json:"hopCount,omitempty"`
+	PrivilegedMode bool    `json:"privilegedMode,omitempty"`
+	ProbedIP       string  `json:"probedIp,omitempty"`
+	Error          string  `json:"error,omitempty"`
+
+	// CompletedAt is when a recently completed test finished, set while IsComplete is reported
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
 }
 
 type PacketLossMonitor struct {
-	ID              int64      `db:"id" json:"id"`
-	Host            string     `db:"host" json:"host"`
-	Name            string     `db:"name" json:"name"`
-	Interval        string     `db:"interval" json:"interval"` // Changed from int to string
-	PacketCount     int        `db:"packet_count" json:"packetCount"`
-	Enabled         bool       `db:"enabled" json:"enabled"`
-	Threshold       float64    `db:"threshold" json:"threshold"`
-	LastRun         *time.Time `db:"last_run" json:"lastRun"` // New field
-	NextRun         *time.Time `db:"next_run" json:"nextRun"` // New field
-	LastState       string     `db:"last_state" json:"lastState"`
-	LastStateChange *time.Time `db:"last_state_change" json:"lastStateChange"`
-	CreatedAt       time.Time  `db:"created_at" json:"createdAt"`
-	UpdatedAt       time.Time  `db:"updated_at" json:"updatedAt"`
+	ID                int64      `db:"id" json:"id"`
+	Host              string     `db:"host" json:"host"`
+	Name              string     `db:"name" json:"name"`
+	Interval          string     `db:"interval" json:"interval"` // Changed from int to string
+	PacketCount       int        `db:"packet_count" json:"packetCount"`
+	Enabled           bool       `db:"enabled" json:"enabled"`
+	Threshold         float64    `db:"threshold" json:"threshold"`                // legacy single threshold, kept equal to CriticalThreshold
+	WarningThreshold  float64    `db:"warning_threshold" json:"warningThreshold"` // 0 disables the warning level
+	CriticalThreshold float64    `db:"critical_threshold" json:"criticalThreshold"`
+	ProbeMode         string     `db:"probe_mode" json:"probeMode"`             // icmp, udp or tcp
+	ProbePort         int        `db:"probe_port" json:"probePort,omitempty"`   // tcp only, 0 means 443
+	IntervalMs        int        `db:"interval_ms" json:"intervalMs,omitempty"` // between ping/MTR packets, 0 means 1000
+	PacketSize        int        `db:"packet_size" json:"packetSize,omitempty"` // ICMP payload bytes, 0 means 24
+	AddressFamily     string     `db:"address_family" json:"addressFamily"`     // auto, ipv4 or ipv6
+	LastRun           *time.Time `db:"last_run" json:"lastRun"`                 // New field
+	NextRun           *time.Time `db:"next_run" json:"nextRun"`                 // New field
+	LastState         string     `db:"last_state" json:"lastState"`
+	LastStateChange   *time.Time `db:"last_state_change" json:"lastStateChange"`
+	CreatedAt         time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt         time.Time  `db:"updated_at" json:"updatedAt"`
 }
 
+// Packet loss probe modes
+const (
+	PacketLossProbeICMP = "icmp" // MTR, falling back to ping
+	PacketLossProbeUDP  = "udp"  // MTR in UDP mode
+	PacketLossProbeTCP  = "tcp"  // TCP connects to ProbePort
+)
+
+// DefaultPacketLossProbePort is the TCP probe port when none is set
+const DefaultPacketLossProbePort = 443
+
+// Bounds for the packet interval and ICMP payload size of packet loss monitors. The
+// smallest payload still carries the timestamp used to measure RTT; the largest fills
+// a 9000 byte jumbo frame.
+const (
+	MinPacketLossIntervalMs = 100
+	MaxPacketLossIntervalMs = 10000
+	MinPacketLossPacketSize = 24
+	MaxPacketLossPacketSize = 8972
+)
+
 type PacketLossResult struct {
 	ID             int64     `db:"id" json:"id"`
 	MonitorID      int64     `db:"monitor_id" json:"monitorId"`
@@ -158,12 +331,15 @@ type PacketLossResult struct {
 	MaxRTT         float64   `db:"max_rtt" json:"maxRtt"`
 	AvgRTT         float64   `db:"avg_rtt" json:"avgRtt"`
 	StdDevRTT      float64   `db:"std_dev_rtt" json:"stdDevRtt"`
+	Jitter         float64   `db:"jitter" json:"jitter"` // Mean RTT variation between consecutive replies in ms
 	PacketsSent    int       `db:"packets_sent" json:"packetsSent"`
 	PacketsRecv    int       `db:"packets_recv" json:"packetsRecv"`
 	UsedMTR        bool      `db:"used_mtr" json:"usedMtr"`
 	HopCount       int       `db:"hop_count" json:"hopCount"`
 	MTRData        *string   `db:"mtr_data" json:"mtrData,omitempty"`
 	PrivilegedMode bool      `db:"privileged_mode" json:"privilegedMode"`
+	ProbedIP       *string   `db:"probed_ip" json:"probedIp,omitempty"` // Address that was probed, nil on older results
+	Note           *string   `db:"note" json:"note,omitempty"`          // User annotation added after the test
 	CreatedAt      time.Time `db:"created_at" json:"createdAt"`
 }
 
@@ -175,14 +351,53 @@ type PacketLossResultSummary struct {
 	MaxRTT         float64   `db:"max_rtt" json:"maxRtt"`
 	AvgRTT         float64   `db:"avg_rtt" json:"avgRtt"`
 	StdDevRTT      float64   `db:"std_dev_rtt" json:"stdDevRtt"`
+	Jitter         float64   `db:"jitter" json:"jitter"` // Mean RTT variation between consecutive replies in ms
 	PacketsSent    int       `db:"packets_sent" json:"packetsSent"`
 	PacketsRecv    int       `db:"packets_recv" json:"packetsRecv"`
 	UsedMTR        bool      `db:"used_mtr" json:"usedMtr"`
 	HopCount       int       `db:"hop_count" json:"hopCount"`
 	PrivilegedMode bool      `db:"privileged_mode" json:"privilegedMode"`
+	ProbedIP       *string   `db:"probed_ip" json:"probedIp,omitempty"` // Address that was probed, nil on older results
+	Note           *string   `db:"note" json:"note,omitempty"`          // User annotation added after the test
 	CreatedAt      time.Time `db:"created_at" json:"createdAt"`
 }
 
+// PacketLossSparklinePoint is a single run in a packet loss monitor's recent trend. UsedMTR
+// marks runs measured by MTR rather than ping, whose loss and RTT aren't quite comparable.
+type PacketLossSparklinePoint struct {
+	ResultID   int64     `json:"resultId"`
+	PacketLoss float64   `json:"packetLoss"`
+	AvgRTT     float64   `json:"avgRtt"`
+	Jitter     float64   `json:"jitter"`
+	UsedMTR    bool      `json:"usedMtr"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// PacketLossSparkline holds the latest runs of a packet loss monitor, oldest first
+type PacketLossSparkline struct {
+	MonitorID int64                      `json:"monitorId"`
+	Points    []PacketLossSparklinePoint `json:"points"`
+}
+
+// HopCountPoint is a single route length sample for a packet loss or traceroute monitor
+type HopCountPoint struct {
+	ResultID  int64     `json:"resultId"`
+	HopCount  int       `json:"hopCount"`
+	Changed   bool      `json:"changed"` // Hop count differs from the previous sample
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// HopCountTrend summarises how the route length to a monitored host evolves over time
+type HopCountTrend struct {
+	MonitorID int64           `json:"monitorId"`
+	Points    []HopCountPoint `json:"points"`
+	Min       int             `json:"min"`
+	Max       int             `json:"max"`
+	Avg       float64         `json:"avg"`
+	Latest    int             `json:"latest"`
+	Changes   int             `json:"changes"`
+}
+
 type PaginatedPacketLossResults struct {
 	Data  []PacketLossResultSummary `json:"data"`
 	Total int                       `json:"total"`
@@ -190,6 +405,40 @@ type PaginatedPacketLossResults struct {
 	Limit int                       `json:"limit"`
 }
 
+// TracerouteMonitor runs a traceroute on a schedule to track routing changes over time
+type TracerouteMonitor struct {
+	ID        int64      `db:"id" json:"id"`
+	Host      string     `db:"host" json:"host"`
+	Name      string     `db:"name" json:"name"`
+	Interval  string     `db:"interval" json:"interval"`
+	Family    string     `db:"family" json:"family"` // auto, ipv4 or ipv6
+	Enabled   bool       `db:"enabled" json:"enabled"`
+	LastRun   *time.Time `db:"last_run" json:"lastRun"`
+	NextRun   *time.Time `db:"next_run" json:"nextRun"`
+	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updatedAt"`
+}
+
+// TracerouteMonitorResult is one scheduled traceroute run. Hops holds the hop list as JSON.
+type TracerouteMonitorResult struct {
+	ID          int64           `db:"id" json:"id"`
+	MonitorID   int64           `db:"monitor_id" json:"monitorId"`
+	Destination string          `db:"destination" json:"destination"`
+	IP          string          `db:"ip" json:"ip"`
+	TotalHops   int             `db:"total_hops" json:"totalHops"`
+	Complete    bool            `db:"complete" json:"complete"`
+	PathChanged bool            `db:"path_changed" json:"pathChanged"` // Hop addresses differ from the previous run
+	Hops        json.RawMessage `db:"hops" json:"hops"`
+	CreatedAt   time.Time       `db:"created_at" json:"createdAt"`
+}
+
+type PaginatedTracerouteMonitorResults struct {
+	Data  []TracerouteMonitorResult `json:"data"`
+	Total int                       `json:"total"`
+	Page  int                       `json:"page"`
+	Limit int                       `json:"limit"`
+}
+
 // MonitorAgent represents a monitoring agent configuration
 type MonitorAgent struct {
 	ID                int64      `db:"id" json:"id"`
@@ -203,8 +452,46 @@ type MonitorAgent struct {
 	DiscoveredAt      *time.Time `db:"discovered_at" json:"discoveredAt,omitempty"`
 	CreatedAt         time.Time  `db:"created_at" json:"createdAt"`
 	UpdatedAt         time.Time  `db:"updated_at" json:"updatedAt"`
+
+	// Token auth for agents behind OAuth-style gateways (auth_mode = "token")
+	AuthMode          string  `db:"auth_mode" json:"authMode"`
+	TokenURL          *string `db:"token_url" json:"tokenUrl,omitempty"`
+	TokenClientID     *string `db:"token_client_id" json:"tokenClientId,omitempty"`
+	TokenClientSecret *string `db:"token_client_secret" json:"tokenClientSecret,omitempty"`
+	TokenScope        *string `db:"token_scope" json:"tokenScope,omitempty"`
+
+	// TLS settings for HTTPS agents. InsecureSkipVerify disables certificate checks entirely
+	// and is dangerous; prefer pinning the agent's CA with CACert (PEM).
+	InsecureSkipVerify bool    `db:"insecure_skip_verify" json:"insecureSkipVerify"`
+	CACert             *string `db:"ca_cert" json:"caCert,omitempty"`
+
+	// Interface filtering. Container and VM interfaces (veth, docker, br-, ...) are skipped
+	// unless IncludeVirtualInterfaces is set; the regex patterns narrow the list further.
+	InterfaceInclude         *string `db:"interface_include" json:"interfaceInclude,omitempty"`
+	InterfaceExclude         *string `db:"interface_exclude" json:"interfaceExclude,omitempty"`
+	IncludeVirtualInterfaces bool    `db:"include_virtual_interfaces" json:"includeVirtualInterfaces"`
+
+	// Resource notification thresholds for this agent. When set they replace the
+	// notification rule threshold of the matching event; nil uses the rule.
+	CPUThreshold    *float64 `db:"cpu_threshold" json:"cpuThreshold,omitempty"`
+	MemoryThreshold *float64 `db:"memory_threshold" json:"memoryThreshold,omitempty"`
+	DiskThreshold   *float64 `db:"disk_threshold" json:"diskThreshold,omitempty"`
+	TempThreshold   *float64 `db:"temp_threshold" json:"tempThreshold,omitempty"`
+
+	// Tags group agents in the UI and let notification rules target a group
+	Tags []string `db:"tags" json:"tags"`
+
+	// Headers are added to every request to the agent, e.g. for an authenticating
+	// reverse proxy in front of it
+	Headers map[string]string `db:"headers" json:"headers,omitempty"`
 }
 
+// Monitor agent auth modes
+const (
+	AgentAuthModeAPIKey = "api_key"
+	AgentAuthModeToken  = "token"
+)
+
 // MonitorBandwidth represents bandwidth data from monitoring agent
 type MonitorBandwidth struct {
 	ID                 int64     `db:"id" json:"id"`
@@ -220,9 +507,13 @@ type MonitorBandwidth struct {
 
 // MonitorLiveData represents live data from monitoring agent
 type MonitorLiveData struct {
-	Index   int `json:"index"`
-	Seconds int `json:"seconds"`
-	Rx      struct {
+	Index     int    `json:"index"`
+	Seconds   int    `json:"seconds"`
+	Interface string `json:"interface,omitempty"` // set by the agent when it monitors a specific interface
+	// Interfaces holds the sample of each interface when the agent monitors several, the
+	// top-level rates are their sum
+	Interfaces map[string]MonitorLiveData `json:"interfaces,omitempty"`
+	Rx         struct {
 		Ratestring       string `json:"ratestring"`
 		Bytespersecond   int    `json:"bytespersecond"`
 		Packetspersecond int    `json:"packetspersecond"`
@@ -328,6 +619,13 @@ type MonitorFullData struct {
 	} `json:"interfaces"`
 }
 
+// MonitorAgentStatus is the live state of one monitored agent
+type MonitorAgentStatus struct {
+	Connected   bool             `json:"connected"`
+	Maintenance bool             `json:"maintenance"`
+	LiveData    *MonitorLiveData `json:"liveData,omitempty"`
+}
+
 // MonitorUpdate represents real-time monitoring updates
 type MonitorUpdate struct {
 	Type             string                 `json:"type"`
@@ -339,6 +637,16 @@ type MonitorUpdate struct {
 	TxRateString     string                 `json:"txRateString"`
 	Connected        bool                   `json:"connected"`
 	Data             map[string]interface{} `json:"data,omitempty"`
+	// Interfaces holds per-interface rates when the agent monitors several
+	Interfaces map[string]MonitorInterfaceRate `json:"interfaces,omitempty"`
+}
+
+// MonitorInterfaceRate is the live rate of one interface of a MonitorUpdate
+type MonitorInterfaceRate struct {
+	RxBytesPerSecond int64  `json:"rxBytesPerSecond"`
+	TxBytesPerSecond int64  `json:"txBytesPerSecond"`
+	RxRateString     string `json:"rxRateString"`
+	TxRateString     string `json:"txRateString"`
 }
 
 // MonitorUpdateType constants
@@ -397,9 +705,152 @@ type MonitorResourceStats struct {
 	DiskUsageJSON     string    `db:"disk_usage_json" json:"diskUsageJson"`
 	TemperatureJSON   string    `db:"temperature_json" json:"temperatureJson"`
 	UptimeSeconds     int64     `db:"uptime_seconds" json:"uptimeSeconds"`
+	DiskUsedPercent   float64   `db:"disk_used_percent" json:"diskUsedPercent"` // highest across disks
+	SwapTotal         int64     `db:"swap_total" json:"swapTotal"`
+	SwapUsed          int64     `db:"swap_used" json:"swapUsed"`
+	CPUPerCoreJSON    string    `db:"cpu_per_core_json" json:"cpuPerCoreJson"` // JSON array of per-core usage
 	CreatedAt         time.Time `db:"created_at" json:"createdAt"`
 }
 
+// MonitorProcessSnapshot is the latest top-process list fetched from an agent, kept so
+// it can be served while the agent is offline
+type MonitorProcessSnapshot struct {
+	AgentID       int64     `db:"agent_id" json:"agentId"`
+	ProcessesJSON string    `db:"processes" json:"processesJson"` // the agent's /system/processes response
+	CreatedAt     time.Time `db:"created_at" json:"createdAt"`
+}
+
+// SMART health states of an agent disk
+const (
+	SmartHealthPassed  = "passed"
+	SmartHealthPrefail = "prefail" // a pre-failure attribute is at or below its threshold
+	SmartHealthFailing = "failing" // the drive's overall self-assessment failed
+	SmartHealthUnknown = "unknown" // SMART data couldn't be read, e.g. a sleeping disk
+)
+
+// MonitorSmartDevice is the last SMART health read from one disk of an agent, kept so
+// it can be served while the agent is offline
+type MonitorSmartDevice struct {
+	AgentID            int64     `db:"agent_id" json:"agentId"`
+	Device             string    `db:"device" json:"device"`
+	Model              string    `db:"model" json:"model"`
+	Serial             string    `db:"serial" json:"serial"`
+	Health             string    `db:"health" json:"health"`
+	ReallocatedSectors int64     `db:"reallocated_sectors" json:"reallocatedSectors"`
+	PendingSectors     int64     `db:"pending_sectors" json:"pendingSectors"`
+	Temperature        float64   `db:"temperature" json:"temperature"` // Celsius, 0 when unknown
+	CreatedAt          time.Time `db:"created_at" json:"createdAt"`
+}
+
+// MonitorAgentSnapshot is the point-in-time state of a single agent
+type MonitorAgentSnapshot struct {
+	AgentID       int64                 `json:"agentId"`
+	AgentName     string                `json:"agentName"`
+	Enabled       bool                  `json:"enabled"`
+	Connected     bool                  `json:"connected"`
+	LiveData      *MonitorLiveData      `json:"liveData,omitempty"`
+	ResourceStats *MonitorResourceStats `json:"resourceStats,omitempty"`
+}
+
+// MonitorSnapshot is the point-in-time state of every monitored agent
+type MonitorSnapshot struct {
+	CapturedAt time.Time              `json:"capturedAt"`
+	Agents     []MonitorAgentSnapshot `json:"agents"`
+}
+
+// MonitorResourceSummary aggregates resource usage samples over a time range
+type MonitorResourceSummary struct {
+	AgentID          int64    `json:"agentId,omitempty"`
+	AgentName        string   `json:"agentName,omitempty"`
+	Samples          int64    `json:"samples"`
+	AvgCPUPercent    float64  `json:"avgCpuPercent"`
+	MaxCPUPercent    float64  `json:"maxCpuPercent"`
+	AvgMemoryPercent float64  `json:"avgMemoryPercent"`
+	MaxMemoryPercent float64  `json:"maxMemoryPercent"`
+	AvgSwapPercent   float64  `json:"avgSwapPercent"`
+	MaxSwapPercent   float64  `json:"maxSwapPercent"`
+	AvgDiskPercent   *float64 `json:"avgDiskPercent,omitempty"`
+	MaxDiskPercent   *float64 `json:"maxDiskPercent,omitempty"`
+}
+
+// FleetResourceStats summarizes resource usage per agent and across all agents
+type FleetResourceStats struct {
+	Start      time.Time                `json:"start"`
+	End        time.Time                `json:"end"`
+	AgentCount int                      `json:"agentCount"`
+	Fleet      MonitorResourceSummary   `json:"fleet"`
+	Agents     []MonitorResourceSummary `json:"agents"`
+}
+
+// MonitorBandwidthUsage is the traffic an agent transferred between Start and End, in bytes
+type MonitorBandwidthUsage struct {
+	AgentID  int64     `json:"agentId"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Download int64     `json:"download"`
+	Upload   int64     `json:"upload"`
+	Total    int64     `json:"total"`
+	// CoveredFrom is where the vnstat data of every interface starts within the range,
+	// nil when none of it lies in the range. Partial is set when that is after Start,
+	// as traffic before it is missing from the totals.
+	CoveredFrom *time.Time `json:"coveredFrom"`
+	Partial     bool       `json:"partial"`
+}
+
+// MonitorInterfaceHistory is the traffic of one agent interface per hour, day or month,
+// oldest bucket first
+type MonitorInterfaceHistory struct {
+	AgentID   int64                    `json:"agentId"`
+	Interface string                   `json:"interface"`
+	Period    string                   `json:"period"`
+	UpdatedAt time.Time                `json:"updatedAt"` // When the snapshot was taken
+	Buckets   []MonitorInterfaceBucket `json:"buckets"`
+}
+
+// MonitorInterfaceBucket is the traffic of an interface in the bucket starting at Start, in bytes
+type MonitorInterfaceBucket struct {
+	Start time.Time `json:"start"`
+	RX    int64     `json:"rx"`
+	TX    int64     `json:"tx"`
+	Total int64     `json:"total"`
+}
+
+// MonitorAgentProbe is a ping probe the agent runs from its own vantage point
+type MonitorAgentProbe struct {
+	ID          int64     `db:"id" json:"id"`
+	AgentID     int64     `db:"agent_id" json:"agentId"`
+	Host        string    `db:"host" json:"host"`
+	Interval    string    `db:"interval" json:"interval"`
+	PacketCount int       `db:"packet_count" json:"packetCount"`
+	Enabled     bool      `db:"enabled" json:"enabled"`
+	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+}
+
+// MonitorAgentProbeResult is a single probe run reported by an agent
+type MonitorAgentProbeResult struct {
+	ID          int64     `db:"id" json:"id"`
+	AgentID     int64     `db:"agent_id" json:"agentId"`
+	Host        string    `db:"host" json:"host"`
+	PacketLoss  float64   `db:"packet_loss" json:"packetLoss"`
+	MinRTT      float64   `db:"min_rtt" json:"minRtt"`
+	AvgRTT      float64   `db:"avg_rtt" json:"avgRtt"`
+	MaxRTT      float64   `db:"max_rtt" json:"maxRtt"`
+	StdDevRTT   float64   `db:"std_dev_rtt" json:"stdDevRtt"`
+	PacketsSent int       `db:"packets_sent" json:"packetsSent"`
+	PacketsRecv int       `db:"packets_recv" json:"packetsRecv"`
+	Error       *string   `db:"error" json:"error,omitempty"`
+	MeasuredAt  time.Time `db:"measured_at" json:"measuredAt"`
+	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+}
+
+// MonitorRetention sets how long each class of monitor data is kept. Zero disables
+// cleanup for that class.
+type MonitorRetention struct {
+	ResourceStats time.Duration
+	Snapshots     time.Duration
+	ProbeResults  time.Duration
+}
+
 // MonitorHistoricalSnapshot represents monitoring data snapshots
 type MonitorHistoricalSnapshot struct {
 	ID            int64     `db:"id" json:"id"`
@@ -409,3 +860,19 @@ type MonitorHistoricalSnapshot struct {
 	DataJSON      string    `db:"data_json" json:"dataJson"`
 	CreatedAt     time.Time `db:"created_at" json:"createdAt"`
 }
+
+// QueueStats describes the state of one job queue: the speed test and packet loss
+// slots, or the scheduler's traceroute queue
+type QueueStats struct {
+	Limit   int `json:"limit"`
+	Queued  int `json:"queued"`
+	Running int `json:"running"`
+	// Started counts jobs that have left the queue since startup
+	Started int64 `json:"started"`
+
+	TotalWaitSeconds float64 `json:"totalWaitSeconds"`
+	MaxWaitSeconds   float64 `json:"maxWaitSeconds"`
+	LastWaitSeconds  float64 `json:"lastWaitSeconds"`
+	// LastDelaySeconds is how far past its scheduled time the last scheduled job started
+	LastDelaySeconds float64 `json:"lastDelaySeconds"`
+}
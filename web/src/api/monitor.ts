@@ -16,10 +16,28 @@ export interface MonitorAgent {
   isTailscale?: boolean;
   tailscaleHostname?: string;
   discoveredAt?: string;
+  authMode?: AgentAuthMode;
+  tokenUrl?: string;
+  tokenClientId?: string;
+  tokenClientSecret?: string;
+  tokenScope?: string;
+  insecureSkipVerify?: boolean;
+  caCert?: string;
+  interfaceInclude?: string;
+  interfaceExclude?: string;
+  includeVirtualInterfaces?: boolean;
+  cpuThreshold?: number;
+  memoryThreshold?: number;
+  diskThreshold?: number;
+  tempThreshold?: number;
+  tags?: string[];
 }
 
+export type AgentAuthMode = "api_key" | "token";
+
 export interface MonitorStatus {
   connected: boolean;
+  maintenance?: boolean;
   liveData?: {
     rx: {
       bytespersecond: number;
@@ -70,6 +88,8 @@ export interface HardwareStats {
   memory: MemoryStats;
   disks: DiskStats[];
   temperature?: TemperatureStats[];
+  uptime?: number; // seconds
+  boot_time?: string;
   updated_at: string;
   from_cache?: boolean; // True when data is from database, not live agent
 }
@@ -81,6 +101,7 @@ export interface CPUStats {
   model: string;
   frequency: number; // MHz
   load_avg?: number[]; // 1, 5, 15 min
+  per_core?: number[]; // usage percent per logical CPU
 }
 
 export interface MemoryStats {
@@ -116,11 +137,113 @@ export interface TemperatureStats {
   critical?: number;
 }
 
+export interface MonitorResourceSummary {
+  agentId?: number;
+  agentName?: string;
+  samples: number;
+  avgCpuPercent: number;
+  maxCpuPercent: number;
+  avgMemoryPercent: number;
+  maxMemoryPercent: number;
+  avgSwapPercent: number;
+  maxSwapPercent: number;
+  avgDiskPercent?: number;
+  maxDiskPercent?: number;
+}
+
+export interface FleetResourceStats {
+  start: string;
+  end: string;
+  agentCount: number;
+  fleet: MonitorResourceSummary;
+  agents: MonitorResourceSummary[];
+}
+
+export interface MonitorBandwidthUsage {
+  agentId: number;
+  start: string;
+  end: string;
+  download: number; // bytes
+  upload: number; // bytes
+  total: number; // bytes
+  coveredFrom: string | null; // where vnstat data starts within the range
+  partial: boolean; // traffic before coveredFrom is missing from the totals
+}
+
+export type MonitorInterfaceHistoryPeriod = "hourly" | "daily" | "monthly";
+
+export interface MonitorInterfaceBucket {
+  start: string;
+  rx: number; // bytes
+  tx: number; // bytes
+  total: number; // bytes
+}
+
+export interface MonitorInterfaceHistory {
+  agentId: number;
+  interface: string;
+  period: MonitorInterfaceHistoryPeriod;
+  updatedAt: string;
+  buckets: MonitorInterfaceBucket[]; // oldest first
+}
+
+export interface MonitorAgentSnapshot {
+  agentId: number;
+  agentName: string;
+  enabled: boolean;
+  connected: boolean;
+  liveData?: MonitorStatus["liveData"];
+  resourceStats?: {
+    cpuUsagePercent: number;
+    memoryUsedPercent: number;
+    swapUsedPercent: number;
+    diskUsedPercent: number;
+    uptimeSeconds: number;
+    createdAt: string;
+  };
+}
+
+export interface MonitorSnapshot {
+  capturedAt: string;
+  agents: MonitorAgentSnapshot[];
+}
+
+export interface MonitorAgentProbe {
+  id?: number;
+  agentId?: number;
+  host: string;
+  interval: string;
+  packetCount: number;
+  enabled: boolean;
+  createdAt?: string;
+}
+
+export interface MonitorAgentProbeResult {
+  id: number;
+  agentId: number;
+  host: string;
+  packetLoss: number;
+  minRtt: number;
+  avgRtt: number;
+  maxRtt: number;
+  stdDevRtt: number;
+  packetsSent: number;
+  packetsRecv: number;
+  error?: string;
+  measuredAt: string;
+  createdAt: string;
+}
+
 export interface CreateAgentRequest {
   name: string;
   url: string;
   apiKey?: string;
   enabled: boolean;
+  authMode?: AgentAuthMode;
+  tokenUrl?: string;
+  tokenClientId?: string;
+  tokenClientSecret?: string;
+  tokenScope?: string;
 }
 
 export interface UpdateAgentRequest extends CreateAgentRequest {
@@ -128,8 +251,9 @@ export interface UpdateAgentRequest extends CreateAgentRequest {
 }
 
 // Agent management
-export async function getMonitorAgents(): Promise<MonitorAgent[]> {
-  const response = await fetch(getApiUrl("/monitor/agents"));
+export async function getMonitorAgents(tag?: string): Promise<MonitorAgent[]> {
+  const query = tag ? `?tag=${encodeURIComponent(tag)}` : "";
+  const response = await fetch(getApiUrl(`/monitor/agents${query}`));
   if (!response.ok) {
     const errorData = await response.json().catch(() => ({}));
     throw new Error(errorData.error || "Failed to fetch agents");
@@ -198,6 +322,18 @@ export async function getMonitorAgentStatus(id: number): Promise<MonitorStatus>
   return response.json();
 }
 
+// Status of every monitored agent in one request, keyed by agent ID
+export async function getMonitorAgentStatuses(): Promise<
+  Record<number, MonitorStatus>
+> {
+  const response = await fetch(getApiUrl("/monitor/agents/status"));
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({}));
+    throw new Error(errorData.error || "Failed to fetch agent statuses");
+  }
+  return response.json();
+}
+
 export async function startMonitorAgent(id: number): Promise<void> {
   const response = await fetch(getApiUrl(`/monitor/agents/${id}/start`), {
     method: "POST",
@@ -218,6 +354,20 @@ export async function stopMonitorAgent(id: number): Promise<void> {
   }
 }
 
+// Collects from the agent right away and returns the resource stats collected
+export async function refreshMonitorAgent(
+  id: number,
+): Promise<NonNullable<MonitorAgentSnapshot["resourceStats"]>> {
+  const response = await fetch(getApiUrl(`/monitor/agents/${id}/refresh`), {
+    method: "POST",
+  });
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({}));
+    throw new Error(errorData.error || "Failed to refresh agent");
+  }
+  return response.json();
+}
+
 // Native vnstat data types
 export interface MonitorNativeData {
   vnstatversion: string;
@@ -311,6 +461,37 @@ export async function getMonitorAgentHardwareStats(
   return response.json();
 }
 
+export interface SmartDevice {
+  device: string;
+  type?: string;
+  model?: string;
+  serial?: string;
+  health: "passed" | "prefail" | "failing" | "unknown";
+  reallocated_sectors: number;
+  pending_sectors: number;
+  temperature?: number; // Celsius
+  failing_attributes?: string[];
+  error?: string;
+}
+
+export interface SmartStats {
+  available: boolean; // false when smartctl isn't installed on the agent
+  devices: SmartDevice[];
+  updated_at: string;
+  from_cache?: boolean; // True when data is from database, not live agent
+}
+
+export async function getMonitorAgentSmartStats(
+  id: number,
+): Promise<SmartStats> {
+  const response = await fetch(getApiUrl(`/monitor/agents/${id}/smart`));
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({}));
+    throw new Error(errorData.error || "Failed to fetch SMART stats");
+  }
+  return response.json();
+}
+
 // Peak stats fetching
 export async function getMonitorAgentPeakStats(
   id: number,
@@ -322,3 +503,118 @@ export async function getMonitorAgentPeakStats(
   }
   return response.json();
 }
+
+// Agent-side probes
+export async function getMonitorAgentProbes(
+  id: number,
+): Promise<MonitorAgentProbe[]> {
+  const response = await fetch(getApiUrl(`/monitor/agents/${id}/probes`));
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({}));
+    throw new Error(errorData.error || "Failed to fetch agent probes");
+  }
+  return response.json();
+}
+
+export async function updateMonitorAgentProbes(
+  id: number,
+  probes: MonitorAgentProbe[],
+): Promise<MonitorAgentProbe[]> {
+  const response = await fetch(getApiUrl(`/monitor/agents/${id}/probes`), {
+    method: "PUT",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(probes),
+  });
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({}));
+    throw new Error(errorData.error || "Failed to update agent probes");
+  }
+  return response.json();
+}
+
+export async function getMonitorAgentProbeResults(
+  id: number,
+  host?: string,
+  hours?: number,
+): Promise<MonitorAgentProbeResult[]> {
+  const params = new URLSearchParams();
+  if (host) params.set("host", host);
+  if (hours) params.set("hours", String(hours));
+  const query = params.toString();
+  const response = await fetch(
+    getApiUrl(`/monitor/agents/${id}/probes/results${query ? `?${query}` : ""}`),
+  );
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({}));
+    throw new Error(errorData.error || "Failed to fetch agent probe results");
+  }
+  return response.json();
+}
+
+// Traffic transferred by an agent between start and end
+export async function getMonitorAgentBandwidthUsage(
+  id: number,
+  start?: string,
+  end?: string,
+): Promise<MonitorBandwidthUsage> {
+  const params = new URLSearchParams();
+  if (start) params.set("start", start);
+  if (end) params.set("end", end);
+  const query = params.toString();
+  const response = await fetch(
+    getApiUrl(`/monitor/agents/${id}/usage${query ? `?${query}` : ""}`),
+  );
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({}));
+    throw new Error(errorData.error || "Failed to fetch bandwidth usage");
+  }
+  return response.json();
+}
+
+// Traffic of one agent interface per hour, day or month
+export async function getMonitorAgentInterfaceHistory(
+  id: number,
+  iface: string,
+  period: MonitorInterfaceHistoryPeriod = "hourly",
+  limit?: number,
+): Promise<MonitorInterfaceHistory> {
+  const params = new URLSearchParams({ interface: iface, period });
+  if (limit) params.set("limit", String(limit));
+  const response = await fetch(
+    getApiUrl(`/monitor/agents/${id}/interfaces/history?${params}`),
+  );
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({}));
+    throw new Error(errorData.error || "Failed to fetch interface history");
+  }
+  return response.json();
+}
+
+// Fleet-wide resource stats
+export async function getMonitorFleetResourceStats(
+  start?: string,
+  end?: string,
+): Promise<FleetResourceStats> {
+  const params = new URLSearchParams();
+  if (start) params.set("start", start);
+  if (end) params.set("end", end);
+  const query = params.toString();
+  const response = await fetch(
+    getApiUrl(`/monitor/fleet/resources${query ? `?${query}` : ""}`),
+  );
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({}));
+    throw new Error(errorData.error || "Failed to fetch fleet resource stats");
+  }
+  return response.json();
+}
+
+// Point-in-time snapshot of every agent
+export async function getMonitorSnapshot(): Promise<MonitorSnapshot> {
+  const response = await fetch(getApiUrl("/monitor/snapshot"));
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({}));
+    throw new Error(errorData.error || "Failed to fetch monitor snapshot");
+  }
+  return response.json();
+}
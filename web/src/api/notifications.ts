@@ -38,6 +38,7 @@ export interface NotificationChannel {
   name: string;
   url: string;
   enabled: boolean;
+  payload_template?: string;
   created_at: string;
   updated_at: string;
 }
@@ -61,6 +62,8 @@ export interface NotificationRule {
   enabled: boolean;
   threshold_value?: number;
   threshold_operator?: "gt" | "lt" | "eq" | "gte" | "lte";
+  cooldown_seconds?: number;
+  agent_tag?: string;
   created_at: string;
   updated_at: string;
   channel?: NotificationChannel;
@@ -71,6 +74,8 @@ export interface NotificationChannelInput {
   name: string;
   url: string;
   enabled?: boolean;
+  // Omitted keeps the stored template, "" clears it
+  payload_template?: string;
 }
 
 export interface NotificationRuleInput {
@@ -79,6 +84,8 @@ export interface NotificationRuleInput {
   enabled?: boolean;
   threshold_value?: number;
   threshold_operator?: "gt" | "lt" | "eq" | "gte" | "lte";
+  cooldown_seconds?: number; // 0 clears the override
+  agent_tag?: string; // "" clears the tag
 }
 
 export interface NotificationHistory {
@@ -86,6 +93,7 @@ export interface NotificationHistory {
   channel_id: number;
   event_id: number;
   success: boolean;
+  suppressed: boolean;
   error_message?: string;
   payload?: string;
   created_at: string;
@@ -188,11 +196,9 @@ export const notificationsApi = {
 
   // Test
   testChannel: async (channelId: number): Promise<{ success: boolean; message: string }> => {
-    const response = await fetch(getApiUrl("/notifications/test"), {
+    const response = await fetch(getApiUrl(`/notifications/channels/${channelId}/test`), {
       method: "POST",
-      headers: { "Content-Type": "application/json" },
       credentials: "include",
-      body: JSON.stringify({ channel_id: channelId }),
     });
     await assertOk(response, "Failed to send test notification");
     return response.json();
@@ -8,6 +8,7 @@ import {
   PacketLossMonitor,
   PacketLossResult,
   PacketLossResultDetail,
+  PacketLossSparkline,
   PacketLossUpdate,
   PaginatedResponse,
 } from "@/types/types";
@@ -101,6 +102,19 @@ export const getPacketLossHistoryDetail = async (
   return response.json();
 };
 
+export const getPacketLossSparkline = async (
+  id: number,
+  limit: number = 30,
+): Promise<PacketLossSparkline> => {
+  const response = await fetch(
+    getApiUrl(`/packetloss/monitors/${id}/sparkline?limit=${limit}`),
+  );
+  if (!response.ok) {
+    throw new Error("Failed to fetch monitor sparkline");
+  }
+  return response.json();
+};
+
 export const startPacketLossMonitor = async (id: number): Promise<void> => {
   const response = await fetch(getApiUrl(`/packetloss/monitors/${id}/start`), {
     method: "POST",
@@ -5,6 +5,11 @@
 
 import { getApiUrl } from "@/utils/baseUrl";
 import { SpeedTestOptions } from "@/types/speedtest";
+import {
+  SpeedTestHistoryFilters,
+  SpeedTestRatio,
+  TracerouteAddressFamily,
+} from "@/types/types";
 
 export async function getServers(testType: string) {
   try {
@@ -23,13 +28,21 @@ export async function getServers(testType: string) {
 export async function getHistory(
   timeRange: string,
   page: number,
-  limit: number
+  limit: number,
+  filters: SpeedTestHistoryFilters = {}
 ) {
   try {
+    const params = new URLSearchParams({
+      timeRange,
+      page: String(page),
+      limit: String(limit),
+    });
+    if (filters.provider) params.set("provider", filters.provider);
+    if (filters.server) params.set("server", filters.server);
+    if (filters.since) params.set("since", filters.since);
+
     const response = await fetch(
-      getApiUrl(
-        `/speedtest/history?timeRange=${timeRange}&page=${page}&limit=${limit}`
-      )
+      getApiUrl(`/speedtest/history?${params.toString()}`)
     );
     if (!response.ok) {
       const errorData = await response.json().catch(() => ({}));
@@ -42,6 +55,29 @@ export async function getHistory(
   }
 }
 
+export async function getRatioHistory(
+  testType?: string,
+  threshold?: number
+): Promise<SpeedTestRatio[]> {
+  const params = new URLSearchParams();
+  if (testType) params.set("testType", testType);
+  if (threshold !== undefined) params.set("threshold", String(threshold));
+
+  try {
+    const response = await fetch(
+      getApiUrl(`/speedtest/ratio?${params.toString()}`)
+    );
+    if (!response.ok) {
+      const errorData = await response.json().catch(() => ({}));
+      throw new Error(errorData.message || "Failed to fetch ratio history");
+    }
+    return await response.json();
+  } catch (error) {
+    console.error("Error fetching ratio history:", error);
+    throw error;
+  }
+}
+
 export async function getSchedules() {
   try {
     const response = await fetch(getApiUrl("/schedules"));
@@ -117,10 +153,17 @@ export async function getSpeedTestStatus() {
   }
 }
 
-export async function runTraceroute(host: string) {
+export async function runTraceroute(
+  host: string,
+  family: TracerouteAddressFamily = "auto",
+  resolveNames = false
+) {
   try {
     const response = await fetch(
-      getApiUrl(`/traceroute?host=${encodeURIComponent(host)}`)
+      getApiUrl(
+        `/traceroute?host=${encodeURIComponent(host)}&family=${family}` +
+          (resolveNames ? "&resolveNames=true" : "")
+      )
     );
     if (!response.ok) {
       const errorData = await response.json().catch(() => ({}));
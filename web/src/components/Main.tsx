@@ -365,6 +365,7 @@ export default function Main({ isPublic = false }: MainProps) {
                 currentServer: update.serverName || "",
                 currentTest: update.type,
                 currentSpeed: update.speed || 0,
+                currentUploadSpeed: update.uploadSpeed,
                 isComplete: update.isComplete,
                 type: update.type,
                 speed: update.speed || 0,
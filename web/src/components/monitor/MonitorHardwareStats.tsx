@@ -96,6 +96,26 @@ export const MonitorHardwareStats: React.FC<MonitorHardwareStatsProps> = ({
                 {hardwareStats.cpu.load_avg.map((l) => l.toFixed(2)).join(", ")}
               </p>
             )}
+            {hardwareStats.cpu.per_core &&
+              hardwareStats.cpu.per_core.length > 0 && (
+                <div className="grid grid-cols-8 gap-1 pt-1">
+                  {hardwareStats.cpu.per_core.map((usage, index) => (
+                    <div
+                      key={index}
+                      className="h-6 bg-gray-200 dark:bg-gray-700 rounded-sm flex items-end overflow-hidden"
+                      title={`Core ${index}: ${usage.toFixed(1)}%`}
+                    >
+                      <div
+                        className="w-full transition-all duration-300"
+                        style={{
+                          height: `${usage}%`,
+                          backgroundColor: getProgressColor(usage),
+                        }}
+                      />
+                    </div>
+                  ))}
+                </div>
+              )}
           </div>
         </div>
       )}
@@ -41,6 +41,9 @@ export const ChannelDetails: React.FC<ChannelDetailsProps> = ({
   const [isEditingUrl, setIsEditingUrl] = useState(false);
   const [editedUrl, setEditedUrl] = useState(channel.url);
   const [urlError, setUrlError] = useState("");
+  const [editedTemplate, setEditedTemplate] = useState(
+    channel.payload_template ?? ""
+  );
 
   // Detect service type from URL
   const detectServiceType = (url: string) => {
@@ -91,6 +94,18 @@ export const ChannelDetails: React.FC<ChannelDetailsProps> = ({
   };
 
   const detectedService = detectServiceType(isEditingUrl ? editedUrl : channel.url);
+  const isWebhook = /^https?:\/\//.test(channel.url);
+  const templateChanged = editedTemplate !== (channel.payload_template ?? "");
+
+  const handleSaveTemplate = () => {
+    onUpdate.mutate({
+      id: channel.id,
+      name: channel.name,
+      url: channel.url,
+      enabled: channel.enabled,
+      payload_template: editedTemplate,
+    });
+  };
 
   return (
     <Card>
@@ -217,6 +232,47 @@ export const ChannelDetails: React.FC<ChannelDetailsProps> = ({
           )}
         </div>
 
+        {isWebhook && (
+          <div>
+            <Label>Payload Template</Label>
+            <p className="text-xs text-gray-600 dark:text-gray-400 mt-1 mb-2">
+              Optional Go template for the webhook body. Fields: .Category,
+              .EventType, .Name, .Value, .Threshold, .Message, .Timestamp; use{" "}
+              <code className="font-mono">{"{{json .Name}}"}</code> to quote
+              values. Leave empty to send the default JSON.
+            </p>
+            <textarea
+              value={editedTemplate}
+              onChange={(e) => setEditedTemplate(e.target.value)}
+              rows={5}
+              className="w-full rounded-lg border border-gray-300 dark:border-gray-700 bg-white dark:bg-gray-900 p-3 font-mono text-sm text-gray-800 dark:text-gray-200 focus:outline-none focus:ring-2 focus:ring-blue-500"
+              placeholder={'{"text": {{json .Message}}}'}
+            />
+            {templateChanged && (
+              <div className="flex items-center gap-2 mt-2">
+                <Button
+                  onClick={handleSaveTemplate}
+                  disabled={onUpdate.isPending}
+                  size="sm"
+                >
+                  <CheckIcon className="w-4 h-4" />
+                  Save
+                </Button>
+                <Button
+                  onClick={() =>
+                    setEditedTemplate(channel.payload_template ?? "")
+                  }
+                  variant="secondary"
+                  size="sm"
+                >
+                  <XMarkIcon className="w-4 h-4" />
+                  Cancel
+                </Button>
+              </div>
+            )}
+          </div>
+        )}
+
         <div className="flex items-center justify-between p-4 bg-gray-200/30 dark:bg-gray-800/30 rounded-lg">
           <div>
             <Label>
@@ -4,7 +4,7 @@
  */
 
 import { type ReactNode, useState, useEffect } from "react";
-import { type Schedule, type Server, type SavedIperfServer, type TestType } from "@/types/types";
+import { type Schedule, type Server, type SavedIperfServer, type ServerRotation, type TestType } from "@/types/types";
 import { useQuery, useQueryClient } from "@tanstack/react-query";
 import { getSchedules } from "@/api/speedtest";
 import { showToast } from "@/components/common/Toast";
@@ -72,6 +72,12 @@ const intervalOptions: IntervalOption[] = [
   { value: "7d", label: "Every Week" },
 ];
 
+const rotationOptions: IntervalOption[] = [
+  { value: "none", label: "No Rotation" },
+  { value: "round_robin", label: "Rotate Servers (Round-Robin)" },
+  { value: "random", label: "Rotate Servers (Random)" },
+];
+
 const timeOptions: TimeOption[] = [
   { value: "00:00", label: "12:00 AM" },
   { value: "01:00", label: "1:00 AM" },
@@ -190,6 +196,7 @@ export default function ScheduleManager({ servers, selectedServers, testType }:
     "interval"
   );
   const [exactTimes, setExactTimes] = useState<string[]>(["09:00"]);
+  const [serverRotation, setServerRotation] = useState<string>("none");
   const [enabled] = useState(true);
   const [, setError] = useState<string | null>(null);
   const [updateTrigger, setUpdateTrigger] = useState(0);
@@ -352,6 +359,10 @@ export default function ScheduleManager({ servers, selectedServers, testType }:
         serverHost: isIperfServer ? selectedServers[0].host : undefined,
         serverName: isIperfServer ? selectedServers[0].name : undefined,
         isPublicServer: isLibrespeedServer && (selectedServers[0]?.isPublic ?? false),
+        serverRotation:
+          selectedServers.length > 1 && serverRotation !== "none"
+            ? (serverRotation as ServerRotation)
+            : undefined,
       },
     };
 
@@ -693,6 +704,33 @@ export default function ScheduleManager({ servers, selectedServers, testType }:
                             </div>
                           )}
 
+                          {/* Server Rotation */}
+                          {selectedServers.length > 1 && (
+                            <div className="mt-4">
+                              <Select value={serverRotation} onValueChange={setServerRotation}>
+                                <SelectTrigger className="w-full px-4 py-2 bg-gray-200/50 dark:bg-gray-800/50 border border-gray-300 dark:border-gray-900 rounded-lg text-gray-700 dark:text-gray-300 shadow-md">
+                                  <SelectValue>
+                                    {
+                                      rotationOptions.find(
+                                        (opt) => opt.value === serverRotation
+                                      )?.label
+                                    }
+                                  </SelectValue>
+                                </SelectTrigger>
+                                <SelectContent>
+                                  {rotationOptions.map((option) => (
+                                    <SelectItem
+                                      key={option.value}
+                                      value={option.value}
+                                    >
+                                      {option.label}
+                                    </SelectItem>
+                                  ))}
+                                </SelectContent>
+                              </Select>
+                            </div>
+                          )}
+
                           {/* Next Run Preview */}
                           {(scheduleType === "interval" ||
                               exactTimes.length > 0) && (
@@ -126,15 +126,24 @@ export const TestProgress: React.FC<TestProgressProps> = ({ progress }) => {
     switch (progress.type) {
       case "download":
         return "Download Test";
+      case "bidirectional":
+        return "Bidirectional Test";
       case "upload":
         return "Upload Test";
       case "ping":
         return "Latency Test";
+      case "queued":
+        return "Queued";
       default:
         return progress.currentTest;
     }
   };
 
+  const formatSpeed = (speed: number) =>
+    speed < 1
+      ? `${(speed * 1000).toFixed(0)} Kbps`
+      : `${speed.toFixed(1)} Mbps`;
+
   // Always render a container with consistent height to prevent layout shift
   return (
     <div className="relative h-5 w-full max-w-[16rem] flex items-center justify-center">
@@ -148,6 +157,34 @@ export const TestProgress: React.FC<TestProgressProps> = ({ progress }) => {
             exit={{ opacity: 0 }}
             className="absolute inset-0"
           />
+        ) : content === "testProgress" &&
+          progress?.type === "bidirectional" ? (
+          // Bidirectional iperf3 tests report both directions at once
+          <motion.div
+            key="bidirectional"
+            initial={{ opacity: 0 }}
+            animate={{ opacity: 1 }}
+            exit={{ opacity: 0 }}
+            className="flex items-center justify-center space-x-3 text-xs w-full px-2 whitespace-nowrap"
+          >
+            <div className="flex items-center space-x-1">
+              <ArrowDownIcon className="w-4 h-4 text-blue-500" />
+              <span className="font-bold text-blue-500">
+                {formatSpeed(progress.currentSpeed)}
+              </span>
+            </div>
+            <div className="flex items-center space-x-1">
+              <ArrowUpIcon className="w-4 h-4 text-emerald-500" />
+              <span className="font-bold text-emerald-500">
+                {formatSpeed(progress.currentUploadSpeed ?? 0)}
+              </span>
+            </div>
+            {progress.progress > 0 && (
+              <span className="text-gray-500 dark:text-gray-500">
+                ({Math.round(progress.progress)}%)
+              </span>
+            )}
+          </motion.div>
         ) : content === "testProgress" && progress ? (
           // Show actual test progress
           <motion.div
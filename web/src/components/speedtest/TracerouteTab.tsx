@@ -283,6 +283,7 @@ export const TracerouteTab: React.FC = () => {
     const monitorData = {
       ...data,
       interval,
+      criticalThreshold: data.threshold,
       scheduleType: undefined,
       exactTimes: undefined,
     };
@@ -320,7 +321,13 @@ export const TracerouteTab: React.FC = () => {
       scheduleType,
       exactTimes,
       packetCount: monitor.packetCount,
-      threshold: monitor.threshold,
+      threshold: monitor.criticalThreshold || monitor.threshold,
+      warningThreshold: monitor.warningThreshold ?? 0,
+      probeMode: monitor.probeMode || "icmp",
+      probePort: monitor.probePort,
+      intervalMs: monitor.intervalMs,
+      packetSize: monitor.packetSize,
+      addressFamily: monitor.addressFamily || "auto",
       enabled: monitor.enabled,
     });
     setShowForm(true);
@@ -30,6 +30,8 @@ import { PacketLossMonitor } from "@/types/types";
 import {
   MonitorFormData,
   intervalOptions,
+  probeModeOptions,
+  addressFamilyOptions,
   timeOptions,
 } from "./constants/packetLossConstants";
 import { formatInterval } from "./utils/packetLossUtils";
@@ -59,10 +61,15 @@ export const PacketLossMonitorForm: React.FC<PacketLossMonitorFormProps> = ({
   onFormDataChange,
   isLoading = false,
 }) => {
-  const [errors, setErrors] = useState<{ host?: string; name?: string }>({});
+  const [errors, setErrors] = useState<{
+    host?: string;
+    name?: string;
+    warningThreshold?: string;
+  }>({});
 
   const validateForm = () => {
-    const newErrors: { host?: string; name?: string } = {};
+    const newErrors: { host?: string; name?: string; warningThreshold?: string } =
+      {};
 
     if (!formData.host.trim()) {
       newErrors.host = "Host is required";
@@ -72,6 +79,14 @@ export const PacketLossMonitorForm: React.FC<PacketLossMonitorFormProps> = ({
       newErrors.name = "Name is required";
     }
 
+    if (
+      formData.warningThreshold > 0 &&
+      formData.warningThreshold >= formData.threshold
+    ) {
+      newErrors.warningThreshold =
+        "Warning threshold must be below the critical threshold";
+    }
+
     setErrors(newErrors);
     return Object.keys(newErrors).length === 0;
   };
@@ -355,20 +370,156 @@ export const PacketLossMonitorForm: React.FC<PacketLossMonitorFormProps> = ({
                       />
                     </div>
                     <div>
-                      <Label>Alert Threshold (% packet loss)</Label>
-                      <Input
-                        type="number"
-                        value={formData.threshold}
-                        onChange={(e) =>
+                      <Label>Probe Mode</Label>
+                      <Select
+                        value={formData.probeMode}
+                        onValueChange={(value) =>
                           onFormDataChange({
                             ...formData,
-                            threshold: parseFloat(e.target.value) || 5.0,
+                            probeMode: value as MonitorFormData["probeMode"],
                           })
                         }
-                        min="0"
-                        max="100"
-                        step="0.1"
-                      />
+                      >
+                        <SelectTrigger className="w-full bg-gray-200/50 dark:bg-gray-800/50 border-gray-300 dark:border-gray-900">
+                          <SelectValue>
+                            {probeModeOptions.find(
+                              (opt) => opt.value === formData.probeMode
+                            )?.label}
+                          </SelectValue>
+                        </SelectTrigger>
+                        <SelectContent>
+                          {probeModeOptions.map((option) => (
+                            <SelectItem key={option.value} value={option.value}>
+                              {option.label}
+                            </SelectItem>
+                          ))}
+                        </SelectContent>
+                      </Select>
+                      <p className="mt-1 text-xs text-gray-500 dark:text-gray-400">
+                        Use TCP for hosts that block ICMP
+                      </p>
+                    </div>
+                    <div>
+                      <Label>Address Family</Label>
+                      <Select
+                        value={formData.addressFamily}
+                        onValueChange={(value) =>
+                          onFormDataChange({
+                            ...formData,
+                            addressFamily:
+                              value as MonitorFormData["addressFamily"],
+                          })
+                        }
+                      >
+                        <SelectTrigger className="w-full bg-gray-200/50 dark:bg-gray-800/50 border-gray-300 dark:border-gray-900">
+                          <SelectValue>
+                            {addressFamilyOptions.find(
+                              (opt) => opt.value === formData.addressFamily
+                            )?.label}
+                          </SelectValue>
+                        </SelectTrigger>
+                        <SelectContent>
+                          {addressFamilyOptions.map((option) => (
+                            <SelectItem key={option.value} value={option.value}>
+                              {option.label}
+                            </SelectItem>
+                          ))}
+                        </SelectContent>
+                      </Select>
+                    </div>
+                    {formData.probeMode === "tcp" && (
+                      <div>
+                        <Label>TCP Port</Label>
+                        <Input
+                          type="number"
+                          value={formData.probePort ?? ""}
+                          onChange={(e) =>
+                            onFormDataChange({
+                              ...formData,
+                              probePort: parseInt(e.target.value) || undefined,
+                            })
+                          }
+                          placeholder="443"
+                          min="1"
+                          max="65535"
+                        />
+                      </div>
+                    )}
+                    {formData.probeMode !== "tcp" && (
+                      <div className="grid grid-cols-2 gap-3">
+                        <div>
+                          <Label>Packet Interval (ms)</Label>
+                          <Input
+                            type="number"
+                            value={formData.intervalMs ?? ""}
+                            onChange={(e) =>
+                              onFormDataChange({
+                                ...formData,
+                                intervalMs: parseInt(e.target.value) || undefined,
+                              })
+                            }
+                            placeholder="1000"
+                            min="100"
+                            max="10000"
+                          />
+                        </div>
+                        <div>
+                          <Label>Packet Size (bytes)</Label>
+                          <Input
+                            type="number"
+                            value={formData.packetSize ?? ""}
+                            onChange={(e) =>
+                              onFormDataChange({
+                                ...formData,
+                                packetSize: parseInt(e.target.value) || undefined,
+                              })
+                            }
+                            placeholder="24"
+                            min="24"
+                            max="8972"
+                          />
+                        </div>
+                      </div>
+                    )}
+                    <div className="grid grid-cols-2 gap-4">
+                      <div>
+                        <Label>Warning Threshold (% packet loss)</Label>
+                        <Input
+                          type="number"
+                          value={formData.warningThreshold || ""}
+                          onChange={(e) =>
+                            onFormDataChange({
+                              ...formData,
+                              warningThreshold: parseFloat(e.target.value) || 0,
+                            })
+                          }
+                          placeholder="Off"
+                          min="0"
+                          max="100"
+                          step="0.1"
+                        />
+                        {errors.warningThreshold && (
+                          <p className="text-xs text-red-600 dark:text-red-400 mt-1">
+                            {errors.warningThreshold}
+                          </p>
+                        )}
+                      </div>
+                      <div>
+                        <Label>Critical Threshold (% packet loss)</Label>
+                        <Input
+                          type="number"
+                          value={formData.threshold}
+                          onChange={(e) =>
+                            onFormDataChange({
+                              ...formData,
+                              threshold: parseFloat(e.target.value) || 5.0,
+                            })
+                          }
+                          min="0"
+                          max="100"
+                          step="0.1"
+                        />
+                      </div>
                     </div>
                     <div className="flex items-center space-x-2">
                       <Checkbox
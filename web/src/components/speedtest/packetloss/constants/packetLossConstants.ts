@@ -3,6 +3,8 @@
  * SPDX-License-Identifier: GPL-2.0-or-later
  */
 
+import { PacketLossAddressFamily, PacketLossProbeMode } from "@/types/types";
+
 export interface IntervalOption {
   value: string; // Changed from number to string
   label: string;
@@ -62,7 +64,13 @@ export interface MonitorFormData {
   scheduleType?: "interval" | "exact"; // New field
   exactTimes?: string[]; // New field for exact times
   packetCount: number;
-  threshold: number;
+  threshold: number; // critical threshold
+  warningThreshold: number; // 0 disables the warning level
+  probeMode: PacketLossProbeMode;
+  probePort?: number;
+  intervalMs?: number;
+  packetSize?: number;
+  addressFamily: PacketLossAddressFamily;
   enabled: boolean;
 }
 
@@ -74,5 +82,23 @@ export const defaultFormData: MonitorFormData = {
   exactTimes: [],
   packetCount: 10,
   threshold: 5.0,
+  warningThreshold: 0,
+  probeMode: "icmp",
+  addressFamily: "auto",
   enabled: true,
 };
+
+export const probeModeOptions: { value: PacketLossProbeMode; label: string }[] = [
+  { value: "icmp", label: "ICMP (MTR or ping)" },
+  { value: "udp", label: "UDP (MTR)" },
+  { value: "tcp", label: "TCP connect" },
+];
+
+export const addressFamilyOptions: {
+  value: PacketLossAddressFamily;
+  label: string;
+}[] = [
+  { value: "auto", label: "Auto" },
+  { value: "ipv4", label: "IPv4 only" },
+  { value: "ipv6", label: "IPv6 only" },
+];
@@ -11,7 +11,10 @@ export interface MonitorStatus {
   avgRtt?: number;
   minRtt?: number;
   maxRtt?: number;
+  jitter?: number;
   packetsSent?: number;
   packetsRecv?: number;
   usedMtr?: boolean;
+  privilegedMode?: boolean;
+  completedAt?: string;
 }
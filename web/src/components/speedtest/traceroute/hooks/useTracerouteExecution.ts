@@ -22,7 +22,7 @@ export const useTracerouteExecution = ({
   const queryClient = useQueryClient();
 
   const tracerouteMutation = useMutation({
-    mutationFn: runTraceroute,
+    mutationFn: (targetHost: string) => runTraceroute(targetHost),
     onMutate: (targetHost: string) => {
       // Clear previous results and error state
       queryClient.setQueryData(["traceroute", "results"], null);
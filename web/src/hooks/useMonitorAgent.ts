@@ -10,7 +10,7 @@ import {
   SystemInfo,
   PeakStats,
   HardwareStats,
-  getMonitorAgentStatus,
+  getMonitorAgentStatuses,
   getMonitorAgentNative,
   getMonitorAgentSystemInfo,
   getMonitorAgentHardwareStats,
@@ -38,10 +38,15 @@ export const useMonitorAgent = ({
 }: UseMonitorAgentOptions) => {
   const queryClient = useQueryClient();
 
-  // Fetch agent status with polling
-  const statusQuery = useQuery<MonitorStatus>({
-    queryKey: ["monitor-agent-status", agent.id],
-    queryFn: () => getMonitorAgentStatus(agent.id),
+  // Poll the status of all agents with one shared request, agents not being monitored are left out
+  const statusQuery = useQuery<
+    Record<number, MonitorStatus>,
+    Error,
+    MonitorStatus
+  >({
+    queryKey: ["monitor-agent-statuses"],
+    queryFn: getMonitorAgentStatuses,
+    select: (statuses) => statuses[agent.id] ?? { connected: false },
     refetchInterval: agent.enabled ? MONITOR_REFRESH_INTERVALS.STATUS : false,
     staleTime: MONITOR_REFRESH_INTERVALS.STATUS / 2, // Consider data fresh for half the refetch interval
     enabled: agent.enabled,
@@ -99,7 +104,7 @@ export const useMonitorAgent = ({
     onSuccess: () => {
       queryClient.invalidateQueries({ queryKey: ["monitor-agents"] });
       queryClient.invalidateQueries({
-        queryKey: ["monitor-agent-status", agent.id],
+        queryKey: ["monitor-agent-statuses"],
       });
       showToast("Agent started", "success", {
         description: `${agent.name} is now active`,
@@ -118,7 +123,7 @@ export const useMonitorAgent = ({
     onSuccess: () => {
       queryClient.invalidateQueries({ queryKey: ["monitor-agents"] });
       queryClient.invalidateQueries({
-        queryKey: ["monitor-agent-status", agent.id],
+        queryKey: ["monitor-agent-statuses"],
       });
       showToast("Agent stopped", "success", {
         description: `${agent.name} has been stopped`,
@@ -9,6 +9,7 @@ export interface SpeedTestOptions {
   enableUpload?: boolean;
   useIperf?: boolean;
   useLibrespeed?: boolean;
+  useOokla?: boolean;
   serverHost?: string;
   serverName?: string;
 }
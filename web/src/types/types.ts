@@ -28,6 +28,28 @@ export interface SpeedTestResult {
   uploadSpeed: number;
   latency: string;
   jitter?: number;
+  ttfb?: number;
+  downUpRatio?: number;
+  pathMtu?: number;
+  mtuWarning?: string;
+  datagramLoss?: number;
+  attempts?: number; // more than 1 when failed scheduled runs were retried
+  createdAt: string;
+  loadRxBytesPerSecond?: number;
+  loadTxBytesPerSecond?: number;
+  loadCpuPercent?: number;
+}
+
+export interface SpeedTestRatio {
+  id: number;
+  serverName: string;
+  testType: "speedtest" | "iperf3" | "librespeed";
+  downloadSpeed: number;
+  uploadSpeed: number;
+  downUpRatio: number;
+  baselineRatio?: number;
+  changePercent?: number;
+  shift: boolean;
   createdAt: string;
 }
 
@@ -35,6 +57,7 @@ export interface TestProgress {
   currentServer: string;
   currentTest: string;
   currentSpeed: number;
+  currentUploadSpeed?: number; // Set while a bidirectional iperf3 test runs
   isComplete: boolean;
   type: string;
   speed: number;
@@ -45,21 +68,27 @@ export interface TestProgress {
   isLibrespeed: boolean;
 }
 
+export type ServerRotation = "round_robin" | "random";
+
 export interface Schedule {
   id?: number;
   serverIds: string[];
   interval: string;
   nextRun?: string;
   enabled: boolean;
+  rotationIndex?: number;
+  lastServerId?: string;
   options: {
     enableDownload: boolean;
     enableUpload: boolean;
     serverIds: string[];
     useIperf: boolean;
     useLibrespeed?: boolean;
+    useOokla?: boolean;
     serverHost: string | undefined;
     serverName?: string | undefined;
     isPublicServer?: boolean;
+    serverRotation?: ServerRotation;
   };
 }
 
@@ -70,10 +99,12 @@ export interface TestOptions {
   multiServer: boolean;
   useIperf: boolean;
   useLibrespeed?: boolean;
+  useOokla?: boolean;
   serverIds?: string[];
   serverHost?: string;
   serverName?: string;
   isPublicServer?: boolean;
+  enableMtuProbe?: boolean;
 }
 
 export type TimeRange = "1d" | "3d" | "1w" | "1m" | "all";
@@ -82,8 +113,15 @@ export type TestType = "speedtest" | "iperf" | "librespeed";
 
 export interface SpeedUpdate {
   isComplete: boolean;
-  type: "download" | "upload" | "ping" | "complete";
+  type:
+    | "download"
+    | "upload"
+    | "bidirectional"
+    | "ping"
+    | "complete"
+    | "queued"; // "queued" while waiting for speedtest.max_concurrent
   speed: number;
+  uploadSpeed?: number; // Upload speed of bidirectional updates, speed is the download
   progress: number;
   serverName: string;
   latency?: string;
@@ -96,6 +134,14 @@ export interface PaginatedResponse<T> {
   page: number;
   limit: number;
   total?: number;
+  filters?: SpeedTestHistoryFilters;
+}
+
+// Filters for the speed test history, echoed back in the response
+export interface SpeedTestHistoryFilters {
+  provider?: string; // "speedtest", "iperf3", "librespeed"
+  server?: string; // Server name, ID or host
+  since?: string; // RFC3339
 }
 
 export interface SavedIperfServer {
@@ -120,12 +166,16 @@ export interface TracerouteHop {
   countryCode?: string;
 }
 
+export type TracerouteAddressFamily = "auto" | "ipv4" | "ipv6";
+
 export interface TracerouteResult {
   destination: string;
   ip: string;
   hops: TracerouteHop[];
   totalHops: number;
   complete: boolean;
+  resolvedIps?: string[];
+  paths?: TracerouteResult[];
 }
 
 export interface TracerouteUpdate {
@@ -142,6 +192,10 @@ export interface TracerouteUpdate {
   terminatedEarly?: boolean;
 }
 
+export type PacketLossProbeMode = "icmp" | "udp" | "tcp";
+
+export type PacketLossAddressFamily = "auto" | "ipv4" | "ipv6";
+
 export interface PacketLossMonitor {
   id: number;
   host: string;
@@ -149,7 +203,14 @@ export interface PacketLossMonitor {
   interval: string; // Changed from number to string
   packetCount: number;
   enabled: boolean;
-  threshold: number;
+  threshold: number; // kept equal to criticalThreshold
+  warningThreshold?: number; // 0 disables the warning level
+  criticalThreshold?: number;
+  probeMode?: PacketLossProbeMode;
+  probePort?: number; // tcp only, defaults to 443
+  intervalMs?: number; // between ping/MTR packets, defaults to 1000
+  packetSize?: number; // ICMP payload bytes, defaults to 24
+  addressFamily?: PacketLossAddressFamily;
   lastRun?: string; // New field
   nextRun?: string; // New field
   createdAt: string;
@@ -164,14 +225,30 @@ export interface PacketLossResult {
   maxRtt: number;
   avgRtt: number;
   stdDevRtt: number;
+  jitter: number;
   packetsSent: number;
   packetsRecv: number;
   usedMtr?: boolean;
   hopCount?: number;
   privilegedMode?: boolean;
+  probedIp?: string; // address that was actually probed
+  createdAt: string;
+}
+
+export interface PacketLossSparklinePoint {
+  resultId: number;
+  packetLoss: number;
+  avgRtt: number;
+  jitter: number;
+  usedMtr: boolean; // measured by MTR rather than ping
   createdAt: string;
 }
 
+export interface PacketLossSparkline {
+  monitorId: number;
+  points: PacketLossSparklinePoint[]; // oldest first
+}
+
 export interface PacketLossResultDetail extends PacketLossResult {
   mtrData?: string;
 }
@@ -212,9 +289,12 @@ export interface PacketLossUpdate {
   maxRtt?: number;
   avgRtt?: number;
   stdDevRtt?: number;
+  jitter?: number;
   packetsSent?: number;
   packetsRecv?: number;
   usedMtr?: boolean;
   hopCount?: number;
+  privilegedMode?: boolean;
   error?: string;
+  completedAt?: string;
 }